@@ -30,6 +30,9 @@ var (
 	globalConfig  *config.Config
 	routerMutex   sync.RWMutex
 	isInstallMode bool
+
+	// 停止监听配置文件
+	stopConfigWatcher func()
 )
 
 func main() {
@@ -41,7 +44,7 @@ func main() {
 	
 	// 检查是否已安装（配置文件都在data目录中）
 	installLockPath := "./data/install.lock"
-	configPath := "./data/config.yaml"
+	configPath := config.DefaultConfigPath
 	
 	// 如果没有安装锁文件或配置文件，进入安装模式
 	if !fileExists(installLockPath) || !fileExists(configPath) {
@@ -67,10 +70,10 @@ func runUnifiedMode(installMode bool) {
 		fmt.Println("\n===========================================")
 		fmt.Println("  Huoxing-Go 安装向导已启动")
 		fmt.Println("  请在浏览器中访问: http://localhost:6060/install")
-		fmt.Println("===========================================\n")
+		fmt.Print("===========================================\n\n")
 	} else {
 		// 正常模式：加载完整配置（从data目录）
-		cfg, err = config.LoadConfig("./data/config.yaml")
+		cfg, err = config.LoadConfig(config.DefaultConfigPath)
 		if err != nil {
 			fmt.Printf("加载配置失败: %v\n", err)
 			os.Exit(1)
@@ -98,9 +101,18 @@ func runUnifiedMode(installMode bool) {
 		defer database.Close()
 		logger.Info("MySQL连接成功")
 
+		// 升级已安装数据库的表结构（补齐新版本增加的表和字段）
+		applied, err := database.Migrate(database.GetDB())
+		if err != nil {
+			logger.Fatal("升级数据库表结构失败", zap.Error(err))
+		}
+		for _, name := range applied {
+			logger.Info("数据库已升级", zap.String("migration", name))
+		}
+
 		// 初始化Redis（可选）
 		if err := redis.InitRedis(&cfg.Redis); err != nil {
-			logger.Warn("Redis连接失败，缓存功能将不可用", zap.Error(err))
+			logger.Warn("Redis连接失败，缓存将使用进程内存", zap.Error(err))
 		} else {
 			defer redis.Close()
 			logger.Info("Redis连接成功")
@@ -114,6 +126,27 @@ func runUnifiedMode(installMode bool) {
 		
 		// 启动临时资源清理任务（每天凌晨3点执行）
 		go startCleanupTask(cfg)
+
+		// 构建资源全文索引
+		go buildSourceIndex()
+
+		// 启动网盘账号健康检查
+		go startNetdiskHealthCheck(cfg)
+
+		// 启动异步转存任务服务
+		service.StartTransferJobService(cfg)
+
+		// 启动链接有效性检测服务（含本地资源定时复检）
+		service.StartLinkCheckService(cfg)
+
+		// 启动资源自动分类服务
+		service.StartCategoryService()
+
+		// 启动开放接口密钥服务（按密钥限流和统计用量）
+		service.StartAPIKeyService()
+
+		// 监听配置文件变更并热更新
+		startConfigWatcher()
 	}
 
 	// 保存全局配置
@@ -180,6 +213,17 @@ func runUnifiedMode(installMode bool) {
 		}
 	}
 
+	// 停止异步转存任务服务（执行中的任务会在下次启动时重新排队）
+	service.StopTransferJobService()
+
+	// 停止开放接口密钥服务（写入未落库的用量）
+	service.StopAPIKeyService()
+
+	// 停止监听配置文件
+	if stopConfigWatcher != nil {
+		stopConfigWatcher()
+	}
+
 	if installMode {
 		fmt.Println("服务器已关闭")
 	} else {
@@ -238,13 +282,13 @@ func createRouter(installMode bool, cfg *config.Config) *gin.Engine {
 func switchToNormalMode() error {
 	fmt.Println("\n===========================================")
 	fmt.Println("  安装完成，正在切换到正常模式...")
-	fmt.Println("===========================================\n")
+	fmt.Print("===========================================\n\n")
 
 	// 等待一下，让安装请求完成
 	time.Sleep(500 * time.Millisecond)
 
 	// 加载配置（从data目录）
-	cfg, err := config.LoadConfig("./data/config.yaml")
+	cfg, err := config.LoadConfig(config.DefaultConfigPath)
 	if err != nil {
 		return fmt.Errorf("加载配置失败: %v", err)
 	}
@@ -270,7 +314,7 @@ func switchToNormalMode() error {
 
 	// 初始化Redis（可选）
 	if err := redis.InitRedis(&cfg.Redis); err != nil {
-		logger.Warn("Redis连接失败，缓存功能将不可用", zap.Error(err))
+		logger.Warn("Redis连接失败，缓存将使用进程内存", zap.Error(err))
 	} else {
 		logger.Info("Redis连接成功")
 	}
@@ -281,6 +325,27 @@ func switchToNormalMode() error {
 	}
 	logger.Info("Pansou搜索引擎初始化成功")
 
+	// 构建资源全文索引
+	go buildSourceIndex()
+
+	// 启动网盘账号健康检查
+	go startNetdiskHealthCheck(cfg)
+
+	// 启动异步转存任务服务
+	service.StartTransferJobService(cfg)
+
+	// 启动链接有效性检测服务（含本地资源定时复检）
+	service.StartLinkCheckService(cfg)
+
+	// 启动资源自动分类服务
+	service.StartCategoryService()
+
+	// 启动开放接口密钥服务（按密钥限流和统计用量）
+	service.StartAPIKeyService()
+
+	// 监听配置文件变更并热更新
+	startConfigWatcher()
+
 	// 创建新路由
 	newRouter := api.SetupRouter(cfg)
 
@@ -295,11 +360,34 @@ func switchToNormalMode() error {
 	fmt.Println("\n===========================================")
 	fmt.Println("  ✅ 系统初始化完成！")
 	fmt.Println("  现在可以访问管理后台: http://localhost:6060/admin/login")
-	fmt.Println("===========================================\n")
+	fmt.Print("===========================================\n\n")
 
 	return nil
 }
 
+// startConfigWatcher 监听配置文件变更，日志级别、限流、转存和Pansou引擎配置修改后无需重启
+func startConfigWatcher() {
+	config.Subscribe("logger", func(ev config.ChangeEvent) error {
+		if ev.Source == config.ChangeSourceFile && ev.Config != nil {
+			logger.SetLevel(ev.Config.Log.Level)
+		}
+		return nil
+	})
+
+	stop, err := config.WatchFile(config.DefaultConfigPath, func(status config.ReloadStatus) {
+		if !status.Success {
+			logger.Warn("⚠️ 配置文件重新加载失败，继续使用原配置", zap.Strings("errors", status.Errors))
+			return
+		}
+		logger.Info("🔄 配置文件已重新加载", zap.Strings("restart_required", status.RestartRequired))
+	})
+	if err != nil {
+		logger.Warn("监听配置文件失败，修改配置文件后需重启生效", zap.Error(err))
+		return
+	}
+	stopConfigWatcher = stop
+}
+
 // fileExists 检查文件是否存在
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -318,4 +406,21 @@ func startCleanupTask(cfg *config.Config) {
 	
 	// 每24小时执行一次清理
 	cleanupService.StartScheduledCleanup(ctx, 24*time.Hour)
-}
\ No newline at end of file
+}
+
+// startNetdiskHealthCheck 启动网盘账号健康检查（每30分钟检查一次，异常账号自动停用，恢复后自动启用）
+func startNetdiskHealthCheck(cfg *config.Config) {
+	accountService := service.NewNetdiskAccountService(netdisk.NewNetdiskManager(cfg))
+	accountService.StartHealthCheck(context.Background(), 30*time.Minute)
+}
+
+// buildSourceIndex 启动时构建资源全文索引（构建完成前搜索回退到数据库LIKE查询）
+func buildSourceIndex() {
+	start := time.Now()
+	count, err := repository.RebuildSourceIndex(context.Background())
+	if err != nil {
+		logger.Error("构建资源索引失败", zap.Error(err))
+		return
+	}
+	logger.Info("资源索引构建完成", zap.Int("documents", count), zap.Duration("elapsed", time.Since(start)))
+}
@@ -10,7 +10,14 @@ CREATE TABLE IF NOT EXISTS `qf_admin` (
   `phone` varchar(20) DEFAULT NULL COMMENT '手机号',
   `avatar` varchar(255) DEFAULT NULL COMMENT '头像',
   `status` tinyint(4) DEFAULT '1' COMMENT '状态:0禁用,1启用',
+  `role` tinyint(4) NOT NULL DEFAULT '0' COMMENT '角色:0只读审计,1内容编辑,2超级管理员',
   `last_login_time` bigint(20) DEFAULT NULL COMMENT '最后登录时间',
+  `last_login_ip` varchar(50) DEFAULT NULL COMMENT '最后登录IP',
+  `login_fail_count` int(11) NOT NULL DEFAULT '0' COMMENT '连续登录失败次数',
+  `locked_until` bigint(20) NOT NULL DEFAULT '0' COMMENT '登录锁定截止时间,0未锁定',
+  `totp_secret` varchar(64) DEFAULT NULL COMMENT '两步验证密钥',
+  `totp_enabled` tinyint(4) NOT NULL DEFAULT '0' COMMENT '两步验证:0未启用,1已启用',
+  `recovery_codes` text COMMENT '两步验证恢复码哈希(JSON)',
   `create_time` bigint(20) NOT NULL COMMENT '创建时间',
   `update_time` bigint(20) NOT NULL COMMENT '更新时间',
   PRIMARY KEY (`admin_id`),
@@ -24,7 +31,7 @@ CREATE TABLE IF NOT EXISTS `qf_source` (
   `url` varchar(500) NOT NULL COMMENT '分享链接',
   `content` varchar(500) DEFAULT NULL COMMENT '原始链接',
   `password` varchar(50) DEFAULT NULL COMMENT '提取码',
-  `is_type` tinyint(4) DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷',
+  `is_type` tinyint(4) DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
   `fid` varchar(500) DEFAULT NULL COMMENT '文件ID',
   `size` bigint(20) DEFAULT NULL COMMENT '文件大小',
   `source_name` varchar(100) DEFAULT NULL COMMENT '原始来源名称',
@@ -65,7 +72,7 @@ CREATE TABLE IF NOT EXISTS `qf_api_list` (
   `id` int(10) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
   `name` varchar(100) NOT NULL COMMENT '线路名称',
   `type` varchar(20) NOT NULL DEFAULT 'api' COMMENT '接口类型:api,html,tg',
-  `pantype` tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷',
+  `pantype` tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
   `url` varchar(255) DEFAULT NULL COMMENT '请求地址',
   `method` varchar(10) DEFAULT 'GET' COMMENT '请求方式',
   `fixed_params` text COMMENT '固定参数(JSON)',
@@ -74,8 +81,9 @@ CREATE TABLE IF NOT EXISTS `qf_api_list` (
   `html_item` varchar(255) DEFAULT NULL COMMENT 'HTML列表项选择器',
   `html_title` varchar(255) DEFAULT NULL COMMENT 'HTML标题选择器',
   `html_url` varchar(255) DEFAULT NULL COMMENT 'HTML链接选择器',
-  `html_type` tinyint(4) DEFAULT '0' COMMENT 'HTML类型',
-  `html_url2` varchar(255) DEFAULT NULL COMMENT 'HTML备用链接选择器',
+  `html_type` tinyint(4) DEFAULT '0' COMMENT 'HTML类型 0=列表页含网盘链接 1=详情页模式',
+  `html_url2` varchar(255) DEFAULT NULL COMMENT 'HTML详情页网盘链接选择器',
+  `count` int(11) DEFAULT '0' COMMENT '命中次数',
   `weight` int(11) DEFAULT '0' COMMENT '权重',
   `status` tinyint(1) DEFAULT '1' COMMENT '状态:0禁用,1启用',
   `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
@@ -101,6 +109,112 @@ CREATE TABLE IF NOT EXISTS `qf_conf` (
   UNIQUE KEY `uk_name` (`name`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统配置表';
 
+-- 转存任务表
+CREATE TABLE IF NOT EXISTS `qf_transfer_job` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `job_no` varchar(32) NOT NULL COMMENT '任务编号',
+  `status` varchar(20) NOT NULL DEFAULT 'queued' COMMENT '状态:queued,running,succeeded,failed,cancelled',
+  `pan_type` tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
+  `source` varchar(20) DEFAULT NULL COMMENT '任务来源:web,wechat,api',
+  `keyword` varchar(255) DEFAULT NULL COMMENT '搜索关键词',
+  `owner` varchar(64) DEFAULT NULL COMMENT '创建者:key:<密钥ID>或session:<页面会话哈希>',
+  `request` mediumtext COMMENT '转存请求(JSON)',
+  `result` mediumtext COMMENT '转存结果(JSON)',
+  `error` varchar(500) DEFAULT NULL COMMENT '最近一次错误',
+  `attempts` int(11) NOT NULL DEFAULT '0' COMMENT '已尝试次数',
+  `max_attempts` int(11) NOT NULL DEFAULT '3' COMMENT '最大尝试次数',
+  `next_run_at` bigint(20) NOT NULL DEFAULT '0' COMMENT '下次执行时间',
+  `started_at` bigint(20) NOT NULL DEFAULT '0' COMMENT '开始时间',
+  `finished_at` bigint(20) NOT NULL DEFAULT '0' COMMENT '结束时间',
+  `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `update_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_job_no` (`job_no`),
+  KEY `idx_status_next_run` (`status`, `next_run_at`),
+  KEY `idx_keyword_status` (`keyword`(64), `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='转存任务表';
+
+-- 网盘账号表
+CREATE TABLE IF NOT EXISTS `qf_netdisk_account` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `pan_type` tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
+  `name` varchar(100) NOT NULL COMMENT '账号名称',
+  `credential` text COMMENT 'Cookie或RefreshToken',
+  `save_dir` varchar(255) DEFAULT NULL COMMENT '默认存储目录,留空使用网盘配置',
+  `save_dir_time` varchar(255) DEFAULT NULL COMMENT '临时存储目录,留空使用网盘配置',
+  `status` tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用,2异常停用',
+  `use_count` bigint(20) NOT NULL DEFAULT '0' COMMENT '成功转存次数',
+  `fail_count` int(11) NOT NULL DEFAULT '0' COMMENT '连续失败次数',
+  `total_space` bigint(20) NOT NULL DEFAULT '0' COMMENT '总容量(字节)',
+  `used_space` bigint(20) NOT NULL DEFAULT '0' COMMENT '已用容量(字节)',
+  `last_error` varchar(500) DEFAULT NULL COMMENT '最近一次错误',
+  `last_used_at` bigint(20) NOT NULL DEFAULT '0' COMMENT '最近使用时间',
+  `last_check_at` bigint(20) NOT NULL DEFAULT '0' COMMENT '最近检查时间',
+  `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `update_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_pan_type_status` (`pan_type`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='网盘账号表';
+
+-- 开放接口密钥表
+CREATE TABLE IF NOT EXISTS `qf_api_key` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `name` varchar(100) NOT NULL COMMENT '名称',
+  `key_prefix` varchar(16) DEFAULT NULL COMMENT '密钥前缀',
+  `key_hash` char(64) NOT NULL COMMENT '密钥SHA-256',
+  `rate_limit` int(11) NOT NULL DEFAULT '0' COMMENT '每分钟最大请求数,0不限制',
+  `daily_transfer_quota` int(11) NOT NULL DEFAULT '0' COMMENT '每日最大转存成功数,0不限制',
+  `allow_transfer` tinyint(1) NOT NULL DEFAULT '1' COMMENT '允许自动转存:0否,1是',
+  `status` tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+  `expire_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '过期时间,0永不过期',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+  `total_requests` bigint(20) NOT NULL DEFAULT '0' COMMENT '累计请求数',
+  `total_transfers` bigint(20) NOT NULL DEFAULT '0' COMMENT '累计转存成功数',
+  `last_used_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '最近使用时间',
+  `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `update_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_key_hash` (`key_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='开放接口密钥表';
+
+-- 开放接口密钥每日用量表
+CREATE TABLE IF NOT EXISTS `qf_api_key_usage` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `key_id` int(11) unsigned NOT NULL COMMENT '密钥ID',
+  `date` char(10) NOT NULL COMMENT '日期YYYY-MM-DD',
+  `requests` bigint(20) NOT NULL DEFAULT '0' COMMENT '请求数',
+  `transfers` bigint(20) NOT NULL DEFAULT '0' COMMENT '转存成功数',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_key_date` (`key_id`, `date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='开放接口密钥每日用量表';
+
+-- 插件设置表
+CREATE TABLE IF NOT EXISTS `qf_plugin_setting` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `name` varchar(50) NOT NULL COMMENT '插件名',
+  `status` tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+  `priority` tinyint(1) NOT NULL DEFAULT '0' COMMENT '优先级覆盖:1-4,0使用插件默认优先级',
+  `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `update_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='插件设置表';
+
+-- TG频道表
+CREATE TABLE IF NOT EXISTS `qf_tg_channel` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+  `name` varchar(100) NOT NULL COMMENT '频道用户名',
+  `status` tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+  `weight` int(11) NOT NULL DEFAULT '0' COMMENT '权重,越大越先搜索',
+  `tags` varchar(255) DEFAULT NULL COMMENT '分组标签,多个用逗号分隔',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+  `create_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+  `update_time` bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`),
+  KEY `idx_status_weight` (`status`, `weight`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='TG频道表';
+
 -- 操作日志表
 CREATE TABLE IF NOT EXISTS `qf_log` (
   `log_id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
@@ -149,6 +263,46 @@ INSERT INTO `qf_conf` (`name`, `value`, `title`, `description`, `group`, `type`,
 ('pansou_url', 'http://localhost:8888', 'Pansou服务地址', 'Pansou搜索引擎的API地址', 1, 1, 14, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 ('pansou_timeout', '30', 'Pansou超时时间', 'Pansou API调用超时时间(秒)', 1, 2, 15, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 
+-- Pansou引擎配置（优先级：环境变量 > 本表 > config.yaml的pansou.engine > 默认值，修改后重启生效）
+('pansou_channels', '', 'TG搜索频道', 'Pansou引擎CHANNELS：搜索的Telegram频道，用逗号分隔（同名环境变量优先）', 1, 1, 100, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_concurrency', '', '搜索并发数', 'Pansou引擎CONCURRENCY：单次搜索的最大并发数，留空按频道数+插件数+10自动计算（同名环境变量优先）', 1, 2, 101, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_plugin_count', '', '预估插件数', 'Pansou引擎PLUGIN_COUNT：自动计算并发数时使用的插件数，插件加载后按实际数量调整（同名环境变量优先）', 1, 2, 102, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_proxy', '', '代理地址', 'Pansou引擎PROXY：访问Telegram和插件站点使用的代理，如 socks5://127.0.0.1:1080（同名环境变量优先）', 1, 1, 103, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_cache_enabled', '', '启用引擎缓存', 'Pansou引擎CACHE_ENABLED：是否缓存插件和频道的搜索结果：true/false（同名环境变量优先）', 1, 1, 104, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_cache_path', '', '缓存目录', 'Pansou引擎CACHE_PATH：磁盘缓存目录，默认为运行目录下的cache（同名环境变量优先）', 1, 1, 105, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_cache_max_size', '', '缓存大小上限', 'Pansou引擎CACHE_MAX_SIZE：磁盘缓存最大占用(MB)（同名环境变量优先）', 1, 2, 106, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_cache_ttl', '', '缓存有效期', 'Pansou引擎CACHE_TTL：搜索结果缓存有效期(分钟)（同名环境变量优先）', 1, 2, 107, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_cache_serializer', '', '缓存序列化方式', 'Pansou引擎CACHE_SERIALIZER：gob 或 sonic（同名环境变量优先）', 1, 1, 108, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_enable_compression', '', '启用缓存压缩', 'Pansou引擎ENABLE_COMPRESSION：是否压缩磁盘缓存：true/false（同名环境变量优先）', 1, 1, 109, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_min_size_to_compress', '', '最小压缩大小', 'Pansou引擎MIN_SIZE_TO_COMPRESS：超过该大小(字节)的缓存才压缩（同名环境变量优先）', 1, 2, 110, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_gc_percent', '', 'GC触发百分比', 'Pansou引擎GC_PERCENT：Go运行时的GOGC值（同名环境变量优先）', 1, 2, 111, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_optimize_memory', '', '内存优化', 'Pansou引擎OPTIMIZE_MEMORY：启动时是否释放空闲内存：true/false（同名环境变量优先）', 1, 1, 112, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_plugin_timeout', '', '插件超时时间', 'Pansou引擎PLUGIN_TIMEOUT：单个插件搜索的超时时间(秒)（同名环境变量优先）', 1, 2, 113, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_plugin_enabled', '', '启用插件搜索', 'Pansou引擎ASYNC_PLUGIN_ENABLED：是否启用插件搜索：true/false（同名环境变量优先）', 1, 1, 114, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_enabled_plugins', '', '启用的插件', 'Pansou引擎ENABLED_PLUGINS：启用的插件名，用逗号分隔，未设置表示全部启用（同名环境变量优先）', 1, 1, 115, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_response_timeout', '', '插件响应超时', 'Pansou引擎ASYNC_RESPONSE_TIMEOUT：插件超过该时间(秒)未返回时先响应已有结果，剩余在后台继续（同名环境变量优先）', 1, 2, 116, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_max_background_workers', '', '后台工作者数', 'Pansou引擎ASYNC_MAX_BACKGROUND_WORKERS：插件后台工作池大小，留空按CPU核数自动计算（同名环境变量优先）', 1, 2, 117, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_max_background_tasks', '', '后台任务上限', 'Pansou引擎ASYNC_MAX_BACKGROUND_TASKS：同时执行的插件后台任务上限，留空按工作者数自动计算（同名环境变量优先）', 1, 2, 118, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_cache_ttl_hours', '', '插件缓存有效期', 'Pansou引擎ASYNC_CACHE_TTL_HOURS：插件搜索结果缓存有效期(小时)（同名环境变量优先）', 1, 2, 119, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_async_log_enabled', '', '插件详细日志', 'Pansou引擎ASYNC_LOG_ENABLED：是否输出插件异步搜索的详细日志：true/false（同名环境变量优先）', 1, 1, 120, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_http_read_timeout', '', 'HTTP读取超时', 'Pansou引擎HTTP_READ_TIMEOUT：插件请求的读取超时(秒)，留空自动计算（同名环境变量优先）', 1, 2, 121, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_http_write_timeout', '', 'HTTP写入超时', 'Pansou引擎HTTP_WRITE_TIMEOUT：插件请求的写入超时(秒)，留空自动计算（同名环境变量优先）', 1, 2, 122, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_http_idle_timeout', '', 'HTTP空闲超时', 'Pansou引擎HTTP_IDLE_TIMEOUT：插件连接的空闲超时(秒)（同名环境变量优先）', 1, 2, 123, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_http_max_conns', '', 'HTTP最大连接数', 'Pansou引擎HTTP_MAX_CONNS：插件请求的最大连接数，留空按CPU核数自动计算（同名环境变量优先）', 1, 2, 124, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_plugin_breaker_threshold', '', '插件熔断阈值', 'Pansou引擎PLUGIN_BREAKER_THRESHOLD：插件连续失败多少次后暂停调用，0表示不熔断（同名环境变量优先）', 1, 2, 125, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pansou_plugin_breaker_cooldown', '', '插件熔断探测间隔', 'Pansou引擎PLUGIN_BREAKER_COOLDOWN：插件熔断后多久(秒)重新放行一次请求探测是否恢复（同名环境变量优先）', 1, 2, 126, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
+-- 链接有效性检测配置
+('link_check_enabled', '1', '搜索结果链接检测', '搜索时检测外部结果的分享链接是否有效并过滤失效链接：1=开启，0=关闭', 1, 2, 16, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('link_check_timeout', '3', '链接检测超时时间', '搜索时链接检测的最长等待时间(秒)，超时未完成的链接保留', 1, 2, 17, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('link_recheck_interval', '24', '本地资源复检间隔', '定时复检本地资源链接的间隔(小时)，失效资源自动下线，0表示不复检', 1, 2, 18, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
+-- 开放接口配置
+('api_key_required', '0', '开放接口需要API Key', '开启后第三方调用搜索、转存接口必须携带API Key（请求头X-API-Key或参数api_key），本站网页的同源请求不受影响：1=开启，0=关闭', 1, 2, 19, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
+-- 网盘配置 - 账号池 (group=2)
+('netdisk_account_strategy', 'round_robin', '账号选择策略', '同一网盘配置多个账号时的选择策略：round_robin=轮询，least_used=最少使用，most_free_space=剩余空间最多', 2, 1, 19, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
 -- 网盘配置 - 夸克网盘 (group=2)
 ('quark_cookie', '', '夸克网盘Cookie', '夸克网盘的Cookie值', 2, 1, 20, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 ('quark_file', '0', '夸克默认文件夹ID', '转存资源默认保存的文件夹ID，0表示根目录', 2, 1, 21, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
@@ -175,6 +329,21 @@ INSERT INTO `qf_conf` (`name`, `value`, `title`, `description`, `group`, `type`,
 ('xunlei_file', '', '迅雷默认文件夹ID', '转存资源默认保存的文件夹ID，留空表示根目录', 2, 1, 61, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 ('xunlei_file_time', '', '迅雷临时文件夹ID', '临时有效期资源的存储文件夹ID', 2, 1, 62, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 
+-- 网盘配置 - 天翼云盘
+('tianyi_cookie', '', '天翼云盘Cookie', '天翼云盘的Cookie值', 2, 1, 65, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('tianyi_file', '-11', '天翼默认文件夹ID', '转存资源默认保存的文件夹ID，-11表示根目录', 2, 1, 66, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('tianyi_file_time', '-11', '天翼临时文件夹ID', '临时有效期资源的存储文件夹ID', 2, 1, 67, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
+-- 网盘配置 - 123网盘
+('pan123_token', '', '123网盘Token', '123网盘登录后的Authorization Token', 2, 1, 68, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pan123_file', '0', '123默认文件夹ID', '转存资源默认保存的文件夹ID，0表示根目录', 2, 1, 69, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pan123_file_time', '0', '123临时文件夹ID', '临时有效期资源的存储文件夹ID', 2, 1, 70, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
+-- 网盘配置 - 115网盘
+('pan115_cookie', '', '115网盘Cookie', '115网盘的Cookie值（包含UID、CID、SEID）', 2, 1, 71, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pan115_file', '0', '115默认文件夹ID', '转存资源默认保存的文件夹ID，0表示根目录', 2, 1, 72, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+('pan115_file_time', '0', '115临时文件夹ID', '临时有效期资源的存储文件夹ID', 2, 1, 73, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+
 -- 系统功能配置 (group=4)
 ('delete_netdisk_files', '0', '清理网盘文件', '清理临时资源时是否同时删除网盘中的文件：0=仅删除数据库记录，1=同时删除网盘文件', 4, 3, 90, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
 
@@ -1,24 +1,57 @@
 ﻿package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
 )
 
 // AdminManagementHandler 管理员管理处理器
 type AdminManagementHandler struct {
-	repo repository.AdminRepository
+	repo        repository.AdminRepository
+	authService service.AuthService
 }
 
 // NewAdminManagementHandler 创建管理员管理处理器
-func NewAdminManagementHandler() *AdminManagementHandler {
+func NewAdminManagementHandler(cfg *config.Config) *AdminManagementHandler {
 	return &AdminManagementHandler{
-		repo: repository.NewAdminRepository(),
+		repo:        repository.NewAdminRepository(),
+		authService: service.NewAuthService(cfg),
+	}
+}
+
+// errLastSuperAdmin 操作会导致没有可用的超级管理员
+var errLastSuperAdmin = errors.New("至少需要保留一个启用的超级管理员")
+
+// ensureSuperAdminRemains 目标管理员将失去超级管理员权限（降级、禁用、删除）时，确保仍有其他启用的超级管理员
+func (h *AdminManagementHandler) ensureSuperAdminRemains(ctx context.Context, admin *model.Admin) error {
+	if !admin.IsAdmin() || !admin.IsActive() {
+		return nil
+	}
+	count, err := h.repo.CountActiveSuperAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return errLastSuperAdmin
+	}
+	return nil
+}
+
+// revokeTokens 吊销管理员已签发的令牌（重置密码、禁用、删除后立即生效）
+func (h *AdminManagementHandler) revokeTokens(ctx context.Context, adminID uint) {
+	if err := h.authService.RevokeUserTokens(ctx, int64(adminID)); err != nil {
+		logger.Error("吊销管理员令牌失败", zap.Uint("admin_id", adminID), zap.Error(err))
 	}
 }
 
@@ -92,6 +125,7 @@ func (h *AdminManagementHandler) Create(c *gin.Context) {
 		Email    string `json:"email"`
 		Mobile   string `json:"mobile"`
 		Status   int    `json:"status"`
+		Role     *int   `json:"role"` // 未指定时默认只读审计，避免误建高权限账号
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -102,6 +136,18 @@ func (h *AdminManagementHandler) Create(c *gin.Context) {
 		return
 	}
 
+	role := model.RoleAuditor
+	if req.Role != nil {
+		role = *req.Role
+	}
+	if !model.IsValidRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的角色",
+		})
+		return
+	}
+
 	// 检查用户名是否已存在
 	existingAdmin, _ := h.repo.GetByUsername(c.Request.Context(), req.Username)
 	if existingAdmin != nil {
@@ -129,6 +175,7 @@ func (h *AdminManagementHandler) Create(c *gin.Context) {
 		Email:    req.Email,
 		Mobile:   req.Mobile,
 		Status:   req.Status,
+		Role:     role,
 	}
 
 	if err := h.repo.Create(c.Request.Context(), admin); err != nil {
@@ -155,6 +202,7 @@ func (h *AdminManagementHandler) Update(c *gin.Context) {
 		Email    string `json:"email"`
 		Mobile   string `json:"mobile"`
 		Status   int    `json:"status"`
+		Role     *int   `json:"role"` // 不传则保持原角色
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -164,6 +212,13 @@ func (h *AdminManagementHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	if req.Role != nil && !model.IsValidRole(*req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的角色",
+		})
+		return
+	}
 
 	// 获取现有管理员
 	admin, err := h.repo.GetByID(c.Request.Context(), req.AdminID)
@@ -188,6 +243,24 @@ func (h *AdminManagementHandler) Update(c *gin.Context) {
 	if req.Mobile != "" {
 		admin.Mobile = req.Mobile
 	}
+
+	// 降级或禁用超级管理员前检查是否还有其他超级管理员
+	newRole := admin.Role
+	if req.Role != nil {
+		newRole = *req.Role
+	}
+	if newRole != model.RoleSuperAdmin || req.Status != 1 {
+		if err := h.ensureSuperAdminRemains(c.Request.Context(), admin); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+	// 令牌中带有角色，角色变更后需要重新登录
+	roleChanged := newRole != admin.Role
+	admin.Role = newRole
 	admin.Status = req.Status
 
 	if err := h.repo.Update(c.Request.Context(), admin); err != nil {
@@ -197,6 +270,9 @@ func (h *AdminManagementHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	if !admin.IsActive() || roleChanged {
+		h.revokeTokens(c.Request.Context(), admin.AdminID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -220,6 +296,15 @@ func (h *AdminManagementHandler) Delete(c *gin.Context) {
 	}
 
 	for _, id := range req.IDs {
+		if admin, err := h.repo.GetByID(c.Request.Context(), id); err == nil {
+			if err := h.ensureSuperAdminRemains(c.Request.Context(), admin); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    400,
+					"message": err.Error(),
+				})
+				return
+			}
+		}
 		if err := h.repo.Delete(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code":    500,
@@ -227,6 +312,7 @@ func (h *AdminManagementHandler) Delete(c *gin.Context) {
 			})
 			return
 		}
+		h.revokeTokens(c.Request.Context(), id)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -267,6 +353,7 @@ func (h *AdminManagementHandler) ResetPassword(c *gin.Context) {
 		})
 		return
 	}
+	h.revokeTokens(c.Request.Context(), req.AdminID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -278,7 +365,7 @@ func (h *AdminManagementHandler) ResetPassword(c *gin.Context) {
 func (h *AdminManagementHandler) UpdateStatus(c *gin.Context) {
 	var req struct {
 		AdminID uint `json:"admin_id" binding:"required"`
-		Status  int  `json:"status" binding:"required"`
+		Status  *int `json:"status" binding:"required"` // 指针类型，避免禁用（0）被required校验拦截
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -289,16 +376,101 @@ func (h *AdminManagementHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.UpdateStatus(c.Request.Context(), req.AdminID, req.Status); err != nil {
+	if *req.Status != 1 {
+		if admin, err := h.repo.GetByID(c.Request.Context(), req.AdminID); err == nil {
+			if err := h.ensureSuperAdminRemains(c.Request.Context(), admin); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    400,
+					"message": err.Error(),
+				})
+				return
+			}
+		}
+	}
+
+	if err := h.repo.UpdateStatus(c.Request.Context(), req.AdminID, *req.Status); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
 			"message": "更新状态失败: " + err.Error(),
 		})
 		return
 	}
+	if *req.Status != 1 {
+		h.revokeTokens(c.Request.Context(), req.AdminID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "更新状态成功",
 	})
-}
\ No newline at end of file
+}
+
+// Unlock 解除管理员的登录锁定
+func (h *AdminManagementHandler) Unlock(c *gin.Context) {
+	var req struct {
+		AdminID uint `json:"admin_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.UnlockUser(c.Request.Context(), int64(req.AdminID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "解除锁定失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已解除锁定",
+	})
+}
+
+// ResetTOTP 重置管理员的两步验证（验证器丢失且恢复码用尽时），同时吊销其已签发的令牌
+func (h *AdminManagementHandler) ResetTOTP(c *gin.Context) {
+	var req struct {
+		AdminID uint `json:"admin_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ResetTOTP(c.Request.Context(), int64(req.AdminID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "重置两步验证失败: " + err.Error(),
+		})
+		return
+	}
+	h.revokeTokens(c.Request.Context(), req.AdminID)
+
+	logger.Info("管理员两步验证已被重置",
+		zap.Uint("admin_id", req.AdminID),
+		zap.Int64("operator_id", c.GetInt64("user_id")),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "两步验证已重置",
+	})
+}
+
+// Roles 获取角色列表及各角色的模块权限
+func (h *AdminManagementHandler) Roles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    model.Roles(),
+	})
+}
@@ -1,23 +1,30 @@
 ﻿package api
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ApiConfigHandler API配置处理器
 type ApiConfigHandler struct {
-	repo repository.APIConfigRepository
+	repo      repository.APIConfigRepository
+	customAPI service.CustomAPIService
 }
 
 // NewApiConfigHandler 创建API配置处理器
 func NewApiConfigHandler() *ApiConfigHandler {
+	repo := repository.NewAPIConfigRepository()
 	return &ApiConfigHandler{
-		repo: repository.NewAPIConfigRepository(),
+		repo:      repo,
+		customAPI: service.NewCustomAPIService(repo),
 	}
 }
 
@@ -65,6 +72,14 @@ func (h *ApiConfigHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if err := service.ValidateHTMLSelectors(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	if err := h.repo.Create(c.Request.Context(), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -99,6 +114,14 @@ func (h *ApiConfigHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if err := service.ValidateHTMLSelectors(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	if err := h.repo.Update(c.Request.Context(), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -196,4 +219,59 @@ func (h *ApiConfigHandler) GetByID(c *gin.Context) {
 		"message": "success",
 		"data":    config,
 	})
-}
\ No newline at end of file
+}
+
+// Test 测试自定义接口：用关键词调用一次接口，返回解析结果和选择器诊断信息
+// 传id时测试已保存的配置，否则测试请求中的config（用于保存前调试）
+func (h *ApiConfigHandler) Test(c *gin.Context) {
+	var req struct {
+		ID      int              `json:"id"`
+		Config  *model.APIConfig `json:"config"`
+		Keyword string           `json:"keyword"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	req.Keyword = strings.TrimSpace(req.Keyword)
+	if req.Keyword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "测试关键词不能为空",
+		})
+		return
+	}
+
+	config := req.Config
+	if req.ID > 0 {
+		saved, err := h.repo.GetByID(c.Request.Context(), req.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": "接口配置不存在",
+			})
+			return
+		}
+		config = saved
+	}
+	if config == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请指定接口ID或接口配置",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    h.customAPI.Test(ctx, config, req.Keyword),
+	})
+}
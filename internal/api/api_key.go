@@ -0,0 +1,319 @@
+﻿package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/service"
+)
+
+// APIKeyHandler 开放接口密钥处理器
+type APIKeyHandler struct{}
+
+// NewAPIKeyHandler 创建开放接口密钥处理器
+func NewAPIKeyHandler() *APIKeyHandler {
+	return &APIKeyHandler{}
+}
+
+// apiKeyRequest 创建/更新密钥的请求参数
+type apiKeyRequest struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	RateLimit          int    `json:"rate_limit"`
+	DailyTransferQuota int    `json:"daily_transfer_quota"`
+	AllowTransfer      int    `json:"allow_transfer"`
+	Status             int    `json:"status"`
+	ExpireTime         int64  `json:"expire_time"`
+	Remark             string `json:"remark"`
+}
+
+// keyService 获取全局密钥服务，未启动时返回错误响应
+func (h *APIKeyHandler) keyService(c *gin.Context) service.APIKeyService {
+	keyService := service.GetAPIKeyService()
+	if keyService == nil {
+		c.JSON(http.StatusServiceUnavailable, model.Error(http.StatusServiceUnavailable, "API Key服务未启动"))
+	}
+	return keyService
+}
+
+// List 获取密钥列表
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	keys, total, err := keyService.List(c.Request.Context(), page, pageSize, strings.TrimSpace(c.Query("keyword")))
+	if err != nil {
+		logger.Error("获取API Key列表失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取API Key列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PageData(total, page, pageSize, keys))
+}
+
+// GetByID 根据ID获取密钥
+func (h *APIKeyHandler) GetByID(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("无效的ID"))
+		return
+	}
+
+	key, err := keyService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, model.NotFound("API Key不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(key))
+}
+
+// Create 创建密钥，密钥明文只在响应中返回这一次
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if msg := validateAPIKeyRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, model.BadRequest(msg))
+		return
+	}
+
+	key := &model.APIKey{}
+	applyAPIKeyRequest(key, &req)
+	raw, err := keyService.Create(c.Request.Context(), key)
+	if err != nil {
+		logger.Error("创建API Key失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("创建API Key失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessWithMessage("创建成功，请立即保存密钥，关闭后将无法再次查看", &model.APIKeyCreateResponse{APIKey: key, Key: raw}))
+}
+
+// Update 更新密钥配置
+func (h *APIKeyHandler) Update(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if req.ID == 0 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("ID不能为空"))
+		return
+	}
+	if msg := validateAPIKeyRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, model.BadRequest(msg))
+		return
+	}
+
+	key, ok := h.getKey(c, keyService, req.ID)
+	if !ok {
+		return
+	}
+	applyAPIKeyRequest(key, &req)
+	if err := keyService.Update(c.Request.Context(), key); err != nil {
+		logger.Error("更新API Key失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("更新API Key失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(key))
+}
+
+// Status 启用/禁用密钥
+func (h *APIKeyHandler) Status(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	var req struct {
+		ID     int `json:"id" binding:"required"`
+		Status int `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if req.Status != 0 && req.Status != 1 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("状态值无效"))
+		return
+	}
+
+	key, ok := h.getKey(c, keyService, req.ID)
+	if !ok {
+		return
+	}
+	key.Status = req.Status
+	if err := keyService.Update(c.Request.Context(), key); err != nil {
+		logger.Error("更新API Key状态失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("更新API Key状态失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessWithMessage("状态已更新", nil))
+}
+
+// Regenerate 重置密钥，旧密钥立即失效
+func (h *APIKeyHandler) Regenerate(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	var req struct {
+		ID int `json:"id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	raw, err := keyService.Regenerate(c.Request.Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.NotFound("API Key不存在"))
+			return
+		}
+		logger.Error("重置API Key失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("重置API Key失败"))
+		return
+	}
+
+	key, ok := h.getKey(c, keyService, req.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("密钥已重置，请立即保存新密钥", &model.APIKeyCreateResponse{APIKey: key, Key: raw}))
+}
+
+// Delete 删除密钥及其用量记录
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	var req struct {
+		ID int `json:"id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	if err := keyService.Delete(c.Request.Context(), req.ID); err != nil {
+		logger.Error("删除API Key失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("删除API Key失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessWithMessage("删除成功", nil))
+}
+
+// Usage 获取密钥最近的每日用量
+func (h *APIKeyHandler) Usage(c *gin.Context) {
+	keyService := h.keyService(c)
+	if keyService == nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("无效的ID"))
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+
+	usage, err := keyService.Usage(c.Request.Context(), id, days)
+	if err != nil {
+		logger.Error("获取API Key用量失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取API Key用量失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(usage))
+}
+
+// getKey 获取密钥，不存在时写入错误响应
+func (h *APIKeyHandler) getKey(c *gin.Context, keyService service.APIKeyService, id int) (*model.APIKey, bool) {
+	key, err := keyService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, model.NotFound("API Key不存在"))
+		} else {
+			c.JSON(http.StatusInternalServerError, model.ServerError("获取API Key失败"))
+		}
+		return nil, false
+	}
+	return key, true
+}
+
+// validateAPIKeyRequest 校验密钥参数，返回错误提示
+func validateAPIKeyRequest(req *apiKeyRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	switch {
+	case req.Name == "":
+		return "名称不能为空"
+	case req.RateLimit < 0:
+		return "每分钟请求数限制不能为负数"
+	case req.DailyTransferQuota < 0:
+		return "每日转存额度不能为负数"
+	case req.AllowTransfer != 0 && req.AllowTransfer != 1:
+		return "转存权限值无效"
+	case req.Status != 0 && req.Status != 1:
+		return "状态值无效"
+	case req.ExpireTime < 0:
+		return "过期时间无效"
+	}
+	return ""
+}
+
+// applyAPIKeyRequest 将请求参数写入密钥（不修改密钥本身和用量计数）
+func applyAPIKeyRequest(key *model.APIKey, req *apiKeyRequest) {
+	key.Name = req.Name
+	key.RateLimit = req.RateLimit
+	key.DailyTransferQuota = req.DailyTransferQuota
+	key.AllowTransfer = req.AllowTransfer
+	key.Status = req.Status
+	key.ExpireTime = req.ExpireTime
+	key.Remark = strings.TrimSpace(req.Remark)
+}
@@ -1,12 +1,16 @@
 ﻿package api
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"huoxing-search/internal/middleware"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/jwt"
 	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/service"
 )
@@ -24,56 +28,84 @@ func NewAuthHandler(cfg *config.Config) *AuthHandler {
 }
 
 // Login 登录接口
+// 账号启用两步验证时返回 mfa_required 和 mfa_token，需再调用 /auth/login/mfa 提交验证码
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req model.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
 		return
 	}
+	req.ClientIP = c.ClientIP()
 
-	logger.Info("收到登录请求", zap.String("username", req.Username))
+	logger.Info("收到登录请求", zap.String("username", req.Username), zap.String("ip", req.ClientIP))
 
 	resp, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
 		logger.Error("登录失败", 
 			zap.String("username", req.Username),
+			zap.String("ip", req.ClientIP),
 			zap.Error(err),
 		)
-		
-		// 根据错误类型返回不同的消息
-		switch err {
-		case service.ErrUserNotFound:
-			c.JSON(http.StatusUnauthorized, model.Unauthorized("用户不存在"))
-		case service.ErrPasswordIncorrect:
-			c.JSON(http.StatusUnauthorized, model.Unauthorized("密码错误"))
-		case service.ErrUserDisabled:
-			c.JSON(http.StatusForbidden, model.Forbidden("用户已被禁用"))
-		default:
-			c.JSON(http.StatusInternalServerError, model.ServerError("登录失败"))
-		}
+		respondLoginError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, model.Success(resp))
 }
 
-// Register 注册接口
-func (h *AuthHandler) Register(c *gin.Context) {
-	var user model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+// LoginMFA 两步验证登录接口：提交登录接口返回的 mfa_token 和验证码（或恢复码）
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req model.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
 		return
 	}
+	req.ClientIP = c.ClientIP()
 
-	// 验证必填字段
-	if user.Username == "" || user.Password == "" {
+	resp, err := h.authService.LoginMFA(c.Request.Context(), &req)
+	if err != nil {
+		logger.Warn("两步验证登录失败", zap.String("ip", req.ClientIP), zap.Error(err))
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(resp))
+}
+
+// respondLoginError 根据错误类型返回不同的消息
+func respondLoginError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusUnauthorized, model.Unauthorized("用户不存在"))
+	case errors.Is(err, service.ErrPasswordIncorrect):
+		c.JSON(http.StatusUnauthorized, model.Unauthorized("密码错误"))
+	case errors.Is(err, service.ErrTOTPCodeIncorrect), errors.Is(err, service.ErrMFATokenInvalid):
+		c.JSON(http.StatusUnauthorized, model.Unauthorized(err.Error()))
+	case errors.Is(err, service.ErrAccountLocked):
+		c.JSON(http.StatusTooManyRequests, model.Error(http.StatusTooManyRequests, err.Error()))
+	case errors.Is(err, service.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, model.Forbidden("用户已被禁用"))
+	default:
+		c.JSON(http.StatusInternalServerError, model.ServerError("登录失败"))
+	}
+}
+
+// Register 注册接口（需要管理员管理权限）
+// 新账号固定为只读审计角色，忽略请求中的角色，调整角色需通过管理员管理接口
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req model.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, model.BadRequest("用户名和密码不能为空"))
 		return
 	}
 
-	// 默认启用
-	if user.Status == 0 {
-		user.Status = 1
+	user := model.User{
+		Username: req.Username,
+		Password: req.Password,
+		Nickname: req.Nickname,
+		Email:    req.Email,
+		Mobile:   req.Mobile,
+		Status:   1,
 	}
 
 	logger.Info("收到注册请求", zap.String("username", user.Username))
@@ -94,28 +126,35 @@ func (h *AuthHandler) Register(c *gin.Context) {
 }
 
 // RefreshToken 刷新token接口
+// 请求体传 refresh_token（兼容通过 Authorization 头传递刷新令牌），返回新的访问令牌和刷新令牌
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	token := c.GetHeader("Authorization")
+	var req model.RefreshTokenRequest
+	_ = c.ShouldBindJSON(&req)
+
+	token := req.RefreshToken
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, model.Unauthorized("未提供token"))
-		return
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 	}
-
-	// 移除 "Bearer " 前缀
-	if len(token) > 7 && token[:7] == "Bearer " {
-		token = token[7:]
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, model.Unauthorized("未提供刷新令牌"))
+		return
 	}
 
-	newToken, err := h.authService.RefreshToken(c.Request.Context(), token)
+	resp, err := h.authService.RefreshToken(c.Request.Context(), token)
 	if err != nil {
-		logger.Error("刷新token失败", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, model.Unauthorized("token刷新失败"))
+		logger.Warn("刷新token失败", zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, model.Forbidden("用户已被禁用"))
+		case errors.Is(err, jwt.ErrTokenType):
+			c.JSON(http.StatusUnauthorized, model.Unauthorized("请使用刷新令牌"))
+		default:
+			c.JSON(http.StatusUnauthorized, model.Unauthorized("token刷新失败，请重新登录"))
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, model.Success(gin.H{
-		"token": newToken,
-	}))
+	c.JSON(http.StatusOK, model.Success(resp))
 }
 
 // GetUserInfo 获取当前用户信息
@@ -129,15 +168,128 @@ func (h *AuthHandler) GetUserInfo(c *gin.Context) {
 	username, _ := c.Get("username")
 	role, _ := c.Get("role")
 
+	roleInt, _ := role.(int)
+
 	c.JSON(http.StatusOK, model.Success(gin.H{
-		"user_id":  userID,
-		"username": username,
-		"role":     role,
+		"user_id":     userID,
+		"username":    username,
+		"role":        role,
+		"role_name":   model.RoleName(roleInt),
+		"permissions": model.RolePermissions(roleInt), // 超级管理员为空，表示拥有全部权限
 	}))
 }
 
-// Logout 登出接口
+// Logout 登出接口：吊销当前访问令牌，请求体带 refresh_token 时一并吊销
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// TODO: 可以将token加入黑名单
+	var req model.RefreshTokenRequest
+	_ = c.ShouldBindJSON(&req)
+
+	claims, _ := c.Get(middleware.ContextKeyClaims)
+	jwtClaims, _ := claims.(*jwt.Claims)
+
+	if err := h.authService.Logout(c.Request.Context(), jwtClaims, req.RefreshToken); err != nil {
+		logger.Error("登出失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("登出失败"))
+		return
+	}
+
 	c.JSON(http.StatusOK, model.SuccessWithMessage("登出成功", nil))
-}
\ No newline at end of file
+}
+
+// LogoutAll 退出所有设备：吊销当前用户已签发的全部令牌
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if err := h.authService.RevokeUserTokens(c.Request.Context(), userID); err != nil {
+		logger.Error("退出所有设备失败", zap.Int64("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("退出所有设备失败"))
+		return
+	}
+
+	logger.Info("管理员已退出所有设备", zap.Int64("user_id", userID))
+	c.JSON(http.StatusOK, model.SuccessWithMessage("已退出所有设备", nil))
+}
+
+// TOTPStatus 获取当前用户的两步验证状态
+func (h *AuthHandler) TOTPStatus(c *gin.Context) {
+	status, err := h.authService.TOTPStatus(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		logger.Error("获取两步验证状态失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取两步验证状态失败"))
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(status))
+}
+
+// SetupTOTP 生成两步验证密钥和 otpauth:// 地址，需调用 EnableTOTP 验证后才生效
+func (h *AuthHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.authService.SetupTOTP(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		respondTOTPError(c, "生成两步验证密钥失败", err)
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(setup))
+}
+
+// EnableTOTP 校验验证码并启用两步验证，返回的恢复码只展示这一次
+func (h *AuthHandler) EnableTOTP(c *gin.Context) {
+	var req model.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
+		return
+	}
+
+	codes, err := h.authService.EnableTOTP(c.Request.Context(), c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		respondTOTPError(c, "启用两步验证失败", err)
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("两步验证已启用，请妥善保存恢复码", gin.H{
+		"recovery_codes": codes,
+	}))
+}
+
+// DisableTOTP 关闭两步验证
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req model.TOTPDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
+		return
+	}
+
+	if err := h.authService.DisableTOTP(c.Request.Context(), c.GetInt64("user_id"), req.Password, req.Code); err != nil {
+		respondTOTPError(c, "关闭两步验证失败", err)
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("两步验证已关闭", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req model.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		respondTOTPError(c, "生成恢复码失败", err)
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("恢复码已重新生成，旧恢复码已失效", gin.H{
+		"recovery_codes": codes,
+	}))
+}
+
+// respondTOTPError 两步验证管理接口的错误响应
+func respondTOTPError(c *gin.Context, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTOTPCodeIncorrect), errors.Is(err, service.ErrPasswordIncorrect),
+		errors.Is(err, service.ErrTOTPAlreadyEnabled), errors.Is(err, service.ErrTOTPNotEnabled),
+		errors.Is(err, service.ErrTOTPNotSetup):
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+	default:
+		logger.Error(action, zap.Int64("user_id", c.GetInt64("user_id")), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError(action))
+	}
+}
@@ -2,12 +2,14 @@
 
 import (
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
 )
 
 // BatchImportHandler 批量导入处理器
@@ -25,9 +27,12 @@ func NewBatchImportHandler() *BatchImportHandler {
 // ImportRequest 批量导入请求
 type ImportRequest struct {
 	Content string `json:"content" binding:"required"` // 批量链接内容，每行一个
-	PanType int    `json:"pan_type"`                   // 网盘类型：0=夸克 2=百度 3=阿里 4=UC 5=迅雷
+	PanType int    `json:"pan_type"`                   // 网盘类型：0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
 	IsTime  int    `json:"is_time"`                    // 是否临时：0=否 1=是
 	Status  int    `json:"status"`                     // 状态：0=禁用 1=启用
+
+	CategoryID int    `json:"category_id"` // 分类ID：0=按分类规则自动分类
+	SourceName string `json:"source_name"` // 原始来源名称，为空时记为"批量导入"
 }
 
 // ImportResponse 导入响应
@@ -49,6 +54,14 @@ func (h *BatchImportHandler) Import(c *gin.Context) {
 		return
 	}
 
+	// 未指定分类时按分类规则自动分类
+	categoryService := service.GetCategoryService()
+
+	sourceName := strings.TrimSpace(req.SourceName)
+	if sourceName == "" {
+		sourceName = "批量导入"
+	}
+
 	// 分割内容，每行一个链接
 	lines := strings.Split(req.Content, "\n")
 	response := ImportResponse{
@@ -67,7 +80,7 @@ func (h *BatchImportHandler) Import(c *gin.Context) {
 		response.Total++
 
 		// 解析链接
-		url, title := parseLine(line)
+		url, title, password := parseLine(line)
 		if url == "" {
 			response.Failed++
 			response.Errors = append(response.Errors, "无效的链接: "+line)
@@ -87,13 +100,20 @@ func (h *BatchImportHandler) Import(c *gin.Context) {
 			Title:      title,
 			URL:        url,
 			Content:    url,
+			Password:   password,
 			IsType:     req.PanType,
+			SourceName: sourceName,
 			IsTime:     req.IsTime,
 			Status:     req.Status,
+			CategoryID: req.CategoryID,
 			CreateTime: time.Now().Unix(),
 			UpdateTime: time.Now().Unix(),
 		}
 
+		if categoryService != nil {
+			categoryService.ClassifySources(c.Request.Context(), source)
+		}
+
 		if err := h.sourceRepo.Create(c.Request.Context(), source); err != nil {
 			response.Failed++
 			response.Errors = append(response.Errors, "导入失败: "+url+" - "+err.Error())
@@ -110,12 +130,13 @@ func (h *BatchImportHandler) Import(c *gin.Context) {
 	})
 }
 
-// parseLine 解析一行内容，返回URL和标题
-func parseLine(line string) (url string, title string) {
+// parseLine 解析一行内容，返回URL、标题和提取码
+func parseLine(line string) (url string, title string, password string) {
 	// 支持格式：
 	// 1. 纯链接：https://pan.quark.cn/s/xxx
 	// 2. 标题|链接：速度与激情|https://pan.quark.cn/s/xxx
 	// 3. 链接|标题：https://pan.quark.cn/s/xxx|速度与激情
+	// 以上格式均可追加 |提取码，链接中的 ?pwd=xxx 也会识别为提取码
 
 	parts := strings.Split(line, "|")
 	if len(parts) == 1 {
@@ -137,12 +158,34 @@ func parseLine(line string) (url string, title string) {
 		}
 	}
 
+	if len(parts) >= 3 {
+		password = strings.TrimSpace(parts[2])
+	}
+	if password == "" {
+		password = extractPasswordFromURL(url)
+	}
+
 	// 如果没有标题，使用URL作为标题
 	if title == "" {
 		title = extractTitleFromURL(url)
 	}
 
-	return url, title
+	return url, title, password
+}
+
+// extractPasswordFromURL 从链接参数中提取提取码（pwd/password/passcode）
+func extractPasswordFromURL(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	for _, key := range []string{"pwd", "password", "passcode"} {
+		if value := strings.TrimSpace(query.Get(key)); value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
 // extractTitleFromURL 从URL中提取标题
@@ -172,6 +215,10 @@ https://pan.baidu.com/s/def456
 https://pan.quark.cn/s/abc123|速度与激情1-10合集
 https://pan.baidu.com/s/def456|三体全集
 
+# 4. 追加提取码（标题|链接|提取码，或链接中带 ?pwd=）：
+三体全集|https://pan.baidu.com/s/def456|a1b2
+https://pan.baidu.com/s/def456?pwd=a1b2|三体全集
+
 # 注意事项：
 # - 每行一个资源
 # - 空行会被忽略
@@ -1,12 +1,15 @@
 ﻿package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
 )
 
 // CategoryHandler 分类处理器
@@ -66,11 +69,16 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, model.BadRequest("名称不能为空"))
 		return
 	}
+	if err := service.ValidateCategoryRule(category.Keyword); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return
+	}
 
 	if err := h.categoryRepo.Create(c.Request.Context(), &category); err != nil {
 		c.JSON(http.StatusInternalServerError, model.ServerError("创建分类失败"))
 		return
 	}
+	invalidateCategoryRules()
 
 	c.JSON(http.StatusOK, model.Success(category))
 }
@@ -87,11 +95,16 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, model.BadRequest("ID不能为空"))
 		return
 	}
+	if err := service.ValidateCategoryRule(category.Keyword); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return
+	}
 
 	if err := h.categoryRepo.Update(c.Request.Context(), &category); err != nil {
 		c.JSON(http.StatusInternalServerError, model.ServerError("更新分类失败"))
 		return
 	}
+	invalidateCategoryRules()
 
 	c.JSON(http.StatusOK, model.Success(category))
 }
@@ -111,6 +124,54 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, model.ServerError("删除分类失败"))
 		return
 	}
+	invalidateCategoryRules()
 
 	c.JSON(http.StatusOK, model.SuccessWithMessage("删除成功", nil))
-}
\ No newline at end of file
+}
+
+// Reclassify 按当前分类规则在后台重新分类资源
+func (h *CategoryHandler) Reclassify(c *gin.Context) {
+	var req struct {
+		OnlyUncategorized bool `json:"only_uncategorized"` // 只处理未分类资源
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	categoryService := service.GetCategoryService()
+	if categoryService == nil {
+		c.JSON(http.StatusServiceUnavailable, model.Error(http.StatusServiceUnavailable, "自动分类服务未启动"))
+		return
+	}
+
+	progress, err := categoryService.StartReclassify(req.OnlyUncategorized)
+	if err != nil {
+		if errors.Is(err, service.ErrReclassifyRunning) {
+			c.JSON(http.StatusConflict, model.Error(http.StatusConflict, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessWithMessage("重新分类任务已启动", progress))
+}
+
+// ReclassifyStatus 获取重新分类任务进度
+func (h *CategoryHandler) ReclassifyStatus(c *gin.Context) {
+	categoryService := service.GetCategoryService()
+	if categoryService == nil {
+		c.JSON(http.StatusServiceUnavailable, model.Error(http.StatusServiceUnavailable, "自动分类服务未启动"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(categoryService.ReclassifyProgress()))
+}
+
+// invalidateCategoryRules 分类变更后清除自动分类规则缓存
+func invalidateCategoryRules() {
+	if categoryService := service.GetCategoryService(); categoryService != nil {
+		categoryService.InvalidateRules()
+	}
+}
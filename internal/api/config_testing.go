@@ -28,7 +28,7 @@ func NewConfigTestHandler(cfg *config.Config) *ConfigTestHandler {
 
 // TestNetdiskConnection 测试网盘连接
 // POST /api/admin/test/netdisk
-// Body: {"netdisk": "quark|baidu|aliyun|uc|xunlei"}
+// Body: {"netdisk": "quark|baidu|aliyun|uc|xunlei|tianyi|pan123|pan115"}
 func (h *ConfigTestHandler) TestNetdiskConnection(c *gin.Context) {
 	var req struct {
 		Netdisk string `json:"netdisk" binding:"required"`
@@ -49,6 +49,9 @@ func (h *ConfigTestHandler) TestNetdiskConnection(c *gin.Context) {
 		"aliyun": 3,
 		"uc":     4,
 		"xunlei": 5,
+		"tianyi": 6,
+		"pan123": 7,
+		"pan115": 8,
 	}
 
 	panType, ok := panTypeMap[req.Netdisk]
@@ -4,14 +4,17 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"huoxing-search/internal/middleware"
 )
 
 // FrontendHandler 前端页面处理器
-type FrontendHandler struct{}
+type FrontendHandler struct {
+	siteToken *middleware.SiteToken
+}
 
 // NewFrontendHandler 创建前端处理器
-func NewFrontendHandler() *FrontendHandler {
-	return &FrontendHandler{}
+func NewFrontendHandler(siteToken *middleware.SiteToken) *FrontendHandler {
+	return &FrontendHandler{siteToken: siteToken}
 }
 
 // RegisterRoutes 注册前端路由
@@ -44,6 +47,7 @@ func (h *FrontendHandler) RegisterRoutes(r *gin.Engine) {
 			
 			// 搜索配置
 			authAdmin.GET("/search/api", h.AdminSearchAPI)
+			authAdmin.GET("/search/apikeys", h.AdminSearchAPIKeys)
 			
 			// 用户管理
 			authAdmin.GET("/user", h.AdminUser)
@@ -59,7 +63,8 @@ func (h *FrontendHandler) RegisterRoutes(r *gin.Engine) {
 // Home 首页
 func (h *FrontendHandler) Home(c *gin.Context) {
 	c.HTML(http.StatusOK, "index/home_simple.html", gin.H{
-		"Title": "火星网盘搜索",
+		"Title":     "火星网盘搜索",
+		"SiteToken": h.siteToken.Issue(c),
 	})
 }
 
@@ -69,9 +74,10 @@ func (h *FrontendHandler) Search(c *gin.Context) {
 	panType := c.DefaultQuery("pan_type", "0")
 	
 	c.HTML(http.StatusOK, "index/search_simple.html", gin.H{
-		"Title":   keyword + " - 搜索结果",
-		"Keyword": keyword,
-		"PanType": panType,
+		"Title":     keyword + " - 搜索结果",
+		"Keyword":   keyword,
+		"PanType":   panType,
+		"SiteToken": h.siteToken.Issue(c),
 	})
 }
 
@@ -145,6 +151,16 @@ func (h *FrontendHandler) AdminSearchAPI(c *gin.Context) {
 	})
 }
 
+// AdminSearchAPIKeys 开放接口密钥
+func (h *FrontendHandler) AdminSearchAPIKeys(c *gin.Context) {
+	c.HTML(http.StatusOK, "admin/api_keys.html", gin.H{
+		"Title":       "API Key管理",
+		"Username":    "admin",
+		"ActiveMenu":  "/admin/search/apikeys",
+		"Breadcrumbs": []string{"搜索配置", "API Key"},
+	})
+}
+
 // AdminUser 用户管理
 func (h *FrontendHandler) AdminUser(c *gin.Context) {
 	c.HTML(http.StatusOK, "admin/admin_management.html", gin.H{
@@ -1,15 +1,20 @@
 ﻿package api
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/database"
+	"huoxing-search/internal/pkg/metrics"
 	"huoxing-search/internal/pkg/redis"
+	"huoxing-search/internal/service"
 )
 
 // HealthHandler 健康检查处理器
@@ -20,12 +25,19 @@ type HealthHandler struct {
 
 // NewHealthHandler 创建健康检查处理器
 func NewHealthHandler(cfg *config.Config) *HealthHandler {
-	return &HealthHandler{
+	h := &HealthHandler{
 		cfg:       cfg,
 		startTime: time.Now(),
 	}
+	registerRuntimeMetricsOnce.Do(func() {
+		metrics.RegisterFunc(h.collectRuntimeMetrics)
+	})
+	return h
 }
 
+// registerRuntimeMetricsOnce 重载配置会重建路由，运行时指标只注册一次
+var registerRuntimeMetricsOnce sync.Once
+
 // Health 健康检查接口
 func (h *HealthHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, model.Success(gin.H{
@@ -129,9 +141,54 @@ func (h *HealthHandler) Metrics(c *gin.Context) {
 		}
 	}
 
+	// 搜索结果缓存命中情况
+	metrics["search_cache"] = service.GetSearchCacheStats()
+
 	c.JSON(http.StatusOK, model.Success(metrics))
 }
 
+// Prometheus Prometheus文本格式的指标接口
+// 需携带 Authorization: Bearer <metrics.token>，未配置令牌时拒绝所有请求
+func (h *HealthHandler) Prometheus(c *gin.Context) {
+	token := h.cfg.Metrics.Token
+	got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		c.String(http.StatusUnauthorized, "unauthorized\n")
+		return
+	}
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+// collectRuntimeMetrics 运行时和数据库连接池指标
+func (h *HealthHandler) collectRuntimeMetrics() []metrics.Family {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	gauge := func(name, help string, value float64) metrics.Family {
+		return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: value}}}
+	}
+	families := []metrics.Family{
+		gauge("huoxing_uptime_seconds", "进程运行时长", time.Since(h.startTime).Seconds()),
+		gauge("huoxing_goroutines", "当前协程数", float64(runtime.NumGoroutine())),
+		gauge("huoxing_memory_heap_alloc_bytes", "堆上已分配内存", float64(m.HeapAlloc)),
+		gauge("huoxing_memory_sys_bytes", "从系统申请的内存", float64(m.Sys)),
+		{Name: "huoxing_gc_total", Help: "GC次数", Type: metrics.TypeCounter, Samples: []metrics.Sample{{Value: float64(m.NumGC)}}},
+	}
+
+	if db := database.GetDB(); db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			stats := sqlDB.Stats()
+			families = append(families,
+				gauge("huoxing_db_open_connections", "数据库已打开的连接数", float64(stats.OpenConnections)),
+				gauge("huoxing_db_in_use_connections", "数据库正在使用的连接数", float64(stats.InUse)),
+				gauge("huoxing_db_max_open_connections", "数据库最大连接数", float64(stats.MaxOpenConnections)),
+				metrics.Family{Name: "huoxing_db_wait_total", Help: "等待数据库连接的次数", Type: metrics.TypeCounter, Samples: []metrics.Sample{{Value: float64(stats.WaitCount)}}},
+			)
+		}
+	}
+	return families
+}
+
 // Version 版本信息接口
 func (h *HealthHandler) Version(c *gin.Context) {
 	c.JSON(http.StatusOK, model.Success(gin.H{
@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"golang.org/x/crypto/bcrypt"
+	"huoxing-search/internal/model"
 )
 
 // InstallHandler 安装处理器
@@ -257,11 +258,11 @@ func (h *InstallHandler) ExecuteInstall(c *gin.Context) {
 	}
 
 	insertAdmin := fmt.Sprintf(`
-		INSERT INTO %sadmin (username, password, status, create_time, update_time)
-		VALUES (?, ?, 1, UNIX_TIMESTAMP(), UNIX_TIMESTAMP())
+		INSERT INTO %sadmin (username, password, status, role, create_time, update_time)
+		VALUES (?, ?, 1, ?, UNIX_TIMESTAMP(), UNIX_TIMESTAMP())
 	`, req.DBPrefix)
 	
-	_, err = db.Exec(insertAdmin, req.AdminUser, string(hashedPassword))
+	_, err = db.Exec(insertAdmin, req.AdminUser, string(hashedPassword), model.RoleSuperAdmin)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code":    500,
@@ -296,10 +297,17 @@ redis:
 
 pansou:
   url: http://localhost:8888
+  # 内置搜索引擎配置，键为对应环境变量名的小写形式
+  # 优先级：环境变量 > 后台系统配置(pansou_*) > 本节 > 默认值
+  engine:
+    # channels: [tgsearchers4]
+    # plugin_timeout: 30
+    # async_max_background_workers: 20
 
 jwt:
   secret: %s
   expire_hours: 168
+  access_expire_minutes: 30
 
 log:
   level: info
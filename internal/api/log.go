@@ -0,0 +1,174 @@
+﻿package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+// logExportLimit 单次导出的最大日志条数
+const logExportLimit = 10000
+
+// LogHandler 操作日志处理器
+type LogHandler struct {
+	logRepo repository.LogRepository
+}
+
+// NewLogHandler 创建操作日志处理器
+func NewLogHandler() *LogHandler {
+	return &LogHandler{
+		logRepo: repository.NewLogRepository(),
+	}
+}
+
+// List 分页查询操作日志
+func (h *LogHandler) List(c *gin.Context) {
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	logs, total, err := h.logRepo.List(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		logger.Error("查询操作日志失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("查询操作日志失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PageData(total, page, pageSize, logs))
+}
+
+// Export 按查询条件导出操作日志(CSV)
+func (h *LogHandler) Export(c *gin.Context) {
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return
+	}
+
+	logs, err := h.logRepo.ListAll(c.Request.Context(), filter, logExportLimit)
+	if err != nil {
+		logger.Error("导出操作日志失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("导出操作日志失败"))
+		return
+	}
+
+	filename := fmt.Sprintf("operation_logs_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Status(http.StatusOK)
+
+	// 写入BOM，保证Excel正确识别UTF-8
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"ID", "管理员ID", "用户名", "模块", "操作", "请求方法", "URL", "IP", "状态", "请求数据", "时间"})
+	for _, log := range logs {
+		status := "成功"
+		if log.Status == model.LogStatusFailed {
+			status = "失败"
+		}
+		writer.Write([]string{
+			strconv.FormatUint(log.LogID, 10),
+			strconv.FormatInt(log.AdminID, 10),
+			log.Username,
+			log.Module,
+			log.Action,
+			log.Method,
+			log.URL,
+			log.IP,
+			status,
+			log.RequestData,
+			time.Unix(log.CreateTime, 0).Format("2006-01-02 15:04:05"),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Warn("写入操作日志CSV失败", zap.Error(err))
+	}
+}
+
+// Modules 获取日志中出现过的模块列表
+func (h *LogHandler) Modules(c *gin.Context) {
+	modules, err := h.logRepo.ListModules(c.Request.Context())
+	if err != nil {
+		logger.Error("查询日志模块失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("查询日志模块失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(modules))
+}
+
+// parseLogFilter 解析日志查询条件
+// start_time/end_time 支持Unix时间戳或 2006-01-02 格式日期（结束日期包含当天）
+func parseLogFilter(c *gin.Context) (model.OperationLogFilter, error) {
+	filter := model.OperationLogFilter{
+		Module: strings.TrimSpace(c.Query("module")),
+		Status: -1,
+	}
+
+	if adminID := c.Query("admin_id"); adminID != "" {
+		id, err := strconv.ParseInt(adminID, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("无效的管理员ID")
+		}
+		filter.AdminID = id
+	}
+
+	if status := c.Query("status"); status != "" {
+		s, err := strconv.Atoi(status)
+		if err != nil || (s != model.LogStatusFailed && s != model.LogStatusSuccess) {
+			return filter, fmt.Errorf("无效的状态")
+		}
+		filter.Status = s
+	}
+
+	var err error
+	if filter.StartTime, err = parseLogTime(c.Query("start_time"), false); err != nil {
+		return filter, fmt.Errorf("无效的开始时间")
+	}
+	if filter.EndTime, err = parseLogTime(c.Query("end_time"), true); err != nil {
+		return filter, fmt.Errorf("无效的结束时间")
+	}
+
+	return filter, nil
+}
+
+// parseLogTime 解析时间参数，空值返回0
+func parseLogTime(value string, endOfDay bool) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return 0, err
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Unix() - 1, nil
+	}
+	return day.Unix(), nil
+}
@@ -0,0 +1,261 @@
+﻿package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/service"
+)
+
+// NetdiskAccountHandler 网盘账号池处理器
+type NetdiskAccountHandler struct {
+	accountService service.NetdiskAccountService
+}
+
+// NewNetdiskAccountHandler 创建网盘账号池处理器
+func NewNetdiskAccountHandler(cfg *config.Config) *NetdiskAccountHandler {
+	return &NetdiskAccountHandler{
+		accountService: service.NewNetdiskAccountService(netdisk.NewNetdiskManager(cfg)),
+	}
+}
+
+// List 获取账号列表
+// GET /api/admin/netdisk/accounts?pan_type=0
+func (h *NetdiskAccountHandler) List(c *gin.Context) {
+	panType, err := strconv.Atoi(c.DefaultQuery("pan_type", "-1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "网盘类型参数错误",
+		})
+		return
+	}
+
+	accounts, err := h.accountService.List(c.Request.Context(), panType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	list := make([]*model.NetdiskAccount, 0, len(accounts))
+	for _, account := range accounts {
+		list = append(list, maskNetdiskAccount(account))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"list":  list,
+			"total": len(list),
+		},
+	})
+}
+
+// GetByID 获取账号详情
+func (h *NetdiskAccountHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的ID",
+		})
+		return
+	}
+
+	account, err := h.accountService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "账号不存在",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    maskNetdiskAccount(account),
+	})
+}
+
+// Create 创建账号
+func (h *NetdiskAccountHandler) Create(c *gin.Context) {
+	var req model.NetdiskAccount
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	req.ID = 0
+	if err := h.accountService.Create(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "创建成功",
+		"data":    maskNetdiskAccount(&req),
+	})
+}
+
+// Update 更新账号（credential留空表示不修改）
+func (h *NetdiskAccountHandler) Update(c *gin.Context) {
+	var req model.NetdiskAccount
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if req.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "ID不能为空",
+		})
+		return
+	}
+
+	if err := h.accountService.Update(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "更新失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    maskNetdiskAccount(&req),
+	})
+}
+
+// Delete 删除账号
+func (h *NetdiskAccountHandler) Delete(c *gin.Context) {
+	var req struct {
+		ID int `json:"id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.accountService.Delete(c.Request.Context(), req.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "删除失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+// UpdateStatus 启用/禁用账号
+func (h *NetdiskAccountHandler) UpdateStatus(c *gin.Context) {
+	var req struct {
+		ID     int `json:"id" binding:"required"`
+		Status int `json:"status"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.accountService.UpdateStatus(c.Request.Context(), req.ID, req.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "更新状态失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "状态更新成功",
+	})
+}
+
+// Test 测试账号连接
+// POST /api/admin/netdisk/accounts/:id/test
+func (h *NetdiskAccountHandler) Test(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的ID",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	account, err := h.accountService.Test(ctx, id)
+	if account == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    500,
+			"message": "测试失败: " + err.Error(),
+			"success": false,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    500,
+			"message": "连接测试失败: " + err.Error(),
+			"success": false,
+			"data":    maskNetdiskAccount(account),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "连接测试成功！",
+		"success": true,
+		"data":    maskNetdiskAccount(account),
+	})
+}
+
+// maskNetdiskAccount 隐藏账号凭证，仅保留首尾少量字符用于辨识
+func maskNetdiskAccount(account *model.NetdiskAccount) *model.NetdiskAccount {
+	masked := *account
+	runes := []rune(account.Credential)
+	if len(runes) > 12 {
+		masked.Credential = string(runes[:6]) + "******" + string(runes[len(runes)-4:])
+	} else if len(runes) > 0 {
+		masked.Credential = "******"
+	}
+	return &masked
+}
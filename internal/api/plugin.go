@@ -0,0 +1,112 @@
+﻿package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/service"
+)
+
+// PluginHandler Pansou插件管理处理器
+type PluginHandler struct {
+	pluginService service.PluginService
+}
+
+// NewPluginHandler 创建插件管理处理器
+func NewPluginHandler() *PluginHandler {
+	return &PluginHandler{
+		pluginService: service.NewPluginService(),
+	}
+}
+
+// List 获取插件列表及调用统计
+// GET /api/admin/plugins
+func (h *PluginHandler) List(c *gin.Context) {
+	list := h.pluginService.List(c.Request.Context())
+	c.JSON(http.StatusOK, model.Success(gin.H{
+		"list":  list,
+		"total": len(list),
+	}))
+}
+
+// UpdateStatus 启用或禁用插件
+// POST /api/admin/plugins/status {"name":"xxx","status":0}
+func (h *PluginHandler) UpdateStatus(c *gin.Context) {
+	var req struct {
+		Name   string `json:"name" binding:"required"`
+		Status int    `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if req.Status != 0 && req.Status != 1 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("状态值无效"))
+		return
+	}
+
+	err := h.pluginService.SetEnabled(c.Request.Context(), req.Name, req.Status == 1)
+	if h.handleError(c, err, "更新插件状态失败") {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("状态已更新", nil))
+}
+
+// UpdatePriority 覆盖插件优先级，priority为0时恢复默认
+// POST /api/admin/plugins/priority {"name":"xxx","priority":2}
+func (h *PluginHandler) UpdatePriority(c *gin.Context) {
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		Priority int    `json:"priority"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	err := h.pluginService.SetPriority(c.Request.Context(), req.Name, req.Priority)
+	if h.handleError(c, err, "更新插件优先级失败") {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("优先级已更新", nil))
+}
+
+// ResetBreaker 手动解除插件熔断
+// POST /api/admin/plugins/reset {"name":"xxx"}
+func (h *PluginHandler) ResetBreaker(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	err := h.pluginService.ResetBreaker(c.Request.Context(), req.Name)
+	if h.handleError(c, err, "解除熔断失败") {
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("熔断已解除", nil))
+}
+
+// handleError 输出错误响应，返回是否有错误
+func (h *PluginHandler) handleError(c *gin.Context, err error, message string) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, service.ErrPluginNotFound) {
+		c.JSON(http.StatusNotFound, model.NotFound(err.Error()))
+		return true
+	}
+	if errors.Is(err, service.ErrInvalidPluginPriority) {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return true
+	}
+	logger.Error(message, zap.Error(err))
+	c.JSON(http.StatusInternalServerError, model.ServerError(message))
+	return true
+}
@@ -0,0 +1,107 @@
+﻿package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/middleware"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/pansou/plugin"
+)
+
+// PluginWebPrefix 插件管理页面的挂载前缀
+const PluginWebPrefix = "/admin/plugins"
+
+// PluginWebRoute 插件管理页面信息
+type PluginWebRoute struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Path     string `json:"path"` // 管理页面路径前缀，后接用户标识
+}
+
+// PluginWebHandler 插件Web路由处理器
+type PluginWebHandler struct {
+	routes []PluginWebRoute
+}
+
+// NewPluginWebHandler 创建插件Web路由处理器
+func NewPluginWebHandler() *PluginWebHandler {
+	return &PluginWebHandler{}
+}
+
+// RegisterRoutes 将实现了PluginWithWebHandler的插件路由挂载到 PluginWebPrefix 下（需要管理员登录）
+// 管理页面由浏览器直接打开，使用允许cookie认证并校验同源的 PageAuthMiddleware
+func (h *PluginWebHandler) RegisterRoutes(r *gin.Engine, cfg *config.Config) {
+	group := r.Group(PluginWebPrefix)
+	group.Use(middleware.PageAuthMiddleware(cfg), middleware.AdminMiddleware())
+
+	plugins := plugin.GetRegisteredPlugins()
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name() < plugins[j].Name()
+	})
+
+	for _, p := range plugins {
+		webPlugin, ok := p.(plugin.PluginWithWebHandler)
+		if !ok {
+			continue
+		}
+
+		// 记录注册前已有的路由，注册后对比得出该插件新增的路由
+		existing := make(map[string]bool)
+		for _, route := range r.Routes() {
+			existing[route.Method+" "+route.Path] = true
+		}
+
+		webPlugin.RegisterWebRoutes(group)
+
+		path := ""
+		for _, route := range r.Routes() {
+			if existing[route.Method+" "+route.Path] || route.Method != http.MethodGet {
+				continue
+			}
+			path = route.Path
+			if idx := strings.Index(path, "/:"); idx > 0 {
+				path = path[:idx]
+			}
+			break
+		}
+		if path == "" {
+			continue
+		}
+
+		h.routes = append(h.routes, PluginWebRoute{
+			Name:     webPlugin.Name(),
+			Priority: webPlugin.Priority(),
+			Path:     path,
+		})
+		logger.Info("插件管理页面已挂载",
+			zap.String("plugin", webPlugin.Name()),
+			zap.String("path", path),
+		)
+	}
+}
+
+// List 获取插件管理页面列表
+func (h *PluginWebHandler) List(c *gin.Context) {
+	username := c.GetString("username")
+
+	list := make([]gin.H, 0, len(h.routes))
+	for _, route := range h.routes {
+		list = append(list, gin.H{
+			"name":     route.Name,
+			"priority": route.Priority,
+			"path":     route.Path,
+			"url":      route.Path + "/" + username,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    list,
+	})
+}
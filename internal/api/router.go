@@ -8,6 +8,7 @@ import (
 	"huoxing-search/internal/middleware"
 	"huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/database"
+	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/repository"
 	"huoxing-search/internal/service"
 )
@@ -54,10 +55,29 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	// 静态文件服务
 	r.Static("/static", "./web/static")
 	
+	// 本站页面令牌（开启强制API Key后，前台页面凭此调用搜索、转存接口）
+	siteToken := middleware.NewSiteToken(cfg.JWT.Secret)
+
 	// 前端页面路由
-	frontendHandler := NewFrontendHandler()
+	frontendHandler := NewFrontendHandler(siteToken)
 	frontendHandler.RegisterRoutes(r)
 
+	// 插件管理页面（gying/qqpd/weibo等插件的账号管理，需要管理员登录）
+	pluginWebHandler := NewPluginWebHandler()
+	pluginWebHandler.RegisterRoutes(r, cfg)
+
+	// 异步转存任务处理器（公开接口与管理接口共用）
+	transferJobHandler := NewTransferJobHandler(cfg)
+
+	// Prometheus指标（与 /api/metrics 的JSON统计并存），指标包含内部运行数据，必须配置抓取令牌才开放
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Token != "" {
+			r.GET("/metrics", NewHealthHandler(cfg).Prometheus)
+		} else {
+			logger.Warn("已开启 metrics.enabled 但未配置 metrics.token，/metrics 未开放")
+		}
+	}
+
 	// API分组
 	api := r.Group("/api")
 	{
@@ -75,26 +95,35 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			// 认证接口
 			authHandler := NewAuthHandler(cfg)
 			public.POST("/auth/login", authHandler.Login)
-			public.POST("/auth/register", authHandler.Register)
 			public.POST("/auth/refresh", authHandler.RefreshToken)
+			public.POST("/auth/login/mfa", authHandler.LoginMFA)
 			
 			// 管理员登录接口（为了兼容前端）
 			public.POST("/admin/login", authHandler.Login)
+			public.POST("/admin/login/mfa", authHandler.LoginMFA)
 
 			// 初始化仓储和服务
 			configRepo := repository.NewConfigRepository()
 			cacheRepo := repository.NewCacheRepository()
 			
+			// 开放接口密钥（按密钥限流、统计用量和转存额度）
+			apiKeyMiddleware := middleware.APIKeyMiddleware(service.GetAPIKeyService(), siteToken)
+
 			// 转存服务（需要先创建，因为搜索服务依赖它）
 			transferService := service.NewTransferService(cfg)
 			transferHandler := NewTransferHandler(cfg)
-			public.POST("/transfer", transferHandler.Transfer)
-			public.POST("/transfer/save", transferHandler.TransferAndSave)
+			public.POST("/transfer", apiKeyMiddleware, transferHandler.Transfer)
+			public.POST("/transfer/save", apiKeyMiddleware, transferHandler.TransferAndSave)
+			
+			// 异步转存任务（入队后立即返回任务编号，通过轮询获取转存结果）
+			public.POST("/transfer/jobs", apiKeyMiddleware, transferJobHandler.Enqueue)
+			public.GET("/transfer/jobs/:job_no", apiKeyMiddleware, transferJobHandler.Get)
+			public.POST("/transfer/jobs/:job_no/cancel", apiKeyMiddleware, transferJobHandler.Cancel)
 			
 			// 搜索接口（传入转存服务）
 			searchService := service.NewSearchService(configRepo, cacheRepo, transferService)
 			searchHandler := NewSearchHandler(searchService)
-			public.POST("/search", searchHandler.Search)
+			public.POST("/search", apiKeyMiddleware, searchHandler.Search)
 			public.DELETE("/search/cache", searchHandler.ClearCache)
 
 			// 微信回调接口（无需认证）
@@ -119,19 +148,32 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			// 用户相关接口
 			authHandler2 := NewAuthHandler(cfg)
 			auth.GET("/auth/userinfo", authHandler2.GetUserInfo)
+			auth.POST("/auth/register", middleware.RequirePermission("admins"), authHandler2.Register)
 			auth.POST("/auth/logout", authHandler2.Logout)
+			auth.POST("/auth/logout-all", authHandler2.LogoutAll)
+
+			// 两步验证（TOTP）
+			auth.GET("/auth/2fa", authHandler2.TOTPStatus)
+			auth.POST("/auth/2fa/setup", authHandler2.SetupTOTP)
+			auth.POST("/auth/2fa/enable", authHandler2.EnableTOTP)
+			auth.POST("/auth/2fa/disable", authHandler2.DisableTOTP)
+			auth.POST("/auth/2fa/recovery-codes", authHandler2.RegenerateRecoveryCodes)
+
+			// 管理员操作审计（后台页面的资源管理直接调用 /api/sources，同样需要记录）
+			auditMiddleware := middleware.AuditMiddleware(repository.NewLogRepository())
 
 			// 资源管理
 			sourceHandler := NewSourceHandler(cfg)
-			auth.GET("/sources", sourceHandler.List)
-			auth.GET("/sources/:id", sourceHandler.GetByID)
-			auth.POST("/sources", sourceHandler.Create)
-			auth.PUT("/sources", sourceHandler.Update)      // 接受body中的source_id
-			auth.DELETE("/sources", sourceHandler.Delete)   // 接受body中的ids数组
+			sources := auth.Group("/sources", middleware.RequirePermission("sources"), auditMiddleware)
+			sources.GET("", sourceHandler.List)
+			sources.GET("/:id", sourceHandler.GetByID)
+			sources.POST("", sourceHandler.Create)
+			sources.PUT("", sourceHandler.Update)      // 接受body中的source_id
+			sources.DELETE("", sourceHandler.Delete)   // 接受body中的ids数组
 
 			// 用户管理(仅管理员)
 			admin := auth.Group("/admin")
-			admin.Use(middleware.AdminMiddleware())
+			admin.Use(middleware.AdminMiddleware(), auditMiddleware)
 			{
 				// 用户管理
 				userHandler := NewUserHandler(cfg)
@@ -146,6 +188,8 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.POST("/sources/create", sourceHandler.Create)
 				admin.POST("/sources/update", sourceHandler.Update)
 				admin.POST("/sources/delete", sourceHandler.Delete)
+				admin.GET("/sources/index", sourceHandler.IndexStatus)
+				admin.POST("/sources/index/rebuild", sourceHandler.RebuildIndex)
 
 				// API配置管理
 				apiConfigHandler := NewApiConfigHandler()
@@ -155,6 +199,17 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.POST("/apis/update", apiConfigHandler.Update)
 				admin.POST("/apis/delete", apiConfigHandler.Delete)
 				admin.POST("/apis/status", apiConfigHandler.UpdateStatus)
+				admin.POST("/apis/test", apiConfigHandler.Test)
+
+				// 网盘账号池
+				netdiskAccountHandler := NewNetdiskAccountHandler(cfg)
+				admin.GET("/netdisk/accounts", netdiskAccountHandler.List)
+				admin.GET("/netdisk/accounts/:id", netdiskAccountHandler.GetByID)
+				admin.POST("/netdisk/accounts/create", netdiskAccountHandler.Create)
+				admin.POST("/netdisk/accounts/update", netdiskAccountHandler.Update)
+				admin.POST("/netdisk/accounts/delete", netdiskAccountHandler.Delete)
+				admin.POST("/netdisk/accounts/status", netdiskAccountHandler.UpdateStatus)
+				admin.POST("/netdisk/accounts/:id/test", netdiskAccountHandler.Test)
 
 				// 配置测试
 				configTestHandler := NewConfigTestHandler(cfg)
@@ -166,6 +221,9 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.GET("/configs", systemConfigHandler.List)
 				admin.GET("/configs/:id", systemConfigHandler.GetByID)
 				admin.GET("/configs/name/:name", systemConfigHandler.GetByName)
+				admin.GET("/configs/pansou/effective", systemConfigHandler.PansouEffective)  // Pansou引擎配置生效值及来源
+				admin.GET("/configs/reload", systemConfigHandler.ReloadStatus)  // 最近一次配置重载结果
+				admin.POST("/configs/reload", systemConfigHandler.Reload)  // 立即重新加载配置文件
 				admin.POST("/configs/create", systemConfigHandler.Create)
 				admin.POST("/configs/update", systemConfigHandler.Update)
 				admin.POST("/configs/delete", systemConfigHandler.Delete)
@@ -173,14 +231,17 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.POST("/configs/batch-upsert", systemConfigHandler.BatchUpsert)  // 批量插入或更新（根据name）
 
 				// 管理员管理
-				adminManagementHandler := NewAdminManagementHandler()
+				adminManagementHandler := NewAdminManagementHandler(cfg)
 				admin.GET("/admins", adminManagementHandler.List)
+				admin.GET("/admins/roles", adminManagementHandler.Roles)
 				admin.GET("/admins/:id", adminManagementHandler.GetByID)
 				admin.POST("/admins/create", adminManagementHandler.Create)
 				admin.POST("/admins/update", adminManagementHandler.Update)
 				admin.POST("/admins/delete", adminManagementHandler.Delete)
 				admin.POST("/admins/reset-password", adminManagementHandler.ResetPassword)
 				admin.POST("/admins/status", adminManagementHandler.UpdateStatus)
+				admin.POST("/admins/unlock", adminManagementHandler.Unlock)
+				admin.POST("/admins/reset-2fa", adminManagementHandler.ResetTOTP)
 
 				// 分类管理
 				categoryHandler := NewCategoryHandler()
@@ -189,6 +250,8 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.POST("/categories/create", categoryHandler.Create)
 				admin.POST("/categories/update", categoryHandler.Update)
 				admin.POST("/categories/delete", categoryHandler.Delete)
+				admin.GET("/categories/reclassify", categoryHandler.ReclassifyStatus)
+				admin.POST("/categories/reclassify", categoryHandler.Reclassify)
 
 				// 批量导入
 				batchImportHandler := NewBatchImportHandler()
@@ -200,6 +263,47 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 				admin.GET("/stats/dashboard", statsHandler.GetDashboardStats)
 				admin.GET("/stats/resources", statsHandler.GetResourceStats)
 				admin.GET("/stats/recent", statsHandler.GetRecentSources)
+
+				// 插件管理页面列表
+				admin.GET("/plugins/web", pluginWebHandler.List)
+
+				// TG频道管理
+				tgChannelHandler := NewTGChannelHandler()
+				admin.GET("/channels", tgChannelHandler.List)
+				admin.GET("/channels/groups", tgChannelHandler.Groups)
+				admin.GET("/channels/:id", tgChannelHandler.GetByID)
+				admin.POST("/channels/create", tgChannelHandler.Create)
+				admin.POST("/channels/update", tgChannelHandler.Update)
+				admin.POST("/channels/delete", tgChannelHandler.Delete)
+				admin.POST("/channels/status", tgChannelHandler.UpdateStatus)
+				admin.POST("/channels/import", tgChannelHandler.Import)
+
+				// 插件管理（启用/禁用、优先级覆盖、熔断状态）
+				pluginHandler := NewPluginHandler()
+				admin.GET("/plugins", pluginHandler.List)
+				admin.POST("/plugins/status", pluginHandler.UpdateStatus)
+				admin.POST("/plugins/priority", pluginHandler.UpdatePriority)
+				admin.POST("/plugins/reset", pluginHandler.ResetBreaker)
+
+				// 转存任务列表
+				admin.GET("/transfer/jobs", transferJobHandler.List)
+
+				// 开放接口密钥
+				apiKeyHandler := NewAPIKeyHandler()
+				admin.GET("/api-keys", apiKeyHandler.List)
+				admin.GET("/api-keys/:id", apiKeyHandler.GetByID)
+				admin.GET("/api-keys/:id/usage", apiKeyHandler.Usage)
+				admin.POST("/api-keys/create", apiKeyHandler.Create)
+				admin.POST("/api-keys/update", apiKeyHandler.Update)
+				admin.POST("/api-keys/status", apiKeyHandler.Status)
+				admin.POST("/api-keys/regenerate", apiKeyHandler.Regenerate)
+				admin.POST("/api-keys/delete", apiKeyHandler.Delete)
+
+				// 操作日志
+				logHandler := NewLogHandler()
+				admin.GET("/logs", logHandler.List)
+				admin.GET("/logs/export", logHandler.Export)
+				admin.GET("/logs/modules", logHandler.Modules)
 			}
 		}
 	}
@@ -2,6 +2,7 @@
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"huoxing-search/internal/model"
@@ -39,6 +40,8 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		return
 	}
 
+	req.Source = model.TransferJobSourceWeb
+
 	// 调用搜索服务
 	result, err := h.searchService.Search(c.Request.Context(), req)
 	if err != nil {
@@ -70,13 +73,15 @@ func (h *SearchHandler) ClearCache(c *gin.Context) {
 	keyword := c.Query("keyword")
 	panType := 0
 	if pt := c.Query("pan_type"); pt != "" {
-		if _, err := c.GetQuery("pan_type"); err {
+		val, err := strconv.Atoi(pt)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, model.Response{
 				Code:    400,
 				Message: "网盘类型参数错误",
 			})
 			return
 		}
+		panType = val
 	}
 
 	var err error
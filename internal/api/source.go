@@ -5,9 +5,12 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
 )
 
 // SourceHandler 资源处理器
@@ -28,6 +31,7 @@ func (h *SourceHandler) List(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	isType, _ := strconv.Atoi(c.DefaultQuery("is_type", "-1"))
 	status, _ := strconv.Atoi(c.DefaultQuery("status", "1"))
+	categoryID, _ := strconv.Atoi(c.DefaultQuery("category_id", "-1")) // 0表示未分类
 	keyword := c.Query("keyword")
 
 	var sources []*model.Source
@@ -38,7 +42,13 @@ func (h *SourceHandler) List(c *gin.Context) {
 	if keyword != "" {
 		sources, total, err = h.sourceRepo.Search(c.Request.Context(), keyword, page, pageSize)
 	} else {
-		sources, total, err = h.sourceRepo.List(c.Request.Context(), page, pageSize, isType, status)
+		filter := model.SourceFilter{
+			IsType:     isType,
+			Status:     status,
+			CategoryID: categoryID,
+			SourceName: c.Query("source_name"),
+		}
+		sources, total, err = h.sourceRepo.List(c.Request.Context(), filter, page, pageSize)
 	}
 
 	if err != nil {
@@ -74,6 +84,11 @@ func (h *SourceHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// 未指定分类时按分类规则自动分类
+	if categoryService := service.GetCategoryService(); categoryService != nil {
+		categoryService.ClassifySources(c.Request.Context(), &source)
+	}
+
 	if err := h.sourceRepo.Create(c.Request.Context(), &source); err != nil {
 		c.JSON(http.StatusInternalServerError, model.ServerError("创建资源失败"))
 		return
@@ -123,4 +138,21 @@ func (h *SourceHandler) Delete(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, model.SuccessWithMessage("删除成功", nil))
-}
\ No newline at end of file
+}
+// IndexStatus 获取资源全文索引状态
+func (h *SourceHandler) IndexStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, model.Success(repository.GetSourceIndexStatus()))
+}
+
+// RebuildIndex 重建资源全文索引
+func (h *SourceHandler) RebuildIndex(c *gin.Context) {
+	count, err := repository.RebuildSourceIndex(c.Request.Context())
+	if err != nil {
+		logger.Error("重建资源索引失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("重建索引失败"))
+		return
+	}
+
+	logger.Info("✅ 资源索引重建完成", zap.Int("documents", count))
+	c.JSON(http.StatusOK, model.SuccessWithMessage("重建索引成功", repository.GetSourceIndexStatus()))
+}
@@ -63,17 +63,19 @@ func (h *StatsHandler) GetDashboardStats(c *gin.Context) {
 // GetResourceStats 获取资源统计（按分类）
 func (h *StatsHandler) GetResourceStats(c *gin.Context) {
 	type CategoryStat struct {
+		CategoryID   int    `json:"category_id"`
 		CategoryName string `json:"category_name"`
 		Count        int64  `json:"count"`
 	}
 
 	var stats []CategoryStat
 
+	// 未分类或分类已删除的资源归入"未分类"
 	h.db.Table("qf_source s").
-		Select("c.cate_name as category_name, COUNT(s.source_id) as count").
-		Joins("LEFT JOIN qf_source_category c ON s.cate_id = c.cate_id").
+		Select("COALESCE(c.category_id, 0) as category_id, COALESCE(c.name, '未分类') as category_name, COUNT(s.source_id) as count").
+		Joins("LEFT JOIN qf_source_category c ON s.category_id = c.category_id").
 		Where("s.status = ?", 1).
-		Group("s.cate_id, c.cate_name").
+		Group("COALESCE(c.category_id, 0), COALESCE(c.name, '未分类')").
 		Order("count DESC").
 		Limit(10).
 		Scan(&stats)
@@ -1,13 +1,18 @@
 ﻿package api
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
 	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/repository"
+	pansouConfig "huoxing-search/pansou/config"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // SystemConfigHandler 系统配置处理器
@@ -100,6 +105,7 @@ func (h *SystemConfigHandler) Create(c *gin.Context) {
 		})
 		return
 	}
+	h.publishChange([]string{req.Name})
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -151,6 +157,7 @@ func (h *SystemConfigHandler) Update(c *gin.Context) {
 		})
 		return
 	}
+	h.publishChange(h.configNames(c.Request.Context(), []int{req.ConfID}))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -186,6 +193,11 @@ func (h *SystemConfigHandler) BatchUpdate(c *gin.Context) {
 		})
 		return
 	}
+	ids := make([]int, 0, len(req.Configs))
+	for _, conf := range req.Configs {
+		ids = append(ids, conf.ConfID)
+	}
+	h.publishChange(h.configNames(c.Request.Context(), ids))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -289,6 +301,11 @@ func (h *SystemConfigHandler) BatchUpsert(c *gin.Context) {
 		})
 		return
 	}
+	names := make([]string, 0, len(strMap))
+	for name := range strMap {
+		names = append(names, name)
+	}
+	h.publishChange(names)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -327,6 +344,7 @@ func (h *SystemConfigHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	names := h.configNames(c.Request.Context(), req.IDs)
 	if err := h.repo.BatchDelete(c.Request.Context(), req.IDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -335,6 +353,8 @@ func (h *SystemConfigHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	h.publishChange(names)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "删除成功",
@@ -366,4 +386,79 @@ func (h *SystemConfigHandler) GetByName(c *gin.Context) {
 		"message": "success",
 		"data":    config,
 	})
-}
\ No newline at end of file
+}
+
+// PansouEffective 获取Pansou引擎配置的生效值及来源（env/db/yaml/default）
+// 系统配置表中的 pansou_* 配置项保存后立即重新加载，缓存目录、工作池大小等启动时使用的配置需重启生效
+func (h *SystemConfigHandler) PansouEffective(c *gin.Context) {
+	list := pansouConfig.Effective()
+	if list == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    503,
+			"message": "Pansou引擎尚未初始化",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"list":     list,
+			"priority": []string{pansouConfig.SourceEnv, pansouConfig.SourceDB, pansouConfig.SourceYAML, pansouConfig.SourceDefault},
+		},
+	})
+}
+
+// ReloadStatus 获取最近一次配置变更（配置文件重载或系统配置修改）的处理结果
+func (h *SystemConfigHandler) ReloadStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"last_reload": config.LastReload(),
+			"log_level":   logger.GetLevel(),
+		},
+	})
+}
+
+// Reload 立即重新加载配置文件，校验失败时保留原配置
+func (h *SystemConfigHandler) Reload(c *gin.Context) {
+	status := config.ReloadFile(config.DefaultConfigPath)
+	if !status.Success {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    500,
+			"message": "重新加载失败",
+			"data":    status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "重新加载成功",
+		"data":    status,
+	})
+}
+
+// configNames 根据ID查询配置名称
+func (h *SystemConfigHandler) configNames(ctx context.Context, ids []int) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if conf, err := h.repo.GetByID(ctx, id); err == nil {
+			names = append(names, conf.Name)
+		}
+	}
+	return names
+}
+
+// publishChange 通知系统配置变更，Pansou引擎、网盘账号池等订阅者立即应用
+func (h *SystemConfigHandler) publishChange(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	status := config.Publish(config.ChangeEvent{Source: config.ChangeSourceDB, Names: names})
+	if !status.Success {
+		logger.Warn("⚠️ 系统配置变更应用失败", zap.Strings("names", names), zap.Strings("errors", status.Errors))
+	}
+}
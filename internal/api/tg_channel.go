@@ -0,0 +1,180 @@
+﻿package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+	"huoxing-search/internal/service"
+)
+
+// TGChannelHandler TG频道管理处理器
+type TGChannelHandler struct {
+	channelService service.TGChannelService
+}
+
+// NewTGChannelHandler 创建TG频道管理处理器
+func NewTGChannelHandler() *TGChannelHandler {
+	return &TGChannelHandler{
+		channelService: service.NewTGChannelService(repository.NewTGChannelRepository()),
+	}
+}
+
+// List 获取频道列表及搜索统计
+// GET /api/admin/channels?status=1&tag=影视
+func (h *TGChannelHandler) List(c *gin.Context) {
+	status, err := strconv.Atoi(c.DefaultQuery("status", "-1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("状态参数错误"))
+		return
+	}
+
+	channels, err := h.channelService.List(c.Request.Context(), status, c.Query("tag"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取频道列表失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(gin.H{
+		"list":  channels,
+		"total": len(channels),
+	}))
+}
+
+// Groups 获取频道分组
+// GET /api/admin/channels/groups
+func (h *TGChannelHandler) Groups(c *gin.Context) {
+	groups, err := h.channelService.Groups(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取频道分组失败"))
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(groups))
+}
+
+// GetByID 获取频道详情
+func (h *TGChannelHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("无效的ID"))
+		return
+	}
+
+	channel, err := h.channelService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, model.NotFound("频道不存在"))
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(channel))
+}
+
+// Create 创建频道
+func (h *TGChannelHandler) Create(c *gin.Context) {
+	var channel model.TGChannel
+	if err := c.ShouldBindJSON(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	if err := h.channelService.Create(c.Request.Context(), &channel); err != nil {
+		h.handleError(c, err, "创建频道失败")
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(channel))
+}
+
+// Update 更新频道
+func (h *TGChannelHandler) Update(c *gin.Context) {
+	var channel model.TGChannel
+	if err := c.ShouldBindJSON(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if channel.ID == 0 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("ID不能为空"))
+		return
+	}
+
+	if err := h.channelService.Update(c.Request.Context(), &channel); err != nil {
+		h.handleError(c, err, "更新频道失败")
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(channel))
+}
+
+// Delete 删除频道
+func (h *TGChannelHandler) Delete(c *gin.Context) {
+	var req struct {
+		ID int `json:"id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	if err := h.channelService.Delete(c.Request.Context(), req.ID); err != nil {
+		h.handleError(c, err, "删除频道失败")
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("删除成功", nil))
+}
+
+// UpdateStatus 启用或禁用频道
+func (h *TGChannelHandler) UpdateStatus(c *gin.Context) {
+	var req struct {
+		ID     int `json:"id" binding:"required"`
+		Status int `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+	if req.Status != 0 && req.Status != 1 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("状态值无效"))
+		return
+	}
+
+	if err := h.channelService.UpdateStatus(c.Request.Context(), req.ID, req.Status); err != nil {
+		h.handleError(c, err, "更新频道状态失败")
+		return
+	}
+	c.JSON(http.StatusOK, model.SuccessWithMessage("状态已更新", nil))
+}
+
+// Import 批量导入频道
+// POST /api/admin/channels/import {"channels":"tgsearchers4\nhttps://t.me/xxx","tags":"影视","weight":0}
+// channels为空时导入当前CHANNELS配置中的频道
+func (h *TGChannelHandler) Import(c *gin.Context) {
+	var req struct {
+		Channels string `json:"channels"`
+		Tags     string `json:"tags"`
+		Weight   int    `json:"weight"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误"))
+		return
+	}
+
+	result, err := h.channelService.Import(c.Request.Context(), req.Channels, req.Tags, req.Weight)
+	if err != nil {
+		logger.Error("导入频道失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError("导入频道失败"))
+		return
+	}
+	c.JSON(http.StatusOK, model.Success(result))
+}
+
+// handleError 输出错误响应：频道名无效或重复时返回400
+func (h *TGChannelHandler) handleError(c *gin.Context, err error, message string) {
+	if errors.Is(err, service.ErrInvalidTGChannel) || errors.Is(err, service.ErrTGChannelExists) {
+		c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+		return
+	}
+	logger.Error(message, zap.Error(err))
+	c.JSON(http.StatusInternalServerError, model.ServerError(message+": "+err.Error()))
+}
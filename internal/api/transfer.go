@@ -1,6 +1,7 @@
 ﻿package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,12 +15,14 @@ import (
 // TransferHandler 转存处理器
 type TransferHandler struct {
 	transferService service.TransferService
+	cfg             *config.Config
 }
 
 // NewTransferHandler 创建转存处理器
 func NewTransferHandler(cfg *config.Config) *TransferHandler {
 	return &TransferHandler{
 		transferService: service.NewTransferService(cfg),
+		cfg:             cfg,
 	}
 }
 
@@ -42,7 +45,16 @@ func (h *TransferHandler) Transfer(c *gin.Context) {
 		zap.Int("max_count", req.MaxCount),
 	)
 
+	_, commit, ok := reserveAPIKeyTransfers(c, &req, service.TransferSettings(h.cfg).MaxSuccess)
+	if !ok {
+		return
+	}
 	resp, err := h.transferService.BatchTransfer(c.Request.Context(), &req)
+	if err != nil {
+		commit(0)
+	} else {
+		commit(resp.Success)
+	}
 	if err != nil {
 		logger.Error("转存失败", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, model.ServerError("转存失败: "+err.Error()))
@@ -71,7 +83,16 @@ func (h *TransferHandler) TransferAndSave(c *gin.Context) {
 		zap.Int("max_count", req.MaxCount),
 	)
 
+	_, commit, ok := reserveAPIKeyTransfers(c, &req, service.TransferSettings(h.cfg).MaxSuccess)
+	if !ok {
+		return
+	}
 	resp, err := h.transferService.TransferAndSave(c.Request.Context(), &req)
+	if err != nil {
+		commit(0)
+	} else {
+		commit(resp.Success)
+	}
 	if err != nil {
 		logger.Error("转存并保存失败", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, model.ServerError("转存并保存失败: "+err.Error()))
@@ -79,4 +100,35 @@ func (h *TransferHandler) TransferAndSave(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, model.Success(resp))
-}
\ No newline at end of file
+}
+// reserveAPIKeyTransfers 按请求携带的API Key预占转存额度，并将本次最大转存数量收紧到可用额度
+// 返回false时已写入错误响应；返回的提交函数需传入实际转存成功数量
+func reserveAPIKeyTransfers(c *gin.Context, req *model.TransferRequest, defaultMax int) (int, func(used int), bool) {
+	want := req.MaxCount
+	if want <= 0 {
+		want = defaultMax
+	}
+
+	grant, commit, err := service.ReserveAPIKeyTransfers(c.Request.Context(), want)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAPIKeyTransferDenied):
+			c.JSON(http.StatusForbidden, model.Forbidden(err.Error()))
+		case errors.Is(err, service.ErrAPIKeyQuotaExceeded):
+			c.JSON(http.StatusTooManyRequests, model.Error(http.StatusTooManyRequests, err.Error()))
+		default:
+			logger.Error("预占API Key转存额度失败", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, model.ServerError("预占转存额度失败"))
+		}
+		return 0, nil, false
+	}
+
+	if grant < want {
+		// 展示数量默认等于转存数量，额度不足时保持原展示数量，其余链接按原始链接返回
+		if req.MaxDisplay <= 0 {
+			req.MaxDisplay = want
+		}
+		req.MaxCount = grant
+	}
+	return grant, commit, true
+}
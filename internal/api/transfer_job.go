@@ -0,0 +1,176 @@
+﻿package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/service"
+)
+
+// TransferJobHandler 异步转存任务处理器
+type TransferJobHandler struct {
+	cfg *config.Config
+}
+
+// NewTransferJobHandler 创建异步转存任务处理器
+func NewTransferJobHandler(cfg *config.Config) *TransferJobHandler {
+	return &TransferJobHandler{
+		cfg: cfg,
+	}
+}
+
+// jobService 获取全局任务服务，未启动时返回错误响应
+func (h *TransferJobHandler) jobService(c *gin.Context) service.TransferJobService {
+	jobService := service.GetTransferJobService()
+	if jobService == nil {
+		c.JSON(http.StatusServiceUnavailable, model.Error(http.StatusServiceUnavailable, "转存任务服务未启动"))
+	}
+	return jobService
+}
+
+// Enqueue 创建转存任务
+func (h *TransferJobHandler) Enqueue(c *gin.Context) {
+	var req model.TransferJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.BadRequest("参数错误: "+err.Error()))
+		return
+	}
+
+	if len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, model.BadRequest("转存项不能为空"))
+		return
+	}
+
+	// 任务只能由创建者查询，无法识别调用方时创建的任务无法取回结果
+	if model.TransferJobOwner(c.Request.Context()) == "" {
+		c.JSON(http.StatusUnauthorized, model.Unauthorized("创建异步转存任务需要提供API Key，请在请求头 "+model.APIKeyHeader+" 中提供"))
+		return
+	}
+
+	jobService := h.jobService(c)
+	if jobService == nil {
+		return
+	}
+
+	// 异步任务在入队时按预占数量计入API Key用量
+	grant, commit, ok := reserveAPIKeyTransfers(c, &req.TransferRequest, service.TransferSettings(h.cfg).MaxSuccess)
+	if !ok {
+		return
+	}
+	job, created, err := jobService.Enqueue(c.Request.Context(), &req.TransferRequest, model.TransferJobSourceAPI, req.Keyword)
+	if err != nil {
+		commit(0)
+		if errors.Is(err, service.ErrTransferJobInProgress) {
+			c.JSON(http.StatusConflict, model.Error(http.StatusConflict, err.Error()))
+			return
+		}
+		logger.Error("创建转存任务失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+	// 复用已有任务时不重复计入用量
+	if !created {
+		grant = 0
+	}
+	commit(grant)
+
+	c.JSON(http.StatusOK, model.Success(transferJobView(job)))
+}
+
+// Get 查询转存任务状态及结果
+func (h *TransferJobHandler) Get(c *gin.Context) {
+	jobService := h.jobService(c)
+	if jobService == nil {
+		return
+	}
+
+	job, err := jobService.Get(c.Request.Context(), c.Param("job_no"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Success(transferJobView(job)))
+}
+
+// Cancel 取消转存任务
+func (h *TransferJobHandler) Cancel(c *gin.Context) {
+	jobService := h.jobService(c)
+	if jobService == nil {
+		return
+	}
+
+	job, err := jobService.Cancel(c.Request.Context(), c.Param("job_no"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuccessWithMessage("任务已取消", transferJobView(job)))
+}
+
+// List 获取转存任务列表（管理员）
+func (h *TransferJobHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status := c.Query("status")
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	jobService := h.jobService(c)
+	if jobService == nil {
+		return
+	}
+
+	jobs, total, err := jobService.List(c.Request.Context(), page, pageSize, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ServerError("获取转存任务列表失败"))
+		return
+	}
+
+	list := make([]gin.H, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, transferJobView(job))
+	}
+
+	c.JSON(http.StatusOK, model.PageData(total, page, pageSize, list))
+}
+
+// handleError 根据错误类型返回对应响应
+func (h *TransferJobHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrTransferJobNotFound) {
+		c.JSON(http.StatusNotFound, model.NotFound(err.Error()))
+		return
+	}
+	c.JSON(http.StatusBadRequest, model.BadRequest(err.Error()))
+}
+
+// transferJobView 任务响应数据（附带解析后的转存结果）
+func transferJobView(job *model.TransferJob) gin.H {
+	return gin.H{
+		"job_no":       job.JobNo,
+		"status":       job.Status,
+		"pan_type":     job.PanType,
+		"source":       job.Source,
+		"keyword":      job.Keyword,
+		"error":        job.Error,
+		"attempts":     job.Attempts,
+		"max_attempts": job.MaxAttempts,
+		"next_run_at":  job.NextRunAt,
+		"started_at":   job.StartedAt,
+		"finished_at":  job.FinishedAt,
+		"create_time":  job.CreateTime,
+		"finished":     job.IsFinished(),
+		"result":       job.DecodeResult(),
+	}
+}
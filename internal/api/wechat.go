@@ -18,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	"huoxing-search/internal/middleware"
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/logger"
@@ -28,6 +29,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// wechatRateLimitedReply 微信用户请求过于频繁时的回复
+const wechatRateLimitedReply = "搜索太频繁了，请稍后再试~"
+
 // WechatHandler 微信处理器
 type WechatHandler struct {
 	configRepo     repository.ConfigRepository
@@ -171,9 +175,18 @@ func (h *WechatHandler) ChatbotCallback(c *gin.Context) {
 		return
 	}
 
+	// 按微信用户限流（回调均来自微信服务器，不能按IP限流）
+	allowed := middleware.AllowRateLimitIdentity(c, msg.UserID)
+
 	// ⚡ 立即响应微信服务器（<1秒），避免触发重试
 	c.JSON(http.StatusOK, gin.H{"code": 200})
 
+	if !allowed {
+		logger.Warn("微信用户请求过于频繁", zap.String("user_id", msg.UserID))
+		go h.sendChatbotMessage(msg, wechatRateLimitedReply, appID, token, encodingAESKey)
+		return
+	}
+
 	// 🚀 异步处理消息（在后台执行搜索和转存）
 	go func() {
 		defer func() {
@@ -572,6 +585,20 @@ func (h *WechatHandler) OfficialAccountCallback(c *gin.Context) {
 		return
 	}
 
+	// 按微信用户限流（回调均来自微信服务器，不能按IP限流）
+	if !middleware.AllowRateLimitIdentity(c, msg.FromUserName) {
+		logger.Warn("微信用户请求过于频繁", zap.String("openid", msg.FromUserName))
+		replyXML := fmt.Sprintf(`<xml>
+<ToUserName><![CDATA[%s]]></ToUserName>
+<FromUserName><![CDATA[%s]]></FromUserName>
+<CreateTime>%d</CreateTime>
+<MsgType><![CDATA[text]]></MsgType>
+<Content><![CDATA[%s]]></Content>
+</xml>`, msg.FromUserName, msg.ToUserName, time.Now().Unix(), wechatRateLimitedReply)
+		c.Data(http.StatusOK, "application/xml", []byte(replyXML))
+		return
+	}
+
 	// 创建搜索服务（公众号禁用转存，避免超过5秒响应限制）
 	cacheRepo := repository.NewCacheRepository()
 	searchService := service.NewSearchService(h.configRepo, cacheRepo, nil)
@@ -582,6 +609,8 @@ func (h *WechatHandler) OfficialAccountCallback(c *gin.Context) {
 		Keyword:  keyword,
 		PanType:  0, // 默认夸克
 		MaxCount: 10, // 微信公众号最多返回10条
+		Async:    true, // 转存交给后台任务，转存完成后再次搜索即可命中本地资源
+		Source:   model.TransferJobSourceWechat,
 	})
 
 	if err != nil {
@@ -634,6 +663,9 @@ func (h *WechatHandler) OfficialAccountCallback(c *gin.Context) {
 			}
 		}
 		replyContent += "\n\n步骤：点击上方链接-打开网盘-点立即查看-点右下角保存-打开文件-按文件名排序即可从第一集开始-自动-全集播放"
+		if results.JobNo != "" {
+			replyContent += "\n\n资源正在后台转存，稍后再次搜索可获取更稳定的链接"
+		}
 	}
 
 	// 构建回复XML
@@ -0,0 +1,104 @@
+﻿package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+)
+
+// ContextKeyAPIKey 上下文中保存当前请求API Key的键
+const ContextKeyAPIKey = "api_key"
+
+// APIKeyAuthenticator API Key校验接口（由 service.APIKeyService 实现）
+type APIKeyAuthenticator interface {
+	Required(ctx context.Context) bool
+	Authenticate(ctx context.Context, raw string) (*model.APIKey, error)
+	Allow(key *model.APIKey) bool
+	RecordRequest(key *model.APIKey)
+}
+
+// APIKeyMiddleware 开放接口密钥中间件
+// 携带密钥时校验密钥并按密钥限流、计数；未携带时仅在开启强制API Key后拒绝非本站页面发起的请求
+// 本站页面以 siteToken 签发的页面令牌识别，siteToken 为nil时强制API Key对所有请求生效
+func APIKeyMiddleware(auth APIKeyAuthenticator, siteToken *SiteToken) gin.HandlerFunc {
+	if auth == nil {
+		return func(c *gin.Context) {
+			withSiteSession(c, siteToken)
+			c.Next()
+		}
+	}
+
+	return func(c *gin.Context) {
+		raw := rawAPIKey(c)
+
+		ctx := c.Request.Context()
+		if raw == "" {
+			// 本站页面的请求携带渲染页面时签发、与会话Cookie绑定的页面令牌
+			if !withSiteSession(c, siteToken) && auth.Required(ctx) {
+				c.JSON(http.StatusUnauthorized, model.Unauthorized("缺少API Key，请在请求头 "+model.APIKeyHeader+" 中提供"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := auth.Authenticate(ctx, raw)
+		if err != nil {
+			logger.Error("校验API Key失败", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, model.ServerError("校验API Key失败"))
+			c.Abort()
+			return
+		}
+		if key == nil {
+			// 无效密钥按IP计入路由限流，避免不断更换随机密钥绕过限流
+			if !allowAPIKeyRateLimit(c) {
+				abortRateLimited(c)
+				return
+			}
+			c.JSON(http.StatusUnauthorized, model.Unauthorized("API Key无效、已禁用或已过期"))
+			c.Abort()
+			return
+		}
+
+		if !auth.Allow(key) {
+			logger.Warn("API Key请求被限流",
+				zap.Int("key_id", key.ID),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.JSON(http.StatusTooManyRequests, model.Response{
+				Code:    http.StatusTooManyRequests,
+				Message: "API Key请求过于频繁,请稍后再试",
+				Data:    nil,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyAPIKey, key)
+		if !allowAPIKeyRateLimit(c) {
+			abortRateLimited(c)
+			return
+		}
+
+		auth.RecordRequest(key)
+		c.Request = c.Request.WithContext(model.ContextWithAPIKey(ctx, key))
+		c.Next()
+	}
+}
+
+// withSiteSession 本站页面发起的请求将页面会话写入请求上下文（用于识别异步转存任务的创建者），返回是否为本站页面
+func withSiteSession(c *gin.Context, siteToken *SiteToken) bool {
+	if siteToken == nil {
+		return false
+	}
+	session, ok := siteToken.Session(c)
+	if ok {
+		c.Request = c.Request.WithContext(model.ContextWithSiteSession(c.Request.Context(), session))
+	}
+	return ok
+}
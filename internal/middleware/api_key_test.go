@@ -0,0 +1,145 @@
+﻿package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"huoxing-search/internal/model"
+)
+
+// stubAPIKeyAuth 只识别一个密钥的校验器，allowed次请求后开始限流
+type stubAPIKeyAuth struct {
+	required bool
+	key      *model.APIKey
+	allowed  int
+	recorded int
+}
+
+func (a *stubAPIKeyAuth) Required(ctx context.Context) bool { return a.required }
+
+func (a *stubAPIKeyAuth) Authenticate(ctx context.Context, raw string) (*model.APIKey, error) {
+	if raw == "hx_valid" {
+		return a.key, nil
+	}
+	return nil, nil
+}
+
+func (a *stubAPIKeyAuth) Allow(key *model.APIKey) bool {
+	a.allowed--
+	return a.allowed >= 0
+}
+
+func (a *stubAPIKeyAuth) RecordRequest(key *model.APIKey) { a.recorded++ }
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := &stubAPIKeyAuth{required: true, key: &model.APIKey{ID: 7}, allowed: 1}
+	siteToken := NewSiteToken("test-secret")
+
+	r := gin.New()
+	r.GET("/search", func(c *gin.Context) {
+		c.String(http.StatusOK, siteToken.Issue(c))
+	})
+	r.POST("/api/search", APIKeyMiddleware(auth, siteToken), func(c *gin.Context) {
+		if key := model.APIKeyFromContext(c.Request.Context()); key != nil {
+			c.String(http.StatusOK, "key")
+			return
+		}
+		if session := model.SiteSessionFromContext(c.Request.Context()); session != "" {
+			c.String(http.StatusOK, "site:"+session)
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	})
+	call := func(header map[string]string, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/search"+query, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(nil, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("强制API Key时未携带密钥状态码 = %d, want 401", w.Code)
+	}
+	if w := call(map[string]string{"Sec-Fetch-Site": "same-origin"}, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("伪造 Sec-Fetch-Site 状态码 = %d, want 401", w.Code)
+	}
+
+	page := httptest.NewRecorder()
+	r.ServeHTTP(page, httptest.NewRequest(http.MethodGet, "/search", nil))
+	cookie := page.Result().Cookies()[0]
+	session := cookie.Name + "=" + cookie.Value
+	token := page.Body.String()
+	if w := call(map[string]string{"Cookie": session, SiteTokenHeader: token}, ""); w.Code != http.StatusOK || w.Body.String() != "site:"+cookie.Value {
+		t.Errorf("本站页面请求 = %d %s, want 200 site:<会话>", w.Code, w.Body.String())
+	}
+	if w := call(map[string]string{SiteTokenHeader: token}, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("页面令牌缺少会话Cookie状态码 = %d, want 401", w.Code)
+	}
+	other := siteCookieFor(t, r)
+	if w := call(map[string]string{"Cookie": other, SiteTokenHeader: token}, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("页面令牌与会话不匹配状态码 = %d, want 401", w.Code)
+	}
+	if w := call(map[string]string{model.APIKeyHeader: "hx_wrong"}, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("无效密钥状态码 = %d, want 401", w.Code)
+	}
+	if w := call(nil, "?api_key=hx_valid"); w.Code != http.StatusOK || w.Body.String() != "key" {
+		t.Errorf("查询参数携带密钥 = %d %s, want 200 key", w.Code, w.Body.String())
+	}
+	if w := call(map[string]string{model.APIKeyHeader: "hx_valid"}, ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("超出密钥限流状态码 = %d, want 429", w.Code)
+	}
+	if auth.recorded != 1 {
+		t.Errorf("recorded = %d, want 1（被限流的请求不计数）", auth.recorded)
+	}
+
+	auth.required = false
+	if w := call(nil, ""); w.Code != http.StatusOK || w.Body.String() != "anonymous" {
+		t.Errorf("未强制API Key时匿名请求 = %d %s, want 200 anonymous", w.Code, w.Body.String())
+	}
+}
+
+// siteCookieFor 打开一次页面，返回新会话的Cookie
+func siteCookieFor(t *testing.T, r *gin.Engine) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("页面未下发会话Cookie")
+	}
+	return cookies[0].Name + "=" + cookies[0].Value
+}
+
+func TestSiteTokenExpired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	siteToken := NewSiteToken("test-secret")
+	siteToken.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	token := siteToken.Issue(c)
+	cookie := w.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodPost, "/api/search", nil)
+	req.AddCookie(cookie)
+	req.Header.Set(SiteTokenHeader, token)
+	c.Request = req
+	if !siteToken.Valid(c) {
+		t.Fatal("有效期内的页面令牌校验失败")
+	}
+
+	now = now.Add(siteTokenTTL + time.Second)
+	if siteToken.Valid(c) {
+		t.Error("过期的页面令牌不应通过校验")
+	}
+}
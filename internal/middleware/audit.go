@@ -0,0 +1,303 @@
+﻿package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	// auditMaxDataSize 请求/响应数据最多记录的字节数
+	auditMaxDataSize = 4096
+	// auditRedacted 敏感字段的替换值
+	auditRedacted = "******"
+	// adminPathPrefix 管理接口路径前缀
+	adminPathPrefix = "/api/admin/"
+	// apiPathPrefix 接口路径前缀
+	apiPathPrefix = "/api/"
+)
+
+// auditSensitiveKeys 字段名包含以下关键词时记录前脱敏
+var auditSensitiveKeys = []string{
+	"password", "passwd", "pwd_hash", "cookie", "token", "secret",
+	"api_key", "apikey", "authorization", "aes_key", "private_key", "credential",
+}
+
+// auditNameKeys 键值对形式的配置（如 {"name":"quark_cookie","value":"..."}）中表示配置名的字段，
+// 配置名是敏感字段时同级的 value 也要脱敏
+var auditNameKeys = []string{"name", "key"}
+
+// auditValueKey 键值对形式的配置中表示配置值的字段
+const auditValueKey = "value"
+
+// AuditMiddleware 管理员操作审计中间件（必须在AuthMiddleware之后使用）
+// 只记录修改类请求（非GET/HEAD/OPTIONS），日志异步写入qf_log，写入失败不影响请求
+func AuditMiddleware(logRepo repository.LogRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isReadMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		requestData := readAuditRequest(c)
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		entry := &model.OperationLog{
+			AdminID:      c.GetInt64("user_id"),
+			Username:     c.GetString("username"),
+			Action:       auditAction(c),
+			Module:       auditModule(c.Request.URL.Path),
+			Method:       c.Request.Method,
+			URL:          truncateAuditData(redactURL(c.Request.URL), 500),
+			IP:           c.ClientIP(),
+			UserAgent:    truncateAuditData(c.Request.UserAgent(), 500),
+			RequestData:  requestData,
+			ResponseData: truncateAuditData(redactAuditData(writer.body.Bytes(), c.Writer.Header().Get("Content-Type")), auditMaxDataSize),
+			Status:       auditStatus(c.Writer.Status(), writer.body.Bytes(), writer.truncated),
+			CreateTime:   time.Now().Unix(),
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := logRepo.Create(ctx, entry); err != nil {
+				logger.Warn("写入操作日志失败",
+					zap.String("action", entry.Action),
+					zap.Int64("admin_id", entry.AdminID),
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+}
+
+// auditResponseWriter 记录响应体（超过上限的部分只写出不记录）
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(data []byte) {
+	remaining := auditMaxDataSize*4 - w.body.Len()
+	if remaining <= 0 {
+		w.truncated = true
+		return
+	}
+	if len(data) > remaining {
+		data = data[:remaining]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
+
+// readAuditRequest 读取并还原请求体，返回脱敏后的记录内容
+func readAuditRequest(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	contentType := c.ContentType()
+	if strings.HasPrefix(contentType, "multipart/") {
+		// 文件上传只记录大小，不读取内容
+		return fmt.Sprintf("[%s %d bytes]", contentType, c.Request.ContentLength)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return truncateAuditData(redactAuditData(body, contentType), auditMaxDataSize)
+}
+
+// redactAuditData 对JSON或表单数据中的敏感字段脱敏，其他格式只记录大小
+func redactAuditData(data []byte, contentType string) string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return ""
+	}
+
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(data))
+		if err == nil {
+			return redactValues(values).Encode()
+		}
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(data, &parsed); err == nil {
+		redacted, err := json.Marshal(redactJSON(parsed))
+		if err == nil {
+			return string(redacted)
+		}
+	}
+
+	if strings.Contains(contentType, "json") || strings.HasPrefix(contentType, "text/") || contentType == "" {
+		// 无法解析的文本仍可能包含密钥，只记录大小
+		return fmt.Sprintf("[unparsed %d bytes]", len(data))
+	}
+	return fmt.Sprintf("[%s %d bytes]", contentType, len(data))
+}
+
+// redactJSON 递归脱敏JSON中的敏感字段
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		sensitiveValue := hasSensitiveAuditName(func(key string) string {
+			name, _ := v[key].(string)
+			return name
+		})
+		for key, item := range v {
+			if isSensitiveAuditKey(key) || (sensitiveValue && strings.EqualFold(key, auditValueKey)) {
+				if item != nil && item != "" {
+					v[key] = auditRedacted
+				}
+				continue
+			}
+			v[key] = redactJSON(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// redactValues 脱敏表单或查询参数中的敏感字段
+func redactValues(values url.Values) url.Values {
+	sensitiveValue := hasSensitiveAuditName(values.Get)
+	for key, items := range values {
+		if !isSensitiveAuditKey(key) && !(sensitiveValue && strings.EqualFold(key, auditValueKey)) {
+			continue
+		}
+		for i := range items {
+			if items[i] != "" {
+				items[i] = auditRedacted
+			}
+		}
+	}
+	return values
+}
+
+// redactURL 返回脱敏查询参数后的请求地址
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return u.Path
+	}
+	return u.Path + "?" + redactValues(values).Encode()
+}
+
+// hasSensitiveAuditName 键值对中的配置名（name/key 字段的值）是否为敏感字段
+func hasSensitiveAuditName(get func(key string) string) bool {
+	for _, key := range auditNameKeys {
+		if name := get(key); name != "" && isSensitiveAuditKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveAuditKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range auditSensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// adminModule 从请求路径提取后台模块名，如 /api/admin/netdisk/accounts/create -> netdisk，非后台路径返回空
+func adminModule(path string) string {
+	path = strings.TrimPrefix(path, adminPathPrefix)
+	if idx := strings.Index(path, "/"); idx != -1 {
+		path = path[:idx]
+	}
+	return path
+}
+
+// auditModule 审计日志的模块名，/api/admin 之外的接口（如 /api/sources）取 /api/ 后的第一段路径
+func auditModule(path string) string {
+	if strings.HasPrefix(path, adminPathPrefix) {
+		return adminModule(path)
+	}
+	return adminModule(strings.TrimPrefix(path, apiPathPrefix))
+}
+
+// auditAction 操作名称使用路由模板，如 netdisk/accounts/:id/test
+func auditAction(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	if strings.HasPrefix(route, adminPathPrefix) {
+		route = strings.TrimPrefix(route, adminPathPrefix)
+	} else {
+		route = strings.TrimPrefix(route, apiPathPrefix)
+	}
+	return truncateAuditData(route, 100)
+}
+
+// auditStatus 根据HTTP状态码和响应中的code判断操作是否成功
+func auditStatus(httpStatus int, body []byte, truncated bool) int {
+	if httpStatus >= http.StatusBadRequest {
+		return model.LogStatusFailed
+	}
+	if truncated {
+		return model.LogStatusSuccess
+	}
+
+	var resp struct {
+		Code *int `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Code != nil && *resp.Code != model.CodeSuccess && *resp.Code != 0 {
+		return model.LogStatusFailed
+	}
+	return model.LogStatusSuccess
+}
+
+// truncateAuditData 按字节截断（不截断多字节字符）
+func truncateAuditData(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && (s[cut]&0xC0) == 0x80 {
+		cut--
+	}
+	return s[:cut] + "...(truncated)"
+}
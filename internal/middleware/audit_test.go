@@ -0,0 +1,193 @@
+﻿package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"huoxing-search/internal/model"
+)
+
+func TestRedactAuditData(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "JSON敏感字段",
+			data:        `{"name":"quark","cookie":"__pus=abc","config":{"access_token":"t1","dir":"/a"}}`,
+			contentType: "application/json",
+			want:        `{"config":{"access_token":"******","dir":"/a"},"cookie":"******","name":"quark"}`,
+		},
+		{
+			name:        "JSON数组中的敏感字段",
+			data:        `[{"Password":"p1","username":"admin"}]`,
+			contentType: "application/json",
+			want:        `[{"Password":"******","username":"admin"}]`,
+		},
+		{
+			name:        "网盘账号凭据",
+			data:        `{"name":"主账号","credential":"refresh-token"}`,
+			contentType: "application/json",
+			want:        `{"credential":"******","name":"主账号"}`,
+		},
+		{
+			name:        "敏感配置项的值",
+			data:        `{"configs":[{"name":"quark_cookie","value":"__pus=abc"},{"name":"site_name","value":"火星"}]}`,
+			contentType: "application/json",
+			want:        `{"configs":[{"name":"quark_cookie","value":"******"},{"name":"site_name","value":"火星"}]}`,
+		},
+		{
+			name:        "表单中的敏感配置项",
+			data:        "key=wechat_token&value=abc",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "key=wechat_token&value=%2A%2A%2A%2A%2A%2A",
+		},
+		{
+			name:        "空值不替换",
+			data:        `{"token":""}`,
+			contentType: "application/json",
+			want:        `{"token":""}`,
+		},
+		{
+			name:        "表单敏感字段",
+			data:        "username=admin&password=123456",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "password=%2A%2A%2A%2A%2A%2A&username=admin",
+		},
+		{
+			name:        "无法解析的文本只记录大小",
+			data:        "token=abc",
+			contentType: "text/plain",
+			want:        "[unparsed 9 bytes]",
+		},
+		{
+			name:        "空请求体",
+			data:        "  ",
+			contentType: "application/json",
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAuditData([]byte(tt.data), tt.contentType); got != tt.want {
+				t.Errorf("redactAuditData() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpStatus int
+		body       string
+		want       int
+	}{
+		{"成功响应", http.StatusOK, `{"code":200,"message":"成功"}`, model.LogStatusSuccess},
+		{"业务错误码", http.StatusOK, `{"code":400,"message":"参数错误"}`, model.LogStatusFailed},
+		{"HTTP错误", http.StatusInternalServerError, `{"code":200}`, model.LogStatusFailed},
+		{"非JSON响应", http.StatusOK, "ok", model.LogStatusSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditStatus(tt.httpStatus, []byte(tt.body), false); got != tt.want {
+				t.Errorf("auditStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingLogRepository 将写入的日志发送到通道
+type recordingLogRepository struct {
+	logs chan *model.OperationLog
+}
+
+func (r *recordingLogRepository) Create(ctx context.Context, log *model.OperationLog) error {
+	r.logs <- log
+	return nil
+}
+
+func (r *recordingLogRepository) List(ctx context.Context, filter model.OperationLogFilter, page, pageSize int) ([]*model.OperationLog, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *recordingLogRepository) ListAll(ctx context.Context, filter model.OperationLogFilter, limit int) ([]*model.OperationLog, error) {
+	return nil, nil
+}
+
+func (r *recordingLogRepository) ListModules(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &recordingLogRepository{logs: make(chan *model.OperationLog, 1)}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(7))
+		c.Set("username", "admin")
+		c.Next()
+	}, AuditMiddleware(repo))
+	r.POST("/api/admin/netdisk/accounts/:id/test", func(c *gin.Context) {
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil || body["cookie"] != "secret-cookie" {
+			t.Errorf("请求体未还原: %v %v", body, err)
+		}
+		c.JSON(http.StatusOK, model.BadRequest("连接失败"))
+	})
+	r.GET("/api/admin/logs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, model.Success(nil))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/netdisk/accounts/3/test?token=abc", strings.NewReader(`{"cookie":"secret-cookie"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case log := <-repo.logs:
+		if log.AdminID != 7 || log.Username != "admin" {
+			t.Errorf("管理员信息 = %d/%s", log.AdminID, log.Username)
+		}
+		if log.Module != "netdisk" || log.Action != "netdisk/accounts/:id/test" {
+			t.Errorf("模块/操作 = %s/%s", log.Module, log.Action)
+		}
+		if strings.Contains(log.RequestData, "secret-cookie") || strings.Contains(log.URL, "abc") {
+			t.Errorf("敏感信息未脱敏: %s %s", log.RequestData, log.URL)
+		}
+		if log.Status != model.LogStatusFailed {
+			t.Errorf("Status = %d, want %d", log.Status, model.LogStatusFailed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未写入操作日志")
+	}
+
+	r.DELETE("/api/sources", func(c *gin.Context) {
+		c.JSON(http.StatusOK, model.Success(nil))
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/sources", strings.NewReader(`{"ids":[1]}`)))
+	select {
+	case log := <-repo.logs:
+		if log.Module != "sources" || log.Action != "sources" {
+			t.Errorf("后台之外接口的模块/操作 = %s/%s", log.Module, log.Action)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未写入资源删除的操作日志")
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/admin/logs", nil))
+	select {
+	case log := <-repo.logs:
+		t.Errorf("GET请求不应记录日志: %+v", log)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,7 +1,10 @@
 ﻿package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -10,19 +13,48 @@ import (
 	"huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/jwt"
 	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
 )
 
-// AuthMiddleware JWT认证中间件
+// AdminTokenCookie 后台登录token的cookie名（由前端登录后写入，仅 PageAuthMiddleware 读取）
+const AdminTokenCookie = "admin_token"
+
+// ContextKeyClaims 上下文中保存当前访问令牌声明的键（用于登出时吊销当前令牌）
+const ContextKeyClaims = "jwt_claims"
+
+// AuthMiddleware JWT认证中间件，只接受Authorization header中的token
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
-	expiration := cfg.JWT.Expiration
-	if expiration == 0 {
-		expiration = cfg.JWT.ExpireHours
-	}
-	jwtService := jwt.NewJWTService(cfg.JWT.Secret, expiration)
+	return authMiddleware(cfg, false)
+}
+
+// PageAuthMiddleware 后台页面认证中间件（仅用于浏览器直接打开的插件管理页）
+// 页面导航无法携带header，允许从cookie读取token；cookie认证的写请求必须来自同源页面，防止跨站请求伪造
+func PageAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return authMiddleware(cfg, true)
+}
+
+func authMiddleware(cfg *config.Config, allowCookie bool) gin.HandlerFunc {
+	jwtService := jwt.NewFromConfig(cfg.JWT)
+	tokenRepo := repository.NewTokenRepository()
 
 	return func(c *gin.Context) {
 		// 获取Authorization header
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && allowCookie {
+			if cookieToken, err := c.Cookie(AdminTokenCookie); err == nil && cookieToken != "" {
+				if !isReadMethod(c.Request.Method) && !isSameOriginRequest(c.Request) {
+					logger.Warn("拒绝跨站的cookie认证请求",
+						zap.String("method", c.Request.Method),
+						zap.String("path", c.Request.URL.Path),
+						zap.String("origin", c.GetHeader("Origin")),
+					)
+					c.JSON(http.StatusForbidden, model.Forbidden("跨站请求被拒绝"))
+					c.Abort()
+					return
+				}
+				authHeader = "Bearer " + cookieToken
+			}
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, model.Unauthorized("未提供认证token"))
 			c.Abort()
@@ -40,7 +72,7 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		token := parts[1]
 
 		// 验证token
-		claims, err := jwtService.ValidateToken(token)
+		claims, err := authenticate(c.Request.Context(), jwtService, tokenRepo, token)
 		if err != nil {
 			// 安全地截取token用于日志
 			tokenPreview := token
@@ -51,7 +83,11 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 				zap.Error(err),
 				zap.String("token", tokenPreview),
 			)
-			c.JSON(http.StatusUnauthorized, model.Unauthorized("token无效或已过期"))
+			message := "token无效或已过期"
+			if errors.Is(err, jwt.ErrTokenRevoked) || errors.Is(err, jwt.ErrUserTokensRevoked) {
+				message = "token已失效，请重新登录"
+			}
+			c.JSON(http.StatusUnauthorized, model.Unauthorized(message))
 			c.Abort()
 			return
 		}
@@ -60,13 +96,29 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set(ContextKeyClaims, claims)
 
 		c.Next()
 	}
 }
 
-// AdminMiddleware 管理员权限中间件 (必须在AuthMiddleware之后使用)
+// AdminMiddleware 后台权限中间件 (必须在AuthMiddleware之后使用)
+// 按角色权限表检查请求的模块和读写操作：/api/admin/ 下的接口按路径取模块，其余路由（如插件管理页）只允许超级管理员
 func AdminMiddleware() gin.HandlerFunc {
+	return permissionMiddleware(func(c *gin.Context) string {
+		return adminModule(c.Request.URL.Path)
+	})
+}
+
+// RequirePermission 按指定模块检查权限 (必须在AuthMiddleware之后使用，用于 /api/admin 之外的路由)
+func RequirePermission(module string) gin.HandlerFunc {
+	return permissionMiddleware(func(c *gin.Context) string {
+		return module
+	})
+}
+
+// permissionMiddleware GET/HEAD/OPTIONS 视为读操作，其余为写操作
+func permissionMiddleware(moduleOf func(c *gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
@@ -75,13 +127,17 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// role = 0 表示管理员
-		if roleInt, ok := role.(int); !ok || roleInt != 0 {
-			logger.Warn("非管理员尝试访问管理接口",
+		module := moduleOf(c)
+		write := !isReadMethod(c.Request.Method)
+		roleInt, ok := role.(int)
+		if !ok || !model.RoleCan(roleInt, module, write) {
+			logger.Warn("管理员无权访问接口",
 				zap.Any("user_id", c.GetInt64("user_id")),
 				zap.Any("role", role),
+				zap.String("module", module),
+				zap.String("method", c.Request.Method),
 			)
-			c.JSON(http.StatusForbidden, model.Forbidden("需要管理员权限"))
+			c.JSON(http.StatusForbidden, model.Forbidden("当前角色无权执行该操作"))
 			c.Abort()
 			return
 		}
@@ -90,13 +146,32 @@ func AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
+// isSameOriginRequest 请求是否由同源页面发起
+// 优先使用浏览器设置、脚本无法伪造的 Sec-Fetch-Site，旧浏览器退而比较 Origin/Referer 与请求Host，都缺失时视为跨站
+func isSameOriginRequest(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin"
+	}
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+	u, err := url.Parse(source)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// isReadMethod 是否为只读请求方法
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 // OptionalAuthMiddleware 可选认证中间件 (如果有token则验证,没有则跳过)
 func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
-	expiration := cfg.JWT.Expiration
-	if expiration == 0 {
-		expiration = cfg.JWT.ExpireHours
-	}
-	jwtService := jwt.NewJWTService(cfg.JWT.Secret, expiration)
+	jwtService := jwt.NewFromConfig(cfg.JWT)
+	tokenRepo := repository.NewTokenRepository()
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -112,14 +187,32 @@ func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := jwtService.ValidateToken(token)
+		claims, err := authenticate(c.Request.Context(), jwtService, tokenRepo, token)
 		if err == nil {
 			// token有效,存入上下文
 			c.Set("user_id", claims.UserID)
 			c.Set("username", claims.Username)
 			c.Set("role", claims.Role)
+			c.Set(ContextKeyClaims, claims)
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// authenticate 验证访问令牌并检查是否已被吊销
+// 吊销状态查询失败（如Redis故障）时放行，避免缓存不可用导致所有管理员被登出
+func authenticate(ctx context.Context, jwtService *jwt.JWTService, tokenRepo repository.TokenRepository, token string) (*jwt.Claims, error) {
+	claims, err := jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jwt.CheckRevoked(ctx, tokenRepo, claims); err != nil {
+		if errors.Is(err, jwt.ErrTokenRevoked) || errors.Is(err, jwt.ErrUserTokensRevoked) {
+			return nil, err
+		}
+		logger.Warn("查询token吊销状态失败，已放行", zap.Int64("user_id", claims.UserID), zap.Error(err))
+	}
+	return claims, nil
+}
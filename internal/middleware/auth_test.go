@@ -0,0 +1,177 @@
+﻿package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/jwt"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestAuthMiddlewareRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: "test-secret", Expiration: 24}}
+	jwtService := jwt.NewFromConfig(cfg.JWT)
+	tokenRepo := repository.NewTokenRepository()
+
+	r := gin.New()
+	r.GET("/api/admin/check", AuthMiddleware(cfg), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/check", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	pair, err := jwtService.GenerateTokenPair(201, "admin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := call(pair.AccessToken); code != http.StatusOK {
+		t.Fatalf("有效token状态码 = %d, want 200", code)
+	}
+	if code := call(pair.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("刷新令牌访问接口状态码 = %d, want 401", code)
+	}
+
+	claims, _ := jwtService.ValidateToken(pair.AccessToken)
+	if err := tokenRepo.RevokeToken(context.Background(), claims.ID, claims.RemainingTTL()); err != nil {
+		t.Fatal(err)
+	}
+	if code := call(pair.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("已吊销token状态码 = %d, want 401", code)
+	}
+
+	other, _ := jwtService.GenerateToken(202, "other", 0)
+	if err := tokenRepo.RevokeUserTokens(context.Background(), 202, jwtService.RefreshExpiration()); err != nil {
+		t.Fatal(err)
+	}
+	if code := call(other); code != http.StatusUnauthorized {
+		t.Errorf("用户整体吊销后状态码 = %d, want 401", code)
+	}
+}
+
+func TestPageAuthMiddlewareCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: "test-secret", Expiration: 24}}
+	token, err := jwt.NewFromConfig(cfg.JWT).GenerateToken(301, "admin", model.RoleSuperAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.Any("/admin/plugins/gying/admin", PageAuthMiddleware(cfg), ok)
+	r.Any("/api/admin/check", AuthMiddleware(cfg), ok)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"插件页面cookie访问", http.MethodGet, "/admin/plugins/gying/admin", nil, http.StatusOK},
+		{"同源页面提交", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Sec-Fetch-Site": "same-origin"}, http.StatusOK},
+		{"跨站页面提交", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"同站子域名提交", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		{"旧浏览器同源Origin", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Origin": "http://example.com"}, http.StatusOK},
+		{"旧浏览器跨站Origin", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Origin": "http://evil.com"}, http.StatusForbidden},
+		{"旧浏览器同源Referer", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Referer": "http://example.com/admin/plugins/gying/admin"}, http.StatusOK},
+		{"缺少来源信息的提交", http.MethodPost, "/admin/plugins/gying/admin", nil, http.StatusForbidden},
+		{"header认证不校验来源", http.MethodPost, "/admin/plugins/gying/admin", map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"接口不接受cookie", http.MethodGet, "/api/admin/check", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: AdminTokenCookie, Value: token})
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("状态码 = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminMiddlewarePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		role   int
+		method string
+		path   string
+		want   int
+	}{
+		{"超级管理员修改网盘账号", model.RoleSuperAdmin, http.MethodPost, "/api/admin/netdisk/accounts/update", http.StatusOK},
+		{"编辑删除资源", model.RoleEditor, http.MethodPost, "/api/admin/sources/delete", http.StatusOK},
+		{"编辑批量导入", model.RoleEditor, http.MethodPost, "/api/admin/batch-import", http.StatusOK},
+		{"编辑查看统计", model.RoleEditor, http.MethodGet, "/api/admin/stats/dashboard", http.StatusOK},
+		{"编辑修改网盘账号", model.RoleEditor, http.MethodPost, "/api/admin/netdisk/accounts/update", http.StatusForbidden},
+		{"编辑创建管理员", model.RoleEditor, http.MethodPost, "/api/admin/admins/create", http.StatusForbidden},
+		{"审计查看日志", model.RoleAuditor, http.MethodGet, "/api/admin/logs", http.StatusOK},
+		{"审计删除资源", model.RoleAuditor, http.MethodPost, "/api/admin/sources/delete", http.StatusForbidden},
+		{"审计查看网盘账号", model.RoleAuditor, http.MethodGet, "/api/admin/netdisk/accounts", http.StatusForbidden},
+		{"编辑访问插件管理页", model.RoleEditor, http.MethodGet, "/admin/plugins/gying", http.StatusForbidden},
+		{"零值角色创建管理员", 0, http.MethodPost, "/api/admin/admins/create", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("role", tt.role)
+				c.Next()
+			}, AdminMiddleware())
+			r.Handle(tt.method, tt.path, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("状态码 = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", model.RoleAuditor)
+		c.Next()
+	})
+	sources := r.Group("/api/sources", RequirePermission("sources"))
+	sources.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	sources.DELETE("", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for method, want := range map[string]int{http.MethodGet: http.StatusOK, http.MethodDelete: http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/api/sources", nil))
+		if w.Code != want {
+			t.Errorf("%s 状态码 = %d, want %d", method, w.Code, want)
+		}
+	}
+}
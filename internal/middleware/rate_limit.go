@@ -1,9 +1,11 @@
 ﻿package middleware
 
 import (
-	"fmt"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,123 +13,177 @@ import (
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/pkg/ratelimit"
 )
 
-// RateLimiter 限流器接口
-type RateLimiter interface {
-	Allow(key string) bool
-}
+// contextKeyIdentityLimiter 上下文中保存按用户限流函数的键（策略限流对象为openid时，由微信回调解析出用户后调用）
+const contextKeyIdentityLimiter = "rate_limit_identity"
+
+// contextKeyAPIKeyLimiter 上下文中保存按API Key限流函数的键（策略限流对象为api_key时，由API Key中间件校验密钥后调用）
+const contextKeyAPIKeyLimiter = "rate_limit_api_key"
 
-// TokenBucket 令牌桶限流器
-type TokenBucket struct {
-	rate       int           // 每秒生成的令牌数
-	capacity   int           // 桶容量
-	tokens     map[string]int // 当前令牌数
-	lastUpdate map[string]time.Time // 上次更新时间
-	mu         sync.RWMutex
+// rateLimitPolicy 解析后的路由限流策略
+type rateLimitPolicy struct {
+	name     string
+	paths    []string
+	methods  []string
+	identity string
+	limit    ratelimit.Limit
 }
 
-// NewTokenBucket 创建令牌桶限流器
-func NewTokenBucket(rate, capacity int) *TokenBucket {
-	tb := &TokenBucket{
-		rate:       rate,
-		capacity:   capacity,
-		tokens:     make(map[string]int),
-		lastUpdate: make(map[string]time.Time),
+// match 请求是否命中策略
+func (p *rateLimitPolicy) match(method, path string) bool {
+	if len(p.methods) > 0 {
+		matched := false
+		for _, m := range p.methods {
+			if strings.EqualFold(m, method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
 	}
-
-	// 定期清理过期的key
-	go tb.cleanup()
-
-	return tb
+	for _, pattern := range p.paths {
+		switch {
+		case strings.HasSuffix(pattern, "*"):
+			if strings.HasPrefix(path, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case strings.HasSuffix(pattern, "/"):
+			if strings.HasPrefix(path, pattern) {
+				return true
+			}
+		case path == pattern:
+			return true
+		}
+	}
+	return false
 }
 
-// Allow 检查是否允许请求
-func (tb *TokenBucket) Allow(key string) bool {
-	tb.mu.Lock()
-	defer tb.mu.Unlock()
-
-	now := time.Now()
-
-	// 初始化key
-	if _, exists := tb.tokens[key]; !exists {
-		tb.tokens[key] = tb.capacity
-		tb.lastUpdate[key] = now
+// buildRateLimitPolicies 将配置转换为限流策略，按配置顺序匹配
+func buildRateLimitPolicies(policies []config.RateLimitPolicy) []*rateLimitPolicy {
+	list := make([]*rateLimitPolicy, 0, len(policies))
+	for i, p := range policies {
+		name := p.Name
+		if name == "" {
+			name = "policy" + strconv.Itoa(i)
+		}
+		identity := strings.ToLower(p.Identity)
+		if identity == "" {
+			identity = config.RateLimitByIP
+		}
+		list = append(list, &rateLimitPolicy{
+			name:     name,
+			paths:    p.Paths,
+			methods:  p.Methods,
+			identity: identity,
+			limit:    ratelimit.Limit{Rate: p.Rate, Burst: p.Burst},
+		})
 	}
+	return list
+}
 
-	// 计算应该增加的令牌数
-	elapsed := now.Sub(tb.lastUpdate[key])
-	tokensToAdd := int(elapsed.Seconds() * float64(tb.rate))
+// rateLimitState 限流配置解析后的状态，配置文件热更新时整体替换
+type rateLimitState struct {
+	enabled       bool
+	redis         bool
+	defaultPolicy *rateLimitPolicy
+	policies      []*rateLimitPolicy
+	limiter       ratelimit.Limiter
+}
 
-	if tokensToAdd > 0 {
-		tb.tokens[key] = min(tb.tokens[key]+tokensToAdd, tb.capacity)
-		tb.lastUpdate[key] = now
+// newRateLimitState 解析限流配置，存储方式未变化时沿用原限流器以保留计数
+func newRateLimitState(cfg config.RateLimitConfig, prev *rateLimitState) *rateLimitState {
+	rate := cfg.Rate
+	if rate == 0 {
+		rate = cfg.RequestsPerSecond
 	}
-
-	// 检查是否有可用令牌
-	if tb.tokens[key] > 0 {
-		tb.tokens[key]--
-		return true
+	state := &rateLimitState{
+		enabled: cfg.Enabled,
+		redis:   cfg.Redis,
+		defaultPolicy: &rateLimitPolicy{
+			name:     "default",
+			identity: config.RateLimitByIP,
+			limit:    ratelimit.Limit{Rate: rate, Burst: cfg.Burst},
+		},
+		policies: buildRateLimitPolicies(cfg.GetPolicies()),
 	}
 
-	return false
-}
-
-// cleanup 定期清理过期的key (超过5分钟未访问)
-func (tb *TokenBucket) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		tb.mu.Lock()
-		now := time.Now()
-		for key, lastTime := range tb.lastUpdate {
-			if now.Sub(lastTime) > 5*time.Minute {
-				delete(tb.tokens, key)
-				delete(tb.lastUpdate, key)
-			}
-		}
-		tb.mu.Unlock()
+	switch {
+	case prev != nil && prev.limiter != nil && prev.redis == cfg.Redis:
+		state.limiter = prev.limiter
+	case cfg.Redis:
+		state.limiter = ratelimit.New()
+	default:
+		state.limiter = ratelimit.NewLocalLimiter()
 	}
+	return state
 }
 
 // RateLimitMiddleware 限流中间件
+// 按路由策略限流，未命中策略的请求按默认速率以IP限流；Redis可用时多实例共享计数
+// 修改配置文件中的 rate_limit 后立即生效
 func RateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
-	if !cfg.RateLimit.Enabled {
-		// 限流未启用,直接放行
-		return func(c *gin.Context) {
-			c.Next()
-		}
-	}
+	var state atomic.Pointer[rateLimitState]
+	state.Store(newRateLimitState(cfg.RateLimit, nil))
 
-	rate := int(cfg.RateLimit.Rate)
-	if rate == 0 {
-		rate = int(cfg.RateLimit.RequestsPerSecond)
-	}
-	limiter := NewTokenBucket(rate, cfg.RateLimit.Burst)
+	config.Subscribe("rate_limit", func(ev config.ChangeEvent) error {
+		if ev.Source != config.ChangeSourceFile || ev.Config == nil {
+			return nil
+		}
+		state.Store(newRateLimitState(ev.Config.RateLimit, state.Load()))
+		return nil
+	})
 
 	return func(c *gin.Context) {
-		// 使用IP作为限流key
-		key := c.ClientIP()
+		st := state.Load()
+		if !st.enabled {
+			// 限流未启用,直接放行
+			c.Next()
+			return
+		}
+		limiter := st.limiter
+
+		policy := st.defaultPolicy
+		for _, p := range st.policies {
+			if p.match(c.Request.Method, c.Request.URL.Path) {
+				policy = p
+				break
+			}
+		}
+		if policy.limit.Unlimited() {
+			c.Next()
+			return
+		}
 
-		// 如果用户已登录,使用用户ID作为key
-		if userID, exists := c.Get("user_id"); exists {
-			key = fmt.Sprintf("user_%v", userID)
+		// 微信回调来自微信服务器IP，只能在解析出用户后按用户限流
+		if policy.identity == config.RateLimitByOpenID {
+			c.Set(contextKeyIdentityLimiter, func(identity string) bool {
+				return allowRateLimit(c, limiter, policy, "openid:"+identity)
+			})
+			c.Next()
+			return
 		}
 
-		if !limiter.Allow(key) {
-			logger.Warn("请求被限流",
-				zap.String("ip", c.ClientIP()),
-				zap.String("path", c.Request.URL.Path),
-				zap.String("key", key),
-			)
-
-			c.JSON(http.StatusTooManyRequests, model.Response{
-				Code:    http.StatusTooManyRequests,
-				Message: "请求过于频繁,请稍后再试",
-				Data:    nil,
+		// 携带API Key的请求需等API Key中间件校验密钥后才能按密钥限流，未经校验的密钥不能作为限流对象
+		if policy.identity == config.RateLimitByAPIKey && rawAPIKey(c) != "" {
+			checked := false
+			c.Set(contextKeyAPIKeyLimiter, func() bool {
+				checked = true
+				return allowRateLimit(c, limiter, policy, rateLimitIdentity(c, policy.identity))
 			})
-			c.Abort()
+			c.Next()
+			if !checked {
+				// 接口未接入API Key校验，按IP补记本次请求，后续请求照常受限
+				allowRateLimit(c, limiter, policy, rateLimitIdentity(c, config.RateLimitByIP))
+			}
+			return
+		}
+
+		if !allowRateLimit(c, limiter, policy, rateLimitIdentity(c, policy.identity)) {
+			abortRateLimited(c)
 			return
 		}
 
@@ -135,25 +191,53 @@ func RateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-// IPRateLimitMiddleware IP限流中间件 (更严格的IP限流)
+// allowAPIKeyRateLimit 按已校验的API Key（无有效密钥时按IP）执行路由限流策略，由API Key中间件调用
+// 当前路由未配置按API Key限流时直接放行
+func allowAPIKeyRateLimit(c *gin.Context) bool {
+	value, ok := c.Get(contextKeyAPIKeyLimiter)
+	if !ok {
+		return true
+	}
+	allow, ok := value.(func() bool)
+	if !ok {
+		return true
+	}
+	c.Set(contextKeyAPIKeyLimiter, nil)
+	return allow()
+}
+
+// abortRateLimited 返回限流响应
+func abortRateLimited(c *gin.Context) {
+	c.JSON(http.StatusTooManyRequests, model.Response{
+		Code:    http.StatusTooManyRequests,
+		Message: "请求过于频繁,请稍后再试",
+		Data:    nil,
+	})
+	c.Abort()
+}
+
+// AllowRateLimitIdentity 按用户标识限流（如微信openid），当前路由未配置按用户限流时直接放行
+func AllowRateLimitIdentity(c *gin.Context, identity string) bool {
+	value, ok := c.Get(contextKeyIdentityLimiter)
+	if !ok {
+		return true
+	}
+	allow, ok := value.(func(string) bool)
+	return !ok || allow(identity)
+}
+
+// IPRateLimitMiddleware IP限流中间件 (更严格的IP限流，进程内计数)
 func IPRateLimitMiddleware(rate, burst int) gin.HandlerFunc {
-	limiter := NewTokenBucket(rate, burst)
+	limiter := ratelimit.NewLocalLimiter()
+	policy := &rateLimitPolicy{
+		name:     "ip",
+		identity: config.RateLimitByIP,
+		limit:    ratelimit.Limit{Rate: float64(rate), Burst: burst},
+	}
 
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		if !limiter.Allow(ip) {
-			logger.Warn("IP被限流",
-				zap.String("ip", ip),
-				zap.String("path", c.Request.URL.Path),
-			)
-
-			c.JSON(http.StatusTooManyRequests, model.Response{
-				Code:    http.StatusTooManyRequests,
-				Message: "请求过于频繁,请稍后再试",
-				Data:    nil,
-			})
-			c.Abort()
+		if !allowRateLimit(c, limiter, policy, rateLimitIdentity(c, policy.identity)) {
+			abortRateLimited(c)
 			return
 		}
 
@@ -161,10 +245,55 @@ func IPRateLimitMiddleware(rate, burst int) gin.HandlerFunc {
 	}
 }
 
-// min 辅助函数
-func min(a, b int) int {
-	if a < b {
-		return a
+// rateLimitIdentity 获取限流对象标识
+// 按API Key限流时只使用API Key中间件校验通过的密钥，未携带或无效的密钥按IP限流
+func rateLimitIdentity(c *gin.Context, identity string) string {
+	if identity == config.RateLimitByAPIKey {
+		if value, ok := c.Get(ContextKeyAPIKey); ok {
+			if key, ok := value.(*model.APIKey); ok && key != nil {
+				return "key:" + strconv.Itoa(key.ID)
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rawAPIKey 请求中携带的API Key明文（未校验）
+func rawAPIKey(c *gin.Context) string {
+	raw := c.GetHeader(model.APIKeyHeader)
+	if raw == "" {
+		raw = c.Query("api_key")
+	}
+	return raw
+}
+
+// allowRateLimit 消耗一次配额并写入限流响应头，限流器出错时放行
+func allowRateLimit(c *gin.Context, limiter ratelimit.Limiter, policy *rateLimitPolicy, identity string) bool {
+	result, err := limiter.Allow(c.Request.Context(), policy.name+":"+identity, policy.limit)
+	if err != nil {
+		logger.Warn("限流检查失败，放行请求", zap.Error(err))
+		return true
+	}
+
+	header := c.Writer.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	if result.Allowed {
+		return true
 	}
-	return b
-}
\ No newline at end of file
+
+	header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+	logger.Warn("请求被限流",
+		zap.String("ip", c.ClientIP()),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("policy", policy.name),
+		zap.String("key", identity),
+	)
+	return false
+}
+
+// ceilSeconds 向上取整的秒数
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,147 @@
+﻿package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+)
+
+// keyMapAuth 按明文查表的API Key校验器，不做密钥级限流
+type keyMapAuth map[string]*model.APIKey
+
+func (a keyMapAuth) Required(ctx context.Context) bool { return false }
+
+func (a keyMapAuth) Authenticate(ctx context.Context, raw string) (*model.APIKey, error) {
+	return a[raw], nil
+}
+
+func (a keyMapAuth) Allow(key *model.APIKey) bool { return true }
+
+func (a keyMapAuth) RecordRequest(key *model.APIKey) {}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RateLimit = config.RateLimitConfig{
+		Enabled: true,
+		Rate:    100,
+		Burst:   100,
+		Policies: []config.RateLimitPolicy{
+			{Name: "probe", Paths: []string{"/api/health"}},
+			{Name: "search", Paths: []string{"/api/search"}, Methods: []string{"POST"}, Identity: config.RateLimitByAPIKey, Rate: 0.01, Burst: 1},
+			{Name: "wechat", Paths: []string{"/api/wechat/"}, Identity: config.RateLimitByOpenID, Rate: 0.01, Burst: 1},
+		},
+	}
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(cfg))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/api/health", ok)
+	auth := keyMapAuth{"hx_a": {ID: 1}, "hx_b": {ID: 2}}
+	r.POST("/api/search", APIKeyMiddleware(auth, nil), ok)
+	r.GET("/api/search/hot", ok)
+	r.POST("/api/wechat/callback", func(c *gin.Context) {
+		if !AllowRateLimitIdentity(c, c.Query("openid")) {
+			c.String(http.StatusOK, "limited")
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	call := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := call(http.MethodGet, "/api/health", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("探针接口被限流: %d %v", w.Code, w.Header())
+		}
+	}
+
+	w := call(http.MethodPost, "/api/search", "hx_a")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("首次搜索 = %d %v", w.Code, w.Header())
+	}
+	w = call(http.MethodPost, "/api/search", "hx_a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("超出限制 = %d, Retry-After=%q, want 429", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := call(http.MethodPost, "/api/search", "hx_b"); w.Code != http.StatusOK {
+		t.Errorf("不同API Key共享了配额: %d", w.Code)
+	}
+
+	// 无效密钥按IP限流，更换随机密钥不能获得新的配额
+	if w := call(http.MethodPost, "/api/search", "hx_random1"); w.Code != http.StatusUnauthorized {
+		t.Errorf("首个无效密钥 = %d, want 401", w.Code)
+	}
+	if w := call(http.MethodPost, "/api/search", "hx_random2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("更换无效密钥 = %d, want 429", w.Code)
+	}
+	if w := call(http.MethodPost, "/api/search", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("无效密钥与匿名请求应共享IP配额: %d", w.Code)
+	}
+
+	// 精确路径不匹配子路径，改按默认策略限流
+	if w := call(http.MethodGet, "/api/search/hot", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("子路径 = %d %v, want 默认策略", w.Code, w.Header())
+	}
+
+	// 按openid限流由回调处理函数触发
+	if w := call(http.MethodPost, "/api/wechat/callback?openid=u1", ""); w.Body.String() != "ok" {
+		t.Errorf("首次微信消息 = %q", w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/wechat/callback?openid=u1", ""); w.Body.String() != "limited" {
+		t.Errorf("同一用户超出限制 = %q, want limited", w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/wechat/callback?openid=u2", ""); w.Body.String() != "ok" {
+		t.Errorf("其他用户 = %q, want ok", w.Body.String())
+	}
+}
+
+func TestRateLimitMiddlewareReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RateLimit = config.RateLimitConfig{Enabled: false, Redis: false}
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(cfg))
+	r.GET("/api/search/hot", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	call := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/search/hot", nil))
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := call(); code != http.StatusOK {
+			t.Fatalf("限流未启用时被限流: %d", code)
+		}
+	}
+
+	// 配置文件修改后无需重建路由即生效
+	next := &config.Config{}
+	next.RateLimit = config.RateLimitConfig{Enabled: true, Rate: 0.01, Burst: 1, Policies: []config.RateLimitPolicy{}}
+	config.Publish(config.ChangeEvent{Source: config.ChangeSourceFile, Config: next})
+	if code := call(); code != http.StatusOK {
+		t.Fatalf("启用限流后首次请求 = %d", code)
+	}
+	if code := call(); code != http.StatusTooManyRequests {
+		t.Errorf("启用限流后超出限制 = %d, want 429", code)
+	}
+
+	// 系统配置变更不影响限流
+	config.Publish(config.ChangeEvent{Source: config.ChangeSourceDB, Names: []string{"site_name"}})
+	if code := call(); code != http.StatusTooManyRequests {
+		t.Errorf("系统配置变更后限流状态被重置: %d", code)
+	}
+}
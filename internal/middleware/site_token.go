@@ -0,0 +1,100 @@
+﻿package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SiteTokenHeader 本站页面调用开放接口时携带页面令牌的请求头
+	SiteTokenHeader = "X-Site-Token"
+	// siteSessionCookie 页面会话Cookie（HttpOnly），页面令牌与之绑定
+	siteSessionCookie = "site_session"
+	// siteTokenTTL 页面令牌有效期，过期后刷新页面即可获取新令牌
+	siteTokenTTL = 12 * time.Hour
+)
+
+// SiteToken 本站页面令牌
+// 渲染前台页面时签发与会话Cookie绑定的令牌，页面脚本通过请求头回传；
+// 开启强制API Key后，只有同时携带会话Cookie和有效令牌的请求才视为本站页面发起
+type SiteToken struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewSiteToken 创建页面令牌签发器
+func NewSiteToken(secret string) *SiteToken {
+	return &SiteToken{
+		secret: []byte("site-token:" + secret),
+		ttl:    siteTokenTTL,
+		now:    time.Now,
+	}
+}
+
+// Issue 签发页面令牌，会话Cookie不存在时一并下发
+func (s *SiteToken) Issue(c *gin.Context) string {
+	session, err := c.Cookie(siteSessionCookie)
+	if err != nil || !validSiteSession(session) {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return ""
+		}
+		session = hex.EncodeToString(buf)
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie(siteSessionCookie, session, 0, "/", "", c.Request.TLS != nil, true)
+	}
+
+	expires := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+	return expires + "." + s.sign(session, expires)
+}
+
+// Valid 请求是否携带与会话Cookie匹配且未过期的页面令牌
+func (s *SiteToken) Valid(c *gin.Context) bool {
+	_, ok := s.Session(c)
+	return ok
+}
+
+// Session 页面令牌有效时返回绑定的会话标识
+func (s *SiteToken) Session(c *gin.Context) (string, bool) {
+	session, err := c.Cookie(siteSessionCookie)
+	if err != nil || !validSiteSession(session) {
+		return "", false
+	}
+
+	expires, signature, ok := strings.Cut(c.GetHeader(SiteTokenHeader), ".")
+	if !ok {
+		return "", false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(session, expires))) {
+		return "", false
+	}
+	return session, true
+}
+
+func (s *SiteToken) sign(session, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(session + "." + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSiteSession 会话标识为32位十六进制
+func validSiteSession(session string) bool {
+	if len(session) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(session)
+	return err == nil
+}
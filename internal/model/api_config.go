@@ -17,6 +17,11 @@ type APIConfig struct {
 	FixedParams string `gorm:"column:fixed_params;type:text" json:"fixed_params"`
 	Headers     string `gorm:"column:headers;type:text" json:"headers"`
 	FieldMap    string `gorm:"column:field_map;type:text" json:"field_map"`
+	HTMLItem    string `gorm:"column:html_item;type:varchar(255)" json:"html_item"`   // HTML列表项选择器
+	HTMLTitle   string `gorm:"column:html_title;type:varchar(255)" json:"html_title"` // 标题选择器（项内）
+	HTMLURL     string `gorm:"column:html_url;type:varchar(255)" json:"html_url"`     // 链接选择器（项内），详情页模式下为详情页链接
+	HTMLType    int    `gorm:"column:html_type;type:tinyint;default:0" json:"html_type"`
+	HTMLURL2    string `gorm:"column:html_url2;type:varchar(255)" json:"html_url2"` // 详情页中的网盘链接选择器
 	Count       int    `gorm:"column:count;type:int;default:0" json:"count"`
 	Weight      int    `gorm:"column:weight;type:int;default:0" json:"weight"`
 	Status      int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
@@ -24,6 +29,30 @@ type APIConfig struct {
 	UpdateTime  int64  `gorm:"column:update_time;not null" json:"update_time"`
 }
 
+// 自定义接口类型
+const (
+	APITypeAPI  = "api"
+	APITypeHTML = "html"
+	APITypeTG   = "tg"
+)
+
+// HTML抓取模式（html_type）
+const (
+	HTMLTypeList   = 0 // 列表页直接包含网盘链接
+	HTMLTypeDetail = 1 // 列表页链接指向详情页，需进入详情页用html_url2提取网盘链接
+)
+
+// APITestResult 自定义接口测试结果
+type APITestResult struct {
+	RequestURL string         `json:"request_url"`
+	StatusCode int            `json:"status_code"`
+	Mode       string         `json:"mode"`       // 解析方式: json/html/html_selector
+	ItemCount  int            `json:"item_count"` // 解析出的原始条目数（含无链接的条目）
+	Items      []SearchResult `json:"items"`
+	Errors     []string       `json:"errors"`
+	ElapsedMs  int64          `json:"elapsed_ms"`
+}
+
 // TableName 指定表名
 func (APIConfig) TableName() string {
 	return "qf_api_list"
@@ -41,4 +70,4 @@ func (a *APIConfig) BeforeCreate(tx *gorm.DB) error {
 func (a *APIConfig) BeforeUpdate(tx *gorm.DB) error {
 	a.UpdateTime = time.Now().Unix()
 	return nil
-}
\ No newline at end of file
+}
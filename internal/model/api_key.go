@@ -0,0 +1,109 @@
+﻿package model
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyHeader 调用方传递API Key的请求头（也可使用查询参数 api_key）
+const APIKeyHeader = "X-API-Key"
+
+// APIKey 开放接口密钥（用于 /api/search、/api/transfer 的第三方调用）
+type APIKey struct {
+	ID                 int    `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	Name               string `gorm:"column:name;type:varchar(100);not null" json:"name"`
+	KeyPrefix          string `gorm:"column:key_prefix;type:varchar(16)" json:"key_prefix"`               // 密钥前缀，用于在列表中识别
+	KeyHash            string `gorm:"column:key_hash;type:char(64);uniqueIndex" json:"-"`                 // 密钥SHA-256，明文只在创建时返回一次
+	RateLimit          int    `gorm:"column:rate_limit;default:0" json:"rate_limit"`                      // 每分钟最大请求数，0表示不限制
+	DailyTransferQuota int    `gorm:"column:daily_transfer_quota;default:0" json:"daily_transfer_quota"`  // 每日最大转存成功数，0表示不限制
+	AllowTransfer      int    `gorm:"column:allow_transfer;type:tinyint;default:1" json:"allow_transfer"` // 是否允许触发自动转存 0=否 1=是
+	Status             int    `gorm:"column:status;type:tinyint;default:1" json:"status"`                 // 0=禁用 1=启用
+	ExpireTime         int64  `gorm:"column:expire_time;default:0" json:"expire_time"`                    // 过期时间（Unix秒），0表示永不过期
+	Remark             string `gorm:"column:remark;type:varchar(255)" json:"remark"`
+	TotalRequests      int64  `gorm:"column:total_requests;default:0" json:"total_requests"`
+	TotalTransfers     int64  `gorm:"column:total_transfers;default:0" json:"total_transfers"`
+	LastUsedTime       int64  `gorm:"column:last_used_time;default:0" json:"last_used_time"`
+	CreateTime         int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime         int64  `gorm:"column:update_time;not null" json:"update_time"`
+
+	TodayRequests  int64 `gorm:"-" json:"today_requests"`  // 今日请求数（含未落库部分）
+	TodayTransfers int64 `gorm:"-" json:"today_transfers"` // 今日转存成功数（含未落库部分）
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "qf_api_key"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Unix()
+	k.CreateTime = now
+	k.UpdateTime = now
+	return nil
+}
+
+// BeforeUpdate GORM钩子:更新前
+func (k *APIKey) BeforeUpdate(tx *gorm.DB) error {
+	k.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// IsUsable 密钥在指定时间是否可用（启用且未过期）
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.Status == 1 && (k.ExpireTime == 0 || k.ExpireTime > now.Unix())
+}
+
+// CanTransfer 是否允许触发自动转存
+func (k *APIKey) CanTransfer() bool {
+	return k.AllowTransfer == 1
+}
+
+// APIKeyUsage API Key每日用量
+type APIKeyUsage struct {
+	ID        int64  `gorm:"primaryKey;column:id;autoIncrement" json:"-"`
+	KeyID     int    `gorm:"column:key_id;not null;uniqueIndex:uk_key_date" json:"key_id"`
+	Date      string `gorm:"column:date;type:char(10);not null;uniqueIndex:uk_key_date" json:"date"` // YYYY-MM-DD
+	Requests  int64  `gorm:"column:requests;default:0" json:"requests"`
+	Transfers int64  `gorm:"column:transfers;default:0" json:"transfers"` // 转存成功数
+}
+
+// TableName 指定表名
+func (APIKeyUsage) TableName() string {
+	return "qf_api_key_usage"
+}
+
+// APIKeyCreateResponse 创建/重置API Key的响应，Key明文只返回这一次
+// 字段名 api_key 会被后台操作日志脱敏，明文不会写入日志
+type APIKeyCreateResponse struct {
+	*APIKey
+	Key string `json:"api_key"`
+}
+
+type apiKeyContextKey struct{}
+
+// ContextWithAPIKey 将请求携带的API Key写入上下文
+func ContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext 获取请求携带的API Key，匿名请求返回nil
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key
+}
+
+type siteSessionContextKey struct{}
+
+// ContextWithSiteSession 将本站页面的会话标识（已校验页面令牌）写入上下文
+func ContextWithSiteSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, siteSessionContextKey{}, session)
+}
+
+// SiteSessionFromContext 获取本站页面的会话标识，非本站页面发起的请求返回空字符串
+func SiteSessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(siteSessionContextKey{}).(string)
+	return session
+}
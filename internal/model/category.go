@@ -9,8 +9,8 @@ import (
 type Category struct {
 	CategoryID int    `gorm:"primaryKey;column:category_id;autoIncrement" json:"category_id"`
 	Name       string `gorm:"column:name;type:varchar(100);not null" json:"name"`
-	Keyword    string `gorm:"column:keyword;type:varchar(500)" json:"keyword"`
-	Sort       int    `gorm:"column:sort;type:int;default:0" json:"sort"`
+	Keyword    string `gorm:"column:keyword;type:varchar(500)" json:"keyword"`      // 自动分类规则，多个关键词用逗号/竖线/换行分隔，re:开头为正则
+	Sort       int    `gorm:"column:sort;type:int;default:0" json:"sort"`           // 排序值，越小越优先匹配
 	IsType     int    `gorm:"column:is_type;type:tinyint;default:0" json:"is_type"` // 0: 网络, 1: 本地
 	Status     int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
 	CreateTime int64  `gorm:"column:create_time;not null" json:"create_time"`
@@ -34,4 +34,15 @@ func (c *Category) BeforeCreate(tx *gorm.DB) error {
 func (c *Category) BeforeUpdate(tx *gorm.DB) error {
 	c.UpdateTime = time.Now().Unix()
 	return nil
-}
\ No newline at end of file
+}
+
+// CategoryReclassifyProgress 资源重新分类任务进度
+type CategoryReclassifyProgress struct {
+	Running           bool   `json:"running"`
+	OnlyUncategorized bool   `json:"only_uncategorized"` // 只处理未分类资源
+	Scanned           int    `json:"scanned"`            // 已扫描资源数
+	Updated           int    `json:"updated"`            // 分类发生变化的资源数
+	StartTime         int64  `json:"start_time"`
+	EndTime           int64  `json:"end_time"`
+	Error             string `json:"error,omitempty"`
+}
@@ -63,6 +63,14 @@ const (
 	ConfPansouURL        = "pansou_url"
 	ConfPansouTimeout    = "pansou_timeout"
 	
+	// 链接有效性检测配置
+	ConfLinkCheckEnabled    = "link_check_enabled"
+	ConfLinkCheckTimeout    = "link_check_timeout"
+	ConfLinkRecheckInterval = "link_recheck_interval"
+	
+	// 开放接口配置
+	ConfAPIKeyRequired = "api_key_required"
+	
 	// 夸克网盘配置
 	ConfQuarkCookie   = "quark_cookie"
 	ConfQuarkSavePath = "quark_save_path"
@@ -86,6 +94,21 @@ const (
 	ConfXunleiToken    = "xunlei_token"
 	ConfXunleiSavePath = "xunlei_save_path"
 	
+	// 天翼云盘配置
+	ConfTianyiCookie   = "tianyi_cookie"
+	ConfTianyiSavePath = "tianyi_file"
+	
+	// 123网盘配置
+	ConfPan123Token    = "pan123_token"
+	ConfPan123SavePath = "pan123_file"
+	
+	// 115网盘配置
+	ConfPan115Cookie   = "pan115_cookie"
+	ConfPan115SavePath = "pan115_file"
+	
+	// 网盘账号池配置
+	ConfNetdiskAccountStrategy = "netdisk_account_strategy"
+	
 	// 微信对话平台配置
 	ConfWechatToken  = "wechat_token"
 	ConfWechatAesKey = "wechat_aes_key"
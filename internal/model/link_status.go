@@ -0,0 +1,12 @@
+﻿package model
+
+// LinkStatus 分享链接状态
+type LinkStatus string
+
+// 分享链接状态
+const (
+	LinkStatusUnknown      LinkStatus = "unknown"       // 无法判断（网盘未配置、接口异常等）
+	LinkStatusAlive        LinkStatus = "alive"         // 有效
+	LinkStatusDead         LinkStatus = "dead"          // 已失效（取消、过期、删除、违规）
+	LinkStatusNeedPassword LinkStatus = "need_password" // 需要提取码或提取码错误
+)
@@ -0,0 +1,52 @@
+﻿package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 操作日志状态
+const (
+	LogStatusFailed  = 0
+	LogStatusSuccess = 1
+)
+
+// OperationLog 管理员操作日志模型
+type OperationLog struct {
+	LogID        uint64 `gorm:"primaryKey;column:log_id;autoIncrement" json:"log_id"`
+	AdminID      int64  `gorm:"column:admin_id;index:idx_admin_id" json:"admin_id"`
+	Username     string `gorm:"column:username;type:varchar(50)" json:"username"`
+	Action       string `gorm:"column:action;type:varchar(100);not null" json:"action"`
+	Module       string `gorm:"column:module;type:varchar(50)" json:"module"`
+	Method       string `gorm:"column:method;type:varchar(10)" json:"method"`
+	URL          string `gorm:"column:url;type:varchar(500)" json:"url"`
+	IP           string `gorm:"column:ip;type:varchar(50)" json:"ip"`
+	UserAgent    string `gorm:"column:user_agent;type:varchar(500)" json:"user_agent"`
+	RequestData  string `gorm:"column:request_data;type:text" json:"request_data"`
+	ResponseData string `gorm:"column:response_data;type:text" json:"response_data"`
+	Status       int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
+	CreateTime   int64  `gorm:"column:create_time;not null;index:idx_create_time" json:"create_time"`
+}
+
+// TableName 指定表名
+func (OperationLog) TableName() string {
+	return "qf_log"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (l *OperationLog) BeforeCreate(tx *gorm.DB) error {
+	if l.CreateTime == 0 {
+		l.CreateTime = time.Now().Unix()
+	}
+	return nil
+}
+
+// OperationLogFilter 操作日志查询条件（零值表示不过滤，Status为-1表示不过滤）
+type OperationLogFilter struct {
+	AdminID   int64
+	Module    string
+	Status    int
+	StartTime int64
+	EndTime   int64
+}
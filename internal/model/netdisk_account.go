@@ -0,0 +1,68 @@
+﻿package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 网盘账号状态
+const (
+	NetdiskAccountDisabled = 0 // 手动禁用
+	NetdiskAccountEnabled  = 1 // 启用
+	NetdiskAccountAbnormal = 2 // 异常停用（连接测试失败或容量不足，健康检查恢复后自动启用）
+)
+
+// 网盘账号选择策略
+const (
+	AccountStrategyRoundRobin    = "round_robin"     // 轮询
+	AccountStrategyLeastUsed     = "least_used"      // 使用次数最少
+	AccountStrategyMostFreeSpace = "most_free_space" // 剩余空间最多
+)
+
+// NetdiskAccount 网盘账号模型（同一网盘类型可配置多个账号）
+type NetdiskAccount struct {
+	ID          int    `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	PanType     int    `gorm:"column:pan_type;type:tinyint;default:0" json:"pan_type"` // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
+	Name        string `gorm:"column:name;type:varchar(100);not null" json:"name"`
+	Credential  string `gorm:"column:credential;type:text" json:"credential"`           // Cookie或RefreshToken
+	SaveDir     string `gorm:"column:save_dir;type:varchar(255)" json:"save_dir"`       // 默认存储目录，留空使用网盘配置
+	SaveDirTime string `gorm:"column:save_dir_time;type:varchar(255)" json:"save_dir_time"` // 临时存储目录，留空使用网盘配置
+	Status      int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
+	UseCount    int64  `gorm:"column:use_count;default:0" json:"use_count"`
+	FailCount   int    `gorm:"column:fail_count;default:0" json:"fail_count"`
+	TotalSpace  int64  `gorm:"column:total_space;default:0" json:"total_space"` // 总容量(字节)，0表示未知
+	UsedSpace   int64  `gorm:"column:used_space;default:0" json:"used_space"`   // 已用容量(字节)
+	LastError   string `gorm:"column:last_error;type:varchar(500)" json:"last_error"`
+	LastUsedAt  int64  `gorm:"column:last_used_at;default:0" json:"last_used_at"`
+	LastCheckAt int64  `gorm:"column:last_check_at;default:0" json:"last_check_at"`
+	CreateTime  int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime  int64  `gorm:"column:update_time;not null" json:"update_time"`
+}
+
+// TableName 指定表名
+func (NetdiskAccount) TableName() string {
+	return "qf_netdisk_account"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (a *NetdiskAccount) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Unix()
+	a.CreateTime = now
+	a.UpdateTime = now
+	return nil
+}
+
+// BeforeUpdate GORM钩子:更新前
+func (a *NetdiskAccount) BeforeUpdate(tx *gorm.DB) error {
+	a.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// FreeSpace 剩余容量，容量未知时返回-1
+func (a *NetdiskAccount) FreeSpace() int64 {
+	if a.TotalSpace <= 0 {
+		return -1
+	}
+	return a.TotalSpace - a.UsedSpace
+}
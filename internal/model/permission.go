@@ -0,0 +1,107 @@
+﻿package model
+
+// 管理员角色（qf_admin.role）
+// 零值为权限最小的只读审计，未指定角色的账号和令牌不会获得额外权限
+const (
+	RoleAuditor    = 0 // 只读审计：查看后台数据，不能修改
+	RoleEditor     = 1 // 内容编辑：资源、分类、批量导入
+	RoleSuperAdmin = 2 // 超级管理员：全部权限
+)
+
+// 模块权限级别
+const (
+	PermissionNone  = 0
+	PermissionRead  = 1
+	PermissionWrite = 2 // 包含读权限
+)
+
+// RoleInfo 角色说明
+type RoleInfo struct {
+	Role        int            `json:"role"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Permissions map[string]int `json:"permissions"` // 模块 -> 权限级别，超级管理员为空表示全部
+}
+
+// roles 角色列表
+var roles = []RoleInfo{
+	{Role: RoleSuperAdmin, Name: "超级管理员", Description: "拥有全部后台权限，可管理管理员、网盘账号和系统配置"},
+	{Role: RoleEditor, Name: "内容编辑", Description: "只能管理资源、分类和批量导入，可查看统计数据"},
+	{Role: RoleAuditor, Name: "只读审计", Description: "可查看后台数据和操作日志，不能修改；不能查看网盘账号和系统配置"},
+}
+
+// rolePermissions 非超级管理员角色可访问的后台模块（/api/admin/ 后的第一段路径）
+var rolePermissions = map[int]map[string]int{
+	RoleEditor: {
+		"sources":      PermissionWrite,
+		"categories":   PermissionWrite,
+		"batch-import": PermissionWrite,
+		"stats":        PermissionRead,
+	},
+	// 网盘账号（netdisk、test）和系统配置（configs）包含Cookie、密钥等凭据，审计角色不可见
+	RoleAuditor: {
+		"sources":      PermissionRead,
+		"categories":   PermissionRead,
+		"batch-import": PermissionRead,
+		"stats":        PermissionRead,
+		"apis":         PermissionRead,
+		"admins":       PermissionRead,
+		"users":        PermissionRead,
+		"plugins":      PermissionRead,
+		"channels":     PermissionRead,
+		"transfer":     PermissionRead,
+		"api-keys":     PermissionRead,
+		"logs":         PermissionRead,
+	},
+}
+
+// Roles 获取全部角色说明
+func Roles() []RoleInfo {
+	list := make([]RoleInfo, len(roles))
+	for i, info := range roles {
+		info.Permissions = RolePermissions(info.Role)
+		list[i] = info
+	}
+	return list
+}
+
+// RolePermissions 角色的模块权限表，超级管理员返回空表示全部权限
+func RolePermissions(role int) map[string]int {
+	permissions := make(map[string]int, len(rolePermissions[role]))
+	for module, level := range rolePermissions[role] {
+		permissions[module] = level
+	}
+	return permissions
+}
+
+// IsValidRole 角色是否有效
+func IsValidRole(role int) bool {
+	for _, info := range roles {
+		if info.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleName 角色名称
+func RoleName(role int) string {
+	for _, info := range roles {
+		if info.Role == role {
+			return info.Name
+		}
+	}
+	return "未知角色"
+}
+
+// RoleCan 判断角色对模块是否有读/写权限，超级管理员拥有全部权限
+func RoleCan(role int, module string, write bool) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	level := rolePermissions[role][module]
+	if write {
+		return level >= PermissionWrite
+	}
+	return level >= PermissionRead
+}
@@ -0,0 +1,30 @@
+﻿package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PluginSetting Pansou插件的运行时设置（管理后台修改，没有记录的插件使用默认值）
+type PluginSetting struct {
+	ID         int    `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	Name       string `gorm:"column:name;type:varchar(50);uniqueIndex" json:"name"`
+	Status     int    `gorm:"column:status;type:tinyint;default:1" json:"status"`     // 0=禁用 1=启用
+	Priority   int    `gorm:"column:priority;type:tinyint;default:0" json:"priority"` // 优先级覆盖1-4，0表示使用插件默认优先级
+	CreateTime int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime int64  `gorm:"column:update_time;not null" json:"update_time"`
+}
+
+// TableName 指定表名
+func (PluginSetting) TableName() string {
+	return "qf_plugin_setting"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (s *PluginSetting) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Unix()
+	s.CreateTime = now
+	s.UpdateTime = now
+	return nil
+}
@@ -107,9 +107,14 @@ func PageData(total int64, page, perPage int, data interface{}) Response {
 
 // SearchRequest 搜索请求
 type SearchRequest struct {
-	Keyword  string `json:"keyword" binding:"required"`
-	PanType  int    `json:"pan_type"` // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷
-	MaxCount int    `json:"max_count"` // 最大返回数量
+	Keyword    string `json:"keyword" binding:"required"`
+	PanType    int    `json:"pan_type"`    // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
+	MaxCount   int    `json:"max_count"`   // 最大返回数量
+	CategoryID int    `json:"category_id"` // 分类ID，大于0时只返回该分类的资源
+	Async      bool   `json:"async"`       // 异步转存：立即返回原始链接，转存在后台任务中执行
+	Source     string `json:"-"`           // 请求来源(web/wechat/api)，用于记录转存任务
+
+	ChannelGroups []string `json:"channel_groups"` // TG频道分组，为空时搜索全部启用的频道
 }
 
 // SearchResponse 搜索响应
@@ -117,12 +122,13 @@ type SearchResponse struct {
 	Total   int            `json:"total"`
 	Results []SearchResult `json:"results"`
 	Message string         `json:"message,omitempty"`
+	JobNo   string         `json:"job_no,omitempty"` // 异步转存任务编号
 }
 
 // TransferRequest 转存请求
 type TransferRequest struct {
 	Items       []SearchResult `json:"items" binding:"required"`
-	PanType     int            `json:"pan_type"`     // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷
+	PanType     int            `json:"pan_type"`     // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
 	MaxCount    int            `json:"max_count"`    // 最多转存成功数量
 	MaxDisplay  int            `json:"max_display"`  // 最大展示数量(转存+未转存)
 	ExpiredType int            `json:"expired_type"` // 过期类型: 0=永久 2=临时2天
@@ -2,22 +2,39 @@
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	"gorm.io/gorm"
 )
 
 // Source 资源模型
 type Source struct {
-	SourceID   uint64 `gorm:"primaryKey;column:source_id;autoIncrement" json:"source_id"`
-	Title      string `gorm:"column:title;type:varchar(255);not null" json:"title"`
-	URL        string `gorm:"column:url;type:varchar(500);not null" json:"url"`
-	Content    string `gorm:"column:content;type:varchar(500)" json:"content,omitempty"`
-	IsType     int    `gorm:"column:is_type;type:tinyint;default:0" json:"is_type"` // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷
-	Fid        string `gorm:"column:fid;type:varchar(500)" json:"fid,omitempty"`
-	IsTime     int    `gorm:"column:is_time;type:tinyint;default:0" json:"is_time"` // 是否临时:0否,1是
-	Status     int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
-	CreateTime int64  `gorm:"column:create_time;not null" json:"create_time"`
-	UpdateTime int64  `gorm:"column:update_time;not null" json:"update_time"`
+	SourceID      uint64 `gorm:"primaryKey;column:source_id;autoIncrement" json:"source_id"`
+	Title         string `gorm:"column:title;type:varchar(255);not null" json:"title"`
+	URL           string `gorm:"column:url;type:varchar(500);not null" json:"url"`
+	Content       string `gorm:"column:content;type:varchar(500)" json:"content,omitempty"`
+	Password      string `gorm:"column:password;type:varchar(50)" json:"password,omitempty"` // 提取码
+	IsType        int    `gorm:"column:is_type;type:tinyint;default:0" json:"is_type"`       // 0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
+	Fid           string `gorm:"column:fid;type:varchar(500)" json:"fid,omitempty"`
+	Size          int64  `gorm:"column:size" json:"size"`                                           // 文件大小(字节)
+	SourceName    string `gorm:"column:source_name;type:varchar(100)" json:"source_name,omitempty"` // 原始来源(插件/频道/接口名称)
+	SourceTime    string `gorm:"column:source_time;type:varchar(50)" json:"source_time,omitempty"`  // 原始资源时间
+	IsTime        int    `gorm:"column:is_time;type:tinyint;default:0" json:"is_time"`              // 是否临时:0否,1是
+	Status        int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
+	ViewCount     int    `gorm:"column:view_count;default:0" json:"view_count"`
+	TransferCount int    `gorm:"column:transfer_count;default:0" json:"transfer_count"`
+	CategoryID    int    `gorm:"column:category_id" json:"category_id"` // 0表示未分类
+	CreateTime    int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime    int64  `gorm:"column:update_time;not null" json:"update_time"`
+}
+
+// SourceFilter 资源列表筛选条件（IsType/Status/CategoryID为-1表示不筛选，SourceName为空表示不筛选）
+type SourceFilter struct {
+	IsType     int
+	Status     int
+	CategoryID int
+	SourceName string
 }
 
 // TableName 指定表名
@@ -41,6 +58,7 @@ func (s *Source) BeforeUpdate(tx *gorm.DB) error {
 
 // SearchResult 搜索结果
 type SearchResult struct {
+	SourceID      uint64 `json:"source_id,omitempty"` // 本地资源ID（仅本地结果）
 	Title         string `json:"title"`
 	URL           string `json:"url"`
 	Password      string `json:"password,omitempty"`
@@ -50,6 +68,7 @@ type SearchResult struct {
 	Time          string `json:"time,omitempty"`
 	Content       string `json:"content,omitempty"` // 原始链接
 	IsTransferred bool   `json:"is_transferred"`    // 是否已转存
+	LinkStatus    string `json:"link_status,omitempty"` // 链接有效性检测结果
 }
 
 // TransferResult 转存结果
@@ -74,6 +93,9 @@ const (
 	PanTypeAliyun  = 3 // 阿里
 	PanTypeUC      = 4 // UC
 	PanTypeXunlei  = 5 // 迅雷
+	PanTypeTianyi  = 6 // 天翼
+	PanType123     = 7 // 123网盘
+	PanType115     = 8 // 115网盘
 )
 
 // GetPanTypeName 获取网盘类型名称
@@ -84,6 +106,9 @@ func GetPanTypeName(panType int) string {
 		PanTypeAliyun: "阿里",
 		PanTypeUC:     "UC",
 		PanTypeXunlei: "迅雷",
+		PanTypeTianyi: "天翼",
+		PanType123:    "123",
+		PanType115:    "115",
 	}
 	if name, ok := names[panType]; ok {
 		return name
@@ -99,6 +124,9 @@ func GetCloudType(panType int) string {
 		PanTypeAliyun: "aliyun",
 		PanTypeUC:     "uc",
 		PanTypeXunlei: "xunlei",
+		PanTypeTianyi: "tianyi",
+		PanType123:    "123",
+		PanType115:    "115",
 	}
 	if cloudType, ok := cloudTypes[panType]; ok {
 		return cloudType
@@ -140,7 +168,7 @@ func (p *PansouResultRaw) ToSearchResult() SearchResult {
 		Password: p.Pwd,
 		Source:   p.Source,
 		PanType:  cloudTypeToPanType(p.CloudType),
-		Size:     formatSize(p.Size),
+		Size:     FormatSize(p.Size),
 		Time:     p.Time,
 		Content:  p.URL,
 	}
@@ -154,6 +182,9 @@ func cloudTypeToPanType(cloudType string) int {
 		"aliyun": PanTypeAliyun,
 		"uc":     PanTypeUC,
 		"xunlei": PanTypeXunlei,
+		"tianyi": PanTypeTianyi,
+		"123":    PanType123,
+		"115":    PanType115,
 	}
 	if panType, ok := typeMap[cloudType]; ok {
 		return panType
@@ -161,8 +192,39 @@ func cloudTypeToPanType(cloudType string) int {
 	return PanTypeQuark
 }
 
-// formatSize 格式化文件大小
-func formatSize(size int64) string {
+// ParseSize 解析格式化的文件大小（如 "1.5 GB"、"700MB"、"2G"），无法解析时返回0
+func ParseSize(size string) int64 {
+	size = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(size), " ", ""))
+	if size == "" {
+		return 0
+	}
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	factor := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSuffix(size, unit.suffix)
+			factor = unit.factor
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return int64(value * factor)
+}
+
+// FormatSize 格式化文件大小
+func FormatSize(size int64) string {
 	if size <= 0 {
 		return ""
 	}
@@ -0,0 +1,36 @@
+﻿package model
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"", 0},
+		{"512 B", 512},
+		{"1.5 KB", 1536},
+		{"700MB", 700 << 20},
+		{"1.00 GB", 1 << 30},
+		{"2g", 2 << 30},
+		{"1 T", 1 << 40},
+		{"1024", 1024},
+		{"未知", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			if got := ParseSize(tt.size); got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSizeRoundTrip(t *testing.T) {
+	for _, size := range []int64{2048, 5 << 20, 3 << 30} {
+		if got := ParseSize(FormatSize(size)); got != size {
+			t.Errorf("ParseSize(FormatSize(%d)) = %d", size, got)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+﻿package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TGChannel Pansou搜索的Telegram频道
+type TGChannel struct {
+	ID         int    `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	Name       string `gorm:"column:name;type:varchar(100);uniqueIndex" json:"name"` // 频道用户名，不含@和t.me前缀
+	Status     int    `gorm:"column:status;type:tinyint;default:1" json:"status"`    // 0=禁用 1=启用
+	Weight     int    `gorm:"column:weight;default:0" json:"weight"`                 // 权重，越大越先搜索
+	Tags       string `gorm:"column:tags;type:varchar(255)" json:"tags"`             // 分组标签，多个用逗号分隔，搜索时可按分组指定频道
+	Remark     string `gorm:"column:remark;type:varchar(255)" json:"remark"`
+	CreateTime int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime int64  `gorm:"column:update_time;not null" json:"update_time"`
+
+	Stats *TGChannelStats `gorm:"-" json:"stats,omitempty"` // 进程启动以来的搜索统计
+}
+
+// TGChannelStats 频道搜索统计
+type TGChannelStats struct {
+	Requests    int64   `json:"requests"`
+	Hits        int64   `json:"hits"` // 返回了结果的次数
+	Errors      int64   `json:"errors"`
+	Results     int64   `json:"results"`
+	AvgMs       float64 `json:"avg_ms"`       // 平均耗时(毫秒)
+	LastSuccess int64   `json:"last_success"` // 最近一次成功的时间，0表示启动以来未成功过
+	LastError   string  `json:"last_error"`
+}
+
+// TableName 指定表名
+func (TGChannel) TableName() string {
+	return "qf_tg_channel"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (c *TGChannel) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Unix()
+	c.CreateTime = now
+	c.UpdateTime = now
+	return nil
+}
+
+// BeforeUpdate GORM钩子:更新前
+func (c *TGChannel) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// TagList 分组标签列表（去空白、去重）
+func (c *TGChannel) TagList() []string {
+	return SplitTags(c.Tags)
+}
+
+// HasAnyTag 是否属于任一分组
+func (c *TGChannel) HasAnyTag(tags []string) bool {
+	for _, tag := range c.TagList() {
+		for _, want := range tags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SplitTags 拆分逗号分隔的标签（兼容中文逗号），去空白、去重
+func SplitTags(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' })
+	tags := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, tag := range fields {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
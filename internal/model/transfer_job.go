@@ -0,0 +1,113 @@
+﻿package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 转存任务状态
+const (
+	TransferJobQueued    = "queued"
+	TransferJobRunning   = "running"
+	TransferJobSucceeded = "succeeded"
+	TransferJobFailed    = "failed"
+	TransferJobCancelled = "cancelled"
+)
+
+// 转存任务来源
+const (
+	TransferJobSourceWeb    = "web"
+	TransferJobSourceWechat = "wechat"
+	TransferJobSourceAPI    = "api"
+)
+
+// TransferJob 异步转存任务模型
+type TransferJob struct {
+	ID          uint64 `gorm:"primaryKey;column:id;autoIncrement" json:"id"`
+	JobNo       string `gorm:"column:job_no;type:varchar(32);uniqueIndex;not null" json:"job_no"`
+	Status      string `gorm:"column:status;type:varchar(20);default:'queued'" json:"status"`
+	PanType     int    `gorm:"column:pan_type;type:tinyint;default:0" json:"pan_type"`
+	Source      string `gorm:"column:source;type:varchar(20)" json:"source"`
+	Keyword     string `gorm:"column:keyword;type:varchar(255)" json:"keyword"`
+	Owner       string `gorm:"column:owner;type:varchar(64)" json:"-"` // 创建者，见 TransferJobOwner
+	Request     string `gorm:"column:request;type:mediumtext" json:"-"`
+	Result      string `gorm:"column:result;type:mediumtext" json:"-"`
+	Error       string `gorm:"column:error;type:varchar(500)" json:"error,omitempty"`
+	Attempts    int    `gorm:"column:attempts;type:int;default:0" json:"attempts"`
+	MaxAttempts int    `gorm:"column:max_attempts;type:int;default:3" json:"max_attempts"`
+	NextRunAt   int64  `gorm:"column:next_run_at;default:0" json:"next_run_at"`
+	StartedAt   int64  `gorm:"column:started_at;default:0" json:"started_at"`
+	FinishedAt  int64  `gorm:"column:finished_at;default:0" json:"finished_at"`
+	CreateTime  int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime  int64  `gorm:"column:update_time;not null" json:"update_time"`
+}
+
+// TableName 指定表名
+func (TransferJob) TableName() string {
+	return "qf_transfer_job"
+}
+
+// BeforeCreate GORM钩子:创建前
+func (j *TransferJob) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().Unix()
+	j.CreateTime = now
+	j.UpdateTime = now
+	return nil
+}
+
+// BeforeUpdate GORM钩子:更新前
+func (j *TransferJob) BeforeUpdate(tx *gorm.DB) error {
+	j.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// TransferJobOwner 当前请求对应的任务创建者：携带API Key时为密钥，本站页面为页面会话（存哈希），其余为空
+// 开放接口只能查询和取消创建者与当前请求一致的任务，创建者为空的任务（如微信公众号触发）只能在后台查看
+func TransferJobOwner(ctx context.Context) string {
+	if key := APIKeyFromContext(ctx); key != nil {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+	if session := SiteSessionFromContext(ctx); session != "" {
+		sum := sha256.Sum256([]byte(session))
+		return "session:" + hex.EncodeToString(sum[:16])
+	}
+	return ""
+}
+
+// IsFinished 任务是否已结束（成功、失败或取消）
+func (j *TransferJob) IsFinished() bool {
+	return j.Status == TransferJobSucceeded || j.Status == TransferJobFailed || j.Status == TransferJobCancelled
+}
+
+// DecodeRequest 解析任务的转存请求
+func (j *TransferJob) DecodeRequest() (*TransferRequest, error) {
+	var req TransferRequest
+	if err := json.Unmarshal([]byte(j.Request), &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// DecodeResult 解析任务的转存结果，未完成时返回nil
+func (j *TransferJob) DecodeResult() *TransferResponse {
+	if j.Result == "" {
+		return nil
+	}
+	var resp TransferResponse
+	if err := json.Unmarshal([]byte(j.Result), &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// TransferJobRequest 创建转存任务请求
+type TransferJobRequest struct {
+	TransferRequest
+	Keyword string `json:"keyword"`
+}
@@ -7,17 +7,23 @@ import (
 
 // Admin 管理员模型
 type Admin struct {
-	AdminID       uint   `gorm:"primaryKey;column:admin_id;autoIncrement" json:"admin_id"`
-	Username      string `gorm:"column:username;type:varchar(50);uniqueIndex;not null" json:"username"`
-	Password      string `gorm:"column:password;type:varchar(255);not null" json:"-"` // 不返回密码
-	Nickname      string `gorm:"column:nickname;type:varchar(50)" json:"nickname"`
-	Email         string `gorm:"column:email;type:varchar(100)" json:"email"`
-	Mobile        string `gorm:"column:mobile;type:varchar(20)" json:"mobile"`
-	Status        int    `gorm:"column:status;type:tinyint;default:1" json:"status"` // 0=禁用 1=启用
-	LastLoginTime int64  `gorm:"column:last_login_time" json:"last_login_time"`
-	LastLoginIP   string `gorm:"column:last_login_ip;type:varchar(50)" json:"last_login_ip"`
-	CreateTime    int64  `gorm:"column:create_time;not null" json:"create_time"`
-	UpdateTime    int64  `gorm:"column:update_time;not null" json:"update_time"`
+	AdminID        uint   `gorm:"primaryKey;column:admin_id;autoIncrement" json:"admin_id"`
+	Username       string `gorm:"column:username;type:varchar(50);uniqueIndex;not null" json:"username"`
+	Password       string `gorm:"column:password;type:varchar(255);not null" json:"-"` // 不返回密码
+	Nickname       string `gorm:"column:nickname;type:varchar(50)" json:"nickname"`
+	Email          string `gorm:"column:email;type:varchar(100)" json:"email"`
+	Mobile         string `gorm:"column:mobile;type:varchar(20)" json:"mobile"`
+	Status         int    `gorm:"column:status;type:tinyint;default:1" json:"status"` // 0=禁用 1=启用
+	Role           int    `gorm:"column:role;type:tinyint;default:0" json:"role"`     // 0=只读审计 1=内容编辑 2=超级管理员
+	LastLoginTime  int64  `gorm:"column:last_login_time" json:"last_login_time"`
+	LastLoginIP    string `gorm:"column:last_login_ip;type:varchar(50)" json:"last_login_ip"`
+	LoginFailCount int    `gorm:"column:login_fail_count;default:0" json:"login_fail_count"`      // 连续登录失败次数，登录成功或锁定后清零
+	LockedUntil    int64  `gorm:"column:locked_until;default:0" json:"locked_until"`              // 锁定截止时间（Unix秒），0表示未锁定
+	TOTPSecret     string `gorm:"column:totp_secret;type:varchar(64)" json:"-"`                   // 两步验证密钥（Base32）
+	TOTPEnabled    int    `gorm:"column:totp_enabled;type:tinyint;default:0" json:"totp_enabled"` // 0=未启用 1=已启用
+	RecoveryCodes  string `gorm:"column:recovery_codes;type:text" json:"-"`                       // 恢复码哈希（JSON数组），使用后移除
+	CreateTime     int64  `gorm:"column:create_time;not null" json:"create_time"`
+	UpdateTime     int64  `gorm:"column:update_time;not null" json:"update_time"`
 }
 
 // TableName 指定表名
@@ -42,9 +48,9 @@ func (a *Admin) BeforeUpdate(tx *gorm.DB) error {
 	return nil
 }
 
-// IsAdmin 判断是否为管理员（管理员表中的都是管理员）
+// IsAdmin 判断是否为超级管理员
 func (a *Admin) IsAdmin() bool {
-	return true
+	return a.Role == RoleSuperAdmin
 }
 
 // IsActive 判断用户是否激活
@@ -52,16 +58,78 @@ func (a *Admin) IsActive() bool {
 	return a.Status == 1
 }
 
+// IsTOTPEnabled 是否已启用两步验证
+func (a *Admin) IsTOTPEnabled() bool {
+	return a.TOTPEnabled == 1 && a.TOTPSecret != ""
+}
+
+// IsLocked 账号在指定时间是否处于登录锁定状态
+func (a *Admin) IsLocked(now time.Time) bool {
+	return a.LockedUntil > now.Unix()
+}
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	ClientIP string `json:"-"` // 由处理器填充，记录到最后登录IP
+}
+
+// RegisterRequest 注册请求（超级管理员创建账号，新账号固定为只读审计角色）
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Mobile   string `json:"mobile"`
 }
 
 // LoginResponse 登录响应
+// 账号启用两步验证时，密码校验通过后只返回 MFARequired 和 MFAToken，需再调用两步验证登录接口换取令牌
 type LoginResponse struct {
-	Token    string `json:"token"`
-	UserInfo *Admin `json:"user_info"`
+	Token            string `json:"token"`                    // 访问令牌
+	ExpiresAt        int64  `json:"expires_at"`               // 访问令牌过期时间（Unix秒）
+	RefreshToken     string `json:"refresh_token"`            // 刷新令牌，每次刷新后旧的刷新令牌失效
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`       // 刷新令牌过期时间（Unix秒）
+	MFARequired      bool   `json:"mfa_required,omitempty"`   // 是否需要两步验证
+	MFAToken         string `json:"mfa_token,omitempty"`      // 两步验证凭据，仅在 MFARequired 时返回
+	MFAExpiresAt     int64  `json:"mfa_expires_at,omitempty"` // 两步验证凭据过期时间（Unix秒）
+	UserInfo         *Admin `json:"user_info,omitempty"`
+}
+
+// RefreshTokenRequest 刷新令牌/登出请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// MFALoginRequest 两步验证登录请求，Code 为验证器中的6位验证码或恢复码
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	ClientIP string `json:"-"`
+}
+
+// TOTPSetupResponse 两步验证绑定信息
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"` // Base32密钥，供无法扫码时手动输入
+	URI    string `json:"uri"`    // otpauth:// 地址，用于生成二维码
+}
+
+// TOTPStatus 两步验证状态
+type TOTPStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TOTPCodeRequest 两步验证码请求（启用、重新生成恢复码）
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPDisableRequest 关闭两步验证请求，需同时提供密码和验证码（或恢复码）
+type TOTPDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
 }
 
 // ApiList 接口配置模型
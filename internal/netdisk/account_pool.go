@@ -0,0 +1,467 @@
+﻿package netdisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+// netdiskConfigKeys 各网盘在qf_conf中的凭证与目录配置项
+type netdiskConfigKeys struct {
+	Credential  string
+	SaveDir     string
+	SaveDirTime string
+}
+
+var panTypeConfigKeys = map[int]netdiskConfigKeys{
+	model.PanTypeQuark:  {Credential: "quark_cookie", SaveDir: "quark_file", SaveDirTime: "quark_file_time"},
+	model.PanTypeBaidu:  {Credential: "baidu_cookie", SaveDir: "baidu_file", SaveDirTime: "baidu_file_time"},
+	model.PanTypeAliyun: {Credential: "Authorization", SaveDir: "ali_file", SaveDirTime: "ali_file_time"},
+	model.PanTypeUC:     {Credential: "uc_cookie", SaveDir: "uc_file", SaveDirTime: "uc_file_time"},
+	model.PanTypeXunlei: {Credential: "xunlei_cookie", SaveDir: "xunlei_file", SaveDirTime: "xunlei_file_time"},
+	model.PanTypeTianyi: {Credential: "tianyi_cookie", SaveDir: "tianyi_file", SaveDirTime: "tianyi_file_time"},
+	model.PanType123:    {Credential: "pan123_token", SaveDir: "pan123_file", SaveDirTime: "pan123_file_time"},
+	model.PanType115:    {Credential: "pan115_cookie", SaveDir: "pan115_file", SaveDirTime: "pan115_file_time"},
+}
+
+// SupportedPanTypes 支持账号池的网盘类型
+func SupportedPanTypes() []int {
+	types := make([]int, 0, len(panTypeConfigKeys))
+	for panType := range panTypeConfigKeys {
+		types = append(types, panType)
+	}
+	sort.Ints(types)
+	return types
+}
+
+// SaveDirConfigKey 获取网盘存储目录的配置项名称（temp=true时返回临时目录配置项）
+func SaveDirConfigKey(panType int, temp bool) string {
+	keys, ok := panTypeConfigKeys[panType]
+	if !ok {
+		return ""
+	}
+	if temp {
+		return keys.SaveDirTime
+	}
+	return keys.SaveDir
+}
+
+// accountConfigRepo 账号级配置覆盖：凭证与存储目录优先使用账号自身的值，其余配置读取qf_conf
+type accountConfigRepo struct {
+	repository.ConfigRepository
+	account *model.NetdiskAccount
+	keys    netdiskConfigKeys
+}
+
+func (r *accountConfigRepo) override(name string) (string, bool) {
+	switch name {
+	case r.keys.Credential:
+		return r.account.Credential, true
+	case r.keys.SaveDir:
+		return r.account.SaveDir, r.account.SaveDir != ""
+	case r.keys.SaveDirTime:
+		return r.account.SaveDirTime, r.account.SaveDirTime != ""
+	}
+	return "", false
+}
+
+// Get 获取配置值
+func (r *accountConfigRepo) Get(ctx context.Context, name string) (string, error) {
+	if value, ok := r.override(name); ok {
+		return value, nil
+	}
+	return r.ConfigRepository.Get(ctx, name)
+}
+
+// GetByName 根据名称获取配置
+func (r *accountConfigRepo) GetByName(ctx context.Context, name string) (*model.Config, error) {
+	if value, ok := r.override(name); ok {
+		return &model.Config{Name: name, Value: value}, nil
+	}
+	return r.ConfigRepository.GetByName(ctx, name)
+}
+
+// AccountClient 绑定账号的网盘客户端，记录使用情况并在账号异常时自动停用
+type AccountClient struct {
+	Netdisk
+	account     *model.NetdiskAccount
+	accountRepo repository.NetdiskAccountRepository
+}
+
+// Account 获取客户端绑定的账号
+func (c *AccountClient) Account() *model.NetdiskAccount {
+	return c.account
+}
+
+// Transfer 转存分享链接，容量不足或凭证连续失效时自动停用账号
+func (c *AccountClient) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	result, err := c.Netdisk.Transfer(ctx, shareURL, password, expiredType)
+
+	// 使用独立的context记录，避免转存超时导致记录失败
+	recordCtx := context.Background()
+	if err == nil {
+		resetAuthFailures(c.account.ID)
+		if recordErr := c.accountRepo.RecordUse(recordCtx, c.account.ID); recordErr != nil {
+			logger.Warn("记录网盘账号使用失败", zap.Int("account_id", c.account.ID), zap.Error(recordErr))
+		}
+		return result, nil
+	}
+
+	authFailures := 0
+	if IsAuthError(err) {
+		authFailures = recordAuthFailure(c.account.ID)
+	} else {
+		resetAuthFailures(c.account.ID)
+	}
+
+	switch {
+	case IsQuotaError(err):
+		logger.Warn("⚠️ 网盘账号容量不足，自动停用",
+			zap.Int("account_id", c.account.ID),
+			zap.String("name", c.account.Name),
+			zap.Error(err),
+		)
+		c.disable(recordCtx, err)
+	case authFailures >= authFailureThreshold:
+		// 偶发的登录态校验失败不停用，连续失败说明凭证已失效
+		logger.Warn("⚠️ 网盘账号凭证连续失效，自动停用",
+			zap.Int("account_id", c.account.ID),
+			zap.String("name", c.account.Name),
+			zap.Int("auth_failures", authFailures),
+			zap.Error(err),
+		)
+		resetAuthFailures(c.account.ID)
+		c.disable(recordCtx, err)
+	default:
+		if recordErr := c.accountRepo.RecordFailure(recordCtx, c.account.ID, truncateAccountError(err.Error())); recordErr != nil {
+			logger.Warn("记录网盘账号失败次数失败", zap.Int("account_id", c.account.ID), zap.Error(recordErr))
+		}
+	}
+
+	return result, err
+}
+
+// disable 将账号标记为异常停用，健康检查恢复后自动启用
+func (c *AccountClient) disable(ctx context.Context, err error) {
+	if recordErr := c.accountRepo.UpdateStatus(ctx, c.account.ID, model.NetdiskAccountAbnormal, truncateAccountError(err.Error())); recordErr != nil {
+		logger.Warn("停用网盘账号失败", zap.Int("account_id", c.account.ID), zap.Error(recordErr))
+	}
+}
+
+// TestConnection 测试连接并记录结果：失败时停用账号，成功时恢复异常停用的账号并刷新容量
+func (c *AccountClient) TestConnection(ctx context.Context) error {
+	err := c.Netdisk.TestConnection(ctx)
+
+	fields := map[string]interface{}{}
+	if err != nil {
+		fields["last_error"] = truncateAccountError(err.Error())
+		if c.account.Status == model.NetdiskAccountEnabled {
+			fields["status"] = model.NetdiskAccountAbnormal
+			logger.Warn("⚠️ 网盘账号连接测试失败，自动停用",
+				zap.Int("account_id", c.account.ID),
+				zap.String("name", c.account.Name),
+				zap.Error(err),
+			)
+		}
+	} else {
+		resetAuthFailures(c.account.ID)
+		fields["last_error"] = ""
+		fields["fail_count"] = 0
+		if c.account.Status == model.NetdiskAccountAbnormal {
+			fields["status"] = model.NetdiskAccountEnabled
+			logger.Info("✅ 网盘账号恢复正常，重新启用",
+				zap.Int("account_id", c.account.ID),
+				zap.String("name", c.account.Name),
+			)
+		}
+		if provider, ok := c.Netdisk.(CapacityProvider); ok {
+			if total, used, capErr := provider.GetCapacity(ctx); capErr == nil {
+				fields["total_space"] = total
+				fields["used_space"] = used
+			} else {
+				logger.Debug("获取网盘容量失败", zap.Int("account_id", c.account.ID), zap.Error(capErr))
+			}
+		}
+	}
+
+	if recordErr := c.accountRepo.RecordCheck(context.Background(), c.account.ID, fields); recordErr != nil {
+		logger.Warn("记录网盘账号检查结果失败", zap.Int("account_id", c.account.ID), zap.Error(recordErr))
+	}
+	return err
+}
+
+// CheckLink 检查分享链接状态，底层客户端不支持检测时返回unknown
+func (c *AccountClient) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	checker, ok := c.Netdisk.(LinkChecker)
+	if !ok {
+		return model.LinkStatusUnknown, fmt.Errorf("%s暂不支持链接检测", c.GetName())
+	}
+	return checker.CheckLink(ctx, shareURL, password)
+}
+
+// quotaErrorKeywords 容量/配额不足的错误关键词
+var quotaErrorKeywords = []string{
+	"容量不足", "空间不足", "容量已满", "超出容量", "capacity", "quota", "insufficient space", "no space",
+}
+
+// IsQuotaError 判断错误是否为容量或配额不足
+func IsQuotaError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range quotaErrorKeywords {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// authFailureThreshold 连续认证失败达到该次数后自动停用账号
+const authFailureThreshold = 3
+
+// authErrorKeywords 凭证失效（Cookie/Token过期、未登录）的错误关键词
+var authErrorKeywords = []string{
+	"已过期或无效", "请重新登录", "登录超时", "未登录", "错误码: -6",
+	"require login", "unauthorized", "unauthenticated", "invalidsessionkey", "accesstokeninvalid", "accesstokenexpired",
+}
+
+// IsAuthError 判断错误是否为凭证失效
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range authErrorKeywords {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// 连续认证失败计数（进程级，按账号区分），转存成功、出现其他错误或连接测试通过后清零
+var (
+	authFailureMu     sync.Mutex
+	authFailureCounts = make(map[int]int)
+)
+
+// recordAuthFailure 记录一次认证失败，返回累加后的连续失败次数
+func recordAuthFailure(accountID int) int {
+	authFailureMu.Lock()
+	defer authFailureMu.Unlock()
+	authFailureCounts[accountID]++
+	return authFailureCounts[accountID]
+}
+
+// resetAuthFailures 清零账号的连续认证失败次数
+func resetAuthFailures(accountID int) {
+	authFailureMu.Lock()
+	delete(authFailureCounts, accountID)
+	authFailureMu.Unlock()
+}
+
+// truncateAccountError 截断错误信息以适配字段长度
+func truncateAccountError(msg string) string {
+	runes := []rune(msg)
+	if len(runes) > 200 {
+		return string(runes[:200])
+	}
+	return msg
+}
+
+// 轮询计数器（进程级，按网盘类型区分）
+var (
+	roundRobinMu       sync.Mutex
+	roundRobinCounters = make(map[int]int)
+)
+
+// selectAccount 按策略从可用账号中选择一个
+func selectAccount(panType int, accounts []*model.NetdiskAccount, strategy string) *model.NetdiskAccount {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	switch strategy {
+	case model.AccountStrategyLeastUsed:
+		best := accounts[0]
+		for _, account := range accounts[1:] {
+			if account.UseCount < best.UseCount ||
+				(account.UseCount == best.UseCount && account.LastUsedAt < best.LastUsedAt) {
+				best = account
+			}
+		}
+		return best
+
+	case model.AccountStrategyMostFreeSpace:
+		var best *model.NetdiskAccount
+		for _, account := range accounts {
+			if account.FreeSpace() < 0 {
+				continue
+			}
+			if best == nil || account.FreeSpace() > best.FreeSpace() {
+				best = account
+			}
+		}
+		if best != nil {
+			return best
+		}
+		// 所有账号容量未知时退化为轮询
+	}
+
+	roundRobinMu.Lock()
+	idx := roundRobinCounters[panType] % len(accounts)
+	roundRobinCounters[panType]++
+	roundRobinMu.Unlock()
+	return accounts[idx]
+}
+
+// 已完成旧版单账号配置迁移的网盘类型，迁移失败的类型在下次获取账号时重试
+var (
+	legacyImportMu   sync.Mutex
+	legacyImportDone = make(map[int]bool)
+)
+
+// legacyAccountName 从qf_conf迁移的账号名称
+const legacyAccountName = "默认账号"
+
+func init() {
+	// 后台系统配置中写入旧版单账号凭证后立即同步到账号池
+	config.Subscribe("netdisk", func(ev config.ChangeEvent) error {
+		if ev.Source != config.ChangeSourceDB {
+			return nil
+		}
+		ctx := context.Background()
+		configRepo := repository.NewConfigRepository()
+		accountRepo := repository.NewNetdiskAccountRepository()
+		for _, panType := range SupportedPanTypes() {
+			if ev.HasName(func(name string) bool { return name == panTypeConfigKeys[panType].Credential }) {
+				importLegacyAccount(ctx, configRepo, accountRepo, panType, true)
+			}
+		}
+		return nil
+	})
+}
+
+// importLegacyAccounts 将qf_conf中的单账号凭证迁移到账号表，迁移成功后清空原配置项
+func importLegacyAccounts(ctx context.Context, configRepo repository.ConfigRepository, accountRepo repository.NetdiskAccountRepository) {
+	legacyImportMu.Lock()
+	defer legacyImportMu.Unlock()
+
+	for _, panType := range SupportedPanTypes() {
+		if !legacyImportDone[panType] {
+			legacyImportDone[panType] = importLegacyAccount(ctx, configRepo, accountRepo, panType, false)
+		}
+	}
+}
+
+// importLegacyAccount 迁移单个网盘的旧版凭证，返回false表示迁移失败需要重试
+// replace为false时只在账号池为空时新建账号；为true时（后台修改了凭证）更新已迁移的默认账号，没有则新建
+// 只有账号写入成功后才清空旧配置项，迁移失败或跳过时保留原凭证
+func importLegacyAccount(ctx context.Context, configRepo repository.ConfigRepository, accountRepo repository.NetdiskAccountRepository, panType int, replace bool) bool {
+	keys := panTypeConfigKeys[panType]
+
+	credential, err := configRepo.Get(ctx, keys.Credential)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	}
+	if err != nil {
+		logger.Warn("读取旧网盘凭证配置失败", zap.String("name", keys.Credential), zap.Error(err))
+		return false
+	}
+	credential = strings.TrimSpace(credential)
+	if credential == "" {
+		return true
+	}
+
+	accounts, err := accountRepo.List(ctx, panType)
+	if err != nil {
+		logger.Warn("迁移网盘账号失败", zap.Int("pan_type", panType), zap.Error(err))
+		return false
+	}
+
+	var existing *model.NetdiskAccount
+	if replace {
+		for _, account := range accounts {
+			if account.Name == legacyAccountName {
+				existing = account
+				break
+			}
+		}
+	}
+
+	switch {
+	case existing != nil:
+		existing.Credential = credential
+		existing.Status = model.NetdiskAccountEnabled
+		existing.LastError = ""
+		if err := accountRepo.Update(ctx, existing); err != nil {
+			logger.Warn("更新网盘账号凭证失败", zap.Int("pan_type", panType), zap.Error(err))
+			return false
+		}
+		resetAuthFailures(existing.ID)
+		logger.Info("已将网盘配置同步到账号池",
+			zap.Int("pan_type", panType),
+			zap.Int("account_id", existing.ID),
+		)
+	case replace || len(accounts) == 0:
+		account := &model.NetdiskAccount{
+			PanType:    panType,
+			Name:       legacyAccountName,
+			Credential: credential,
+			Status:     model.NetdiskAccountEnabled,
+		}
+		if err := accountRepo.Create(ctx, account); err != nil {
+			logger.Warn("迁移网盘账号失败", zap.Int("pan_type", panType), zap.Error(err))
+			return false
+		}
+		logger.Info("已将网盘配置迁移到账号池",
+			zap.Int("pan_type", panType),
+			zap.Int("account_id", account.ID),
+		)
+	default:
+		// 账号池已有账号，不覆盖也不清空旧配置
+		return true
+	}
+
+	// 账号池接管凭证，清空旧配置项避免删除账号后被重新导入
+	if err := configRepo.BatchUpsert(ctx, map[string]string{keys.Credential: ""}); err != nil {
+		logger.Warn("清空旧网盘凭证配置失败", zap.String("name", keys.Credential), zap.Error(err))
+	}
+	return true
+}
+
+// IsPanTypeConfigured 检查指定网盘类型是否有可用账号
+func IsPanTypeConfigured(ctx context.Context, panType int) bool {
+	configRepo := repository.NewConfigRepository()
+	accountRepo := repository.NewNetdiskAccountRepository()
+	importLegacyAccounts(ctx, configRepo, accountRepo)
+
+	accounts, err := accountRepo.ListByStatus(ctx, panType, []int{model.NetdiskAccountEnabled})
+	if err != nil {
+		logger.Debug("查询网盘账号失败", zap.Int("pan_type", panType), zap.Error(err))
+		return false
+	}
+	for _, account := range accounts {
+		if strings.TrimSpace(account.Credential) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// errNoAvailableAccount 无可用账号时的错误
+func errNoAvailableAccount(panType int) error {
+	return fmt.Errorf("网盘未配置可用账号: %s", model.GetPanTypeName(panType))
+}
@@ -0,0 +1,181 @@
+﻿package netdisk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// stubNetdisk 按顺序返回预设转存错误的网盘客户端
+type stubNetdisk struct {
+	errs []error
+}
+
+func (c *stubNetdisk) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	if err != nil {
+		return nil, err
+	}
+	return &model.TransferResult{Success: true}, nil
+}
+func (c *stubNetdisk) GetName() string                                           { return "stub" }
+func (c *stubNetdisk) IsConfigured() bool                                        { return true }
+func (c *stubNetdisk) TestConnection(ctx context.Context) error                  { return nil }
+func (c *stubNetdisk) DeleteDirectory(ctx context.Context, dirPath string) error { return nil }
+func (c *stubNetdisk) CreateDirectory(ctx context.Context, dirPath string) error { return nil }
+
+func TestImportLegacyAccount(t *testing.T) {
+	credentialKey := panTypeConfigKeys[model.PanTypeQuark].Credential
+
+	tests := []struct {
+		name         string
+		accounts     []*model.NetdiskAccount
+		createErr    error
+		replace      bool
+		wantDone     bool
+		wantCleared  bool // 迁移成功，qf_conf中的旧凭证已清空
+		wantAccounts int
+	}{
+		{name: "账号池为空时迁移并清空旧配置", wantDone: true, wantCleared: true, wantAccounts: 1},
+		{name: "写入账号失败时保留旧配置", createErr: errors.New("db down"), wantDone: false, wantAccounts: 0},
+		{
+			name:         "账号池已有账号时跳过且保留旧配置",
+			accounts:     []*model.NetdiskAccount{{PanType: model.PanTypeQuark, Name: "主账号", Credential: "kps=main", Status: model.NetdiskAccountEnabled}},
+			wantDone:     true,
+			wantAccounts: 1,
+		},
+		{
+			name:         "后台修改凭证时更新默认账号",
+			accounts:     []*model.NetdiskAccount{{PanType: model.PanTypeQuark, Name: legacyAccountName, Credential: "kps=old", Status: model.NetdiskAccountAbnormal}},
+			replace:      true,
+			wantDone:     true,
+			wantCleared:  true,
+			wantAccounts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configRepo := repotest.NewConfigRepository(map[string]string{credentialKey: " kps=legacy "})
+			accountRepo := repotest.NewNetdiskAccountRepository(tt.accounts...)
+			accountRepo.CreateErr = tt.createErr
+
+			done := importLegacyAccount(context.Background(), configRepo, accountRepo, model.PanTypeQuark, tt.replace)
+			if done != tt.wantDone {
+				t.Fatalf("importLegacyAccount() = %v, want %v", done, tt.wantDone)
+			}
+			wantCredential := " kps=legacy "
+			if tt.wantCleared {
+				wantCredential = ""
+			}
+			if got, _ := configRepo.Get(context.Background(), credentialKey); got != wantCredential {
+				t.Fatalf("legacy credential = %q, want %q", got, wantCredential)
+			}
+
+			accounts, _ := accountRepo.List(context.Background(), model.PanTypeQuark)
+			if len(accounts) != tt.wantAccounts {
+				t.Fatalf("accounts = %d, want %d", len(accounts), tt.wantAccounts)
+			}
+			if tt.wantCleared {
+				var migrated *model.NetdiskAccount
+				for _, account := range accounts {
+					if account.Name == legacyAccountName {
+						migrated = account
+					}
+				}
+				if migrated == nil || migrated.Credential != "kps=legacy" || migrated.Status != model.NetdiskAccountEnabled {
+					t.Fatalf("migrated account = %+v, want enabled with legacy credential", migrated)
+				}
+			}
+		})
+	}
+}
+
+func TestImportLegacyAccountNothingToMigrate(t *testing.T) {
+	accountRepo := repotest.NewNetdiskAccountRepository()
+	for name, values := range map[string]map[string]string{
+		"未配置旧凭证": nil,
+		"旧凭证为空":  {panTypeConfigKeys[model.PanTypeQuark].Credential: " "},
+	} {
+		if !importLegacyAccount(context.Background(), repotest.NewConfigRepository(values), accountRepo, model.PanTypeQuark, false) {
+			t.Fatalf("%s: importLegacyAccount() = false, want true", name)
+		}
+	}
+	if accounts, _ := accountRepo.List(context.Background(), -1); len(accounts) != 0 {
+		t.Fatalf("accounts = %d, want 0", len(accounts))
+	}
+}
+
+func TestAccountClientAuthFailures(t *testing.T) {
+	authErr := errors.New("Cookie已过期或无效，请重新获取")
+	otherErr := errors.New("网络请求失败")
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantStatus int
+	}{
+		{name: "连续认证失败后停用", errs: []error{authErr, authErr, authErr}, wantStatus: model.NetdiskAccountAbnormal},
+		{name: "未达到阈值不停用", errs: []error{authErr, authErr}, wantStatus: model.NetdiskAccountEnabled},
+		{name: "转存成功后重新计数", errs: []error{authErr, authErr, nil, authErr, authErr}, wantStatus: model.NetdiskAccountEnabled},
+		{name: "其他错误打断连续计数", errs: []error{authErr, otherErr, authErr, authErr}, wantStatus: model.NetdiskAccountEnabled},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := repotest.NewNetdiskAccountRepository(&model.NetdiskAccount{
+				ID:         100 + i,
+				PanType:    model.PanTypeQuark,
+				Name:       "主账号",
+				Credential: "kps=main",
+				Status:     model.NetdiskAccountEnabled,
+			})
+			account, _ := accountRepo.GetByID(context.Background(), 100+i)
+			client := &AccountClient{Netdisk: &stubNetdisk{errs: tt.errs}, account: account, accountRepo: accountRepo}
+			defer resetAuthFailures(account.ID)
+
+			for range tt.errs {
+				client.Transfer(context.Background(), "https://pan.quark.cn/s/abc", "", 0)
+			}
+
+			got, _ := accountRepo.GetByID(context.Background(), account.ID)
+			if got.Status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (last_error %q)", got.Status, tt.wantStatus, got.LastError)
+			}
+			if tt.wantStatus == model.NetdiskAccountAbnormal && got.LastError != authErr.Error() {
+				t.Fatalf("last_error = %q, want %q", got.LastError, authErr.Error())
+			}
+		})
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("Token已过期或无效，请重新获取"), true},
+		{errors.New("获取stoken失败: require login [guest]"), true},
+		{errors.New("获取bdstoken失败,错误码: -6"), true},
+		{errors.New("容量不足"), false},
+		{errors.New("分享已过期"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsAuthError(tt.err); got != tt.want {
+			t.Errorf("IsAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -274,7 +275,14 @@ func (c *AliyunClient) doRequestWithHeaders(ctx context.Context, method, url str
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("请求失败,状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+		apiErr := &apiError{statusCode: resp.StatusCode, body: string(respBody)}
+		var body struct {
+			Code string `json:"code"`
+		}
+		if json.Unmarshal(respBody, &body) == nil {
+			apiErr.code = body.Code
+		}
+		return apiErr
 	}
 
 	if err := json.Unmarshal(respBody, result); err != nil {
@@ -284,6 +292,42 @@ func (c *AliyunClient) doRequestWithHeaders(ctx context.Context, method, url str
 	return nil
 }
 
+// apiError 接口返回的非200响应
+type apiError struct {
+	statusCode int
+	code       string // 响应体中的错误码，如 ShareLink.Cancelled
+	body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("请求失败,状态码: %d, 响应: %s", e.statusCode, e.body)
+}
+
+// aliyunShareCodes 分享接口错误码对应的链接状态
+var aliyunShareCodes = map[string]model.LinkStatus{
+	"ShareLink.Cancelled":      model.LinkStatusDead,
+	"ShareLink.Expired":        model.LinkStatusDead,
+	"ShareLink.Forbidden":      model.LinkStatusDead,
+	"NotFound.ShareLink":       model.LinkStatusDead,
+	"SharePwd.Invalid":         model.LinkStatusNeedPassword,
+	"InvalidResource.SharePwd": model.LinkStatusNeedPassword,
+}
+
+// linkStatusOf 根据接口错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return model.LinkStatusUnknown
+	}
+	if status, ok := aliyunShareCodes[apiErr.code]; ok {
+		return status
+	}
+	if apiErr.statusCode == http.StatusNotFound {
+		return model.LinkStatusDead
+	}
+	return model.LinkStatusUnknown
+}
+
 // AliyunFile 阿里云盘文件信息
 type AliyunFile struct {
 	FileID string `json:"file_id"`
@@ -417,4 +461,28 @@ func (c *AliyunClient) TestConnection(ctx context.Context) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+// CheckLink 检查阿里云盘分享链接状态（复用转存流程的分享token接口）
+func (c *AliyunClient) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	shareID, err := c.extractShareID(shareURL)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+
+	shareToken, err := c.getShareToken(ctx, shareID, password)
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+	if shareToken == "" {
+		return model.LinkStatusUnknown, fmt.Errorf("未获取到分享token")
+	}
+
+	files, err := c.getShareFileList(ctx, shareID, shareToken)
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+	if len(files) == 0 {
+		return model.LinkStatusDead, fmt.Errorf("分享内容为空")
+	}
+	return model.LinkStatusAlive, nil
+}
@@ -0,0 +1,194 @@
+﻿package aliyun
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/repository/repotest"
+)
+
+const (
+	refreshRoute    = "api.aliyundrive.com/token/refresh"
+	shareTokenRoute = "api.aliyundrive.com/v2/share_link/get_share_token"
+	fileListRoute   = "api.aliyundrive.com/adrive/v3/file/list"
+	copyRoute       = "api.aliyundrive.com/adrive/v2/file/copy"
+	createRoute     = "api.aliyundrive.com/adrive/v2/share_link/create"
+)
+
+// newFakeAliyun 注册一次完整转存流程的成功响应
+func newFakeAliyun(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("POST", refreshRoute, map[string]string{
+		"access_token":     "access-1",
+		"refresh_token":    "refresh-2",
+		"default_drive_id": "drive-1",
+	})
+	srv.HandleJSON("POST", shareTokenRoute, map[string]string{"share_token": "share-token-1"})
+	srv.HandleJSON("POST", fileListRoute, map[string]interface{}{
+		"items": []map[string]string{
+			{"file_id": "file-1", "name": "三体", "type": "folder"},
+			{"file_id": "file-2", "name": "三体.txt", "type": "file"},
+		},
+	})
+	srv.HandleJSON("POST", copyRoute, map[string]interface{}{"responses": []interface{}{}})
+	srv.HandleJSON("POST", createRoute, map[string]string{
+		"share_url": "https://www.alipan.com/s/mine",
+		"share_pwd": "6666",
+	})
+	return srv
+}
+
+// handleError 注册返回指定状态码和错误码的路由
+func handleError(srv *netdisktest.Server, route string, status int, code string) {
+	srv.Handle("POST", route, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		netdisktest.WriteJSON(w, map[string]string{"code": code, "message": code})
+	})
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *AliyunClient {
+	c := NewAliyunClient("refresh-1", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakeAliyun(t)
+	c := newTestClient(srv, map[string]string{"ali_file": "folder-1", "ali_file_time": "folder-tmp"})
+
+	result, err := c.Transfer(context.Background(), "https://www.alipan.com/s/abc123", "", 2)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://www.alipan.com/s/mine" || result.Password != "6666" || result.Title != "三体" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	copies := srv.Requests(copyRoute)
+	if len(copies) != 1 {
+		t.Fatalf("转存请求次数 = %d, want 1", len(copies))
+	}
+	var body struct {
+		FileIDList     []string `json:"file_id_list"`
+		ToParentFileID string   `json:"to_parent_file_id"`
+		ToDriveID      string   `json:"to_drive_id"`
+	}
+	if err := copies[0].JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ToParentFileID != "folder-tmp" || body.ToDriveID != "drive-1" || len(body.FileIDList) != 2 {
+		t.Errorf("转存请求 = %+v", body)
+	}
+	if got := copies[0].Header.Get("X-Share-Token"); got != "share-token-1" {
+		t.Errorf("X-Share-Token = %q, want share-token-1", got)
+	}
+	if got := copies[0].Header.Get("Authorization"); got != "Bearer access-1" {
+		t.Errorf("Authorization = %q, want Bearer access-1", got)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs map[string]string
+		setup   func(srv *netdisktest.Server)
+		wantErr string
+	}{
+		{
+			name:    "refresh_token失效",
+			configs: map[string]string{"ali_file": "root"},
+			setup: func(srv *netdisktest.Server) {
+				handleError(srv, refreshRoute, http.StatusBadRequest, "InvalidParameter.RefreshToken")
+			},
+			wantErr: "刷新token失败",
+		},
+		{
+			name:    "分享为空",
+			configs: map[string]string{"ali_file": "root"},
+			setup: func(srv *netdisktest.Server) {
+				srv.HandleJSON("POST", fileListRoute, map[string]interface{}{"items": []interface{}{}})
+			},
+			wantErr: "分享链接中没有文件",
+		},
+		{
+			name:    "未配置转存目录",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeAliyun(t)
+			if tt.setup != nil {
+				tt.setup(srv)
+			}
+			c := newTestClient(srv, tt.configs)
+
+			_, err := c.Transfer(context.Background(), "https://www.alipan.com/s/abc123", "", 1)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %v, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(srv *netdisktest.Server)
+		want  model.LinkStatus
+	}{
+		{
+			name: "有效",
+			want: model.LinkStatusAlive,
+		},
+		{
+			name: "已取消",
+			setup: func(srv *netdisktest.Server) {
+				handleError(srv, shareTokenRoute, http.StatusBadRequest, "ShareLink.Cancelled")
+			},
+			want: model.LinkStatusDead,
+		},
+		{
+			name: "提取码错误",
+			setup: func(srv *netdisktest.Server) {
+				handleError(srv, shareTokenRoute, http.StatusBadRequest, "SharePwd.Invalid")
+			},
+			want: model.LinkStatusNeedPassword,
+		},
+		{
+			name: "分享不存在",
+			setup: func(srv *netdisktest.Server) {
+				handleError(srv, shareTokenRoute, http.StatusNotFound, "NotFound.ShareLink")
+			},
+			want: model.LinkStatusDead,
+		},
+		{
+			name: "服务异常",
+			setup: func(srv *netdisktest.Server) {
+				handleError(srv, shareTokenRoute, http.StatusInternalServerError, "InternalError")
+			},
+			want: model.LinkStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeAliyun(t)
+			if tt.setup != nil {
+				tt.setup(srv)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), "https://www.alipan.com/s/abc123", "")
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
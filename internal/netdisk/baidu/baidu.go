@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -275,7 +276,7 @@ func (c *BaiduClient) verifyPassCode(ctx context.Context, shareURL, password str
 	fmt.Printf("🔍 [DEBUG] verifyPassCode返回: errno=%d\n", result.Errno)
 	
 	if result.Errno != 0 {
-		return "", fmt.Errorf("验证提取码失败,错误码: %d", result.Errno)
+		return "", &errnoError{op: "验证提取码失败", errno: result.Errno}
 	}
 
 	fmt.Printf("🔍 [DEBUG] 成功获取randsk: %s (前10字符)\n", result.Randsk[:min(10, len(result.Randsk))])
@@ -915,4 +916,113 @@ func (c *BaiduClient) TestConnection(ctx context.Context) error {
 
 	// 即使result是数组或其他格式，只要errno=0就认为Cookie有效
 	return nil
-}
\ No newline at end of file
+}
+
+// GetCapacity 获取百度网盘容量（字节）
+func (c *BaiduClient) GetCapacity(ctx context.Context) (int64, int64, error) {
+	var result struct {
+		Errno int   `json:"errno"`
+		Total int64 `json:"total"`
+		Used  int64 `json:"used"`
+	}
+
+	params := map[string]string{
+		"checkfree":   "1",
+		"checkexpire": "1",
+		"clienttype":  "0",
+		"app_id":      "250528",
+		"web":         "1",
+	}
+
+	if err := c.doRequest(ctx, "GET", "https://pan.baidu.com/api/quota", params, nil, &result); err != nil {
+		return 0, 0, fmt.Errorf("网络请求失败: %w", err)
+	}
+	if result.Errno != 0 {
+		return 0, 0, fmt.Errorf("获取容量失败，错误码: %d", result.Errno)
+	}
+
+	return result.Total, result.Used, nil
+}
+
+// errnoError 接口返回的非0错误码
+type errnoError struct {
+	op    string
+	errno int
+}
+
+func (e *errnoError) Error() string {
+	return fmt.Sprintf("%s,错误码: %d", e.op, e.errno)
+}
+
+// baiduVerifyErrnos 提取码校验接口错误码对应的链接状态（-62需要验证码等无法判断）
+var baiduVerifyErrnos = map[int]model.LinkStatus{
+	-9:  model.LinkStatusNeedPassword, // 提取码错误
+	-12: model.LinkStatusNeedPassword, // 缺少提取码
+	-7:  model.LinkStatusDead,         // 分享不存在
+	105: model.LinkStatusDead,         // 链接已失效
+	115: model.LinkStatusDead,         // 文件禁止分享
+}
+
+// linkStatusOf 根据接口错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	var errnoErr *errnoError
+	if errors.As(err, &errnoErr) {
+		if status, ok := baiduVerifyErrnos[errnoErr.errno]; ok {
+			return status
+		}
+	}
+	return model.LinkStatusUnknown
+}
+
+// baiduDeadPageMarkers 百度分享页中表示链接失效的文案
+var baiduDeadPageMarkers = []string{"你来晚了", "分享的文件已经被取消", "分享已过期", "链接不存在", "涉及侵权", "此链接分享内容可能因为"}
+
+// CheckLink 检查百度分享链接状态（复用转存流程的bdstoken和提取码校验，再解析分享页）
+func (c *BaiduClient) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	if err := c.getBdstoken(ctx); err != nil {
+		return model.LinkStatusUnknown, err
+	}
+
+	if password != "" {
+		randsk, err := c.verifyPassCode(ctx, shareURL, password)
+		if err != nil {
+			return linkStatusOf(err), err
+		}
+		c.updateCookie(randsk)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", shareURL, nil)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Referer", "https://pan.baidu.com/disk/main")
+	req.Header.Set("Cookie", c.cookie)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return model.LinkStatusUnknown, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return model.LinkStatusUnknown, err
+	}
+
+	// 需要提取码的分享会跳转到 /share/init 页面
+	if strings.Contains(resp.Request.URL.Path, "/share/init") {
+		return model.LinkStatusNeedPassword, fmt.Errorf("需要提取码")
+	}
+
+	bodyStr := string(body)
+	for _, marker := range baiduDeadPageMarkers {
+		if strings.Contains(bodyStr, marker) {
+			return model.LinkStatusDead, fmt.Errorf("分享已失效: %s", marker)
+		}
+	}
+	if strings.Contains(bodyStr, `"fs_id"`) {
+		return model.LinkStatusAlive, nil
+	}
+	return model.LinkStatusUnknown, fmt.Errorf("无法解析分享页面")
+}
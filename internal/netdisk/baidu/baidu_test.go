@@ -0,0 +1,235 @@
+﻿package baidu
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const (
+	shareURL      = "https://pan.baidu.com/s/1AbCdEfGhIjKlMnOpQrStUv"
+	sharePath     = "pan.baidu.com/s/1AbCdEfGhIjKlMnOpQrStUv"
+	templateRoute = "pan.baidu.com/api/gettemplatevariable"
+	verifyRoute   = "pan.baidu.com/share/verify"
+	transferRoute = "pan.baidu.com/share/transfer"
+	listRoute     = "pan.baidu.com/api/list"
+	shareSetRoute = "pan.baidu.com/share/set"
+)
+
+// sharePage 分享页中转存参数所在的片段
+const sharePage = `<html><script>locals.mset({"shareid":123,"share_uk":"456","file_list":[{"fs_id":789,"server_filename":"流浪地球2.mkv","isdir":0,"size":1}]});</script></html>`
+
+// newFakeBaidu 注册一次完整转存流程的成功响应
+func newFakeBaidu(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("GET", templateRoute, map[string]interface{}{
+		"errno":  0,
+		"result": map[string]string{"bdstoken": "token-123"},
+	})
+	srv.HandleJSON("POST", verifyRoute, map[string]interface{}{"errno": 0, "randsk": "randsk-1"})
+	srv.Handle("GET", sharePath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, sharePage)
+	})
+	srv.HandleJSON("POST", transferRoute, map[string]interface{}{"errno": 0})
+	srv.HandleJSON("GET", listRoute, map[string]interface{}{
+		"errno": 0,
+		"list": []map[string]interface{}{
+			{"fs_id": 1001, "server_filename": "流浪地球2.mkv", "isdir": 0},
+			{"fs_id": 1002, "server_filename": "其他文件.txt", "isdir": 0},
+		},
+	})
+	srv.HandleJSON("POST", shareSetRoute, map[string]interface{}{
+		"errno":       0,
+		"link":        "https://pan.baidu.com/s/1mine",
+		"expiredType": 0,
+	})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *BaiduClient {
+	c := NewBaiduClient("BDUSS=abc; STOKEN=def", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakeBaidu(t)
+	c := newTestClient(srv, map[string]string{"baidu_file": "/huoxing", "baidu_file_time": "/temp"})
+
+	result, err := c.Transfer(context.Background(), shareURL, "abcd", 1)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://pan.baidu.com/s/1mine?pwd=6666" || result.Password != "6666" || result.Title != "流浪地球2.mkv" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	// 提取码校验后randsk写入Cookie的BDCLND
+	pages := srv.Requests(sharePath)
+	if len(pages) != 1 || !strings.Contains(pages[0].Header.Get("Cookie"), "BDCLND=randsk-1") {
+		t.Errorf("分享页请求Cookie未包含BDCLND: %v", pages)
+	}
+
+	transfers := srv.Requests(transferRoute)
+	if len(transfers) != 1 {
+		t.Fatalf("转存请求次数 = %d, want 1", len(transfers))
+	}
+	if got := transfers[0].Query.Get("shareid"); got != "123" {
+		t.Errorf("shareid = %q, want 123", got)
+	}
+	if got := transfers[0].Form().Get("path"); got != "/huoxing" {
+		t.Errorf("转存目录 = %q, want /huoxing", got)
+	}
+
+	// 只分享本次转存的文件
+	shares := srv.Requests(shareSetRoute)
+	if len(shares) == 0 || shares[0].Form().Get("fid_list") != "[1001]" {
+		t.Errorf("分享fid_list = %v", shares)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs map[string]string
+		setup   func(srv *netdisktest.Server)
+		wantErr string
+	}{
+		{
+			name:    "未配置转存目录",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+		{
+			name:    "提取码错误",
+			configs: map[string]string{"baidu_file": "huoxing"},
+			setup: func(srv *netdisktest.Server) {
+				srv.HandleJSON("POST", verifyRoute, map[string]interface{}{"errno": -12})
+			},
+			wantErr: "验证提取码失败",
+		},
+		{
+			name:    "全部为广告",
+			configs: map[string]string{"baidu_file": "huoxing", "quark_banned": "流浪"},
+			wantErr: "资源内容为空或全部为广告",
+		},
+		{
+			name:    "分享页无转存参数",
+			configs: map[string]string{"baidu_file": "huoxing"},
+			setup: func(srv *netdisktest.Server) {
+				srv.Handle("GET", sharePath, func(w http.ResponseWriter, r *http.Request) {
+					_, _ = io.WriteString(w, "<html>你来晚了，分享的文件已经被删除了</html>")
+				})
+			},
+			wantErr: "获取转存参数失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeBaidu(t)
+			if tt.setup != nil {
+				tt.setup(srv)
+			}
+			c := newTestClient(srv, tt.configs)
+
+			_, err := c.Transfer(context.Background(), shareURL, "abcd", 1)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %v, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		verify   interface{}
+		page     http.HandlerFunc
+		want     model.LinkStatus
+	}{
+		{
+			name: "有效",
+			want: model.LinkStatusAlive,
+		},
+		{
+			name:     "带提取码有效",
+			password: "abcd",
+			want:     model.LinkStatusAlive,
+		},
+		{
+			name:     "提取码错误",
+			password: "abcd",
+			verify:   map[string]interface{}{"errno": -9},
+			want:     model.LinkStatusNeedPassword,
+		},
+		{
+			name:     "校验接口返回失效",
+			password: "abcd",
+			verify:   map[string]interface{}{"errno": 105},
+			want:     model.LinkStatusDead,
+		},
+		{
+			name:     "需要验证码",
+			password: "abcd",
+			verify:   map[string]interface{}{"errno": -62},
+			want:     model.LinkStatusUnknown,
+		},
+		{
+			name: "已失效",
+			page: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "<html>啊哦，你来晚了，分享的文件已经被取消了</html>")
+			},
+			want: model.LinkStatusDead,
+		},
+		{
+			name: "需要提取码",
+			page: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://pan.baidu.com/share/init?surl=AbCdEfGhIjKlMnOpQrStUv", http.StatusFound)
+			},
+			want: model.LinkStatusNeedPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeBaidu(t)
+			srv.Handle("GET", "pan.baidu.com/share/init", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "<html>请输入提取码</html>")
+			})
+			if tt.verify != nil {
+				srv.HandleJSON("POST", verifyRoute, tt.verify)
+			}
+			if tt.page != nil {
+				srv.Handle("GET", sharePath, tt.page)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), shareURL, tt.password)
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+			if tt.password != "" {
+				verifies := srv.Requests(verifyRoute)
+				if len(verifies) != 1 || verifies[0].Form().Get("pwd") != tt.password {
+					t.Errorf("verify requests = %+v, want pwd=%s", verifies, tt.password)
+				}
+			}
+		})
+	}
+}
@@ -33,8 +33,27 @@ type Netdisk interface {
 	CreateDirectory(ctx context.Context, dirPath string) error
 }
 
+// CapacityProvider 可选接口：支持查询网盘容量的客户端实现此接口
+type CapacityProvider interface {
+	// GetCapacity 获取网盘总容量和已用容量（字节）
+	GetCapacity(ctx context.Context) (total int64, used int64, err error)
+}
+
+// LinkChecker 可选接口：支持校验分享链接有效性的客户端实现此接口
+type LinkChecker interface {
+	// CheckLink 检查分享链接状态（不转存）
+	// 返回LinkStatusUnknown时error说明无法判断的原因
+	CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error)
+}
+
 // NetdiskManager 网盘管理器接口
 type NetdiskManager interface {
-	// GetClient 获取指定类型的网盘客户端
+	// GetClient 按账号选择策略获取指定类型的网盘客户端
 	GetClient(panType int) (Netdisk, error)
+	
+	// GetClients 获取指定类型下所有启用账号的客户端（用于清理等需要遍历账号的场景）
+	GetClients(panType int) ([]Netdisk, error)
+	
+	// GetAccountClient 获取指定账号的客户端（不检查账号状态，用于后台测试）
+	GetAccountClient(accountID int) (Netdisk, error)
 }
\ No newline at end of file
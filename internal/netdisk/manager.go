@@ -3,11 +3,15 @@
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"huoxing-search/internal/model"
 	"huoxing-search/internal/netdisk/aliyun"
 	"huoxing-search/internal/netdisk/baidu"
+	"huoxing-search/internal/netdisk/pan115"
+	"huoxing-search/internal/netdisk/pan123"
 	"huoxing-search/internal/netdisk/quark"
+	"huoxing-search/internal/netdisk/tianyi"
 	"huoxing-search/internal/netdisk/uc"
 	"huoxing-search/internal/netdisk/xunlei"
 	"huoxing-search/internal/pkg/config"
@@ -15,48 +19,15 @@ import (
 )
 
 type netdiskManager struct {
-	quark      Netdisk
-	baidu      Netdisk
-	aliyun     Netdisk
-	uc         Netdisk
-	xunlei     Netdisk
-	configRepo repository.ConfigRepository
+	configRepo  repository.ConfigRepository
+	accountRepo repository.NetdiskAccountRepository
 }
 
-// NewNetdiskManager 创建网盘管理器 - 简化版本，只读取认证信息
+// NewNetdiskManager 创建网盘管理器 - 认证信息从网盘账号池读取
 func NewNetdiskManager(cfg *config.Config) NetdiskManager {
-	configRepo := repository.NewConfigRepository()
-	ctx := context.Background()
-
-	// 从数据库读取基本认证配置
-	quarkCookie, _ := configRepo.GetByName(ctx, "quark_cookie")
-	baiduCookie, _ := configRepo.GetByName(ctx, "baidu_cookie")
-	aliAuth, _ := configRepo.GetByName(ctx, "Authorization")
-	ucCookie, _ := configRepo.GetByName(ctx, "uc_cookie")
-	xunleiCookie, _ := configRepo.GetByName(ctx, "xunlei_cookie")
-
 	return &netdiskManager{
-		quark: quark.NewQuarkClient(
-			getConfigValue(quarkCookie),
-			configRepo,
-		),
-		baidu: baidu.NewBaiduClient(
-			getConfigValue(baiduCookie),
-			configRepo,
-		),
-		aliyun: aliyun.NewAliyunClient(
-			getConfigValue(aliAuth),
-			configRepo,
-		),
-		uc: uc.NewUCClient(
-			getConfigValue(ucCookie),
-			configRepo,
-		),
-		xunlei: xunlei.NewXunleiClient(
-			getConfigValue(xunleiCookie),
-			configRepo,
-		),
-		configRepo: configRepo,
+		configRepo:  repository.NewConfigRepository(),
+		accountRepo: repository.NewNetdiskAccountRepository(),
 	}
 }
 
@@ -70,44 +41,111 @@ func getConfigValue(conf *model.Config) string {
 
 // GetClient 获取指定类型的网盘客户端
 // ⚠️ 重要：每次调用都创建新的客户端实例，避免并发时Cookie相互覆盖
+// 同一网盘类型配置了多个账号时，按 netdisk_account_strategy 配置的策略选择账号
 func (m *netdiskManager) GetClient(panType int) (Netdisk, error) {
 	ctx := context.Background()
-	
-	var client Netdisk
 
-	switch panType {
+	if _, ok := panTypeConfigKeys[panType]; !ok {
+		return nil, fmt.Errorf("不支持的网盘类型: %d", panType)
+	}
+
+	accounts, err := m.availableAccounts(ctx, panType)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, errNoAvailableAccount(panType)
+	}
+
+	strategy, _ := m.configRepo.GetByName(ctx, model.ConfNetdiskAccountStrategy)
+	account := selectAccount(panType, accounts, getConfigValue(strategy))
+
+	return m.newAccountClient(account)
+}
+
+// GetClients 获取指定类型下所有启用账号的客户端
+func (m *netdiskManager) GetClients(panType int) ([]Netdisk, error) {
+	ctx := context.Background()
+
+	accounts, err := m.availableAccounts(ctx, panType)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]Netdisk, 0, len(accounts))
+	for _, account := range accounts {
+		client, err := m.newAccountClient(account)
+		if err != nil {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// GetAccountClient 获取指定账号的客户端
+func (m *netdiskManager) GetAccountClient(accountID int) (Netdisk, error) {
+	account, err := m.accountRepo.GetByID(context.Background(), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("网盘账号不存在: %w", err)
+	}
+	return m.newAccountClient(account)
+}
+
+// availableAccounts 获取已启用且填写了凭证的账号
+func (m *netdiskManager) availableAccounts(ctx context.Context, panType int) ([]*model.NetdiskAccount, error) {
+	importLegacyAccounts(ctx, m.configRepo, m.accountRepo)
+
+	accounts, err := m.accountRepo.ListByStatus(ctx, panType, []int{model.NetdiskAccountEnabled})
+	if err != nil {
+		return nil, fmt.Errorf("读取网盘账号失败: %w", err)
+	}
+
+	available := make([]*model.NetdiskAccount, 0, len(accounts))
+	for _, account := range accounts {
+		if strings.TrimSpace(account.Credential) != "" {
+			available = append(available, account)
+		}
+	}
+	return available, nil
+}
+
+// newAccountClient 为账号创建客户端实例
+func (m *netdiskManager) newAccountClient(account *model.NetdiskAccount) (*AccountClient, error) {
+	keys, ok := panTypeConfigKeys[account.PanType]
+	if !ok {
+		return nil, fmt.Errorf("不支持的网盘类型: %d", account.PanType)
+	}
+
+	configRepo := &accountConfigRepo{
+		ConfigRepository: m.configRepo,
+		account:          account,
+		keys:             keys,
+	}
+
+	var client Netdisk
+	switch account.PanType {
 	case model.PanTypeQuark:
-		// 每次都从数据库读取最新配置并创建新实例
-		quarkCookie, _ := m.configRepo.GetByName(ctx, "quark_cookie")
-		client = quark.NewQuarkClient(getConfigValue(quarkCookie), m.configRepo)
-		
+		client = quark.NewQuarkClient(account.Credential, configRepo)
 	case model.PanTypeBaidu:
-		// 每次都从数据库读取最新配置并创建新实例
-		baiduCookie, _ := m.configRepo.GetByName(ctx, "baidu_cookie")
-		client = baidu.NewBaiduClient(getConfigValue(baiduCookie), m.configRepo)
-		
+		client = baidu.NewBaiduClient(account.Credential, configRepo)
 	case model.PanTypeAliyun:
-		// 每次都从数据库读取最新配置并创建新实例
-		aliAuth, _ := m.configRepo.GetByName(ctx, "Authorization")
-		client = aliyun.NewAliyunClient(getConfigValue(aliAuth), m.configRepo)
-		
+		client = aliyun.NewAliyunClient(account.Credential, configRepo)
 	case model.PanTypeUC:
-		// 每次都从数据库读取最新配置并创建新实例
-		ucCookie, _ := m.configRepo.GetByName(ctx, "uc_cookie")
-		client = uc.NewUCClient(getConfigValue(ucCookie), m.configRepo)
-		
+		client = uc.NewUCClient(account.Credential, configRepo)
 	case model.PanTypeXunlei:
-		// 每次都从数据库读取最新配置并创建新实例
-		xunleiCookie, _ := m.configRepo.GetByName(ctx, "xunlei_cookie")
-		client = xunlei.NewXunleiClient(getConfigValue(xunleiCookie), m.configRepo)
-		
-	default:
-		return nil, fmt.Errorf("不支持的网盘类型: %d", panType)
-	}
-
-	if !client.IsConfigured() {
-		return nil, fmt.Errorf("网盘未配置: %s", client.GetName())
+		client = xunlei.NewXunleiClient(account.Credential, configRepo)
+	case model.PanTypeTianyi:
+		client = tianyi.NewTianyiClient(account.Credential, configRepo)
+	case model.PanType123:
+		client = pan123.NewPan123Client(account.Credential, configRepo)
+	case model.PanType115:
+		client = pan115.NewPan115Client(account.Credential, configRepo)
 	}
 
-	return client, nil
-}
\ No newline at end of file
+	return &AccountClient{
+		Netdisk:     client,
+		account:     account,
+		accountRepo: m.accountRepo,
+	}, nil
+}
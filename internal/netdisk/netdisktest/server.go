@@ -0,0 +1,172 @@
+﻿// Package netdisktest 提供网盘客户端测试用的伪造HTTP服务
+//
+// 网盘客户端内部写死了各网盘的API地址，伪造服务通过替换HTTP传输层，
+// 把发往任意域名的请求都转发到本地httptest服务，并保留原始Host用于路由匹配。
+// 同包测试中把客户端的httpClient替换为 Server.Client() 即可。
+package netdisktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Request 伪造服务收到的请求记录
+type Request struct {
+	Method string
+	Host   string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Form 解析表单格式的请求体
+func (r Request) Form() url.Values {
+	values, _ := url.ParseQuery(string(r.Body))
+	return values
+}
+
+// JSON 解析JSON格式的请求体
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Server 伪造的网盘API服务
+type Server struct {
+	*httptest.Server
+
+	t        testing.TB
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []Request
+}
+
+// NewServer 创建伪造服务，测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:      t,
+		routes: make(map[string]http.HandlerFunc),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Handle 注册路由，route格式为 "host/path" 或 "/path"（不区分域名）
+func (s *Server) Handle(method, route string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[method+" "+route] = handler
+}
+
+// HandleJSON 注册返回固定JSON的路由
+func (s *Server) HandleJSON(method, route string, v interface{}) {
+	s.Handle(method, route, func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, v)
+	})
+}
+
+// HandleSequence 注册按调用顺序依次返回的JSON，超出后重复返回最后一个
+func (s *Server) HandleSequence(method, route string, responses ...interface{}) {
+	var mu sync.Mutex
+	calls := 0
+	s.Handle(method, route, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		idx := calls
+		if idx >= len(responses) {
+			idx = len(responses) - 1
+		}
+		calls++
+		mu.Unlock()
+		WriteJSON(w, responses[idx])
+	})
+}
+
+// Requests 获取匹配路由的请求记录
+func (s *Server) Requests(route string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []Request
+	for _, req := range s.requests {
+		if req.Host+req.Path == route || req.Path == route {
+			matched = append(matched, req)
+		}
+	}
+	return matched
+}
+
+// Client 返回把所有请求转发到伪造服务的HTTP客户端
+func (s *Server) Client() *http.Client {
+	return &http.Client{Transport: s.Transport()}
+}
+
+// Transport 返回把所有请求转发到伪造服务的传输层
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+	base := s.Server.Client().Transport
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		forwarded := req.Clone(req.Context())
+		forwarded.Host = req.URL.Host
+		forwarded.URL.Scheme = target.Scheme
+		forwarded.URL.Host = target.Host
+		return base.RoundTrip(forwarded)
+	})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	host := r.Host
+	if idx := strings.LastIndex(host, ":"); idx != -1 && !strings.Contains(host[idx:], "]") {
+		host = host[:idx]
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Host:   host,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	handler, ok := s.routes[r.Method+" "+host+r.URL.Path]
+	if !ok {
+		handler, ok = s.routes[r.Method+" "+r.URL.Path]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		s.t.Errorf("netdisktest: 未注册的请求 %s %s%s", r.Method, host, r.URL.Path)
+		http.Error(w, fmt.Sprintf("no route for %s %s%s", r.Method, host, r.URL.Path), http.StatusNotFound)
+		return
+	}
+	handler(w, r)
+}
+
+// WriteJSON 输出JSON响应
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if raw, ok := v.(string); ok {
+		_, _ = io.WriteString(w, raw)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
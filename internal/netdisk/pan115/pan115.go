@@ -0,0 +1,558 @@
+﻿package pan115
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	webAPIBase   = "https://webapi.115.com"
+	rootFolderID = "0"
+	pageSize     = 100
+)
+
+// shareCodePattern 分享码提取规则，支持115.com、115cdn.com、anxia.com域名
+var shareCodePattern = regexp.MustCompile(`(?:115\.com|115cdn\.com|anxia\.com)/s/([a-zA-Z0-9]+)`)
+
+// receiveCodePattern 链接中携带的访问码
+var receiveCodePattern = regexp.MustCompile(`password=([a-zA-Z0-9]{4})`)
+
+// Pan115Client 115网盘客户端
+type Pan115Client struct {
+	cookie     string
+	httpClient *http.Client
+	configRepo repository.ConfigRepository
+}
+
+// NewPan115Client 创建115网盘客户端 - 只需要cookie（UID、CID、SEID）
+func NewPan115Client(cookie string, configRepo repository.ConfigRepository) *Pan115Client {
+	return &Pan115Client{
+		cookie:     cookie,
+		configRepo: configRepo,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Transfer 实现转存功能
+func (c *Pan115Client) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	// 1. 从分享链接提取分享码和访问码
+	shareCode, err := c.extractShareCode(shareURL)
+	if err != nil {
+		return nil, fmt.Errorf("提取分享码失败: %w", err)
+	}
+	password = receiveCode(shareURL, password)
+
+	// 2. 获取分享详情
+	snap, err := c.getShareSnap(ctx, shareCode, password)
+	if err != nil {
+		return nil, fmt.Errorf("获取分享详情失败: %w", err)
+	}
+	if len(snap.List) == 0 {
+		return nil, fmt.Errorf("分享内容为空")
+	}
+
+	// 3. 动态获取转存目录
+	folderID, err := c.getToPdirFid(ctx, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("获取转存目录失败: %w", err)
+	}
+
+	// 4. 转存文件到自己的网盘
+	fileIDs := make([]string, 0, len(snap.List))
+	for _, file := range snap.List {
+		fileIDs = append(fileIDs, file.id())
+	}
+	if err := c.receive(ctx, shareCode, password, fileIDs, folderID); err != nil {
+		return nil, fmt.Errorf("转存文件失败: %w", err)
+	}
+
+	// 5. 在转存目录中找到刚保存的文件
+	savedIDs, err := c.findSavedFiles(ctx, folderID, snap.List)
+	if err != nil {
+		return nil, fmt.Errorf("查找转存文件失败: %w", err)
+	}
+
+	// 6. 创建新的分享链接
+	newShareURL, newPassword, err := c.createShare(ctx, savedIDs, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("创建分享失败: %w", err)
+	}
+
+	title := snap.ShareInfo.ShareTitle
+	if title == "" {
+		title = snap.List[0].Name
+	}
+
+	result := &model.TransferResult{
+		Title:       title,
+		OriginalURL: shareURL,
+		ShareURL:    newShareURL,
+		Password:    newPassword,
+		Success:     true,
+		Message:     "转存成功",
+	}
+
+	return result, nil
+}
+
+// getToPdirFid 根据过期类型动态获取转存目录
+func (c *Pan115Client) getToPdirFid(ctx context.Context, expiredType int) (string, error) {
+	configKey := "pan115_file" // 默认存储目录
+	if expiredType == 2 {
+		configKey = "pan115_file_time" // 临时资源目录
+	}
+
+	folderID, err := c.configRepo.Get(ctx, configKey)
+	if err != nil {
+		return "", fmt.Errorf("读取配置%s失败: %w", configKey, err)
+	}
+
+	if folderID == "" {
+		return rootFolderID, nil
+	}
+
+	return folderID, nil
+}
+
+// extractShareCode 从分享链接提取分享码
+func (c *Pan115Client) extractShareCode(shareURL string) (string, error) {
+	// 115网盘分享链接格式: https://115.com/s/abc123?password=xxxx
+	matches := shareCodePattern.FindStringSubmatch(shareURL)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("无效的分享链接")
+	}
+	return matches[1], nil
+}
+
+// receiveCode 未传入访问码时从链接中提取
+func receiveCode(shareURL, password string) string {
+	if password == "" {
+		if matches := receiveCodePattern.FindStringSubmatch(shareURL); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+	return password
+}
+
+// getShareSnap 获取分享根目录详情
+func (c *Pan115Client) getShareSnap(ctx context.Context, shareCode, receiveCode string) (*ShareSnap, error) {
+	params := url.Values{
+		"share_code":   {shareCode},
+		"receive_code": {receiveCode},
+		"offset":       {"0"},
+		"limit":        {strconv.Itoa(pageSize)},
+		"cid":          {""},
+	}
+
+	var result struct {
+		apiResponse
+		Data ShareSnap `json:"data"`
+	}
+	if err := c.doRequest(ctx, "GET", webAPIBase+"/share/snap", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return &result.Data, nil
+}
+
+// receive 转存分享文件到指定目录
+func (c *Pan115Client) receive(ctx context.Context, shareCode, receiveCode string, fileIDs []string, toFolderID string) error {
+	form := url.Values{
+		"share_code":   {shareCode},
+		"receive_code": {receiveCode},
+		"file_id":      {strings.Join(fileIDs, ",")},
+		"cid":          {toFolderID},
+	}
+
+	var result apiResponse
+	if err := c.doForm(ctx, webAPIBase+"/share/receive", form, &result); err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// listFiles 列出目录下的文件和文件夹（按修改时间倒序）
+func (c *Pan115Client) listFiles(ctx context.Context, folderID string) ([]File, error) {
+	params := url.Values{
+		"aid":      {"1"},
+		"cid":      {folderID},
+		"o":        {"user_ptime"},
+		"asc":      {"0"},
+		"offset":   {"0"},
+		"show_dir": {"1"},
+		"limit":    {strconv.Itoa(pageSize)},
+		"format":   {"json"},
+	}
+
+	var result struct {
+		apiResponse
+		Data []File `json:"data"`
+	}
+	if err := c.doRequest(ctx, "GET", webAPIBase+"/files", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// findSavedFiles 在转存目录中按文件名找到刚保存的文件ID
+func (c *Pan115Client) findSavedFiles(ctx context.Context, folderID string, shareFiles []File) ([]string, error) {
+	files, err := c.listFiles(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileIDs := make([]string, 0, len(shareFiles))
+	for _, shared := range shareFiles {
+		for _, file := range files {
+			if file.Name == shared.Name && file.isDir() == shared.isDir() {
+				fileIDs = append(fileIDs, file.id())
+				break
+			}
+		}
+	}
+
+	if len(fileIDs) == 0 {
+		return nil, fmt.Errorf("未找到转存后的文件")
+	}
+	return fileIDs, nil
+}
+
+// createShare 创建分享，临时资源分享1天后过期（115不支持2天，取最接近的有效期）
+func (c *Pan115Client) createShare(ctx context.Context, fileIDs []string, expiredType int) (string, string, error) {
+	form := url.Values{
+		"file_ids":    {strings.Join(fileIDs, ",")},
+		"ignore_warn": {"1"},
+		"is_asc":      {"0"},
+		"order":       {"file_name"},
+	}
+
+	var result struct {
+		apiResponse
+		Data struct {
+			ShareCode   string `json:"share_code"`
+			ReceiveCode string `json:"receive_code"`
+			ShareURL    string `json:"share_url"`
+		} `json:"data"`
+	}
+	if err := c.doForm(ctx, webAPIBase+"/share/send", form, &result); err != nil {
+		return "", "", err
+	}
+	if err := result.err(); err != nil {
+		return "", "", err
+	}
+	if result.Data.ShareCode == "" {
+		return "", "", fmt.Errorf("未返回分享链接")
+	}
+
+	// 默认创建的分享有效期为7天，需要更新为永久或临时
+	duration := "-1" // 永久
+	if expiredType == 2 {
+		duration = "1"
+	}
+	updateForm := url.Values{
+		"share_code":     {result.Data.ShareCode},
+		"share_duration": {duration},
+	}
+	var updateResult apiResponse
+	if err := c.doForm(ctx, webAPIBase+"/share/updateshare", updateForm, &updateResult); err != nil {
+		return "", "", fmt.Errorf("设置分享有效期失败: %w", err)
+	}
+	if err := updateResult.err(); err != nil {
+		return "", "", fmt.Errorf("设置分享有效期失败: %w", err)
+	}
+
+	shareURL := result.Data.ShareURL
+	if shareURL == "" {
+		shareURL = "https://115.com/s/" + result.Data.ShareCode
+	}
+	if result.Data.ReceiveCode != "" && !strings.Contains(shareURL, "password=") {
+		shareURL += "?password=" + result.Data.ReceiveCode
+	}
+
+	return shareURL, result.Data.ReceiveCode, nil
+}
+
+// doRequest 执行GET请求
+func (c *Pan115Client) doRequest(ctx context.Context, method, urlStr string, params url.Values, result interface{}) error {
+	if len(params) > 0 {
+		urlStr += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	return c.do(req, result)
+}
+
+// doForm 执行表单POST请求
+func (c *Pan115Client) doForm(ctx context.Context, urlStr string, form url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", urlStr, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, result)
+}
+
+// do 设置公共请求头并解析JSON响应
+func (c *Pan115Client) do(req *http.Request, result interface{}) error {
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Referer", "https://115.com/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Cookie", c.cookie)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败,状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, body: %s", err, string(respBody))
+	}
+
+	return nil
+}
+
+// 数据结构
+
+// apiError 115接口返回的业务错误
+type apiError struct {
+	errno   string
+	message string
+}
+
+func (e *apiError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("请求失败 (错误码:%s)", e.errno)
+	}
+	return fmt.Sprintf("%s (错误码:%s)", e.message, e.errno)
+}
+
+// apiResponse 115接口公共响应字段，state为true表示成功
+type apiResponse struct {
+	State bool            `json:"state"`
+	Error string          `json:"error"`
+	Errno json.RawMessage `json:"errno"` // 不同接口返回数字或字符串
+}
+
+func (r *apiResponse) err() error {
+	if r.State {
+		return nil
+	}
+	errno := strings.Trim(string(r.Errno), `"`)
+	// 990001: 登录超时
+	if errno == "990001" {
+		return fmt.Errorf("Cookie已过期或无效，请重新获取")
+	}
+	return &apiError{errno: errno, message: r.Error}
+}
+
+type ShareSnap struct {
+	ShareInfo struct {
+		ShareTitle string `json:"share_title"`
+	} `json:"shareinfo"`
+	Count int    `json:"count"`
+	List  []File `json:"list"`
+}
+
+// File 115文件/文件夹：文件有fid，文件夹只有cid
+type File struct {
+	FileID   string `json:"fid"`
+	FolderID string `json:"cid"`
+	Name     string `json:"n"`
+	Size     int64  `json:"s"`
+}
+
+func (f File) isDir() bool {
+	return f.FileID == ""
+}
+
+func (f File) id() string {
+	if f.isDir() {
+		return f.FolderID
+	}
+	return f.FileID
+}
+
+// shareErrnos 分享接口错误码对应的链接状态
+var shareErrnos = map[string]model.LinkStatus{
+	"4100012": model.LinkStatusNeedPassword, // 访问码错误
+	"4100013": model.LinkStatusNeedPassword, // 缺少访问码
+	"4100009": model.LinkStatusDead,         // 分享已取消
+	"4100010": model.LinkStatusDead,         // 分享已过期
+	"4100026": model.LinkStatusDead,         // 分享已被删除或违规
+}
+
+// linkStatusOf 根据接口错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		if status, ok := shareErrnos[apiErr.errno]; ok {
+			return status
+		}
+	}
+	return model.LinkStatusUnknown
+}
+
+// CheckLink 检查115分享链接状态（复用转存流程的分享详情接口）
+func (c *Pan115Client) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	shareCode, err := c.extractShareCode(shareURL)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+
+	snap, err := c.getShareSnap(ctx, shareCode, receiveCode(shareURL, password))
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+	if len(snap.List) == 0 {
+		return model.LinkStatusDead, fmt.Errorf("分享内容为空")
+	}
+	return model.LinkStatusAlive, nil
+}
+
+// GetName 获取网盘名称
+func (c *Pan115Client) GetName() string {
+	return "115网盘"
+}
+
+// IsConfigured 检查是否已配置 - 实时从数据库读取
+func (c *Pan115Client) IsConfigured() bool {
+	// 先检查初始化时的cookie
+	if c.cookie != "" {
+		return true
+	}
+
+	// 如果初始化时没有cookie，尝试从数据库读取最新配置
+	if c.configRepo != nil {
+		ctx := context.Background()
+		conf, err := c.configRepo.GetByName(ctx, "pan115_cookie")
+		if err == nil && conf != nil && conf.Value != "" {
+			// 更新内存中的cookie
+			c.cookie = conf.Value
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeleteDirectory 删除指定目录（dirPath可以是根目录下的目录名或目录ID）
+func (c *Pan115Client) DeleteDirectory(ctx context.Context, dirPath string) error {
+	// 1. 列出根目录找到目标目录
+	files, err := c.listFiles(ctx, rootFolderID)
+	if err != nil {
+		return fmt.Errorf("列出根目录失败: %w", err)
+	}
+
+	targetID := ""
+	for _, file := range files {
+		if file.isDir() && (file.Name == dirPath || file.FolderID == dirPath) {
+			targetID = file.FolderID
+			break
+		}
+	}
+
+	if targetID == "" {
+		return fmt.Errorf("目录不存在: %s", dirPath)
+	}
+
+	// 2. 删除目录
+	form := url.Values{
+		"fid[0]": {targetID},
+		"pid":    {rootFolderID},
+	}
+
+	var result apiResponse
+	if err := c.doForm(ctx, webAPIBase+"/rb/delete", form, &result); err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// CreateDirectory 创建指定目录
+func (c *Pan115Client) CreateDirectory(ctx context.Context, dirPath string) error {
+	form := url.Values{
+		"pid":   {rootFolderID}, // 在根目录创建
+		"cname": {dirPath},
+	}
+
+	var result apiResponse
+	if err := c.doForm(ctx, webAPIBase+"/files/add", form, &result); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	return nil
+}
+
+// TestConnection 测试115网盘连接
+func (c *Pan115Client) TestConnection(ctx context.Context) error {
+	// 测试策略：获取空间信息，验证cookie是否有效
+	if _, _, err := c.GetCapacity(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCapacity 获取115网盘容量（字节）
+func (c *Pan115Client) GetCapacity(ctx context.Context) (int64, int64, error) {
+	var result struct {
+		apiResponse
+		Data struct {
+			SpaceInfo struct {
+				AllTotal struct {
+					Size int64 `json:"size"`
+				} `json:"all_total"`
+				AllUse struct {
+					Size int64 `json:"size"`
+				} `json:"all_use"`
+			} `json:"space_info"`
+		} `json:"data"`
+	}
+
+	if err := c.doRequest(ctx, "GET", webAPIBase+"/files/index_info", nil, &result); err != nil {
+		return 0, 0, fmt.Errorf("网络请求失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return 0, 0, fmt.Errorf("连接失败: %w", err)
+	}
+
+	return result.Data.SpaceInfo.AllTotal.Size, result.Data.SpaceInfo.AllUse.Size, nil
+}
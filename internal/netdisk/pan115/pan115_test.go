@@ -0,0 +1,228 @@
+﻿package pan115
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const (
+	snapRoute    = "webapi.115.com/share/snap"
+	receiveRoute = "webapi.115.com/share/receive"
+	filesRoute   = "webapi.115.com/files"
+	sendRoute    = "webapi.115.com/share/send"
+	updateRoute  = "webapi.115.com/share/updateshare"
+)
+
+// newFakePan115 注册一次完整转存流程的成功响应
+func newFakePan115(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("GET", snapRoute, map[string]interface{}{
+		"state": true,
+		"data": map[string]interface{}{
+			"shareinfo": map[string]string{"share_title": "流浪地球2"},
+			"count":     2,
+			"list": []map[string]interface{}{
+				{"cid": "c1", "n": "流浪地球2"},
+				{"fid": "f1", "cid": "c0", "n": "说明.txt", "s": 10},
+			},
+		},
+	})
+	srv.HandleJSON("POST", receiveRoute, map[string]interface{}{"state": true})
+	srv.HandleJSON("GET", filesRoute, map[string]interface{}{
+		"state": true,
+		"data": []map[string]interface{}{
+			{"cid": "c9", "n": "流浪地球2"},
+			{"fid": "f9", "cid": "dir", "n": "说明.txt"},
+			{"fid": "f8", "cid": "dir", "n": "流浪地球2"},
+		},
+	})
+	srv.HandleJSON("POST", sendRoute, map[string]interface{}{
+		"state": true,
+		"data":  map[string]string{"share_code": "mine1", "receive_code": "ab12", "share_url": "https://115.com/s/mine1"},
+	})
+	srv.HandleJSON("POST", updateRoute, map[string]interface{}{"state": true})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *Pan115Client {
+	c := NewPan115Client("UID=1; CID=2; SEID=3", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakePan115(t)
+	c := newTestClient(srv, map[string]string{"pan115_file": "", "pan115_file_time": "tmp-cid"})
+
+	result, err := c.Transfer(context.Background(), "https://115cdn.com/s/abc123?password=x9y8", "", 2)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://115.com/s/mine1?password=ab12" || result.Password != "ab12" || result.Title != "流浪地球2" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	// 访问码从链接中解析
+	snap := srv.Requests(snapRoute)[0].Query
+	if snap.Get("share_code") != "abc123" || snap.Get("receive_code") != "x9y8" {
+		t.Errorf("share/snap query = %v", snap)
+	}
+
+	// 文件夹用cid、文件用fid转存，临时资源转存到pan115_file_time目录
+	receive := srv.Requests(receiveRoute)[0].Form()
+	if receive.Get("file_id") != "c1,f1" || receive.Get("cid") != "tmp-cid" || receive.Get("receive_code") != "x9y8" {
+		t.Errorf("share/receive form = %v", receive)
+	}
+
+	// 按名称和类型匹配转存后的文件，临时资源分享1天
+	if ids := srv.Requests(sendRoute)[0].Form().Get("file_ids"); ids != "c9,f9" {
+		t.Errorf("file_ids = %q, want c9,f9", ids)
+	}
+	update := srv.Requests(updateRoute)[0].Form()
+	if update.Get("share_code") != "mine1" || update.Get("share_duration") != "1" {
+		t.Errorf("share/updateshare form = %v", update)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		route   string
+		method  string
+		resp    interface{}
+		configs map[string]string
+		wantErr string
+	}{
+		{
+			name:    "无效链接",
+			url:     "https://115.com/abc",
+			wantErr: "提取分享码失败: 无效的分享链接",
+		},
+		{
+			name:    "访问码错误",
+			url:     "https://115.com/s/abc123",
+			route:   snapRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"state": false, "error": "访问码错误", "errno": 4100012},
+			wantErr: "获取分享详情失败: 访问码错误 (错误码:4100012)",
+		},
+		{
+			name:    "Cookie失效",
+			url:     "https://115.com/s/abc123",
+			route:   receiveRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"state": false, "errno": "990001"},
+			wantErr: "转存文件失败: Cookie已过期或无效",
+		},
+		{
+			name:    "无错误信息",
+			url:     "https://115.com/s/abc123",
+			route:   sendRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"state": false, "errno": 20001},
+			wantErr: "创建分享失败: 请求失败 (错误码:20001)",
+		},
+		{
+			name:    "设置有效期失败",
+			url:     "https://115.com/s/abc123",
+			route:   updateRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"state": false, "error": "参数错误", "errno": 1},
+			wantErr: "创建分享失败: 设置分享有效期失败: 参数错误",
+		},
+		{
+			name:    "未配置转存目录",
+			url:     "https://115.com/s/abc123",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePan115(t)
+			if tt.route != "" {
+				srv.HandleJSON(tt.method, tt.route, tt.resp)
+			}
+			configs := tt.configs
+			if configs == nil {
+				configs = map[string]string{"pan115_file": "dir"}
+			}
+			c := newTestClient(srv, configs)
+
+			_, err := c.Transfer(context.Background(), tt.url, "", 1)
+			if err == nil {
+				t.Fatalf("Transfer() error = nil, want %q", tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %q, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name string
+		resp interface{}
+		want model.LinkStatus
+	}{
+		{
+			name: "有效",
+			want: model.LinkStatusAlive,
+		},
+		{
+			name: "访问码错误",
+			resp: map[string]interface{}{"state": false, "error": "访问码错误", "errno": 4100012},
+			want: model.LinkStatusNeedPassword,
+		},
+		{
+			name: "已取消",
+			resp: map[string]interface{}{"state": false, "error": "分享已取消", "errno": "4100009"},
+			want: model.LinkStatusDead,
+		},
+		{
+			name: "内容为空",
+			resp: map[string]interface{}{"state": true, "data": map[string]interface{}{"list": []interface{}{}}},
+			want: model.LinkStatusDead,
+		},
+		{
+			// 登录失效等账号问题不能判定链接失效
+			name: "Cookie失效",
+			resp: map[string]interface{}{"state": false, "error": "分享链接已失效，请重新登录", "errno": 990001},
+			want: model.LinkStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePan115(t)
+			if tt.resp != nil {
+				srv.HandleJSON("GET", snapRoute, tt.resp)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), "https://115.com/s/abc123?password=x9y8", "")
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+			if code := srv.Requests(snapRoute)[0].Query.Get("receive_code"); code != "x9y8" {
+				t.Errorf("receive_code = %q, want x9y8", code)
+			}
+		})
+	}
+}
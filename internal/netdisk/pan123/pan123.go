@@ -0,0 +1,611 @@
+﻿package pan123
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	apiBase       = "https://www.123pan.com"
+	shareURLBase  = "https://www.123pan.com/s/"
+	rootFolderID  = "0"
+	pageSize      = 100
+	permanentTime = "2099-12-12T08:00:00+08:00"
+)
+
+// shareKeyPattern 分享key提取规则，123网盘有多个域名：123pan.com、123684.com、123912.com等
+var shareKeyPattern = regexp.MustCompile(`123(?:684|685|865|912|pan|592)\.(?:com|cn)/s/([a-zA-Z0-9_-]+)`)
+
+// sharePwdPattern 链接中携带的提取码
+var sharePwdPattern = regexp.MustCompile(`(?:提取码|%E6%8F%90%E5%8F%96%E7%A0%81|pwd=)[:：]?([a-zA-Z0-9]{4})`)
+
+// Pan123Client 123网盘客户端
+type Pan123Client struct {
+	token      string
+	httpClient *http.Client
+	configRepo repository.ConfigRepository
+}
+
+// NewPan123Client 创建123网盘客户端 - 只需要登录后的Authorization Token
+func NewPan123Client(token string, configRepo repository.ConfigRepository) *Pan123Client {
+	return &Pan123Client{
+		token:      strings.TrimPrefix(strings.TrimSpace(token), "Bearer "),
+		configRepo: configRepo,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Transfer 实现转存功能
+func (c *Pan123Client) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	// 1. 从分享链接提取shareKey和提取码
+	shareKey, err := c.extractShareKey(shareURL)
+	if err != nil {
+		return nil, fmt.Errorf("提取shareKey失败: %w", err)
+	}
+	password = sharePassword(shareURL, password)
+
+	// 2. 获取分享文件列表
+	shareFiles, err := c.getShareFiles(ctx, shareKey, password)
+	if err != nil {
+		return nil, fmt.Errorf("获取分享详情失败: %w", err)
+	}
+	if len(shareFiles) == 0 {
+		return nil, fmt.Errorf("分享内容为空")
+	}
+
+	// 3. 动态获取转存目录
+	folderID, err := c.getToPdirFid(ctx, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("获取转存目录失败: %w", err)
+	}
+
+	// 4. 转存文件到自己的网盘
+	if err := c.saveFiles(ctx, shareKey, password, shareFiles, folderID); err != nil {
+		return nil, fmt.Errorf("转存文件失败: %w", err)
+	}
+
+	// 5. 在转存目录中找到刚保存的文件
+	fileIDs, err := c.findSavedFiles(ctx, folderID, shareFiles)
+	if err != nil {
+		return nil, fmt.Errorf("查找转存文件失败: %w", err)
+	}
+
+	// 6. 创建新的分享链接
+	title := shareFiles[0].FileName
+	newShareURL, newPassword, err := c.createShare(ctx, fileIDs, title, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("创建分享失败: %w", err)
+	}
+
+	result := &model.TransferResult{
+		Title:       title,
+		OriginalURL: shareURL,
+		ShareURL:    newShareURL,
+		Password:    newPassword,
+		Success:     true,
+		Message:     "转存成功",
+	}
+
+	return result, nil
+}
+
+// getToPdirFid 根据过期类型动态获取转存目录
+func (c *Pan123Client) getToPdirFid(ctx context.Context, expiredType int) (string, error) {
+	configKey := "pan123_file" // 默认存储目录
+	if expiredType == 2 {
+		configKey = "pan123_file_time" // 临时资源目录
+	}
+
+	folderID, err := c.configRepo.Get(ctx, configKey)
+	if err != nil {
+		return "", fmt.Errorf("读取配置%s失败: %w", configKey, err)
+	}
+
+	if folderID == "" {
+		return rootFolderID, nil
+	}
+
+	return folderID, nil
+}
+
+// extractShareKey 从分享链接提取shareKey
+func (c *Pan123Client) extractShareKey(shareURL string) (string, error) {
+	// 123网盘分享链接格式: https://www.123pan.com/s/abc-123
+	matches := shareKeyPattern.FindStringSubmatch(shareURL)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("无效的分享链接")
+	}
+	return matches[1], nil
+}
+
+// sharePassword 未传入提取码时从链接中提取
+func sharePassword(shareURL, password string) string {
+	if password == "" {
+		if matches := sharePwdPattern.FindStringSubmatch(shareURL); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+	return password
+}
+
+// getShareFiles 获取分享根目录下的文件列表
+func (c *Pan123Client) getShareFiles(ctx context.Context, shareKey, password string) ([]FileInfo, error) {
+	params := url.Values{
+		"limit":          {strconv.Itoa(pageSize)},
+		"next":           {"1"},
+		"orderBy":        {"file_name"},
+		"orderDirection": {"asc"},
+		"shareKey":       {shareKey},
+		"SharePwd":       {password},
+		"ParentFileId":   {"0"},
+		"Page":           {"1"},
+	}
+
+	var result struct {
+		apiResponse
+		Data struct {
+			InfoList []FileInfo `json:"InfoList"`
+		} `json:"data"`
+	}
+	if err := c.doRequest(ctx, "GET", apiBase+"/b/api/share/get", params, nil, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return result.Data.InfoList, nil
+}
+
+// saveFiles 转存分享文件
+func (c *Pan123Client) saveFiles(ctx context.Context, shareKey, password string, files []FileInfo, toFolderID string) error {
+	parentID, _ := strconv.ParseInt(toFolderID, 10, 64)
+
+	fileList := make([]map[string]interface{}, 0, len(files))
+	for _, file := range files {
+		fileList = append(fileList, map[string]interface{}{
+			"fileId":       file.FileID,
+			"size":         file.Size,
+			"etag":         file.Etag,
+			"type":         file.Type,
+			"parentFileId": parentID,
+			"fileName":     file.FileName,
+			"driveId":      0,
+		})
+	}
+
+	body := map[string]interface{}{
+		"fileList":     fileList,
+		"shareKey":     shareKey,
+		"sharePwd":     password,
+		"currentLevel": 1,
+	}
+
+	var result struct {
+		apiResponse
+		Data struct {
+			TaskID int64 `json:"TaskId"`
+		} `json:"data"`
+	}
+	if err := c.doRequest(ctx, "POST", apiBase+"/b/api/file/copy/async", nil, body, &result); err != nil {
+		return err
+	}
+	if err := result.err(); err != nil {
+		return err
+	}
+	if result.Data.TaskID == 0 {
+		return nil
+	}
+
+	return c.waitForTask(ctx, result.Data.TaskID)
+}
+
+// waitForTask 等待转存任务完成
+func (c *Pan123Client) waitForTask(ctx context.Context, taskID int64) error {
+	maxRetries := 30
+	for i := 0; i < maxRetries; i++ {
+		params := url.Values{
+			"taskId": {strconv.FormatInt(taskID, 10)},
+		}
+
+		var result struct {
+			apiResponse
+			Data struct {
+				Status int `json:"status"`
+			} `json:"data"`
+		}
+		if err := c.doRequest(ctx, "GET", apiBase+"/b/api/file/copy/task", params, nil, &result); err != nil {
+			return err
+		}
+		if err := result.err(); err != nil {
+			return err
+		}
+
+		// status: 1=进行中 2=完成 3=失败
+		switch result.Data.Status {
+		case 2:
+			return nil
+		case 3:
+			return fmt.Errorf("任务失败")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return fmt.Errorf("任务超时")
+}
+
+// listFiles 列出目录下的文件（按更新时间倒序）
+func (c *Pan123Client) listFiles(ctx context.Context, parentID string) ([]FileInfo, error) {
+	params := url.Values{
+		"driveId":        {"0"},
+		"limit":          {strconv.Itoa(pageSize)},
+		"next":           {"0"},
+		"orderBy":        {"update_time"},
+		"orderDirection": {"desc"},
+		"parentFileId":   {parentID},
+		"trashed":        {"false"},
+		"Page":           {"1"},
+	}
+
+	var result struct {
+		apiResponse
+		Data struct {
+			InfoList []FileInfo `json:"InfoList"`
+		} `json:"data"`
+	}
+	if err := c.doRequest(ctx, "GET", apiBase+"/b/api/file/list/new", params, nil, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return result.Data.InfoList, nil
+}
+
+// findSavedFiles 在转存目录中按文件名找到刚保存的文件ID
+func (c *Pan123Client) findSavedFiles(ctx context.Context, folderID string, shareFiles []FileInfo) ([]int64, error) {
+	files, err := c.listFiles(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileIDs := make([]int64, 0, len(shareFiles))
+	for _, shared := range shareFiles {
+		for _, file := range files {
+			if file.FileName == shared.FileName && file.Type == shared.Type {
+				fileIDs = append(fileIDs, file.FileID)
+				break
+			}
+		}
+	}
+
+	if len(fileIDs) == 0 {
+		return nil, fmt.Errorf("未找到转存后的文件")
+	}
+	return fileIDs, nil
+}
+
+// createShare 创建分享，临时资源分享2天后过期
+func (c *Pan123Client) createShare(ctx context.Context, fileIDs []int64, title string, expiredType int) (string, string, error) {
+	expiration := permanentTime
+	if expiredType == 2 {
+		expiration = time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	}
+
+	ids := make([]string, 0, len(fileIDs))
+	for _, id := range fileIDs {
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+
+	sharePwd := randomCode(4)
+	body := map[string]interface{}{
+		"driveId":    0,
+		"expiration": expiration,
+		"fileIdList": strings.Join(ids, ","),
+		"shareName":  title,
+		"sharePwd":   sharePwd,
+		"event":      "shareCreate",
+	}
+
+	var result struct {
+		apiResponse
+		Data struct {
+			ShareKey string `json:"ShareKey"`
+		} `json:"data"`
+	}
+	if err := c.doRequest(ctx, "POST", apiBase+"/a/api/share/create", nil, body, &result); err != nil {
+		return "", "", err
+	}
+	if err := result.err(); err != nil {
+		return "", "", err
+	}
+	if result.Data.ShareKey == "" {
+		return "", "", fmt.Errorf("未返回分享链接")
+	}
+
+	return shareURLBase + result.Data.ShareKey + "?提取码:" + sharePwd, sharePwd, nil
+}
+
+// doRequest 执行HTTP请求
+func (c *Pan123Client) doRequest(ctx context.Context, method, urlStr string, params url.Values, body interface{}, result interface{}) error {
+	if len(params) > 0 {
+		urlStr += "?" + params.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求体失败: %w", err)
+		}
+		reqBody = strings.NewReader(string(jsonData))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, reqBody)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 设置请求头
+	req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Origin", apiBase)
+	req.Header.Set("Referer", apiBase+"/")
+	req.Header.Set("Platform", "web")
+	req.Header.Set("App-Version", "3")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败,状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, body: %s", err, string(respBody))
+	}
+
+	return nil
+}
+
+// randomCode 生成分享提取码
+func randomCode(n int) string {
+	const letters = "abcdefghijkmnpqrstuvwxyz23456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// 数据结构
+
+// apiError 123网盘接口返回的业务错误
+type apiError struct {
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (错误码:%d)", e.message, e.code)
+}
+
+// apiResponse 123网盘接口公共响应字段，code为0表示成功
+type apiResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (r *apiResponse) err() error {
+	switch r.Code {
+	case 0:
+		return nil
+	case 401:
+		return fmt.Errorf("Token已过期或无效，请重新获取")
+	}
+	return &apiError{code: r.Code, message: r.Message}
+}
+
+type FileInfo struct {
+	FileID   int64  `json:"FileId"`
+	FileName string `json:"FileName"`
+	Type     int    `json:"Type"` // 0=文件 1=文件夹
+	Size     int64  `json:"Size"`
+	Etag     string `json:"Etag"`
+}
+
+// shareErrorCodes 分享接口错误码对应的链接状态
+var shareErrorCodes = map[int]model.LinkStatus{
+	5103: model.LinkStatusNeedPassword, // 提取码错误
+	5104: model.LinkStatusDead,         // 分享已过期
+	5105: model.LinkStatusDead,         // 分享不存在或已取消
+}
+
+// linkStatusOf 根据接口错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		if status, ok := shareErrorCodes[apiErr.code]; ok {
+			return status
+		}
+	}
+	return model.LinkStatusUnknown
+}
+
+// CheckLink 检查123网盘分享链接状态（复用转存流程的分享文件列表接口）
+func (c *Pan123Client) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	shareKey, err := c.extractShareKey(shareURL)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+
+	files, err := c.getShareFiles(ctx, shareKey, sharePassword(shareURL, password))
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+	if len(files) == 0 {
+		return model.LinkStatusDead, fmt.Errorf("分享内容为空")
+	}
+	return model.LinkStatusAlive, nil
+}
+
+// GetName 获取网盘名称
+func (c *Pan123Client) GetName() string {
+	return "123网盘"
+}
+
+// IsConfigured 检查是否已配置 - 实时从数据库读取
+func (c *Pan123Client) IsConfigured() bool {
+	// 先检查初始化时的token
+	if c.token != "" {
+		return true
+	}
+
+	// 如果初始化时没有token，尝试从数据库读取最新配置
+	if c.configRepo != nil {
+		ctx := context.Background()
+		conf, err := c.configRepo.GetByName(ctx, "pan123_token")
+		if err == nil && conf != nil && conf.Value != "" {
+			// 更新内存中的token
+			c.token = strings.TrimPrefix(strings.TrimSpace(conf.Value), "Bearer ")
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeleteDirectory 删除指定目录（dirPath可以是根目录下的目录名或目录ID）
+func (c *Pan123Client) DeleteDirectory(ctx context.Context, dirPath string) error {
+	// 1. 列出根目录找到目标目录
+	files, err := c.listFiles(ctx, rootFolderID)
+	if err != nil {
+		return fmt.Errorf("列出根目录失败: %w", err)
+	}
+
+	var target *FileInfo
+	for i, file := range files {
+		if file.Type == 1 && (file.FileName == dirPath || strconv.FormatInt(file.FileID, 10) == dirPath) {
+			target = &files[i]
+			break
+		}
+	}
+
+	if target == nil {
+		return fmt.Errorf("目录不存在: %s", dirPath)
+	}
+
+	// 2. 删除目录（移入回收站）
+	body := map[string]interface{}{
+		"driveId":           0,
+		"fileTrashInfoList": []map[string]interface{}{{"FileId": target.FileID}},
+		"operation":         true,
+	}
+
+	var result apiResponse
+	if err := c.doRequest(ctx, "POST", apiBase+"/a/api/file/trash", nil, body, &result); err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// CreateDirectory 创建指定目录
+func (c *Pan123Client) CreateDirectory(ctx context.Context, dirPath string) error {
+	body := map[string]interface{}{
+		"driveId":      0,
+		"etag":         "",
+		"fileName":     dirPath,
+		"parentFileId": 0, // 在根目录创建
+		"size":         0,
+		"type":         1,
+		"duplicate":    1,
+		"NotReuse":     true,
+		"event":        "newCreateFolder",
+		"operateType":  1,
+	}
+
+	var result apiResponse
+	if err := c.doRequest(ctx, "POST", apiBase+"/a/api/file/upload_request", nil, body, &result); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	return nil
+}
+
+// TestConnection 测试123网盘连接
+func (c *Pan123Client) TestConnection(ctx context.Context) error {
+	// 测试策略：获取用户信息，验证token是否有效
+	var result struct {
+		apiResponse
+		Data struct {
+			UID int64 `json:"UID"`
+		} `json:"data"`
+	}
+
+	if err := c.doRequest(ctx, "GET", apiBase+"/b/api/user/info", nil, nil, &result); err != nil {
+		return fmt.Errorf("网络请求失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	if result.Data.UID == 0 {
+		return fmt.Errorf("Token已过期或无效，请重新获取")
+	}
+
+	return nil
+}
+
+// GetCapacity 获取123网盘容量（字节）
+func (c *Pan123Client) GetCapacity(ctx context.Context) (int64, int64, error) {
+	var result struct {
+		apiResponse
+		Data struct {
+			SpacePermanent int64 `json:"SpacePermanent"`
+			SpaceTemp      int64 `json:"SpaceTemp"`
+			SpaceUsed      int64 `json:"SpaceUsed"`
+		} `json:"data"`
+	}
+
+	if err := c.doRequest(ctx, "GET", apiBase+"/b/api/user/info", nil, nil, &result); err != nil {
+		return 0, 0, fmt.Errorf("网络请求失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return 0, 0, fmt.Errorf("获取容量失败: %w", err)
+	}
+
+	return result.Data.SpacePermanent + result.Data.SpaceTemp, result.Data.SpaceUsed, nil
+}
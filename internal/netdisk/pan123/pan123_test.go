@@ -0,0 +1,236 @@
+﻿package pan123
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const (
+	shareGetRoute    = "www.123pan.com/b/api/share/get"
+	copyRoute        = "www.123pan.com/b/api/file/copy/async"
+	copyTaskRoute    = "www.123pan.com/b/api/file/copy/task"
+	listRoute        = "www.123pan.com/b/api/file/list/new"
+	shareCreateRoute = "www.123pan.com/a/api/share/create"
+)
+
+// newFakePan123 注册一次完整转存流程的成功响应
+func newFakePan123(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("GET", shareGetRoute, map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"InfoList": []map[string]interface{}{
+				{"FileId": 11, "FileName": "流浪地球2", "Type": 1, "Size": 0, "Etag": ""},
+			},
+		},
+	})
+	srv.HandleJSON("POST", copyRoute, map[string]interface{}{"code": 0, "data": map[string]interface{}{"TaskId": 99}})
+	srv.HandleJSON("GET", copyTaskRoute, map[string]interface{}{"code": 0, "data": map[string]interface{}{"status": 2}})
+	srv.HandleJSON("GET", listRoute, map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"InfoList": []map[string]interface{}{
+				{"FileId": 21, "FileName": "流浪地球2.mkv", "Type": 0},
+				{"FileId": 22, "FileName": "流浪地球2", "Type": 1},
+			},
+		},
+	})
+	srv.HandleJSON("POST", shareCreateRoute, map[string]interface{}{"code": 0, "data": map[string]string{"ShareKey": "mine-1"}})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *Pan123Client {
+	c := NewPan123Client("Bearer token-1", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakePan123(t)
+	c := newTestClient(srv, map[string]string{"pan123_file": "", "pan123_file_time": "300"})
+
+	result, err := c.Transfer(context.Background(), "https://www.123684.com/s/abc-123?提取码:x9y8", "", 2)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.Title != "流浪地球2" || !strings.HasPrefix(result.ShareURL, "https://www.123pan.com/s/mine-1?提取码:") {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+	if len(result.Password) != 4 || !strings.HasSuffix(result.ShareURL, result.Password) {
+		t.Errorf("Password = %q, ShareURL = %q", result.Password, result.ShareURL)
+	}
+
+	// 提取码从链接中解析，Authorization去掉Bearer前缀后重新拼接
+	get := srv.Requests(shareGetRoute)[0]
+	if get.Query.Get("shareKey") != "abc-123" || get.Query.Get("SharePwd") != "x9y8" {
+		t.Errorf("share/get query = %v", get.Query)
+	}
+	if auth := get.Header.Get("Authorization"); auth != "Bearer token-1" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	// 临时资源转存到pan123_file_time目录
+	var copyBody struct {
+		ShareKey string                   `json:"shareKey"`
+		SharePwd string                   `json:"sharePwd"`
+		FileList []map[string]interface{} `json:"fileList"`
+	}
+	if err := srv.Requests(copyRoute)[0].JSON(&copyBody); err != nil {
+		t.Fatal(err)
+	}
+	if copyBody.SharePwd != "x9y8" || len(copyBody.FileList) != 1 || copyBody.FileList[0]["parentFileId"] != float64(300) {
+		t.Errorf("copy body = %+v", copyBody)
+	}
+
+	// 按文件名和类型找到转存后的文件夹再分享
+	var share map[string]interface{}
+	if err := srv.Requests(shareCreateRoute)[0].JSON(&share); err != nil {
+		t.Fatal(err)
+	}
+	if share["fileIdList"] != "22" || share["expiration"] == permanentTime {
+		t.Errorf("share/create body = %v", share)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		route   string
+		method  string
+		resp    interface{}
+		configs map[string]string
+		wantErr string
+	}{
+		{
+			name:    "无效链接",
+			url:     "https://www.123pan.com/abc",
+			wantErr: "提取shareKey失败: 无效的分享链接",
+		},
+		{
+			name:    "提取码错误",
+			url:     "https://www.123pan.com/s/abc-123",
+			route:   shareGetRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"code": 5103, "message": "提取码错误"},
+			wantErr: "获取分享详情失败: 提取码错误 (错误码:5103)",
+		},
+		{
+			name:    "分享内容为空",
+			url:     "https://www.123pan.com/s/abc-123",
+			route:   shareGetRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"code": 0, "data": map[string]interface{}{"InfoList": []interface{}{}}},
+			wantErr: "分享内容为空",
+		},
+		{
+			name:    "Token失效",
+			url:     "https://www.123pan.com/s/abc-123",
+			route:   copyRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"code": 401, "message": "token expired"},
+			wantErr: "转存文件失败: Token已过期或无效",
+		},
+		{
+			name:    "转存任务失败",
+			url:     "https://www.123pan.com/s/abc-123",
+			route:   copyTaskRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"code": 0, "data": map[string]interface{}{"status": 3}},
+			wantErr: "转存文件失败: 任务失败",
+		},
+		{
+			name:    "未配置转存目录",
+			url:     "https://www.123pan.com/s/abc-123",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePan123(t)
+			if tt.route != "" {
+				srv.HandleJSON(tt.method, tt.route, tt.resp)
+			}
+			configs := tt.configs
+			if configs == nil {
+				configs = map[string]string{"pan123_file": "100"}
+			}
+			c := newTestClient(srv, configs)
+
+			_, err := c.Transfer(context.Background(), tt.url, "", 1)
+			if err == nil {
+				t.Fatalf("Transfer() error = nil, want %q", tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %q, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name string
+		resp interface{}
+		want model.LinkStatus
+	}{
+		{
+			name: "有效",
+			want: model.LinkStatusAlive,
+		},
+		{
+			name: "提取码错误",
+			resp: map[string]interface{}{"code": 5103, "message": "提取码错误"},
+			want: model.LinkStatusNeedPassword,
+		},
+		{
+			name: "已过期",
+			resp: map[string]interface{}{"code": 5104, "message": "分享已过期"},
+			want: model.LinkStatusDead,
+		},
+		{
+			name: "内容为空",
+			resp: map[string]interface{}{"code": 0, "data": map[string]interface{}{"InfoList": []interface{}{}}},
+			want: model.LinkStatusDead,
+		},
+		{
+			// 错误码未知时不根据提示文案猜测
+			name: "未知错误",
+			resp: map[string]interface{}{"code": 500, "message": "分享不存在"},
+			want: model.LinkStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakePan123(t)
+			if tt.resp != nil {
+				srv.HandleJSON("GET", shareGetRoute, tt.resp)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), "https://www.123pan.com/s/abc-123", "x9y8")
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+			if pwd := srv.Requests(shareGetRoute)[0].Query.Get("SharePwd"); pwd != "x9y8" {
+				t.Errorf("SharePwd = %q, want x9y8", pwd)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -186,6 +187,7 @@ func (c *QuarkClient) getStoken(ctx context.Context, pwdID, passcode string) (*S
 
 	var result struct {
 		Status  int    `json:"status"`
+		Code    int    `json:"code"`
 		Message string `json:"message"`
 		Data    StokenResponse `json:"data"`
 	}
@@ -196,7 +198,7 @@ func (c *QuarkClient) getStoken(ctx context.Context, pwdID, passcode string) (*S
 	}
 
 	if result.Status != 200 {
-		return nil, fmt.Errorf("获取stoken失败: %s", result.Message)
+		return nil, &shareError{op: "获取stoken失败", status: result.Status, code: result.Code, message: result.Message}
 	}
 
 	return &result.Data, nil
@@ -222,6 +224,7 @@ func (c *QuarkClient) getShareDetail(ctx context.Context, pwdID, stoken string)
 
 	var result struct {
 		Status  int    `json:"status"`
+		Code    int    `json:"code"`
 		Message string `json:"message"`
 		Data    ShareDetailResponse `json:"data"`
 	}
@@ -232,7 +235,7 @@ func (c *QuarkClient) getShareDetail(ctx context.Context, pwdID, stoken string)
 	}
 
 	if result.Status != 200 {
-		return nil, fmt.Errorf("获取分享详情失败: %s", result.Message)
+		return nil, &shareError{op: "获取分享详情失败", status: result.Status, code: result.Code, message: result.Message}
 	}
 
 	return &result.Data, nil
@@ -725,4 +728,95 @@ func (c *QuarkClient) TestConnection(ctx context.Context) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetCapacity 获取夸克网盘容量（字节）
+func (c *QuarkClient) GetCapacity(ctx context.Context) (int64, int64, error) {
+	params := url.Values{
+		"pr":               {"ucpro"},
+		"fr":               {"pc"},
+		"uc_param_str":     {""},
+		"fetch_subscribe":  {"true"},
+		"_ch":              {"home"},
+		"fetch_identity":   {"true"},
+	}
+
+	var result struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Data    struct {
+			TotalCapacity int64 `json:"total_capacity"`
+			UseCapacity   int64 `json:"use_capacity"`
+		} `json:"data"`
+	}
+
+	if err := c.doRequest(ctx, "GET", "https://drive-pc.quark.cn/1/clouddrive/member", params, nil, &result); err != nil {
+		return 0, 0, fmt.Errorf("网络请求失败: %w", err)
+	}
+	if result.Status != 200 {
+		return 0, 0, fmt.Errorf("获取容量失败: %s (状态码:%d)", result.Message, result.Status)
+	}
+
+	return result.Data.TotalCapacity, result.Data.UseCapacity, nil
+}
+
+// shareError 分享接口返回的业务错误
+type shareError struct {
+	op      string
+	status  int
+	code    int
+	message string
+}
+
+func (e *shareError) Error() string {
+	return e.op + ": " + e.message
+}
+
+// quarkShareCodes 分享接口错误码对应的链接状态
+var quarkShareCodes = map[int]model.LinkStatus{
+	41004: model.LinkStatusDead,         // 分享不存在
+	41006: model.LinkStatusDead,         // 分享已删除
+	41011: model.LinkStatusDead,         // 分享者已取消分享
+	41012: model.LinkStatusDead,         // 分享已过期
+	41007: model.LinkStatusNeedPassword, // 需要提取码
+	41008: model.LinkStatusNeedPassword, // 提取码错误
+}
+
+// linkStatusOf 根据分享接口的错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	var se *shareError
+	if !errors.As(err, &se) {
+		return model.LinkStatusUnknown
+	}
+	if status, ok := quarkShareCodes[se.code]; ok {
+		return status
+	}
+	if se.status == http.StatusNotFound {
+		return model.LinkStatusDead
+	}
+	return model.LinkStatusUnknown
+}
+
+// CheckLink 检查夸克分享链接状态（复用转存流程的stoken和分享详情接口）
+func (c *QuarkClient) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	pwdID, err := c.extractPwdID(shareURL)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+
+	stokenResp, err := c.getStoken(ctx, pwdID, password)
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+
+	stoken := strings.ReplaceAll(stokenResp.Stoken, " ", "+")
+	shareDetail, err := c.getShareDetail(ctx, pwdID, stoken)
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+
+	if len(shareDetail.List) == 0 {
+		return model.LinkStatusDead, fmt.Errorf("分享内容为空")
+	}
+	return model.LinkStatusAlive, nil
+}
@@ -0,0 +1,220 @@
+﻿package quark
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const (
+	tokenRoute  = "drive-pc.quark.cn/1/clouddrive/share/sharepage/token"
+	detailRoute = "drive-pc.quark.cn/1/clouddrive/share/sharepage/detail"
+	saveRoute   = "drive-pc.quark.cn/1/clouddrive/share/sharepage/save"
+	taskRoute   = "drive-pc.quark.cn/1/clouddrive/task"
+	shareRoute  = "drive-pc.quark.cn/1/clouddrive/share"
+	pwdRoute    = "drive-pc.quark.cn/1/clouddrive/share/password"
+)
+
+// newFakeQuark 注册一次完整转存流程的成功响应
+func newFakeQuark(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("POST", tokenRoute, map[string]interface{}{
+		"status": 200,
+		"data":   map[string]string{"stoken": "st ok", "title": "流浪地球2"},
+	})
+	srv.HandleJSON("GET", detailRoute, map[string]interface{}{
+		"status": 200,
+		"data": map[string]interface{}{
+			"list": []map[string]string{{"fid": "f1", "share_fid_token": "t1"}},
+		},
+	})
+	srv.HandleJSON("POST", saveRoute, map[string]interface{}{
+		"status": 200,
+		"data":   map[string]string{"task_id": "save-task"},
+	})
+	srv.HandleJSON("GET", taskRoute, map[string]interface{}{
+		"status": 200,
+		"data": map[string]interface{}{
+			"status":   2,
+			"share_id": "share-1",
+			"save_as":  map[string]interface{}{"save_as_top_fids": []string{"saved-1"}},
+		},
+	})
+	srv.HandleJSON("POST", shareRoute, map[string]interface{}{
+		"status": 200,
+		"data":   map[string]string{"task_id": "share-task"},
+	})
+	srv.HandleJSON("POST", pwdRoute, map[string]interface{}{
+		"status": 200,
+		"data":   map[string]string{"share_url": "https://pan.quark.cn/s/mine", "passcode": "abcd"},
+	})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *QuarkClient {
+	c := NewQuarkClient("cookie=1", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakeQuark(t)
+	c := newTestClient(srv, map[string]string{"quark_file": "0", "quark_file_time": "tmp-dir"})
+
+	result, err := c.Transfer(context.Background(), "https://pan.quark.cn/s/abc123", "", 2)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://pan.quark.cn/s/mine" || result.Password != "abcd" || result.Title != "流浪地球2" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	var token map[string]string
+	if err := srv.Requests(tokenRoute)[0].JSON(&token); err != nil {
+		t.Fatal(err)
+	}
+	if token["pwd_id"] != "abc123" {
+		t.Errorf("pwd_id = %q, want abc123", token["pwd_id"])
+	}
+
+	// 临时资源转存到quark_file_time目录，stoken中的空格替换为+
+	var save map[string]interface{}
+	if err := srv.Requests(saveRoute)[0].JSON(&save); err != nil {
+		t.Fatal(err)
+	}
+	if save["to_pdir_fid"] != "tmp-dir" {
+		t.Errorf("to_pdir_fid = %v, want tmp-dir", save["to_pdir_fid"])
+	}
+	if save["stoken"] != "st+ok" {
+		t.Errorf("stoken = %v, want st+ok", save["stoken"])
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		route   string
+		method  string
+		resp    interface{}
+		wantErr string
+	}{
+		{
+			name:    "无效链接",
+			url:     "https://pan.quark.cn/abc",
+			wantErr: "提取pwd_id失败: 无效的分享链接",
+		},
+		{
+			name:    "分享已失效",
+			url:     "https://pan.quark.cn/s/abc123",
+			route:   tokenRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"status": 404, "message": "分享已取消"},
+			wantErr: "获取stoken失败: 获取stoken失败: 分享已取消",
+		},
+		{
+			name:    "容量不足",
+			url:     "https://pan.quark.cn/s/abc123",
+			route:   saveRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"status": 400, "message": "capacity limit[{0}]"},
+			wantErr: "转存失败: 容量不足",
+		},
+		{
+			name:    "响应格式错误",
+			url:     "https://pan.quark.cn/s/abc123",
+			route:   detailRoute,
+			method:  "GET",
+			resp:    "<html>",
+			wantErr: "获取分享详情失败: 解析响应失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeQuark(t)
+			if tt.route != "" {
+				srv.HandleJSON(tt.method, tt.route, tt.resp)
+			}
+			c := newTestClient(srv, nil)
+
+			_, err := c.Transfer(context.Background(), tt.url, "", 1)
+			if err == nil {
+				t.Fatalf("Transfer() error = nil, want %q", tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %q, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  interface{}
+		detail interface{}
+		want   model.LinkStatus
+	}{
+		{
+			name: "有效",
+			want: model.LinkStatusAlive,
+		},
+		{
+			name:  "提取码错误",
+			token: map[string]interface{}{"status": 400, "code": 41008, "message": "提取码错误"},
+			want:  model.LinkStatusNeedPassword,
+		},
+		{
+			name:  "已取消",
+			token: map[string]interface{}{"status": 400, "code": 41011, "message": "分享者已取消分享"},
+			want:  model.LinkStatusDead,
+		},
+		{
+			name:   "分享不存在",
+			detail: map[string]interface{}{"status": 404, "code": 41999, "message": "not found"},
+			want:   model.LinkStatusDead,
+		},
+		{
+			// 错误码未知时不根据提示文案猜测
+			name:  "未知错误",
+			token: map[string]interface{}{"status": 500, "code": 50000, "message": "分享已失效，请稍后重试"},
+			want:  model.LinkStatusUnknown,
+		},
+		{
+			name:   "内容为空",
+			detail: map[string]interface{}{"status": 200, "data": map[string]interface{}{"list": []interface{}{}}},
+			want:   model.LinkStatusDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeQuark(t)
+			if tt.token != nil {
+				srv.HandleJSON("POST", tokenRoute, tt.token)
+			}
+			if tt.detail != nil {
+				srv.HandleJSON("GET", detailRoute, tt.detail)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), "https://pan.quark.cn/s/abc123", "")
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,639 @@
+﻿package tianyi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	apiBase      = "https://cloud.189.cn/api"
+	rootFolderID = "-11" // 个人云根目录
+)
+
+// shareCodePatterns 分享码提取规则：/t/xxx 短链接 与 /web/share?code=xxx 长链接
+var shareCodePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`cloud\.189\.cn/t/([a-zA-Z0-9]+)`),
+	regexp.MustCompile(`cloud\.189\.cn/web/share\?code=([a-zA-Z0-9]+)`),
+}
+
+// errAccessCodeInvalid 访问码错误（校验接口未返回shareId）
+var errAccessCodeInvalid = errors.New("访问码错误")
+
+// TianyiClient 天翼云盘客户端
+type TianyiClient struct {
+	cookie     string
+	httpClient *http.Client
+	configRepo repository.ConfigRepository
+}
+
+// NewTianyiClient 创建天翼云盘客户端 - 只需要cookie
+func NewTianyiClient(cookie string, configRepo repository.ConfigRepository) *TianyiClient {
+	return &TianyiClient{
+		cookie:     cookie,
+		configRepo: configRepo,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Transfer 实现转存功能
+func (c *TianyiClient) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	// 1. 从分享链接提取分享码
+	shareCode, err := c.extractShareCode(shareURL)
+	if err != nil {
+		return nil, fmt.Errorf("提取分享码失败: %w", err)
+	}
+
+	// 2. 获取分享详情
+	shareInfo, err := c.getShareInfo(ctx, shareCode)
+	if err != nil {
+		return nil, fmt.Errorf("获取分享详情失败: %w", err)
+	}
+
+	// 3. 需要访问码时校验访问码，获取真实的shareId
+	if shareInfo.NeedAccessCode == 1 || password != "" {
+		shareID, err := c.checkAccessCode(ctx, shareCode, password)
+		if err != nil {
+			return nil, fmt.Errorf("校验访问码失败: %w", err)
+		}
+		shareInfo.ShareID = shareID
+	}
+
+	// 4. 动态获取转存目录
+	folderID, err := c.getToPdirFid(ctx, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("获取转存目录失败: %w", err)
+	}
+
+	// 5. 转存分享的根文件（夹）到自己的网盘
+	taskInfos := []map[string]interface{}{
+		{
+			"fileId":   shareInfo.FileID.String(),
+			"fileName": shareInfo.FileName,
+			"isFolder": boolToInt(shareInfo.IsFolder),
+		},
+	}
+	if err := c.runBatchTask(ctx, "SHARE_SAVE", taskInfos, folderID, shareInfo.ShareID.String()); err != nil {
+		return nil, fmt.Errorf("转存文件失败: %w", err)
+	}
+
+	// 6. 在转存目录中找到刚保存的文件
+	savedID, err := c.findFileID(ctx, folderID, shareInfo.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("查找转存文件失败: %w", err)
+	}
+
+	// 7. 创建新的分享链接
+	newShareURL, newPassword, err := c.createShare(ctx, savedID, expiredType)
+	if err != nil {
+		return nil, fmt.Errorf("创建分享失败: %w", err)
+	}
+
+	result := &model.TransferResult{
+		Title:       shareInfo.FileName,
+		OriginalURL: shareURL,
+		ShareURL:    newShareURL,
+		Password:    newPassword,
+		Success:     true,
+		Message:     "转存成功",
+	}
+
+	return result, nil
+}
+
+// getToPdirFid 根据过期类型动态获取转存目录
+func (c *TianyiClient) getToPdirFid(ctx context.Context, expiredType int) (string, error) {
+	configKey := "tianyi_file" // 默认存储目录
+	if expiredType == 2 {
+		configKey = "tianyi_file_time" // 临时资源目录
+	}
+
+	folderID, err := c.configRepo.Get(ctx, configKey)
+	if err != nil {
+		return "", fmt.Errorf("读取配置%s失败: %w", configKey, err)
+	}
+
+	if folderID == "" || folderID == "0" {
+		return rootFolderID, nil
+	}
+
+	return folderID, nil
+}
+
+// extractShareCode 从分享链接提取分享码
+func (c *TianyiClient) extractShareCode(shareURL string) (string, error) {
+	// 天翼云盘分享链接格式: https://cloud.189.cn/t/abc123 或 https://cloud.189.cn/web/share?code=abc123
+	for _, pattern := range shareCodePatterns {
+		if matches := pattern.FindStringSubmatch(shareURL); len(matches) > 1 {
+			return matches[1], nil
+		}
+	}
+	return "", fmt.Errorf("无效的分享链接")
+}
+
+// getShareInfo 获取分享详情
+func (c *TianyiClient) getShareInfo(ctx context.Context, shareCode string) (*ShareInfo, error) {
+	params := url.Values{
+		"shareCode": {shareCode},
+	}
+
+	var result ShareInfo
+	if err := c.doRequest(ctx, "GET", apiBase+"/open/share/getShareInfoByCodeV2.action", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// checkAccessCode 校验访问码
+func (c *TianyiClient) checkAccessCode(ctx context.Context, shareCode, accessCode string) (flexID, error) {
+	params := url.Values{
+		"shareCode":  {shareCode},
+		"accessCode": {accessCode},
+	}
+
+	var result struct {
+		apiResponse
+		ShareID flexID `json:"shareId"`
+	}
+	if err := c.doRequest(ctx, "GET", apiBase+"/open/share/checkAccessCode.action", params, &result); err != nil {
+		return "", err
+	}
+	if err := result.err(); err != nil {
+		return "", err
+	}
+	if result.ShareID == "" {
+		return "", errAccessCodeInvalid
+	}
+
+	return result.ShareID, nil
+}
+
+// runBatchTask 创建批量任务并等待完成（转存、删除等）
+func (c *TianyiClient) runBatchTask(ctx context.Context, taskType string, taskInfos []map[string]interface{}, targetFolderID, shareID string) error {
+	taskInfosJSON, err := json.Marshal(taskInfos)
+	if err != nil {
+		return fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+
+	form := url.Values{
+		"type":           {taskType},
+		"taskInfos":      {string(taskInfosJSON)},
+		"targetFolderId": {targetFolderID},
+	}
+	if shareID != "" {
+		form.Set("shareId", shareID)
+	}
+
+	var result struct {
+		apiResponse
+		TaskID flexID `json:"taskId"`
+	}
+	if err := c.doForm(ctx, apiBase+"/open/batch/createBatchTask.action", form, &result); err != nil {
+		return err
+	}
+	if err := result.err(); err != nil {
+		return err
+	}
+
+	return c.waitForTask(ctx, taskType, result.TaskID.String())
+}
+
+// waitForTask 等待批量任务完成
+func (c *TianyiClient) waitForTask(ctx context.Context, taskType, taskID string) error {
+	maxRetries := 30
+	for i := 0; i < maxRetries; i++ {
+		form := url.Values{
+			"type":   {taskType},
+			"taskId": {taskID},
+		}
+
+		var result struct {
+			apiResponse
+			TaskStatus int `json:"taskStatus"`
+		}
+		if err := c.doForm(ctx, apiBase+"/open/batch/checkBatchTask.action", form, &result); err != nil {
+			return err
+		}
+		if err := result.err(); err != nil {
+			return err
+		}
+
+		// taskStatus: 1/3=进行中 2=存在同名文件（保留已有文件，直接使用） 4=完成
+		if result.TaskStatus == 2 || result.TaskStatus == 4 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return fmt.Errorf("任务超时")
+}
+
+// listFiles 列出目录下的文件和文件夹
+func (c *TianyiClient) listFiles(ctx context.Context, folderID string) (*FileList, error) {
+	params := url.Values{
+		"folderId":   {folderID},
+		"pageNum":    {"1"},
+		"pageSize":   {"100"},
+		"mediaType":  {"0"},
+		"iconOption": {"5"},
+		"orderBy":    {"lastOpTime"},
+		"descending": {"true"},
+	}
+
+	var result struct {
+		apiResponse
+		FileListAO FileList `json:"fileListAO"`
+	}
+	if err := c.doRequest(ctx, "GET", apiBase+"/open/file/listFiles.action", params, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err(); err != nil {
+		return nil, err
+	}
+
+	return &result.FileListAO, nil
+}
+
+// findFileID 在目录中按名称查找文件（夹），按最近操作时间倒序取第一个
+func (c *TianyiClient) findFileID(ctx context.Context, folderID, name string) (string, error) {
+	files, err := c.listFiles(ctx, folderID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files.all() {
+		if file.Name == name {
+			return file.ID.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("未找到文件: %s", name)
+}
+
+// createShare 创建分享
+func (c *TianyiClient) createShare(ctx context.Context, fileID string, expiredType int) (string, string, error) {
+	// 天翼只支持1天、7天和永久，临时资源使用7天（临时目录会被定时清理）
+	expireTime := "2099" // 永久
+	if expiredType == 2 {
+		expireTime = "7"
+	}
+
+	params := url.Values{
+		"fileId":     {fileID},
+		"expireTime": {expireTime},
+		"shareType":  {"3"},
+	}
+
+	var result struct {
+		apiResponse
+		ShareLinkList []struct {
+			URL        string `json:"url"`
+			AccessCode string `json:"accessCode"`
+		} `json:"shareLinkList"`
+	}
+	if err := c.doRequest(ctx, "GET", apiBase+"/open/share/createShareLink.action", params, &result); err != nil {
+		return "", "", err
+	}
+	if err := result.err(); err != nil {
+		return "", "", err
+	}
+	if len(result.ShareLinkList) == 0 || result.ShareLinkList[0].URL == "" {
+		return "", "", fmt.Errorf("未返回分享链接")
+	}
+
+	// 天翼链接不支持在URL中携带访问码，访问码单独返回
+	link := result.ShareLinkList[0]
+	return link.URL, link.AccessCode, nil
+}
+
+// doRequest 执行GET/POST请求（参数放在URL中）
+func (c *TianyiClient) doRequest(ctx context.Context, method, urlStr string, params url.Values, result interface{}) error {
+	if len(params) > 0 {
+		urlStr += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	return c.do(req, result)
+}
+
+// doForm 执行表单POST请求
+func (c *TianyiClient) doForm(ctx context.Context, urlStr string, form url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", urlStr, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, result)
+}
+
+// do 设置公共请求头并解析JSON响应
+func (c *TianyiClient) do(req *http.Request, result interface{}) error {
+	// 不带Accept: application/json时接口会返回XML
+	req.Header.Set("Accept", "application/json;charset=UTF-8")
+	req.Header.Set("Referer", "https://cloud.189.cn/web/main/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Cookie", c.cookie)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败,状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, body: %s", err, string(respBody))
+	}
+
+	return nil
+}
+
+// 数据结构
+
+// flexID 天翼接口中的ID有时是数字有时是字符串，统一按字符串处理
+type flexID string
+
+// UnmarshalJSON 兼容数字和字符串两种格式
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		s = ""
+	}
+	*id = flexID(s)
+	return nil
+}
+
+// String 返回字符串形式的ID
+func (id flexID) String() string {
+	return string(id)
+}
+
+// apiError 天翼接口返回的业务错误
+type apiError struct {
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (错误码:%s)", e.message, e.code)
+}
+
+// apiResponse 天翼接口公共响应字段，res_code为0表示成功
+type apiResponse struct {
+	ResCode    flexID `json:"res_code"`
+	ResMessage string `json:"res_message"`
+	ErrorCode  string `json:"errorCode"`
+	ErrorMsg   string `json:"errorMsg"`
+}
+
+func (r *apiResponse) err() error {
+	if r.ErrorCode != "" {
+		if r.ErrorCode == "InvalidSessionKey" {
+			return fmt.Errorf("Cookie已过期或无效，请重新获取")
+		}
+		return &apiError{code: r.ErrorCode, message: r.ErrorMsg}
+	}
+	if r.ResCode != "" && r.ResCode != "0" {
+		return &apiError{code: r.ResCode.String(), message: r.ResMessage}
+	}
+	return nil
+}
+
+type ShareInfo struct {
+	apiResponse
+	ShareID        flexID `json:"shareId"`
+	FileID         flexID `json:"fileId"`
+	FileName       string `json:"fileName"`
+	IsFolder       bool   `json:"isFolder"`
+	NeedAccessCode int    `json:"needAccessCode"`
+}
+
+type TianyiFile struct {
+	ID   flexID `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type FileList struct {
+	Count      int          `json:"count"`
+	FileList   []TianyiFile `json:"fileList"`
+	FolderList []TianyiFile `json:"folderList"`
+}
+
+// all 合并文件夹和文件列表（文件夹在前）
+func (l *FileList) all() []TianyiFile {
+	files := make([]TianyiFile, 0, len(l.FolderList)+len(l.FileList))
+	files = append(files, l.FolderList...)
+	return append(files, l.FileList...)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// shareErrorCodes 分享接口错误码对应的链接状态
+var shareErrorCodes = map[string]model.LinkStatus{
+	"ShareNotFound":        model.LinkStatusDead, // 分享不存在
+	"ShareInfoNotFound":    model.LinkStatusDead, // 分享不存在
+	"ShareExpiredError":    model.LinkStatusDead, // 分享已过期
+	"ShareAuditNotPass":    model.LinkStatusDead, // 分享审核未通过
+	"FileNotFound":         model.LinkStatusDead, // 分享的文件已删除
+	"ShareAccessCodeError": model.LinkStatusNeedPassword,
+}
+
+// linkStatusOf 根据接口错误码判断链接状态，网络错误等无法判断时返回unknown
+func linkStatusOf(err error) model.LinkStatus {
+	if errors.Is(err, errAccessCodeInvalid) {
+		return model.LinkStatusNeedPassword
+	}
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		if status, ok := shareErrorCodes[apiErr.code]; ok {
+			return status
+		}
+	}
+	return model.LinkStatusUnknown
+}
+
+// CheckLink 检查天翼分享链接状态（复用转存流程的分享详情和访问码校验接口）
+func (c *TianyiClient) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	shareCode, err := c.extractShareCode(shareURL)
+	if err != nil {
+		return model.LinkStatusDead, err
+	}
+
+	shareInfo, err := c.getShareInfo(ctx, shareCode)
+	if err != nil {
+		return linkStatusOf(err), err
+	}
+
+	if shareInfo.NeedAccessCode == 1 {
+		if password == "" {
+			return model.LinkStatusNeedPassword, fmt.Errorf("需要访问码")
+		}
+		if _, err := c.checkAccessCode(ctx, shareCode, password); err != nil {
+			return linkStatusOf(err), err
+		}
+	}
+
+	if shareInfo.FileID == "" {
+		return model.LinkStatusDead, fmt.Errorf("分享内容为空")
+	}
+	return model.LinkStatusAlive, nil
+}
+
+// GetName 获取网盘名称
+func (c *TianyiClient) GetName() string {
+	return "天翼云盘"
+}
+
+// IsConfigured 检查是否已配置 - 实时从数据库读取
+func (c *TianyiClient) IsConfigured() bool {
+	// 先检查初始化时的cookie
+	if c.cookie != "" {
+		return true
+	}
+
+	// 如果初始化时没有cookie，尝试从数据库读取最新配置
+	if c.configRepo != nil {
+		ctx := context.Background()
+		conf, err := c.configRepo.GetByName(ctx, "tianyi_cookie")
+		if err == nil && conf != nil && conf.Value != "" {
+			// 更新内存中的cookie
+			c.cookie = conf.Value
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeleteDirectory 删除指定目录（dirPath可以是根目录下的目录名或目录ID）
+func (c *TianyiClient) DeleteDirectory(ctx context.Context, dirPath string) error {
+	// 1. 列出根目录找到目标目录
+	files, err := c.listFiles(ctx, rootFolderID)
+	if err != nil {
+		return fmt.Errorf("列出根目录失败: %w", err)
+	}
+
+	var target *TianyiFile
+	for i, folder := range files.FolderList {
+		if folder.Name == dirPath || folder.ID.String() == dirPath {
+			target = &files.FolderList[i]
+			break
+		}
+	}
+
+	if target == nil {
+		return fmt.Errorf("目录不存在: %s", dirPath)
+	}
+
+	// 2. 删除目录
+	taskInfos := []map[string]interface{}{
+		{
+			"fileId":   target.ID.String(),
+			"fileName": target.Name,
+			"isFolder": 1,
+		},
+	}
+	return c.runBatchTask(ctx, "DELETE", taskInfos, "", "")
+}
+
+// CreateDirectory 创建指定目录
+func (c *TianyiClient) CreateDirectory(ctx context.Context, dirPath string) error {
+	form := url.Values{
+		"parentFolderId": {rootFolderID}, // 在根目录创建
+		"folderName":     {dirPath},
+	}
+
+	var result struct {
+		apiResponse
+		ID flexID `json:"id"`
+	}
+	if err := c.doForm(ctx, apiBase+"/open/file/createFolder.action", form, &result); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	return nil
+}
+
+// TestConnection 测试天翼云盘连接
+func (c *TianyiClient) TestConnection(ctx context.Context) error {
+	// 测试策略：获取用户信息，验证cookie是否有效
+	var result struct {
+		apiResponse
+		LoginName string `json:"loginName"`
+	}
+
+	if err := c.doRequest(ctx, "GET", apiBase+"/open/user/getUserInfoForPortal.action", nil, &result); err != nil {
+		return fmt.Errorf("网络请求失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	if result.LoginName == "" {
+		return fmt.Errorf("Cookie已过期或无效，请重新获取")
+	}
+
+	return nil
+}
+
+// GetCapacity 获取天翼云盘容量（字节）
+func (c *TianyiClient) GetCapacity(ctx context.Context) (int64, int64, error) {
+	var result struct {
+		apiResponse
+		CloudCapacityInfo struct {
+			TotalSize int64 `json:"totalSize"`
+			UsedSize  int64 `json:"usedSize"`
+		} `json:"cloudCapacityInfo"`
+	}
+
+	if err := c.doRequest(ctx, "GET", apiBase+"/portal/getUserSizeInfo.action", nil, &result); err != nil {
+		return 0, 0, fmt.Errorf("网络请求失败: %w", err)
+	}
+	if err := result.err(); err != nil {
+		return 0, 0, fmt.Errorf("获取容量失败: %w", err)
+	}
+
+	return result.CloudCapacityInfo.TotalSize, result.CloudCapacityInfo.UsedSize, nil
+}
@@ -0,0 +1,242 @@
+﻿package tianyi
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+const (
+	shareInfoRoute   = "cloud.189.cn/api/open/share/getShareInfoByCodeV2.action"
+	accessCodeRoute  = "cloud.189.cn/api/open/share/checkAccessCode.action"
+	createTaskRoute  = "cloud.189.cn/api/open/batch/createBatchTask.action"
+	checkTaskRoute   = "cloud.189.cn/api/open/batch/checkBatchTask.action"
+	listFilesRoute   = "cloud.189.cn/api/open/file/listFiles.action"
+	createShareRoute = "cloud.189.cn/api/open/share/createShareLink.action"
+)
+
+// newFakeTianyi 注册一次完整转存流程的成功响应（分享需要访问码）
+func newFakeTianyi(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("GET", shareInfoRoute, map[string]interface{}{
+		"res_code":       0,
+		"shareId":        111,
+		"fileId":         "222",
+		"fileName":       "流浪地球2",
+		"isFolder":       true,
+		"needAccessCode": 1,
+	})
+	srv.HandleJSON("GET", accessCodeRoute, map[string]interface{}{"res_code": 0, "shareId": 333})
+	srv.HandleJSON("POST", createTaskRoute, map[string]interface{}{"res_code": 0, "taskId": "task-1"})
+	srv.HandleJSON("POST", checkTaskRoute, map[string]interface{}{"res_code": 0, "taskStatus": 4})
+	srv.HandleJSON("GET", listFilesRoute, map[string]interface{}{
+		"res_code": 0,
+		"fileListAO": map[string]interface{}{
+			"folderList": []map[string]interface{}{
+				{"id": 444, "name": "其他目录"},
+				{"id": 555, "name": "流浪地球2"},
+			},
+		},
+	})
+	srv.HandleJSON("GET", createShareRoute, map[string]interface{}{
+		"res_code":      0,
+		"shareLinkList": []map[string]string{{"url": "https://cloud.189.cn/t/mine", "accessCode": "ab12"}},
+	})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *TianyiClient {
+	c := NewTianyiClient("COOKIE_LOGIN_USER=abc", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakeTianyi(t)
+	c := newTestClient(srv, map[string]string{"tianyi_file": "0", "tianyi_file_time": "tmp-dir"})
+
+	result, err := c.Transfer(context.Background(), "https://cloud.189.cn/web/share?code=abc123", "x9y8", 2)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://cloud.189.cn/t/mine" || result.Password != "ab12" || result.Title != "流浪地球2" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	if code := srv.Requests(shareInfoRoute)[0].Query.Get("shareCode"); code != "abc123" {
+		t.Errorf("shareCode = %q, want abc123", code)
+	}
+	if code := srv.Requests(accessCodeRoute)[0].Query.Get("accessCode"); code != "x9y8" {
+		t.Errorf("accessCode = %q, want x9y8", code)
+	}
+
+	// 转存使用校验访问码后返回的shareId，临时资源转存到tianyi_file_time目录
+	task := srv.Requests(createTaskRoute)[0].Form()
+	if task.Get("shareId") != "333" || task.Get("targetFolderId") != "tmp-dir" || task.Get("type") != "SHARE_SAVE" {
+		t.Errorf("createBatchTask form = %v", task)
+	}
+	var infos []map[string]interface{}
+	if err := json.Unmarshal([]byte(task.Get("taskInfos")), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0]["fileId"] != "222" || infos[0]["isFolder"] != float64(1) {
+		t.Errorf("taskInfos = %v", infos)
+	}
+
+	// 按名称找到转存后的目录再分享，临时资源分享7天
+	share := srv.Requests(createShareRoute)[0].Query
+	if share.Get("fileId") != "555" || share.Get("expireTime") != "7" {
+		t.Errorf("createShareLink query = %v", share)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		route   string
+		method  string
+		resp    interface{}
+		configs map[string]string
+		wantErr string
+	}{
+		{
+			name:    "无效链接",
+			url:     "https://cloud.189.cn/abc",
+			wantErr: "提取分享码失败: 无效的分享链接",
+		},
+		{
+			name:    "分享不存在",
+			url:     "https://cloud.189.cn/t/abc123",
+			route:   shareInfoRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"res_code": "ShareInfoNotFound", "res_message": "分享不存在"},
+			wantErr: "获取分享详情失败: 分享不存在 (错误码:ShareInfoNotFound)",
+		},
+		{
+			name:    "访问码错误",
+			url:     "https://cloud.189.cn/t/abc123",
+			route:   accessCodeRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"res_code": 0},
+			wantErr: "校验访问码失败: 访问码错误",
+		},
+		{
+			name:    "Cookie失效",
+			url:     "https://cloud.189.cn/t/abc123",
+			route:   createTaskRoute,
+			method:  "POST",
+			resp:    map[string]interface{}{"errorCode": "InvalidSessionKey", "errorMsg": "session invalid"},
+			wantErr: "转存文件失败: Cookie已过期或无效",
+		},
+		{
+			name:    "未找到转存文件",
+			url:     "https://cloud.189.cn/t/abc123",
+			route:   listFilesRoute,
+			method:  "GET",
+			resp:    map[string]interface{}{"res_code": 0, "fileListAO": map[string]interface{}{}},
+			wantErr: "查找转存文件失败: 未找到文件: 流浪地球2",
+		},
+		{
+			name:    "未配置转存目录",
+			url:     "https://cloud.189.cn/t/abc123",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeTianyi(t)
+			if tt.route != "" {
+				srv.HandleJSON(tt.method, tt.route, tt.resp)
+			}
+			configs := tt.configs
+			if configs == nil {
+				configs = map[string]string{"tianyi_file": "dir-1"}
+			}
+			c := newTestClient(srv, configs)
+
+			_, err := c.Transfer(context.Background(), tt.url, "x9y8", 1)
+			if err == nil {
+				t.Fatalf("Transfer() error = nil, want %q", tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %q, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		shareInfo  interface{}
+		accessCode interface{}
+		want       model.LinkStatus
+	}{
+		{
+			name:     "有效",
+			password: "x9y8",
+			want:     model.LinkStatusAlive,
+		},
+		{
+			name:      "无需访问码",
+			shareInfo: map[string]interface{}{"res_code": 0, "shareId": 111, "fileId": 222, "needAccessCode": 0},
+			want:      model.LinkStatusAlive,
+		},
+		{
+			name: "缺少访问码",
+			want: model.LinkStatusNeedPassword,
+		},
+		{
+			name:       "访问码错误",
+			password:   "0000",
+			accessCode: map[string]interface{}{"res_code": 0},
+			want:       model.LinkStatusNeedPassword,
+		},
+		{
+			name:      "已过期",
+			shareInfo: map[string]interface{}{"res_code": "ShareExpiredError", "res_message": "分享已过期"},
+			want:      model.LinkStatusDead,
+		},
+		{
+			// 错误码未知时不根据提示文案猜测
+			name:      "未知错误",
+			shareInfo: map[string]interface{}{"res_code": "InternalError", "res_message": "分享不存在或已失效"},
+			want:      model.LinkStatusUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeTianyi(t)
+			if tt.shareInfo != nil {
+				srv.HandleJSON("GET", shareInfoRoute, tt.shareInfo)
+			}
+			if tt.accessCode != nil {
+				srv.HandleJSON("GET", accessCodeRoute, tt.accessCode)
+			}
+			c := newTestClient(srv, nil)
+
+			got, _ := c.CheckLink(context.Background(), "https://cloud.189.cn/t/abc123", tt.password)
+			if got != tt.want {
+				t.Errorf("CheckLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+﻿package uc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/repository/repotest"
+)
+
+const (
+	detailRoute = "drive.uc.cn/api/share/detail"
+	saveRoute   = "drive.uc.cn/api/share/save"
+	shareRoute  = "pc-api.uc.cn/1/clouddrive/share"
+)
+
+// newFakeUC 注册一次完整转存流程的成功响应
+func newFakeUC(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("POST", detailRoute, map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"title": "繁花",
+			"files": []map[string]interface{}{{"file_id": "uc-1", "file_name": "繁花01.mp4"}},
+		},
+	})
+	srv.HandleJSON("POST", saveRoute, map[string]interface{}{"code": 0})
+	srv.HandleJSON("POST", shareRoute, map[string]interface{}{
+		"code": 0,
+		"data": map[string]string{"share_url": "https://drive.uc.cn/s/mine"},
+	})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *UCClient {
+	c := NewUCClient("cookie=1", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	srv := newFakeUC(t)
+	c := newTestClient(srv, map[string]string{"uc_file": "folder-1", "uc_file_time": "folder-tmp"})
+
+	result, err := c.Transfer(context.Background(), "https://drive.uc.cn/s/abc123", "pw", 1)
+	if err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if !result.Success || result.ShareURL != "https://drive.uc.cn/s/mine" || result.Password != "6666" || result.Title != "繁花" {
+		t.Fatalf("Transfer() = %+v", result)
+	}
+
+	var detail map[string]string
+	if err := srv.Requests(detailRoute)[0].JSON(&detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail["share_id"] != "abc123" || detail["password"] != "pw" {
+		t.Errorf("分享详情请求 = %v", detail)
+	}
+
+	var save struct {
+		FileIDs    []string `json:"file_ids"`
+		ToFolderID string   `json:"to_folder_id"`
+	}
+	if err := srv.Requests(saveRoute)[0].JSON(&save); err != nil {
+		t.Fatal(err)
+	}
+	if save.ToFolderID != "folder-1" || len(save.FileIDs) != 1 || save.FileIDs[0] != "uc-1" {
+		t.Errorf("转存请求 = %+v", save)
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		configs map[string]string
+		route   string
+		resp    interface{}
+		wantErr string
+	}{
+		{
+			name:    "无效链接",
+			url:     "https://drive.uc.cn/abc123",
+			wantErr: "提取share_id失败",
+		},
+		{
+			name:    "分享已失效",
+			url:     "https://drive.uc.cn/s/abc123",
+			route:   detailRoute,
+			resp:    map[string]interface{}{"code": 41004, "msg": "分享不存在"},
+			wantErr: "获取分享详情失败: 获取分享详情失败: 分享不存在",
+		},
+		{
+			name:    "未配置转存目录",
+			url:     "https://drive.uc.cn/s/abc123",
+			configs: map[string]string{},
+			wantErr: "获取转存目录失败",
+		},
+		{
+			name:    "转存失败",
+			url:     "https://drive.uc.cn/s/abc123",
+			route:   saveRoute,
+			resp:    map[string]interface{}{"code": 32003, "msg": "容量不足"},
+			wantErr: "转存文件失败: 转存文件失败: 容量不足",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeUC(t)
+			if tt.route != "" {
+				srv.HandleJSON("POST", tt.route, tt.resp)
+			}
+			configs := tt.configs
+			if configs == nil {
+				configs = map[string]string{"uc_file": "0"}
+			}
+			c := newTestClient(srv, configs)
+
+			_, err := c.Transfer(context.Background(), tt.url, "", 1)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %v, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+﻿package xunlei
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"huoxing-search/internal/netdisk/netdisktest"
+	"huoxing-search/internal/repository/repotest"
+)
+
+const (
+	tokenRoute = "api.xpan.xunlei.com/oauth/token"
+	infoRoute  = "api.xpan.xunlei.com/drive/v1/share/abc123"
+	saveRoute  = "api.xpan.xunlei.com/drive/v1/share/save"
+	taskRoute  = "api.xpan.xunlei.com/drive/v1/task/task-1"
+	shareRoute = "api-pan.xunlei.com/drive/v1/share"
+)
+
+// newFakeXunlei 注册一次完整转存流程的成功响应
+func newFakeXunlei(t *testing.T) *netdisktest.Server {
+	srv := netdisktest.NewServer(t)
+	srv.HandleJSON("POST", tokenRoute, map[string]string{
+		"access_token":  "access-1",
+		"refresh_token": "refresh-2",
+		"user_id":       "user-1",
+	})
+	srv.HandleJSON("POST", infoRoute, map[string]interface{}{
+		"share_info": map[string]interface{}{
+			"title": "奥本海默",
+			"files": []map[string]interface{}{{"file_id": "xl-1", "file_name": "奥本海默.mkv"}},
+		},
+	})
+	srv.HandleJSON("POST", saveRoute, map[string]string{"task_id": "task-1"})
+	srv.HandleJSON("GET", taskRoute, map[string]string{"status": "completed"})
+	srv.HandleJSON("POST", shareRoute, map[string]string{
+		"share_url": "https://pan.xunlei.com/s/mine",
+		"pass_code": "x1y2",
+	})
+	return srv
+}
+
+func newTestClient(srv *netdisktest.Server, configs map[string]string) *XunleiClient {
+	c := NewXunleiClient("refresh-1", repotest.NewConfigRepository(configs))
+	c.httpClient = srv.Client()
+	return c
+}
+
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		expiredType    int
+		wantFolder     string
+		wantExpiration string
+	}{
+		{name: "永久资源", expiredType: 1, wantFolder: "folder-1", wantExpiration: "-1"},
+		{name: "临时资源", expiredType: 2, wantFolder: "folder-tmp", wantExpiration: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeXunlei(t)
+			c := newTestClient(srv, map[string]string{"xunlei_file": "folder-1", "xunlei_file_time": "folder-tmp"})
+
+			result, err := c.Transfer(context.Background(), "https://pan.xunlei.com/s/abc123", "", tt.expiredType)
+			if err != nil {
+				t.Fatalf("Transfer() error = %v", err)
+			}
+			if result.ShareURL != "https://pan.xunlei.com/s/mine?pwd=x1y2" || result.Password != "x1y2" || result.Title != "奥本海默" {
+				t.Fatalf("Transfer() = %+v", result)
+			}
+
+			var save map[string]interface{}
+			if err := srv.Requests(saveRoute)[0].JSON(&save); err != nil {
+				t.Fatal(err)
+			}
+			if save["to_parent_id"] != tt.wantFolder || save["to_drive_id"] != "user-1" {
+				t.Errorf("转存请求 = %v", save)
+			}
+
+			var share map[string]interface{}
+			if err := srv.Requests(shareRoute)[0].JSON(&share); err != nil {
+				t.Fatal(err)
+			}
+			if share["expiration_days"] != tt.wantExpiration {
+				t.Errorf("expiration_days = %v, want %s", share["expiration_days"], tt.wantExpiration)
+			}
+			if got := srv.Requests(shareRoute)[0].Header.Get("Authorization"); got != "Bearer access-1" {
+				t.Errorf("Authorization = %q, want Bearer access-1", got)
+			}
+		})
+	}
+}
+
+func TestTransferErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(srv *netdisktest.Server)
+		wantErr string
+	}{
+		{
+			name: "refresh_token失效",
+			setup: func(srv *netdisktest.Server) {
+				srv.Handle("POST", tokenRoute, func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusUnauthorized)
+					netdisktest.WriteJSON(w, map[string]string{"error": "invalid_grant"})
+				})
+			},
+			wantErr: "刷新token失败",
+		},
+		{
+			name: "转存任务失败",
+			setup: func(srv *netdisktest.Server) {
+				srv.HandleJSON("GET", taskRoute, map[string]string{"status": "failed"})
+			},
+			wantErr: "转存文件失败: 任务失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeXunlei(t)
+			tt.setup(srv)
+			c := newTestClient(srv, map[string]string{"xunlei_file": ""})
+
+			_, err := c.Transfer(context.Background(), "https://pan.xunlei.com/s/abc123", "", 1)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Transfer() error = %v, want prefix %q", err, tt.wantErr)
+			}
+		})
+	}
+}
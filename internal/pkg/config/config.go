@@ -2,6 +2,7 @@
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -19,6 +20,7 @@ type Config struct {
 	JWT       JWTConfig       `mapstructure:"jwt"`
 	Log       LogConfig       `mapstructure:"log"`
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
 }
 
 type ServerConfig struct {
@@ -59,6 +61,8 @@ type RedisConfig struct {
 type PansouConfig struct {
 	URL     string
 	Timeout int
+	// Engine 内置Pansou引擎配置，键为对应环境变量名的小写形式，如 plugin_timeout、enabled_plugins
+	Engine map[string]interface{} `mapstructure:"engine"`
 }
 
 type CacheConfig struct {
@@ -69,6 +73,7 @@ type TransferConfig struct {
 	MaxConcurrent int `mapstructure:"max_concurrent"`
 	Timeout       int
 	MaxSuccess    int `mapstructure:"max_success"`
+	MaxAttempts   int `mapstructure:"max_attempts"` // 异步转存任务最大尝试次数
 }
 
 type NetdiskConfig struct {
@@ -87,9 +92,10 @@ type NetdiskAccount struct {
 }
 
 type JWTConfig struct {
-	Secret      string
-	Expiration  int `mapstructure:"expiration"`  // 过期时间（小时）
-	ExpireHours int `mapstructure:"expire_hours"` // 兼容旧配置
+	Secret              string
+	Expiration          int `mapstructure:"expiration"`            // 登录有效期（小时），即刷新令牌有效期
+	ExpireHours         int `mapstructure:"expire_hours"`          // 兼容旧配置
+	AccessExpireMinutes int `mapstructure:"access_expire_minutes"` // 访问令牌有效期（分钟）
 }
 
 type LogConfig struct {
@@ -105,45 +111,107 @@ type RateLimitConfig struct {
 	Rate               float64 `mapstructure:"rate"` // 兼容rate字段
 	RequestsPerSecond  float64 `mapstructure:"requests_per_second"`
 	Burst              int
+	Redis              bool `mapstructure:"redis"` // Redis可用时多实例共享限流计数，Redis故障时自动降级为进程内限流
+	Policies           []RateLimitPolicy `mapstructure:"policies"` // 按路由的限流策略，未配置时使用内置策略，未命中任何策略的请求按上面的默认速率限流
 }
 
+// RateLimitPolicy 路由限流策略
+type RateLimitPolicy struct {
+	Name     string   `mapstructure:"name"`     // 策略名（限流计数按策略隔离）
+	Paths    []string `mapstructure:"paths"`    // 请求路径，以 / 或 * 结尾时按前缀匹配
+	Methods  []string `mapstructure:"methods"`  // 请求方法，为空表示全部
+	Identity string   `mapstructure:"identity"` // 限流对象：ip（默认）、api_key（按校验通过的密钥，未携带或无效时按IP）、openid（微信用户，由微信回调按用户计数）
+	Rate     float64  `mapstructure:"rate"`     // 每秒请求数，0表示不限制
+	Burst    int      `mapstructure:"burst"`    // 突发容量
+}
+
+// 限流对象
+const (
+	RateLimitByIP     = "ip"
+	RateLimitByAPIKey = "api_key"
+	RateLimitByOpenID = "openid"
+)
+
+// DefaultRateLimitPolicies 内置限流策略：探活接口不限流；搜索、转存会触发大量插件请求和网盘转存，按IP或API Key从严限流；微信按用户限流
+func DefaultRateLimitPolicies() []RateLimitPolicy {
+	return []RateLimitPolicy{
+		{Name: "probe", Paths: []string{"/api/health", "/api/ping", "/api/ready", "/api/version", "/api/metrics", "/metrics"}, Rate: 0},
+		{Name: "search", Paths: []string{"/api/search"}, Methods: []string{"POST"}, Identity: RateLimitByAPIKey, Rate: 0.5, Burst: 5},
+		{Name: "transfer", Paths: []string{"/api/transfer", "/api/transfer/save", "/api/transfer/jobs"}, Methods: []string{"POST"}, Identity: RateLimitByAPIKey, Rate: 0.2, Burst: 3},
+		{Name: "wechat", Paths: []string{"/api/wechat/"}, Methods: []string{"POST"}, Identity: RateLimitByOpenID, Rate: 0.2, Burst: 3},
+	}
+}
+
+// GetPolicies 获取限流策略，未配置时返回内置策略
+func (c *RateLimitConfig) GetPolicies() []RateLimitPolicy {
+	if c.Policies == nil {
+		return DefaultRateLimitPolicies()
+	}
+	return c.Policies
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   // 是否开放 /metrics，默认关闭
+	Token   string // 抓取令牌，开启时必须设置，抓取需携带 Authorization: Bearer <token>
+}
+
+// DefaultConfigPath 配置文件路径
+const DefaultConfigPath = "./data/config.yaml"
+
+// GlobalConfig 启动时加载的配置，配置文件热更新后的配置通过 Current 获取
 var GlobalConfig *Config
 
 // LoadConfig 加载配置文件
 func LoadConfig(configPath string) (*Config, error) {
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
+	config, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	GlobalConfig = config
+	current.Store(config)
+	return config, nil
+}
+
+// readConfig 读取并解析配置文件
+func readConfig(configPath string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
 
 	// 设置默认值
-	setDefaults()
+	setDefaults(v)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
-
-	GlobalConfig = &config
 	return &config, nil
 }
 
-func setDefaults() {
-	viper.SetDefault("server.port", 6060)
-	viper.SetDefault("server.mode", "release")
-	viper.SetDefault("server.read_timeout", 60)
-	viper.SetDefault("server.write_timeout", 60)
-	viper.SetDefault("cache.search_ttl", 60)
-	viper.SetDefault("transfer.max_concurrent", 5)
-	viper.SetDefault("transfer.timeout", 15)
-	viper.SetDefault("transfer.max_success", 2)
-	viper.SetDefault("jwt.expire_hours", 24)
-	viper.SetDefault("log.level", "info")
-	viper.SetDefault("rate_limit.enabled", true)
-	viper.SetDefault("rate_limit.requests_per_second", 10)
-	viper.SetDefault("rate_limit.burst", 20)
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.port", 6060)
+	v.SetDefault("server.mode", "release")
+	v.SetDefault("server.read_timeout", 60)
+	v.SetDefault("server.write_timeout", 60)
+	v.SetDefault("cache.search_ttl", 60)
+	v.SetDefault("transfer.max_concurrent", 5)
+	v.SetDefault("transfer.timeout", 15)
+	v.SetDefault("transfer.max_success", 2)
+	v.SetDefault("transfer.max_attempts", 3)
+	v.SetDefault("jwt.expire_hours", 24)
+	v.SetDefault("jwt.access_expire_minutes", 30)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("rate_limit.requests_per_second", 10)
+	v.SetDefault("rate_limit.burst", 20)
+	v.SetDefault("rate_limit.redis", true)
+	v.SetDefault("metrics.enabled", false)
 }
 
 // GetDSN 获取数据库连接字符串
@@ -188,6 +256,26 @@ func (c *PansouConfig) GetTimeout() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
 }
 
+// EngineValues 内置引擎配置转为以环境变量名为键的字符串，列表用逗号拼接
+func (c *PansouConfig) EngineValues() map[string]string {
+	values := make(map[string]string, len(c.Engine))
+	for key, v := range c.Engine {
+		if v == nil {
+			continue
+		}
+		if list, ok := v.([]interface{}); ok {
+			items := make([]string, 0, len(list))
+			for _, item := range list {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[strings.ToUpper(key)] = strings.Join(items, ",")
+			continue
+		}
+		values[strings.ToUpper(key)] = fmt.Sprint(v)
+	}
+	return values
+}
+
 // GetTransferTimeout 获取转存超时时间
 func (c *TransferConfig) GetTimeout() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
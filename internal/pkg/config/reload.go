@@ -0,0 +1,277 @@
+﻿package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 配置变更来源
+const (
+	ChangeSourceFile = "file" // 配置文件变更
+	ChangeSourceDB   = "db"   // 管理后台修改系统配置
+)
+
+// ChangeEvent 配置变更事件
+type ChangeEvent struct {
+	Source string
+	Config *Config  // 变更后的完整配置，系统配置变更时为当前配置
+	Names  []string // 变更的系统配置名称，仅系统配置变更时有值
+}
+
+// HasName 是否包含满足条件的系统配置名称
+func (e ChangeEvent) HasName(match func(name string) bool) bool {
+	for _, name := range e.Names {
+		if match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadStatus 最近一次配置变更的处理结果
+type ReloadStatus struct {
+	Time            int64    `json:"time"`
+	Source          string   `json:"source"`
+	Names           []string `json:"names,omitempty"`
+	Success         bool     `json:"success"`
+	Errors          []string `json:"errors,omitempty"`           // 校验错误或订阅者应用失败的原因
+	RestartRequired []string `json:"restart_required,omitempty"` // 已变更但需重启才能生效的配置节
+}
+
+type subscriber struct {
+	name string
+	fn   func(ChangeEvent) error
+}
+
+var (
+	busMu       sync.Mutex
+	subscribers []subscriber
+	lastReload  *ReloadStatus
+	current     atomic.Pointer[Config]
+)
+
+// Current 当前生效的配置，配置文件热更新后返回新配置；未加载时返回nil
+func Current() *Config {
+	return current.Load()
+}
+
+// Subscribe 订阅配置变更，同名订阅者只保留最后一次注册的
+func Subscribe(name string, fn func(ChangeEvent) error) {
+	busMu.Lock()
+	defer busMu.Unlock()
+	for i, s := range subscribers {
+		if s.name == name {
+			subscribers[i].fn = fn
+			return
+		}
+	}
+	subscribers = append(subscribers, subscriber{name: name, fn: fn})
+}
+
+// Publish 按注册顺序通知订阅者，单个订阅者失败不影响其他订阅者
+func Publish(ev ChangeEvent) ReloadStatus {
+	if ev.Config == nil {
+		ev.Config = Current()
+	}
+
+	busMu.Lock()
+	subs := make([]subscriber, len(subscribers))
+	copy(subs, subscribers)
+	busMu.Unlock()
+
+	status := ReloadStatus{Time: time.Now().Unix(), Source: ev.Source, Names: ev.Names}
+	for _, s := range subs {
+		if err := s.fn(ev); err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("%s: %v", s.name, err))
+		}
+	}
+	status.Success = len(status.Errors) == 0
+	recordReload(status)
+	return status
+}
+
+// LastReload 最近一次配置变更的处理结果，尚未发生变更时返回nil
+func LastReload() *ReloadStatus {
+	busMu.Lock()
+	defer busMu.Unlock()
+	if lastReload == nil {
+		return nil
+	}
+	status := *lastReload
+	return &status
+}
+
+func recordReload(status ReloadStatus) {
+	busMu.Lock()
+	defer busMu.Unlock()
+	lastReload = &status
+}
+
+// Validate 校验配置，返回全部错误
+func Validate(c *Config) []string {
+	var errs []string
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Sprintf("log.level 无效: %s", c.Log.Level))
+	}
+	if c.Transfer.MaxConcurrent <= 0 {
+		errs = append(errs, "transfer.max_concurrent 必须大于0")
+	}
+	if c.Transfer.Timeout <= 0 {
+		errs = append(errs, "transfer.timeout 必须大于0")
+	}
+	if c.Transfer.MaxSuccess <= 0 {
+		errs = append(errs, "transfer.max_success 必须大于0")
+	}
+	if c.RateLimit.Rate < 0 || c.RateLimit.RequestsPerSecond < 0 || c.RateLimit.Burst < 0 {
+		errs = append(errs, "rate_limit 的速率和突发容量不能为负数")
+	}
+	for i, p := range c.RateLimit.Policies {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if len(p.Paths) == 0 {
+			errs = append(errs, fmt.Sprintf("rate_limit.policies[%s] 未配置paths", name))
+		}
+		if p.Rate < 0 || p.Burst < 0 {
+			errs = append(errs, fmt.Sprintf("rate_limit.policies[%s] 的速率和突发容量不能为负数", name))
+		}
+		switch strings.ToLower(p.Identity) {
+		case "", RateLimitByIP, RateLimitByAPIKey, RateLimitByOpenID:
+		default:
+			errs = append(errs, fmt.Sprintf("rate_limit.policies[%s] 的identity无效: %s", name, p.Identity))
+		}
+	}
+	if c.Metrics.Enabled && c.Metrics.Token == "" {
+		errs = append(errs, "开启 metrics.enabled 时必须配置 metrics.token")
+	}
+	return errs
+}
+
+// restartRequired 比较新旧配置，返回需重启才能生效的配置节
+func restartRequired(old, c *Config) []string {
+	if old == nil {
+		return nil
+	}
+	var sections []string
+	checks := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"server", old.Server, c.Server},
+		{"database", old.Database, c.Database},
+		{"redis", old.Redis, c.Redis},
+		{"jwt", old.JWT, c.JWT},
+		{"metrics", old.Metrics, c.Metrics},
+		{"log.file_path", old.Log.FilePath, c.Log.FilePath},
+		{"transfer.max_concurrent", old.Transfer.MaxConcurrent, c.Transfer.MaxConcurrent},
+	}
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.old, check.new) {
+			sections = append(sections, check.name)
+		}
+	}
+	return sections
+}
+
+// ReloadFile 重新读取配置文件，校验通过后替换当前配置并通知订阅者
+// 校验失败时保留原配置，错误记录在 LastReload 中
+func ReloadFile(configPath string) ReloadStatus {
+	c, err := readConfig(configPath)
+	if err == nil {
+		if errs := Validate(c); len(errs) > 0 {
+			err = fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+	}
+	if err != nil {
+		status := ReloadStatus{
+			Time:   time.Now().Unix(),
+			Source: ChangeSourceFile,
+			Errors: []string{err.Error()},
+		}
+		recordReload(status)
+		return status
+	}
+
+	restart := restartRequired(current.Swap(c), c)
+	status := Publish(ChangeEvent{Source: ChangeSourceFile, Config: c})
+	status.RestartRequired = restart
+	recordReload(status)
+	return status
+}
+
+// WatchFile 监听配置文件变更并自动重载，onReload在每次重载后调用（可为nil），返回停止监听的函数
+// 监听所在目录而不是文件本身，兼容编辑器先写临时文件再重命名的保存方式
+func WatchFile(configPath string, onReload func(ReloadStatus)) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		// 保存文件常触发多次事件，合并500ms内的事件只重载一次
+		var timer *time.Timer
+		reload := make(chan struct{}, 1)
+		for {
+			select {
+			case <-done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != absPath || ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(500*time.Millisecond, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+			case <-reload:
+				status := ReloadFile(absPath)
+				if onReload != nil {
+					onReload(status)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
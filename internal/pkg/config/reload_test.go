@@ -0,0 +1,117 @@
+﻿package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfigYAML = `server:
+  port: 6060
+log:
+  level: %s
+transfer:
+  max_success: %d
+`
+
+func writeTestConfig(t *testing.T, path, level string, maxSuccess int) {
+	t.Helper()
+	content := []byte(fmt.Sprintf(testConfigYAML, level, maxSuccess))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestConfig(t, path, "info", 2)
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []ChangeEvent
+	Subscribe("test", func(ev ChangeEvent) error {
+		got = append(got, ev)
+		return nil
+	})
+	defer Subscribe("test", func(ChangeEvent) error { return nil })
+
+	writeTestConfig(t, path, "debug", 5)
+	status := ReloadFile(path)
+	if !status.Success || len(got) != 1 || got[0].Config.Transfer.MaxSuccess != 5 {
+		t.Fatalf("ReloadFile() = %+v, events = %d", status, len(got))
+	}
+	if Current().Log.Level != "debug" || GlobalConfig.Log.Level != "info" {
+		t.Errorf("Current = %s, GlobalConfig = %s", Current().Log.Level, GlobalConfig.Log.Level)
+	}
+
+	// 校验失败时保留原配置，不通知订阅者
+	writeTestConfig(t, path, "verbose", 0)
+	status = ReloadFile(path)
+	if status.Success || len(status.Errors) == 0 || len(got) != 1 {
+		t.Fatalf("ReloadFile(无效配置) = %+v, events = %d", status, len(got))
+	}
+	if Current().Transfer.MaxSuccess != 5 {
+		t.Errorf("校验失败后配置被替换: max_success = %d", Current().Transfer.MaxSuccess)
+	}
+	if last := LastReload(); last == nil || last.Success {
+		t.Errorf("LastReload() = %+v, want 记录失败结果", last)
+	}
+
+	// 修改需重启的配置节时提示
+	os.WriteFile(path, []byte("server:\n  port: 7070\n"), 0644)
+	if status := ReloadFile(path); len(status.RestartRequired) != 1 || status.RestartRequired[0] != "server" {
+		t.Errorf("RestartRequired = %v, want [server]", status.RestartRequired)
+	}
+}
+
+func TestValidateMetricsToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestConfig(t, path, "info", 2)
+	c, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metrics.Enabled {
+		t.Error("metrics.enabled 默认应关闭")
+	}
+	if errs := Validate(c); len(errs) != 0 {
+		t.Fatalf("Validate() = %v, want no errors", errs)
+	}
+
+	c.Metrics.Enabled = true
+	if errs := Validate(c); len(errs) != 1 {
+		t.Errorf("Validate(开启指标未配置令牌) = %v, want 1 error", errs)
+	}
+	c.Metrics.Token = "scrape-token"
+	if errs := Validate(c); len(errs) != 0 {
+		t.Errorf("Validate(开启指标并配置令牌) = %v, want no errors", errs)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestConfig(t, path, "info", 2)
+	if _, err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan ReloadStatus, 4)
+	stop, err := WatchFile(path, func(status ReloadStatus) { reloaded <- status })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	writeTestConfig(t, path, "warn", 3)
+	select {
+	case status := <-reloaded:
+		if !status.Success || Current().Transfer.MaxSuccess != 3 {
+			t.Errorf("自动重载 = %+v, max_success = %d", status, Current().Transfer.MaxSuccess)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("修改配置文件后未自动重载")
+	}
+}
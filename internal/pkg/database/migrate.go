@@ -0,0 +1,245 @@
+﻿package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// migration 数据库升级项
+// 新安装由 install/data.sql 建表；之后新增的表和字段需要在这里登记，已安装的数据库启动时补齐
+type migration struct {
+	name       string   // 升级项说明
+	check      string   // 检查语句，返回数量大于0表示已升级
+	statements []string // 未升级时依次执行的语句
+}
+
+// migrations 按添加顺序执行的升级项
+var migrations = []migration{
+	{
+		name:  "qf_api_list 增加命中次数",
+		check: columnExists("qf_api_list", "count"),
+		statements: []string{
+			"ALTER TABLE `qf_api_list` ADD COLUMN `count` int(11) DEFAULT '0' COMMENT '命中次数' AFTER `html_url2`",
+		},
+	},
+	{
+		name:  "创建转存任务表 qf_transfer_job",
+		check: tableExists("qf_transfer_job"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_transfer_job (
+				id bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				job_no varchar(32) NOT NULL COMMENT '任务编号',
+				status varchar(20) NOT NULL DEFAULT 'queued' COMMENT '状态:queued,running,succeeded,failed,cancelled',
+				pan_type tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
+				source varchar(20) DEFAULT NULL COMMENT '任务来源:web,wechat,api',
+				keyword varchar(255) DEFAULT NULL COMMENT '搜索关键词',
+				request mediumtext COMMENT '转存请求(JSON)',
+				result mediumtext COMMENT '转存结果(JSON)',
+				error varchar(500) DEFAULT NULL COMMENT '最近一次错误',
+				attempts int(11) NOT NULL DEFAULT '0' COMMENT '已尝试次数',
+				max_attempts int(11) NOT NULL DEFAULT '3' COMMENT '最大尝试次数',
+				next_run_at bigint(20) NOT NULL DEFAULT '0' COMMENT '下次执行时间',
+				started_at bigint(20) NOT NULL DEFAULT '0' COMMENT '开始时间',
+				finished_at bigint(20) NOT NULL DEFAULT '0' COMMENT '结束时间',
+				create_time bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+				update_time bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+				PRIMARY KEY (id),
+				UNIQUE KEY uk_job_no (job_no),
+				KEY idx_status_next_run (status, next_run_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='转存任务表'`,
+		},
+	},
+	{
+		name:  "qf_transfer_job 增加任务创建者",
+		check: columnExists("qf_transfer_job", "owner"),
+		statements: []string{
+			"ALTER TABLE `qf_transfer_job` ADD COLUMN `owner` varchar(64) DEFAULT NULL COMMENT '创建者:key:<密钥ID>或session:<页面会话哈希>' AFTER `keyword`",
+			"ALTER TABLE `qf_transfer_job` ADD KEY `idx_keyword_status` (`keyword`(64), `status`)",
+		},
+	},
+	{
+		name:  "创建网盘账号表 qf_netdisk_account",
+		check: tableExists("qf_netdisk_account"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_netdisk_account (
+				id int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				pan_type tinyint(1) NOT NULL DEFAULT '0' COMMENT '网盘类型:0夸克,2百度,3阿里,4UC,5迅雷,6天翼,7(123网盘),8(115网盘)',
+				name varchar(100) NOT NULL COMMENT '账号名称',
+				credential text COMMENT 'Cookie或RefreshToken',
+				save_dir varchar(255) DEFAULT NULL COMMENT '默认存储目录,留空使用网盘配置',
+				save_dir_time varchar(255) DEFAULT NULL COMMENT '临时存储目录,留空使用网盘配置',
+				status tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用,2异常停用',
+				use_count bigint(20) NOT NULL DEFAULT '0' COMMENT '成功转存次数',
+				fail_count int(11) NOT NULL DEFAULT '0' COMMENT '连续失败次数',
+				total_space bigint(20) NOT NULL DEFAULT '0' COMMENT '总容量(字节)',
+				used_space bigint(20) NOT NULL DEFAULT '0' COMMENT '已用容量(字节)',
+				last_error varchar(500) DEFAULT NULL COMMENT '最近一次错误',
+				last_used_at bigint(20) NOT NULL DEFAULT '0' COMMENT '最近使用时间',
+				last_check_at bigint(20) NOT NULL DEFAULT '0' COMMENT '最近检查时间',
+				create_time bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+				update_time bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+				PRIMARY KEY (id),
+				KEY idx_pan_type_status (pan_type, status)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='网盘账号表'`,
+		},
+	},
+	{
+		name:  "qf_admin 增加角色，已有管理员设为超级管理员",
+		check: columnExists("qf_admin", "role"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `role` tinyint(4) NOT NULL DEFAULT '0' COMMENT '角色:0只读审计,1内容编辑,2超级管理员' AFTER `status`",
+			// 升级前没有角色区分，已有管理员均拥有全部权限
+			"UPDATE `qf_admin` SET `role` = 2",
+		},
+	},
+	{
+		name:  "qf_admin 增加最后登录IP",
+		check: columnExists("qf_admin", "last_login_ip"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `last_login_ip` varchar(50) DEFAULT NULL COMMENT '最后登录IP' AFTER `last_login_time`",
+		},
+	},
+	{
+		name:  "qf_admin 增加连续登录失败次数",
+		check: columnExists("qf_admin", "login_fail_count"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `login_fail_count` int(11) NOT NULL DEFAULT '0' COMMENT '连续登录失败次数' AFTER `last_login_ip`",
+		},
+	},
+	{
+		name:  "qf_admin 增加登录锁定截止时间",
+		check: columnExists("qf_admin", "locked_until"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `locked_until` bigint(20) NOT NULL DEFAULT '0' COMMENT '登录锁定截止时间,0未锁定' AFTER `login_fail_count`",
+		},
+	},
+	{
+		name:  "qf_admin 增加两步验证密钥",
+		check: columnExists("qf_admin", "totp_secret"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `totp_secret` varchar(64) DEFAULT NULL COMMENT '两步验证密钥' AFTER `locked_until`",
+		},
+	},
+	{
+		name:  "qf_admin 增加两步验证开关",
+		check: columnExists("qf_admin", "totp_enabled"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `totp_enabled` tinyint(4) NOT NULL DEFAULT '0' COMMENT '两步验证:0未启用,1已启用' AFTER `totp_secret`",
+		},
+	},
+	{
+		name:  "qf_admin 增加两步验证恢复码",
+		check: columnExists("qf_admin", "recovery_codes"),
+		statements: []string{
+			"ALTER TABLE `qf_admin` ADD COLUMN `recovery_codes` text COMMENT '两步验证恢复码哈希(JSON)' AFTER `totp_enabled`",
+		},
+	},
+	{
+		name:  "创建开放接口密钥表 qf_api_key",
+		check: tableExists("qf_api_key"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_api_key (
+				id int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				name varchar(100) NOT NULL COMMENT '名称',
+				key_prefix varchar(16) DEFAULT NULL COMMENT '密钥前缀',
+				key_hash char(64) NOT NULL COMMENT '密钥SHA-256',
+				rate_limit int(11) NOT NULL DEFAULT '0' COMMENT '每分钟最大请求数,0不限制',
+				daily_transfer_quota int(11) NOT NULL DEFAULT '0' COMMENT '每日最大转存成功数,0不限制',
+				allow_transfer tinyint(1) NOT NULL DEFAULT '1' COMMENT '允许自动转存:0否,1是',
+				status tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+				expire_time bigint(20) NOT NULL DEFAULT '0' COMMENT '过期时间,0永不过期',
+				remark varchar(255) DEFAULT NULL COMMENT '备注',
+				total_requests bigint(20) NOT NULL DEFAULT '0' COMMENT '累计请求数',
+				total_transfers bigint(20) NOT NULL DEFAULT '0' COMMENT '累计转存成功数',
+				last_used_time bigint(20) NOT NULL DEFAULT '0' COMMENT '最近使用时间',
+				create_time bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+				update_time bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+				PRIMARY KEY (id),
+				UNIQUE KEY uk_key_hash (key_hash)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='开放接口密钥表'`,
+		},
+	},
+	{
+		name:  "创建开放接口密钥每日用量表 qf_api_key_usage",
+		check: tableExists("qf_api_key_usage"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_api_key_usage (
+				id bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				key_id int(11) unsigned NOT NULL COMMENT '密钥ID',
+				date char(10) NOT NULL COMMENT '日期YYYY-MM-DD',
+				requests bigint(20) NOT NULL DEFAULT '0' COMMENT '请求数',
+				transfers bigint(20) NOT NULL DEFAULT '0' COMMENT '转存成功数',
+				PRIMARY KEY (id),
+				UNIQUE KEY uk_key_date (key_id, date)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='开放接口密钥每日用量表'`,
+		},
+	},
+	{
+		name:  "创建插件设置表 qf_plugin_setting",
+		check: tableExists("qf_plugin_setting"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_plugin_setting (
+				id int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				name varchar(50) NOT NULL COMMENT '插件名',
+				status tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+				priority tinyint(1) NOT NULL DEFAULT '0' COMMENT '优先级覆盖:1-4,0使用插件默认优先级',
+				create_time bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+				update_time bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+				PRIMARY KEY (id),
+				UNIQUE KEY uk_name (name)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='插件设置表'`,
+		},
+	},
+	{
+		name:  "创建TG频道表 qf_tg_channel",
+		check: tableExists("qf_tg_channel"),
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS qf_tg_channel (
+				id int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+				name varchar(100) NOT NULL COMMENT '频道用户名',
+				status tinyint(1) NOT NULL DEFAULT '1' COMMENT '状态:0禁用,1启用',
+				weight int(11) NOT NULL DEFAULT '0' COMMENT '权重,越大越先搜索',
+				tags varchar(255) DEFAULT NULL COMMENT '分组标签,多个用逗号分隔',
+				remark varchar(255) DEFAULT NULL COMMENT '备注',
+				create_time bigint(20) NOT NULL DEFAULT '0' COMMENT '创建时间',
+				update_time bigint(20) NOT NULL DEFAULT '0' COMMENT '更新时间',
+				PRIMARY KEY (id),
+				UNIQUE KEY uk_name (name),
+				KEY idx_status_weight (status, weight)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='TG频道表'`,
+		},
+	},
+}
+
+// Migrate 检查并升级已安装数据库的表结构，返回本次执行的升级项（可重复执行）
+func Migrate(db *gorm.DB) ([]string, error) {
+	var applied []string
+	for _, m := range migrations {
+		var count int64
+		if err := db.Raw(m.check).Scan(&count).Error; err != nil {
+			return applied, fmt.Errorf("检查数据库升级项「%s」失败: %w", m.name, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		for _, stmt := range m.statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				return applied, fmt.Errorf("执行数据库升级项「%s」失败: %w", m.name, err)
+			}
+		}
+		applied = append(applied, m.name)
+	}
+	return applied, nil
+}
+
+// tableExists 检查表是否存在的语句
+func tableExists(table string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s'", table)
+}
+
+// columnExists 检查字段是否存在的语句
+func columnExists(table, column string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' AND COLUMN_NAME = '%s'", table, column)
+}
@@ -0,0 +1,428 @@
+﻿package fulltext
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// BM25参数及字段权重
+const (
+	bm25K1       = 1.2
+	bm25B        = 0.75
+	titleBoost   = 2.0
+	fuzzyWeight  = 0.6 // 纠错匹配的权重
+	phraseBonus  = 3.0 // 标题完整包含查询词的加分
+	initialsBase = 2.0 // 拼音首字母匹配的基础分
+)
+
+// Document 索引文档
+type Document struct {
+	ID         uint64
+	Title      string
+	Content    string
+	PanType    int
+	Status     int
+	CategoryID int
+	CreateTime int64
+}
+
+// Filter 搜索过滤条件，小于0表示不过滤
+type Filter struct {
+	PanType    int
+	Status     int
+	CategoryID int
+}
+
+// Hit 搜索命中结果
+type Hit struct {
+	ID    uint64
+	Score float64
+}
+
+type posting struct {
+	title   int // 标题中出现次数
+	content int // 内容中出现次数
+}
+
+type docEntry struct {
+	doc        Document
+	tokens     []string // 去重后的词项，用于删除
+	titleLen   int
+	contentLen int
+	title      string // 归一化后的标题，用于短语匹配
+	initials   string // 标题拼音首字母
+}
+
+// journalOp 重建期间记录的写操作，doc为nil表示删除
+type journalOp struct {
+	id  uint64
+	doc *Document
+}
+
+// Index 内存倒排索引（并发安全）
+type Index struct {
+	mu              sync.RWMutex
+	rebuildMu       sync.Mutex  // 串行化重建
+	journal         []journalOp // 重建期间的写操作，非nil表示正在重建
+	docs            map[uint64]*docEntry
+	postings        map[string]map[uint64]*posting
+	initials        map[string]map[uint64]struct{} // 拼音首字母二元组 -> 文档
+	totalTitleLen   int
+	totalContentLen int
+}
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[uint64]*docEntry),
+		postings: make(map[string]map[uint64]*posting),
+		initials: make(map[string]map[uint64]struct{}),
+	}
+}
+
+// Len 已索引的文档数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Add 添加或更新文档
+func (idx *Index) Add(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.ID)
+	idx.add(doc)
+	if idx.journal != nil {
+		idx.journal = append(idx.journal, journalOp{id: doc.ID, doc: &doc})
+	}
+}
+
+// Remove 删除文档
+func (idx *Index) Remove(id uint64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+	if idx.journal != nil {
+		idx.journal = append(idx.journal, journalOp{id: id})
+	}
+}
+
+// Replace 用给定文档集合整体替换索引内容
+func (idx *Index) Replace(docs []Document) {
+	fresh := NewIndex()
+	for _, doc := range docs {
+		fresh.add(doc)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.swap(fresh)
+}
+
+// Rebuild 重建索引：load在锁外读取全量文档（可能耗时较长），
+// 读取期间发生的Add/Remove会在替换前重放到新索引上，避免被旧快照覆盖
+func (idx *Index) Rebuild(load func() ([]Document, error)) (int, error) {
+	idx.rebuildMu.Lock()
+	defer idx.rebuildMu.Unlock()
+
+	idx.mu.Lock()
+	idx.journal = []journalOp{}
+	idx.mu.Unlock()
+
+	docs, err := load()
+
+	fresh := NewIndex()
+	if err == nil {
+		for _, doc := range docs {
+			fresh.add(doc)
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	journal := idx.journal
+	idx.journal = nil
+	if err != nil {
+		return 0, err
+	}
+
+	for _, op := range journal {
+		fresh.remove(op.id)
+		if op.doc != nil {
+			fresh.add(*op.doc)
+		}
+	}
+	idx.swap(fresh)
+	return len(fresh.docs), nil
+}
+
+// swap 用新索引的内容替换当前索引，调用方需持有写锁
+func (idx *Index) swap(fresh *Index) {
+	idx.docs = fresh.docs
+	idx.postings = fresh.postings
+	idx.initials = fresh.initials
+	idx.totalTitleLen = fresh.totalTitleLen
+	idx.totalContentLen = fresh.totalContentLen
+}
+
+func (idx *Index) add(doc Document) {
+	titleTokens := Tokenize(doc.Title)
+	contentTokens := Tokenize(doc.Content)
+
+	entry := &docEntry{
+		doc:        doc,
+		titleLen:   len(titleTokens),
+		contentLen: len(contentTokens),
+		title:      normalize(doc.Title),
+		initials:   Initials(doc.Title),
+	}
+
+	counts := make(map[string]*posting)
+	for _, token := range titleTokens {
+		if counts[token] == nil {
+			counts[token] = &posting{}
+		}
+		counts[token].title++
+	}
+	for _, token := range contentTokens {
+		if counts[token] == nil {
+			counts[token] = &posting{}
+		}
+		counts[token].content++
+	}
+
+	entry.tokens = make([]string, 0, len(counts))
+	for token, p := range counts {
+		if idx.postings[token] == nil {
+			idx.postings[token] = make(map[uint64]*posting)
+		}
+		idx.postings[token][doc.ID] = p
+		entry.tokens = append(entry.tokens, token)
+	}
+
+	for _, gram := range initialsGrams(entry.initials) {
+		if idx.initials[gram] == nil {
+			idx.initials[gram] = make(map[uint64]struct{})
+		}
+		idx.initials[gram][doc.ID] = struct{}{}
+	}
+
+	idx.docs[doc.ID] = entry
+	idx.totalTitleLen += entry.titleLen
+	idx.totalContentLen += entry.contentLen
+}
+
+func (idx *Index) remove(id uint64) {
+	entry, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, token := range entry.tokens {
+		if docs := idx.postings[token]; docs != nil {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(idx.postings, token)
+			}
+		}
+	}
+	for _, gram := range initialsGrams(entry.initials) {
+		if docs := idx.initials[gram]; docs != nil {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(idx.initials, gram)
+			}
+		}
+	}
+
+	idx.totalTitleLen -= entry.titleLen
+	idx.totalContentLen -= entry.contentLen
+	delete(idx.docs, id)
+}
+
+// Search 搜索并按相关度排序，limit<=0时返回全部命中；total为过滤后的命中总数
+func (idx *Index) Search(query string, filter Filter, limit int) ([]Hit, int) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	query = strings.TrimSpace(query)
+	tokens := QueryTokens(query)
+	if len(tokens) == 0 || len(idx.docs) == 0 {
+		return nil, 0
+	}
+
+	scores := make(map[uint64]float64)
+	matched := make(map[uint64]int)
+
+	for _, token := range tokens {
+		// 每个查询词在同一文档只计一次命中（精确或纠错）
+		hitDocs := make(map[uint64]bool)
+		idx.scoreToken(token, 1.0, scores, hitDocs)
+		if len(hitDocs) == 0 {
+			for _, variant := range idx.fuzzyVariants(token) {
+				idx.scoreToken(variant, fuzzyWeight, scores, hitDocs)
+			}
+		}
+		for id := range hitDocs {
+			matched[id]++
+		}
+	}
+
+	// 查询词较多时允许部分不命中，以容忍错别字
+	minMatch := len(tokens)
+	if len(tokens) > 2 {
+		minMatch = int(math.Ceil(float64(len(tokens)) * 0.6))
+	}
+
+	normalizedQuery := normalize(query)
+	results := make(map[uint64]float64)
+	for id, count := range matched {
+		if count < minMatch {
+			continue
+		}
+		score := scores[id]
+		if strings.Contains(idx.docs[id].title, normalizedQuery) {
+			score += phraseBonus
+		}
+		results[id] = score
+	}
+
+	// 拼音首字母匹配
+	if isInitialsQuery(query) {
+		for id, score := range idx.matchInitials(normalizedQuery) {
+			results[id] += score
+		}
+	}
+
+	hits := make([]Hit, 0, len(results))
+	for id, score := range results {
+		doc := idx.docs[id].doc
+		if filter.PanType >= 0 && doc.PanType != filter.PanType {
+			continue
+		}
+		if filter.Status >= 0 && doc.Status != filter.Status {
+			continue
+		}
+		if filter.CategoryID >= 0 && doc.CategoryID != filter.CategoryID {
+			continue
+		}
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return idx.docs[hits[i].ID].doc.CreateTime > idx.docs[hits[j].ID].doc.CreateTime
+	})
+
+	total := len(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total
+}
+
+// scoreToken 按BM25累加单个词项的得分
+func (idx *Index) scoreToken(token string, weight float64, scores map[uint64]float64, hitDocs map[uint64]bool) {
+	docs := idx.postings[token]
+	if len(docs) == 0 {
+		return
+	}
+
+	n := float64(len(idx.docs))
+	df := float64(len(docs))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	avgTitle := math.Max(float64(idx.totalTitleLen)/n, 1)
+	avgContent := math.Max(float64(idx.totalContentLen)/n, 1)
+
+	for id, p := range docs {
+		entry := idx.docs[id]
+		score := 0.0
+		if p.title > 0 {
+			score += titleBoost * bm25(float64(p.title), float64(entry.titleLen), avgTitle)
+		}
+		if p.content > 0 {
+			score += bm25(float64(p.content), float64(entry.contentLen), avgContent)
+		}
+		scores[id] += weight * idf * score
+		hitDocs[id] = true
+	}
+}
+
+func bm25(tf, length, avgLength float64) float64 {
+	return tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+}
+
+// fuzzyVariants 为未命中的字母词查找编辑距离相近的词项（拼写纠错）
+func (idx *Index) fuzzyVariants(token string) []string {
+	length := utf8.RuneCountInString(token)
+	if length < 4 || token[0] >= utf8.RuneSelf {
+		return nil
+	}
+	maxDistance := 1
+	if length >= 8 {
+		maxDistance = 2
+	}
+
+	var variants []string
+	for term := range idx.postings {
+		if term[0] >= utf8.RuneSelf {
+			continue
+		}
+		if editDistance(token, term, maxDistance) <= maxDistance {
+			variants = append(variants, term)
+		}
+	}
+	return variants
+}
+
+// matchInitials 拼音首字母匹配：用二元组缩小候选，再校验是否连续包含
+func (idx *Index) matchInitials(query string) map[uint64]float64 {
+	grams := initialsGrams(query)
+	if len(grams) == 0 {
+		return nil
+	}
+
+	var candidates map[uint64]struct{}
+	for _, gram := range grams {
+		docs := idx.initials[gram]
+		if len(docs) == 0 {
+			return nil
+		}
+		if candidates == nil || len(docs) < len(candidates) {
+			candidates = docs
+		}
+	}
+
+	results := make(map[uint64]float64)
+	for id := range candidates {
+		initials := idx.docs[id].initials
+		if strings.Contains(initials, query) {
+			// 首字母覆盖标题的比例越高，得分越高
+			results[id] = initialsBase * (1 + float64(len(query))/float64(len(initials)))
+		}
+	}
+	return results
+}
+
+// initialsGrams 首字母串的二元组
+func initialsGrams(initials string) []string {
+	if len(initials) < 2 {
+		return nil
+	}
+	grams := make([]string, 0, len(initials)-1)
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(initials); i++ {
+		gram := initials[i : i+2]
+		if !seen[gram] {
+			seen[gram] = true
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
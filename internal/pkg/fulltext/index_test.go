@@ -0,0 +1,269 @@
+﻿package fulltext
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// noFilter 不过滤任何字段
+var noFilter = Filter{PanType: -1, Status: -1, CategoryID: -1}
+
+func hitIDs(hits []Hit) []uint64 {
+	ids := make([]uint64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"流浪地球2 4K", []string{"流", "浪", "地", "球", "流浪", "浪地", "地球", "2", "4k"}},
+		{"Ｈｅｌｌｏ，世界", []string{"hello", "世", "界", "世界"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestQueryTokens(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"流浪地球", []string{"流浪", "浪地", "地球"}},
+		{"剧 Avatar avatar", []string{"剧", "avatar"}},
+		{"，。", nil},
+	}
+	for _, tt := range tests {
+		if got := QueryTokens(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryTokens(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchBM25Order(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "纪录片合集", Content: "包含流浪地球幕后花絮"})
+	idx.Add(Document{ID: 2, Title: "流浪地球2 4K", Content: "科幻电影"})
+	idx.Add(Document{ID: 3, Title: "流浪地球", Content: "流浪地球 国语中字"})
+	idx.Add(Document{ID: 4, Title: "三体", Content: "科幻剧集"})
+
+	hits, total := idx.Search("流浪地球", noFilter, 0)
+	if total != 3 {
+		t.Fatalf("total = %d, want 3", total)
+	}
+	// 标题完全匹配且内容重复出现的排最前，仅内容命中的排最后
+	if got, want := hitIDs(hits), []uint64{3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	hits, total = idx.Search("流浪地球", noFilter, 1)
+	if total != 3 || len(hits) != 1 || hits[0].ID != 3 {
+		t.Errorf("limit 1: hits = %v, total = %d", hitIDs(hits), total)
+	}
+}
+
+func TestSearchTieBreaksByCreateTime(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "三体", CreateTime: 100})
+	idx.Add(Document{ID: 2, Title: "三体", CreateTime: 200})
+
+	hits, _ := idx.Search("三体", noFilter, 0)
+	if got, want := hitIDs(hits), []uint64{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "Avatar 2009"})
+	idx.Add(Document{ID: 2, Title: "Interstellar"})
+
+	hits, _ := idx.Search("avatr", noFilter, 0)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Errorf("avatr = %v, want [1]", got)
+	}
+
+	// 长词允许两处错误
+	hits, _ = idx.Search("intersteler", noFilter, 0)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint64{2}) {
+		t.Errorf("intersteler = %v, want [2]", got)
+	}
+
+	// 短词不纠错
+	idx.Add(Document{ID: 3, Title: "abc"})
+	if hits, _ := idx.Search("abd", noFilter, 0); len(hits) != 0 {
+		t.Errorf("abd = %v, want none", hitIDs(hits))
+	}
+
+	// 精确命中优先于纠错命中
+	idx.Add(Document{ID: 4, Title: "Avatr"})
+	hits, _ = idx.Search("avatr", noFilter, 0)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint64{4}) {
+		t.Errorf("avatr with exact term = %v, want [4]", got)
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"流浪地球2", "lldq2"},
+		{"三体 Ⅰ", "st"},
+		{"Hello世界", "hellosj"},
+	}
+	for _, tt := range tests {
+		if got := Initials(tt.text); got != tt.want {
+			t.Errorf("Initials(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchInitials(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "流浪地球"})
+	idx.Add(Document{ID: 2, Title: "流浪地球2 特别版"})
+	idx.Add(Document{ID: 3, Title: "三体"})
+
+	hits, total := idx.Search("lldq", noFilter, 0)
+	if total != 2 {
+		t.Fatalf("total = %d, want 2", total)
+	}
+	// 首字母覆盖标题比例更高的排前
+	if got, want := hitIDs(hits), []uint64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	if hits, _ := idx.Search("ldq", noFilter, 0); len(hits) != 2 {
+		t.Errorf("ldq = %v, want 2 hits", hitIDs(hits))
+	}
+	if hits, _ := idx.Search("lqd", noFilter, 0); len(hits) != 0 {
+		t.Errorf("lqd = %v, want none", hitIDs(hits))
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "三体", PanType: 0, Status: 1, CategoryID: 1})
+	idx.Add(Document{ID: 2, Title: "三体", PanType: 1, Status: 1, CategoryID: 2})
+	idx.Add(Document{ID: 3, Title: "三体", PanType: 1, Status: 0, CategoryID: 2})
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"不过滤", noFilter, 3},
+		{"网盘类型", Filter{PanType: 1, Status: -1, CategoryID: -1}, 2},
+		{"状态", Filter{PanType: -1, Status: 1, CategoryID: -1}, 2},
+		{"分类", Filter{PanType: -1, Status: -1, CategoryID: 1}, 1},
+		{"组合", Filter{PanType: 1, Status: 1, CategoryID: 2}, 1},
+		{"无匹配", Filter{PanType: 2, Status: -1, CategoryID: -1}, 0},
+	}
+	for _, tt := range tests {
+		if _, total := idx.Search("三体", tt.filter, 0); total != tt.want {
+			t.Errorf("%s: total = %d, want %d", tt.name, total, tt.want)
+		}
+	}
+}
+
+func TestAddRemoveReplace(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "三体"})
+	idx.Add(Document{ID: 2, Title: "流浪地球"})
+	if idx.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", idx.Len())
+	}
+
+	// 更新文档会替换旧词项
+	idx.Add(Document{ID: 1, Title: "球状闪电"})
+	if hits, _ := idx.Search("三体", noFilter, 0); len(hits) != 0 {
+		t.Errorf("stale title still indexed: %v", hitIDs(hits))
+	}
+	if hits, _ := idx.Search("闪电", noFilter, 0); !reflect.DeepEqual(hitIDs(hits), []uint64{1}) {
+		t.Errorf("updated title = %v, want [1]", hitIDs(hits))
+	}
+
+	idx.Remove(2)
+	idx.Remove(99)
+	if idx.Len() != 1 {
+		t.Errorf("Len() after remove = %d, want 1", idx.Len())
+	}
+	if hits, _ := idx.Search("lldq", noFilter, 0); len(hits) != 0 {
+		t.Errorf("removed doc still matches initials: %v", hitIDs(hits))
+	}
+	if len(idx.postings["地球"]) != 0 || idx.totalTitleLen != len(Tokenize("球状闪电")) {
+		t.Errorf("postings not cleaned up: %v, totalTitleLen = %d", idx.postings["地球"], idx.totalTitleLen)
+	}
+
+	idx.Replace([]Document{{ID: 5, Title: "三体"}, {ID: 6, Title: "三体2"}})
+	if idx.Len() != 2 {
+		t.Errorf("Len() after replace = %d, want 2", idx.Len())
+	}
+	if hits, _ := idx.Search("闪电", noFilter, 0); len(hits) != 0 {
+		t.Errorf("replaced doc still indexed: %v", hitIDs(hits))
+	}
+	if _, total := idx.Search("三体", noFilter, 0); total != 2 {
+		t.Errorf("total after replace = %d, want 2", total)
+	}
+}
+
+func TestRebuildReplaysConcurrentWrites(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "三体"})
+	idx.Add(Document{ID: 2, Title: "流浪地球"})
+
+	count, err := idx.Rebuild(func() ([]Document, error) {
+		// 读取快照后、替换前发生的写操作
+		idx.Add(Document{ID: 3, Title: "球状闪电"})
+		idx.Add(Document{ID: 1, Title: "三体 全集"})
+		idx.Remove(2)
+		return []Document{{ID: 1, Title: "三体"}, {ID: 2, Title: "流浪地球"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+	if count != 2 || idx.Len() != 2 {
+		t.Errorf("count = %d, Len() = %d, want 2", count, idx.Len())
+	}
+	if hits, _ := idx.Search("流浪地球", noFilter, 0); len(hits) != 0 {
+		t.Errorf("removed doc restored by rebuild: %v", hitIDs(hits))
+	}
+	if hits, _ := idx.Search("闪电", noFilter, 0); !reflect.DeepEqual(hitIDs(hits), []uint64{3}) {
+		t.Errorf("added doc lost by rebuild: %v", hitIDs(hits))
+	}
+	if hits, _ := idx.Search("全集", noFilter, 0); !reflect.DeepEqual(hitIDs(hits), []uint64{1}) {
+		t.Errorf("updated doc reverted by rebuild: %v", hitIDs(hits))
+	}
+
+	// 重建结束后不再记录写操作
+	idx.Add(Document{ID: 4, Title: "三体2"})
+	if idx.journal != nil {
+		t.Errorf("journal = %v, want nil after rebuild", idx.journal)
+	}
+}
+
+func TestRebuildErrorKeepsIndex(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Document{ID: 1, Title: "三体"})
+
+	loadErr := errors.New("db down")
+	if _, err := idx.Rebuild(func() ([]Document, error) {
+		idx.Add(Document{ID: 2, Title: "流浪地球"})
+		return nil, loadErr
+	}); !errors.Is(err, loadErr) {
+		t.Fatalf("Rebuild() error = %v, want %v", err, loadErr)
+	}
+	if idx.Len() != 2 || idx.journal != nil {
+		t.Errorf("Len() = %d, journal = %v, want 2/nil", idx.Len(), idx.journal)
+	}
+}
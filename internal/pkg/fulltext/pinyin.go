@@ -0,0 +1,62 @@
+﻿package fulltext
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// GB2312一级汉字按拼音排序，根据编码区间即可得到拼音首字母
+var initialBoundaries = []struct {
+	code   int
+	letter byte
+}{
+	{45217, 'a'}, {45253, 'b'}, {45761, 'c'}, {46318, 'd'}, {46826, 'e'},
+	{47010, 'f'}, {47297, 'g'}, {47614, 'h'}, {48119, 'j'}, {49062, 'k'},
+	{49324, 'l'}, {49896, 'm'}, {50371, 'n'}, {50614, 'o'}, {50622, 'p'},
+	{50906, 'q'}, {51387, 'r'}, {51446, 's'}, {52218, 't'}, {52698, 'w'},
+	{52980, 'x'}, {53689, 'y'}, {54481, 'z'},
+}
+
+// initialsEnd GB2312一级汉字结束编码
+const initialsEnd = 55289
+
+// initialOf 获取单个汉字的拼音首字母，非一级常用汉字返回0
+func initialOf(r rune) byte {
+	encoded, err := simplifiedchinese.GBK.NewEncoder().String(string(r))
+	if err != nil || len(encoded) != 2 {
+		return 0
+	}
+
+	code := int(encoded[0])<<8 | int(encoded[1])
+	if code < initialBoundaries[0].code || code >= initialsEnd {
+		return 0
+	}
+
+	letter := initialBoundaries[0].letter
+	for _, b := range initialBoundaries {
+		if code < b.code {
+			break
+		}
+		letter = b.letter
+	}
+	return letter
+}
+
+// Initials 获取文本的拼音首字母串：汉字取首字母，字母数字原样保留（小写），其他字符忽略
+// 例如 "流浪地球2" -> "lldq2"
+func Initials(text string) string {
+	var sb strings.Builder
+	for _, r := range normalize(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if letter := initialOf(r); letter != 0 {
+				sb.WriteByte(letter)
+			}
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
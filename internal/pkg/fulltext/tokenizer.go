@@ -0,0 +1,161 @@
+﻿package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalize 统一文本：全角转半角、转小写
+func normalize(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r == 0x3000:
+			r = ' '
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFEE0
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// segment 文本片段：连续的汉字或连续的字母数字
+type segment struct {
+	text  []rune
+	isHan bool
+}
+
+// split 将文本切分为汉字片段和字母数字片段，标点空白作为分隔
+func split(text string) []segment {
+	var segments []segment
+	var current []rune
+	currentHan := false
+
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, segment{text: current, isHan: currentHan})
+			current = nil
+		}
+	}
+
+	for _, r := range normalize(text) {
+		isHan := unicode.Is(unicode.Han, r)
+		isWord := !isHan && (unicode.IsLetter(r) || unicode.IsDigit(r))
+
+		if !isHan && !isWord {
+			flush()
+			continue
+		}
+		if len(current) > 0 && isHan != currentHan {
+			flush()
+		}
+		currentHan = isHan
+		current = append(current, r)
+	}
+	flush()
+
+	return segments
+}
+
+// Tokenize 索引分词：汉字切分为单字+二元组（bigram），字母数字按整词
+// 例如 "流浪地球2 4K" -> [流 浪 地 球 流浪 浪地 地球 2 4k]
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, seg := range split(text) {
+		if !seg.isHan {
+			tokens = append(tokens, string(seg.text))
+			continue
+		}
+		for _, r := range seg.text {
+			tokens = append(tokens, string(r))
+		}
+		for i := 0; i+1 < len(seg.text); i++ {
+			tokens = append(tokens, string(seg.text[i:i+2]))
+		}
+	}
+	return tokens
+}
+
+// QueryTokens 查询分词：多字汉字片段只取二元组（单字命中过多），单字片段保留单字
+func QueryTokens(query string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, seg := range split(query) {
+		if !seg.isHan {
+			add(string(seg.text))
+			continue
+		}
+		if len(seg.text) == 1 {
+			add(string(seg.text))
+			continue
+		}
+		for i := 0; i+1 < len(seg.text); i++ {
+			add(string(seg.text[i : i+2]))
+		}
+	}
+	return tokens
+}
+
+// isInitialsQuery 判断查询是否可能是拼音首字母（纯字母，2-12位）
+func isInitialsQuery(query string) bool {
+	query = strings.TrimSpace(normalize(query))
+	if len(query) < 2 || len(query) > 12 {
+		return false
+	}
+	for _, r := range query {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistance 计算两个字符串的编辑距离，超过max时提前返回max+1
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,53 +1,204 @@
 ﻿package jwt
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"huoxing-search/internal/pkg/config"
+)
+
+// 令牌类型
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// defaultAccessExpireMinutes 未配置时访问令牌的有效期（分钟）
+const defaultAccessExpireMinutes = 30
+
+var (
+	// ErrTokenType 令牌类型不匹配（如用刷新令牌访问接口）
+	ErrTokenType = errors.New("token类型错误")
+	// ErrTokenRevoked 令牌已被吊销（登出或刷新后旧的刷新令牌）
+	ErrTokenRevoked = errors.New("token已被吊销")
+	// ErrUserTokensRevoked 用户的令牌已被整体吊销（退出所有设备、重置密码、禁用账号）
+	ErrUserTokensRevoked = errors.New("token已失效，请重新登录")
 )
 
 // Claims JWT声明
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Role     int    `json:"role"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Role      int    `json:"role"`
+	TokenType string `json:"typ,omitempty"` // 旧版本签发的token没有该字段，视为访问令牌
+	// IssuedAtNano 签发时间（Unix纳秒），iat只有秒级精度，整体吊销后同一秒内重新登录签发的token需要用它区分
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsRefresh 是否为刷新令牌
+func (c *Claims) IsRefresh() bool {
+	return c.TokenType == TokenTypeRefresh
+}
+
+// issuedAt 签发时间，旧版本签发的token没有纳秒字段时退化为iat（秒级）
+func (c *Claims) issuedAt() (time.Time, bool) {
+	if c.IssuedAtNano > 0 {
+		return time.Unix(0, c.IssuedAtNano), true
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time, true
+	}
+	return time.Time{}, false
+}
+
+// RemainingTTL token剩余有效期
+func (c *Claims) RemainingTTL() time.Duration {
+	if c.ExpiresAt == nil {
+		return 0
+	}
+	return time.Until(c.ExpiresAt.Time)
+}
+
+// TokenPair 访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// RevocationStore 令牌吊销状态查询
+type RevocationStore interface {
+	// IsTokenRevoked 单个令牌（jti）是否已吊销
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// UserTokensRevokedAt 用户令牌整体吊销的时间点（Unix纳秒），该时间及之前签发的令牌均失效，未吊销返回0
+	UserTokensRevokedAt(ctx context.Context, userID int64) (int64, error)
+}
+
 // JWTService JWT服务
 type JWTService struct {
-	secret     []byte
-	expiration time.Duration
+	secret            []byte
+	expiration        time.Duration
+	refreshExpiration time.Duration
 }
 
-// NewJWTService 创建JWT服务
+// NewJWTService 创建JWT服务（访问令牌和刷新令牌使用相同有效期）
 func NewJWTService(secret string, expirationHours int) *JWTService {
 	return &JWTService{
-		secret:     []byte(secret),
-		expiration: time.Duration(expirationHours) * time.Hour,
+		secret:            []byte(secret),
+		expiration:        time.Duration(expirationHours) * time.Hour,
+		refreshExpiration: time.Duration(expirationHours) * time.Hour,
+	}
+}
+
+// NewFromConfig 按配置创建JWT服务
+// access_expire_minutes 为访问令牌有效期，expiration（兼容 expire_hours）为刷新令牌有效期即登录有效期
+func NewFromConfig(cfg config.JWTConfig) *JWTService {
+	refreshHours := cfg.Expiration
+	if refreshHours == 0 {
+		refreshHours = cfg.ExpireHours
+	}
+	accessMinutes := cfg.AccessExpireMinutes
+	if accessMinutes <= 0 {
+		accessMinutes = defaultAccessExpireMinutes
 	}
+
+	s := NewJWTService(cfg.Secret, refreshHours)
+	s.expiration = time.Duration(accessMinutes) * time.Minute
+	if s.refreshExpiration < s.expiration {
+		s.refreshExpiration = s.expiration
+	}
+	return s
+}
+
+// RefreshExpiration 刷新令牌有效期（即任何令牌的最长有效期）
+func (s *JWTService) RefreshExpiration() time.Duration {
+	return s.refreshExpiration
 }
 
-// GenerateToken 生成token
+// GenerateToken 生成访问令牌
 func (s *JWTService) GenerateToken(userID int64, username string, role int) (string, error) {
+	token, _, err := s.generate(userID, username, role, TokenTypeAccess, s.expiration)
+	return token, err
+}
+
+// GenerateTokenPair 生成访问令牌和刷新令牌
+func (s *JWTService) GenerateTokenPair(userID int64, username string, role int) (*TokenPair, error) {
+	accessToken, accessExpiresAt, err := s.generate(userID, username, role, TokenTypeAccess, s.expiration)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshExpiresAt, err := s.generate(userID, username, role, TokenTypeRefresh, s.refreshExpiration)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// generate 签发指定类型的token，每个token带唯一jti用于吊销
+func (s *JWTService) generate(userID int64, username string, role int, tokenType string, expiration time.Duration) (string, time.Time, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(expiration)
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:       userID,
+		Username:     username,
+		Role:         role,
+		TokenType:    tokenType,
+		IssuedAtNano: now.UnixNano(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	signed, err := token.SignedString(s.secret)
+	return signed, expiresAt, err
 }
 
-// ValidateToken 验证token
+// ValidateToken 验证访问令牌（只校验签名、有效期和类型，不查询吊销状态）
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.IsRefresh() {
+		return nil, ErrTokenType
+	}
+	return claims, nil
+}
+
+// ValidateRefreshToken 验证刷新令牌（不查询吊销状态）
+func (s *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsRefresh() {
+		return nil, ErrTokenType
+	}
+	return claims, nil
+}
+
+func (s *JWTService) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -66,18 +217,41 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken 刷新token
-func (s *JWTService) RefreshToken(tokenString string) (string, error) {
-	claims, err := s.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+// ParseToken 解析token (与ValidateToken相同,用于兼容)
+func (s *JWTService) ParseToken(tokenString string) (*Claims, error) {
+	return s.ValidateToken(tokenString)
+}
+
+// CheckRevoked 检查token是否已被吊销
+func CheckRevoked(ctx context.Context, store RevocationStore, claims *Claims) error {
+	if claims.ID != "" {
+		revoked, err := store.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return fmt.Errorf("查询token吊销状态失败: %w", err)
+		}
+		if revoked {
+			return ErrTokenRevoked
+		}
 	}
 
-	// 生成新token
-	return s.GenerateToken(claims.UserID, claims.Username, claims.Role)
+	revokedAt, err := store.UserTokensRevokedAt(ctx, claims.UserID)
+	if err != nil {
+		return fmt.Errorf("查询token吊销状态失败: %w", err)
+	}
+	if revokedAt > 0 {
+		issuedAt, ok := claims.issuedAt()
+		if !ok || !issuedAt.After(time.Unix(0, revokedAt)) {
+			return ErrUserTokensRevoked
+		}
+	}
+	return nil
 }
 
-// ParseToken 解析token (与ValidateToken相同,用于兼容)
-func (s *JWTService) ParseToken(tokenString string) (*Claims, error) {
-	return s.ValidateToken(tokenString)
-}
\ No newline at end of file
+// newTokenID 生成随机jti
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成token ID失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
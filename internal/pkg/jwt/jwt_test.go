@@ -0,0 +1,104 @@
+﻿package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"huoxing-search/internal/pkg/config"
+)
+
+// memoryRevocationStore 测试用吊销存储
+type memoryRevocationStore struct {
+	tokens    map[string]bool
+	revokedAt map[int64]int64
+}
+
+func (s *memoryRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.tokens[jti], nil
+}
+
+func (s *memoryRevocationStore) UserTokensRevokedAt(ctx context.Context, userID int64) (int64, error) {
+	return s.revokedAt[userID], nil
+}
+
+func TestNewFromConfig(t *testing.T) {
+	s := NewFromConfig(config.JWTConfig{Secret: "secret", ExpireHours: 168})
+	if s.expiration != defaultAccessExpireMinutes*time.Minute || s.refreshExpiration != 168*time.Hour {
+		t.Errorf("expiration = %v/%v", s.expiration, s.refreshExpiration)
+	}
+
+	s = NewFromConfig(config.JWTConfig{Secret: "secret", Expiration: 2, ExpireHours: 168, AccessExpireMinutes: 15})
+	if s.expiration != 15*time.Minute || s.refreshExpiration != 2*time.Hour {
+		t.Errorf("expiration = %v/%v", s.expiration, s.refreshExpiration)
+	}
+}
+
+func TestTokenPairTypes(t *testing.T) {
+	s := NewFromConfig(config.JWTConfig{Secret: "secret", Expiration: 24})
+
+	pair, err := s.GenerateTokenPair(1, "admin", 0)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+
+	access, err := s.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken(access) error = %v", err)
+	}
+	refresh, err := s.ValidateRefreshToken(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken(refresh) error = %v", err)
+	}
+	if access.ID == "" || refresh.ID == "" || access.ID == refresh.ID {
+		t.Errorf("jti = %q/%q, want distinct non-empty", access.ID, refresh.ID)
+	}
+
+	if _, err := s.ValidateToken(pair.RefreshToken); !errors.Is(err, ErrTokenType) {
+		t.Errorf("ValidateToken(refresh) error = %v, want ErrTokenType", err)
+	}
+	if _, err := s.ValidateRefreshToken(pair.AccessToken); !errors.Is(err, ErrTokenType) {
+		t.Errorf("ValidateRefreshToken(access) error = %v, want ErrTokenType", err)
+	}
+	if _, err := NewJWTService("other", 1).ValidateToken(pair.AccessToken); err == nil {
+		t.Error("ValidateToken() 使用其他密钥签名的token应返回错误")
+	}
+}
+
+func TestCheckRevoked(t *testing.T) {
+	s := NewFromConfig(config.JWTConfig{Secret: "secret", Expiration: 24})
+	token, _ := s.GenerateToken(1, "admin", 0)
+	claims, _ := s.ValidateToken(token)
+	issuedAt := claims.IssuedAtNano
+	legacy := *claims
+	legacy.IssuedAtNano = 0
+
+	tests := []struct {
+		name  string
+		store *memoryRevocationStore
+		want  error
+	}{
+		{name: "未吊销", store: &memoryRevocationStore{}},
+		{name: "吊销jti", store: &memoryRevocationStore{tokens: map[string]bool{claims.ID: true}}, want: ErrTokenRevoked},
+		{name: "签发后整体吊销", store: &memoryRevocationStore{revokedAt: map[int64]int64{1: issuedAt}}, want: ErrUserTokensRevoked},
+		{name: "整体吊销后签发", store: &memoryRevocationStore{revokedAt: map[int64]int64{1: issuedAt - 1}}},
+		{name: "整体吊销后同一秒内签发", store: &memoryRevocationStore{revokedAt: map[int64]int64{1: claims.IssuedAt.Unix() * int64(time.Second)}}},
+		{name: "其他用户整体吊销", store: &memoryRevocationStore{revokedAt: map[int64]int64{2: issuedAt}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckRevoked(context.Background(), tt.store, claims); !errors.Is(err, tt.want) {
+				t.Errorf("CheckRevoked() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// 旧版本签发的token没有纳秒签发时间，按iat秒级比较
+	revokedAt := claims.IssuedAt.Add(500 * time.Millisecond).UnixNano()
+	store := &memoryRevocationStore{revokedAt: map[int64]int64{1: revokedAt}}
+	if err := CheckRevoked(context.Background(), store, &legacy); !errors.Is(err, ErrUserTokensRevoked) {
+		t.Errorf("CheckRevoked(旧token) error = %v, want ErrUserTokensRevoked", err)
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 var Logger *zap.Logger
 
+// atomicLevel 当前日志级别，所有输出共用
+var atomicLevel = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
 // InitLogger 初始化日志
 func InitLogger(level, filePath string, maxSize, maxBackups, maxAge int) error {
 	// 确保日志目录存在
@@ -20,20 +23,8 @@ func InitLogger(level, filePath string, maxSize, maxBackups, maxAge int) error {
 		}
 	}
 
-	// 设置日志级别
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
-	case "warn":
-		zapLevel = zapcore.WarnLevel
-	case "error":
-		zapLevel = zapcore.ErrorLevel
-	default:
-		zapLevel = zapcore.InfoLevel
-	}
+	// 设置日志级别（可通过SetLevel热更新）
+	atomicLevel.SetLevel(parseLevel(level))
 
 	// 编码器配置
 	encoderConfig := zapcore.EncoderConfig{
@@ -56,7 +47,7 @@ func InitLogger(level, filePath string, maxSize, maxBackups, maxAge int) error {
 	consoleCore := zapcore.NewCore(
 		consoleEncoder,
 		zapcore.AddSync(os.Stdout),
-		zapLevel,
+		atomicLevel,
 	)
 
 	var cores []zapcore.Core
@@ -72,7 +63,7 @@ func InitLogger(level, filePath string, maxSize, maxBackups, maxAge int) error {
 		fileCore := zapcore.NewCore(
 			fileEncoder,
 			zapcore.AddSync(file),
-			zapLevel,
+			atomicLevel,
 		)
 		cores = append(cores, fileCore)
 	}
@@ -84,6 +75,32 @@ func InitLogger(level, filePath string, maxSize, maxBackups, maxAge int) error {
 	return nil
 }
 
+// parseLevel 解析日志级别，无法识别时使用info
+func parseLevel(level string) zapcore.Level {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// SetLevel 运行时调整日志级别
+func SetLevel(level string) {
+	atomicLevel.SetLevel(parseLevel(level))
+}
+
+// GetLevel 获取当前日志级别
+func GetLevel() string {
+	return atomicLevel.Level().String()
+}
+
 // Debug 记录debug级别日志
 func Debug(msg string, fields ...zap.Field) {
 	Logger.Debug(msg, fields...)
@@ -0,0 +1,368 @@
+﻿// Package metrics 轻量的Prometheus指标注册与文本格式输出
+//
+// 只实现计数器、直方图和采集时回调三种指标，输出 text/plain; version=0.0.4 格式，
+// 可直接被Prometheus抓取
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus文本格式的Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Sample 单个采样值，Labels与所属指标的标签名一一对应
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Family 同名指标的一组采样值
+type Family struct {
+	Name       string
+	Help       string
+	Type       string
+	LabelNames []string
+	Samples    []Sample
+}
+
+// collector 指标采集者
+type collector interface {
+	collect() []Family
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry 默认注册表，NewCounterVec等函数创建的指标都注册在这里
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// RegisterFunc 注册采集时回调，用于输出运行时状态、连接池等无需自行计数的指标
+func (r *Registry) RegisterFunc(fn func() []Family) {
+	r.register(collectorFunc(fn))
+}
+
+// Write 按Prometheus文本格式输出全部指标（按注册顺序，直方图的各行保持相邻）
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		for _, f := range c.collect() {
+			writeFamily(bw, f)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler 输出指标的HTTP处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+// RegisterFunc 在默认注册表注册采集时回调
+func RegisterFunc(fn func() []Family) {
+	DefaultRegistry.RegisterFunc(fn)
+}
+
+// Handler 默认注册表的HTTP处理器
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+type collectorFunc func() []Family
+
+func (f collectorFunc) collect() []Family { return f() }
+
+// ============================================================
+// 计数器
+// ============================================================
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*Sample
+}
+
+// NewCounterVec 创建计数器并注册到默认注册表
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*Sample),
+	}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc 计数加1，labels按创建时的标签名顺序传入
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add 计数增加v（v必须非负）
+func (c *CounterVec) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	key := labelKey(labels)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &Sample{Labels: append([]string(nil), labels...)}
+		c.values[key] = s
+	}
+	s.Value += v
+}
+
+// Value 获取计数值
+func (c *CounterVec) Value(labels ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.values[labelKey(labels)]; ok {
+		return s.Value
+	}
+	return 0
+}
+
+func (c *CounterVec) collect() []Family {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, s := range c.values {
+		samples = append(samples, *s)
+	}
+	c.mu.Unlock()
+
+	sortSamples(samples)
+	return []Family{{Name: c.name, Help: c.help, Type: TypeCounter, LabelNames: c.labelNames, Samples: samples}}
+}
+
+// ============================================================
+// 直方图
+// ============================================================
+
+// DefBuckets 默认耗时分桶（秒），覆盖本地查询到插件超时的范围
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// histogram 单组标签的直方图数据
+type histogram struct {
+	labels []string
+	counts []uint64 // 各分桶的计数（非累计）
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	values map[string]*histogram
+}
+
+// NewHistogramVec 创建直方图并注册到默认注册表，buckets为空时使用DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		values:     make(map[string]*histogram),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, labels ...string) {
+	key := labelKey(labels)
+	idx := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	d, ok := h.values[key]
+	if !ok {
+		d = &histogram{
+			labels: append([]string(nil), labels...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = d
+	}
+	if idx < len(h.buckets) {
+		d.counts[idx]++
+	}
+	d.count++
+	d.sum += v
+}
+
+// Count 获取观测次数
+func (h *HistogramVec) Count(labels ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if d, ok := h.values[labelKey(labels)]; ok {
+		return d.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) collect() []Family {
+	bucketLabels := append(append([]string(nil), h.labelNames...), "le")
+
+	h.mu.Lock()
+	var buckets, sums, counts []Sample
+	for _, d := range h.values {
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += d.counts[i]
+			buckets = append(buckets, Sample{Labels: append(append([]string(nil), d.labels...), formatFloat(upper)), Value: float64(cumulative)})
+		}
+		buckets = append(buckets, Sample{Labels: append(append([]string(nil), d.labels...), "+Inf"), Value: float64(d.count)})
+		sums = append(sums, Sample{Labels: d.labels, Value: d.sum})
+		counts = append(counts, Sample{Labels: d.labels, Value: float64(d.count)})
+	}
+	h.mu.Unlock()
+
+	// 分桶按标签分组输出，组内保持le升序
+	sort.SliceStable(buckets, func(i, j int) bool {
+		return labelKey(buckets[i].Labels[:len(h.labelNames)]) < labelKey(buckets[j].Labels[:len(h.labelNames)])
+	})
+	sortSamples(sums)
+	sortSamples(counts)
+
+	return []Family{{
+		Name:       h.name,
+		Help:       h.help,
+		Type:       TypeHistogram,
+		LabelNames: bucketLabels,
+		Samples:    buckets,
+	}, {
+		Name:       h.name + "_sum",
+		LabelNames: h.labelNames,
+		Samples:    sums,
+	}, {
+		Name:       h.name + "_count",
+		LabelNames: h.labelNames,
+		Samples:    counts,
+	}}
+}
+
+// ============================================================
+// 文本格式输出
+// ============================================================
+
+// writeFamily 输出一组指标，Help和Type为空时不输出注释行（用于直方图的_sum/_count）
+func writeFamily(w *bufio.Writer, f Family) {
+	if f.Type == "" && len(f.Samples) == 0 {
+		return
+	}
+	sampleName := f.Name
+	if f.Type == TypeHistogram {
+		sampleName = f.Name + "_bucket"
+	}
+	if f.Help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+	}
+	if f.Type != "" {
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, f.Type)
+	}
+	for _, s := range f.Samples {
+		w.WriteString(sampleName)
+		if len(f.LabelNames) > 0 {
+			w.WriteByte('{')
+			for i, name := range f.LabelNames {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				value := ""
+				if i < len(s.Labels) {
+					value = s.Labels[i]
+				}
+				fmt.Fprintf(w, `%s="%s"`, name, escapeLabel(value))
+			}
+			w.WriteByte('}')
+		}
+		w.WriteByte(' ')
+		w.WriteString(formatFloat(s.Value))
+		w.WriteByte('\n')
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// labelKey 标签值拼接为map键
+func labelKey(labels []string) string {
+	return strings.Join(labels, "\xff")
+}
+
+func sortSamples(samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool { return labelKey(samples[i].Labels) < labelKey(samples[j].Labels) })
+}
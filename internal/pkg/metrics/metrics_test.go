@@ -0,0 +1,55 @@
+﻿package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	requests := NewCounterVec("test_requests_total", "请求次数", "plugin", "result")
+	requests.Inc("a", "ok")
+	requests.Add(2, "a", "ok")
+	requests.Inc(`b"\`, "error")
+	requests.Add(-1, "a", "ok")
+	if got := requests.Value("a", "ok"); got != 3 {
+		t.Errorf("Value() = %v, want 3", got)
+	}
+
+	latency := NewHistogramVec("test_latency_seconds", "耗时", []float64{1, 0.1}, "stage")
+	latency.Observe(0.05, "local")
+	latency.Observe(0.5, "local")
+	latency.Observe(3, "local")
+
+	RegisterFunc(func() []Family {
+		return []Family{{Name: "test_workers", Help: "工作槽\n总数", Type: TypeGauge, Samples: []Sample{{Value: 4}}}}
+	})
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+
+	want := strings.Join([]string{
+		"# HELP test_requests_total 请求次数",
+		"# TYPE test_requests_total counter",
+		`test_requests_total{plugin="a",result="ok"} 3`,
+		`test_requests_total{plugin="b\"\\",result="error"} 1`,
+		"# HELP test_latency_seconds 耗时",
+		"# TYPE test_latency_seconds histogram",
+		`test_latency_seconds_bucket{stage="local",le="0.1"} 1`,
+		`test_latency_seconds_bucket{stage="local",le="1"} 2`,
+		`test_latency_seconds_bucket{stage="local",le="+Inf"} 3`,
+		`test_latency_seconds_sum{stage="local"} 3.55`,
+		`test_latency_seconds_count{stage="local"} 3`,
+		`# HELP test_workers 工作槽\n总数`,
+		"# TYPE test_workers gauge",
+		"test_workers 4",
+	}, "\n") + "\n"
+	if !strings.Contains(body, want) {
+		t.Errorf("输出不符合预期:\n%s\nwant:\n%s", body, want)
+	}
+}
@@ -0,0 +1,135 @@
+﻿// Package ratelimit 基于GCRA（通用信元速率算法）的限流器，支持Redis分布式限流和进程内限流
+//
+// GCRA与令牌桶等价：以 Rate 的速度恢复配额，最多累积 Burst 个，
+// 但每个键只需保存一个“理论到达时间”，便于在Redis中用单条脚本原子更新
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit 限流规则
+type Limit struct {
+	Rate  float64 // 每秒恢复的请求数，<=0 表示不限制
+	Burst int     // 突发容量（可连续发出的最大请求数），<=0 时按1处理
+}
+
+// Unlimited 规则是否不限制
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// PerMinute 每分钟n次、允许一次性用完的规则
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// interval 每个请求占用的时间（发射间隔）
+func (l Limit) interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.Rate)
+}
+
+// tolerance 允许提前的最长时间（突发容量对应的时间）
+func (l Limit) tolerance() time.Duration {
+	burst := l.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return time.Duration(burst) * l.interval()
+}
+
+// Result 限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 突发容量
+	Remaining  int           // 剩余可连续发出的请求数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 配额完全恢复所需时间
+}
+
+// Limiter 限流器接口
+type Limiter interface {
+	// Allow 按规则消耗键的一次配额
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// gcra 根据理论到达时间tat计算限流结果，返回新的tat（被拒绝时为原值）
+func gcra(now, tat time.Time, limit Limit) (Result, time.Time) {
+	interval := limit.interval()
+	tolerance := limit.tolerance()
+	burst := int(tolerance / interval)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return Result{
+			Allowed:    false,
+			Limit:      burst,
+			Remaining:  0,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}, tat
+	}
+
+	resetAfter := newTAT.Sub(now)
+	return Result{
+		Allowed:    true,
+		Limit:      burst,
+		Remaining:  int(math.Floor(float64(tolerance-resetAfter) / float64(interval))),
+		ResetAfter: resetAfter,
+	}, newTAT
+}
+
+// LocalLimiter 进程内限流器（多实例部署时各实例分别计数）
+type LocalLimiter struct {
+	// Now 时钟，测试时可替换
+	Now func() time.Time
+
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+// sweepInterval 清理已完全恢复的键的间隔
+const sweepInterval = time.Minute
+
+// NewLocalLimiter 创建进程内限流器
+func NewLocalLimiter() *LocalLimiter {
+	return &LocalLimiter{
+		Now:  time.Now,
+		tats: make(map[string]time.Time),
+	}
+}
+
+// Allow 按规则消耗键的一次配额
+func (l *LocalLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	now := l.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, tat := range l.tats {
+			if !tat.After(now) {
+				delete(l.tats, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	result, tat := gcra(now, l.tats[key], limit)
+	if result.Allowed {
+		l.tats[key] = tat
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+﻿package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestLocalLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	l := NewLocalLimiter()
+	l.Now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		r, _ := l.Allow(ctx, "a", limit)
+		if !r.Allowed {
+			t.Fatalf("第%d次请求被限流", i+1)
+		}
+		if r.Limit != 3 || r.Remaining != 2-i {
+			t.Errorf("第%d次 Limit/Remaining = %d/%d, want 3/%d", i+1, r.Limit, r.Remaining, 2-i)
+		}
+	}
+	r, _ := l.Allow(ctx, "a", limit)
+	if r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("超出突发容量 = %+v, want 拒绝且RetryAfter=1s", r)
+	}
+	if r, _ := l.Allow(ctx, "b", limit); !r.Allowed {
+		t.Error("不同的键共享了配额")
+	}
+
+	// 按速率恢复，拒绝的请求不占用配额
+	now = now.Add(time.Second)
+	if r, _ := l.Allow(ctx, "a", limit); !r.Allowed {
+		t.Error("恢复1秒后仍被限流")
+	}
+	if r, _ := l.Allow(ctx, "a", limit); r.Allowed {
+		t.Error("只恢复1次配额却放行了2次")
+	}
+
+	if r, _ := l.Allow(ctx, "a", Limit{}); !r.Allowed {
+		t.Error("不限制的规则被限流")
+	}
+}
+
+// errLimiter 始终出错的限流器
+type errLimiter struct{ calls int }
+
+func (l *errLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.calls++
+	return Result{}, errors.New("redis down")
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	primary := &errLimiter{}
+	local := NewLocalLimiter()
+	local.Now = func() time.Time { return now }
+	l := NewFallbackLimiter(primary, local)
+	l.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if r, err := l.Allow(ctx, "a", limit); err != nil || !r.Allowed {
+		t.Fatalf("主限流器出错时 = %+v, %v, want 备用限流器放行", r, err)
+	}
+	if r, _ := l.Allow(ctx, "a", limit); r.Allowed {
+		t.Error("备用限流器未生效")
+	}
+	if primary.calls != 1 {
+		t.Errorf("冷却期内仍调用主限流器 %d 次", primary.calls)
+	}
+
+	now = now.Add(fallbackCooldown + time.Second)
+	_, _ = l.Allow(ctx, "a", limit)
+	if primary.calls != 2 {
+		t.Errorf("冷却结束后未重试主限流器，调用次数 = %d", primary.calls)
+	}
+}
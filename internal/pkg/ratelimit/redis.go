@@ -0,0 +1,130 @@
+﻿package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"huoxing-search/internal/pkg/logger"
+	redisclient "huoxing-search/internal/pkg/redis"
+)
+
+// keyPrefix Redis中限流键的前缀
+const keyPrefix = "ratelimit:"
+
+// gcraScript 在Redis中原子地执行GCRA，时间统一取Redis服务器时间（微秒），避免多实例时钟偏差
+// 返回 {是否放行, 剩余次数, 重试等待微秒, 完全恢复微秒}
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+  return {0, 0, allow_at - now, tat - now}
+end
+local ttl = math.ceil((new_tat - now) / 1000)
+if ttl < 1 then
+  ttl = 1
+end
+redis.call('SET', KEYS[1], new_tat, 'PX', ttl)
+return {1, math.floor((tolerance - (new_tat - now)) / interval), 0, new_tat - now}
+`)
+
+// RedisLimiter 基于Redis的分布式限流器，多实例共享配额，重启后不重置
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter 创建Redis限流器
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// Allow 按规则消耗键的一次配额
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	interval := limit.interval()
+	tolerance := limit.tolerance()
+
+	values, err := gcraScript.Run(ctx, l.client, []string{keyPrefix + key}, interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("限流脚本返回值异常: %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      int(tolerance / interval),
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// fallbackCooldown Redis出错后改用进程内限流的时长，避免每个请求都等待Redis超时
+const fallbackCooldown = 30 * time.Second
+
+// FallbackLimiter 优先使用主限流器，出错时在一段时间内退回到备用限流器
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	now      func() time.Time
+
+	mu          sync.Mutex
+	bypassUntil time.Time
+}
+
+// NewFallbackLimiter 创建带降级的限流器
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+		now:      time.Now,
+	}
+}
+
+// Allow 按规则消耗键的一次配额
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	bypass := l.now().Before(l.bypassUntil)
+	l.mu.Unlock()
+
+	if !bypass {
+		result, err := l.primary.Allow(ctx, key, limit)
+		if err == nil {
+			return result, nil
+		}
+
+		l.mu.Lock()
+		l.bypassUntil = l.now().Add(fallbackCooldown)
+		l.mu.Unlock()
+		logger.Warn("⚠️ 分布式限流不可用，暂时改用进程内限流",
+			zap.Error(err),
+			zap.Duration("cooldown", fallbackCooldown),
+		)
+	}
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+// New 创建默认限流器：Redis可用时使用分布式限流（出错时降级为进程内限流），否则只使用进程内限流
+func New() Limiter {
+	local := NewLocalLimiter()
+	if !redisclient.IsAvailable() {
+		return local
+	}
+	return NewFallbackLimiter(NewRedisLimiter(redisclient.GetClient()), local)
+}
@@ -11,6 +11,9 @@ import (
 
 var Client *redis.Client
 
+// available 启动时Redis是否连接成功
+var available bool
+
 // InitRedis 初始化Redis连接
 func InitRedis(cfg *config.RedisConfig) error {
 	Client = redis.NewClient(&redis.Options{
@@ -29,12 +32,19 @@ func InitRedis(cfg *config.RedisConfig) error {
 	defer cancel()
 
 	if err := Client.Ping(ctx).Err(); err != nil {
+		available = false
 		return fmt.Errorf("连接Redis失败: %w", err)
 	}
 
+	available = true
 	return nil
 }
 
+// IsAvailable Redis是否可用（启动时连接失败则为false）
+func IsAvailable() bool {
+	return Client != nil && available
+}
+
 // GetClient 获取Redis客户端
 func GetClient() *redis.Client {
 	return Client
@@ -53,6 +63,11 @@ func Set(ctx context.Context, key string, value interface{}, expiration time.Dur
 	return Client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX 键不存在时设置键值，返回是否设置成功
+func SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return Client.SetNX(ctx, key, value, expiration).Result()
+}
+
 // Get 获取值
 func Get(ctx context.Context, key string) (string, error) {
 	return Client.Get(ctx, key).Result()
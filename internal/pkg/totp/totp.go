@@ -0,0 +1,124 @@
+﻿package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 基于时间的一次性密码（RFC 6238，HMAC-SHA1、6位数字、30秒步长），兼容 Google Authenticator 等验证器
+const (
+	// Digits 验证码位数
+	Digits = 6
+	// Period 时间步长（秒）
+	Period = 30
+	// Skew 允许的前后时间步偏差，用于容忍客户端时钟误差
+	Skew = 1
+	// secretSize 密钥字节数（160位，RFC 4226 推荐长度）
+	secretSize = 20
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret 生成随机密钥（Base32编码，不含填充）
+func GenerateSecret() (string, error) {
+	buf := make([]byte, secretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成TOTP密钥失败: %w", err)
+	}
+	return base32NoPadding.EncodeToString(buf), nil
+}
+
+// ProvisioningURI 生成验证器扫码使用的 otpauth:// 地址
+func ProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(Period))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Step 返回时间所在的时间步
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// GenerateCode 计算指定时间步的验证码
+func GenerateCode(secret string, step int64) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(step)), nil
+}
+
+// Validate 校验验证码，允许前后 Skew 个时间步的偏差
+// 校验通过时返回命中的时间步，调用方可据此拒绝同一验证码的重复使用
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := Step(t)
+	for offset := int64(-Skew); offset <= Skew; offset++ {
+		step := current + offset
+		if hmac.Equal([]byte(hotp(key, uint64(step))), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// decodeSecret 解码Base32密钥（忽略大小写、空格和填充）
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("TOTP密钥格式错误: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("TOTP密钥为空")
+	}
+	return key, nil
+}
+
+// hotp 计算HOTP值（RFC 4226 动态截断）
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
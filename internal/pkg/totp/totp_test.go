@@ -0,0 +1,80 @@
+﻿package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的SHA1测试向量（密钥为ASCII "12345678901234567890"，取后6位）
+func TestGenerateCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := GenerateCode(secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := GenerateCode(secret, Step(now))
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		want   bool
+	}{
+		{"当前时间步", secret, code, now, true},
+		{"下一个时间步仍有效", secret, code, now.Add(Period * time.Second), true},
+		{"超出允许偏差", secret, code, now.Add(2 * Period * time.Second), false},
+		{"位数错误", secret, code[:5], now, false},
+		{"小写密钥", strings.ToLower(secret), code, now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, tt.at)
+			if ok != tt.want {
+				t.Errorf("Validate() = %v, want %v", ok, tt.want)
+			}
+			if ok && step != Step(now) {
+				t.Errorf("Validate() step = %d, want %d", step, Step(now))
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	got := ProvisioningURI("火星搜索", "admin", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(got, "otpauth://totp/%E7%81%AB%E6%98%9F%E6%90%9C%E7%B4%A2:admin?") {
+		t.Errorf("ProvisioningURI() = %s", got)
+	}
+	if !strings.Contains(got, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(got, "digits=6") {
+		t.Errorf("ProvisioningURI() = %s, 缺少secret/digits参数", got)
+	}
+}
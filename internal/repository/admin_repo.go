@@ -19,6 +19,8 @@ type AdminRepository interface {
 	UpdatePassword(ctx context.Context, id uint, password string) error
 	UpdateStatus(ctx context.Context, id uint, status int) error
 	UpdateLoginInfo(ctx context.Context, id uint, ip string) error
+	// CountActiveSuperAdmins 统计启用状态的超级管理员数量
+	CountActiveSuperAdmins(ctx context.Context) (int64, error)
 }
 
 type adminRepository struct {
@@ -108,6 +110,15 @@ func (r *adminRepository) UpdateStatus(ctx context.Context, id uint, status int)
 		Update("status", status).Error
 }
 
+// CountActiveSuperAdmins 统计启用状态的超级管理员数量
+func (r *adminRepository) CountActiveSuperAdmins(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Admin{}).
+		Where("role = ? AND status = ?", model.RoleSuperAdmin, 1).
+		Count(&count).Error
+	return count, err
+}
+
 // UpdateLoginInfo 更新登录信息
 func (r *adminRepository) UpdateLoginInfo(ctx context.Context, id uint, ip string) error {
 	return r.db.WithContext(ctx).Model(&model.Admin{}).
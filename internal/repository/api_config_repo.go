@@ -17,6 +17,8 @@ type APIConfigRepository interface {
 	Delete(ctx context.Context, id int) error
 	BatchDelete(ctx context.Context, ids []int) error
 	UpdateStatus(ctx context.Context, id int, status int) error
+	ListEnabled(ctx context.Context, panType int) ([]model.APIConfig, error)
+	IncrementCount(ctx context.Context, id int, delta int) error
 }
 
 type apiConfigRepository struct {
@@ -86,4 +88,21 @@ func (r *apiConfigRepository) UpdateStatus(ctx context.Context, id int, status i
 	return r.db.WithContext(ctx).Model(&model.APIConfig{}).
 		Where("id = ?", id).
 		Update("status", status).Error
+}
+
+// ListEnabled 获取指定网盘类型下已启用的搜索接口（按权重降序）
+func (r *apiConfigRepository) ListEnabled(ctx context.Context, panType int) ([]model.APIConfig, error) {
+	var configs []model.APIConfig
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND pantype = ? AND type IN ?", 1, panType, []string{"api", "html"}).
+		Order("weight DESC, id ASC").
+		Find(&configs).Error
+	return configs, err
+}
+
+// IncrementCount 增加接口命中次数
+func (r *apiConfigRepository) IncrementCount(ctx context.Context, id int, delta int) error {
+	return r.db.WithContext(ctx).Model(&model.APIConfig{}).
+		Where("id = ?", id).
+		UpdateColumn("count", gorm.Expr("count + ?", delta)).Error
 }
\ No newline at end of file
@@ -0,0 +1,160 @@
+﻿package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// APIKeyRepository 开放接口密钥仓储接口
+type APIKeyRepository interface {
+	List(ctx context.Context, page, pageSize int, keyword string) ([]*model.APIKey, int64, error)
+	GetByID(ctx context.Context, id int) (*model.APIKey, error)
+	// GetByHash 根据密钥哈希获取，不存在时返回nil
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	Create(ctx context.Context, key *model.APIKey) error
+	Update(ctx context.Context, key *model.APIKey) error
+	// UpdateHash 重置密钥
+	UpdateHash(ctx context.Context, id int, keyPrefix, keyHash string) error
+	Delete(ctx context.Context, id int) error
+	// AddUsage 累加指定日期的用量及累计计数
+	AddUsage(ctx context.Context, id int, date string, requests, transfers int64, lastUsed int64) error
+	// GetUsage 获取指定日期的用量，无记录时返回零值
+	GetUsage(ctx context.Context, id int, date string) (*model.APIKeyUsage, error)
+	// ListUsage 获取from（含）之后的每日用量，按日期升序
+	ListUsage(ctx context.Context, id int, from string) ([]*model.APIKeyUsage, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建开放接口密钥仓储
+func NewAPIKeyRepository() APIKeyRepository {
+	return &apiKeyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// List 获取密钥列表
+func (r *apiKeyRepository) List(ctx context.Context, page, pageSize int, keyword string) ([]*model.APIKey, int64, error) {
+	var keys []*model.APIKey
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.APIKey{})
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("name LIKE ? OR key_prefix LIKE ? OR remark LIKE ?", like, like, like)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&keys).Error
+	return keys, total, err
+}
+
+// GetByID 根据ID获取密钥
+func (r *apiKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash 根据密钥哈希获取
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Create 创建密钥
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// Update 更新密钥配置（不修改密钥本身和用量计数）
+func (r *apiKeyRepository) Update(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Model(key).
+		Select("name", "rate_limit", "daily_transfer_quota", "allow_transfer", "status", "expire_time", "remark", "update_time").
+		Updates(key).Error
+}
+
+// UpdateHash 重置密钥
+func (r *apiKeyRepository) UpdateHash(ctx context.Context, id int, keyPrefix, keyHash string) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"key_prefix": keyPrefix,
+			"key_hash":   keyHash,
+		}).Error
+}
+
+// Delete 删除密钥及其用量记录
+func (r *apiKeyRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key_id = ?", id).Delete(&model.APIKeyUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.APIKey{}).Error
+	})
+}
+
+// AddUsage 累加用量（每日用量按 key_id+date 唯一键合并）
+func (r *apiKeyRepository) AddUsage(ctx context.Context, id int, date string, requests, transfers int64, lastUsed int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		usage := &model.APIKeyUsage{KeyID: id, Date: date, Requests: requests, Transfers: transfers}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests":  gorm.Expr("requests + ?", requests),
+				"transfers": gorm.Expr("transfers + ?", transfers),
+			}),
+		}).Create(usage).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.APIKey{}).
+			Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{
+				"total_requests":  gorm.Expr("total_requests + ?", requests),
+				"total_transfers": gorm.Expr("total_transfers + ?", transfers),
+				"last_used_time":  lastUsed,
+			}).Error
+	})
+}
+
+// GetUsage 获取指定日期的用量
+func (r *apiKeyRepository) GetUsage(ctx context.Context, id int, date string) (*model.APIKeyUsage, error) {
+	usage := &model.APIKeyUsage{KeyID: id, Date: date}
+	err := r.db.WithContext(ctx).Where("key_id = ? AND date = ?", id, date).First(usage).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// ListUsage 获取每日用量
+func (r *apiKeyRepository) ListUsage(ctx context.Context, id int, from string) ([]*model.APIKeyUsage, error) {
+	var list []*model.APIKeyUsage
+	err := r.db.WithContext(ctx).
+		Where("key_id = ? AND date >= ?", id, from).
+		Order("date ASC").
+		Find(&list).Error
+	return list, err
+}
@@ -3,58 +3,239 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"huoxing-search/internal/pkg/redis"
 )
 
+// errCacheMiss 内存缓存未命中
+var errCacheMiss = errors.New("cache miss")
+
 // CacheRepository 缓存仓储接口
 type CacheRepository interface {
 	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, bool)
 	Delete(ctx context.Context, keys ...string) error
 	DeletePattern(ctx context.Context, pattern string) error
 	Exists(ctx context.Context, key string) (bool, error)
 	SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	GetJSON(ctx context.Context, key string, dest interface{}) error
-	SetSearchResult(ctx context.Context, keyword string, panType int, results interface{}, ttl time.Duration) error
-	GetSearchResult(ctx context.Context, keyword string, panType int, dest interface{}) (bool, error)
+	SetSearchResult(ctx context.Context, keyword string, panType int, mode string, results interface{}, ttl time.Duration) error
+	GetSearchResult(ctx context.Context, keyword string, panType int, mode string, dest interface{}) (bool, error)
+	Backend() string
 }
 
+// Redis启动时连接失败则退化为进程内存缓存，所有仓储实例共享同一份数据
 type cacheRepository struct{}
 
+// memoryCacheItem 内存缓存项
+type memoryCacheItem struct {
+	value  []byte
+	expiry time.Time // 零值表示永不过期
+}
+
+// memoryCacheStore 进程内存缓存
+type memoryCacheStore struct {
+	mu    sync.RWMutex
+	items map[string]memoryCacheItem
+}
+
+var (
+	memoryStore     *memoryCacheStore
+	memoryStoreOnce sync.Once
+)
+
+// getMemoryStore 获取进程内存缓存（首次使用时启动过期清理）
+func getMemoryStore() *memoryCacheStore {
+	memoryStoreOnce.Do(func() {
+		memoryStore = &memoryCacheStore{items: make(map[string]memoryCacheItem)}
+		go memoryStore.cleanupLoop()
+	})
+	return memoryStore
+}
+
+func (m *memoryCacheStore) set(key string, value []byte, expiration time.Duration) {
+	item := memoryCacheItem{value: value}
+	if expiration > 0 {
+		item.expiry = time.Now().Add(expiration)
+	}
+	m.mu.Lock()
+	m.items[key] = item
+	m.mu.Unlock()
+}
+
+// setNX 键不存在或已过期时写入，返回是否写入成功
+func (m *memoryCacheStore) setNX(key string, value []byte, expiration time.Duration) bool {
+	now := time.Now()
+	item := memoryCacheItem{value: value}
+	if expiration > 0 {
+		item.expiry = now.Add(expiration)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.items[key]; ok && (old.expiry.IsZero() || !now.After(old.expiry)) {
+		return false
+	}
+	m.items[key] = item
+	return true
+}
+
+func (m *memoryCacheStore) get(key string) ([]byte, bool) {
+	m.mu.RLock()
+	item, ok := m.items[key]
+	m.mu.RUnlock()
+	if !ok || (!item.expiry.IsZero() && time.Now().After(item.expiry)) {
+		return nil, false
+	}
+	return item.value, true
+}
+
+func (m *memoryCacheStore) del(keys ...string) {
+	m.mu.Lock()
+	for _, key := range keys {
+		delete(m.items, key)
+	}
+	m.mu.Unlock()
+}
+
+// delPattern 按Redis风格的通配符删除
+func (m *memoryCacheStore) delPattern(pattern string) {
+	m.mu.Lock()
+	for key := range m.items {
+		if matchCachePattern(pattern, key) {
+			delete(m.items, key)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// matchCachePattern 通配符匹配，支持 * ? 及反斜杠转义（* 可匹配任意字符，包括 / ）
+func matchCachePattern(pattern, key string) bool {
+	p, k := []rune(pattern), []rune(key)
+	pi, ki := 0, 0
+	starP, starK := -1, 0
+	for ki < len(k) {
+		if pi < len(p) {
+			switch {
+			case p[pi] == '*':
+				starP, starK = pi, ki
+				pi++
+				continue
+			case p[pi] == '?':
+				pi++
+				ki++
+				continue
+			case p[pi] == '\\' && pi+1 < len(p):
+				if p[pi+1] == k[ki] {
+					pi += 2
+					ki++
+					continue
+				}
+			case p[pi] == k[ki]:
+				pi++
+				ki++
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starK++
+		pi, ki = starP+1, starK
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func (m *memoryCacheStore) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		m.mu.Lock()
+		for key, item := range m.items {
+			if !item.expiry.IsZero() && now.After(item.expiry) {
+				delete(m.items, key)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
 // NewCacheRepository 创建缓存仓储
 func NewCacheRepository() CacheRepository {
 	return &cacheRepository{}
 }
 
+// Backend 当前使用的缓存后端
+func (r *cacheRepository) Backend() string {
+	if redis.IsAvailable() {
+		return "redis"
+	}
+	return "memory"
+}
+
 // Set 设置缓存(字符串)
 func (r *cacheRepository) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
-	return redis.Set(ctx, key, []byte(value), expiration)
+	return r.setBytes(ctx, key, []byte(value), expiration)
+}
+
+// SetNX 键不存在时设置缓存(字符串)
+func (r *cacheRepository) SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error) {
+	if !redis.IsAvailable() {
+		return getMemoryStore().setNX(key, []byte(value), expiration), nil
+	}
+	return redis.SetNX(ctx, key, value, expiration)
 }
 
 // Get 获取缓存(字符串)
 func (r *cacheRepository) Get(ctx context.Context, key string) (string, bool) {
-	data, err := redis.Get(ctx, key)
+	data, err := r.getString(ctx, key)
 	if err != nil {
 		return "", false
 	}
 	return data, true
 }
 
+// setBytes 写入Redis或内存缓存
+func (r *cacheRepository) setBytes(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if !redis.IsAvailable() {
+		getMemoryStore().set(key, value, expiration)
+		return nil
+	}
+	return redis.Set(ctx, key, value, expiration)
+}
+
+// getString 读取Redis或内存缓存
+func (r *cacheRepository) getString(ctx context.Context, key string) (string, error) {
+	if !redis.IsAvailable() {
+		data, ok := getMemoryStore().get(key)
+		if !ok {
+			return "", errCacheMiss
+		}
+		return string(data), nil
+	}
+	return redis.Get(ctx, key)
+}
+
 // SetJSON 设置缓存(JSON对象)
 func (r *cacheRepository) SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("序列化失败: %w", err)
 	}
-	return redis.Set(ctx, key, data, expiration)
+	return r.setBytes(ctx, key, data, expiration)
 }
 
 // GetJSON 获取缓存(JSON对象)
 func (r *cacheRepository) GetJSON(ctx context.Context, key string, dest interface{}) error {
-	data, err := redis.Get(ctx, key)
+	data, err := r.getString(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -63,11 +244,20 @@ func (r *cacheRepository) GetJSON(ctx context.Context, key string, dest interfac
 
 // Delete 删除缓存
 func (r *cacheRepository) Delete(ctx context.Context, keys ...string) error {
+	if !redis.IsAvailable() {
+		getMemoryStore().del(keys...)
+		return nil
+	}
 	return redis.Del(ctx, keys...)
 }
 
 // DeletePattern 删除匹配模式的所有缓存
 func (r *cacheRepository) DeletePattern(ctx context.Context, pattern string) error {
+	if !redis.IsAvailable() {
+		getMemoryStore().delPattern(pattern)
+		return nil
+	}
+
 	// 使用SCAN命令查找匹配的key
 	keys, err := redis.Keys(ctx, pattern)
 	if err != nil {
@@ -81,6 +271,10 @@ func (r *cacheRepository) DeletePattern(ctx context.Context, pattern string) err
 
 // Exists 检查缓存是否存在
 func (r *cacheRepository) Exists(ctx context.Context, key string) (bool, error) {
+	if !redis.IsAvailable() {
+		_, ok := getMemoryStore().get(key)
+		return ok, nil
+	}
 	count, err := redis.Exists(ctx, key)
 	if err != nil {
 		return false, err
@@ -89,14 +283,14 @@ func (r *cacheRepository) Exists(ctx context.Context, key string) (bool, error)
 }
 
 // SetSearchResult 设置搜索结果缓存
-func (r *cacheRepository) SetSearchResult(ctx context.Context, keyword string, panType int, results interface{}, ttl time.Duration) error {
-	key := r.buildSearchKey(keyword, panType)
+func (r *cacheRepository) SetSearchResult(ctx context.Context, keyword string, panType int, mode string, results interface{}, ttl time.Duration) error {
+	key := BuildSearchCacheKey(keyword, panType, mode)
 	return r.SetJSON(ctx, key, results, ttl)
 }
 
 // GetSearchResult 获取搜索结果缓存
-func (r *cacheRepository) GetSearchResult(ctx context.Context, keyword string, panType int, dest interface{}) (bool, error) {
-	key := r.buildSearchKey(keyword, panType)
+func (r *cacheRepository) GetSearchResult(ctx context.Context, keyword string, panType int, mode string, dest interface{}) (bool, error) {
+	key := BuildSearchCacheKey(keyword, panType, mode)
 	exists, err := r.Exists(ctx, key)
 	if err != nil || !exists {
 		return false, err
@@ -108,7 +302,7 @@ func (r *cacheRepository) GetSearchResult(ctx context.Context, keyword string, p
 	return true, nil
 }
 
-// buildSearchKey 构建搜索缓存key
-func (r *cacheRepository) buildSearchKey(keyword string, panType int) string {
-	return fmt.Sprintf("search:%s:%d", keyword, panType)
+// BuildSearchCacheKey 构建搜索缓存key（关键词需由调用方归一化）
+func BuildSearchCacheKey(keyword string, panType int, mode string) string {
+	return fmt.Sprintf("search:%s:%d:%s", keyword, panType, mode)
 }
\ No newline at end of file
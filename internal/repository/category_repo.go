@@ -16,6 +16,7 @@ type CategoryRepository interface {
 	GetByID(ctx context.Context, id int) (*model.Category, error)
 	List(ctx context.Context, page, pageSize int, isType int) ([]*model.Category, int64, error)
 	BatchDelete(ctx context.Context, ids []int) error
+	ListEnabled(ctx context.Context) ([]*model.Category, error)
 }
 
 type categoryRepository struct {
@@ -84,4 +85,14 @@ func (r *categoryRepository) List(ctx context.Context, page, pageSize int, isTyp
 // BatchDelete 批量删除分类
 func (r *categoryRepository) BatchDelete(ctx context.Context, ids []int) error {
 	return r.db.WithContext(ctx).Where("category_id IN ?", ids).Delete(&model.Category{}).Error
-}
\ No newline at end of file
+}
+
+// ListEnabled 获取所有启用的分类（按排序值升序，用于自动分类规则匹配）
+func (r *categoryRepository) ListEnabled(ctx context.Context) ([]*model.Category, error) {
+	var categories []*model.Category
+	err := r.db.WithContext(ctx).
+		Where("status = ?", 1).
+		Order("sort ASC, category_id ASC").
+		Find(&categories).Error
+	return categories, err
+}
@@ -0,0 +1,89 @@
+﻿package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// LogRepository 操作日志仓储接口
+type LogRepository interface {
+	Create(ctx context.Context, log *model.OperationLog) error
+	List(ctx context.Context, filter model.OperationLogFilter, page, pageSize int) ([]*model.OperationLog, int64, error)
+	ListAll(ctx context.Context, filter model.OperationLogFilter, limit int) ([]*model.OperationLog, error)
+	ListModules(ctx context.Context) ([]string, error)
+}
+
+type logRepository struct {
+	db *gorm.DB
+}
+
+// NewLogRepository 创建操作日志仓储
+func NewLogRepository() LogRepository {
+	return &logRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 写入操作日志
+func (r *logRepository) Create(ctx context.Context, log *model.OperationLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// List 分页获取操作日志
+func (r *logRepository) List(ctx context.Context, filter model.OperationLogFilter, page, pageSize int) ([]*model.OperationLog, int64, error) {
+	var logs []*model.OperationLog
+	var total int64
+
+	query := r.applyFilter(r.db.WithContext(ctx).Model(&model.OperationLog{}), filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("log_id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error
+	return logs, total, err
+}
+
+// ListAll 获取符合条件的操作日志（用于导出，最多limit条）
+func (r *logRepository) ListAll(ctx context.Context, filter model.OperationLogFilter, limit int) ([]*model.OperationLog, error) {
+	var logs []*model.OperationLog
+	err := r.applyFilter(r.db.WithContext(ctx).Model(&model.OperationLog{}), filter).
+		Order("log_id DESC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+// ListModules 获取日志中出现过的模块（用于筛选下拉框）
+func (r *logRepository) ListModules(ctx context.Context) ([]string, error) {
+	var modules []string
+	err := r.db.WithContext(ctx).Model(&model.OperationLog{}).
+		Where("module <> ''").
+		Distinct("module").
+		Order("module ASC").
+		Pluck("module", &modules).Error
+	return modules, err
+}
+
+// applyFilter 应用查询条件
+func (r *logRepository) applyFilter(query *gorm.DB, filter model.OperationLogFilter) *gorm.DB {
+	if filter.AdminID > 0 {
+		query = query.Where("admin_id = ?", filter.AdminID)
+	}
+	if filter.Module != "" {
+		query = query.Where("module = ?", filter.Module)
+	}
+	if filter.Status >= 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StartTime > 0 {
+		query = query.Where("create_time >= ?", filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query = query.Where("create_time <= ?", filter.EndTime)
+	}
+	return query
+}
@@ -0,0 +1,135 @@
+﻿package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// NetdiskAccountRepository 网盘账号仓储接口
+type NetdiskAccountRepository interface {
+	List(ctx context.Context, panType int) ([]*model.NetdiskAccount, error)
+	ListByStatus(ctx context.Context, panType int, statuses []int) ([]*model.NetdiskAccount, error)
+	GetByID(ctx context.Context, id int) (*model.NetdiskAccount, error)
+	Create(ctx context.Context, account *model.NetdiskAccount) error
+	Update(ctx context.Context, account *model.NetdiskAccount) error
+	Delete(ctx context.Context, id int) error
+	CountByPanType(ctx context.Context, panType int) (int64, error)
+	UpdateStatus(ctx context.Context, id int, status int, lastError string) error
+	RecordUse(ctx context.Context, id int) error
+	RecordFailure(ctx context.Context, id int, lastError string) error
+	RecordCheck(ctx context.Context, id int, fields map[string]interface{}) error
+}
+
+type netdiskAccountRepository struct {
+	db *gorm.DB
+}
+
+// NewNetdiskAccountRepository 创建网盘账号仓储
+func NewNetdiskAccountRepository() NetdiskAccountRepository {
+	return &netdiskAccountRepository{
+		db: database.GetDB(),
+	}
+}
+
+// List 获取账号列表（panType<0时返回全部）
+func (r *netdiskAccountRepository) List(ctx context.Context, panType int) ([]*model.NetdiskAccount, error) {
+	var accounts []*model.NetdiskAccount
+	query := r.db.WithContext(ctx).Model(&model.NetdiskAccount{})
+	if panType >= 0 {
+		query = query.Where("pan_type = ?", panType)
+	}
+	err := query.Order("pan_type ASC, id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// ListByStatus 获取指定状态的账号（panType<0时不过滤网盘类型）
+func (r *netdiskAccountRepository) ListByStatus(ctx context.Context, panType int, statuses []int) ([]*model.NetdiskAccount, error) {
+	var accounts []*model.NetdiskAccount
+	query := r.db.WithContext(ctx).Where("status IN ?", statuses)
+	if panType >= 0 {
+		query = query.Where("pan_type = ?", panType)
+	}
+	err := query.Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// GetByID 根据ID获取账号
+func (r *netdiskAccountRepository) GetByID(ctx context.Context, id int) (*model.NetdiskAccount, error) {
+	var account model.NetdiskAccount
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Create 创建账号
+func (r *netdiskAccountRepository) Create(ctx context.Context, account *model.NetdiskAccount) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+// Update 更新账号基本信息
+func (r *netdiskAccountRepository) Update(ctx context.Context, account *model.NetdiskAccount) error {
+	return r.db.WithContext(ctx).Model(account).
+		Select("name", "credential", "save_dir", "save_dir_time", "status", "update_time").
+		Updates(account).Error
+}
+
+// Delete 删除账号
+func (r *netdiskAccountRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.NetdiskAccount{}).Error
+}
+
+// CountByPanType 统计指定网盘类型的账号数量（不区分状态）
+func (r *netdiskAccountRepository) CountByPanType(ctx context.Context, panType int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.NetdiskAccount{}).
+		Where("pan_type = ?", panType).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateStatus 更新账号状态
+func (r *netdiskAccountRepository) UpdateStatus(ctx context.Context, id int, status int, lastError string) error {
+	return r.db.WithContext(ctx).Model(&model.NetdiskAccount{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"last_error":  lastError,
+			"update_time": time.Now().Unix(),
+		}).Error
+}
+
+// RecordUse 记录一次成功使用
+func (r *netdiskAccountRepository) RecordUse(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Model(&model.NetdiskAccount{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"fail_count":   0,
+			"last_used_at": time.Now().Unix(),
+		}).Error
+}
+
+// RecordFailure 记录一次失败
+func (r *netdiskAccountRepository) RecordFailure(ctx context.Context, id int, lastError string) error {
+	return r.db.WithContext(ctx).Model(&model.NetdiskAccount{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"fail_count":   gorm.Expr("fail_count + 1"),
+			"last_error":   lastError,
+			"last_used_at": time.Now().Unix(),
+		}).Error
+}
+
+// RecordCheck 记录健康检查结果（状态、错误信息、容量等）
+func (r *netdiskAccountRepository) RecordCheck(ctx context.Context, id int, fields map[string]interface{}) error {
+	fields["last_check_at"] = time.Now().Unix()
+	return r.db.WithContext(ctx).Model(&model.NetdiskAccount{}).
+		Where("id = ?", id).
+		UpdateColumns(fields).Error
+}
@@ -0,0 +1,45 @@
+﻿package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// PluginSettingRepository 插件运行时设置仓储接口
+type PluginSettingRepository interface {
+	List(ctx context.Context) ([]*model.PluginSetting, error)
+	// Save 按插件名插入或更新启用状态和优先级
+	Save(ctx context.Context, setting *model.PluginSetting) error
+}
+
+type pluginSettingRepository struct {
+	db *gorm.DB
+}
+
+// NewPluginSettingRepository 创建插件运行时设置仓储
+func NewPluginSettingRepository() PluginSettingRepository {
+	return &pluginSettingRepository{
+		db: database.GetDB(),
+	}
+}
+
+// List 获取全部插件设置
+func (r *pluginSettingRepository) List(ctx context.Context) ([]*model.PluginSetting, error) {
+	var settings []*model.PluginSetting
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&settings).Error
+	return settings, err
+}
+
+// Save 按插件名插入或更新
+func (r *pluginSettingRepository) Save(ctx context.Context, setting *model.PluginSetting) error {
+	setting.UpdateTime = time.Now().Unix()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "priority", "update_time"}),
+	}).Create(setting).Error
+}
@@ -0,0 +1,204 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// APIKeyRepository 内存开放接口密钥仓储
+type APIKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[int]*model.APIKey
+	usage  map[int]map[string]*model.APIKeyUsage
+	nextID int
+
+	// AddUsageErr 不为nil时AddUsage返回该错误，用于模拟写库失败
+	AddUsageErr error
+}
+
+// NewAPIKeyRepository 创建内存开放接口密钥仓储
+func NewAPIKeyRepository(keys ...*model.APIKey) *APIKeyRepository {
+	r := &APIKeyRepository{
+		keys:  make(map[int]*model.APIKey),
+		usage: make(map[int]map[string]*model.APIKeyUsage),
+	}
+	for _, key := range keys {
+		r.insert(key)
+	}
+	return r
+}
+
+// insert 写入密钥（调用方持有锁或在初始化阶段）
+func (r *APIKeyRepository) insert(key *model.APIKey) {
+	if key.ID == 0 {
+		r.nextID++
+		key.ID = r.nextID
+	} else if key.ID > r.nextID {
+		r.nextID = key.ID
+	}
+	copied := *key
+	r.keys[key.ID] = &copied
+}
+
+// List 获取密钥列表（按ID倒序）
+func (r *APIKeyRepository) List(ctx context.Context, page, pageSize int, keyword string) ([]*model.APIKey, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.APIKey
+	for _, key := range r.keys {
+		if keyword == "" || strings.Contains(key.Name, keyword) || strings.Contains(key.KeyPrefix, keyword) || strings.Contains(key.Remark, keyword) {
+			copied := *key
+			matched = append(matched, &copied)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+
+	total := int64(len(matched))
+	start := (page - 1) * pageSize
+	if start >= len(matched) {
+		return []*model.APIKey{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], total, nil
+}
+
+// GetByID 根据ID获取密钥
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// GetByHash 根据密钥哈希获取，不存在时返回nil
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// Create 创建密钥
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(key)
+	return nil
+}
+
+// Update 更新密钥配置（不修改密钥本身和用量计数）
+func (r *APIKeyRepository) Update(ctx context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[key.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Name = key.Name
+	stored.RateLimit = key.RateLimit
+	stored.DailyTransferQuota = key.DailyTransferQuota
+	stored.AllowTransfer = key.AllowTransfer
+	stored.Status = key.Status
+	stored.ExpireTime = key.ExpireTime
+	stored.Remark = key.Remark
+	return nil
+}
+
+// UpdateHash 重置密钥
+func (r *APIKeyRepository) UpdateHash(ctx context.Context, id int, keyPrefix, keyHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.KeyPrefix = keyPrefix
+	stored.KeyHash = keyHash
+	return nil
+}
+
+// Delete 删除密钥及其用量记录
+func (r *APIKeyRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, id)
+	delete(r.usage, id)
+	return nil
+}
+
+// AddUsage 累加用量
+func (r *APIKeyRepository) AddUsage(ctx context.Context, id int, date string, requests, transfers int64, lastUsed int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.AddUsageErr != nil {
+		return r.AddUsageErr
+	}
+	if r.usage[id] == nil {
+		r.usage[id] = make(map[string]*model.APIKeyUsage)
+	}
+	usage, ok := r.usage[id][date]
+	if !ok {
+		usage = &model.APIKeyUsage{KeyID: id, Date: date}
+		r.usage[id][date] = usage
+	}
+	usage.Requests += requests
+	usage.Transfers += transfers
+
+	if key, ok := r.keys[id]; ok {
+		key.TotalRequests += requests
+		key.TotalTransfers += transfers
+		key.LastUsedTime = lastUsed
+	}
+	return nil
+}
+
+// GetUsage 获取指定日期的用量，无记录时返回零值
+func (r *APIKeyRepository) GetUsage(ctx context.Context, id int, date string) (*model.APIKeyUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if usage, ok := r.usage[id][date]; ok {
+		copied := *usage
+		return &copied, nil
+	}
+	return &model.APIKeyUsage{KeyID: id, Date: date}, nil
+}
+
+// ListUsage 获取from（含）之后的每日用量，按日期升序
+func (r *APIKeyRepository) ListUsage(ctx context.Context, id int, from string) ([]*model.APIKeyUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []*model.APIKeyUsage
+	for date, usage := range r.usage[id] {
+		if date >= from {
+			copied := *usage
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Date < list[j].Date })
+	return list, nil
+}
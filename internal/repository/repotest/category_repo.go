@@ -0,0 +1,129 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// CategoryRepository 内存分类仓储
+type CategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[int]*model.Category
+	nextID     int
+}
+
+// NewCategoryRepository 创建内存分类仓储
+func NewCategoryRepository(categories ...*model.Category) *CategoryRepository {
+	r := &CategoryRepository{categories: make(map[int]*model.Category)}
+	for _, category := range categories {
+		r.insert(category)
+	}
+	return r
+}
+
+// insert 写入分类（调用方持有锁或在初始化阶段）
+func (r *CategoryRepository) insert(category *model.Category) {
+	if category.CategoryID == 0 {
+		r.nextID++
+		category.CategoryID = r.nextID
+	} else if category.CategoryID > r.nextID {
+		r.nextID = category.CategoryID
+	}
+	copied := *category
+	r.categories[category.CategoryID] = &copied
+}
+
+// Create 创建分类
+func (r *CategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(category)
+	return nil
+}
+
+// Update 更新分类
+func (r *CategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	return r.Create(ctx, category)
+}
+
+// Delete 删除分类
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
+	return r.BatchDelete(ctx, []int{id})
+}
+
+// GetByID 根据ID获取分类
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*model.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *category
+	return &copied, nil
+}
+
+// List 获取分类列表
+func (r *CategoryRepository) List(ctx context.Context, page, pageSize int, isType int) ([]*model.Category, int64, error) {
+	var matched []*model.Category
+	for _, category := range r.sorted() {
+		if isType < 0 || category.IsType == isType {
+			matched = append(matched, category)
+		}
+	}
+
+	start := (page - 1) * pageSize
+	if start < 0 || start >= len(matched) {
+		return []*model.Category{}, int64(len(matched)), nil
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], int64(len(matched)), nil
+}
+
+// BatchDelete 批量删除分类
+func (r *CategoryRepository) BatchDelete(ctx context.Context, ids []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		delete(r.categories, id)
+	}
+	return nil
+}
+
+// ListEnabled 获取所有启用的分类（按排序值升序）
+func (r *CategoryRepository) ListEnabled(ctx context.Context) ([]*model.Category, error) {
+	var enabled []*model.Category
+	for _, category := range r.sorted() {
+		if category.Status == 1 {
+			enabled = append(enabled, category)
+		}
+	}
+	return enabled, nil
+}
+
+// sorted 按排序值、ID升序返回全部分类的副本
+func (r *CategoryRepository) sorted() []*model.Category {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]*model.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		copied := *category
+		categories = append(categories, &copied)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Sort != categories[j].Sort {
+			return categories[i].Sort < categories[j].Sort
+		}
+		return categories[i].CategoryID < categories[j].CategoryID
+	})
+	return categories
+}
@@ -0,0 +1,209 @@
+﻿// Package repotest 提供仓储接口的内存实现，用于不依赖数据库的单元测试
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// ConfigRepository 内存配置仓储，未设置的配置项与数据库一样返回 gorm.ErrRecordNotFound
+type ConfigRepository struct {
+	mu      sync.RWMutex
+	configs map[string]*model.Config
+	nextID  int
+}
+
+// NewConfigRepository 创建内存配置仓储
+func NewConfigRepository(values map[string]string) *ConfigRepository {
+	r := &ConfigRepository{configs: make(map[string]*model.Config)}
+	for name, value := range values {
+		r.Set(name, value)
+	}
+	return r
+}
+
+// Set 设置配置项
+func (r *ConfigRepository) Set(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if conf, ok := r.configs[name]; ok {
+		conf.Value = value
+		return
+	}
+	r.nextID++
+	r.configs[name] = &model.Config{ConfID: r.nextID, Name: name, Value: value, Status: 1}
+}
+
+// List 获取配置列表（按ID排序）
+func (r *ConfigRepository) List(ctx context.Context, page, pageSize int) ([]model.Config, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	configs := make([]model.Config, 0, len(r.configs))
+	for _, conf := range r.configs {
+		configs = append(configs, *conf)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ConfID < configs[j].ConfID })
+
+	total := int64(len(configs))
+	start := (page - 1) * pageSize
+	if start >= len(configs) {
+		return []model.Config{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(configs) {
+		end = len(configs)
+	}
+	return configs[start:end], total, nil
+}
+
+// GetByID 根据ID获取配置
+func (r *ConfigRepository) GetByID(ctx context.Context, id int) (*model.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, conf := range r.configs {
+		if conf.ConfID == id {
+			copied := *conf
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetByName 根据名称获取配置
+func (r *ConfigRepository) GetByName(ctx context.Context, name string) (*model.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	conf, ok := r.configs[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *conf
+	return &copied, nil
+}
+
+// GetByNames 根据名称批量获取配置
+func (r *ConfigRepository) GetByNames(ctx context.Context, names []string) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]string)
+	for _, name := range names {
+		if conf, ok := r.configs[name]; ok {
+			result[name] = conf.Value
+		}
+	}
+	return result, nil
+}
+
+// Get 根据名称获取配置值
+func (r *ConfigRepository) Get(ctx context.Context, name string) (string, error) {
+	conf, err := r.GetByName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return conf.Value, nil
+}
+
+// GetInt 根据名称获取配置值(整数)
+func (r *ConfigRepository) GetInt(ctx context.Context, name string) (int, error) {
+	value, err := r.Get(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	var intValue int
+	if _, err := fmt.Sscanf(value, "%d", &intValue); err != nil {
+		return 0, fmt.Errorf("配置值 %s 不是有效的整数: %w", name, err)
+	}
+	return intValue, nil
+}
+
+// Create 创建配置
+func (r *ConfigRepository) Create(ctx context.Context, config *model.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.configs[config.Name]; ok {
+		return fmt.Errorf("配置 %s 已存在", config.Name)
+	}
+	r.nextID++
+	config.ConfID = r.nextID
+	copied := *config
+	r.configs[config.Name] = &copied
+	return nil
+}
+
+// Update 更新配置
+func (r *ConfigRepository) Update(ctx context.Context, config *model.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, conf := range r.configs {
+		if conf.ConfID == config.ConfID {
+			copied := *config
+			delete(r.configs, name)
+			r.configs[config.Name] = &copied
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// Delete 删除配置
+func (r *ConfigRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, conf := range r.configs {
+		if conf.ConfID == id {
+			delete(r.configs, name)
+		}
+	}
+	return nil
+}
+
+// BatchDelete 批量删除配置
+func (r *ConfigRepository) BatchDelete(ctx context.Context, ids []int) error {
+	for _, id := range ids {
+		if err := r.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchUpdate 批量更新配置值
+func (r *ConfigRepository) BatchUpdate(ctx context.Context, configs []model.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, config := range configs {
+		for _, conf := range r.configs {
+			if conf.ConfID == config.ConfID {
+				conf.Value = config.Value
+				conf.UpdateTime = config.UpdateTime
+			}
+		}
+	}
+	return nil
+}
+
+// BatchUpsert 批量插入或更新配置（根据name）
+func (r *ConfigRepository) BatchUpsert(ctx context.Context, configs map[string]string) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("配置列表为空")
+	}
+	for name, value := range configs {
+		r.Set(name, value)
+	}
+	return nil
+}
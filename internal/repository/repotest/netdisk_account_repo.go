@@ -0,0 +1,194 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// NetdiskAccountRepository 内存网盘账号仓储，可通过 CreateErr 模拟写入失败
+type NetdiskAccountRepository struct {
+	mu       sync.RWMutex
+	accounts map[int]*model.NetdiskAccount
+	nextID   int
+
+	// CreateErr 非nil时 Create 返回该错误
+	CreateErr error
+}
+
+// NewNetdiskAccountRepository 创建内存网盘账号仓储
+func NewNetdiskAccountRepository(accounts ...*model.NetdiskAccount) *NetdiskAccountRepository {
+	r := &NetdiskAccountRepository{accounts: make(map[int]*model.NetdiskAccount)}
+	for _, account := range accounts {
+		r.insert(account)
+	}
+	return r
+}
+
+// insert 写入账号（调用方持有锁或在初始化阶段）
+func (r *NetdiskAccountRepository) insert(account *model.NetdiskAccount) {
+	if account.ID == 0 {
+		r.nextID++
+		account.ID = r.nextID
+	} else if account.ID > r.nextID {
+		r.nextID = account.ID
+	}
+	copied := *account
+	r.accounts[account.ID] = &copied
+}
+
+// List 获取账号列表（panType<0时返回全部）
+func (r *NetdiskAccountRepository) List(ctx context.Context, panType int) ([]*model.NetdiskAccount, error) {
+	return r.filter(func(account *model.NetdiskAccount) bool {
+		return panType < 0 || account.PanType == panType
+	}), nil
+}
+
+// ListByStatus 获取指定状态的账号（panType<0时不过滤网盘类型）
+func (r *NetdiskAccountRepository) ListByStatus(ctx context.Context, panType int, statuses []int) ([]*model.NetdiskAccount, error) {
+	return r.filter(func(account *model.NetdiskAccount) bool {
+		if panType >= 0 && account.PanType != panType {
+			return false
+		}
+		for _, status := range statuses {
+			if account.Status == status {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// GetByID 根据ID获取账号
+func (r *NetdiskAccountRepository) GetByID(ctx context.Context, id int) (*model.NetdiskAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+// Create 创建账号
+func (r *NetdiskAccountRepository) Create(ctx context.Context, account *model.NetdiskAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.CreateErr != nil {
+		return r.CreateErr
+	}
+	r.insert(account)
+	return nil
+}
+
+// Update 更新账号基本信息
+func (r *NetdiskAccountRepository) Update(ctx context.Context, account *model.NetdiskAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.accounts[account.ID]
+	if !ok {
+		return nil
+	}
+	existing.Name = account.Name
+	existing.Credential = account.Credential
+	existing.SaveDir = account.SaveDir
+	existing.SaveDirTime = account.SaveDirTime
+	existing.Status = account.Status
+	existing.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// Delete 删除账号
+func (r *NetdiskAccountRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.accounts, id)
+	return nil
+}
+
+// CountByPanType 统计指定网盘类型的账号数量（不区分状态）
+func (r *NetdiskAccountRepository) CountByPanType(ctx context.Context, panType int) (int64, error) {
+	accounts, _ := r.List(ctx, panType)
+	return int64(len(accounts)), nil
+}
+
+// UpdateStatus 更新账号状态
+func (r *NetdiskAccountRepository) UpdateStatus(ctx context.Context, id int, status int, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if account, ok := r.accounts[id]; ok {
+		account.Status = status
+		account.LastError = lastError
+	}
+	return nil
+}
+
+// RecordUse 记录一次成功使用
+func (r *NetdiskAccountRepository) RecordUse(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if account, ok := r.accounts[id]; ok {
+		account.UseCount++
+		account.FailCount = 0
+		account.LastUsedAt = time.Now().Unix()
+	}
+	return nil
+}
+
+// RecordFailure 记录一次失败
+func (r *NetdiskAccountRepository) RecordFailure(ctx context.Context, id int, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if account, ok := r.accounts[id]; ok {
+		account.FailCount++
+		account.LastError = lastError
+		account.LastUsedAt = time.Now().Unix()
+	}
+	return nil
+}
+
+// RecordCheck 记录健康检查结果，只支持 AccountClient 会写入的字段
+func (r *NetdiskAccountRepository) RecordCheck(ctx context.Context, id int, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil
+	}
+	for column, value := range fields {
+		switch column {
+		case "status":
+			account.Status = value.(int)
+		case "last_error":
+			account.LastError = value.(string)
+		case "fail_count":
+			account.FailCount = value.(int)
+		case "total_space":
+			account.TotalSpace = value.(int64)
+		case "used_space":
+			account.UsedSpace = value.(int64)
+		}
+	}
+	account.LastCheckAt = time.Now().Unix()
+	return nil
+}
+
+// filter 返回满足条件的账号副本（按ID排序）
+func (r *NetdiskAccountRepository) filter(match func(*model.NetdiskAccount) bool) []*model.NetdiskAccount {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var accounts []*model.NetdiskAccount
+	for _, account := range r.accounts {
+		if match(account) {
+			copied := *account
+			accounts = append(accounts, &copied)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts
+}
@@ -0,0 +1,328 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// SourceRepository 内存资源仓储，搜索按标题包含匹配（与LIKE查询一致）
+type SourceRepository struct {
+	mu      sync.RWMutex
+	sources map[uint64]*model.Source
+	nextID  uint64
+	calls   map[string]int
+
+	// Err 非nil时所有写操作返回该错误，用于模拟数据库故障
+	Err error
+}
+
+// NewSourceRepository 创建内存资源仓储
+func NewSourceRepository(sources ...*model.Source) *SourceRepository {
+	r := &SourceRepository{
+		sources: make(map[uint64]*model.Source),
+		calls:   make(map[string]int),
+	}
+	for _, source := range sources {
+		r.insert(source)
+	}
+	return r
+}
+
+// Calls 获取方法调用次数
+func (r *SourceRepository) Calls(method string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.calls[method]
+}
+
+// All 获取全部资源（按ID排序）
+func (r *SourceRepository) All() []*model.Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := make([]*model.Source, 0, len(r.sources))
+	for _, source := range r.sources {
+		copied := *source
+		sources = append(sources, &copied)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].SourceID < sources[j].SourceID })
+	return sources
+}
+
+func (r *SourceRepository) record(method string) {
+	r.calls[method]++
+}
+
+// insert 写入资源（调用方持有锁或在初始化阶段）
+func (r *SourceRepository) insert(source *model.Source) {
+	if source.SourceID == 0 {
+		r.nextID++
+		source.SourceID = r.nextID
+	} else if source.SourceID > r.nextID {
+		r.nextID = source.SourceID
+	}
+	if source.CreateTime == 0 {
+		source.CreateTime = time.Now().Unix()
+	}
+	copied := *source
+	r.sources[source.SourceID] = &copied
+}
+
+// Create 创建资源
+func (r *SourceRepository) Create(ctx context.Context, source *model.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("Create")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	r.insert(source)
+	return nil
+}
+
+// Update 更新资源
+func (r *SourceRepository) Update(ctx context.Context, source *model.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("Update")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	// 与数据库实现一致，计数字段不随资源更新覆盖
+	if existing, ok := r.sources[source.SourceID]; ok {
+		source.ViewCount = existing.ViewCount
+		source.TransferCount = existing.TransferCount
+	}
+	r.insert(source)
+	return nil
+}
+
+// Delete 删除资源
+func (r *SourceRepository) Delete(ctx context.Context, sourceID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("Delete")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	delete(r.sources, sourceID)
+	return nil
+}
+
+// GetByID 根据ID获取资源
+func (r *SourceRepository) GetByID(ctx context.Context, sourceID uint64) (*model.Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("GetByID")
+
+	source, ok := r.sources[sourceID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *source
+	return &copied, nil
+}
+
+// GetByURL 根据URL获取资源，不存在时返回nil
+func (r *SourceRepository) GetByURL(ctx context.Context, url string) (*model.Source, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("GetByURL")
+
+	for _, source := range r.sources {
+		if source.URL == url {
+			copied := *source
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// List 获取资源列表
+func (r *SourceRepository) List(ctx context.Context, filter model.SourceFilter, page, pageSize int) ([]*model.Source, int64, error) {
+	r.mu.Lock()
+	r.record("List")
+	r.mu.Unlock()
+
+	matched := r.filter(func(source *model.Source) bool {
+		return (filter.IsType < 0 || source.IsType == filter.IsType) &&
+			(filter.Status < 0 || source.Status == filter.Status) &&
+			(filter.CategoryID < 0 || source.CategoryID == filter.CategoryID) &&
+			(filter.SourceName == "" || source.SourceName == filter.SourceName)
+	})
+	return paginate(matched, page, pageSize), int64(len(matched)), nil
+}
+
+// Search 搜索已上线资源
+func (r *SourceRepository) Search(ctx context.Context, keyword string, page, pageSize int) ([]*model.Source, int64, error) {
+	r.mu.Lock()
+	r.record("Search")
+	r.mu.Unlock()
+
+	matched := r.filter(func(source *model.Source) bool {
+		return source.Status == 1 && strings.Contains(source.Title, keyword)
+	})
+	return paginate(matched, page, pageSize), int64(len(matched)), nil
+}
+
+// SearchByKeywordAndType 按关键词和网盘类型搜索已上线资源
+func (r *SourceRepository) SearchByKeywordAndType(ctx context.Context, keyword string, panType, categoryID int, limit int) ([]*model.Source, error) {
+	r.mu.Lock()
+	r.record("SearchByKeywordAndType")
+	r.mu.Unlock()
+
+	matched := r.filter(func(source *model.Source) bool {
+		return source.Status == 1 && (panType < 0 || source.IsType == panType) &&
+			(categoryID <= 0 || source.CategoryID == categoryID) && strings.Contains(source.Title, keyword)
+	})
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+// BatchCreate 批量创建资源
+func (r *SourceRepository) BatchCreate(ctx context.Context, sources []*model.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("BatchCreate")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	for _, source := range sources {
+		r.insert(source)
+	}
+	return nil
+}
+
+// ListAfterID 按ID顺序分批获取资源
+func (r *SourceRepository) ListAfterID(ctx context.Context, lastID uint64, status int, limit int) ([]*model.Source, error) {
+	r.mu.Lock()
+	r.record("ListAfterID")
+	r.mu.Unlock()
+
+	var matched []*model.Source
+	for _, source := range r.All() {
+		if source.SourceID > lastID && (status < 0 || source.Status == status) {
+			matched = append(matched, source)
+		}
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
+
+// DeleteExpiredTemp 删除过期的临时资源
+func (r *SourceRepository) DeleteExpiredTemp(ctx context.Context, expiryTime int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("DeleteExpiredTemp")
+
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	var deleted int64
+	for id, source := range r.sources {
+		if source.IsTime == 1 && source.CreateTime < expiryTime {
+			delete(r.sources, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// IncrementViewCount 增加资源查看次数
+func (r *SourceRepository) IncrementViewCount(ctx context.Context, sourceIDs ...uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("IncrementViewCount")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	for _, id := range sourceIDs {
+		if source, ok := r.sources[id]; ok {
+			source.ViewCount++
+		}
+	}
+	return nil
+}
+
+// IncrementTransferCount 增加分享链接或原始链接匹配的资源转存次数
+func (r *SourceRepository) IncrementTransferCount(ctx context.Context, urls ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("IncrementTransferCount")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	for _, source := range r.sources {
+		for _, url := range urls {
+			if source.URL == url || source.Content == url {
+				source.TransferCount++
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateCategory 只更新资源分类
+func (r *SourceRepository) UpdateCategory(ctx context.Context, source *model.Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record("UpdateCategory")
+
+	if r.Err != nil {
+		return r.Err
+	}
+	if existing, ok := r.sources[source.SourceID]; ok {
+		existing.CategoryID = source.CategoryID
+	}
+	return nil
+}
+
+// filter 按条件筛选，结果按创建时间倒序
+func (r *SourceRepository) filter(match func(*model.Source) bool) []*model.Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.Source
+	for _, source := range r.sources {
+		if match(source) {
+			copied := *source
+			matched = append(matched, &copied)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].CreateTime != matched[j].CreateTime {
+			return matched[i].CreateTime > matched[j].CreateTime
+		}
+		return matched[i].SourceID > matched[j].SourceID
+	})
+	return matched
+}
+
+func paginate(sources []*model.Source, page, pageSize int) []*model.Source {
+	start := (page - 1) * pageSize
+	if start < 0 || start >= len(sources) {
+		return []*model.Source{}
+	}
+	end := start + pageSize
+	if end > len(sources) {
+		end = len(sources)
+	}
+	return sources[start:end]
+}
@@ -0,0 +1,115 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// TGChannelRepository 内存TG频道仓储
+type TGChannelRepository struct {
+	mu       sync.RWMutex
+	channels map[int]*model.TGChannel
+	nextID   int
+}
+
+// NewTGChannelRepository 创建内存TG频道仓储
+func NewTGChannelRepository(channels ...*model.TGChannel) *TGChannelRepository {
+	r := &TGChannelRepository{channels: make(map[int]*model.TGChannel)}
+	for _, channel := range channels {
+		r.insert(channel)
+	}
+	return r
+}
+
+// insert 写入频道（调用方持有锁或在初始化阶段）
+func (r *TGChannelRepository) insert(channel *model.TGChannel) {
+	if channel.ID == 0 {
+		r.nextID++
+		channel.ID = r.nextID
+	} else if channel.ID > r.nextID {
+		r.nextID = channel.ID
+	}
+	copied := *channel
+	r.channels[channel.ID] = &copied
+}
+
+// List 获取频道列表（按权重降序）
+func (r *TGChannelRepository) List(ctx context.Context, status int) ([]*model.TGChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []*model.TGChannel
+	for _, channel := range r.channels {
+		if status < 0 || channel.Status == status {
+			copied := *channel
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Weight != list[j].Weight {
+			return list[i].Weight > list[j].Weight
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// GetByID 根据ID获取频道
+func (r *TGChannelRepository) GetByID(ctx context.Context, id int) (*model.TGChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channel, ok := r.channels[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *channel
+	return &copied, nil
+}
+
+// GetByName 根据频道名获取，不存在时返回nil
+func (r *TGChannelRepository) GetByName(ctx context.Context, name string) (*model.TGChannel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, channel := range r.channels {
+		if channel.Name == name {
+			copied := *channel
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// Create 创建频道
+func (r *TGChannelRepository) Create(ctx context.Context, channel *model.TGChannel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(channel)
+	return nil
+}
+
+// Update 更新频道
+func (r *TGChannelRepository) Update(ctx context.Context, channel *model.TGChannel) error {
+	return r.Create(ctx, channel)
+}
+
+// Delete 删除频道
+func (r *TGChannelRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.channels, id)
+	return nil
+}
+
+// UpdateStatus 更新频道状态
+func (r *TGChannelRepository) UpdateStatus(ctx context.Context, id int, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if channel, ok := r.channels[id]; ok {
+		channel.Status = status
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+﻿package repotest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// TransferJobRepository 内存转存任务仓储，Claim/CancelQueued与数据库实现一样按状态条件更新
+type TransferJobRepository struct {
+	mu     sync.RWMutex
+	jobs   map[uint64]*model.TransferJob
+	nextID uint64
+}
+
+// NewTransferJobRepository 创建内存转存任务仓储
+func NewTransferJobRepository(jobs ...*model.TransferJob) *TransferJobRepository {
+	r := &TransferJobRepository{jobs: make(map[uint64]*model.TransferJob)}
+	for _, job := range jobs {
+		r.insert(job)
+	}
+	return r
+}
+
+// insert 写入任务（调用方持有锁或在初始化阶段）
+func (r *TransferJobRepository) insert(job *model.TransferJob) {
+	if job.ID == 0 {
+		r.nextID++
+		job.ID = r.nextID
+	} else if job.ID > r.nextID {
+		r.nextID = job.ID
+	}
+	if job.CreateTime == 0 {
+		job.CreateTime = time.Now().Unix()
+		job.UpdateTime = job.CreateTime
+	}
+	copied := *job
+	r.jobs[job.ID] = &copied
+}
+
+// Create 创建转存任务
+func (r *TransferJobRepository) Create(ctx context.Context, job *model.TransferJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(job)
+	return nil
+}
+
+// GetByID 根据ID获取任务
+func (r *TransferJobRepository) GetByID(ctx context.Context, id uint64) (*model.TransferJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// GetByJobNo 根据任务编号获取任务，不存在时返回nil
+func (r *TransferJobRepository) GetByJobNo(ctx context.Context, jobNo string) (*model.TransferJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, job := range r.jobs {
+		if job.JobNo == jobNo {
+			copied := *job
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindActive 查找同一关键词和网盘类型下排队中或执行中的任务，不存在时返回nil
+func (r *TransferJobRepository) FindActive(ctx context.Context, panType int, keyword string) (*model.TransferJob, error) {
+	jobs := r.filter(func(job *model.TransferJob) bool {
+		return job.Keyword == keyword && job.PanType == panType &&
+			(job.Status == model.TransferJobQueued || job.Status == model.TransferJobRunning)
+	})
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID > jobs[j].ID })
+	return jobs[0], nil
+}
+
+// List 获取任务列表（按ID倒序，status为空时不过滤）
+func (r *TransferJobRepository) List(ctx context.Context, page, pageSize int, status string) ([]*model.TransferJob, int64, error) {
+	jobs := r.filter(func(job *model.TransferJob) bool {
+		return status == "" || job.Status == status
+	})
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID > jobs[j].ID })
+
+	total := int64(len(jobs))
+	start := (page - 1) * pageSize
+	if start >= len(jobs) {
+		return []*model.TransferJob{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(jobs) {
+		end = len(jobs)
+	}
+	return jobs[start:end], total, nil
+}
+
+// ListDue 获取已到执行时间的排队任务
+func (r *TransferJobRepository) ListDue(ctx context.Context, now int64, limit int) ([]*model.TransferJob, error) {
+	jobs := r.filter(func(job *model.TransferJob) bool {
+		return job.Status == model.TransferJobQueued && job.NextRunAt <= now
+	})
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].NextRunAt != jobs[j].NextRunAt {
+			return jobs[i].NextRunAt < jobs[j].NextRunAt
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// Claim 抢占排队任务并标记为执行中
+func (r *TransferJobRepository) Claim(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.Status != model.TransferJobQueued {
+		return false, nil
+	}
+	job.Status = model.TransferJobRunning
+	job.Attempts++
+	job.StartedAt = time.Now().Unix()
+	job.UpdateTime = job.StartedAt
+	return true, nil
+}
+
+// UpdateFields 更新任务字段，只支持服务层会更新的字段
+func (r *TransferJobRepository) UpdateFields(ctx context.Context, id uint64, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	for column, value := range fields {
+		switch column {
+		case "status":
+			job.Status = value.(string)
+		case "error":
+			job.Error = value.(string)
+		case "result":
+			job.Result = value.(string)
+		case "next_run_at":
+			job.NextRunAt = value.(int64)
+		case "finished_at":
+			job.FinishedAt = value.(int64)
+		default:
+			return fmt.Errorf("repotest: 不支持更新字段 %s", column)
+		}
+	}
+	job.UpdateTime = time.Now().Unix()
+	return nil
+}
+
+// CancelQueued 取消排队中的任务
+func (r *TransferJobRepository) CancelQueued(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.Status != model.TransferJobQueued {
+		return false, nil
+	}
+	job.Status = model.TransferJobCancelled
+	job.FinishedAt = time.Now().Unix()
+	job.UpdateTime = job.FinishedAt
+	return true, nil
+}
+
+// RequeueRunning 将执行中的任务重新排队
+func (r *TransferJobRepository) RequeueRunning(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, job := range r.jobs {
+		if job.Status == model.TransferJobRunning {
+			job.Status = model.TransferJobQueued
+			job.NextRunAt = 0
+			count++
+		}
+	}
+	return count, nil
+}
+
+// filter 返回满足条件的任务副本
+func (r *TransferJobRepository) filter(match func(*model.TransferJob) bool) []*model.TransferJob {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var jobs []*model.TransferJob
+	for _, job := range r.jobs {
+		if match(job) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,170 @@
+﻿package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+)
+
+// UserRepository 内存管理员仓储
+type UserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*model.User
+	nextID uint
+}
+
+// NewUserRepository 创建内存管理员仓储
+func NewUserRepository(users ...*model.User) *UserRepository {
+	r := &UserRepository{users: make(map[uint]*model.User)}
+	for _, user := range users {
+		r.insert(user)
+	}
+	return r
+}
+
+// insert 写入管理员（调用方持有锁或在初始化阶段）
+func (r *UserRepository) insert(user *model.User) {
+	if user.AdminID == 0 {
+		r.nextID++
+		user.AdminID = r.nextID
+	} else if user.AdminID > r.nextID {
+		r.nextID = user.AdminID
+	}
+	copied := *user
+	r.users[user.AdminID] = &copied
+}
+
+// Create 创建管理员
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(user)
+	return nil
+}
+
+// Update 更新管理员
+func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	return r.Create(ctx, user)
+}
+
+// Delete 删除管理员
+func (r *UserRepository) Delete(ctx context.Context, userID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, uint(userID))
+	return nil
+}
+
+// GetByID 根据ID获取管理员
+func (r *UserRepository) GetByID(ctx context.Context, userID uint64) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[uint(userID)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// GetByUsername 根据用户名获取管理员，不存在时返回nil
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// List 获取管理员列表（按ID排序）
+func (r *UserRepository) List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*model.User, 0, len(r.users))
+	for _, user := range r.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].AdminID < users[j].AdminID })
+
+	start := (page - 1) * pageSize
+	if start < 0 || start >= len(users) {
+		return []*model.User{}, int64(len(users)), nil
+	}
+	end := start + pageSize
+	if end > len(users) {
+		end = len(users)
+	}
+	return users[start:end], int64(len(users)), nil
+}
+
+// UpdateLastLogin 更新最后登录时间和IP，清除失败计数和锁定状态
+func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID uint64, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[uint(userID)]; ok {
+		user.LastLoginTime = time.Now().Unix()
+		user.LastLoginIP = ip
+		user.LoginFailCount = 0
+		user.LockedUntil = 0
+	}
+	return nil
+}
+
+// IncrLoginFailCount 连续登录失败次数加一
+func (r *UserRepository) IncrLoginFailCount(ctx context.Context, userID uint64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[uint(userID)]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	user.LoginFailCount++
+	return user.LoginFailCount, nil
+}
+
+// LockUntil 锁定账号至指定时间
+func (r *UserRepository) LockUntil(ctx context.Context, userID uint64, lockedUntil int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[uint(userID)]; ok {
+		user.LockedUntil = lockedUntil
+		user.LoginFailCount = 0
+	}
+	return nil
+}
+
+// UpdateTOTP 更新两步验证配置
+func (r *UserRepository) UpdateTOTP(ctx context.Context, userID uint64, secret string, enabled int, recoveryCodes string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[uint(userID)]; ok {
+		user.TOTPSecret = secret
+		user.TOTPEnabled = enabled
+		user.RecoveryCodes = recoveryCodes
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 条件更新恢复码
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint64, oldCodes, newCodes string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[uint(userID)]
+	if !ok || user.RecoveryCodes != oldCodes {
+		return false, nil
+	}
+	user.RecoveryCodes = newCodes
+	return true, nil
+}
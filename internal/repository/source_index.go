@@ -0,0 +1,149 @@
+﻿package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+	"huoxing-search/internal/pkg/fulltext"
+)
+
+// sourceIndexBatchSize 重建索引时每批读取的资源数量
+const sourceIndexBatchSize = 1000
+
+var (
+	// sourceIndex 资源全文索引（进程内共享）
+	sourceIndex = fulltext.NewIndex()
+	// sourceIndexReady 索引是否已完成首次构建，未就绪时搜索回退到LIKE查询
+	sourceIndexReady atomic.Bool
+	// sourceIndexBuiltAt 最近一次重建完成时间
+	sourceIndexBuiltAt atomic.Int64
+)
+
+// SourceIndexStatus 资源索引状态
+type SourceIndexStatus struct {
+	Ready     bool  `json:"ready"`
+	Documents int   `json:"documents"`
+	BuiltAt   int64 `json:"built_at"`
+}
+
+// GetSourceIndexStatus 获取资源索引状态
+func GetSourceIndexStatus() SourceIndexStatus {
+	return SourceIndexStatus{
+		Ready:     sourceIndexReady.Load(),
+		Documents: sourceIndex.Len(),
+		BuiltAt:   sourceIndexBuiltAt.Load(),
+	}
+}
+
+// RebuildSourceIndex 从数据库全量重建资源索引，返回索引的文档数量
+// 读取期间的增删改会在替换时重放，不会被读取时的旧数据覆盖
+func RebuildSourceIndex(ctx context.Context) (int, error) {
+	count, err := sourceIndex.Rebuild(func() ([]fulltext.Document, error) {
+		return loadSourceDocuments(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sourceIndexReady.Store(true)
+	sourceIndexBuiltAt.Store(time.Now().Unix())
+	return count, nil
+}
+
+// loadSourceDocuments 分批读取全部资源
+func loadSourceDocuments(ctx context.Context) ([]fulltext.Document, error) {
+	db := database.GetDB()
+	var docs []fulltext.Document
+	var lastID uint64
+
+	for {
+		var batch []*model.Source
+		err := db.WithContext(ctx).
+			Select("source_id", "title", "content", "is_type", "status", "category_id", "create_time").
+			Where("source_id > ?", lastID).
+			Order("source_id ASC").
+			Limit(sourceIndexBatchSize).
+			Find(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range batch {
+			docs = append(docs, toIndexDocument(source))
+		}
+		if len(batch) < sourceIndexBatchSize {
+			break
+		}
+		lastID = batch[len(batch)-1].SourceID
+	}
+	return docs, nil
+}
+
+func toIndexDocument(source *model.Source) fulltext.Document {
+	return fulltext.Document{
+		ID:         source.SourceID,
+		Title:      source.Title,
+		Content:    source.Content,
+		PanType:    source.IsType,
+		Status:     source.Status,
+		CategoryID: source.CategoryID,
+		CreateTime: source.CreateTime,
+	}
+}
+
+// indexSources 写入或更新索引
+func indexSources(sources ...*model.Source) {
+	for _, source := range sources {
+		if source != nil && source.SourceID > 0 {
+			sourceIndex.Add(toIndexDocument(source))
+		}
+	}
+}
+
+// unindexSources 从索引中移除
+func unindexSources(ids ...uint64) {
+	for _, id := range ids {
+		sourceIndex.Remove(id)
+	}
+}
+
+// searchSourceIndex 通过索引搜索已上线资源，返回按相关度排序的资源及命中总数
+// panType/categoryID小于0表示不筛选；ok为false表示索引不可用，调用方应回退到数据库查询
+func (r *sourceRepository) searchSourceIndex(ctx context.Context, keyword string, panType, categoryID int, offset, limit int) ([]*model.Source, int64, bool, error) {
+	if !sourceIndexReady.Load() {
+		return nil, 0, false, nil
+	}
+
+	filter := fulltext.Filter{PanType: panType, Status: 1, CategoryID: categoryID}
+	hits, total := sourceIndex.Search(keyword, filter, offset+limit)
+	if offset >= len(hits) {
+		return []*model.Source{}, int64(total), true, nil
+	}
+	hits = hits[offset:]
+
+	ids := make([]uint64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var found []*model.Source
+	if err := r.db.WithContext(ctx).Where("source_id IN ? AND status = 1", ids).Find(&found).Error; err != nil {
+		return nil, 0, true, err
+	}
+
+	// 按相关度顺序返回
+	byID := make(map[uint64]*model.Source, len(found))
+	for _, source := range found {
+		byID[source.SourceID] = source
+	}
+	sources := make([]*model.Source, 0, len(found))
+	for _, id := range ids {
+		if source, ok := byID[id]; ok {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources, int64(total), true, nil
+}
@@ -16,11 +16,15 @@ type SourceRepository interface {
 	Delete(ctx context.Context, sourceID uint64) error
 	GetByID(ctx context.Context, sourceID uint64) (*model.Source, error)
 	GetByURL(ctx context.Context, url string) (*model.Source, error)
-	List(ctx context.Context, page, pageSize int, isType int, status int) ([]*model.Source, int64, error)
+	List(ctx context.Context, filter model.SourceFilter, page, pageSize int) ([]*model.Source, int64, error)
 	Search(ctx context.Context, keyword string, page, pageSize int) ([]*model.Source, int64, error)
-	SearchByKeywordAndType(ctx context.Context, keyword string, panType int, limit int) ([]*model.Source, error)
+	SearchByKeywordAndType(ctx context.Context, keyword string, panType, categoryID int, limit int) ([]*model.Source, error)
 	BatchCreate(ctx context.Context, sources []*model.Source) error
+	ListAfterID(ctx context.Context, lastID uint64, status int, limit int) ([]*model.Source, error)
 	DeleteExpiredTemp(ctx context.Context, expiryTime int64) (int64, error)
+	IncrementViewCount(ctx context.Context, sourceIDs ...uint64) error
+	IncrementTransferCount(ctx context.Context, urls ...string) error
+	UpdateCategory(ctx context.Context, source *model.Source) error
 }
 
 type sourceRepository struct {
@@ -36,17 +40,29 @@ func NewSourceRepository() SourceRepository {
 
 // Create 创建资源
 func (r *sourceRepository) Create(ctx context.Context, source *model.Source) error {
-	return r.db.WithContext(ctx).Create(source).Error
+	if err := r.db.WithContext(ctx).Create(source).Error; err != nil {
+		return err
+	}
+	indexSources(source)
+	return nil
 }
 
-// Update 更新资源
+// Update 更新资源（查看/转存次数由计数方法维护，不随资源更新覆盖）
 func (r *sourceRepository) Update(ctx context.Context, source *model.Source) error {
-	return r.db.WithContext(ctx).Save(source).Error
+	if err := r.db.WithContext(ctx).Omit("view_count", "transfer_count").Save(source).Error; err != nil {
+		return err
+	}
+	indexSources(source)
+	return nil
 }
 
 // Delete 删除资源
 func (r *sourceRepository) Delete(ctx context.Context, sourceID uint64) error {
-	return r.db.WithContext(ctx).Delete(&model.Source{}, sourceID).Error
+	if err := r.db.WithContext(ctx).Delete(&model.Source{}, sourceID).Error; err != nil {
+		return err
+	}
+	unindexSources(sourceID)
+	return nil
 }
 
 // GetByID 根据ID获取资源
@@ -73,18 +89,26 @@ func (r *sourceRepository) GetByURL(ctx context.Context, url string) (*model.Sou
 }
 
 // List 获取资源列表
-func (r *sourceRepository) List(ctx context.Context, page, pageSize int, isType int, status int) ([]*model.Source, int64, error) {
+func (r *sourceRepository) List(ctx context.Context, filter model.SourceFilter, page, pageSize int) ([]*model.Source, int64, error) {
 	var sources []*model.Source
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.Source{})
 
 	// 筛选条件
-	if isType >= 0 {
-		query = query.Where("is_type = ?", isType)
+	if filter.IsType >= 0 {
+		query = query.Where("is_type = ?", filter.IsType)
 	}
-	if status >= 0 {
-		query = query.Where("status = ?", status)
+	if filter.Status >= 0 {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CategoryID == 0 {
+		query = query.Where("category_id IS NULL OR category_id = 0")
+	} else if filter.CategoryID > 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if filter.SourceName != "" {
+		query = query.Where("source_name = ?", filter.SourceName)
 	}
 
 	// 获取总数
@@ -102,11 +126,18 @@ func (r *sourceRepository) List(ctx context.Context, page, pageSize int, isType
 	return sources, total, nil
 }
 
-// Search 搜索资源
+// Search 搜索资源（优先使用全文索引，按相关度排序）
 func (r *sourceRepository) Search(ctx context.Context, keyword string, page, pageSize int) ([]*model.Source, int64, error) {
 	var sources []*model.Source
 	var total int64
 
+	offset := (page - 1) * pageSize
+	if keyword != "" {
+		if sources, total, ok, err := r.searchSourceIndex(ctx, keyword, -1, -1, offset, pageSize); ok {
+			return sources, total, err
+		}
+	}
+
 	query := r.db.WithContext(ctx).Model(&model.Source{}).Where("status = 1")
 
 	// 搜索条件
@@ -121,7 +152,6 @@ func (r *sourceRepository) Search(ctx context.Context, keyword string, page, pag
 	}
 
 	// 分页查询
-	offset := (page - 1) * pageSize
 	err := query.Order("create_time DESC").Offset(offset).Limit(pageSize).Find(&sources).Error
 	if err != nil {
 		return nil, 0, err
@@ -135,19 +165,56 @@ func (r *sourceRepository) BatchCreate(ctx context.Context, sources []*model.Sou
 	if len(sources) == 0 {
 		return nil
 	}
-	return r.db.WithContext(ctx).CreateInBatches(sources, 100).Error
+	if err := r.db.WithContext(ctx).CreateInBatches(sources, 100).Error; err != nil {
+		return err
+	}
+	indexSources(sources...)
+	return nil
 }
 
-// SearchByKeywordAndType 按关键词和网盘类型搜索本地资源
-func (r *sourceRepository) SearchByKeywordAndType(ctx context.Context, keyword string, panType int, limit int) ([]*model.Source, error) {
+// ListAfterID 按ID顺序分批获取资源（用于全量遍历），status<0表示不筛选状态
+func (r *sourceRepository) ListAfterID(ctx context.Context, lastID uint64, status int, limit int) ([]*model.Source, error) {
 	var sources []*model.Source
 	
+	query := r.db.WithContext(ctx).Where("source_id > ?", lastID)
+	if status >= 0 {
+		query = query.Where("status = ?", status)
+	}
+	
+	err := query.Order("source_id ASC").Limit(limit).Find(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+	
+	return sources, nil
+}
+
+// SearchByKeywordAndType 按关键词和网盘类型搜索本地资源（优先使用全文索引，按相关度排序）
+// categoryID大于0时只返回该分类的资源
+func (r *sourceRepository) SearchByKeywordAndType(ctx context.Context, keyword string, panType, categoryID int, limit int) ([]*model.Source, error) {
+	var sources []*model.Source
+
+	indexCategory := -1
+	if categoryID > 0 {
+		indexCategory = categoryID
+	}
+	if keyword != "" {
+		if sources, _, ok, err := r.searchSourceIndex(ctx, keyword, panType, indexCategory, 0, limit); ok {
+			return sources, err
+		}
+	}
+	
 	query := r.db.WithContext(ctx).Model(&model.Source{}).Where("status = 1")
 	
 	// 网盘类型筛选
 	if panType >= 0 {
 		query = query.Where("is_type = ?", panType)
 	}
+
+	// 分类筛选
+	if categoryID > 0 {
+		query = query.Where("category_id = ?", categoryID)
+	}
 	
 	// 关键词搜索
 	if keyword != "" {
@@ -167,13 +234,58 @@ func (r *sourceRepository) SearchByKeywordAndType(ctx context.Context, keyword s
 // DeleteExpiredTemp 删除过期的临时资源
 // expiryTime: 过期时间戳，早于此时间的临时资源将被删除
 func (r *sourceRepository) DeleteExpiredTemp(ctx context.Context, expiryTime int64) (int64, error) {
-	result := r.db.WithContext(ctx).
+	// 先取出待删除的ID，删除后同步移除索引
+	var ids []uint64
+	err := r.db.WithContext(ctx).Model(&model.Source{}).
 		Where("is_time = ? AND create_time < ?", 1, expiryTime).
+		Pluck("source_id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("source_id IN ?", ids).
 		Delete(&model.Source{})
 	
 	if result.Error != nil {
 		return 0, result.Error
 	}
+	unindexSources(ids...)
 	
 	return result.RowsAffected, nil
-}
\ No newline at end of file
+}
+
+// IncrementViewCount 增加资源查看次数
+func (r *sourceRepository) IncrementViewCount(ctx context.Context, sourceIDs ...uint64) error {
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.Source{}).
+		Where("source_id IN ?", sourceIDs).
+		UpdateColumn("view_count", gorm.Expr("COALESCE(view_count, 0) + 1")).Error
+}
+
+// IncrementTransferCount 增加资源转存次数（分享链接或原始链接匹配即计数）
+func (r *sourceRepository) IncrementTransferCount(ctx context.Context, urls ...string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.Source{}).
+		Where("url IN ? OR content IN ?", urls, urls).
+		UpdateColumn("transfer_count", gorm.Expr("COALESCE(transfer_count, 0) + 1")).Error
+}
+
+// UpdateCategory 只更新资源分类（不触发更新时间）
+func (r *sourceRepository) UpdateCategory(ctx context.Context, source *model.Source) error {
+	err := r.db.WithContext(ctx).Model(&model.Source{}).
+		Where("source_id = ?", source.SourceID).
+		UpdateColumn("category_id", source.CategoryID).Error
+	if err != nil {
+		return err
+	}
+	indexSources(source)
+	return nil
+}
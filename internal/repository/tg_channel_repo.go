@@ -0,0 +1,95 @@
+﻿package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// TGChannelRepository TG频道仓储接口
+type TGChannelRepository interface {
+	// List 获取频道列表，status<0时不过滤状态，按权重降序
+	List(ctx context.Context, status int) ([]*model.TGChannel, error)
+	GetByID(ctx context.Context, id int) (*model.TGChannel, error)
+	// GetByName 根据频道名获取，不存在时返回nil
+	GetByName(ctx context.Context, name string) (*model.TGChannel, error)
+	Create(ctx context.Context, channel *model.TGChannel) error
+	Update(ctx context.Context, channel *model.TGChannel) error
+	Delete(ctx context.Context, id int) error
+	UpdateStatus(ctx context.Context, id int, status int) error
+}
+
+type tgChannelRepository struct {
+	db *gorm.DB
+}
+
+// NewTGChannelRepository 创建TG频道仓储
+func NewTGChannelRepository() TGChannelRepository {
+	return &tgChannelRepository{
+		db: database.GetDB(),
+	}
+}
+
+// List 获取频道列表
+func (r *tgChannelRepository) List(ctx context.Context, status int) ([]*model.TGChannel, error) {
+	var channels []*model.TGChannel
+	query := r.db.WithContext(ctx).Model(&model.TGChannel{})
+	if status >= 0 {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("weight DESC, id ASC").Find(&channels).Error
+	return channels, err
+}
+
+// GetByID 根据ID获取频道
+func (r *tgChannelRepository) GetByID(ctx context.Context, id int) (*model.TGChannel, error) {
+	var channel model.TGChannel
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&channel).Error
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// GetByName 根据频道名获取
+func (r *tgChannelRepository) GetByName(ctx context.Context, name string) (*model.TGChannel, error) {
+	var channel model.TGChannel
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&channel).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// Create 创建频道
+func (r *tgChannelRepository) Create(ctx context.Context, channel *model.TGChannel) error {
+	return r.db.WithContext(ctx).Create(channel).Error
+}
+
+// Update 更新频道
+func (r *tgChannelRepository) Update(ctx context.Context, channel *model.TGChannel) error {
+	return r.db.WithContext(ctx).Model(channel).
+		Select("name", "status", "weight", "tags", "remark", "update_time").
+		Updates(channel).Error
+}
+
+// Delete 删除频道
+func (r *tgChannelRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.TGChannel{}).Error
+}
+
+// UpdateStatus 更新频道状态
+func (r *tgChannelRepository) UpdateStatus(ctx context.Context, id int, status int) error {
+	return r.db.WithContext(ctx).Model(&model.TGChannel{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"update_time": time.Now().Unix(),
+		}).Error
+}
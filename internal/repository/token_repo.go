@@ -0,0 +1,129 @@
+﻿package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 令牌吊销、两步验证缓存键前缀
+const (
+	revokedTokenKeyPrefix = "auth:revoked:jti:"
+	revokedUserKeyPrefix  = "auth:revoked:user:"
+	mfaChallengeKeyPrefix = "auth:mfa:challenge:"
+	totpUsedKeyPrefix     = "auth:mfa:used:"
+)
+
+// TokenRepository 令牌吊销及两步验证临时凭据仓储（存储在缓存中，Redis不可用时退化为进程内存）
+type TokenRepository interface {
+	// RevokeToken 吊销单个令牌，ttl为令牌剩余有效期，过期后记录自动清除
+	RevokeToken(ctx context.Context, jti string, ttl time.Duration) error
+	// TryRevokeToken 原子地吊销单个令牌，令牌此前已被吊销时返回false
+	TryRevokeToken(ctx context.Context, jti string, ttl time.Duration) (bool, error)
+	// IsTokenRevoked 令牌是否已吊销
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeUserTokens 吊销用户当前时间及之前签发的全部令牌，ttl应不小于令牌最长有效期
+	RevokeUserTokens(ctx context.Context, userID int64, ttl time.Duration) error
+	// UserTokensRevokedAt 用户令牌整体吊销的时间点（Unix纳秒），未吊销返回0
+	UserTokensRevokedAt(ctx context.Context, userID int64) (int64, error)
+	// SaveMFAChallenge 保存密码校验通过、等待两步验证的登录凭据
+	SaveMFAChallenge(ctx context.Context, token string, userID int64, ttl time.Duration) error
+	// GetMFAChallenge 获取登录凭据对应的用户ID，不存在或已过期返回false
+	GetMFAChallenge(ctx context.Context, token string) (int64, bool)
+	// DeleteMFAChallenge 删除登录凭据（登录成功或账号锁定后）
+	DeleteMFAChallenge(ctx context.Context, token string) error
+	// MarkTOTPUsed 记录已使用的验证码时间步，该时间步已使用过时返回false
+	MarkTOTPUsed(ctx context.Context, userID int64, step int64, ttl time.Duration) (bool, error)
+}
+
+type tokenRepository struct {
+	cache CacheRepository
+}
+
+// NewTokenRepository 创建令牌吊销仓储
+func NewTokenRepository() TokenRepository {
+	return &tokenRepository{cache: NewCacheRepository()}
+}
+
+// RevokeToken 吊销单个令牌
+func (r *tokenRepository) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return r.cache.Set(ctx, revokedTokenKeyPrefix+jti, "1", ttl)
+}
+
+// TryRevokeToken 原子地吊销单个令牌，用于只能使用一次的刷新令牌
+func (r *tokenRepository) TryRevokeToken(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	if jti == "" || ttl <= 0 {
+		return true, nil
+	}
+	return r.cache.SetNX(ctx, revokedTokenKeyPrefix+jti, "1", ttl)
+}
+
+// IsTokenRevoked 令牌是否已吊销
+func (r *tokenRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return r.cache.Exists(ctx, revokedTokenKeyPrefix+jti)
+}
+
+// RevokeUserTokens 吊销用户的全部令牌
+func (r *tokenRepository) RevokeUserTokens(ctx context.Context, userID int64, ttl time.Duration) error {
+	key := fmt.Sprintf("%s%d", revokedUserKeyPrefix, userID)
+	return r.cache.Set(ctx, key, strconv.FormatInt(time.Now().UnixNano(), 10), ttl)
+}
+
+// legacyRevokedAtLimit 小于该值的吊销记录是旧版本写入的Unix秒
+const legacyRevokedAtLimit = 1e12
+
+// UserTokensRevokedAt 获取用户令牌整体吊销的时间点
+func (r *tokenRepository) UserTokensRevokedAt(ctx context.Context, userID int64) (int64, error) {
+	val, ok := r.cache.Get(ctx, fmt.Sprintf("%s%d", revokedUserKeyPrefix, userID))
+	if !ok {
+		return 0, nil
+	}
+	revokedAt, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("令牌吊销记录格式错误: %w", err)
+	}
+	if revokedAt < legacyRevokedAtLimit {
+		// 旧记录按秒吊销，该秒内签发的令牌一并失效
+		return time.Unix(revokedAt+1, 0).UnixNano() - 1, nil
+	}
+	return revokedAt, nil
+}
+
+// SaveMFAChallenge 保存两步验证登录凭据
+func (r *tokenRepository) SaveMFAChallenge(ctx context.Context, token string, userID int64, ttl time.Duration) error {
+	return r.cache.Set(ctx, mfaChallengeKeyPrefix+token, strconv.FormatInt(userID, 10), ttl)
+}
+
+// GetMFAChallenge 获取两步验证登录凭据对应的用户ID
+func (r *tokenRepository) GetMFAChallenge(ctx context.Context, token string) (int64, bool) {
+	if token == "" {
+		return 0, false
+	}
+	val, ok := r.cache.Get(ctx, mfaChallengeKeyPrefix+token)
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+// DeleteMFAChallenge 删除两步验证登录凭据
+func (r *tokenRepository) DeleteMFAChallenge(ctx context.Context, token string) error {
+	return r.cache.Delete(ctx, mfaChallengeKeyPrefix+token)
+}
+
+// MarkTOTPUsed 记录已使用的验证码时间步，防止验证码在有效期内被重放（并发提交同一验证码时只有一个成功）
+func (r *tokenRepository) MarkTOTPUsed(ctx context.Context, userID int64, step int64, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("%s%d:%d", totpUsedKeyPrefix, userID, step)
+	return r.cache.SetNX(ctx, key, "1", ttl)
+}
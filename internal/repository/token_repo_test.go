@@ -0,0 +1,70 @@
+﻿package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowCache 读取时让出执行权的缓存，放大“先检查后写入”的竞争窗口
+type slowCache struct {
+	CacheRepository
+}
+
+func (c slowCache) Exists(ctx context.Context, key string) (bool, error) {
+	ok, err := c.CacheRepository.Exists(ctx, key)
+	time.Sleep(time.Millisecond)
+	return ok, err
+}
+
+func TestTokenRepositoryConcurrentUse(t *testing.T) {
+	repo := &tokenRepository{cache: slowCache{NewCacheRepository()}}
+	ctx := context.Background()
+	// 内存缓存进程内共享，每次运行使用不同的键
+	step := time.Now().UnixNano()
+	jti := strconv.FormatInt(step, 36)
+
+	tests := []struct {
+		name string
+		use  func() (bool, error)
+	}{
+		{name: "验证码时间步", use: func() (bool, error) { return repo.MarkTOTPUsed(ctx, 301, step, time.Minute) }},
+		{name: "刷新令牌", use: func() (bool, error) { return repo.TryRevokeToken(ctx, jti, time.Minute) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const n = 16
+			var (
+				wg    sync.WaitGroup
+				fresh atomic.Int32
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					ok, err := tt.use()
+					if err != nil {
+						t.Errorf("error = %v", err)
+					}
+					if ok {
+						fresh.Add(1)
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if got := fresh.Load(); got != 1 {
+				t.Fatalf("%d 个并发请求成功, want 1", got)
+			}
+			if ok, _ := tt.use(); ok {
+				t.Fatal("再次使用 = true, want false")
+			}
+		})
+	}
+}
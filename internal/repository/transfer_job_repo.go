@@ -0,0 +1,156 @@
+﻿package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/database"
+)
+
+// TransferJobRepository 转存任务仓储接口
+type TransferJobRepository interface {
+	Create(ctx context.Context, job *model.TransferJob) error
+	GetByID(ctx context.Context, id uint64) (*model.TransferJob, error)
+	GetByJobNo(ctx context.Context, jobNo string) (*model.TransferJob, error)
+	FindActive(ctx context.Context, panType int, keyword string) (*model.TransferJob, error)
+	List(ctx context.Context, page, pageSize int, status string) ([]*model.TransferJob, int64, error)
+	ListDue(ctx context.Context, now int64, limit int) ([]*model.TransferJob, error)
+	Claim(ctx context.Context, id uint64) (bool, error)
+	UpdateFields(ctx context.Context, id uint64, fields map[string]interface{}) error
+	CancelQueued(ctx context.Context, id uint64) (bool, error)
+	RequeueRunning(ctx context.Context) (int64, error)
+}
+
+type transferJobRepository struct {
+	db *gorm.DB
+}
+
+// NewTransferJobRepository 创建转存任务仓储
+func NewTransferJobRepository() TransferJobRepository {
+	return &transferJobRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 创建转存任务
+func (r *transferJobRepository) Create(ctx context.Context, job *model.TransferJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// GetByID 根据ID获取任务
+func (r *transferJobRepository) GetByID(ctx context.Context, id uint64) (*model.TransferJob, error) {
+	var job model.TransferJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetByJobNo 根据任务编号获取任务
+func (r *transferJobRepository) GetByJobNo(ctx context.Context, jobNo string) (*model.TransferJob, error) {
+	var job model.TransferJob
+	err := r.db.WithContext(ctx).Where("job_no = ?", jobNo).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindActive 查找同一关键词和网盘类型下排队中或执行中的任务，不存在时返回nil
+func (r *transferJobRepository) FindActive(ctx context.Context, panType int, keyword string) (*model.TransferJob, error) {
+	var job model.TransferJob
+	err := r.db.WithContext(ctx).
+		Where("keyword = ? AND pan_type = ? AND status IN ?", keyword, panType, []string{model.TransferJobQueued, model.TransferJobRunning}).
+		Order("id DESC").
+		First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List 获取任务列表（status为空时不过滤）
+func (r *transferJobRepository) List(ctx context.Context, page, pageSize int, status string) ([]*model.TransferJob, int64, error) {
+	var jobs []*model.TransferJob
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.TransferJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&jobs).Error
+	return jobs, total, err
+}
+
+// ListDue 获取已到执行时间的排队任务
+func (r *transferJobRepository) ListDue(ctx context.Context, now int64, limit int) ([]*model.TransferJob, error) {
+	var jobs []*model.TransferJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", model.TransferJobQueued, now).
+		Order("next_run_at ASC, id ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim 抢占排队任务并标记为执行中，返回是否抢占成功
+func (r *transferJobRepository) Claim(ctx context.Context, id uint64) (bool, error) {
+	now := time.Now().Unix()
+	result := r.db.WithContext(ctx).Model(&model.TransferJob{}).
+		Where("id = ? AND status = ?", id, model.TransferJobQueued).
+		Updates(map[string]interface{}{
+			"status":      model.TransferJobRunning,
+			"attempts":    gorm.Expr("attempts + 1"),
+			"started_at":  now,
+			"update_time": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateFields 更新任务字段
+func (r *transferJobRepository) UpdateFields(ctx context.Context, id uint64, fields map[string]interface{}) error {
+	fields["update_time"] = time.Now().Unix()
+	return r.db.WithContext(ctx).Model(&model.TransferJob{}).
+		Where("id = ?", id).
+		Updates(fields).Error
+}
+
+// CancelQueued 取消排队中的任务，返回是否取消成功
+func (r *transferJobRepository) CancelQueued(ctx context.Context, id uint64) (bool, error) {
+	now := time.Now().Unix()
+	result := r.db.WithContext(ctx).Model(&model.TransferJob{}).
+		Where("id = ? AND status = ?", id, model.TransferJobQueued).
+		Updates(map[string]interface{}{
+			"status":      model.TransferJobCancelled,
+			"finished_at": now,
+			"update_time": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RequeueRunning 将执行中的任务重新排队（服务重启后恢复中断的任务）
+func (r *transferJobRepository) RequeueRunning(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.TransferJob{}).
+		Where("status = ?", model.TransferJobRunning).
+		Updates(map[string]interface{}{
+			"status":      model.TransferJobQueued,
+			"next_run_at": 0,
+			"update_time": time.Now().Unix(),
+		})
+	return result.RowsAffected, result.Error
+}
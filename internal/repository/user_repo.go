@@ -16,7 +16,16 @@ type UserRepository interface {
 	GetByID(ctx context.Context, userID uint64) (*model.User, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	List(ctx context.Context, page, pageSize int) ([]*model.User, int64, error)
-	UpdateLastLogin(ctx context.Context, userID uint64) error
+	// UpdateLastLogin 记录登录成功的时间和IP，并清除失败计数和锁定状态
+	UpdateLastLogin(ctx context.Context, userID uint64, ip string) error
+	// IncrLoginFailCount 连续登录失败次数加一，返回累加后的次数
+	IncrLoginFailCount(ctx context.Context, userID uint64) (int, error)
+	// LockUntil 锁定账号至指定时间（Unix秒），同时清零失败次数
+	LockUntil(ctx context.Context, userID uint64, lockedUntil int64) error
+	// UpdateTOTP 更新两步验证密钥、启用状态和恢复码
+	UpdateTOTP(ctx context.Context, userID uint64, secret string, enabled int, recoveryCodes string) error
+	// ReplaceRecoveryCodes 当恢复码仍为oldCodes时替换为newCodes，返回是否替换成功（防止同一恢复码并发重复使用）
+	ReplaceRecoveryCodes(ctx context.Context, userID uint64, oldCodes, newCodes string) (bool, error)
 }
 
 type userRepository struct {
@@ -90,9 +99,59 @@ func (r *userRepository) List(ctx context.Context, page, pageSize int) ([]*model
 	return users, total, nil
 }
 
-// UpdateLastLogin 更新最后登录时间
-func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uint64) error {
+// UpdateLastLogin 更新最后登录时间和IP，清除失败计数和锁定状态
+func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uint64, ip string) error {
 	return r.db.WithContext(ctx).Model(&model.User{}).
 		Where("admin_id = ?", userID).
-		Update("last_login_time", gorm.Expr("UNIX_TIMESTAMP()")).Error
+		Updates(map[string]interface{}{
+			"last_login_time":  gorm.Expr("UNIX_TIMESTAMP()"),
+			"last_login_ip":    ip,
+			"login_fail_count": 0,
+			"locked_until":     0,
+		}).Error
+}
+
+// IncrLoginFailCount 连续登录失败次数加一
+func (r *userRepository) IncrLoginFailCount(ctx context.Context, userID uint64) (int, error) {
+	var count int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).
+			Where("admin_id = ?", userID).
+			UpdateColumn("login_fail_count", gorm.Expr("login_fail_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).
+			Where("admin_id = ?", userID).
+			Pluck("login_fail_count", &count).Error
+	})
+	return count, err
+}
+
+// LockUntil 锁定账号至指定时间
+func (r *userRepository) LockUntil(ctx context.Context, userID uint64, lockedUntil int64) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("admin_id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"locked_until":     lockedUntil,
+			"login_fail_count": 0,
+		}).Error
+}
+
+// UpdateTOTP 更新两步验证配置
+func (r *userRepository) UpdateTOTP(ctx context.Context, userID uint64, secret string, enabled int, recoveryCodes string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("admin_id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"totp_enabled":   enabled,
+			"recovery_codes": recoveryCodes,
+		}).Error
+}
+
+// ReplaceRecoveryCodes 条件更新恢复码
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint64, oldCodes, newCodes string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("admin_id = ? AND recovery_codes = ?", userID, oldCodes).
+		UpdateColumn("recovery_codes", newCodes)
+	return result.RowsAffected > 0, result.Error
 }
\ No newline at end of file
@@ -0,0 +1,559 @@
+﻿package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/pkg/ratelimit"
+	"huoxing-search/internal/repository"
+)
+
+var (
+	// ErrAPIKeyTransferDenied API Key未开通自动转存
+	ErrAPIKeyTransferDenied = errors.New("该API Key无自动转存权限")
+	// ErrAPIKeyQuotaExceeded API Key今日转存额度已用完
+	ErrAPIKeyQuotaExceeded = errors.New("该API Key今日转存额度已用完")
+)
+
+const (
+	// apiKeyPrefix 密钥明文前缀，便于识别和密钥扫描
+	apiKeyPrefix = "hx_"
+	// apiKeyDisplayLen 列表中展示的密钥前缀长度
+	apiKeyDisplayLen = 10
+	// apiKeyCacheTTL 认证结果缓存时间，修改/删除密钥时立即失效
+	apiKeyCacheTTL = time.Minute
+	// apiKeyRequiredTTL 是否强制API Key配置的缓存时间
+	apiKeyRequiredTTL = 30 * time.Second
+	// apiKeyFlushInterval 用量计数落库间隔
+	apiKeyFlushInterval = 30 * time.Second
+	// apiKeyDateLayout 每日用量的日期格式
+	apiKeyDateLayout = "2006-01-02"
+)
+
+// APIKeyService 开放接口密钥服务接口
+// 请求数和转存数先在内存中累计，定期合并写入每日用量表
+type APIKeyService interface {
+	List(ctx context.Context, page, pageSize int, keyword string) ([]*model.APIKey, int64, error)
+	GetByID(ctx context.Context, id int) (*model.APIKey, error)
+	// Create 创建密钥，返回只展示一次的密钥明文
+	Create(ctx context.Context, key *model.APIKey) (string, error)
+	Update(ctx context.Context, key *model.APIKey) error
+	Delete(ctx context.Context, id int) error
+	// Regenerate 重置密钥，旧密钥立即失效
+	Regenerate(ctx context.Context, id int) (string, error)
+	// Usage 获取最近days天的每日用量
+	Usage(ctx context.Context, id int, days int) ([]*model.APIKeyUsage, error)
+
+	// Required 搜索/转存接口是否强制要求API Key
+	Required(ctx context.Context) bool
+	// Authenticate 校验密钥明文，不存在、已禁用或已过期时返回nil
+	Authenticate(ctx context.Context, raw string) (*model.APIKey, error)
+	// Allow 按密钥的每分钟请求数限制判断是否放行（多实例共享计数）
+	Allow(key *model.APIKey) bool
+	// RecordRequest 记录一次请求
+	RecordRequest(key *model.APIKey)
+	// ReserveTransfers 预占转存额度，返回实际可转存数量和提交函数
+	// 提交函数必须调用一次，传入实际转存成功数量，未使用的额度会被释放
+	ReserveTransfers(ctx context.Context, key *model.APIKey, want int) (int, func(used int), error)
+
+	// Flush 将内存中的用量写入数据库
+	Flush(ctx context.Context) error
+	Start()
+	Stop()
+}
+
+// apiKeyUsageKey 内存用量计数的键
+type apiKeyUsageKey struct {
+	id   int
+	date string
+}
+
+// apiKeyCounter 单个密钥单日的内存用量
+type apiKeyCounter struct {
+	loaded           bool  // 是否已从数据库加载当日转存数
+	storedTransfers  int64 // 数据库中的当日转存数
+	pendingRequests  int64 // 未落库的请求数
+	pendingTransfers int64 // 未落库的转存数
+	reserved         int64 // 已预占未提交的转存额度
+	lastUsed         int64
+}
+
+type cachedAPIKey struct {
+	key     *model.APIKey
+	expires time.Time
+}
+
+type apiKeyService struct {
+	keyRepo    repository.APIKeyRepository
+	configRepo repository.ConfigRepository
+	limiter    ratelimit.Limiter
+	now        func() time.Time
+
+	mu       sync.Mutex
+	counters map[apiKeyUsageKey]*apiKeyCounter
+	cache    map[string]cachedAPIKey
+
+	required        bool
+	requiredExpires time.Time
+
+	flushMu sync.Mutex
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewAPIKeyService 创建开放接口密钥服务
+func NewAPIKeyService(keyRepo repository.APIKeyRepository, configRepo repository.ConfigRepository, limiter ratelimit.Limiter) APIKeyService {
+	return &apiKeyService{
+		keyRepo:    keyRepo,
+		configRepo: configRepo,
+		limiter:    limiter,
+		now:        time.Now,
+		counters:   make(map[apiKeyUsageKey]*apiKeyCounter),
+		cache:      make(map[string]cachedAPIKey),
+	}
+}
+
+var (
+	globalAPIKeyService APIKeyService
+	globalAPIKeyMu      sync.Mutex
+)
+
+// StartAPIKeyService 启动全局开放接口密钥服务（重复调用只启动一次）
+func StartAPIKeyService() APIKeyService {
+	globalAPIKeyMu.Lock()
+	defer globalAPIKeyMu.Unlock()
+
+	if globalAPIKeyService == nil {
+		globalAPIKeyService = NewAPIKeyService(repository.NewAPIKeyRepository(), repository.NewConfigRepository(), ratelimit.New())
+		globalAPIKeyService.Start()
+	}
+	return globalAPIKeyService
+}
+
+// GetAPIKeyService 获取全局开放接口密钥服务，未启动时返回nil
+func GetAPIKeyService() APIKeyService {
+	globalAPIKeyMu.Lock()
+	defer globalAPIKeyMu.Unlock()
+	return globalAPIKeyService
+}
+
+// StopAPIKeyService 停止全局开放接口密钥服务（停止前写入未落库的用量）
+func StopAPIKeyService() {
+	globalAPIKeyMu.Lock()
+	svc := globalAPIKeyService
+	globalAPIKeyService = nil
+	globalAPIKeyMu.Unlock()
+
+	if svc != nil {
+		svc.Stop()
+	}
+}
+
+// ReserveAPIKeyTransfers 按请求上下文中的API Key预占转存额度
+// 匿名请求（或密钥服务未启动）不受限制，直接返回want
+func ReserveAPIKeyTransfers(ctx context.Context, want int) (int, func(used int), error) {
+	key := model.APIKeyFromContext(ctx)
+	svc := GetAPIKeyService()
+	if key == nil || svc == nil {
+		return want, func(int) {}, nil
+	}
+	return svc.ReserveTransfers(ctx, key, want)
+}
+
+// List 获取密钥列表（附带今日用量）
+func (s *apiKeyService) List(ctx context.Context, page, pageSize int, keyword string) ([]*model.APIKey, int64, error) {
+	keys, total, err := s.keyRepo.List(ctx, page, pageSize, keyword)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.Flush(ctx); err != nil {
+		logger.Warn("写入API Key用量失败", zap.Error(err))
+	}
+
+	today := s.today()
+	for _, key := range keys {
+		usage, err := s.keyRepo.GetUsage(ctx, key.ID, today)
+		if err != nil {
+			return nil, 0, err
+		}
+		key.TodayRequests = usage.Requests
+		key.TodayTransfers = usage.Transfers
+	}
+	return keys, total, nil
+}
+
+// GetByID 根据ID获取密钥
+func (s *apiKeyService) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
+	return s.keyRepo.GetByID(ctx, id)
+}
+
+// Create 创建密钥
+func (s *apiKeyService) Create(ctx context.Context, key *model.APIKey) (string, error) {
+	raw, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	key.KeyPrefix = raw[:apiKeyDisplayLen]
+	key.KeyHash = hashAPIKey(raw)
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return "", err
+	}
+
+	logger.Info("🔑 创建API Key", zap.Int("id", key.ID), zap.String("name", key.Name))
+	return raw, nil
+}
+
+// Update 更新密钥配置
+func (s *apiKeyService) Update(ctx context.Context, key *model.APIKey) error {
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		return err
+	}
+	s.invalidate(key.ID)
+	return nil
+}
+
+// Delete 删除密钥及其用量记录
+func (s *apiKeyService) Delete(ctx context.Context, id int) error {
+	if err := s.keyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for k := range s.counters {
+		if k.id == id {
+			delete(s.counters, k)
+		}
+	}
+	s.mu.Unlock()
+
+	s.invalidate(id)
+	return nil
+}
+
+// Regenerate 重置密钥
+func (s *apiKeyService) Regenerate(ctx context.Context, id int) (string, error) {
+	if _, err := s.keyRepo.GetByID(ctx, id); err != nil {
+		return "", err
+	}
+	raw, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	if err := s.keyRepo.UpdateHash(ctx, id, raw[:apiKeyDisplayLen], hashAPIKey(raw)); err != nil {
+		return "", err
+	}
+	s.invalidate(id)
+
+	logger.Info("🔑 重置API Key", zap.Int("id", id))
+	return raw, nil
+}
+
+// Usage 获取最近days天的每日用量
+func (s *apiKeyService) Usage(ctx context.Context, id int, days int) ([]*model.APIKeyUsage, error) {
+	if days <= 0 {
+		days = 7
+	}
+	if err := s.Flush(ctx); err != nil {
+		logger.Warn("写入API Key用量失败", zap.Error(err))
+	}
+	from := s.now().AddDate(0, 0, -(days - 1)).Format(apiKeyDateLayout)
+	return s.keyRepo.ListUsage(ctx, id, from)
+}
+
+// Required 是否强制要求API Key（配置读取失败时按不强制处理）
+func (s *apiKeyService) Required(ctx context.Context) bool {
+	now := s.now()
+	s.mu.Lock()
+	if now.Before(s.requiredExpires) {
+		required := s.required
+		s.mu.Unlock()
+		return required
+	}
+	s.mu.Unlock()
+
+	value, err := s.configRepo.GetInt(ctx, model.ConfAPIKeyRequired)
+	required := err == nil && value == 1
+
+	s.mu.Lock()
+	s.required = required
+	s.requiredExpires = now.Add(apiKeyRequiredTTL)
+	s.mu.Unlock()
+	return required
+}
+
+// Authenticate 校验密钥明文
+func (s *apiKeyService) Authenticate(ctx context.Context, raw string) (*model.APIKey, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	hash := hashAPIKey(raw)
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+
+	key := cached.key
+	if !ok || now.After(cached.expires) {
+		var err error
+		key, err = s.keyRepo.GetByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, nil
+		}
+		s.mu.Lock()
+		s.cache[hash] = cachedAPIKey{key: key, expires: now.Add(apiKeyCacheTTL)}
+		s.mu.Unlock()
+	}
+
+	if !key.IsUsable(now) {
+		return nil, nil
+	}
+	return key, nil
+}
+
+// Allow 按每分钟请求数限制放行，限流器出错时放行
+func (s *apiKeyService) Allow(key *model.APIKey) bool {
+	if key.RateLimit <= 0 {
+		return true
+	}
+	result, err := s.limiter.Allow(context.Background(), "apikey:"+strconv.Itoa(key.ID), ratelimit.PerMinute(key.RateLimit))
+	if err != nil {
+		logger.Warn("API Key限流检查失败", zap.Int("key_id", key.ID), zap.Error(err))
+		return true
+	}
+	return result.Allowed
+}
+
+// RecordRequest 记录一次请求
+func (s *apiKeyService) RecordRequest(key *model.APIKey) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.counter(key.ID, now.Format(apiKeyDateLayout))
+	c.pendingRequests++
+	c.lastUsed = now.Unix()
+}
+
+// ReserveTransfers 预占转存额度，额度按密钥当日已成功转存数和未提交的预占数计算
+func (s *apiKeyService) ReserveTransfers(ctx context.Context, key *model.APIKey, want int) (int, func(used int), error) {
+	if !key.CanTransfer() {
+		return 0, nil, ErrAPIKeyTransferDenied
+	}
+	date := s.today()
+
+	grant := want
+	if key.DailyTransferQuota > 0 {
+		if err := s.loadTransfers(ctx, key.ID, date); err != nil {
+			return 0, nil, err
+		}
+
+		s.mu.Lock()
+		c := s.counter(key.ID, date)
+		remaining := int64(key.DailyTransferQuota) - c.storedTransfers - c.pendingTransfers - c.reserved
+		if remaining <= 0 {
+			s.mu.Unlock()
+			return 0, nil, ErrAPIKeyQuotaExceeded
+		}
+		if int64(grant) > remaining {
+			grant = int(remaining)
+		}
+		c.reserved += int64(grant)
+		s.mu.Unlock()
+	}
+
+	var once sync.Once
+	commit := func(used int) {
+		once.Do(func() {
+			if used < 0 {
+				used = 0
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			c := s.counter(key.ID, date)
+			if key.DailyTransferQuota > 0 {
+				c.reserved -= int64(grant)
+				if c.reserved < 0 {
+					c.reserved = 0
+				}
+			}
+			c.pendingTransfers += int64(used)
+		})
+	}
+	return grant, commit, nil
+}
+
+// loadTransfers 首次预占时从数据库加载当日转存数（服务重启后额度不会重置）
+func (s *apiKeyService) loadTransfers(ctx context.Context, id int, date string) error {
+	s.mu.Lock()
+	loaded := s.counter(id, date).loaded
+	s.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	usage, err := s.keyRepo.GetUsage(ctx, id, date)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.counter(id, date)
+	if !c.loaded {
+		c.loaded = true
+		c.storedTransfers = usage.Transfers
+	}
+	return nil
+}
+
+// Flush 将内存中的用量写入数据库，写入失败的部分保留到下次
+func (s *apiKeyService) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	type pending struct {
+		key                 apiKeyUsageKey
+		requests, transfers int64
+		lastUsed            int64
+	}
+
+	today := s.today()
+	s.mu.Lock()
+	var batch []pending
+	for k, c := range s.counters {
+		if c.pendingRequests > 0 || c.pendingTransfers > 0 {
+			batch = append(batch, pending{key: k, requests: c.pendingRequests, transfers: c.pendingTransfers, lastUsed: c.lastUsed})
+			c.storedTransfers += c.pendingTransfers
+			c.pendingRequests = 0
+			c.pendingTransfers = 0
+		} else if k.date != today && c.reserved == 0 {
+			delete(s.counters, k)
+		}
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, p := range batch {
+		if err := s.keyRepo.AddUsage(ctx, p.key.id, p.key.date, p.requests, p.transfers, p.lastUsed); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			s.mu.Lock()
+			c := s.counter(p.key.id, p.key.date)
+			c.storedTransfers -= p.transfers
+			c.pendingRequests += p.requests
+			c.pendingTransfers += p.transfers
+			s.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
+// Start 启动用量定期落库
+func (s *apiKeyService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.stopCh = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.flushLoop(s.stopCh)
+	logger.Info("✅ API Key服务已启动")
+}
+
+// Stop 停止服务并写入剩余用量
+func (s *apiKeyService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	if err := s.Flush(context.Background()); err != nil {
+		logger.Warn("写入API Key用量失败", zap.Error(err))
+	}
+	logger.Info("API Key服务已停止")
+}
+
+func (s *apiKeyService) flushLoop(stopCh chan struct{}) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(apiKeyFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				logger.Warn("写入API Key用量失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// counter 获取内存用量计数，调用方需持有s.mu
+func (s *apiKeyService) counter(id int, date string) *apiKeyCounter {
+	k := apiKeyUsageKey{id: id, date: date}
+	c, ok := s.counters[k]
+	if !ok {
+		c = &apiKeyCounter{}
+		s.counters[k] = c
+	}
+	return c
+}
+
+// invalidate 清除密钥的认证缓存
+func (s *apiKeyService) invalidate(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+func (s *apiKeyService) today() string {
+	return s.now().Format(apiKeyDateLayout)
+}
+
+// generateAPIKey 生成密钥明文（hx_ + 48位十六进制）
+func generateAPIKey() (string, error) {
+	token, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + token, nil
+}
+
+// hashAPIKey 密钥哈希（数据库只保存哈希）
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
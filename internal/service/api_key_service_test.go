@@ -0,0 +1,177 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/ratelimit"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func newTestAPIKeyService(keyRepo *repotest.APIKeyRepository, now *time.Time) *apiKeyService {
+	limiter := ratelimit.NewLocalLimiter()
+	limiter.Now = func() time.Time { return *now }
+	s := NewAPIKeyService(keyRepo, repotest.NewConfigRepository(nil), limiter).(*apiKeyService)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s := newTestAPIKeyService(repotest.NewAPIKeyRepository(), &now)
+
+	key := &model.APIKey{Name: "合作站点", Status: 1, AllowTransfer: 1}
+	raw, err := s.Create(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Authenticate(ctx, raw); got == nil || got.ID != key.ID {
+		t.Fatalf("Authenticate(有效密钥) = %v", got)
+	}
+	if got, _ := s.Authenticate(ctx, raw+"x"); got != nil {
+		t.Errorf("Authenticate(错误密钥) = %v, want nil", got)
+	}
+
+	// 修改配置后认证缓存立即失效
+	key.Status = 0
+	if err := s.Update(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Authenticate(ctx, raw); got != nil {
+		t.Errorf("Authenticate(已禁用) = %v, want nil", got)
+	}
+
+	key.Status = 1
+	key.ExpireTime = now.Add(-time.Second).Unix()
+	_ = s.Update(ctx, key)
+	if got, _ := s.Authenticate(ctx, raw); got != nil {
+		t.Errorf("Authenticate(已过期) = %v, want nil", got)
+	}
+
+	key.ExpireTime = 0
+	_ = s.Update(ctx, key)
+	newRaw, err := s.Regenerate(ctx, key.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Authenticate(ctx, raw); got != nil {
+		t.Errorf("Authenticate(重置前的密钥) = %v, want nil", got)
+	}
+	if got, _ := s.Authenticate(ctx, newRaw); got == nil {
+		t.Error("Authenticate(重置后的密钥) = nil")
+	}
+}
+
+func TestAPIKeyAllow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestAPIKeyService(repotest.NewAPIKeyRepository(), &now)
+	key := &model.APIKey{ID: 1, RateLimit: 2}
+
+	for i := 0; i < 2; i++ {
+		if !s.Allow(key) {
+			t.Fatalf("第%d次请求被限流", i+1)
+		}
+	}
+	if s.Allow(key) {
+		t.Error("超出每分钟限制仍放行")
+	}
+	if !s.Allow(&model.APIKey{ID: 2}) {
+		t.Error("未设置限流的密钥被限流")
+	}
+
+	// 配额按每分钟限制的速度持续恢复
+	now = now.Add(30 * time.Second)
+	if !s.Allow(key) {
+		t.Error("恢复1次配额后仍被限流")
+	}
+	if s.Allow(key) {
+		t.Error("只恢复1次配额却放行了2次")
+	}
+}
+
+func TestAPIKeyReserveTransfers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	keyRepo := repotest.NewAPIKeyRepository()
+	s := newTestAPIKeyService(keyRepo, &now)
+
+	key := &model.APIKey{ID: 1, AllowTransfer: 1, DailyTransferQuota: 5}
+	// 服务重启前当日已转存2条
+	_ = keyRepo.AddUsage(ctx, key.ID, s.today(), 0, 2, now.Unix())
+
+	grant, commit, err := s.ReserveTransfers(ctx, key, 2)
+	if err != nil || grant != 2 {
+		t.Fatalf("ReserveTransfers() = %d, %v, want 2", grant, err)
+	}
+
+	// 未提交的预占额度同样占用额度
+	grant2, commit2, err := s.ReserveTransfers(ctx, key, 2)
+	if err != nil || grant2 != 1 {
+		t.Fatalf("ReserveTransfers(并发) = %d, %v, want 1", grant2, err)
+	}
+	if _, _, err := s.ReserveTransfers(ctx, key, 1); !errors.Is(err, ErrAPIKeyQuotaExceeded) {
+		t.Fatalf("ReserveTransfers(额度用完) err = %v", err)
+	}
+
+	// 只成功1条，未使用的额度释放；重复提交不重复计数
+	commit(1)
+	commit(1)
+	commit2(0)
+	grant, _, err = s.ReserveTransfers(ctx, key, 10)
+	if err != nil || grant != 2 {
+		t.Fatalf("ReserveTransfers(释放后) = %d, %v, want 2", grant, err)
+	}
+
+	denied := &model.APIKey{ID: 2, AllowTransfer: 0}
+	if _, _, err := s.ReserveTransfers(ctx, denied, 1); !errors.Is(err, ErrAPIKeyTransferDenied) {
+		t.Errorf("ReserveTransfers(无转存权限) err = %v", err)
+	}
+
+	// 次日额度重新计算
+	now = now.Add(24 * time.Hour)
+	if grant, _, err := s.ReserveTransfers(ctx, key, 10); err != nil || grant != 5 {
+		t.Errorf("ReserveTransfers(次日) = %d, %v, want 5", grant, err)
+	}
+}
+
+func TestAPIKeyFlush(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	keyRepo := repotest.NewAPIKeyRepository(&model.APIKey{ID: 1, AllowTransfer: 1, DailyTransferQuota: 10})
+	s := newTestAPIKeyService(keyRepo, &now)
+	key, _ := keyRepo.GetByID(ctx, 1)
+
+	for i := 0; i < 3; i++ {
+		s.RecordRequest(key)
+	}
+	_, commit, _ := s.ReserveTransfers(ctx, key, 3)
+	commit(2)
+
+	// 写库失败时用量保留到下次
+	keyRepo.AddUsageErr = errors.New("db down")
+	if err := s.Flush(ctx); err == nil {
+		t.Fatal("Flush() 未返回写库错误")
+	}
+	keyRepo.AddUsageErr = nil
+	if err := s.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, _ := keyRepo.GetUsage(ctx, 1, s.today())
+	if usage.Requests != 3 || usage.Transfers != 2 {
+		t.Errorf("usage = %d/%d, want 3/2", usage.Requests, usage.Transfers)
+	}
+	stored, _ := keyRepo.GetByID(ctx, 1)
+	if stored.TotalRequests != 3 || stored.TotalTransfers != 2 || stored.LastUsedTime != now.Unix() {
+		t.Errorf("totals = %d/%d/%d", stored.TotalRequests, stored.TotalTransfers, stored.LastUsedTime)
+	}
+
+	// 已落库的转存数仍计入当日额度
+	if grant, _, err := s.ReserveTransfers(ctx, key, 10); err != nil || grant != 8 {
+		t.Errorf("ReserveTransfers(落库后) = %d, %v, want 8", grant, err)
+	}
+}
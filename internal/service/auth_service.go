@@ -3,6 +3,7 @@
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,30 +19,62 @@ var (
 	ErrUserNotFound      = errors.New("用户不存在")
 	ErrPasswordIncorrect = errors.New("密码错误")
 	ErrUserDisabled      = errors.New("用户已被禁用")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用（可能已泄露，该用户的全部令牌已吊销）
+	ErrRefreshTokenReused = errors.New("刷新令牌已失效")
+	// ErrAccountLocked 连续登录失败次数过多，账号被临时锁定
+	ErrAccountLocked = errors.New("登录失败次数过多，账号已临时锁定")
+)
+
+const (
+	// loginMaxFailures 连续登录失败（密码或两步验证码错误）达到该次数后锁定账号
+	loginMaxFailures = 5
+	// loginLockDuration 账号锁定时长
+	loginLockDuration = 15 * time.Minute
 )
 
 // AuthService 认证服务接口
 type AuthService interface {
+	// Login 密码登录；账号启用两步验证时返回 MFARequired 和 MFAToken，需调用 LoginMFA 完成登录
 	Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error)
+	// LoginMFA 使用两步验证码或恢复码完成登录
+	LoginMFA(ctx context.Context, req *model.MFALoginRequest) (*model.LoginResponse, error)
 	Register(ctx context.Context, user *model.User) error
-	RefreshToken(ctx context.Context, token string) (string, error)
+	// RefreshToken 用刷新令牌换取新的访问令牌和刷新令牌（旧刷新令牌随即失效）
+	RefreshToken(ctx context.Context, refreshToken string) (*model.LoginResponse, error)
 	GetUserByToken(ctx context.Context, token string) (*model.User, error)
+	// Logout 吊销当前访问令牌及对应的刷新令牌
+	Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error
+	// RevokeUserTokens 吊销用户已签发的全部令牌（退出所有设备、重置密码、禁用账号）
+	RevokeUserTokens(ctx context.Context, userID int64) error
+	// UnlockUser 解除账号的登录锁定
+	UnlockUser(ctx context.Context, userID int64) error
+
+	// TOTPStatus 获取两步验证状态
+	TOTPStatus(ctx context.Context, userID int64) (*model.TOTPStatus, error)
+	// SetupTOTP 生成新的两步验证密钥（验证通过前不生效）
+	SetupTOTP(ctx context.Context, userID int64) (*model.TOTPSetupResponse, error)
+	// EnableTOTP 校验验证码后启用两步验证，返回一次性恢复码
+	EnableTOTP(ctx context.Context, userID int64, code string) ([]string, error)
+	// DisableTOTP 校验密码和验证码（或恢复码）后关闭两步验证
+	DisableTOTP(ctx context.Context, userID int64, password, code string) error
+	// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧恢复码全部失效
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+	// ResetTOTP 清除账号的两步验证（超级管理员为丢失验证器的账号重置）
+	ResetTOTP(ctx context.Context, userID int64) error
 }
 
 type authService struct {
 	userRepo   repository.UserRepository
+	tokenRepo  repository.TokenRepository
 	jwtService *jwt.JWTService
 }
 
 // NewAuthService 创建认证服务
 func NewAuthService(cfg *config.Config) AuthService {
-	expiration := cfg.JWT.Expiration
-	if expiration == 0 {
-		expiration = cfg.JWT.ExpireHours
-	}
 	return &authService{
 		userRepo:   repository.NewUserRepository(),
-		jwtService: jwt.NewJWTService(cfg.JWT.Secret, expiration),
+		tokenRepo:  repository.NewTokenRepository(),
+		jwtService: jwt.NewFromConfig(cfg.JWT),
 	}
 }
 
@@ -57,6 +90,11 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		return nil, ErrUserNotFound
 	}
 
+	// 锁定期间不再校验密码，避免继续暴力尝试
+	if user.IsLocked(time.Now()) {
+		return nil, lockedError(user.LockedUntil)
+	}
+
 	// 检查用户状态
 	if !user.IsActive() {
 		return nil, ErrUserDisabled
@@ -64,29 +102,91 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 
 	// 验证密码
 	if !s.verifyPassword(req.Password, user.Password) {
-		return nil, ErrPasswordIncorrect
+		return nil, s.recordLoginFailure(ctx, user, ErrPasswordIncorrect)
+	}
+
+	// 已启用两步验证：签发短期登录凭据，等待验证码
+	if user.IsTOTPEnabled() {
+		return s.startMFAChallenge(ctx, user)
 	}
 
+	return s.completeLogin(ctx, user, req.ClientIP)
+}
+
+// completeLogin 签发令牌并记录登录时间、IP
+func (s *authService) completeLogin(ctx context.Context, user *model.User, clientIP string) (*model.LoginResponse, error) {
 	// 生成token
-	token, err := s.jwtService.GenerateToken(int64(user.AdminID), user.Username, 0) // 管理员角色固定为0
+	resp, err := s.issueTokens(user)
 	if err != nil {
 		logger.Error("生成token失败", zap.Error(err))
 		return nil, err
 	}
 
-	// 更新最后登录时间
-	if err := s.userRepo.UpdateLastLogin(ctx, uint64(user.AdminID)); err != nil {
+	// 更新最后登录时间和IP，清除失败计数
+	if err := s.userRepo.UpdateLastLogin(ctx, uint64(user.AdminID), clientIP); err != nil {
 		logger.Warn("更新最后登录时间失败", zap.Error(err))
 	}
 
 	logger.Info("用户登录成功",
 		zap.String("username", user.Username),
 		zap.Uint("admin_id", user.AdminID),
+		zap.String("ip", clientIP),
 	)
 
+	return resp, nil
+}
+
+// recordLoginFailure 累加连续失败次数，达到上限时锁定账号并返回锁定错误，否则返回cause
+func (s *authService) recordLoginFailure(ctx context.Context, user *model.User, cause error) error {
+	count, err := s.userRepo.IncrLoginFailCount(ctx, uint64(user.AdminID))
+	if err != nil {
+		logger.Warn("记录登录失败次数失败", zap.Uint("admin_id", user.AdminID), zap.Error(err))
+		return cause
+	}
+	if count < loginMaxFailures {
+		return cause
+	}
+
+	lockedUntil := time.Now().Add(loginLockDuration).Unix()
+	if err := s.userRepo.LockUntil(ctx, uint64(user.AdminID), lockedUntil); err != nil {
+		logger.Error("锁定账号失败", zap.Uint("admin_id", user.AdminID), zap.Error(err))
+		return cause
+	}
+	logger.Warn("🔒 连续登录失败次数过多，账号已锁定",
+		zap.String("username", user.Username),
+		zap.Uint("admin_id", user.AdminID),
+		zap.Int("failures", count),
+		zap.Int64("locked_until", lockedUntil),
+	)
+	return lockedError(lockedUntil)
+}
+
+// UnlockUser 解除账号的登录锁定
+func (s *authService) UnlockUser(ctx context.Context, userID int64) error {
+	return s.userRepo.LockUntil(ctx, uint64(userID), 0)
+}
+
+// lockedError 带剩余锁定时间的锁定错误
+func lockedError(lockedUntil int64) error {
+	minutes := (time.Until(time.Unix(lockedUntil, 0)) + time.Minute - 1) / time.Minute
+	if minutes < 1 {
+		minutes = 1
+	}
+	return fmt.Errorf("%w，请%d分钟后重试", ErrAccountLocked, minutes)
+}
+
+// issueTokens 为用户签发访问令牌和刷新令牌
+func (s *authService) issueTokens(user *model.User) (*model.LoginResponse, error) {
+	pair, err := s.jwtService.GenerateTokenPair(int64(user.AdminID), user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
 	return &model.LoginResponse{
-		Token:    token,
-		UserInfo: user,
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt.Unix(),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt.Unix(),
+		UserInfo:         user,
 	}, nil
 }
 
@@ -107,6 +207,8 @@ func (s *authService) Register(ctx context.Context, user *model.User) error {
 		return err
 	}
 	user.Password = string(hashedPassword)
+	// 注册账号一律为只读审计，角色只能由超级管理员在管理员管理中调整
+	user.Role = model.RoleAuditor
 	user.CreateTime = time.Now().Unix()
 	user.UpdateTime = time.Now().Unix()
 
@@ -124,13 +226,81 @@ func (s *authService) Register(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-// RefreshToken 刷新token
-func (s *authService) RefreshToken(ctx context.Context, token string) (string, error) {
-	newToken, err := s.jwtService.RefreshToken(token)
+// RefreshToken 轮换刷新令牌
+// 每个刷新令牌只能使用一次；已轮换的刷新令牌再次出现说明可能已泄露，此时吊销该用户的全部令牌
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*model.LoginResponse, error) {
+	claims, err := s.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jwt.CheckRevoked(ctx, s.tokenRepo, claims); err != nil {
+		if errors.Is(err, jwt.ErrTokenRevoked) {
+			return nil, s.refreshTokenReused(ctx, claims)
+		}
+		return nil, err
+	}
+
+	// 账号被删除或禁用后不再续期
+	user, err := s.userRepo.GetByID(ctx, uint64(claims.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.IsActive() {
+		return nil, ErrUserDisabled
+	}
+
+	// 吊销与检查合为一步：并发使用同一刷新令牌时只有一个请求能完成轮换
+	revoked, err := s.tokenRepo.TryRevokeToken(ctx, claims.ID, claims.RemainingTTL())
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, s.refreshTokenReused(ctx, claims)
+	}
+	return s.issueTokens(user)
+}
+
+// refreshTokenReused 已轮换的刷新令牌再次使用，吊销该用户的全部令牌
+func (s *authService) refreshTokenReused(ctx context.Context, claims *jwt.Claims) error {
+	logger.Warn("⚠️ 检测到刷新令牌重复使用，已吊销该用户全部令牌",
+		zap.Int64("user_id", claims.UserID),
+		zap.String("jti", claims.ID),
+	)
+	if err := s.RevokeUserTokens(ctx, claims.UserID); err != nil {
+		logger.Error("吊销用户令牌失败", zap.Error(err))
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout 吊销当前访问令牌，refreshToken属于同一用户时一并吊销
+func (s *authService) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	if claims != nil {
+		if err := s.tokenRepo.RevokeToken(ctx, claims.ID, claims.RemainingTTL()); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	refreshClaims, err := s.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
-		return "", err
+		// 刷新令牌已过期或无效，无需吊销
+		return nil
+	}
+	if claims != nil && refreshClaims.UserID != claims.UserID {
+		return nil
 	}
-	return newToken, nil
+	return s.tokenRepo.RevokeToken(ctx, refreshClaims.ID, refreshClaims.RemainingTTL())
+}
+
+// RevokeUserTokens 吊销用户已签发的全部令牌
+func (s *authService) RevokeUserTokens(ctx context.Context, userID int64) error {
+	return s.tokenRepo.RevokeUserTokens(ctx, userID, s.jwtService.RefreshExpiration())
 }
 
 // GetUserByToken 根据token获取用户信息
@@ -139,6 +309,9 @@ func (s *authService) GetUserByToken(ctx context.Context, token string) (*model.
 	if err != nil {
 		return nil, err
 	}
+	if err := jwt.CheckRevoked(ctx, s.tokenRepo, claims); err != nil {
+		return nil, err
+	}
 
 	user, err := s.userRepo.GetByID(ctx, uint64(claims.UserID))
 	if err != nil {
@@ -0,0 +1,335 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/jwt"
+	"huoxing-search/internal/pkg/totp"
+	"huoxing-search/internal/repository"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func newTestAuthService(t *testing.T, users ...*model.User) (*authService, *repotest.UserRepository) {
+	for _, user := range users {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Password = string(hashed)
+	}
+	userRepo := repotest.NewUserRepository(users...)
+	return &authService{
+		userRepo:   userRepo,
+		tokenRepo:  repository.NewTokenRepository(),
+		jwtService: jwt.NewFromConfig(config.JWTConfig{Secret: "test-secret", Expiration: 24}),
+	}, userRepo
+}
+
+func TestAuthRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 101, Username: "rotate", Password: "123456", Status: 1})
+
+	login, err := s.Login(ctx, &model.LoginRequest{Username: "rotate", Password: "123456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if login.RefreshToken == "" || login.ExpiresAt >= login.RefreshExpiresAt {
+		t.Fatalf("Login() = %+v, want refresh token outliving access token", login)
+	}
+
+	refreshed, err := s.RefreshToken(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("RefreshToken() 应返回新的刷新令牌")
+	}
+
+	// 旧刷新令牌再次使用：拒绝并吊销该用户全部令牌
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken(旧令牌) error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.RefreshToken(ctx, refreshed.RefreshToken); !errors.Is(err, jwt.ErrUserTokensRevoked) {
+		t.Errorf("RefreshToken(轮换后的令牌) error = %v, want ErrUserTokensRevoked", err)
+	}
+	if _, err := s.GetUserByToken(ctx, refreshed.Token); !errors.Is(err, jwt.ErrUserTokensRevoked) {
+		t.Errorf("GetUserByToken() error = %v, want ErrUserTokensRevoked", err)
+	}
+}
+
+func TestAuthRefreshTokenConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 103, Username: "race", Password: "123456", Status: 1})
+
+	login, err := s.Login(ctx, &model.LoginRequest{Username: "race", Password: "123456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	// 同一刷新令牌并发轮换：只能有一个请求成功，其余视为重复使用
+	const n = 8
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+		reused    atomic.Int32
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := s.RefreshToken(ctx, login.RefreshToken)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, ErrRefreshTokenReused), errors.Is(err, jwt.ErrUserTokensRevoked):
+				reused.Add(1)
+			default:
+				t.Errorf("RefreshToken() error = %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if succeeded.Load() != 1 || reused.Load() != n-1 {
+		t.Fatalf("succeeded = %d, reused = %d, want 1 and %d", succeeded.Load(), reused.Load(), n-1)
+	}
+	if _, err := s.GetUserByToken(ctx, login.Token); !errors.Is(err, jwt.ErrUserTokensRevoked) {
+		t.Errorf("GetUserByToken() error = %v, want ErrUserTokensRevoked", err)
+	}
+}
+
+func TestAuthRefreshTokenDisabledUser(t *testing.T) {
+	ctx := context.Background()
+	s, userRepo := newTestAuthService(t, &model.User{AdminID: 102, Username: "disabled", Password: "123456", Status: 1})
+
+	login, err := s.Login(ctx, &model.LoginRequest{Username: "disabled", Password: "123456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	user, _ := userRepo.GetByID(ctx, 102)
+	user.Status = 0
+	_ = userRepo.Update(ctx, user)
+
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("RefreshToken() error = %v, want ErrUserDisabled", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.Token); !errors.Is(err, jwt.ErrTokenType) {
+		t.Errorf("RefreshToken(访问令牌) error = %v, want ErrTokenType", err)
+	}
+}
+
+func TestAuthLogout(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 103, Username: "logout", Password: "123456", Status: 1})
+
+	login, err := s.Login(ctx, &model.LoginRequest{Username: "logout", Password: "123456"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := s.jwtService.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Logout(ctx, claims, login.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := s.GetUserByToken(ctx, login.Token); !errors.Is(err, jwt.ErrTokenRevoked) {
+		t.Errorf("GetUserByToken() error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := s.RefreshToken(ctx, login.RefreshToken); err == nil {
+		t.Error("RefreshToken() 登出后的刷新令牌应失效")
+	}
+}
+
+func TestAuthLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	s, userRepo := newTestAuthService(t, &model.User{AdminID: 104, Username: "lockout", Password: "123456", Status: 1})
+
+	for i := 1; i < loginMaxFailures; i++ {
+		if _, err := s.Login(ctx, &model.LoginRequest{Username: "lockout", Password: "wrong"}); !errors.Is(err, ErrPasswordIncorrect) {
+			t.Fatalf("第%d次 Login() error = %v, want ErrPasswordIncorrect", i, err)
+		}
+	}
+	if _, err := s.Login(ctx, &model.LoginRequest{Username: "lockout", Password: "wrong"}); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Login() error = %v, want ErrAccountLocked", err)
+	}
+
+	// 锁定期间正确密码也无法登录
+	if _, err := s.Login(ctx, &model.LoginRequest{Username: "lockout", Password: "123456"}); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Login(正确密码) error = %v, want ErrAccountLocked", err)
+	}
+
+	if err := s.UnlockUser(ctx, 104); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Login(ctx, &model.LoginRequest{Username: "lockout", Password: "123456", ClientIP: "10.0.0.8"}); err != nil {
+		t.Fatalf("Login() 解锁后 error = %v", err)
+	}
+	user, _ := userRepo.GetByID(ctx, 104)
+	if user.LastLoginIP != "10.0.0.8" || user.LoginFailCount != 0 || user.LockedUntil != 0 {
+		t.Errorf("登录成功后 LastLoginIP/LoginFailCount/LockedUntil = %s/%d/%d", user.LastLoginIP, user.LoginFailCount, user.LockedUntil)
+	}
+}
+
+func TestAuthTOTPLogin(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 105, Username: "totp", Password: "123456", Status: 1})
+
+	setup, err := s.SetupTOTP(ctx, 105)
+	if err != nil {
+		t.Fatalf("SetupTOTP() error = %v", err)
+	}
+	if _, err := s.EnableTOTP(ctx, 105, "000000"); !errors.Is(err, ErrTOTPCodeIncorrect) {
+		t.Fatalf("EnableTOTP(错误验证码) error = %v, want ErrTOTPCodeIncorrect", err)
+	}
+	code, _ := totp.GenerateCode(setup.Secret, totp.Step(time.Now()))
+	recoveryCodes, err := s.EnableTOTP(ctx, 105, code)
+	if err != nil {
+		t.Fatalf("EnableTOTP() error = %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("len(recoveryCodes) = %d, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	login := func() *model.LoginResponse {
+		resp, err := s.Login(ctx, &model.LoginRequest{Username: "totp", Password: "123456"})
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		if !resp.MFARequired || resp.MFAToken == "" || resp.Token != "" {
+			t.Fatalf("Login() = %+v, want 仅返回两步验证凭据", resp)
+		}
+		return resp
+	}
+
+	// 启用时使用过的验证码不能再次使用
+	challenge := login()
+	if _, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: code}); !errors.Is(err, ErrTOTPCodeIncorrect) {
+		t.Fatalf("LoginMFA(已使用的验证码) error = %v, want ErrTOTPCodeIncorrect", err)
+	}
+
+	// 恢复码只能使用一次，不区分大小写
+	resp, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: strings.ToUpper(recoveryCodes[0])})
+	if err != nil || resp.Token == "" {
+		t.Fatalf("LoginMFA(恢复码) = %+v, %v", resp, err)
+	}
+	if _, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[1]}); !errors.Is(err, ErrMFATokenInvalid) {
+		t.Errorf("LoginMFA(已使用的凭据) error = %v, want ErrMFATokenInvalid", err)
+	}
+	if _, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: login().MFAToken, Code: recoveryCodes[0]}); !errors.Is(err, ErrTOTPCodeIncorrect) {
+		t.Errorf("LoginMFA(已使用的恢复码) error = %v, want ErrTOTPCodeIncorrect", err)
+	}
+
+	status, err := s.TOTPStatus(ctx, 105)
+	if err != nil || !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Errorf("TOTPStatus() = %+v, %v", status, err)
+	}
+
+	// 验证码错误计入连续失败次数（上一步已失败一次）
+	challenge = login()
+	var lastErr error
+	for i := 1; i < loginMaxFailures; i++ {
+		_, lastErr = s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: "zzzz-zzzz"})
+	}
+	if !errors.Is(lastErr, ErrAccountLocked) {
+		t.Fatalf("LoginMFA() error = %v, want ErrAccountLocked", lastErr)
+	}
+	if _, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[2]}); !errors.Is(err, ErrMFATokenInvalid) {
+		t.Errorf("LoginMFA(锁定后) error = %v, want ErrMFATokenInvalid", err)
+	}
+}
+
+func TestAuthTOTPConcurrentReplay(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 107, Username: "replay", Password: "123456", Status: 1})
+
+	setup, err := s.SetupTOTP(ctx, 107)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, _ := totp.GenerateCode(setup.Secret, totp.Step(now))
+	if _, err := s.EnableTOTP(ctx, 107, code); err != nil {
+		t.Fatal(err)
+	}
+
+	// 下一个时间步的验证码尚未使用，多个登录请求同时提交：只能有一个成功
+	const n = loginMaxFailures - 1
+	nextCode, _ := totp.GenerateCode(setup.Secret, totp.Step(now)+1)
+	challenges := make([]string, n)
+	for i := range challenges {
+		resp, err := s.Login(ctx, &model.LoginRequest{Username: "replay", Password: "123456"})
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		challenges[i] = resp.MFAToken
+	}
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+	start := make(chan struct{})
+	for _, mfaToken := range challenges {
+		wg.Add(1)
+		go func(mfaToken string) {
+			defer wg.Done()
+			<-start
+			_, err := s.LoginMFA(ctx, &model.MFALoginRequest{MFAToken: mfaToken, Code: nextCode})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case !errors.Is(err, ErrTOTPCodeIncorrect):
+				t.Errorf("LoginMFA() error = %v, want ErrTOTPCodeIncorrect", err)
+			}
+		}(mfaToken)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := succeeded.Load(); got != 1 {
+		t.Fatalf("LoginMFA() succeeded %d times, want 1", got)
+	}
+
+}
+
+func TestAuthDisableTOTP(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestAuthService(t, &model.User{AdminID: 106, Username: "disable2fa", Password: "123456", Status: 1})
+
+	setup, err := s.SetupTOTP(ctx, 106)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := totp.GenerateCode(setup.Secret, totp.Step(time.Now()))
+	recoveryCodes, err := s.EnableTOTP(ctx, 106, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DisableTOTP(ctx, 106, "wrong", recoveryCodes[0]); !errors.Is(err, ErrPasswordIncorrect) {
+		t.Errorf("DisableTOTP(错误密码) error = %v, want ErrPasswordIncorrect", err)
+	}
+	if err := s.DisableTOTP(ctx, 106, "123456", recoveryCodes[0]); err != nil {
+		t.Fatalf("DisableTOTP() error = %v", err)
+	}
+
+	resp, err := s.Login(ctx, &model.LoginRequest{Username: "disable2fa", Password: "123456"})
+	if err != nil || resp.MFARequired || resp.Token == "" {
+		t.Errorf("Login() 关闭两步验证后 = %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,320 @@
+﻿package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/pkg/totp"
+)
+
+var (
+	// ErrMFATokenInvalid 两步验证登录凭据不存在或已过期
+	ErrMFATokenInvalid = errors.New("两步验证已过期，请重新登录")
+	// ErrTOTPCodeIncorrect 验证码或恢复码错误（含重复使用的验证码）
+	ErrTOTPCodeIncorrect = errors.New("验证码错误")
+	// ErrTOTPAlreadyEnabled 已启用两步验证
+	ErrTOTPAlreadyEnabled = errors.New("已启用两步验证")
+	// ErrTOTPNotEnabled 未启用两步验证
+	ErrTOTPNotEnabled = errors.New("未启用两步验证")
+	// ErrTOTPNotSetup 尚未生成两步验证密钥
+	ErrTOTPNotSetup = errors.New("请先获取两步验证密钥")
+)
+
+const (
+	// totpIssuer 验证器中显示的发行方名称
+	totpIssuer = "火星搜索"
+	// mfaChallengeTTL 密码校验通过后完成两步验证的时限
+	mfaChallengeTTL = 5 * time.Minute
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// startMFAChallenge 密码校验通过后签发两步验证登录凭据
+func (s *authService) startMFAChallenge(ctx context.Context, user *model.User) (*model.LoginResponse, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.SaveMFAChallenge(ctx, token, int64(user.AdminID), mfaChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	logger.Info("用户密码校验通过，等待两步验证", zap.String("username", user.Username))
+	return &model.LoginResponse{
+		MFARequired:  true,
+		MFAToken:     token,
+		MFAExpiresAt: time.Now().Add(mfaChallengeTTL).Unix(),
+	}, nil
+}
+
+// LoginMFA 校验两步验证码或恢复码后完成登录，验证码错误计入连续登录失败次数
+func (s *authService) LoginMFA(ctx context.Context, req *model.MFALoginRequest) (*model.LoginResponse, error) {
+	userID, ok := s.tokenRepo.GetMFAChallenge(ctx, req.MFAToken)
+	if !ok {
+		return nil, ErrMFATokenInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil || user == nil {
+		return nil, ErrMFATokenInvalid
+	}
+	if user.IsLocked(time.Now()) {
+		_ = s.tokenRepo.DeleteMFAChallenge(ctx, req.MFAToken)
+		return nil, lockedError(user.LockedUntil)
+	}
+	if !user.IsActive() {
+		return nil, ErrUserDisabled
+	}
+
+	// 等待验证期间两步验证被重置时，密码校验结果仍然有效
+	if user.IsTOTPEnabled() {
+		if err := s.verifySecondFactor(ctx, user, req.Code); err != nil {
+			err = s.recordLoginFailure(ctx, user, err)
+			if errors.Is(err, ErrAccountLocked) {
+				_ = s.tokenRepo.DeleteMFAChallenge(ctx, req.MFAToken)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.tokenRepo.DeleteMFAChallenge(ctx, req.MFAToken); err != nil {
+		logger.Warn("删除两步验证登录凭据失败", zap.Error(err))
+	}
+	return s.completeLogin(ctx, user, req.ClientIP)
+}
+
+// TOTPStatus 获取两步验证状态
+func (s *authService) TOTPStatus(ctx context.Context, userID int64) (*model.TOTPStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	status := &model.TOTPStatus{Enabled: user.IsTOTPEnabled()}
+	if status.Enabled {
+		status.RecoveryCodesRemaining = len(decodeRecoveryCodes(user.RecoveryCodes))
+	}
+	return status, nil
+}
+
+// SetupTOTP 生成新的两步验证密钥，启用前重复调用会覆盖未启用的密钥
+func (s *authService) SetupTOTP(ctx context.Context, userID int64) (*model.TOTPSetupResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, uint64(userID), secret, 0, ""); err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPSetupResponse{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// EnableTOTP 校验验证器生成的验证码后启用两步验证
+func (s *authService) EnableTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotSetup
+	}
+	if err := s.verifyTOTPCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, uint64(userID), user.TOTPSecret, 1, hashed); err != nil {
+		return nil, err
+	}
+
+	logger.Info("🔐 管理员已启用两步验证", zap.String("username", user.Username))
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证
+func (s *authService) DisableTOTP(ctx context.Context, userID int64, password, code string) error {
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return err
+	}
+	if !user.IsTOTPEnabled() {
+		return ErrTOTPNotEnabled
+	}
+	if !s.verifyPassword(password, user.Password) {
+		return ErrPasswordIncorrect
+	}
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTOTP(ctx, uint64(userID), "", 0, ""); err != nil {
+		return err
+	}
+	logger.Info("管理员已关闭两步验证", zap.String("username", user.Username))
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, uint64(userID))
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err := s.verifyTOTPCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, uint64(userID), user.TOTPSecret, 1, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTOTP 清除两步验证配置
+func (s *authService) ResetTOTP(ctx context.Context, userID int64) error {
+	return s.userRepo.UpdateTOTP(ctx, uint64(userID), "", 0, "")
+}
+
+// verifySecondFactor 校验6位验证码，其他格式按恢复码处理（恢复码使用后即失效）
+func (s *authService) verifySecondFactor(ctx context.Context, user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.verifyTOTPCode(ctx, user, code)
+	}
+	return s.useRecoveryCode(ctx, user, code)
+}
+
+// verifyTOTPCode 校验验证码，同一验证码在有效期内只能使用一次
+func (s *authService) verifyTOTPCode(ctx context.Context, user *model.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrTOTPCodeIncorrect
+	}
+
+	ttl := time.Duration(2*totp.Skew+1) * totp.Period * time.Second
+	fresh, err := s.tokenRepo.MarkTOTPUsed(ctx, int64(user.AdminID), step, ttl)
+	if err != nil {
+		logger.Warn("记录验证码使用状态失败", zap.Error(err))
+		return nil
+	}
+	if !fresh {
+		return ErrTOTPCodeIncorrect
+	}
+	return nil
+}
+
+// useRecoveryCode 校验并消耗一个恢复码
+func (s *authService) useRecoveryCode(ctx context.Context, user *model.User, code string) error {
+	hash := hashRecoveryCode(code)
+	hashes := decodeRecoveryCodes(user.RecoveryCodes)
+
+	remaining := make([]string, 0, len(hashes))
+	matched := false
+	for _, h := range hashes {
+		if !matched && h == hash {
+			matched = true
+			continue
+		}
+		remaining = append(remaining, h)
+	}
+	if !matched {
+		return ErrTOTPCodeIncorrect
+	}
+
+	replaced, err := s.userRepo.ReplaceRecoveryCodes(ctx, uint64(user.AdminID), user.RecoveryCodes, encodeRecoveryCodes(remaining))
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		// 恢复码已被并发请求使用
+		return ErrTOTPCodeIncorrect
+	}
+
+	logger.Warn("⚠️ 管理员使用恢复码通过两步验证",
+		zap.String("username", user.Username),
+		zap.Int("remaining", len(remaining)),
+	)
+	return nil
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文（仅展示一次）和哈希后的存储值
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, encodeRecoveryCodes(hashes), nil
+}
+
+// hashRecoveryCode 恢复码哈希（忽略大小写、空格和连字符）
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeRecoveryCodes(hashes []string) string {
+	if len(hashes) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(hashes)
+	return string(data)
+}
+
+func decodeRecoveryCodes(value string) []string {
+	var hashes []string
+	if value == "" || json.Unmarshal([]byte(value), &hashes) != nil {
+		return nil
+	}
+	return hashes
+}
+
+// randomToken 生成n字节随机数的十六进制字符串
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
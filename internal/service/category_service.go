@@ -0,0 +1,318 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	// categoryRulesTTL 分类规则缓存时间（分类变更时主动失效）
+	categoryRulesTTL = 5 * time.Minute
+	// categoryReclassifyBatchSize 重新分类时每批读取的资源数量
+	categoryReclassifyBatchSize = 500
+	// categoryRegexPrefix 正则规则前缀
+	categoryRegexPrefix = "re:"
+)
+
+// ErrReclassifyRunning 已有重新分类任务在执行
+var ErrReclassifyRunning = errors.New("重新分类任务正在执行中")
+
+// CategoryService 资源自动分类服务接口
+type CategoryService interface {
+	// Classify 按分类规则匹配标题，返回分类ID，未命中返回0
+	Classify(ctx context.Context, title string) int
+	// ClassifySources 为未分类的资源分配分类（CategoryID为0的资源）
+	ClassifySources(ctx context.Context, sources ...*model.Source)
+	// InvalidateRules 清除规则缓存（分类增删改后调用）
+	InvalidateRules()
+	// StartReclassify 在后台按当前规则重新分类全部资源
+	StartReclassify(onlyUncategorized bool) (model.CategoryReclassifyProgress, error)
+	// ReclassifyProgress 获取最近一次重新分类任务的进度
+	ReclassifyProgress() model.CategoryReclassifyProgress
+}
+
+// categoryRule 单个分类的匹配规则
+type categoryRule struct {
+	categoryID int
+	keywords   []string // 小写关键词，包含即命中
+	patterns   []*regexp.Regexp
+}
+
+func (r *categoryRule) match(title, lowerTitle string) bool {
+	for _, keyword := range r.keywords {
+		if strings.Contains(lowerTitle, keyword) {
+			return true
+		}
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+type categoryService struct {
+	categoryRepo repository.CategoryRepository
+	sourceRepo   repository.SourceRepository
+
+	rulesMu       sync.Mutex
+	rules         []*categoryRule
+	rulesLoadedAt time.Time
+
+	progressMu sync.Mutex
+	progress   model.CategoryReclassifyProgress
+}
+
+// NewCategoryService 创建资源自动分类服务
+func NewCategoryService(categoryRepo repository.CategoryRepository, sourceRepo repository.SourceRepository) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		sourceRepo:   sourceRepo,
+	}
+}
+
+var (
+	globalCategoryService CategoryService
+	globalCategoryMu      sync.Mutex
+)
+
+// StartCategoryService 启动全局自动分类服务（重复调用只启动一次）
+func StartCategoryService() CategoryService {
+	globalCategoryMu.Lock()
+	defer globalCategoryMu.Unlock()
+
+	if globalCategoryService == nil {
+		globalCategoryService = NewCategoryService(repository.NewCategoryRepository(), repository.NewSourceRepository())
+	}
+	return globalCategoryService
+}
+
+// GetCategoryService 获取全局自动分类服务，未启动时返回nil
+func GetCategoryService() CategoryService {
+	globalCategoryMu.Lock()
+	defer globalCategoryMu.Unlock()
+	return globalCategoryService
+}
+
+// Classify 按排序值依次匹配启用分类的规则，返回第一个命中的分类ID
+func (s *categoryService) Classify(ctx context.Context, title string) int {
+	return classifyTitle(s.loadRules(ctx), title)
+}
+
+// ClassifySources 为未分类的资源分配分类，已指定分类的资源保持不变
+func (s *categoryService) ClassifySources(ctx context.Context, sources ...*model.Source) {
+	rules := s.loadRules(ctx)
+	if len(rules) == 0 {
+		return
+	}
+	for _, source := range sources {
+		if source != nil && source.CategoryID == 0 {
+			source.CategoryID = classifyTitle(rules, source.Title)
+		}
+	}
+}
+
+// InvalidateRules 清除规则缓存，下次分类时重新加载
+func (s *categoryService) InvalidateRules() {
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+	s.rules = nil
+	s.rulesLoadedAt = time.Time{}
+}
+
+// loadRules 获取分类规则（带缓存），加载失败时沿用旧规则
+func (s *categoryService) loadRules(ctx context.Context) []*categoryRule {
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+
+	if !s.rulesLoadedAt.IsZero() && time.Since(s.rulesLoadedAt) < categoryRulesTTL {
+		return s.rules
+	}
+
+	categories, err := s.categoryRepo.ListEnabled(ctx)
+	if err != nil {
+		logger.Warn("加载分类规则失败", zap.Error(err))
+		return s.rules
+	}
+
+	rules := make([]*categoryRule, 0, len(categories))
+	for _, category := range categories {
+		if rule := parseCategoryRule(category); rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	s.rules = rules
+	s.rulesLoadedAt = time.Now()
+	return rules
+}
+
+// parseCategoryRule 解析分类关键词规则
+// 每行以 re: 开头的为正则表达式（不区分大小写），其余行按逗号、竖线分隔为关键词
+func parseCategoryRule(category *model.Category) *categoryRule {
+	rule := &categoryRule{categoryID: category.CategoryID}
+
+	for _, line := range strings.Split(category.Keyword, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, categoryRegexPrefix) {
+			expr := strings.TrimSpace(strings.TrimPrefix(line, categoryRegexPrefix))
+			pattern, err := regexp.Compile("(?i)" + expr)
+			if err != nil {
+				logger.Warn("分类正则规则无效，已忽略",
+					zap.Int("category_id", category.CategoryID),
+					zap.String("pattern", expr),
+					zap.Error(err),
+				)
+				continue
+			}
+			rule.patterns = append(rule.patterns, pattern)
+			continue
+		}
+
+		keywords := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == '，' || r == '|' || r == '、'
+		})
+		for _, keyword := range keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				rule.keywords = append(rule.keywords, keyword)
+			}
+		}
+	}
+
+	if len(rule.keywords) == 0 && len(rule.patterns) == 0 {
+		return nil
+	}
+	return rule
+}
+
+// ValidateCategoryRule 校验分类规则中的正则表达式
+func ValidateCategoryRule(keyword string) error {
+	for _, line := range strings.Split(keyword, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, categoryRegexPrefix) {
+			continue
+		}
+		expr := strings.TrimSpace(strings.TrimPrefix(line, categoryRegexPrefix))
+		if _, err := regexp.Compile("(?i)" + expr); err != nil {
+			return fmt.Errorf("正则规则 %q 无效: %w", expr, err)
+		}
+	}
+	return nil
+}
+
+// classifyTitle 返回第一个命中规则的分类ID，未命中返回0
+func classifyTitle(rules []*categoryRule, title string) int {
+	if title == "" {
+		return 0
+	}
+	lowerTitle := strings.ToLower(title)
+	for _, rule := range rules {
+		if rule.match(title, lowerTitle) {
+			return rule.categoryID
+		}
+	}
+	return 0
+}
+
+// StartReclassify 启动后台重新分类任务
+// onlyUncategorized为true时只处理未分类资源；否则命中规则的资源按规则更新分类，未命中的保持原分类
+func (s *categoryService) StartReclassify(onlyUncategorized bool) (model.CategoryReclassifyProgress, error) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	if s.progress.Running {
+		return s.progress, ErrReclassifyRunning
+	}
+
+	s.progress = model.CategoryReclassifyProgress{
+		Running:           true,
+		OnlyUncategorized: onlyUncategorized,
+		StartTime:         time.Now().Unix(),
+	}
+	// 使用最新规则
+	s.InvalidateRules()
+
+	go s.reclassify(context.Background(), onlyUncategorized)
+	return s.progress, nil
+}
+
+// ReclassifyProgress 获取重新分类任务进度
+func (s *categoryService) ReclassifyProgress() model.CategoryReclassifyProgress {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	return s.progress
+}
+
+// reclassify 分批遍历全部资源并更新分类
+func (s *categoryService) reclassify(ctx context.Context, onlyUncategorized bool) {
+	logger.Info("🏷️ 开始重新分类资源", zap.Bool("only_uncategorized", onlyUncategorized))
+
+	rules := s.loadRules(ctx)
+	var lastID uint64
+	var runErr error
+
+	for len(rules) > 0 {
+		sources, err := s.sourceRepo.ListAfterID(ctx, lastID, -1, categoryReclassifyBatchSize)
+		if err != nil {
+			runErr = err
+			break
+		}
+
+		updated := 0
+		for _, source := range sources {
+			lastID = source.SourceID
+			if onlyUncategorized && source.CategoryID != 0 {
+				continue
+			}
+
+			categoryID := classifyTitle(rules, source.Title)
+			if categoryID == 0 || categoryID == source.CategoryID {
+				continue
+			}
+
+			source.CategoryID = categoryID
+			if err := s.sourceRepo.UpdateCategory(ctx, source); err != nil {
+				logger.Warn("更新资源分类失败", zap.Uint64("source_id", source.SourceID), zap.Error(err))
+				continue
+			}
+			updated++
+		}
+
+		s.progressMu.Lock()
+		s.progress.Scanned += len(sources)
+		s.progress.Updated += updated
+		s.progressMu.Unlock()
+
+		if len(sources) < categoryReclassifyBatchSize {
+			break
+		}
+	}
+
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	s.progress.Running = false
+	s.progress.EndTime = time.Now().Unix()
+	if runErr != nil {
+		s.progress.Error = runErr.Error()
+		logger.Error("重新分类资源失败", zap.Error(runErr))
+		return
+	}
+	logger.Info("✅ 重新分类资源完成",
+		zap.Int("scanned", s.progress.Scanned),
+		zap.Int("updated", s.progress.Updated),
+	)
+}
@@ -0,0 +1,109 @@
+﻿package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func newTestCategoryService(sourceRepo *repotest.SourceRepository) CategoryService {
+	categoryRepo := repotest.NewCategoryRepository(
+		&model.Category{CategoryID: 1, Name: "电影", Keyword: "电影，1080p|蓝光\nre:\\bS\\d{2}E\\d{2}\\b", Sort: 20, Status: 1},
+		&model.Category{CategoryID: 2, Name: "动漫", Keyword: "动漫、番剧", Sort: 10, Status: 1},
+		&model.Category{CategoryID: 3, Name: "剧集", Keyword: "re:第\\d+季", Sort: 30, Status: 1},
+		&model.Category{CategoryID: 4, Name: "已禁用", Keyword: "三体", Sort: 0, Status: 0},
+		&model.Category{CategoryID: 5, Name: "无效正则", Keyword: "re:([", Sort: 0, Status: 1},
+	)
+	return NewCategoryService(categoryRepo, sourceRepo)
+}
+
+func TestCategoryClassify(t *testing.T) {
+	s := newTestCategoryService(repotest.NewSourceRepository())
+
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"流浪地球2 1080P 国语", 1},
+		{"某剧 s01e03 中字", 1},
+		{"进击的巨人 番剧 全集", 2},
+		{"电影版 动漫 合集", 2}, // 排序值小的分类优先
+		{"三体 第2季", 3},
+		{"三体 全集", 0}, // 禁用的分类不参与匹配
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := s.Classify(context.Background(), tt.title); got != tt.want {
+				t.Errorf("Classify(%q) = %d, want %d", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryClassifySourcesKeepsAssigned(t *testing.T) {
+	s := newTestCategoryService(repotest.NewSourceRepository())
+
+	assigned := &model.Source{Title: "蓝光 电影", CategoryID: 9}
+	unassigned := &model.Source{Title: "蓝光 电影"}
+	s.ClassifySources(context.Background(), assigned, unassigned, nil)
+
+	if assigned.CategoryID != 9 || unassigned.CategoryID != 1 {
+		t.Errorf("CategoryID = %d/%d, want 9/1", assigned.CategoryID, unassigned.CategoryID)
+	}
+}
+
+func TestCategoryReclassify(t *testing.T) {
+	tests := []struct {
+		name              string
+		onlyUncategorized bool
+		wantCategories    []int
+		wantUpdated       int
+	}{
+		{name: "全量", wantCategories: []int{1, 2, 7}, wantUpdated: 2},
+		{name: "只处理未分类", onlyUncategorized: true, wantCategories: []int{1, 9, 7}, wantUpdated: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceRepo := repotest.NewSourceRepository(
+				&model.Source{Title: "蓝光 电影"},
+				&model.Source{Title: "番剧合集", CategoryID: 9},
+				&model.Source{Title: "未命中规则", CategoryID: 7},
+			)
+			s := newTestCategoryService(sourceRepo)
+
+			if _, err := s.StartReclassify(tt.onlyUncategorized); err != nil {
+				t.Fatalf("StartReclassify() error = %v", err)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for s.ReclassifyProgress().Running && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			progress := s.ReclassifyProgress()
+			if progress.Running || progress.Scanned != 3 || progress.Updated != tt.wantUpdated {
+				t.Errorf("ReclassifyProgress() = %+v", progress)
+			}
+
+			for i, source := range sourceRepo.All() {
+				if source.CategoryID != tt.wantCategories[i] {
+					t.Errorf("资源%d分类 = %d, want %d", source.SourceID, source.CategoryID, tt.wantCategories[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCategoryRule(t *testing.T) {
+	if err := ValidateCategoryRule("电影,蓝光\nre:第\\d+季"); err != nil {
+		t.Errorf("ValidateCategoryRule() error = %v", err)
+	}
+	if err := ValidateCategoryRule("电影\nre:(["); err == nil {
+		t.Error("ValidateCategoryRule() 无效正则应返回错误")
+	}
+}
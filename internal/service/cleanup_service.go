@@ -94,33 +94,20 @@ func (s *cleanupService) shouldCleanNetdiskFiles(ctx context.Context) bool {
 func (s *cleanupService) cleanNetdiskFiles(ctx context.Context) error {
 	logger.Info("🗑️ 开始清理网盘临时文件")
 	
-	// 网盘类型映射：0=夸克 2=百度 3=阿里 4=UC 5=迅雷
-	netdiskConfigs := map[int]string{
-		0: "quark_file_time",
-		2: "baidu_file_time",
-		3: "ali_file_time",
-		4: "uc_file_time",
-		5: "xunlei_file_time",
-	}
-	
 	successCount := 0
 	failCount := 0
 	
-	for panType, configKey := range netdiskConfigs {
-		// 获取临时目录路径
-		conf, err := s.configRepo.GetByName(ctx, configKey)
-		if err != nil || conf == nil || conf.Value == "" || conf.Value == "0" {
-			logger.Info("跳过网盘清理（未配置临时目录）",
-				zap.Int("pan_type", panType),
-				zap.String("config_key", configKey),
-			)
-			continue
-		}
+	// 遍历每种网盘的所有启用账号：0=夸克 2=百度 3=阿里 4=UC 5=迅雷 6=天翼 7=123 8=115
+	for _, panType := range netdisk.SupportedPanTypes() {
+		configKey := netdisk.SaveDirConfigKey(panType, true)
 		
-		tempDirPath := conf.Value
+		// 网盘级临时目录（账号未单独配置时使用）
+		defaultDir := ""
+		if conf, err := s.configRepo.GetByName(ctx, configKey); err == nil && conf != nil {
+			defaultDir = conf.Value
+		}
 		
-		// 获取网盘客户端
-		client, err := s.netdiskManager.GetClient(panType)
+		clients, err := s.netdiskManager.GetClients(panType)
 		if err != nil {
 			logger.Warn("获取网盘客户端失败",
 				zap.Int("pan_type", panType),
@@ -130,54 +117,26 @@ func (s *cleanupService) cleanNetdiskFiles(ctx context.Context) error {
 			continue
 		}
 		
-		// 检查是否已配置
-		if !client.IsConfigured() {
-			logger.Info("跳过网盘清理（未配置）",
-				zap.Int("pan_type", panType),
-				zap.String("netdisk", client.GetName()),
-			)
-			continue
-		}
-		
-		// 清理流程：删除临时目录 -> 重建空目录
-		logger.Info("开始清理网盘临时目录",
-			zap.String("netdisk", client.GetName()),
-			zap.String("dir_path", tempDirPath),
-		)
-		
-		// 1. 删除临时目录
-		if err := client.DeleteDirectory(ctx, tempDirPath); err != nil {
-			logger.Error("删除临时目录失败",
-				zap.String("netdisk", client.GetName()),
-				zap.String("dir_path", tempDirPath),
-				zap.Error(err),
-			)
-			failCount++
-			continue
-		}
-		
-		logger.Info("✅ 临时目录已删除",
-			zap.String("netdisk", client.GetName()),
-			zap.String("dir_path", tempDirPath),
-		)
-		
-		// 2. 重建空目录
-		if err := client.CreateDirectory(ctx, tempDirPath); err != nil {
-			logger.Error("重建临时目录失败",
-				zap.String("netdisk", client.GetName()),
-				zap.String("dir_path", tempDirPath),
-				zap.Error(err),
-			)
-			failCount++
-			continue
+		for _, client := range clients {
+			tempDirPath := defaultDir
+			if accountClient, ok := client.(*netdisk.AccountClient); ok && accountClient.Account().SaveDirTime != "" {
+				tempDirPath = accountClient.Account().SaveDirTime
+			}
+			
+			if tempDirPath == "" || tempDirPath == "0" {
+				logger.Info("跳过网盘清理（未配置临时目录）",
+					zap.Int("pan_type", panType),
+					zap.String("config_key", configKey),
+				)
+				continue
+			}
+			
+			if s.cleanNetdiskTempDir(ctx, client, tempDirPath) {
+				successCount++
+			} else {
+				failCount++
+			}
 		}
-		
-		logger.Info("✅ 临时目录已重建",
-			zap.String("netdisk", client.GetName()),
-			zap.String("dir_path", tempDirPath),
-		)
-		
-		successCount++
 	}
 	
 	logger.Info("🎉 网盘文件清理完成",
@@ -192,6 +151,54 @@ func (s *cleanupService) cleanNetdiskFiles(ctx context.Context) error {
 	return nil
 }
 
+// cleanNetdiskTempDir 清理单个账号的临时目录：删除临时目录 -> 重建空目录
+func (s *cleanupService) cleanNetdiskTempDir(ctx context.Context, client netdisk.Netdisk, tempDirPath string) bool {
+	// 检查是否已配置
+	if !client.IsConfigured() {
+		logger.Info("跳过网盘清理（未配置）",
+			zap.String("netdisk", client.GetName()),
+		)
+		return true
+	}
+	
+	logger.Info("开始清理网盘临时目录",
+		zap.String("netdisk", client.GetName()),
+		zap.String("dir_path", tempDirPath),
+	)
+	
+	// 1. 删除临时目录
+	if err := client.DeleteDirectory(ctx, tempDirPath); err != nil {
+		logger.Error("删除临时目录失败",
+			zap.String("netdisk", client.GetName()),
+			zap.String("dir_path", tempDirPath),
+			zap.Error(err),
+		)
+		return false
+	}
+	
+	logger.Info("✅ 临时目录已删除",
+		zap.String("netdisk", client.GetName()),
+		zap.String("dir_path", tempDirPath),
+	)
+	
+	// 2. 重建空目录
+	if err := client.CreateDirectory(ctx, tempDirPath); err != nil {
+		logger.Error("重建临时目录失败",
+			zap.String("netdisk", client.GetName()),
+			zap.String("dir_path", tempDirPath),
+			zap.Error(err),
+		)
+		return false
+	}
+	
+	logger.Info("✅ 临时目录已重建",
+		zap.String("netdisk", client.GetName()),
+		zap.String("dir_path", tempDirPath),
+	)
+	
+	return true
+}
+
 // StartScheduledCleanup 启动定时清理任务
 func (s *cleanupService) StartScheduledCleanup(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
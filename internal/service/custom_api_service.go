@@ -0,0 +1,815 @@
+﻿package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+	"huoxing-search/pansou/util"
+)
+
+// 自定义接口中的关键词占位符
+const customAPIKeywordPlaceholder = "{keyword}"
+
+// 自定义接口单次请求超时
+const customAPITimeout = 8 * time.Second
+
+const (
+	// customAPIMaxDetailPages 详情页模式下单次搜索最多抓取的详情页数量
+	customAPIMaxDetailPages = 10
+	// customAPIDetailConcurrency 详情页并发抓取数
+	customAPIDetailConcurrency = 4
+	// customAPIMaxTraceErrors 测试接口时最多记录的诊断信息条数
+	customAPIMaxTraceErrors = 50
+)
+
+// customAPIUserAgent 请求自定义接口时使用的UA
+const customAPIUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// CustomAPIService 自定义搜索接口服务（qf_api_list）
+type CustomAPIService interface {
+	// Search 并发调用所有启用的自定义接口，结果按接口权重从高到低排列
+	Search(ctx context.Context, keyword string, panType int, maxPerAPI int) []model.SearchResult
+	// Execute 调用单个自定义接口
+	Execute(ctx context.Context, api *model.APIConfig, keyword string) ([]model.SearchResult, error)
+	// Test 调用单个自定义接口并返回解析结果和诊断信息（不过滤网盘类型）
+	Test(ctx context.Context, api *model.APIConfig, keyword string) *model.APITestResult
+}
+
+type customAPIService struct {
+	apiRepo repository.APIConfigRepository
+	client  *http.Client
+}
+
+// NewCustomAPIService 创建自定义搜索接口服务
+func NewCustomAPIService(apiRepo repository.APIConfigRepository) CustomAPIService {
+	return &customAPIService{
+		apiRepo: apiRepo,
+		client:  &http.Client{Timeout: customAPITimeout},
+	}
+}
+
+// customAPIFieldMap 字段映射配置
+// JSON模式: list 为结果数组路径（如 data.list），其余为结果项内的字段路径
+// HTML模式: list 为结果项选择器，其余为项内选择器，可用 "选择器@属性" 取属性值
+type customAPIFieldMap struct {
+	List     string `json:"list"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Password string `json:"password"`
+	Content  string `json:"content"`
+	Size     string `json:"size"`
+	Time     string `json:"time"`
+}
+
+// customAPITrace 记录单次调用的诊断信息，为nil时不记录
+type customAPITrace struct {
+	mu         sync.Mutex
+	requestURL string
+	statusCode int
+	mode       string
+	itemCount  int
+	errors     []string
+}
+
+func (t *customAPITrace) addf(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.errors) < customAPIMaxTraceErrors {
+		t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// Search 并发调用所有启用的自定义接口
+func (s *customAPIService) Search(ctx context.Context, keyword string, panType int, maxPerAPI int) []model.SearchResult {
+	apis, err := s.apiRepo.ListEnabled(ctx, panType)
+	if err != nil {
+		logger.Warn("获取自定义搜索接口失败", zap.Error(err))
+		return nil
+	}
+	if len(apis) == 0 {
+		return nil
+	}
+
+	// 每个接口的结果单独保存，最后按权重顺序拼接
+	apiResults := make([][]model.SearchResult, len(apis))
+	var wg sync.WaitGroup
+
+	for i := range apis {
+		wg.Add(1)
+		go func(idx int, api *model.APIConfig) {
+			defer wg.Done()
+
+			results, err := s.Execute(ctx, api, keyword)
+			if err != nil {
+				logger.Warn("自定义搜索接口调用失败",
+					zap.Int("api_id", api.ID),
+					zap.String("name", api.Name),
+					zap.Error(err),
+				)
+				return
+			}
+
+			// 只保留目标网盘类型的链接
+			filtered := make([]model.SearchResult, 0, len(results))
+			for _, r := range results {
+				if r.PanType == panType {
+					filtered = append(filtered, r)
+				}
+			}
+			if maxPerAPI > 0 && len(filtered) > maxPerAPI {
+				filtered = filtered[:maxPerAPI]
+			}
+			apiResults[idx] = filtered
+
+			if len(filtered) > 0 {
+				if err := s.apiRepo.IncrementCount(context.Background(), api.ID, 1); err != nil {
+					logger.Warn("更新自定义接口命中次数失败", zap.Int("api_id", api.ID), zap.Error(err))
+				}
+			}
+		}(i, &apis[i])
+	}
+	wg.Wait()
+
+	merged := make([]model.SearchResult, 0)
+	seen := make(map[string]bool)
+	for i, results := range apiResults {
+		for _, r := range results {
+			if seen[r.URL] {
+				continue
+			}
+			seen[r.URL] = true
+			merged = append(merged, r)
+		}
+		if len(results) > 0 {
+			logger.Info("自定义搜索接口返回结果",
+				zap.String("name", apis[i].Name),
+				zap.Int("weight", apis[i].Weight),
+				zap.Int("count", len(results)),
+			)
+		}
+	}
+
+	return merged
+}
+
+// Execute 调用单个自定义接口并按字段映射解析结果
+func (s *customAPIService) Execute(ctx context.Context, api *model.APIConfig, keyword string) ([]model.SearchResult, error) {
+	return s.execute(ctx, api, keyword, nil)
+}
+
+// Test 调用单个自定义接口，返回请求地址、解析结果以及选择器未命中等诊断信息
+func (s *customAPIService) Test(ctx context.Context, api *model.APIConfig, keyword string) *model.APITestResult {
+	trace := &customAPITrace{}
+	start := time.Now()
+	items, err := s.execute(ctx, api, keyword, trace)
+	if err != nil {
+		trace.addf("%v", err)
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if items == nil {
+		items = []model.SearchResult{}
+	}
+	errs := trace.errors
+	if errs == nil {
+		errs = []string{}
+	}
+	return &model.APITestResult{
+		RequestURL: trace.requestURL,
+		StatusCode: trace.statusCode,
+		Mode:       trace.mode,
+		ItemCount:  trace.itemCount,
+		Items:      items,
+		Errors:     errs,
+		ElapsedMs:  time.Since(start).Milliseconds(),
+	}
+}
+
+// execute 调用接口并解析结果，trace非nil时记录诊断信息
+func (s *customAPIService) execute(ctx context.Context, api *model.APIConfig, keyword string, trace *customAPITrace) ([]model.SearchResult, error) {
+	if strings.TrimSpace(api.URL) == "" {
+		return nil, fmt.Errorf("接口地址为空")
+	}
+
+	// type=html 且配置了 html_item 时使用 html_* 选择器，否则沿用字段映射
+	selectorMode := api.Type == model.APITypeHTML && strings.TrimSpace(api.HTMLItem) != ""
+	var fieldMap *customAPIFieldMap
+	var err error
+	if selectorMode {
+		if err := ValidateHTMLSelectors(api); err != nil {
+			return nil, err
+		}
+	} else if fieldMap, err = parseCustomAPIFieldMap(api.FieldMap); err != nil {
+		return nil, err
+	}
+
+	req, err := s.buildRequest(ctx, api, keyword)
+	if err != nil {
+		return nil, err
+	}
+	if trace != nil {
+		trace.requestURL = req.URL.String()
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if trace != nil {
+		trace.statusCode = resp.StatusCode
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("接口返回状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var items []model.SearchResult
+	mode := "json"
+	switch {
+	case selectorMode:
+		mode = "html_selector"
+		items, err = s.parseHTMLSelectors(ctx, api, keyword, resp.Request.URL, body, trace)
+	case isHTMLResponse(api, resp, body):
+		mode = "html"
+		items, err = parseCustomAPIHTML(body, fieldMap)
+	default:
+		items, err = parseCustomAPIJSON(body, fieldMap)
+	}
+	if trace != nil {
+		trace.mode = mode
+		trace.itemCount = len(items)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.SearchResult, 0, len(items))
+	for i, item := range items {
+		item.URL = strings.TrimSpace(item.URL)
+		if item.URL == "" {
+			trace.addf("第%d项未解析到链接，已忽略", i+1)
+			continue
+		}
+		if item.Title == "" {
+			item.Title = keyword
+		}
+		if item.Password == "" {
+			item.Password = util.ExtractPassword(item.Content, item.URL)
+		}
+		item.PanType = detectPanType(item.URL, api.PanType)
+		item.Source = api.Name
+		results = append(results, item)
+	}
+
+	return results, nil
+}
+
+// buildRequest 构建请求：替换关键词占位符，附加固定参数和请求头
+func (s *customAPIService) buildRequest(ctx context.Context, api *model.APIConfig, keyword string) (*http.Request, error) {
+	params := make(map[string]string)
+	if strings.TrimSpace(api.FixedParams) != "" {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(api.FixedParams), &raw); err != nil {
+			return nil, fmt.Errorf("固定参数格式错误: %w", err)
+		}
+		for k, v := range raw {
+			params[k] = strings.ReplaceAll(fmt.Sprint(v), customAPIKeywordPlaceholder, keyword)
+		}
+	}
+
+	headers, err := parseCustomAPIHeaders(api)
+	if err != nil {
+		return nil, err
+	}
+
+	// URL和参数中都没有占位符时，默认以 keyword 参数传递关键词
+	rawURL := api.URL
+	if strings.Contains(rawURL, customAPIKeywordPlaceholder) {
+		rawURL = strings.ReplaceAll(rawURL, customAPIKeywordPlaceholder, url.QueryEscape(keyword))
+	} else if !strings.Contains(api.FixedParams, customAPIKeywordPlaceholder) {
+		params["keyword"] = keyword
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(api.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var req *http.Request
+	if method == http.MethodGet {
+		u, parseErr := url.Parse(rawURL)
+		if parseErr != nil {
+			return nil, fmt.Errorf("接口地址格式错误: %w", parseErr)
+		}
+		query := u.Query()
+		for k, v := range params {
+			query.Set(k, v)
+		}
+		u.RawQuery = query.Encode()
+		req, err = http.NewRequestWithContext(ctx, method, u.String(), nil)
+	} else {
+		var body io.Reader
+		contentType := headerValue(headers, "Content-Type")
+		if strings.Contains(strings.ToLower(contentType), "json") {
+			data, _ := json.Marshal(params)
+			body = bytes.NewReader(data)
+		} else {
+			form := url.Values{}
+			for k, v := range params {
+				form.Set(k, v)
+			}
+			body = strings.NewReader(form.Encode())
+			if contentType == "" {
+				headers["Content-Type"] = "application/x-www-form-urlencoded"
+			}
+		}
+		req, err = http.NewRequestWithContext(ctx, method, rawURL, body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	setCustomAPIHeaders(req, headers, keyword)
+	return req, nil
+}
+
+// parseCustomAPIHeaders 解析接口配置的请求头
+func parseCustomAPIHeaders(api *model.APIConfig) (map[string]string, error) {
+	headers := make(map[string]string)
+	if strings.TrimSpace(api.Headers) != "" {
+		if err := json.Unmarshal([]byte(api.Headers), &headers); err != nil {
+			return nil, fmt.Errorf("请求头格式错误: %w", err)
+		}
+	}
+	return headers, nil
+}
+
+// setCustomAPIHeaders 设置默认UA和接口配置的请求头
+func setCustomAPIHeaders(req *http.Request, headers map[string]string, keyword string) {
+	req.Header.Set("User-Agent", customAPIUserAgent)
+	for k, v := range headers {
+		req.Header.Set(k, strings.ReplaceAll(v, customAPIKeywordPlaceholder, keyword))
+	}
+}
+
+// parseCustomAPIFieldMap 解析字段映射，未配置的字段使用常见字段名
+func parseCustomAPIFieldMap(raw string) (*customAPIFieldMap, error) {
+	fm := &customAPIFieldMap{}
+	if strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), fm); err != nil {
+			return nil, fmt.Errorf("字段映射格式错误: %w", err)
+		}
+	}
+	if fm.Title == "" {
+		fm.Title = "title"
+	}
+	if fm.URL == "" {
+		fm.URL = "url"
+	}
+	return fm, nil
+}
+
+// isHTMLResponse 判断是否按HTML解析
+func isHTMLResponse(api *model.APIConfig, resp *http.Response, body []byte) bool {
+	if api.Type == model.APITypeHTML {
+		return true
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
+// parseCustomAPIJSON 按字段映射解析JSON响应
+func parseCustomAPIJSON(body []byte, fm *customAPIFieldMap) ([]model.SearchResult, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	}
+
+	var list []interface{}
+	if fm.List != "" {
+		if v, ok := jsonPathValue(root, fm.List).([]interface{}); ok {
+			list = v
+		}
+	} else {
+		// 未配置列表路径时尝试常见结构
+		for _, path := range []string{"", "data", "list", "results", "data.list", "data.results"} {
+			if v, ok := jsonPathValue(root, path).([]interface{}); ok {
+				list = v
+				break
+			}
+		}
+	}
+	if list == nil {
+		return nil, fmt.Errorf("响应中未找到结果列表")
+	}
+
+	results := make([]model.SearchResult, 0, len(list))
+	for _, item := range list {
+		results = append(results, model.SearchResult{
+			Title:    jsonPathString(item, fm.Title),
+			URL:      jsonPathString(item, fm.URL),
+			Password: jsonPathString(item, fm.Password),
+			Content:  jsonPathString(item, fm.Content),
+			Size:     jsonPathString(item, fm.Size),
+			Time:     formatCustomAPITime(jsonPathString(item, fm.Time)),
+		})
+	}
+	return results, nil
+}
+
+// jsonPathValue 按点分路径取值，数字段表示数组下标
+func jsonPathValue(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, seg := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[seg]
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil
+			}
+			v = node[idx]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// jsonPathString 按路径取值并转为字符串
+func jsonPathString(v interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	switch val := jsonPathValue(v, path).(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+// parseCustomAPIHTML 按选择器解析HTML响应
+func parseCustomAPIHTML(body []byte, fm *customAPIFieldMap) ([]model.SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %w", err)
+	}
+	if fm.List == "" {
+		return nil, fmt.Errorf("HTML模式需要在字段映射中配置list选择器")
+	}
+
+	// HTML模式下链接默认取a标签的href
+	if fm.URL == "url" {
+		fm.URL = "a@href"
+	}
+	if fm.Title == "title" {
+		fm.Title = "a"
+	}
+
+	results := make([]model.SearchResult, 0)
+	doc.Find(fm.List).Each(func(_ int, sel *goquery.Selection) {
+		results = append(results, model.SearchResult{
+			Title:    htmlSelectorValue(sel, fm.Title),
+			URL:      htmlSelectorValue(sel, fm.URL),
+			Password: htmlSelectorValue(sel, fm.Password),
+			Content:  htmlSelectorValue(sel, fm.Content),
+			Size:     htmlSelectorValue(sel, fm.Size),
+			Time:     formatCustomAPITime(htmlSelectorValue(sel, fm.Time)),
+		})
+	})
+	return results, nil
+}
+
+// htmlSelectorValue 取选择器对应的文本或属性，"选择器@属性" 取属性，选择器为空时作用于当前节点
+func htmlSelectorValue(sel *goquery.Selection, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	selector, attr := splitHTMLSelector(selector)
+
+	target := sel
+	if strings.TrimSpace(selector) != "" {
+		target = sel.Find(selector).First()
+	}
+	if attr != "" {
+		val, _ := target.Attr(attr)
+		return strings.TrimSpace(val)
+	}
+	return strings.TrimSpace(target.Text())
+}
+
+// ValidateHTMLSelectors 校验 html_* 选择器语法（"选择器@属性" 只校验选择器部分）
+func ValidateHTMLSelectors(api *model.APIConfig) error {
+	selectors := []struct {
+		field    string
+		selector string
+	}{
+		{"html_item", api.HTMLItem},
+		{"html_title", api.HTMLTitle},
+		{"html_url", api.HTMLURL},
+		{"html_url2", api.HTMLURL2},
+	}
+	for _, item := range selectors {
+		selector, _ := splitHTMLSelector(item.selector)
+		if strings.TrimSpace(selector) == "" {
+			continue
+		}
+		if _, err := cascadia.ParseGroup(selector); err != nil {
+			return fmt.Errorf("%s 选择器 %q 无效: %w", item.field, item.selector, err)
+		}
+	}
+	return nil
+}
+
+// splitHTMLSelector 拆分 "选择器@属性"
+func splitHTMLSelector(selector string) (string, string) {
+	if idx := strings.LastIndex(selector, "@"); idx >= 0 {
+		return selector[:idx], selector[idx+1:]
+	}
+	return selector, ""
+}
+
+// parseHTMLSelectors 按 html_* 选择器解析列表页，详情页模式下继续抓取详情页中的网盘链接
+func (s *customAPIService) parseHTMLSelectors(ctx context.Context, api *model.APIConfig, keyword string, pageURL *url.URL, body []byte, trace *customAPITrace) ([]model.SearchResult, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %w", err)
+	}
+
+	titleSelector := api.HTMLTitle
+	if strings.TrimSpace(titleSelector) == "" {
+		titleSelector = "a"
+	}
+	urlSelector := api.HTMLURL
+	if strings.TrimSpace(urlSelector) == "" {
+		urlSelector = "a@href"
+	}
+
+	nodes := doc.Find(api.HTMLItem)
+	if nodes.Length() == 0 {
+		trace.addf("html_item %q 未匹配到任何元素", api.HTMLItem)
+		return []model.SearchResult{}, nil
+	}
+
+	results := make([]model.SearchResult, 0, nodes.Length())
+	nodes.Each(func(i int, sel *goquery.Selection) {
+		item := model.SearchResult{
+			Title:   htmlSelectorValue(sel, titleSelector),
+			URL:     resolveHTMLLink(pageURL, htmlSelectorValue(sel, urlSelector)),
+			Content: strings.Join(strings.Fields(sel.Text()), " "),
+		}
+		if item.Title == "" {
+			trace.addf("第%d项 html_title %q 未取到标题", i+1, titleSelector)
+		}
+		if item.URL == "" {
+			trace.addf("第%d项 html_url %q 未取到链接", i+1, urlSelector)
+		}
+		results = append(results, item)
+	})
+
+	if api.HTMLType != model.HTMLTypeDetail {
+		return results, nil
+	}
+	return s.fetchDetailLinks(ctx, api, keyword, pageURL, results, trace), nil
+}
+
+// fetchDetailLinks 并发抓取详情页，用 html_url2 提取网盘链接（未配置时自动识别页面中的网盘链接）
+// 每个详情页可能包含多个网盘链接，结果沿用列表项的标题
+func (s *customAPIService) fetchDetailLinks(ctx context.Context, api *model.APIConfig, keyword string, pageURL *url.URL, items []model.SearchResult, trace *customAPITrace) []model.SearchResult {
+	headers, err := parseCustomAPIHeaders(api)
+	if err != nil {
+		trace.addf("%v", err)
+		return []model.SearchResult{}
+	}
+
+	detailResults := make([][]model.SearchResult, len(items))
+	sem := make(chan struct{}, customAPIDetailConcurrency)
+	var wg sync.WaitGroup
+
+	fetched := 0
+	for i := range items {
+		if items[i].URL == "" {
+			continue
+		}
+		if fetched >= customAPIMaxDetailPages {
+			trace.addf("详情页数量超过%d个，其余已跳过", customAPIMaxDetailPages)
+			break
+		}
+		fetched++
+
+		wg.Add(1)
+		go func(idx int, item model.SearchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			links, content, err := s.fetchDetailPage(ctx, api.HTMLURL2, item.URL, pageURL, headers, keyword)
+			if err != nil {
+				trace.addf("详情页 %s 抓取失败: %v", item.URL, err)
+				return
+			}
+			if len(links) == 0 {
+				trace.addf("详情页 %s 未找到网盘链接", item.URL)
+				return
+			}
+
+			for _, link := range links {
+				detailResults[idx] = append(detailResults[idx], model.SearchResult{
+					Title:    item.Title,
+					URL:      link,
+					Password: util.ExtractPassword(content, link),
+					Content:  item.Content,
+				})
+			}
+		}(i, items[i])
+	}
+	wg.Wait()
+
+	results := make([]model.SearchResult, 0, len(items))
+	seen := make(map[string]bool)
+	for _, links := range detailResults {
+		for _, r := range links {
+			if seen[r.URL] {
+				continue
+			}
+			seen[r.URL] = true
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// fetchDetailPage 请求详情页并提取网盘链接，同时返回页面文本用于识别提取码
+func (s *customAPIService) fetchDetailPage(ctx context.Context, selector, detailURL string, referer *url.URL, headers map[string]string, keyword string) ([]string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, detailURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	setCustomAPIHeaders(req, headers, keyword)
+	if referer != nil {
+		req.Header.Set("Referer", referer.String())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return nil, "", fmt.Errorf("解析HTML失败: %w", err)
+	}
+	content := strings.Join(strings.Fields(doc.Text()), " ")
+
+	var candidates []string
+	if strings.TrimSpace(selector) != "" {
+		for _, v := range htmlSelectorValues(doc.Selection, selector) {
+			candidates = append(candidates, resolveHTMLLink(resp.Request.URL, v))
+		}
+	} else {
+		doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			href, _ := a.Attr("href")
+			if detectPanType(href, -1) >= 0 {
+				candidates = append(candidates, strings.TrimSpace(href))
+			}
+		})
+		candidates = append(candidates, util.ExtractNetDiskLinks(content)...)
+	}
+
+	links := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, link := range candidates {
+		if link == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links, content, nil
+}
+
+// htmlSelectorValues 取选择器匹配到的所有元素的文本或属性
+func htmlSelectorValues(sel *goquery.Selection, selector string) []string {
+	selector, attr := splitHTMLSelector(selector)
+	target := sel
+	if strings.TrimSpace(selector) != "" {
+		target = sel.Find(selector)
+	}
+
+	var values []string
+	target.Each(func(_ int, node *goquery.Selection) {
+		val := strings.TrimSpace(node.Text())
+		if attr != "" {
+			val, _ = node.Attr(attr)
+			val = strings.TrimSpace(val)
+		}
+		if val != "" {
+			values = append(values, val)
+		}
+	})
+	return values
+}
+
+// resolveHTMLLink 将页面中的相对链接转为绝对地址
+func resolveHTMLLink(base *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" || base == nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// formatCustomAPITime 时间字段为Unix时间戳时转为日期
+func formatCustomAPITime(val string) string {
+	if ts, err := strconv.ParseInt(val, 10, 64); err == nil && ts > 0 {
+		if ts > 1e12 {
+			ts /= 1000
+		}
+		return time.Unix(ts, 0).Format("2006-01-02")
+	}
+	return val
+}
+
+// detectPanType 根据链接识别网盘类型，无法识别时使用接口配置的类型
+func detectPanType(link string, fallback int) int {
+	if strings.Contains(strings.ToLower(link), "pan.xunlei.com") {
+		return model.PanTypeXunlei
+	}
+	switch util.GetLinkType(link) {
+	case "quark":
+		return model.PanTypeQuark
+	case "baidu":
+		return model.PanTypeBaidu
+	case "aliyun":
+		return model.PanTypeAliyun
+	case "uc":
+		return model.PanTypeUC
+	case "tianyi":
+		return model.PanTypeTianyi
+	case "123":
+		return model.PanType123
+	case "115":
+		return model.PanType115
+	}
+	return fallback
+}
+
+// headerValue 不区分大小写获取请求头
+func headerValue(headers map[string]string, key string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
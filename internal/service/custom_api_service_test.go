@@ -0,0 +1,186 @@
+﻿package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"huoxing-search/internal/model"
+)
+
+// newTestHTMLSite 模拟一个资源站：/search 为列表页，/detail/{id} 为详情页
+func newTestHTMLSite(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><ul>
+<li class="item"><a class="title" href="/detail/1">%s 第一季</a><a class="pan" href="https://pan.quark.cn/s/aaa111">下载</a> 提取码: ab12</li>
+<li class="item"><a class="title" href="/detail/2">%s 第二季</a><a class="pan" href="https://pan.baidu.com/s/1bbb222">下载</a></li>
+<li class="item"><a class="title" href="/detail/404">%s 失效</a></li>
+</ul></body></html>`, r.URL.Query().Get("wd"), r.URL.Query().Get("wd"), r.URL.Query().Get("wd"))
+	})
+	mux.HandleFunc("/detail/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<div class="links"><a href="https://pan.quark.cn/s/aaa111">夸克</a>提取码：q1w2</div>`)
+	})
+	mux.HandleFunc("/detail/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<p>百度网盘 https://pan.baidu.com/s/1bbb222 <a href="https://pan.quark.cn/s/ccc333">夸克</a></p>`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestCustomAPIService(srv *httptest.Server) *customAPIService {
+	return &customAPIService{client: srv.Client()}
+}
+
+func TestCustomAPIHTMLSelectors(t *testing.T) {
+	srv := newTestHTMLSite(t)
+	s := newTestCustomAPIService(srv)
+
+	api := &model.APIConfig{
+		Name:      "测试站",
+		URL:       srv.URL + "/search?wd={keyword}",
+		Type:      model.APITypeHTML,
+		HTMLItem:  "li.item",
+		HTMLTitle: "a.title",
+		HTMLURL:   "a.pan@href",
+		PanType:   model.PanTypeQuark,
+	}
+
+	results, err := s.Execute(context.Background(), api, "三体")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2: %+v", len(results), results)
+	}
+
+	first := results[0]
+	if first.Title != "三体 第一季" || first.URL != "https://pan.quark.cn/s/aaa111" || first.Password != "ab12" {
+		t.Errorf("results[0] = %+v", first)
+	}
+	if first.PanType != model.PanTypeQuark || first.Source != "测试站" {
+		t.Errorf("results[0] PanType/Source = %d/%s", first.PanType, first.Source)
+	}
+	if results[1].PanType != model.PanTypeBaidu {
+		t.Errorf("results[1].PanType = %d, want %d", results[1].PanType, model.PanTypeBaidu)
+	}
+}
+
+func TestCustomAPIHTMLDetailPages(t *testing.T) {
+	srv := newTestHTMLSite(t)
+	s := newTestCustomAPIService(srv)
+
+	tests := []struct {
+		name     string
+		htmlURL2 string
+		wantURLs []string
+	}{
+		{
+			name:     "指定详情页选择器",
+			htmlURL2: ".links a@href",
+			wantURLs: []string{"https://pan.quark.cn/s/aaa111"},
+		},
+		{
+			name:     "自动识别详情页网盘链接",
+			wantURLs: []string{"https://pan.quark.cn/s/aaa111", "https://pan.quark.cn/s/ccc333", "https://pan.baidu.com/s/1bbb222"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &model.APIConfig{
+				Name:      "测试站",
+				URL:       srv.URL + "/search?wd={keyword}",
+				Type:      model.APITypeHTML,
+				HTMLItem:  "li.item",
+				HTMLTitle: "a.title",
+				HTMLURL:   "a.title@href",
+				HTMLType:  model.HTMLTypeDetail,
+				HTMLURL2:  tt.htmlURL2,
+			}
+
+			result := s.Test(context.Background(), api, "三体")
+			if result.Mode != "html_selector" || result.ItemCount != len(tt.wantURLs) {
+				t.Errorf("Mode/ItemCount = %s/%d, want html_selector/%d", result.Mode, result.ItemCount, len(tt.wantURLs))
+			}
+
+			var urls []string
+			for _, item := range result.Items {
+				urls = append(urls, item.URL)
+			}
+			if strings.Join(urls, ",") != strings.Join(tt.wantURLs, ",") {
+				t.Errorf("URLs = %v, want %v", urls, tt.wantURLs)
+			}
+			if result.Items[0].Title != "三体 第一季" || result.Items[0].Password != "q1w2" {
+				t.Errorf("Items[0] = %+v", result.Items[0])
+			}
+
+			// 404详情页应出现在诊断信息中
+			if !containsSubstring(result.Errors, "/detail/404") {
+				t.Errorf("Errors = %v, want 404详情页诊断", result.Errors)
+			}
+		})
+	}
+}
+
+func TestCustomAPITestDiagnostics(t *testing.T) {
+	srv := newTestHTMLSite(t)
+	s := newTestCustomAPIService(srv)
+
+	tests := []struct {
+		name      string
+		api       model.APIConfig
+		wantError string
+	}{
+		{
+			name:      "列表选择器未命中",
+			api:       model.APIConfig{HTMLItem: "div.result"},
+			wantError: `html_item "div.result" 未匹配到任何元素`,
+		},
+		{
+			name:      "项内链接未命中",
+			api:       model.APIConfig{HTMLItem: "li.item", HTMLURL: "a.pan@href"},
+			wantError: "第3项 html_url",
+		},
+		{
+			name:      "选择器语法错误",
+			api:       model.APIConfig{HTMLItem: "li[class="},
+			wantError: "html_item 选择器",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := tt.api
+			api.URL = srv.URL + "/search?wd={keyword}"
+			api.Type = model.APITypeHTML
+
+			result := s.Test(context.Background(), &api, "三体")
+			if !containsSubstring(result.Errors, tt.wantError) {
+				t.Errorf("Errors = %v, want %q", result.Errors, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValidateHTMLSelectors(t *testing.T) {
+	if err := ValidateHTMLSelectors(&model.APIConfig{HTMLItem: "ul > li.item", HTMLURL: "a[href^='/detail']@href"}); err != nil {
+		t.Errorf("ValidateHTMLSelectors() error = %v", err)
+	}
+	if err := ValidateHTMLSelectors(&model.APIConfig{HTMLURL2: "a[href@href"}); err == nil {
+		t.Error("ValidateHTMLSelectors() 无效选择器应返回错误")
+	}
+}
+
+func containsSubstring(values []string, substr string) bool {
+	for _, v := range values {
+		if strings.Contains(v, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+﻿package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	// linkCheckConcurrency 搜索时并发检测的链接数
+	linkCheckConcurrency = 8
+	// linkRecheckBatchSize 复检本地资源时每批读取的数量
+	linkRecheckBatchSize = 100
+	// linkRecheckDelay 复检本地资源时每个链接之间的间隔，避免触发网盘风控
+	linkRecheckDelay = 500 * time.Millisecond
+
+	linkAliveCacheTTL = 30 * time.Minute
+	linkDeadCacheTTL  = 6 * time.Hour
+)
+
+// LinkCheckService 分享链接有效性检测服务接口
+type LinkCheckService interface {
+	// Check 检查单个分享链接状态（带缓存）
+	Check(ctx context.Context, panType int, shareURL, password string) model.LinkStatus
+	// FilterSearchResults 检测搜索结果并过滤失效链接
+	FilterSearchResults(ctx context.Context, results []model.SearchResult) []model.SearchResult
+	// RecheckSources 复检本地已上线资源，失效资源设置为下线
+	RecheckSources(ctx context.Context) (checked int, dead int, err error)
+	// StartScheduledRecheck 启动定时复检任务
+	StartScheduledRecheck(ctx context.Context)
+}
+
+type linkCheckService struct {
+	configRepo     repository.ConfigRepository
+	sourceRepo     repository.SourceRepository
+	netdiskManager netdisk.NetdiskManager
+}
+
+// linkStatusCacheEntry 链接检测结果缓存
+type linkStatusCacheEntry struct {
+	status    model.LinkStatus
+	expiresAt time.Time
+}
+
+// linkStatusCache 检测结果缓存（进程内共享，key为链接+提取码）
+var linkStatusCache sync.Map
+
+// NewLinkCheckService 创建链接检测服务
+func NewLinkCheckService(cfg *config.Config) LinkCheckService {
+	return &linkCheckService{
+		configRepo:     repository.NewConfigRepository(),
+		sourceRepo:     repository.NewSourceRepository(),
+		netdiskManager: netdisk.NewNetdiskManager(cfg),
+	}
+}
+
+var (
+	globalLinkCheckService LinkCheckService
+	globalLinkCheckMu      sync.Mutex
+)
+
+// StartLinkCheckService 启动全局链接检测服务及定时复检任务（重复调用只启动一次）
+func StartLinkCheckService(cfg *config.Config) LinkCheckService {
+	globalLinkCheckMu.Lock()
+	defer globalLinkCheckMu.Unlock()
+
+	if globalLinkCheckService == nil {
+		globalLinkCheckService = NewLinkCheckService(cfg)
+		go globalLinkCheckService.StartScheduledRecheck(context.Background())
+	}
+	return globalLinkCheckService
+}
+
+// GetLinkCheckService 获取全局链接检测服务，未启动时返回nil
+func GetLinkCheckService() LinkCheckService {
+	globalLinkCheckMu.Lock()
+	defer globalLinkCheckMu.Unlock()
+	return globalLinkCheckService
+}
+
+// Check 检查单个分享链接状态，网盘未配置或不支持检测时返回unknown
+func (s *linkCheckService) Check(ctx context.Context, panType int, shareURL, password string) model.LinkStatus {
+	if password == "" {
+		password = passwordFromURL(shareURL)
+	}
+
+	cacheKey := shareURL + "#" + password
+	if cached, ok := linkStatusCache.Load(cacheKey); ok {
+		entry := cached.(linkStatusCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			return entry.status
+		}
+		linkStatusCache.Delete(cacheKey)
+	}
+
+	client, err := s.netdiskManager.GetClient(panType)
+	if err != nil {
+		return model.LinkStatusUnknown
+	}
+	checker, ok := client.(netdisk.LinkChecker)
+	if !ok {
+		return model.LinkStatusUnknown
+	}
+
+	status, err := checker.CheckLink(ctx, shareURL, password)
+	if err != nil {
+		logger.Debug("链接检测结果",
+			zap.String("url", shareURL),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+	}
+
+	switch status {
+	case model.LinkStatusAlive:
+		linkStatusCache.Store(cacheKey, linkStatusCacheEntry{status: status, expiresAt: time.Now().Add(linkAliveCacheTTL)})
+	case model.LinkStatusDead:
+		linkStatusCache.Store(cacheKey, linkStatusCacheEntry{status: status, expiresAt: time.Now().Add(linkDeadCacheTTL)})
+	}
+	return status
+}
+
+// FilterSearchResults 并发检测搜索结果，过滤失效链接
+// 检测总时长受link_check_timeout限制，超时未完成的链接按unknown保留
+func (s *linkCheckService) FilterSearchResults(ctx context.Context, results []model.SearchResult) []model.SearchResult {
+	if len(results) == 0 {
+		return results
+	}
+	if enabled, err := s.configRepo.GetInt(ctx, model.ConfLinkCheckEnabled); err == nil && enabled == 0 {
+		return results
+	}
+
+	timeout := 3
+	if conf, err := s.configRepo.GetInt(ctx, model.ConfLinkCheckTimeout); err == nil && conf > 0 {
+		timeout = conf
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	statuses := make([]model.LinkStatus, len(results))
+	sem := make(chan struct{}, linkCheckConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range results {
+		statuses[i] = model.LinkStatusUnknown
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-checkCtx.Done():
+				return
+			}
+			statuses[i] = s.Check(checkCtx, results[i].PanType, results[i].URL, results[i].Password)
+		}(i)
+	}
+	wg.Wait()
+
+	filtered := make([]model.SearchResult, 0, len(results))
+	dead := 0
+	for i, result := range results {
+		if statuses[i] == model.LinkStatusDead {
+			dead++
+			continue
+		}
+		result.LinkStatus = string(statuses[i])
+		filtered = append(filtered, result)
+	}
+
+	if dead > 0 {
+		logger.Info("🔗 已过滤失效链接",
+			zap.Int("total", len(results)),
+			zap.Int("dead", dead),
+		)
+	}
+	return filtered
+}
+
+// RecheckSources 复检所有已上线的本地资源，失效资源设置Status=0
+func (s *linkCheckService) RecheckSources(ctx context.Context) (int, int, error) {
+	var lastID uint64
+	checked, dead := 0, 0
+
+	for {
+		sources, err := s.sourceRepo.ListAfterID(ctx, lastID, 1, linkRecheckBatchSize)
+		if err != nil {
+			return checked, dead, err
+		}
+
+		for _, source := range sources {
+			lastID = source.SourceID
+
+			status := s.Check(ctx, source.IsType, source.URL, source.Password)
+			checked++
+			if status == model.LinkStatusDead {
+				source.Status = 0
+				if err := s.sourceRepo.Update(ctx, source); err != nil {
+					logger.Warn("下线失效资源失败", zap.Uint64("source_id", source.SourceID), zap.Error(err))
+					continue
+				}
+				dead++
+				logger.Info("🔗 资源链接已失效，已下线",
+					zap.Uint64("source_id", source.SourceID),
+					zap.String("title", source.Title),
+				)
+			}
+
+			select {
+			case <-ctx.Done():
+				return checked, dead, ctx.Err()
+			case <-time.After(linkRecheckDelay):
+			}
+		}
+
+		if len(sources) < linkRecheckBatchSize {
+			break
+		}
+	}
+
+	return checked, dead, nil
+}
+
+// StartScheduledRecheck 按link_recheck_interval(小时)定时复检本地资源，配置为0时跳过
+func (s *linkCheckService) StartScheduledRecheck(ctx context.Context) {
+	logger.Info("⏰ 启动资源链接定时复检任务")
+
+	for {
+		interval := 24
+		if conf, err := s.configRepo.GetInt(ctx, model.ConfLinkRecheckInterval); err == nil && conf >= 0 {
+			interval = conf
+		}
+
+		wait := time.Duration(interval) * time.Hour
+		if interval == 0 {
+			// 未开启复检时每小时重新读取一次配置
+			wait = time.Hour
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if interval == 0 {
+			continue
+		}
+
+		start := time.Now()
+		checked, dead, err := s.RecheckSources(ctx)
+		if err != nil {
+			logger.Error("资源链接复检失败", zap.Error(err))
+			continue
+		}
+		logger.Info("✅ 资源链接复检完成",
+			zap.Int("checked", checked),
+			zap.Int("dead", dead),
+			zap.Duration("elapsed", time.Since(start)),
+		)
+	}
+}
+
+// passwordFromURL 从分享链接参数中提取提取码（如 ?pwd=xxxx）
+func passwordFromURL(shareURL string) string {
+	parsed, err := url.Parse(shareURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("pwd")
+}
@@ -0,0 +1,60 @@
+﻿package service
+
+import (
+	"context"
+	"testing"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository/repotest"
+)
+
+// stubLinkChecker 按链接和提取码返回预设状态的网盘客户端
+type stubLinkChecker struct {
+	stubNetdisk
+	check func(shareURL, password string) model.LinkStatus
+}
+
+func (c *stubLinkChecker) CheckLink(ctx context.Context, shareURL, password string) (model.LinkStatus, error) {
+	return c.check(shareURL, password), nil
+}
+
+func TestRecheckSourcesUsesStoredPassword(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository(
+		&model.Source{SourceID: 1, Title: "带提取码", URL: "https://pan.quark.cn/s/recheck-pwd", Password: "abcd", IsType: model.PanTypeQuark, Status: 1},
+		&model.Source{SourceID: 2, Title: "已失效", URL: "https://pan.quark.cn/s/recheck-dead", IsType: model.PanTypeQuark, Status: 1},
+	)
+	passwords := make(map[string]string)
+	checker := &stubLinkChecker{check: func(shareURL, password string) model.LinkStatus {
+		passwords[shareURL] = password
+		if shareURL == "https://pan.quark.cn/s/recheck-dead" {
+			return model.LinkStatusDead
+		}
+		return model.LinkStatusAlive
+	}}
+	s := &linkCheckService{
+		configRepo:     repotest.NewConfigRepository(nil),
+		sourceRepo:     sourceRepo,
+		netdiskManager: &stubNetdiskManager{client: checker},
+	}
+
+	checked, dead, err := s.RecheckSources(context.Background())
+	if err != nil {
+		t.Fatalf("RecheckSources() error = %v", err)
+	}
+	if checked != 2 || dead != 1 {
+		t.Errorf("checked/dead = %d/%d, want 2/1", checked, dead)
+	}
+	if got := passwords["https://pan.quark.cn/s/recheck-pwd"]; got != "abcd" {
+		t.Errorf("CheckLink() password = %q, want stored password", got)
+	}
+
+	for _, source := range sourceRepo.All() {
+		wantStatus := 1
+		if source.SourceID == 2 {
+			wantStatus = 0
+		}
+		if source.Status != wantStatus {
+			t.Errorf("source %d status = %d, want %d", source.SourceID, source.Status, wantStatus)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/metrics"
+	"huoxing-search/pansou/plugin"
+	pansouService "huoxing-search/pansou/service"
+)
+
+// 搜索阶段
+const (
+	searchStageLocal    = "local"
+	searchStageCustom   = "custom"
+	searchStagePansou   = "pansou"
+	searchStageTransfer = "transfer"
+)
+
+// 转存结果
+const (
+	transferResultSuccess = "success"
+	transferResultFailed  = "failed"
+	transferResultTimeout = "timeout"
+)
+
+var (
+	searchStageDuration = metrics.NewHistogramVec(
+		"huoxing_search_stage_duration_seconds",
+		"搜索各阶段耗时（local本地库、custom自定义接口、pansou插件搜索、transfer自动转存）",
+		nil, "stage",
+	)
+	transferTotal = metrics.NewCounterVec(
+		"huoxing_transfer_total",
+		"单个链接的转存次数，按网盘和结果（success/failed/timeout）统计",
+		"netdisk", "result",
+	)
+	transferDuration = metrics.NewHistogramVec(
+		"huoxing_transfer_duration_seconds",
+		"单个链接的转存耗时",
+		nil, "netdisk",
+	)
+)
+
+func init() {
+	metrics.RegisterFunc(collectSearchCacheMetrics)
+	metrics.RegisterFunc(collectPluginMetrics)
+	metrics.RegisterFunc(collectTGChannelMetrics)
+	metrics.RegisterFunc(collectTransferJobMetrics)
+}
+
+// observeSearchStage 记录搜索阶段耗时
+func observeSearchStage(stage string, start time.Time) {
+	searchStageDuration.Observe(time.Since(start).Seconds(), stage)
+}
+
+// observeTransfer 记录单个链接的转存结果
+func observeTransfer(panType int, start time.Time, err error) {
+	netdisk := model.GetCloudType(panType)
+	result := transferResultSuccess
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result = transferResultTimeout
+	case err != nil:
+		result = transferResultFailed
+	}
+	transferTotal.Inc(netdisk, result)
+	transferDuration.Observe(time.Since(start).Seconds(), netdisk)
+}
+
+// collectSearchCacheMetrics 搜索结果缓存命中情况
+func collectSearchCacheMetrics() []metrics.Family {
+	return []metrics.Family{{
+		Name:       "huoxing_search_cache_requests_total",
+		Help:       "搜索结果缓存查询次数，按命中（hit）和未命中（miss）统计",
+		Type:       metrics.TypeCounter,
+		LabelNames: []string{"result"},
+		Samples: []metrics.Sample{
+			{Labels: []string{"hit"}, Value: float64(atomic.LoadInt64(&searchCacheHits))},
+			{Labels: []string{"miss"}, Value: float64(atomic.LoadInt64(&searchCacheMisses))},
+		},
+	}}
+}
+
+// collectPluginMetrics Pansou插件调用、插件缓存和异步工作池状态
+func collectPluginMetrics() []metrics.Family {
+	stats := plugin.GetPluginStats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pluginFamily := func(name, help string, value func(plugin.PluginStats) float64) metrics.Family {
+		f := metrics.Family{Name: name, Help: help, Type: metrics.TypeCounter, LabelNames: []string{"plugin"}}
+		for _, n := range names {
+			f.Samples = append(f.Samples, metrics.Sample{Labels: []string{n}, Value: value(stats[n])})
+		}
+		return f
+	}
+	gauge := func(name, help string, value int) metrics.Family {
+		return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: float64(value)}}}
+	}
+
+	// 熔断中（含半开探测）的插件为1
+	circuit := metrics.Family{Name: "huoxing_plugin_circuit_open", Help: "插件是否因连续失败被熔断", Type: metrics.TypeGauge, LabelNames: []string{"plugin"}}
+	for _, st := range plugin.GetPluginStatuses(pansouPluginManager.GetPlugins()) {
+		if st.Breaker != plugin.BreakerClosed {
+			circuit.Samples = append(circuit.Samples, metrics.Sample{Labels: []string{st.Name}, Value: 1})
+		}
+	}
+
+	async := plugin.GetAsyncStats()
+	return []metrics.Family{
+		pluginFamily("huoxing_plugin_requests_total", "插件调用次数（含命中插件缓存）",
+			func(s plugin.PluginStats) float64 { return float64(s.Requests) }),
+		pluginFamily("huoxing_plugin_errors_total", "插件返回错误的次数",
+			func(s plugin.PluginStats) float64 { return float64(s.Errors) }),
+		pluginFamily("huoxing_plugin_timeouts_total", "插件超过响应超时、转入后台继续处理的次数",
+			func(s plugin.PluginStats) float64 { return float64(s.Timeouts) }),
+		pluginFamily("huoxing_plugin_cache_hits_total", "插件命中自身缓存的次数",
+			func(s plugin.PluginStats) float64 { return float64(s.CacheHits) }),
+		pluginFamily("huoxing_plugin_results_total", "插件返回的结果数（不含缓存命中）",
+			func(s plugin.PluginStats) float64 { return float64(s.Results) }),
+		pluginFamily("huoxing_plugin_duration_seconds_total", "插件累计响应耗时，除以调用次数即平均耗时",
+			func(s plugin.PluginStats) float64 { return s.DurationSeconds }),
+		{
+			Name:       "huoxing_plugin_cache_requests_total",
+			Help:       "插件缓存查询次数，按命中（hit）和未命中（miss）统计",
+			Type:       metrics.TypeCounter,
+			LabelNames: []string{"result"},
+			Samples: []metrics.Sample{
+				{Labels: []string{"hit"}, Value: float64(async.CacheHits)},
+				{Labels: []string{"miss"}, Value: float64(async.CacheMisses)},
+			},
+		},
+		{
+			Name:    "huoxing_plugin_async_completions_total",
+			Help:    "插件响应超时后在后台完成搜索的次数",
+			Type:    metrics.TypeCounter,
+			Samples: []metrics.Sample{{Value: float64(async.AsyncCompletions)}},
+		},
+		circuit,
+		gauge("huoxing_plugin_workers_active", "插件异步工作池正在使用的工作槽", async.ActiveWorkers),
+		gauge("huoxing_plugin_workers_max", "插件异步工作池的工作槽总数", async.MaxWorkers),
+		gauge("huoxing_plugin_background_tasks", "插件正在执行的后台任务数", async.BackgroundTasks),
+		gauge("huoxing_plugin_background_tasks_max", "插件后台任务数上限", async.MaxBackgroundTasks),
+	}
+}
+
+// collectTGChannelMetrics TG频道搜索次数、失败次数和耗时
+func collectTGChannelMetrics() []metrics.Family {
+	stats := pansouService.GetChannelStats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	channelFamily := func(name, help string, value func(pansouService.ChannelStats) float64) metrics.Family {
+		f := metrics.Family{Name: name, Help: help, Type: metrics.TypeCounter, LabelNames: []string{"channel"}}
+		for _, n := range names {
+			f.Samples = append(f.Samples, metrics.Sample{Labels: []string{n}, Value: value(stats[n])})
+		}
+		return f
+	}
+
+	return []metrics.Family{
+		channelFamily("huoxing_tg_channel_requests_total", "TG频道搜索次数（不含命中TG缓存）",
+			func(s pansouService.ChannelStats) float64 { return float64(s.Requests) }),
+		channelFamily("huoxing_tg_channel_hits_total", "TG频道返回了结果的搜索次数",
+			func(s pansouService.ChannelStats) float64 { return float64(s.Hits) }),
+		channelFamily("huoxing_tg_channel_errors_total", "TG频道请求或解析失败的次数",
+			func(s pansouService.ChannelStats) float64 { return float64(s.Errors) }),
+		channelFamily("huoxing_tg_channel_duration_seconds_total", "TG频道累计搜索耗时，除以搜索次数即平均耗时",
+			func(s pansouService.ChannelStats) float64 { return s.DurationSeconds }),
+	}
+}
+
+// collectTransferJobMetrics 异步转存任务工作池状态，服务未启动时不输出
+func collectTransferJobMetrics() []metrics.Family {
+	svc, ok := GetTransferJobService().(*transferJobService)
+	if !ok || svc == nil {
+		return nil
+	}
+	workers, active, queued := svc.poolStats()
+	return []metrics.Family{
+		{Name: "huoxing_transfer_job_workers", Help: "异步转存任务工作协程数", Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: float64(workers)}}},
+		{Name: "huoxing_transfer_job_workers_active", Help: "正在执行的异步转存任务数", Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: float64(active)}}},
+		{Name: "huoxing_transfer_job_queued", Help: "已派发、等待工作协程执行的异步转存任务数", Type: metrics.TypeGauge, Samples: []metrics.Sample{{Value: float64(queued)}}},
+	}
+}
@@ -0,0 +1,176 @@
+﻿package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+// NetdiskAccountService 网盘账号池服务接口
+type NetdiskAccountService interface {
+	List(ctx context.Context, panType int) ([]*model.NetdiskAccount, error)
+	GetByID(ctx context.Context, id int) (*model.NetdiskAccount, error)
+	Create(ctx context.Context, account *model.NetdiskAccount) error
+	Update(ctx context.Context, account *model.NetdiskAccount) error
+	Delete(ctx context.Context, id int) error
+	UpdateStatus(ctx context.Context, id int, status int) error
+	Test(ctx context.Context, id int) (*model.NetdiskAccount, error)
+	CheckAll(ctx context.Context)
+	StartHealthCheck(ctx context.Context, interval time.Duration)
+}
+
+type netdiskAccountService struct {
+	accountRepo    repository.NetdiskAccountRepository
+	netdiskManager netdisk.NetdiskManager
+}
+
+// NewNetdiskAccountService 创建网盘账号池服务
+func NewNetdiskAccountService(netdiskManager netdisk.NetdiskManager) NetdiskAccountService {
+	return &netdiskAccountService{
+		accountRepo:    repository.NewNetdiskAccountRepository(),
+		netdiskManager: netdiskManager,
+	}
+}
+
+// List 获取账号列表（panType<0时返回全部）
+func (s *netdiskAccountService) List(ctx context.Context, panType int) ([]*model.NetdiskAccount, error) {
+	return s.accountRepo.List(ctx, panType)
+}
+
+// GetByID 根据ID获取账号
+func (s *netdiskAccountService) GetByID(ctx context.Context, id int) (*model.NetdiskAccount, error) {
+	return s.accountRepo.GetByID(ctx, id)
+}
+
+// Create 创建账号
+func (s *netdiskAccountService) Create(ctx context.Context, account *model.NetdiskAccount) error {
+	if err := validateNetdiskAccount(account); err != nil {
+		return err
+	}
+	if strings.TrimSpace(account.Credential) == "" {
+		return fmt.Errorf("Cookie/Token不能为空")
+	}
+	account.Credential = strings.TrimSpace(account.Credential)
+	return s.accountRepo.Create(ctx, account)
+}
+
+// Update 更新账号，凭证留空表示不修改
+func (s *netdiskAccountService) Update(ctx context.Context, account *model.NetdiskAccount) error {
+	if err := validateNetdiskAccount(account); err != nil {
+		return err
+	}
+
+	existing, err := s.accountRepo.GetByID(ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("账号不存在")
+	}
+
+	account.PanType = existing.PanType
+	account.Credential = strings.TrimSpace(account.Credential)
+	if account.Credential == "" || strings.Contains(account.Credential, "******") {
+		// 未填写或原样提交了脱敏后的凭证
+		account.Credential = existing.Credential
+	} else if account.Credential != existing.Credential && account.Status == model.NetdiskAccountAbnormal {
+		// 更换凭证后重新启用，由下一次使用或健康检查验证
+		account.Status = model.NetdiskAccountEnabled
+	}
+	return s.accountRepo.Update(ctx, account)
+}
+
+// Delete 删除账号
+func (s *netdiskAccountService) Delete(ctx context.Context, id int) error {
+	return s.accountRepo.Delete(ctx, id)
+}
+
+// UpdateStatus 手动启用/禁用账号
+func (s *netdiskAccountService) UpdateStatus(ctx context.Context, id int, status int) error {
+	if status != model.NetdiskAccountDisabled && status != model.NetdiskAccountEnabled {
+		return fmt.Errorf("无效的状态: %d", status)
+	}
+	return s.accountRepo.UpdateStatus(ctx, id, status, "")
+}
+
+// Test 测试账号连接，结果（状态、容量、错误信息）会写回账号
+func (s *netdiskAccountService) Test(ctx context.Context, id int) (*model.NetdiskAccount, error) {
+	client, err := s.netdiskManager.GetAccountClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	testErr := client.TestConnection(ctx)
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return account, testErr
+}
+
+// CheckAll 检查所有启用和异常停用的账号：失败的停用，恢复的重新启用
+func (s *netdiskAccountService) CheckAll(ctx context.Context) {
+	accounts, err := s.accountRepo.ListByStatus(ctx, -1, []int{model.NetdiskAccountEnabled, model.NetdiskAccountAbnormal})
+	if err != nil {
+		logger.Error("读取网盘账号失败", zap.Error(err))
+		return
+	}
+	if len(accounts) == 0 {
+		return
+	}
+
+	logger.Info("🩺 开始网盘账号健康检查", zap.Int("count", len(accounts)))
+
+	healthy := 0
+	for _, account := range accounts {
+		testCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		_, err := s.Test(testCtx, account.ID)
+		cancel()
+		if err == nil {
+			healthy++
+		}
+	}
+
+	logger.Info("🩺 网盘账号健康检查完成",
+		zap.Int("total", len(accounts)),
+		zap.Int("healthy", healthy),
+		zap.Int("unhealthy", len(accounts)-healthy),
+	)
+}
+
+// StartHealthCheck 启动定时健康检查
+func (s *netdiskAccountService) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("⏰ 启动网盘账号健康检查", zap.Duration("interval", interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckAll(ctx)
+		}
+	}
+}
+
+// validateNetdiskAccount 校验账号参数
+func validateNetdiskAccount(account *model.NetdiskAccount) error {
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		return fmt.Errorf("账号名称不能为空")
+	}
+	if netdisk.SaveDirConfigKey(account.PanType, false) == "" {
+		return fmt.Errorf("不支持的网盘类型: %d", account.PanType)
+	}
+	if account.Status != model.NetdiskAccountDisabled && account.Status != model.NetdiskAccountEnabled && account.Status != model.NetdiskAccountAbnormal {
+		return fmt.Errorf("无效的状态: %d", account.Status)
+	}
+	return nil
+}
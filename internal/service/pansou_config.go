@@ -0,0 +1,98 @@
+﻿package service
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	appConfig "huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+	pansouConfig "huoxing-search/pansou/config"
+	"huoxing-search/pansou/plugin"
+	pansouService "huoxing-search/pansou/service"
+)
+
+// pansouPluginManager 所有搜索服务共用的插件管理器，配置热更新时统一替换启用的插件
+var pansouPluginManager = plugin.NewPluginManager()
+
+func init() {
+	appConfig.Subscribe("pansou", reloadPansouConfig)
+}
+
+// pansouConfigLayers 构建Pansou引擎环境变量之外的配置层，优先级：系统配置表 > config.yaml
+// 系统配置表中值为空的配置项视为未设置
+func pansouConfigLayers(ctx context.Context, configRepo repository.ConfigRepository, cfg *appConfig.Config) []pansouConfig.Layer {
+	names := make([]string, 0, len(pansouConfig.Settings))
+	keys := make(map[string]string, len(pansouConfig.Settings))
+	for _, s := range pansouConfig.Settings {
+		names = append(names, s.Name())
+		keys[s.Name()] = s.Key
+	}
+
+	dbValues := make(map[string]string)
+	if configRepo != nil {
+		values, err := configRepo.GetByNames(ctx, names)
+		if err != nil {
+			logger.Warn("⚠️ 读取Pansou引擎配置失败，忽略系统配置表", zap.Error(err))
+		}
+		for name, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				dbValues[keys[name]] = value
+			}
+		}
+	}
+
+	var yamlValues map[string]string
+	if cfg != nil {
+		yamlValues = cfg.Pansou.EngineValues()
+	}
+
+	return []pansouConfig.Layer{
+		{Source: pansouConfig.SourceDB, Values: dbValues},
+		{Source: pansouConfig.SourceYAML, Values: yamlValues},
+	}
+}
+
+// applyPansouPluginFilter 按当前Pansou配置重新注册启用的插件
+func applyPansouPluginFilter() {
+	enabled := pansouConfig.AppConfig.EnabledPlugins
+	if !pansouConfig.AppConfig.AsyncPluginEnabled {
+		enabled = []string{}
+	}
+	pansouPluginManager.ReplaceGlobalPluginsWithFilter(enabled)
+}
+
+// reloadPansouConfig 系统配置的 pansou_* 或配置文件变更后重新加载Pansou引擎配置，并按新配置启用插件
+// 缓存目录、工作池大小等引擎启动时使用的配置仍需重启生效
+func reloadPansouConfig(ev appConfig.ChangeEvent) error {
+	if ev.Source == appConfig.ChangeSourceDB && !ev.HasName(isPansouSettingName) {
+		return nil
+	}
+	if pansouConfig.AppConfig == nil {
+		// 引擎尚未初始化，初始化时会读取最新配置
+		return nil
+	}
+
+	pansouConfig.SetLayers(pansouConfigLayers(context.Background(), repository.NewConfigRepository(), ev.Config)...)
+	pansouConfig.Init()
+	applyPansouPluginFilter()
+	pansouService.RefreshPluginCache(pansouPluginManager)
+
+	logger.Info("🔄 Pansou引擎配置已重新加载",
+		zap.String("source", ev.Source),
+		zap.Int("plugins", len(pansouPluginManager.GetPlugins())),
+	)
+	return nil
+}
+
+// isPansouSettingName 是否为Pansou引擎配置项
+func isPansouSettingName(name string) bool {
+	for _, s := range pansouConfig.Settings {
+		if s.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+﻿package service
+
+import (
+	"context"
+	"testing"
+
+	appConfig "huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/repository/repotest"
+	pansouConfig "huoxing-search/pansou/config"
+)
+
+func TestPansouConfigLayers(t *testing.T) {
+	configRepo := repotest.NewConfigRepository(map[string]string{
+		"pansou_plugin_timeout": "20",
+		"pansou_cache_ttl":      " ",
+		"pansou_url":            "http://localhost:8888",
+	})
+	cfg := &appConfig.Config{Pansou: appConfig.PansouConfig{Engine: map[string]interface{}{
+		"channels":       []interface{}{"tgsearchers4", "ysxb"},
+		"plugin_timeout": 10,
+	}}}
+
+	layers := pansouConfigLayers(context.Background(), configRepo, cfg)
+	if len(layers) != 2 || layers[0].Source != pansouConfig.SourceDB || layers[1].Source != pansouConfig.SourceYAML {
+		t.Fatalf("layers = %+v", layers)
+	}
+	if db := layers[0].Values; len(db) != 1 || db["PLUGIN_TIMEOUT"] != "20" {
+		t.Errorf("数据库配置层 = %v, want 仅PLUGIN_TIMEOUT=20", db)
+	}
+	yaml := layers[1].Values
+	if yaml["CHANNELS"] != "tgsearchers4,ysxb" || yaml["PLUGIN_TIMEOUT"] != "10" {
+		t.Errorf("配置文件层 = %v", yaml)
+	}
+}
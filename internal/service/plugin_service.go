@@ -0,0 +1,178 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+	"huoxing-search/pansou/plugin"
+	pansouService "huoxing-search/pansou/service"
+)
+
+var (
+	// ErrPluginNotFound 插件未注册
+	ErrPluginNotFound = errors.New("插件不存在")
+	// ErrInvalidPluginPriority 优先级超出范围
+	ErrInvalidPluginPriority = errors.New("优先级必须为1-4，0表示使用默认优先级")
+)
+
+// PluginInfo 插件的设置、运行状态和调用统计
+type PluginInfo struct {
+	Name                string  `json:"name"`
+	DefaultPriority     int     `json:"default_priority"`     // 插件自身的优先级
+	Priority            int     `json:"priority"`             // 生效的优先级
+	PriorityOverride    int     `json:"priority_override"`    // 管理后台设置的优先级，0表示未覆盖
+	Loaded              bool    `json:"loaded"`               // 是否被 ENABLED_PLUGINS 启用
+	Enabled             bool    `json:"enabled"`              // 是否在管理后台启用
+	Breaker             string  `json:"breaker"`              // 熔断状态 closed/open/half_open
+	ConsecutiveFailures int     `json:"consecutive_failures"` // 连续失败次数
+	LastError           string  `json:"last_error"`
+	OpenedAt            int64   `json:"opened_at"` // 最近一次熔断的时间
+	Requests            int64   `json:"requests"`
+	CacheHits           int64   `json:"cache_hits"`
+	Errors              int64   `json:"errors"`
+	Timeouts            int64   `json:"timeouts"`
+	Results             int64   `json:"results"`
+	AvgMs               float64 `json:"avg_ms"`       // 平均响应耗时(毫秒，含缓存命中)
+	P95Ms               int64   `json:"p95_ms"`       // 最近调用响应耗时的95分位数(毫秒，不含缓存命中)
+	LastSuccess         int64   `json:"last_success"` // 最近一次成功的时间，0表示启动以来未成功过
+}
+
+// PluginService Pansou插件运行时管理服务接口
+type PluginService interface {
+	List(ctx context.Context) []PluginInfo
+	SetEnabled(ctx context.Context, name string, enabled bool) error
+	// SetPriority 覆盖插件优先级（1-4），0表示恢复插件默认优先级
+	SetPriority(ctx context.Context, name string, priority int) error
+	// ResetBreaker 手动解除熔断
+	ResetBreaker(ctx context.Context, name string) error
+}
+
+type pluginService struct {
+	settingRepo repository.PluginSettingRepository
+}
+
+// NewPluginService 创建插件管理服务
+func NewPluginService() PluginService {
+	return &pluginService{
+		settingRepo: repository.NewPluginSettingRepository(),
+	}
+}
+
+// loadPluginSettings 从数据库加载插件的启用状态和优先级覆盖（引擎初始化时调用）
+func loadPluginSettings(ctx context.Context, settingRepo repository.PluginSettingRepository) {
+	settings, err := settingRepo.List(ctx)
+	if err != nil {
+		logger.Warn("⚠️ 读取插件设置失败，使用插件默认设置", zap.Error(err))
+		return
+	}
+
+	runtime := make(map[string]plugin.PluginSetting, len(settings))
+	for _, s := range settings {
+		runtime[s.Name] = plugin.PluginSetting{
+			Disabled: s.Status == 0,
+			Priority: s.Priority,
+		}
+	}
+	plugin.SetPluginSettings(runtime)
+	pansouService.ResetPluginLevelCache()
+}
+
+// List 获取全部已注册插件
+func (s *pluginService) List(ctx context.Context) []PluginInfo {
+	statuses := plugin.GetPluginStatuses(pansouPluginManager.GetPlugins())
+	list := make([]PluginInfo, 0, len(statuses))
+	for _, st := range statuses {
+		info := PluginInfo{
+			Name:                st.Name,
+			DefaultPriority:     st.DefaultPriority,
+			Priority:            st.Priority,
+			PriorityOverride:    plugin.GetPluginSetting(st.Name).Priority,
+			Loaded:              st.Loaded,
+			Enabled:             !st.Disabled,
+			Breaker:             st.Breaker,
+			ConsecutiveFailures: st.ConsecutiveFailures,
+			LastError:           st.LastError,
+			OpenedAt:            st.OpenedAt,
+			Requests:            st.Stats.Requests,
+			CacheHits:           st.Stats.CacheHits,
+			Errors:              st.Stats.Errors,
+			Timeouts:            st.Stats.Timeouts,
+			Results:             st.Stats.Results,
+			P95Ms:               int64(st.Stats.P95Seconds * 1000),
+			LastSuccess:         st.Stats.LastSuccess,
+		}
+		if st.Stats.Requests > 0 {
+			info.AvgMs = st.Stats.DurationSeconds * 1000 / float64(st.Stats.Requests)
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+// SetEnabled 启用或禁用插件，立即对后续搜索生效
+func (s *pluginService) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	setting, err := s.current(name)
+	if err != nil {
+		return err
+	}
+	setting.Disabled = !enabled
+	return s.save(ctx, name, setting)
+}
+
+// SetPriority 覆盖插件优先级
+func (s *pluginService) SetPriority(ctx context.Context, name string, priority int) error {
+	if priority < 0 || priority > 4 {
+		return ErrInvalidPluginPriority
+	}
+	setting, err := s.current(name)
+	if err != nil {
+		return err
+	}
+	setting.Priority = priority
+	if err := s.save(ctx, name, setting); err != nil {
+		return err
+	}
+	pansouService.ResetPluginLevelCache()
+	return nil
+}
+
+// ResetBreaker 手动解除熔断
+func (s *pluginService) ResetBreaker(ctx context.Context, name string) error {
+	if _, ok := plugin.GetPluginByName(name); !ok {
+		return ErrPluginNotFound
+	}
+	plugin.ResetBreaker(name)
+	logger.Info("插件熔断已手动解除", zap.String("plugin", name))
+	return nil
+}
+
+// current 获取插件当前的运行时设置
+func (s *pluginService) current(name string) (plugin.PluginSetting, error) {
+	if _, ok := plugin.GetPluginByName(name); !ok {
+		return plugin.PluginSetting{}, ErrPluginNotFound
+	}
+	return plugin.GetPluginSetting(name), nil
+}
+
+// save 保存到数据库后再更新运行时设置
+func (s *pluginService) save(ctx context.Context, name string, setting plugin.PluginSetting) error {
+	status := 1
+	if setting.Disabled {
+		status = 0
+	}
+	if err := s.settingRepo.Save(ctx, &model.PluginSetting{Name: name, Status: status, Priority: setting.Priority}); err != nil {
+		return err
+	}
+	plugin.SetPluginSetting(name, setting)
+	logger.Info("插件设置已更新",
+		zap.String("plugin", name),
+		zap.Bool("enabled", !setting.Disabled),
+		zap.Int("priority", setting.Priority),
+	)
+	return nil
+}
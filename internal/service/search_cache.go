@@ -0,0 +1,105 @@
+﻿package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/repository"
+	"huoxing-search/pansou/plugin"
+)
+
+// 搜索缓存命中统计（进程级）
+var (
+	searchCacheHits   int64
+	searchCacheMisses int64
+)
+
+// SearchCacheStats 搜索缓存统计
+type SearchCacheStats struct {
+	Backend string  `json:"backend"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// GetSearchCacheStats 获取搜索缓存统计
+func GetSearchCacheStats() SearchCacheStats {
+	hits := atomic.LoadInt64(&searchCacheHits)
+	misses := atomic.LoadInt64(&searchCacheMisses)
+
+	stats := SearchCacheStats{
+		Backend: repository.NewCacheRepository().Backend(),
+		Hits:    hits,
+		Misses:  misses,
+	}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+func recordSearchCacheHit() {
+	atomic.AddInt64(&searchCacheHits, 1)
+}
+
+func recordSearchCacheMiss() {
+	atomic.AddInt64(&searchCacheMisses, 1)
+}
+
+// normalizeSearchKeyword 归一化关键词：去首尾空白、合并连续空白、转小写
+func normalizeSearchKeyword(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+}
+
+// searchResultMode 结果模式：是否转存 + 展示数量 + 分类 + TG频道分组 + 插件设置，不同模式的结果互不复用
+func (s *SearchService) searchResultMode(maxSearchResults, categoryID int, channelGroups []string) string {
+	mode := "raw"
+	if s.transferService != nil {
+		mode = "transfer"
+	}
+	mode = fmt.Sprintf("%s_%d", mode, maxSearchResults)
+	if categoryID > 0 {
+		mode = fmt.Sprintf("%s_c%d", mode, categoryID)
+	}
+	if len(channelGroups) > 0 {
+		groups := make([]string, len(channelGroups))
+		for i, group := range channelGroups {
+			groups[i] = strings.ToLower(group)
+		}
+		sort.Strings(groups)
+		mode = fmt.Sprintf("%s_g%s", mode, strings.Join(groups, "+"))
+	}
+	// 禁用插件或调整插件优先级后，不再复用旧设置下缓存的结果
+	if settings := plugin.SettingsFingerprint(); settings != "" {
+		mode = fmt.Sprintf("%s_p%s", mode, settings)
+	}
+	return mode
+}
+
+// searchCacheTTL 搜索缓存过期时间：优先使用配置表cache_expire(秒)，其次使用配置文件cache.search_ttl
+func (s *SearchService) searchCacheTTL(ctx context.Context) time.Duration {
+	if val, err := s.configRepo.GetInt(ctx, model.ConfCacheExpire); err == nil && val > 0 {
+		return time.Duration(val) * time.Second
+	}
+	if config.GlobalConfig != nil && config.GlobalConfig.Cache.SearchTTL > 0 {
+		return config.GlobalConfig.Cache.GetSearchCacheTTL()
+	}
+	return 60 * time.Second
+}
+
+// searchCachePattern 匹配关键词在所有结果模式下的缓存键
+func searchCachePattern(keyword string, panType int) string {
+	return fmt.Sprintf("search:%s:%d:*", escapeCachePattern(normalizeSearchKeyword(keyword)), panType)
+}
+
+// escapeCachePattern 转义Redis通配符，避免关键词中的 * ? [ 影响匹配
+func escapeCachePattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(s)
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,6 +16,8 @@ import (
 	"huoxing-search/pansou/util/cache"
 
 	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	appConfig "huoxing-search/internal/pkg/config"
 	"huoxing-search/internal/pkg/logger"
 	"huoxing-search/internal/repository"
 	
@@ -89,6 +92,8 @@ type SearchService struct {
 	sourceRepo      repository.SourceRepository
 	cacheRepo       repository.CacheRepository
 	transferService TransferService
+	customAPI       CustomAPIService
+	channelService  TGChannelService
 	pansouService   *pansouService.SearchService
 	pluginManager   *plugin.PluginManager
 	initialized     bool
@@ -101,6 +106,8 @@ func NewSearchService(configRepo repository.ConfigRepository, cacheRepo reposito
 		sourceRepo:      repository.NewSourceRepository(),
 		cacheRepo:       cacheRepo,
 		transferService: transferService,
+		customAPI:       NewCustomAPIService(repository.NewAPIConfigRepository()),
+		channelService:  NewTGChannelService(repository.NewTGChannelRepository()),
 		initialized:     false,
 	}
 	
@@ -112,7 +119,8 @@ func NewSearchService(configRepo repository.ConfigRepository, cacheRepo reposito
 
 // initPansou 初始化Pansou搜索引擎
 func (s *SearchService) initPansou() error {
-	// 初始化Pansou配置
+	// 初始化Pansou配置（环境变量 > 系统配置表 > config.yaml > 默认值）
+	config.SetLayers(pansouConfigLayers(context.Background(), s.configRepo, appConfig.GlobalConfig)...)
 	config.Init()
 	
 	// 初始化HTTP客户端
@@ -141,13 +149,12 @@ func (s *SearchService) initPansou() error {
 	// 确保异步插件系统初始化
 	plugin.InitAsyncPluginSystem()
 	
-	// 初始化插件管理器
-	s.pluginManager = plugin.NewPluginManager()
+	// 注册全局插件（根据配置过滤，所有搜索服务共用同一个插件管理器）
+	applyPansouPluginFilter()
+	s.pluginManager = pansouPluginManager
 	
-	// 注册全局插件（根据配置过滤）
-	if config.AppConfig.AsyncPluginEnabled {
-		s.pluginManager.RegisterGlobalPluginsWithFilter(config.AppConfig.EnabledPlugins)
-	}
+	// 加载管理后台设置的插件启用状态和优先级
+	loadPluginSettings(context.Background(), repository.NewPluginSettingRepository())
 	
 	// 初始化Pansou搜索服务
 	s.pansouService = pansouService.NewSearchService(s.pluginManager)
@@ -196,13 +203,51 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		zap.Int("max_transfer_count", maxTransferCount),
 	)
 	
+	// ⚡ 查询搜索结果缓存（关键词归一化 + 网盘类型 + 结果模式）
+	cacheKeyword := normalizeSearchKeyword(req.Keyword)
+	resultMode := s.searchResultMode(maxSearchResults, req.CategoryID, req.ChannelGroups)
+	var cached model.SearchResponse
+	if hit, err := s.cacheRepo.GetSearchResult(ctx, cacheKeyword, req.PanType, resultMode, &cached); err == nil && hit {
+		recordSearchCacheHit()
+		logger.Info("✅ 搜索缓存命中",
+			zap.String("keyword", cacheKeyword),
+			zap.Int("pan_type", req.PanType),
+			zap.String("mode", resultMode),
+		)
+		s.recordViews(cached.Results)
+		return &cached, nil
+	}
+	recordSearchCacheMiss()
+	
+	resp, cacheable, err := s.searchSources(ctx, req, maxSearchResults, maxTransferCount)
+	if err != nil {
+		return nil, err
+	}
+	s.recordViews(resp.Results)
+	
+	// 只缓存有结果且未降级的响应，避免转存失败等临时状态被缓存
+	if cacheable && resp.Total > 0 {
+		ttl := s.searchCacheTTL(ctx)
+		if err := s.cacheRepo.SetSearchResult(ctx, cacheKeyword, req.PanType, resultMode, resp, ttl); err != nil {
+			logger.Warn("写入搜索缓存失败", zap.Error(err))
+		}
+	}
+	
+	return resp, nil
+}
+
+// searchSources 依次搜索本地数据库、自定义接口和Pansou，并按需转存
+// 返回值cacheable表示结果是否可以写入搜索缓存
+func (s *SearchService) searchSources(ctx context.Context, req model.SearchRequest, maxSearchResults, maxTransferCount int) (*model.SearchResponse, bool, error) {
 	// 🔍 第一步: 优先搜索本地数据库
 	logger.Info("开始搜索本地数据库",
 		zap.String("keyword", req.Keyword),
 		zap.Int("pan_type", req.PanType),
 	)
 	
-	localSources, err := s.sourceRepo.SearchByKeywordAndType(ctx, req.Keyword, req.PanType, maxSearchResults)
+	localStart := time.Now()
+	localSources, err := s.sourceRepo.SearchByKeywordAndType(ctx, req.Keyword, req.PanType, req.CategoryID, maxSearchResults)
+	observeSearchStage(searchStageLocal, localStart)
 	if err == nil && len(localSources) > 0 {
 		logger.Info("✅ 本地数据库命中",
 			zap.Int("count", len(localSources)),
@@ -214,10 +259,25 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 			Total:   len(results),
 			Results: results,
 			Message: "搜索成功(本地)",
-		}, nil
+		}, true, nil
+	}
+	
+	logger.Info("本地数据库无结果,开始调用自定义接口和Pansou搜索")
+	
+	// 获取 max(maxSearchResults * 4, 20) 个结果，确保有足够的资源进行转存筛选
+	fetchCount := maxSearchResults * 4
+	if fetchCount < 20 {
+		fetchCount = 20
 	}
 	
-	logger.Info("本地数据库无结果,开始调用Pansou搜索")
+	// 🔌 自定义搜索接口(qf_api_list)与Pansou并行执行
+	customCh := make(chan []model.SearchResult, 1)
+	go func() {
+		customStart := time.Now()
+		results := s.customAPI.Search(ctx, req.Keyword, req.PanType, fetchCount)
+		observeSearchStage(searchStageCustom, customStart)
+		customCh <- results
+	}()
 	
 	// 🌐 第二步: 本地无结果,调用Pansou搜索引擎
 	// 等待Pansou初始化完成(最多等待5秒)
@@ -225,50 +285,74 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		time.Sleep(100 * time.Millisecond)
 	}
 	
-	if !s.initialized {
-		return nil, fmt.Errorf("Pansou搜索引擎初始化失败")
+	var pansouResults []model.SearchResult
+	var pansouErr error
+	if s.initialized {
+		cloudType := model.GetCloudType(req.PanType)
+		cloudTypes := []string{cloudType}
+		channels := s.searchChannels(ctx, req.ChannelGroups)
+		
+		// 调用Pansou搜索(获取20个结果用于转存)
+		// 🔧 关键修复：让pansou使用所有可用插件
+		pansouStart := time.Now()
+		pansouResp, err := s.pansouService.Search(
+			req.Keyword,
+			channels,                        // 频道表中启用的频道（可按分组指定），频道表为空时使用CHANNELS配置
+			config.AppConfig.DefaultConcurrency,
+			false,                           // 不强制刷新，使用缓存
+			"merged_by_type",                // 🔧 返回按类型合并的结果（包含多插件来源）
+			"all",                           // ✅ 搜索所有来源（TG频道 + 插件）
+			nil,                             // ✅ nil = 使用所有可用插件（50+插件）
+			cloudTypes,
+			nil,
+		)
+		observeSearchStage(searchStagePansou, pansouStart)
+		if err != nil {
+			pansouErr = fmt.Errorf("Pansou搜索失败: %w", err)
+		} else {
+			// 转换Pansou结果 - 获取足够多的结果用于后续展示和转存
+			pansouResults = s.convertPansouResults(pansouResp, cloudType, fetchCount)
+		}
+	} else {
+		pansouErr = fmt.Errorf("Pansou搜索引擎初始化失败")
 	}
 	
-	cloudType := model.GetCloudType(req.PanType)
-	cloudTypes := []string{cloudType}
+	customResults := <-customCh
 	
-	// 调用Pansou搜索(获取20个结果用于转存)
-	// 🔧 关键修复：让pansou使用所有可用插件
-	pansouResp, err := s.pansouService.Search(
-		req.Keyword,
-		[]string{},                      // 🔧 改为空数组，让pansou使用默认配置（而非只用1个TG频道）
-		config.AppConfig.DefaultConcurrency,
-		false,                           // 不强制刷新，使用缓存
-		"merged_by_type",                // 🔧 返回按类型合并的结果（包含多插件来源）
-		"all",                           // ✅ 搜索所有来源（TG频道 + 插件）
-		nil,                             // ✅ nil = 使用所有可用插件（50+插件）
-		cloudTypes,
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("Pansou搜索失败: %w", err)
+	// 自定义接口失败不影响搜索，Pansou失败且自定义接口无结果时才返回错误
+	if pansouErr != nil {
+		if len(customResults) == 0 {
+			return nil, false, pansouErr
+		}
+		logger.Warn("Pansou搜索不可用，仅使用自定义接口结果", zap.Error(pansouErr))
 	}
 	
-	// 转换Pansou结果 - 获取足够多的结果用于后续展示和转存
-	// 获取 max(maxSearchResults * 4, 20) 个结果，确保有足够的资源进行转存筛选
-	fetchCount := maxSearchResults * 4
-	if fetchCount < 20 {
-		fetchCount = 20
+	// 合并结果：自定义接口(已按权重排序)在前，Pansou在后，按链接去重
+	externalResults := mergeExternalResults(customResults, pansouResults, fetchCount)
+
+	// 🏷️ 指定分类时按分类规则过滤外部结果
+	if req.CategoryID > 0 {
+		externalResults = filterResultsByCategory(ctx, externalResults, req.CategoryID)
+	}
+	
+	// 🔗 检测链接有效性，过滤失效链接（避免展示死链和浪费转存名额）
+	// 微信公众号场景(转存服务为nil)有5秒响应限制，跳过检测
+	if linkChecker := GetLinkCheckService(); linkChecker != nil && s.transferService != nil {
+		externalResults = linkChecker.FilterSearchResults(ctx, externalResults)
 	}
-	pansouResults := s.convertPansouResults(pansouResp, cloudType, fetchCount)
 	
-	if len(pansouResults) == 0 {
-		logger.Info("Pansou搜索无结果")
+	if len(externalResults) == 0 {
+		logger.Info("自定义接口和Pansou均无结果")
 		return &model.SearchResponse{
 			Total:   0,
 			Results: []model.SearchResult{},
 			Message: "未找到相关资源",
-		}, nil
+		}, false, nil
 	}
 	
 	// 📦 第三步: 尝试批量转存（如果转存服务可用且网盘已配置）
-	logger.Info("📦 Pansou返回结果,检查是否可以转存",
-		zap.Int("count", len(pansouResults)),
+	logger.Info("📦 外部搜索返回结果,检查是否可以转存",
+		zap.Int("count", len(externalResults)),
 		zap.Int("target_display", maxSearchResults),
 		zap.Int("target_transfer", maxTransferCount),
 	)
@@ -281,22 +365,34 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		
 		// 限制返回数量为配置的最大搜索结果数
 		displayCount := maxSearchResults
-		if displayCount > len(pansouResults) {
-			displayCount = len(pansouResults)
+		if displayCount > len(externalResults) {
+			displayCount = len(externalResults)
 		}
 		
 		finalResults := make([]model.SearchResult, 0, displayCount)
 		for i := 0; i < displayCount; i++ {
-			result := pansouResults[i]
+			result := externalResults[i]
 			result.IsTransferred = false
 			finalResults = append(finalResults, result)
 		}
 		
+		// 异步模式下交给后台任务转存，转存结果入库后下次搜索可直接命中本地
+		if req.Async && s.isNetdiskConfigured(ctx, req.PanType) {
+			if jobNo, _, handled := s.enqueueTransferJob(ctx, req, externalResults, maxSearchResults, maxTransferCount); handled {
+				return &model.SearchResponse{
+					Total:   len(finalResults),
+					Results: finalResults,
+					Message: "搜索成功(原始链接，后台转存中)",
+					JobNo:   jobNo,
+				}, false, nil
+			}
+		}
+		
 		return &model.SearchResponse{
 			Total:   len(finalResults),
 			Results: finalResults,
 			Message: "搜索成功(原始链接，微信公众号)",
-		}, nil
+		}, true, nil
 	}
 	
 	// 检查网盘是否已配置
@@ -310,13 +406,13 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		
 		// 限制返回数量为配置的最大搜索结果数
 		displayCount := maxSearchResults
-		if displayCount > len(pansouResults) {
-			displayCount = len(pansouResults)
+		if displayCount > len(externalResults) {
+			displayCount = len(externalResults)
 		}
 		
 		finalResults := make([]model.SearchResult, 0, displayCount)
 		for i := 0; i < displayCount; i++ {
-			result := pansouResults[i]
+			result := externalResults[i]
 			result.IsTransferred = false  // 标记为未转存
 			finalResults = append(finalResults, result)
 		}
@@ -325,44 +421,90 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 			Total:   len(finalResults),
 			Results: finalResults,
 			Message: "搜索成功(原始链接，网盘未配置)",
-		}, nil
+		}, true, nil
+	}
+	
+	// 🔑 按API Key的转存权限和每日额度收紧转存数量（匿名请求不受限制）
+	grant, commitTransfers, err := ReserveAPIKeyTransfers(ctx, maxTransferCount)
+	if err != nil {
+		logger.Info("⚠️ API Key无法转存，直接返回原始搜索结果", zap.Error(err))
+		
+		displayCount := maxSearchResults
+		if displayCount > len(externalResults) {
+			displayCount = len(externalResults)
+		}
+		
+		finalResults := make([]model.SearchResult, 0, displayCount)
+		for i := 0; i < displayCount; i++ {
+			result := externalResults[i]
+			result.IsTransferred = false
+			finalResults = append(finalResults, result)
+		}
+		
+		// 额度只对当前密钥有效，不写入共享的搜索缓存
+		return &model.SearchResponse{
+			Total:   len(finalResults),
+			Results: finalResults,
+			Message: "搜索成功(原始链接，" + err.Error() + ")",
+		}, false, nil
+	}
+	maxTransferCount = grant
+	transferred := 0
+	defer func() { commitTransfers(transferred) }()
+	
+	// ⏳ 异步模式: 先返回原始链接，转存交给后台任务，前端通过任务编号轮询结果
+	if req.Async {
+		if jobNo, created, handled := s.enqueueTransferJob(ctx, req, externalResults, maxSearchResults, maxTransferCount); handled {
+			// 新建的异步任务按预占数量计入API Key用量，复用进行中的任务不重复计入
+			if created {
+				transferred = maxTransferCount
+			}
+			displayCount := maxSearchResults
+			if displayCount > len(externalResults) {
+				displayCount = len(externalResults)
+			}
+			
+			finalResults := make([]model.SearchResult, 0, displayCount)
+			for i := 0; i < displayCount; i++ {
+				result := externalResults[i]
+				result.IsTransferred = false
+				finalResults = append(finalResults, result)
+			}
+			
+			return &model.SearchResponse{
+				Total:   len(finalResults),
+				Results: finalResults,
+				Message: "搜索成功(原始链接，后台转存中)",
+				JobNo:   jobNo,
+			}, false, nil
+		}
 	}
 	
 	// 执行转存
 	logger.Info("✅ 网盘已配置，开始批量转存（两阶段处理）",
-		zap.Int("count", len(pansouResults)),
+		zap.Int("count", len(externalResults)),
 		zap.Int("target_transfer", maxTransferCount),      // 🔧 使用配置的转存数量
 		zap.Int("target_display", maxSearchResults),       // 🔧 使用配置的展示数量
 	)
 	
-	// 获取ExpiredType配置(1=永久, 2=临时)
-	expiredType := 2 // 默认临时（is_time=1）
-	if expiredConf, err := s.configRepo.GetInt(ctx, "default_expired_type"); err == nil && expiredConf > 0 {
-		expiredType = expiredConf
-	}
-	
-	transferReq := &model.TransferRequest{
-		Items:       pansouResults,
-		PanType:     req.PanType,
-		MaxCount:    maxTransferCount,    // 🔧 使用配置表中的转存数量
-		MaxDisplay:  maxSearchResults,    // 🔧 使用配置表中的展示数量
-		ExpiredType: expiredType,         // 设置过期类型（临时资源）
-	}
+	transferReq := s.buildTransferRequest(ctx, req.PanType, externalResults, maxSearchResults, maxTransferCount)
 	
+	transferStart := time.Now()
 	transferResp, err := s.transferService.TransferAndSave(ctx, transferReq)
+	observeSearchStage(searchStageTransfer, transferStart)
 	if err != nil {
 		// 转存失败，但不影响搜索功能，返回原始链接
 		logger.Warn("转存失败，返回原始搜索结果", zap.Error(err))
 		
 		// 限制返回数量为配置的最大搜索结果数
 		displayCount := maxSearchResults
-		if displayCount > len(pansouResults) {
-			displayCount = len(pansouResults)
+		if displayCount > len(externalResults) {
+			displayCount = len(externalResults)
 		}
 		
 		finalResults := make([]model.SearchResult, 0, displayCount)
 		for i := 0; i < displayCount; i++ {
-			result := pansouResults[i]
+			result := externalResults[i]
 			result.IsTransferred = false
 			finalResults = append(finalResults, result)
 		}
@@ -371,7 +513,7 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 			Total:   len(finalResults),
 			Results: finalResults,
 			Message: "搜索成功(原始链接，转存失败)",
-		}, nil
+		}, false, nil
 	}
 	
 	if len(transferResp.Results) == 0 {
@@ -379,13 +521,13 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		
 		// 限制返回数量为配置的最大搜索结果数
 		displayCount := maxSearchResults
-		if displayCount > len(pansouResults) {
-			displayCount = len(pansouResults)
+		if displayCount > len(externalResults) {
+			displayCount = len(externalResults)
 		}
 		
 		finalResults := make([]model.SearchResult, 0, displayCount)
 		for i := 0; i < displayCount; i++ {
-			result := pansouResults[i]
+			result := externalResults[i]
 			result.IsTransferred = false
 			finalResults = append(finalResults, result)
 		}
@@ -394,9 +536,11 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 			Total:   len(finalResults),
 			Results: finalResults,
 			Message: "搜索成功(原始链接，转存全部失败)",
-		}, nil
+		}, false, nil
 	}
 	
+	transferred = transferResp.Success
+	
 	logger.Info("✅ 转存完成（两阶段）",
 		zap.Int("total_display", len(transferResp.Results)),     // 总展示数量
 		zap.Int("transferred", transferResp.Success),            // 实际转存数量
@@ -414,9 +558,9 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 			// 获取原始来源信息
 			var sourceName string
 			var sourceTime string
-			if i < len(pansouResults) {
-				sourceName = pansouResults[i].Source  // 来源插件名
-				sourceTime = pansouResults[i].Time    // 来源时间
+			if i < len(externalResults) {
+				sourceName = externalResults[i].Source  // 来源插件名
+				sourceTime = externalResults[i].Time    // 来源时间
 			}
 			
 			// 显示真实来源，而不是"已转存"
@@ -441,19 +585,71 @@ func (s *SearchService) Search(ctx context.Context, req model.SearchRequest) (*m
 		Total:   len(finalResults),
 		Results: finalResults,
 		Message: fmt.Sprintf("搜索成功(已转存%d条,原始链接%d条)", transferResp.Success, len(finalResults)-transferResp.Success),
-	}, nil
+	}, true, nil
+}
+
+// buildTransferRequest 根据搜索结果构建转存请求
+func (s *SearchService) buildTransferRequest(ctx context.Context, panType int, items []model.SearchResult, maxSearchResults, maxTransferCount int) *model.TransferRequest {
+	// 获取ExpiredType配置(1=永久, 2=临时)
+	expiredType := 2 // 默认临时（is_time=1）
+	if expiredConf, err := s.configRepo.GetInt(ctx, "default_expired_type"); err == nil && expiredConf > 0 {
+		expiredType = expiredConf
+	}
+	
+	return &model.TransferRequest{
+		Items:       items,
+		PanType:     panType,
+		MaxCount:    maxTransferCount,    // 🔧 使用配置表中的转存数量
+		MaxDisplay:  maxSearchResults,    // 🔧 使用配置表中的展示数量
+		ExpiredType: expiredType,         // 设置过期类型（临时资源）
+	}
+}
+
+// enqueueTransferJob 创建异步转存任务，返回任务编号、是否新建了任务，以及是否已交给后台处理
+// 同一关键词已有其他调用方的任务在转存时不再入队，任务编号为空；任务服务未启动或入队失败时handled为false（调用方回退到同步处理）
+func (s *SearchService) enqueueTransferJob(ctx context.Context, req model.SearchRequest, items []model.SearchResult, maxSearchResults, maxTransferCount int) (jobNo string, created, handled bool) {
+	jobService := GetTransferJobService()
+	if jobService == nil {
+		return "", false, false
+	}
+	
+	source := req.Source
+	if source == "" {
+		source = model.TransferJobSourceAPI
+	}
+	
+	transferReq := s.buildTransferRequest(ctx, req.PanType, items, maxSearchResults, maxTransferCount)
+	job, created, err := jobService.Enqueue(ctx, transferReq, source, req.Keyword)
+	if errors.Is(err, ErrTransferJobInProgress) {
+		logger.Info("关键词已在后台转存，跳过入队", zap.String("keyword", req.Keyword))
+		return "", false, true
+	}
+	if err != nil {
+		logger.Warn("创建异步转存任务失败，改为同步处理", zap.Error(err))
+		return "", false, false
+	}
+	return job.JobNo, created, true
 }
 
 // convertSourceToSearchResult 将Source转换为SearchResult
 func (s *SearchService) convertSourceToSearchResult(sources []*model.Source) []model.SearchResult {
 	results := make([]model.SearchResult, 0, len(sources))
 	for _, source := range sources {
+		// 来源为空时（手动添加或历史数据）显示为本地资源
+		sourceName := source.SourceName
+		if sourceName == "" {
+			sourceName = "本地资源"
+		}
+
 		result := model.SearchResult{
+			SourceID: source.SourceID,
 			Title:    source.Title,
 			URL:      source.URL,
-			Password: "",
-			Source:   "本地资源",
+			Password: source.Password,
+			Source:   sourceName,
 			PanType:  source.IsType,
+			Size:     model.FormatSize(source.Size),
+			Time:     source.SourceTime,
 			Content:  source.Content,
 		}
 		results = append(results, result)
@@ -461,6 +657,27 @@ func (s *SearchService) convertSourceToSearchResult(sources []*model.Source) []m
 	return results
 }
 
+// recordViews 异步累加搜索结果中本地资源的查看次数（缓存命中同样计数）
+func (s *SearchService) recordViews(results []model.SearchResult) {
+	ids := make([]uint64, 0, len(results))
+	for _, result := range results {
+		if result.SourceID > 0 {
+			ids = append(ids, result.SourceID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.sourceRepo.IncrementViewCount(ctx, ids...); err != nil {
+			logger.Warn("更新资源查看次数失败", zap.Error(err))
+		}
+	}()
+}
+
 // convertPansouResults 转换Pansou搜索结果为huoxing格式
 // 策略：从MergedByType中获取结果，这些结果已经按时间排序且来自不同插件
 func (s *SearchService) convertPansouResults(pansouResp pansouModel.SearchResponse, cloudType string, maxCount int) []model.SearchResult {
@@ -526,6 +743,41 @@ func (s *SearchService) convertPansouResults(pansouResp pansouModel.SearchRespon
 	return results
 }
 
+// filterResultsByCategory 按分类规则过滤搜索结果，自动分类服务未启动时不过滤
+func filterResultsByCategory(ctx context.Context, results []model.SearchResult, categoryID int) []model.SearchResult {
+	categoryService := GetCategoryService()
+	if categoryService == nil {
+		return results
+	}
+
+	filtered := make([]model.SearchResult, 0, len(results))
+	for _, result := range results {
+		if categoryService.Classify(ctx, result.Title) == categoryID {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// mergeExternalResults 合并自定义接口与Pansou结果，按链接去重并限制数量
+func mergeExternalResults(customResults, pansouResults []model.SearchResult, maxCount int) []model.SearchResult {
+	merged := make([]model.SearchResult, 0, len(customResults)+len(pansouResults))
+	seen := make(map[string]bool)
+	for _, list := range [][]model.SearchResult{customResults, pansouResults} {
+		for _, r := range list {
+			if len(merged) >= maxCount {
+				return merged
+			}
+			if seen[r.URL] {
+				continue
+			}
+			seen[r.URL] = true
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
 // cloudTypeToPanType 云盘类型字符串转PanType
 func cloudTypeToPanType(cloudType string) int {
 	typeMap := map[string]int{
@@ -534,6 +786,9 @@ func cloudTypeToPanType(cloudType string) int {
 		"aliyun": model.PanTypeAliyun,
 		"uc":     model.PanTypeUC,
 		"xunlei": model.PanTypeXunlei,
+		"tianyi": model.PanTypeTianyi,
+		"123":    model.PanType123,
+		"115":    model.PanType115,
 	}
 	if panType, ok := typeMap[cloudType]; ok {
 		return panType
@@ -547,13 +802,28 @@ func (s *SearchService) validateRequest(req *model.SearchRequest) error {
 		return fmt.Errorf("搜索关键词不能为空")
 	}
 	
-	if req.PanType < 0 || req.PanType > 5 {
+	if req.PanType < 0 || req.PanType > model.PanType115 {
 		return fmt.Errorf("无效的网盘类型: %d", req.PanType)
 	}
 	
+	req.ChannelGroups = model.SplitTags(strings.Join(req.ChannelGroups, ","))
+	
 	return nil
 }
 
+// searchChannels 获取本次搜索的TG频道，频道表为空或读取失败时使用Pansou引擎的CHANNELS配置
+func (s *SearchService) searchChannels(ctx context.Context, groups []string) []string {
+	if s.channelService != nil {
+		channels, err := s.channelService.Resolve(ctx, groups)
+		if err != nil {
+			logger.Warn("读取TG频道失败，使用CHANNELS配置", zap.Error(err))
+		} else if channels != nil {
+			return channels
+		}
+	}
+	return config.AppConfig.DefaultChannels
+}
+
 // isKeywordBlocked 检查关键词是否被屏蔽
 func (s *SearchService) isKeywordBlocked(ctx context.Context, keyword string) (bool, error) {
 	banKeywords, err := s.configRepo.Get(ctx, model.ConfBanKeywords)
@@ -577,45 +847,16 @@ func (s *SearchService) isKeywordBlocked(ctx context.Context, keyword string) (b
 
 // ClearCache 清除搜索缓存
 func (s *SearchService) ClearCache(ctx context.Context, keyword string, panType int) error {
-	cacheKey := fmt.Sprintf("search:%s:%d", keyword, panType)
-	return s.cacheRepo.Delete(ctx, cacheKey)
+	// 清除该关键词在所有结果模式下的缓存
+	return s.cacheRepo.DeletePattern(ctx, searchCachePattern(keyword, panType))
 }
 
-// isNetdiskConfigured 检查指定网盘是否已配置
+// isNetdiskConfigured 检查指定网盘是否有可用账号
 func (s *SearchService) isNetdiskConfigured(ctx context.Context, panType int) bool {
-	// 根据网盘类型获取对应的配置键名
-	var configKey string
-	switch panType {
-	case model.PanTypeQuark:
-		configKey = "quark_cookie"
-	case model.PanTypeBaidu:
-		configKey = "baidu_cookie"
-	case model.PanTypeAliyun:
-		configKey = "aliyun_refresh_token"
-	case model.PanTypeUC:
-		configKey = "uc_cookie"
-	case model.PanTypeXunlei:
-		configKey = "xunlei_cookie"
-	default:
-		return false
-	}
-	
-	// 获取配置值
-	value, err := s.configRepo.Get(ctx, configKey)
-	if err != nil {
-		logger.Debug("获取网盘配置失败",
-			zap.String("config_key", configKey),
-			zap.Error(err),
-		)
-		return false
-	}
-	
-	// 检查配置值是否为空
-	configured := strings.TrimSpace(value) != ""
+	configured := netdisk.IsPanTypeConfigured(ctx, panType)
 	
 	logger.Debug("网盘配置检查",
 		zap.Int("pan_type", panType),
-		zap.String("config_key", configKey),
 		zap.Bool("configured", configured),
 	)
 	
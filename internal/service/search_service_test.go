@@ -0,0 +1,204 @@
+﻿package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository"
+	"huoxing-search/internal/repository/repotest"
+)
+
+// newTestSearchService 创建只使用本地数据源的搜索服务（不初始化Pansou）
+func newTestSearchService(configs map[string]string, sourceRepo *repotest.SourceRepository) *SearchService {
+	return &SearchService{
+		configRepo: repotest.NewConfigRepository(configs),
+		sourceRepo: sourceRepo,
+		cacheRepo:  repository.NewCacheRepository(),
+	}
+}
+
+func TestSearchRejectsInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  model.SearchRequest
+	}{
+		{name: "空关键词", req: model.SearchRequest{Keyword: "  "}},
+		{name: "网盘类型为负数", req: model.SearchRequest{Keyword: "三体", PanType: -1}},
+		{name: "网盘类型超出范围", req: model.SearchRequest{Keyword: "三体", PanType: model.PanType115 + 1}},
+	}
+
+	s := newTestSearchService(nil, repotest.NewSourceRepository())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Search(context.Background(), tt.req); err == nil {
+				t.Errorf("Search(%+v) error = nil, want error", tt.req)
+			}
+		})
+	}
+}
+
+func TestSearchBlockedKeyword(t *testing.T) {
+	tests := []struct {
+		name        string
+		banKeywords string
+		keyword     string
+		wantBlocked bool
+	}{
+		{name: "包含屏蔽词", banKeywords: "盗版, 破解", keyword: "破解软件合集", wantBlocked: true},
+		{name: "忽略大小写", banKeywords: "CAM", keyword: "电影 cam版", wantBlocked: true},
+		{name: "未包含屏蔽词", banKeywords: "盗版,破解", keyword: "屏蔽测试正版", wantBlocked: false},
+		{name: "未配置屏蔽词", banKeywords: "", keyword: "屏蔽测试破解", wantBlocked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceRepo := repotest.NewSourceRepository(&model.Source{Title: tt.keyword, URL: "https://pan.quark.cn/s/blocked", Status: 1})
+			s := newTestSearchService(map[string]string{model.ConfBanKeywords: tt.banKeywords}, sourceRepo)
+			t.Cleanup(func() { _ = s.ClearCache(context.Background(), tt.keyword, model.PanTypeQuark) })
+
+			resp, err := s.Search(context.Background(), model.SearchRequest{Keyword: tt.keyword})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			blocked := resp.Message == "该关键词已被屏蔽"
+			if blocked != tt.wantBlocked {
+				t.Errorf("Search() message = %q, blocked = %v, want %v", resp.Message, blocked, tt.wantBlocked)
+			}
+			if blocked && (resp.Total != 0 || sourceRepo.Calls("SearchByKeywordAndType") != 0) {
+				t.Errorf("屏蔽关键词不应查询数据源: total = %d", resp.Total)
+			}
+		})
+	}
+}
+
+func TestSearchLocalSources(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository(
+		&model.Source{Title: "本地搜索测试 第一季", URL: "https://pan.quark.cn/s/local1", IsType: model.PanTypeQuark, Status: 1, CreateTime: 200},
+		&model.Source{Title: "本地搜索测试 第二季", URL: "https://pan.quark.cn/s/local2", IsType: model.PanTypeQuark, Status: 1, CreateTime: 100},
+		&model.Source{Title: "本地搜索测试 第三季", URL: "https://pan.quark.cn/s/local3", IsType: model.PanTypeQuark, Status: 1, CreateTime: 50},
+		&model.Source{Title: "本地搜索测试 已下线", URL: "https://pan.quark.cn/s/offline", IsType: model.PanTypeQuark, Status: 0, CreateTime: 300},
+		&model.Source{Title: "本地搜索测试 百度", URL: "https://pan.baidu.com/s/1local", IsType: model.PanTypeBaidu, Status: 1, CreateTime: 300},
+	)
+	s := newTestSearchService(map[string]string{"max_search_results": "2"}, sourceRepo)
+
+	req := model.SearchRequest{Keyword: "本地搜索测试", PanType: model.PanTypeQuark}
+	t.Cleanup(func() { _ = s.ClearCache(context.Background(), req.Keyword, req.PanType) })
+
+	resp, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if resp.Message != "搜索成功(本地)" || resp.Total != 2 {
+		t.Fatalf("Search() = %+v", resp)
+	}
+	wantURLs := []string{"https://pan.quark.cn/s/local1", "https://pan.quark.cn/s/local2"}
+	for i, result := range resp.Results {
+		if result.URL != wantURLs[i] || result.Source != "本地资源" || result.PanType != model.PanTypeQuark {
+			t.Errorf("Results[%d] = %+v, want url %s", i, result, wantURLs[i])
+		}
+	}
+
+	// 第二次搜索（关键词大小写和空白不同）命中搜索缓存，不再查询数据库
+	cached, err := s.Search(context.Background(), model.SearchRequest{Keyword: "  本地搜索测试 ", PanType: model.PanTypeQuark})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if cached.Total != resp.Total || cached.Results[0].URL != resp.Results[0].URL {
+		t.Errorf("缓存结果 = %+v, want %+v", cached, resp)
+	}
+	if calls := sourceRepo.Calls("SearchByKeywordAndType"); calls != 1 {
+		t.Errorf("数据库查询次数 = %d, want 1", calls)
+	}
+}
+
+func TestSearchMaxCountOverridesConfig(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository(
+		&model.Source{Title: "数量限制测试1", URL: "https://pan.quark.cn/s/limit1", Status: 1},
+		&model.Source{Title: "数量限制测试2", URL: "https://pan.quark.cn/s/limit2", Status: 1},
+		&model.Source{Title: "数量限制测试3", URL: "https://pan.quark.cn/s/limit3", Status: 1},
+	)
+	s := newTestSearchService(map[string]string{"max_search_results": "3"}, sourceRepo)
+
+	req := model.SearchRequest{Keyword: "数量限制测试", MaxCount: 1}
+	t.Cleanup(func() { _ = s.ClearCache(context.Background(), req.Keyword, req.PanType) })
+
+	resp, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if resp.Total != 1 {
+		t.Errorf("Search() total = %d, want 1", resp.Total)
+	}
+}
+
+func TestSearchLocalSourceMetadata(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository(&model.Source{
+		Title:      "元数据测试 合集",
+		URL:        "https://pan.quark.cn/s/meta",
+		Password:   "m3t4",
+		Size:       1 << 30,
+		SourceName: "qqpd",
+		SourceTime: "2024-05-01",
+		Status:     1,
+	})
+	s := newTestSearchService(nil, sourceRepo)
+
+	req := model.SearchRequest{Keyword: "元数据测试"}
+	t.Cleanup(func() { _ = s.ClearCache(context.Background(), req.Keyword, req.PanType) })
+
+	// 第二次搜索命中缓存，同样计入查看次数
+	for i := 0; i < 2; i++ {
+		resp, err := s.Search(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if resp.Total != 1 {
+			t.Fatalf("Search() = %+v", resp)
+		}
+		result := resp.Results[0]
+		if result.SourceID != 1 || result.Password != "m3t4" || result.Source != "qqpd" ||
+			result.Size != "1.00 GB" || result.Time != "2024-05-01" {
+			t.Errorf("Results[0] = %+v", result)
+		}
+	}
+
+	// 查看次数异步更新
+	deadline := time.Now().Add(time.Second)
+	for sourceRepo.All()[0].ViewCount < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sourceRepo.All()[0].ViewCount; got != 2 {
+		t.Errorf("ViewCount = %d, want 2", got)
+	}
+}
+
+func TestSearchLocalSourcesByCategory(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository(
+		&model.Source{Title: "分类筛选测试 电影", URL: "https://pan.quark.cn/s/cat1", CategoryID: 1, Status: 1},
+		&model.Source{Title: "分类筛选测试 动漫", URL: "https://pan.quark.cn/s/cat2", CategoryID: 2, Status: 1},
+	)
+	s := newTestSearchService(nil, sourceRepo)
+
+	req := model.SearchRequest{Keyword: "分类筛选测试", CategoryID: 2}
+	t.Cleanup(func() { _ = s.ClearCache(context.Background(), req.Keyword, req.PanType) })
+
+	resp, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if resp.Total != 1 || resp.Results[0].URL != "https://pan.quark.cn/s/cat2" {
+		t.Errorf("Search() = %+v", resp)
+	}
+
+	// 不同分类使用不同的缓存
+	req.CategoryID = 1
+	resp, err = s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if resp.Total != 1 || resp.Results[0].URL != "https://pan.quark.cn/s/cat1" {
+		t.Errorf("Search() = %+v", resp)
+	}
+}
@@ -0,0 +1,57 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	"huoxing-search/internal/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// stubNetdisk 按链接返回预设转存结果的网盘客户端
+type stubNetdisk struct {
+	transfer func(shareURL, password string) (*model.TransferResult, error)
+}
+
+func (c *stubNetdisk) Transfer(ctx context.Context, shareURL, password string, expiredType int) (*model.TransferResult, error) {
+	return c.transfer(shareURL, password)
+}
+
+func (c *stubNetdisk) GetName() string                                    { return "stub" }
+func (c *stubNetdisk) IsConfigured() bool                                 { return true }
+func (c *stubNetdisk) TestConnection(ctx context.Context) error           { return nil }
+func (c *stubNetdisk) DeleteDirectory(ctx context.Context, dirPath string) error { return nil }
+func (c *stubNetdisk) CreateDirectory(ctx context.Context, dirPath string) error { return nil }
+
+// stubNetdiskManager 始终返回同一个客户端的网盘管理器，client为nil时返回未配置错误
+type stubNetdiskManager struct {
+	client netdisk.Netdisk
+}
+
+func (m *stubNetdiskManager) GetClient(panType int) (netdisk.Netdisk, error) {
+	if m.client == nil {
+		return nil, errors.New("网盘未配置")
+	}
+	return m.client, nil
+}
+
+func (m *stubNetdiskManager) GetClients(panType int) ([]netdisk.Netdisk, error) {
+	client, err := m.GetClient(panType)
+	if err != nil {
+		return nil, err
+	}
+	return []netdisk.Netdisk{client}, nil
+}
+
+func (m *stubNetdiskManager) GetAccountClient(accountID int) (netdisk.Netdisk, error) {
+	return m.GetClient(0)
+}
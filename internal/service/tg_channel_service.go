@@ -0,0 +1,356 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+	pansouConfig "huoxing-search/pansou/config"
+	pansouService "huoxing-search/pansou/service"
+)
+
+// tgChannelCacheTTL 频道列表缓存时间（频道变更时主动失效）
+const tgChannelCacheTTL = time.Minute
+
+var (
+	// ErrTGChannelExists 频道已存在
+	ErrTGChannelExists = errors.New("频道已存在")
+	// ErrInvalidTGChannel 频道名无效
+	ErrInvalidTGChannel = errors.New("频道名无效，只能包含字母、数字和下划线")
+)
+
+// tgChannelNamePattern Telegram频道用户名
+var tgChannelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,100}$`)
+
+// TGChannelImportResult 批量导入结果
+type TGChannelImportResult struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"` // 已存在且补充了分组标签
+	Skipped int      `json:"skipped"` // 已存在且无需修改
+	Invalid []string `json:"invalid"` // 无法识别的频道
+}
+
+// TGChannelGroup 频道分组
+type TGChannelGroup struct {
+	Tag     string `json:"tag"`
+	Total   int    `json:"total"`
+	Enabled int    `json:"enabled"`
+}
+
+// TGChannelService TG频道管理服务接口
+type TGChannelService interface {
+	// List 获取频道列表及搜索统计，status<0时不过滤状态，tag为空时不过滤分组
+	List(ctx context.Context, status int, tag string) ([]*model.TGChannel, error)
+	GetByID(ctx context.Context, id int) (*model.TGChannel, error)
+	Create(ctx context.Context, channel *model.TGChannel) error
+	Update(ctx context.Context, channel *model.TGChannel) error
+	Delete(ctx context.Context, id int) error
+	UpdateStatus(ctx context.Context, id int, status int) error
+	// Import 批量导入频道，text为频道名或t.me链接，用换行、逗号或空格分隔
+	Import(ctx context.Context, text string, tags string, weight int) (*TGChannelImportResult, error)
+	// Groups 获取全部分组及频道数
+	Groups(ctx context.Context) ([]TGChannelGroup, error)
+	// Resolve 获取本次搜索的频道，groups为空时返回全部启用的频道
+	// 频道表为空时返回nil，表示使用Pansou引擎的CHANNELS配置
+	Resolve(ctx context.Context, groups []string) ([]string, error)
+}
+
+type tgChannelService struct {
+	channelRepo repository.TGChannelRepository
+}
+
+// NewTGChannelService 创建TG频道管理服务
+func NewTGChannelService(channelRepo repository.TGChannelRepository) TGChannelService {
+	return &tgChannelService{
+		channelRepo: channelRepo,
+	}
+}
+
+// 频道列表缓存，所有服务实例共用，频道增删改后失效
+var tgChannelCache struct {
+	mu       sync.Mutex
+	channels []*model.TGChannel
+	loadedAt time.Time
+}
+
+// invalidateTGChannels 清除频道列表缓存
+func invalidateTGChannels() {
+	tgChannelCache.mu.Lock()
+	defer tgChannelCache.mu.Unlock()
+	tgChannelCache.channels = nil
+	tgChannelCache.loadedAt = time.Time{}
+}
+
+// loadChannels 获取全部频道（带缓存），加载失败时沿用旧列表
+// 加载失败的结果同样缓存 tgChannelCacheTTL，避免数据库异常或频道表缺失时每次搜索都查询数据库
+func (s *tgChannelService) loadChannels(ctx context.Context) ([]*model.TGChannel, error) {
+	tgChannelCache.mu.Lock()
+	defer tgChannelCache.mu.Unlock()
+
+	if !tgChannelCache.loadedAt.IsZero() && time.Since(tgChannelCache.loadedAt) < tgChannelCacheTTL {
+		return tgChannelCache.channels, nil
+	}
+
+	channels, err := s.channelRepo.List(ctx, -1)
+	if err != nil {
+		tgChannelCache.loadedAt = time.Now()
+		if tgChannelCache.channels != nil {
+			logger.Warn("加载TG频道失败，沿用缓存的频道列表", zap.Error(err))
+			return tgChannelCache.channels, nil
+		}
+		tgChannelCache.channels = []*model.TGChannel{}
+		return nil, err
+	}
+	tgChannelCache.channels = channels
+	tgChannelCache.loadedAt = time.Now()
+	return channels, nil
+}
+
+// NormalizeTGChannelName 从频道名、@用户名或t.me链接中提取频道用户名
+func NormalizeTGChannelName(s string) string {
+	name := strings.TrimSpace(s)
+	name = strings.TrimPrefix(name, "https://")
+	name = strings.TrimPrefix(name, "http://")
+	for _, prefix := range []string{"t.me/s/", "t.me/", "telegram.me/s/", "telegram.me/"} {
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	name = strings.TrimPrefix(name, "@")
+	if idx := strings.IndexAny(name, "/?#"); idx >= 0 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// List 获取频道列表及搜索统计
+func (s *tgChannelService) List(ctx context.Context, status int, tag string) ([]*model.TGChannel, error) {
+	channels, err := s.channelRepo.List(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := pansouService.GetChannelStats()
+	list := make([]*model.TGChannel, 0, len(channels))
+	for _, channel := range channels {
+		if tag != "" && !channel.HasAnyTag([]string{tag}) {
+			continue
+		}
+		if st, ok := stats[channel.Name]; ok {
+			channel.Stats = &model.TGChannelStats{
+				Requests:    st.Requests,
+				Hits:        st.Hits,
+				Errors:      st.Errors,
+				Results:     st.Results,
+				LastSuccess: st.LastSuccess,
+				LastError:   st.LastError,
+			}
+			if st.Requests > 0 {
+				channel.Stats.AvgMs = st.DurationSeconds * 1000 / float64(st.Requests)
+			}
+		}
+		list = append(list, channel)
+	}
+	return list, nil
+}
+
+// GetByID 根据ID获取频道
+func (s *tgChannelService) GetByID(ctx context.Context, id int) (*model.TGChannel, error) {
+	return s.channelRepo.GetByID(ctx, id)
+}
+
+// validate 规范化频道名和标签，并检查频道名是否重复
+func (s *tgChannelService) validate(ctx context.Context, channel *model.TGChannel) error {
+	channel.Name = NormalizeTGChannelName(channel.Name)
+	if !tgChannelNamePattern.MatchString(channel.Name) {
+		return ErrInvalidTGChannel
+	}
+	if channel.Status != 0 && channel.Status != 1 {
+		return fmt.Errorf("状态值无效")
+	}
+	channel.Tags = strings.Join(model.SplitTags(channel.Tags), ",")
+
+	existing, err := s.channelRepo.GetByName(ctx, channel.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != channel.ID {
+		return ErrTGChannelExists
+	}
+	return nil
+}
+
+// Create 创建频道
+func (s *tgChannelService) Create(ctx context.Context, channel *model.TGChannel) error {
+	channel.ID = 0
+	if err := s.validate(ctx, channel); err != nil {
+		return err
+	}
+	if err := s.channelRepo.Create(ctx, channel); err != nil {
+		return err
+	}
+	invalidateTGChannels()
+	return nil
+}
+
+// Update 更新频道
+func (s *tgChannelService) Update(ctx context.Context, channel *model.TGChannel) error {
+	if _, err := s.channelRepo.GetByID(ctx, channel.ID); err != nil {
+		return fmt.Errorf("频道不存在")
+	}
+	if err := s.validate(ctx, channel); err != nil {
+		return err
+	}
+	if err := s.channelRepo.Update(ctx, channel); err != nil {
+		return err
+	}
+	invalidateTGChannels()
+	return nil
+}
+
+// Delete 删除频道
+func (s *tgChannelService) Delete(ctx context.Context, id int) error {
+	if err := s.channelRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	invalidateTGChannels()
+	return nil
+}
+
+// UpdateStatus 启用或禁用频道
+func (s *tgChannelService) UpdateStatus(ctx context.Context, id int, status int) error {
+	if status != 0 && status != 1 {
+		return fmt.Errorf("状态值无效")
+	}
+	if err := s.channelRepo.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+	invalidateTGChannels()
+	return nil
+}
+
+// Import 批量导入频道，已存在的频道只补充分组标签
+// text为空时导入Pansou引擎当前的CHANNELS配置
+func (s *tgChannelService) Import(ctx context.Context, text string, tags string, weight int) (*TGChannelImportResult, error) {
+	var items []string
+	if strings.TrimSpace(text) == "" {
+		if pansouConfig.AppConfig != nil {
+			items = pansouConfig.AppConfig.DefaultChannels
+		}
+	} else {
+		items = strings.FieldsFunc(text, func(r rune) bool {
+			return r == '\n' || r == '\r' || r == ',' || r == '，' || r == ' ' || r == '\t'
+		})
+	}
+
+	result := &TGChannelImportResult{Invalid: []string{}}
+	newTags := model.SplitTags(tags)
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		name := NormalizeTGChannelName(item)
+		if !tgChannelNamePattern.MatchString(name) {
+			result.Invalid = append(result.Invalid, item)
+			continue
+		}
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		existing, err := s.channelRepo.GetByName(ctx, name)
+		if err != nil {
+			return result, err
+		}
+		if existing == nil {
+			channel := &model.TGChannel{Name: name, Status: 1, Weight: weight, Tags: strings.Join(newTags, ",")}
+			if err := s.channelRepo.Create(ctx, channel); err != nil {
+				return result, err
+			}
+			result.Created++
+			continue
+		}
+
+		merged := strings.Join(model.SplitTags(existing.Tags+","+strings.Join(newTags, ",")), ",")
+		if merged == existing.Tags {
+			result.Skipped++
+			continue
+		}
+		existing.Tags = merged
+		if err := s.channelRepo.Update(ctx, existing); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+
+	invalidateTGChannels()
+	logger.Info("📥 TG频道导入完成",
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("invalid", len(result.Invalid)),
+	)
+	return result, nil
+}
+
+// Groups 获取全部分组及频道数，按分组名排序
+func (s *tgChannelService) Groups(ctx context.Context) ([]TGChannelGroup, error) {
+	channels, err := s.channelRepo.List(ctx, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*TGChannelGroup)
+	for _, channel := range channels {
+		for _, tag := range channel.TagList() {
+			group, ok := groups[tag]
+			if !ok {
+				group = &TGChannelGroup{Tag: tag}
+				groups[tag] = group
+			}
+			group.Total++
+			if channel.Status == 1 {
+				group.Enabled++
+			}
+		}
+	}
+
+	list := make([]TGChannelGroup, 0, len(groups))
+	for _, group := range groups {
+		list = append(list, *group)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tag < list[j].Tag })
+	return list, nil
+}
+
+// Resolve 获取本次搜索的频道（按权重降序）
+func (s *tgChannelService) Resolve(ctx context.Context, groups []string) ([]string, error) {
+	channels, err := s.loadChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if channel.Status != 1 {
+			continue
+		}
+		if len(groups) > 0 && !channel.HasAnyTag(groups) {
+			continue
+		}
+		names = append(names, channel.Name)
+	}
+	return names, nil
+}
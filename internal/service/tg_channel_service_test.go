@@ -0,0 +1,111 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func TestNormalizeTGChannelName(t *testing.T) {
+	tests := map[string]string{
+		"tgsearchers4":                   "tgsearchers4",
+		"@tgsearchers4":                  "tgsearchers4",
+		"https://t.me/tgsearchers4":      "tgsearchers4",
+		"https://t.me/s/tgsearchers4?q=": "tgsearchers4",
+		" t.me/Aliyun_4K_Movies/123 ":    "Aliyun_4K_Movies",
+	}
+	for input, want := range tests {
+		if got := NormalizeTGChannelName(input); got != want {
+			t.Errorf("NormalizeTGChannelName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestTGChannelImportAndResolve(t *testing.T) {
+	invalidateTGChannels()
+	t.Cleanup(invalidateTGChannels)
+	ctx := context.Background()
+
+	repo := repotest.NewTGChannelRepository(&model.TGChannel{Name: "tgsearchers4", Status: 1, Weight: 10, Tags: "综合"})
+	s := NewTGChannelService(repo)
+
+	result, err := s.Import(ctx, "https://t.me/s/movie_share\n@tgsearchers4, movie_share 无效-频道", "影视", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || result.Updated != 1 || len(result.Invalid) != 1 {
+		t.Errorf("Import() = %+v", result)
+	}
+
+	if err := s.Create(ctx, &model.TGChannel{Name: "@movie_share", Status: 1}); !errors.Is(err, ErrTGChannelExists) {
+		t.Errorf("重复频道 Create() error = %v, want ErrTGChannelExists", err)
+	}
+	disabled := &model.TGChannel{Name: "disabled_channel", Status: 0, Tags: "影视"}
+	if err := s.Create(ctx, disabled); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		groups []string
+		want   []string
+	}{
+		{groups: nil, want: []string{"tgsearchers4", "movie_share"}},
+		{groups: []string{"影视"}, want: []string{"tgsearchers4", "movie_share"}},
+		{groups: []string{"综合"}, want: []string{"tgsearchers4"}},
+		{groups: []string{"不存在"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		got, err := s.Resolve(ctx, tt.groups)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Resolve(%v) = %v, want %v", tt.groups, got, tt.want)
+		}
+	}
+}
+
+func TestTGChannelResolveEmptyTable(t *testing.T) {
+	invalidateTGChannels()
+	t.Cleanup(invalidateTGChannels)
+
+	got, err := NewTGChannelService(repotest.NewTGChannelRepository()).Resolve(context.Background(), nil)
+	if err != nil || got != nil {
+		t.Errorf("频道表为空时 Resolve() = %v, %v, want nil（使用CHANNELS配置）", got, err)
+	}
+}
+
+// failingTGChannelRepo 查询频道列表总是失败的仓储
+type failingTGChannelRepo struct {
+	*repotest.TGChannelRepository
+	calls int
+}
+
+func (r *failingTGChannelRepo) List(ctx context.Context, status int) ([]*model.TGChannel, error) {
+	r.calls++
+	return nil, errors.New("Table 'qf_tg_channel' doesn't exist")
+}
+
+func TestTGChannelResolveCachesFailure(t *testing.T) {
+	invalidateTGChannels()
+	t.Cleanup(invalidateTGChannels)
+
+	repo := &failingTGChannelRepo{TGChannelRepository: repotest.NewTGChannelRepository()}
+	svc := NewTGChannelService(repo)
+	if _, err := svc.Resolve(context.Background(), nil); err == nil {
+		t.Fatal("首次加载失败应返回错误")
+	}
+	for i := 0; i < 3; i++ {
+		got, err := svc.Resolve(context.Background(), nil)
+		if err != nil || got != nil {
+			t.Fatalf("缓存期内 Resolve() = %v, %v, want nil, nil", got, err)
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("缓存期内查询数据库 %d 次, want 1", repo.calls)
+	}
+}
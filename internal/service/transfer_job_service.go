@@ -0,0 +1,496 @@
+﻿package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/pkg/logger"
+	"huoxing-search/internal/repository"
+)
+
+const (
+	transferJobPollInterval = 5 * time.Second
+	transferJobRetryBase    = 10 * time.Second
+	transferJobRetryMax     = 5 * time.Minute
+)
+
+var (
+	// ErrTransferJobNotFound 转存任务不存在
+	ErrTransferJobNotFound = errors.New("转存任务不存在")
+	// ErrTransferJobInProgress 同一关键词已有其他调用方的转存任务在排队或执行中
+	ErrTransferJobInProgress = errors.New("该关键词的资源正在后台转存，请稍后再搜索")
+)
+
+// TransferJobService 异步转存任务服务接口
+type TransferJobService interface {
+	Enqueue(ctx context.Context, req *model.TransferRequest, source, keyword string) (*model.TransferJob, bool, error)
+	Get(ctx context.Context, jobNo string) (*model.TransferJob, error)
+	Cancel(ctx context.Context, jobNo string) (*model.TransferJob, error)
+	List(ctx context.Context, page, pageSize int, status string) ([]*model.TransferJob, int64, error)
+	Start()
+	Stop()
+}
+
+type transferJobService struct {
+	jobRepo         repository.TransferJobRepository
+	cacheRepo       repository.CacheRepository
+	transferService TransferService
+	workers         int
+	maxAttempts     int
+
+	queue     chan uint64
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	enqueueMu sync.Mutex // 串行化“查找进行中任务-创建任务”，避免并发搜索同一关键词重复入队
+	running   map[uint64]context.CancelFunc
+	started   bool
+}
+
+// NewTransferJobService 创建异步转存任务服务
+// 工作协程数在启动时确定，修改 transfer.max_concurrent 需重启生效
+func NewTransferJobService(cfg *config.Config) TransferJobService {
+	workers := cfg.Transfer.MaxConcurrent
+	if workers <= 0 {
+		workers = 1
+	}
+	maxAttempts := cfg.Transfer.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return &transferJobService{
+		jobRepo:         repository.NewTransferJobRepository(),
+		cacheRepo:       repository.NewCacheRepository(),
+		transferService: NewTransferService(cfg),
+		workers:         workers,
+		maxAttempts:     maxAttempts,
+		queue:           make(chan uint64, workers*4),
+		stopCh:          make(chan struct{}),
+		running:         make(map[uint64]context.CancelFunc),
+	}
+}
+
+var (
+	globalTransferJobService TransferJobService
+	globalTransferJobMu      sync.Mutex
+)
+
+// StartTransferJobService 启动全局异步转存任务服务（重复调用只启动一次）
+func StartTransferJobService(cfg *config.Config) TransferJobService {
+	globalTransferJobMu.Lock()
+	defer globalTransferJobMu.Unlock()
+
+	if globalTransferJobService == nil {
+		globalTransferJobService = NewTransferJobService(cfg)
+		globalTransferJobService.Start()
+	}
+	return globalTransferJobService
+}
+
+// GetTransferJobService 获取全局异步转存任务服务，未启动时返回nil
+func GetTransferJobService() TransferJobService {
+	globalTransferJobMu.Lock()
+	defer globalTransferJobMu.Unlock()
+	return globalTransferJobService
+}
+
+// StopTransferJobService 停止全局异步转存任务服务
+func StopTransferJobService() {
+	globalTransferJobMu.Lock()
+	svc := globalTransferJobService
+	globalTransferJobService = nil
+	globalTransferJobMu.Unlock()
+
+	if svc != nil {
+		svc.Stop()
+	}
+}
+
+// Enqueue 创建转存任务并通知工作协程，返回的bool表示是否新建了任务
+// 同一关键词和网盘类型已有排队中或执行中的任务时不重复入队：同一调用方复用该任务，其他调用方返回 ErrTransferJobInProgress
+func (s *transferJobService) Enqueue(ctx context.Context, req *model.TransferRequest, source, keyword string) (*model.TransferJob, bool, error) {
+	if req == nil || len(req.Items) == 0 {
+		return nil, false, fmt.Errorf("转存项不能为空")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("序列化转存请求失败: %w", err)
+	}
+	jobNo, err := newTransferJobNo()
+	if err != nil {
+		return nil, false, err
+	}
+	owner := model.TransferJobOwner(ctx)
+
+	s.enqueueMu.Lock()
+	defer s.enqueueMu.Unlock()
+
+	if keyword != "" {
+		active, err := s.jobRepo.FindActive(ctx, req.PanType, keyword)
+		if err != nil {
+			return nil, false, fmt.Errorf("查询转存任务失败: %w", err)
+		}
+		if active != nil {
+			if active.Owner != owner {
+				return nil, false, ErrTransferJobInProgress
+			}
+			logger.Info("♻️ 复用进行中的转存任务",
+				zap.String("job_no", active.JobNo),
+				zap.String("keyword", keyword),
+			)
+			return active, false, nil
+		}
+	}
+
+	job := &model.TransferJob{
+		JobNo:       jobNo,
+		Status:      model.TransferJobQueued,
+		PanType:     req.PanType,
+		Source:      source,
+		Keyword:     keyword,
+		Owner:       owner,
+		Request:     string(data),
+		MaxAttempts: s.jobMaxAttempts(),
+		NextRunAt:   time.Now().Unix(),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, false, fmt.Errorf("创建转存任务失败: %w", err)
+	}
+
+	logger.Info("📥 转存任务已入队",
+		zap.String("job_no", job.JobNo),
+		zap.String("source", source),
+		zap.String("keyword", keyword),
+		zap.Int("items", len(req.Items)),
+	)
+
+	s.notify(job.ID)
+	return job, true, nil
+}
+
+// Get 获取当前请求创建的转存任务，其他调用方的任务按不存在处理
+func (s *transferJobService) Get(ctx context.Context, jobNo string) (*model.TransferJob, error) {
+	job, err := s.jobRepo.GetByJobNo(ctx, jobNo)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Owner == "" || job.Owner != model.TransferJobOwner(ctx) {
+		return nil, ErrTransferJobNotFound
+	}
+	return job, nil
+}
+
+// Cancel 取消转存任务：排队中直接取消，执行中中断转存
+func (s *transferJobService) Cancel(ctx context.Context, jobNo string) (*model.TransferJob, error) {
+	job, err := s.Get(ctx, jobNo)
+	if err != nil {
+		return nil, err
+	}
+	if job.IsFinished() {
+		return nil, fmt.Errorf("任务已结束，无法取消")
+	}
+
+	if job.Status == model.TransferJobQueued {
+		if ok, err := s.jobRepo.CancelQueued(ctx, job.ID); err != nil {
+			return nil, err
+		} else if ok {
+			logger.Info("🛑 转存任务已取消", zap.String("job_no", jobNo))
+			return s.Get(ctx, jobNo)
+		}
+	}
+
+	// 执行中的任务：中断转存，由工作协程写入取消状态
+	s.mu.Lock()
+	cancel, ok := s.running[job.ID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("任务状态已变化，请刷新后重试")
+	}
+	cancel()
+	logger.Info("🛑 正在中断执行中的转存任务", zap.String("job_no", jobNo))
+
+	job.Status = model.TransferJobCancelled
+	return job, nil
+}
+
+// List 获取转存任务列表
+func (s *transferJobService) List(ctx context.Context, page, pageSize int, status string) ([]*model.TransferJob, int64, error) {
+	return s.jobRepo.List(ctx, page, pageSize, status)
+}
+
+// Start 启动工作协程和调度协程
+func (s *transferJobService) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	// 恢复上次退出时中断的任务
+	if count, err := s.jobRepo.RequeueRunning(context.Background()); err != nil {
+		logger.Warn("恢复中断的转存任务失败", zap.Error(err))
+	} else if count > 0 {
+		logger.Info("恢复中断的转存任务", zap.Int64("count", count))
+	}
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	s.wg.Add(1)
+	go s.dispatchLoop()
+
+	logger.Info("✅ 异步转存任务服务已启动",
+		zap.Int("workers", s.workers),
+		zap.Int("max_attempts", s.maxAttempts),
+	)
+}
+
+// Stop 停止服务并中断执行中的任务（中断的任务会在下次启动时重新排队）
+func (s *transferJobService) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	close(s.stopCh)
+	for _, cancel := range s.running {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	logger.Info("异步转存任务服务已停止")
+}
+
+// notify 非阻塞地通知工作协程，队列已满时由调度协程稍后拾取
+func (s *transferJobService) notify(id uint64) {
+	select {
+	case s.queue <- id:
+	default:
+	}
+}
+
+// dispatchLoop 定期扫描到期的排队任务（包括重试中的任务）
+func (s *transferJobService) dispatchLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(transferJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		jobs, err := s.jobRepo.ListDue(context.Background(), time.Now().Unix(), cap(s.queue))
+		if err != nil {
+			logger.Warn("扫描转存任务失败", zap.Error(err))
+		}
+		for _, job := range jobs {
+			s.notify(job.ID)
+		}
+
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// jobMaxAttempts 新任务的最大尝试次数，配置文件热更新后使用新值
+func (s *transferJobService) jobMaxAttempts() int {
+	if transfer := liveTransferConfig.Load(); transfer != nil && transfer.MaxAttempts > 0 {
+		return transfer.MaxAttempts
+	}
+	return s.maxAttempts
+}
+
+// poolStats 工作协程数、正在执行的任务数和排队中的任务数
+func (s *transferJobService) poolStats() (workers, active, queued int) {
+	s.mu.Lock()
+	active = len(s.running)
+	s.mu.Unlock()
+	return s.workers, active, len(s.queue)
+}
+
+// worker 工作协程：从队列中取任务执行
+func (s *transferJobService) worker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case id := <-s.queue:
+			s.runJob(id)
+		}
+	}
+}
+
+// runJob 抢占并执行单个任务
+func (s *transferJobService) runJob(id uint64) {
+	ok, err := s.jobRepo.Claim(context.Background(), id)
+	if err != nil {
+		logger.Warn("抢占转存任务失败", zap.Uint64("id", id), zap.Error(err))
+		return
+	}
+	if !ok {
+		// 已被其他协程/实例抢占，或已取消
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.running[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	job, err := s.jobRepo.GetByID(context.Background(), id)
+	if err != nil {
+		logger.Error("读取转存任务失败", zap.Uint64("id", id), zap.Error(err))
+		return
+	}
+
+	req, err := job.DecodeRequest()
+	if err != nil {
+		s.finish(job, model.TransferJobFailed, nil, fmt.Sprintf("转存请求解析失败: %v", err))
+		return
+	}
+
+	logger.Info("🚀 开始执行转存任务",
+		zap.String("job_no", job.JobNo),
+		zap.Int("attempt", job.Attempts),
+		zap.Int("max_attempts", job.MaxAttempts),
+	)
+
+	resp, err := s.transferService.TransferAndSave(ctx, req)
+
+	// 取消或服务停止导致的中断
+	if ctx.Err() != nil {
+		select {
+		case <-s.stopCh:
+			// 服务停止：保留执行中状态，下次启动时重新排队
+			logger.Info("服务停止，转存任务将在重启后继续", zap.String("job_no", job.JobNo))
+		default:
+			s.finish(job, model.TransferJobCancelled, resp, "任务已取消")
+		}
+		return
+	}
+
+	if err == nil && resp != nil && resp.Success > 0 {
+		s.finish(job, model.TransferJobSucceeded, resp, "")
+		s.clearSearchCache(job)
+		return
+	}
+
+	errMsg := "没有转存成功的链接"
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		s.finish(job, model.TransferJobFailed, resp, errMsg)
+		return
+	}
+
+	// 指数退避后重试
+	delay := transferJobBackoff(job.Attempts)
+	if updateErr := s.jobRepo.UpdateFields(context.Background(), job.ID, map[string]interface{}{
+		"status":      model.TransferJobQueued,
+		"error":       truncateJobError(errMsg),
+		"next_run_at": time.Now().Add(delay).Unix(),
+	}); updateErr != nil {
+		logger.Error("更新转存任务失败", zap.String("job_no", job.JobNo), zap.Error(updateErr))
+		return
+	}
+
+	logger.Warn("⏳ 转存任务失败，稍后重试",
+		zap.String("job_no", job.JobNo),
+		zap.Int("attempt", job.Attempts),
+		zap.Duration("delay", delay),
+		zap.String("error", errMsg),
+	)
+}
+
+// finish 写入任务的最终状态
+func (s *transferJobService) finish(job *model.TransferJob, status string, resp *model.TransferResponse, errMsg string) {
+	fields := map[string]interface{}{
+		"status":      status,
+		"error":       truncateJobError(errMsg),
+		"finished_at": time.Now().Unix(),
+	}
+	if resp != nil {
+		if data, err := json.Marshal(resp); err == nil {
+			fields["result"] = string(data)
+		}
+	}
+
+	if err := s.jobRepo.UpdateFields(context.Background(), job.ID, fields); err != nil {
+		logger.Error("更新转存任务失败", zap.String("job_no", job.JobNo), zap.Error(err))
+		return
+	}
+
+	logger.Info("🏁 转存任务结束",
+		zap.String("job_no", job.JobNo),
+		zap.String("status", status),
+		zap.Int("attempts", job.Attempts),
+		zap.String("error", errMsg),
+	)
+}
+
+// clearSearchCache 转存成功后清除该关键词的搜索缓存，使下次搜索命中本地转存结果
+func (s *transferJobService) clearSearchCache(job *model.TransferJob) {
+	if job.Keyword == "" {
+		return
+	}
+	if err := s.cacheRepo.DeletePattern(context.Background(), searchCachePattern(job.Keyword, job.PanType)); err != nil {
+		logger.Warn("清除搜索缓存失败", zap.String("keyword", job.Keyword), zap.Error(err))
+	}
+}
+
+// transferJobBackoff 计算第attempt次失败后的重试间隔
+func transferJobBackoff(attempt int) time.Duration {
+	delay := transferJobRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= transferJobRetryMax {
+			return transferJobRetryMax
+		}
+	}
+	return delay
+}
+
+// truncateJobError 截断错误信息以适配字段长度
+func truncateJobError(msg string) string {
+	runes := []rune(msg)
+	if len(runes) > 200 {
+		return string(runes[:200])
+	}
+	return msg
+}
+
+// newTransferJobNo 生成任务编号：128位随机数，任务编号即查询凭据，不能使用可预测的时间戳
+func newTransferJobNo() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成任务编号失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
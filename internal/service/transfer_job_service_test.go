@@ -0,0 +1,443 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/repository"
+	"huoxing-search/internal/repository/repotest"
+)
+
+// stubTransferService 调用预设函数并记录调用次数的转存服务
+type stubTransferService struct {
+	calls    atomic.Int32
+	transfer func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error)
+}
+
+func (s *stubTransferService) BatchTransfer(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+	return s.TransferAndSave(ctx, req)
+}
+
+func (s *stubTransferService) TransferAndSave(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+	s.calls.Add(1)
+	return s.transfer(ctx, req)
+}
+
+// newTestTransferJobService 使用内存仓储、不启动工作协程的任务服务，测试直接调用runJob
+func newTestTransferJobService(transfer *stubTransferService, jobRepo *repotest.TransferJobRepository) *transferJobService {
+	return &transferJobService{
+		jobRepo:         jobRepo,
+		cacheRepo:       repository.NewCacheRepository(),
+		transferService: transfer,
+		workers:         1,
+		maxAttempts:     3,
+		queue:           make(chan uint64, 4),
+		stopCh:          make(chan struct{}),
+		running:         make(map[uint64]context.CancelFunc),
+	}
+}
+
+// testJobOwnerCtx 携带API Key的请求上下文，测试中的任务都由该密钥创建
+var testJobOwnerCtx = model.ContextWithAPIKey(context.Background(), &model.APIKey{ID: 1})
+
+func transferSucceeded(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+	return &model.TransferResponse{Total: len(req.Items), Success: len(req.Items)}, nil
+}
+
+func testTransferRequest() *model.TransferRequest {
+	return &model.TransferRequest{
+		PanType: 0,
+		Items:   []model.SearchResult{{Title: "流浪地球", URL: "https://pan.quark.cn/s/abc"}},
+	}
+}
+
+func enqueueTestJob(t *testing.T, svc *transferJobService) *model.TransferJob {
+	t.Helper()
+	job, _, err := svc.Enqueue(testJobOwnerCtx, testTransferRequest(), model.TransferJobSourceAPI, "流浪地球")
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	// 测试中手动执行，丢弃入队通知
+	<-svc.queue
+	return job
+}
+
+func TestTransferJobEnqueue(t *testing.T) {
+	svc := newTestTransferJobService(&stubTransferService{transfer: transferSucceeded}, repotest.NewTransferJobRepository())
+
+	if _, _, err := svc.Enqueue(testJobOwnerCtx, &model.TransferRequest{}, model.TransferJobSourceAPI, ""); err == nil {
+		t.Fatal("Enqueue(空转存项) error = nil, want error")
+	}
+
+	job, created, err := svc.Enqueue(testJobOwnerCtx, testTransferRequest(), model.TransferJobSourceAPI, "流浪地球")
+	if err != nil || !created {
+		t.Fatalf("Enqueue() created = %v, error = %v", created, err)
+	}
+	if job.Status != model.TransferJobQueued || job.MaxAttempts != 3 || job.Owner != "key:1" {
+		t.Fatalf("job = %+v, want queued with max_attempts 3 owned by key:1", job)
+	}
+	if len(job.JobNo) != 32 {
+		t.Fatalf("job_no = %q, want 128位随机十六进制", job.JobNo)
+	}
+	select {
+	case id := <-svc.queue:
+		if id != job.ID {
+			t.Fatalf("queue id = %d, want %d", id, job.ID)
+		}
+	default:
+		t.Fatal("Enqueue() did not notify the queue")
+	}
+
+	stored, err := svc.Get(testJobOwnerCtx, job.JobNo)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	req, err := stored.DecodeRequest()
+	if err != nil || len(req.Items) != 1 || req.Items[0].URL != "https://pan.quark.cn/s/abc" {
+		t.Fatalf("DecodeRequest() = %+v, %v", req, err)
+	}
+
+	if _, err := svc.Get(testJobOwnerCtx, "T-missing"); !errors.Is(err, ErrTransferJobNotFound) {
+		t.Fatalf("Get(不存在) error = %v, want ErrTransferJobNotFound", err)
+	}
+}
+
+func TestTransferJobRunSucceeded(t *testing.T) {
+	transfer := &stubTransferService{transfer: transferSucceeded}
+	svc := newTestTransferJobService(transfer, repotest.NewTransferJobRepository())
+	job := enqueueTestJob(t, svc)
+
+	svc.runJob(job.ID)
+
+	got, _ := svc.Get(testJobOwnerCtx, job.JobNo)
+	if got.Status != model.TransferJobSucceeded || got.Attempts != 1 || got.FinishedAt == 0 || got.Error != "" {
+		t.Fatalf("job = %+v, want succeeded after 1 attempt", got)
+	}
+	if resp := got.DecodeResult(); resp == nil || resp.Success != 1 {
+		t.Fatalf("DecodeResult() = %+v, want success 1", resp)
+	}
+	if len(svc.running) != 0 {
+		t.Fatalf("running = %v, want empty", svc.running)
+	}
+}
+
+func TestTransferJobRunOnce(t *testing.T) {
+	transfer := &stubTransferService{transfer: transferSucceeded}
+	svc := newTestTransferJobService(transfer, repotest.NewTransferJobRepository())
+	job := enqueueTestJob(t, svc)
+
+	// 同一任务被重复通知（入队通知与调度扫描同时命中）时只执行一次
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.runJob(job.ID)
+		}()
+	}
+	wg.Wait()
+	svc.runJob(job.ID)
+
+	if calls := transfer.calls.Load(); calls != 1 {
+		t.Fatalf("TransferAndSave calls = %d, want 1", calls)
+	}
+	got, _ := svc.Get(testJobOwnerCtx, job.JobNo)
+	if got.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", got.Attempts)
+	}
+}
+
+func TestTransferJobRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error)
+		wantErr  string
+	}{
+		{
+			name: "转存返回错误",
+			transfer: func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+				return nil, errors.New("网盘未配置")
+			},
+			wantErr: "网盘未配置",
+		},
+		{
+			name: "没有转存成功的链接",
+			transfer: func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+				return &model.TransferResponse{Total: 1, Failed: 1}, nil
+			},
+			wantErr: "没有转存成功的链接",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer := &stubTransferService{transfer: tt.transfer}
+			jobRepo := repotest.NewTransferJobRepository()
+			svc := newTestTransferJobService(transfer, jobRepo)
+			job := enqueueTestJob(t, svc)
+
+			for attempt := 1; attempt < job.MaxAttempts; attempt++ {
+				before := time.Now().Unix()
+				svc.runJob(job.ID)
+
+				got, _ := svc.Get(testJobOwnerCtx, job.JobNo)
+				if got.Status != model.TransferJobQueued || got.Attempts != attempt || got.Error != tt.wantErr {
+					t.Fatalf("attempt %d: job = %+v, want queued with error %q", attempt, got, tt.wantErr)
+				}
+				delay := int64(transferJobBackoff(attempt).Seconds())
+				if got.NextRunAt < before+delay || got.NextRunAt > time.Now().Unix()+delay {
+					t.Fatalf("attempt %d: next_run_at = %d, want now+%ds", attempt, got.NextRunAt, delay)
+				}
+
+				// 未到重试时间的任务不会被调度扫描拾取
+				if due, _ := jobRepo.ListDue(context.Background(), time.Now().Unix(), 10); len(due) != 0 {
+					t.Fatalf("attempt %d: ListDue() = %d jobs, want 0", attempt, len(due))
+				}
+			}
+
+			svc.runJob(job.ID)
+			got, _ := svc.Get(testJobOwnerCtx, job.JobNo)
+			if got.Status != model.TransferJobFailed || got.Attempts != job.MaxAttempts || got.FinishedAt == 0 {
+				t.Fatalf("job = %+v, want failed after %d attempts", got, job.MaxAttempts)
+			}
+			if calls := int(transfer.calls.Load()); calls != job.MaxAttempts {
+				t.Fatalf("TransferAndSave calls = %d, want %d", calls, job.MaxAttempts)
+			}
+
+			// 已失败的任务不会再被执行
+			svc.runJob(job.ID)
+			if calls := int(transfer.calls.Load()); calls != job.MaxAttempts {
+				t.Fatalf("TransferAndSave calls after failed = %d, want %d", calls, job.MaxAttempts)
+			}
+		})
+	}
+}
+
+func TestTransferJobBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+		{6, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := transferJobBackoff(tt.attempt); got != tt.want {
+			t.Errorf("transferJobBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestTransferJobCancel(t *testing.T) {
+	t.Run("排队中的任务", func(t *testing.T) {
+		transfer := &stubTransferService{transfer: transferSucceeded}
+		svc := newTestTransferJobService(transfer, repotest.NewTransferJobRepository())
+		job := enqueueTestJob(t, svc)
+
+		got, err := svc.Cancel(testJobOwnerCtx, job.JobNo)
+		if err != nil {
+			t.Fatalf("Cancel() error = %v", err)
+		}
+		if got.Status != model.TransferJobCancelled || got.FinishedAt == 0 {
+			t.Fatalf("job = %+v, want cancelled", got)
+		}
+
+		// 已取消的任务不会被执行，也不能再次取消
+		svc.runJob(job.ID)
+		if calls := transfer.calls.Load(); calls != 0 {
+			t.Fatalf("TransferAndSave calls = %d, want 0", calls)
+		}
+		if _, err := svc.Cancel(testJobOwnerCtx, job.JobNo); err == nil {
+			t.Fatal("Cancel(已取消) error = nil, want error")
+		}
+	})
+
+	t.Run("已成功的任务", func(t *testing.T) {
+		svc := newTestTransferJobService(&stubTransferService{transfer: transferSucceeded}, repotest.NewTransferJobRepository())
+		job := enqueueTestJob(t, svc)
+		svc.runJob(job.ID)
+
+		if _, err := svc.Cancel(testJobOwnerCtx, job.JobNo); err == nil {
+			t.Fatal("Cancel(已成功) error = nil, want error")
+		}
+	})
+
+	t.Run("执行中的任务", func(t *testing.T) {
+		started := make(chan struct{})
+		transfer := &stubTransferService{transfer: func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}}
+		svc := newTestTransferJobService(transfer, repotest.NewTransferJobRepository())
+		job := enqueueTestJob(t, svc)
+
+		done := make(chan struct{})
+		go func() {
+			svc.runJob(job.ID)
+			close(done)
+		}()
+		<-started
+
+		if _, err := svc.Cancel(testJobOwnerCtx, job.JobNo); err != nil {
+			t.Fatalf("Cancel() error = %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runJob did not return after Cancel")
+		}
+
+		got, _ := svc.Get(testJobOwnerCtx, job.JobNo)
+		if got.Status != model.TransferJobCancelled || got.Attempts != 1 {
+			t.Fatalf("job = %+v, want cancelled after 1 attempt", got)
+		}
+	})
+}
+
+func TestTransferJobOwner(t *testing.T) {
+	transfer := &stubTransferService{transfer: transferSucceeded}
+	jobRepo := repotest.NewTransferJobRepository(&model.TransferJob{
+		JobNo:   "wechat-job",
+		Status:  model.TransferJobQueued,
+		Request: `{"items":[{"title":"流浪地球","url":"https://pan.quark.cn/s/abc"}]}`,
+	})
+	svc := newTestTransferJobService(transfer, jobRepo)
+	job := enqueueTestJob(t, svc)
+
+	siteCtx := model.ContextWithSiteSession(context.Background(), "0123456789abcdef0123456789abcdef")
+	otherSiteCtx := model.ContextWithSiteSession(context.Background(), "fedcba9876543210fedcba9876543210")
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		jobNo string
+	}{
+		{name: "其他API Key", ctx: model.ContextWithAPIKey(context.Background(), &model.APIKey{ID: 2}), jobNo: job.JobNo},
+		{name: "本站页面", ctx: siteCtx, jobNo: job.JobNo},
+		{name: "匿名请求", ctx: context.Background(), jobNo: job.JobNo},
+		{name: "匿名请求查询无创建者的任务", ctx: context.Background(), jobNo: "wechat-job"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Get(tt.ctx, tt.jobNo); !errors.Is(err, ErrTransferJobNotFound) {
+				t.Errorf("Get() error = %v, want ErrTransferJobNotFound", err)
+			}
+			if _, err := svc.Cancel(tt.ctx, tt.jobNo); !errors.Is(err, ErrTransferJobNotFound) {
+				t.Errorf("Cancel() error = %v, want ErrTransferJobNotFound", err)
+			}
+		})
+	}
+	if got, _ := jobRepo.GetByID(context.Background(), job.ID); got.Status != model.TransferJobQueued {
+		t.Fatalf("其他调用方取消后 status = %s, want queued", got.Status)
+	}
+
+	// 本站页面创建的任务归属页面会话
+	siteJob, _, err := svc.Enqueue(siteCtx, testTransferRequest(), model.TransferJobSourceWeb, "三体")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(siteCtx, siteJob.JobNo); err != nil {
+		t.Errorf("Get(同一会话) error = %v", err)
+	}
+	if _, err := svc.Get(otherSiteCtx, siteJob.JobNo); !errors.Is(err, ErrTransferJobNotFound) {
+		t.Errorf("Get(其他会话) error = %v, want ErrTransferJobNotFound", err)
+	}
+}
+
+func TestTransferJobEnqueueReusesActive(t *testing.T) {
+	svc := newTestTransferJobService(&stubTransferService{transfer: transferSucceeded}, repotest.NewTransferJobRepository())
+	job := enqueueTestJob(t, svc)
+
+	// 同一调用方重复搜索时复用排队中的任务，不再入队
+	reused, created, err := svc.Enqueue(testJobOwnerCtx, testTransferRequest(), model.TransferJobSourceAPI, "流浪地球")
+	if err != nil || created || reused.JobNo != job.JobNo {
+		t.Fatalf("Enqueue(重复) = %+v, created %v, error %v, want reuse %s", reused, created, err, job.JobNo)
+	}
+	if len(svc.queue) != 0 {
+		t.Fatal("复用任务时不应再次通知队列")
+	}
+
+	// 其他调用方不能拿到他人的任务编号
+	otherCtx := model.ContextWithAPIKey(context.Background(), &model.APIKey{ID: 2})
+	if _, _, err := svc.Enqueue(otherCtx, testTransferRequest(), model.TransferJobSourceAPI, "流浪地球"); !errors.Is(err, ErrTransferJobInProgress) {
+		t.Fatalf("Enqueue(其他调用方) error = %v, want ErrTransferJobInProgress", err)
+	}
+
+	// 其他网盘类型或其他关键词正常新建
+	baiduReq := testTransferRequest()
+	baiduReq.PanType = 1
+	tests := []struct {
+		name    string
+		req     *model.TransferRequest
+		keyword string
+	}{
+		{name: "其他网盘类型", req: baiduReq, keyword: "流浪地球"},
+		{name: "其他关键词", req: testTransferRequest(), keyword: "三体"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, created, err := svc.Enqueue(otherCtx, tt.req, model.TransferJobSourceAPI, tt.keyword)
+			if err != nil || !created || got.JobNo == job.JobNo {
+				t.Fatalf("Enqueue() = %+v, created %v, error %v, want new job", got, created, err)
+			}
+			<-svc.queue
+		})
+	}
+
+	// 任务结束后再次搜索会新建任务
+	svc.runJob(job.ID)
+	next, created, err := svc.Enqueue(otherCtx, testTransferRequest(), model.TransferJobSourceAPI, "流浪地球")
+	if err != nil || !created || next.JobNo == job.JobNo {
+		t.Fatalf("Enqueue(任务结束后) = %+v, created %v, error %v, want new job", next, created, err)
+	}
+}
+
+func TestTransferJobStartRequeuesRunning(t *testing.T) {
+	jobRepo := repotest.NewTransferJobRepository(&model.TransferJob{
+		JobNo:       "T-interrupted",
+		Owner:       "key:1",
+		Status:      model.TransferJobRunning,
+		Request:     `{"items":[{"title":"流浪地球","url":"https://pan.quark.cn/s/abc"}]}`,
+		Attempts:    1,
+		MaxAttempts: 3,
+	})
+	done := make(chan struct{})
+	transfer := &stubTransferService{transfer: func(ctx context.Context, req *model.TransferRequest) (*model.TransferResponse, error) {
+		defer close(done)
+		return transferSucceeded(ctx, req)
+	}}
+	svc := newTestTransferJobService(transfer, jobRepo)
+
+	// 上次退出时中断的任务在启动后重新排队并由工作协程执行
+	svc.Start()
+	defer svc.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("interrupted job was not resumed")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := svc.Get(testJobOwnerCtx, "T-interrupted")
+		if got.Status == model.TransferJobSucceeded {
+			if got.Attempts != 2 {
+				t.Fatalf("attempts = %d, want 2", got.Attempts)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job = %+v, want succeeded", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
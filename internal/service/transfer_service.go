@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -26,6 +27,28 @@ type transferService struct {
 	config     *config.Config
 }
 
+// liveTransferConfig 配置文件热更新后的转存配置，未发生热更新时为nil
+var liveTransferConfig atomic.Pointer[config.TransferConfig]
+
+func init() {
+	config.Subscribe("transfer", func(ev config.ChangeEvent) error {
+		if ev.Source != config.ChangeSourceFile || ev.Config == nil {
+			return nil
+		}
+		transfer := ev.Config.Transfer
+		liveTransferConfig.Store(&transfer)
+		return nil
+	})
+}
+
+// TransferSettings 获取转存配置，配置文件热更新后返回新配置
+func TransferSettings(cfg *config.Config) config.TransferConfig {
+	if transfer := liveTransferConfig.Load(); transfer != nil {
+		return *transfer
+	}
+	return cfg.Transfer
+}
+
 // NewTransferService 创建转存服务
 func NewTransferService(cfg *config.Config) TransferService {
 	return &transferService{
@@ -49,10 +72,11 @@ func (s *transferService) BatchTransfer(ctx context.Context, req *model.Transfer
 	}
 
 	// 设置默认值
+	settings := TransferSettings(s.config)
 	maxTransfer := req.MaxCount     // 最大转存数量
 	maxDisplay := req.MaxDisplay    // 最大展示数量
 	if maxTransfer <= 0 {
-		maxTransfer = s.config.Transfer.MaxSuccess
+		maxTransfer = settings.MaxSuccess
 	}
 	if maxDisplay <= 0 {
 		maxDisplay = maxTransfer // 如果未指定，则展示数量=转存数量
@@ -70,7 +94,7 @@ func (s *transferService) BatchTransfer(ctx context.Context, req *model.Transfer
 	var wg sync.WaitGroup
 
 	// 并发控制信号量
-	semaphore := make(chan struct{}, s.config.Transfer.MaxConcurrent)
+	semaphore := make(chan struct{}, settings.MaxConcurrent)
 	transferredCount := 0    // 已转存成功的数量
 	stopTransfer := false
 
@@ -126,7 +150,7 @@ func (s *transferService) BatchTransfer(ctx context.Context, req *model.Transfer
 			}
 
 			// 设置超时
-			transferCtx, cancel := context.WithTimeout(ctx, s.config.Transfer.GetTimeout())
+			transferCtx, cancel := context.WithTimeout(ctx, settings.GetTimeout())
 			defer cancel()
 
 			// 执行转存
@@ -213,6 +237,9 @@ func (s *transferService) BatchTransfer(ctx context.Context, req *model.Transfer
 		)
 	}
 
+	// 📈 已入库资源被再次转存时累加转存次数
+	s.recordTransfers(ctx, allResults)
+
 	response := &model.TransferResponse{
 		Total:   len(allResults),
 		Success: transferredCount,
@@ -244,6 +271,12 @@ func (s *transferService) TransferAndSave(ctx context.Context, req *model.Transf
 		sources := make([]*model.Source, 0, resp.Success)
 		now := time.Now().Unix()
 
+		// 原始搜索结果，用于保留来源、大小、时间等元数据
+		items := make(map[string]model.SearchResult, len(req.Items))
+		for _, item := range req.Items {
+			items[item.URL] = item
+		}
+
 		transferredCount := 0
 		for _, result := range resp.Results {
 			// 只保存实际转存的链接（Message不是"原始链接(未转存)"）
@@ -263,16 +296,22 @@ func (s *transferService) TransferAndSave(ctx context.Context, req *model.Transf
 					zap.Int("is_time", isTime),
 				)
 				
+				item := items[result.URL]
 				source := &model.Source{
-					Title:      result.Title,
-					URL:        result.NewURL,     // 转存后的新URL
-					Content:    result.URL,        // 原始URL
-					IsType:     result.PanType,
-					Fid:        result.Fid,
-					IsTime:     isTime,            // 根据用户选择设置（保持与PHP版本一致）
-					Status:     1,
-					CreateTime: now,
-					UpdateTime: now,
+					Title:         result.Title,
+					URL:           result.NewURL,   // 转存后的新URL
+					Content:       result.URL,      // 原始URL
+					Password:      result.Password, // 新分享链接的提取码
+					IsType:        result.PanType,
+					Fid:           result.Fid,
+					Size:          model.ParseSize(item.Size),
+					SourceName:    item.Source,
+					SourceTime:    item.Time,
+					IsTime:        isTime, // 根据用户选择设置（保持与PHP版本一致）
+					Status:        1,
+					TransferCount: 1,
+					CreateTime:    now,
+					UpdateTime:    now,
 				}
 				sources = append(sources, source)
 				transferredCount++
@@ -285,6 +324,11 @@ func (s *transferService) TransferAndSave(ctx context.Context, req *model.Transf
 		}
 
 		if len(sources) > 0 {
+			// 🏷️ 按分类规则自动分类
+			if categoryService := GetCategoryService(); categoryService != nil {
+				categoryService.ClassifySources(ctx, sources...)
+			}
+
 			if err := s.sourceRepo.BatchCreate(ctx, sources); err != nil {
 				logger.Error("保存转存结果到数据库失败", zap.Error(err))
 				// 不影响转存结果的返回
@@ -299,6 +343,23 @@ func (s *transferService) TransferAndSave(ctx context.Context, req *model.Transf
 	return resp, nil
 }
 
+// recordTransfers 累加实际转存成功的链接对应的本地资源转存次数（失败只记录日志）
+func (s *transferService) recordTransfers(ctx context.Context, results []model.TransferResult) {
+	urls := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Success && result.Message != "原始链接(未转存)" {
+			urls = append(urls, result.URL)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	if err := s.sourceRepo.IncrementTransferCount(ctx, urls...); err != nil {
+		logger.Warn("更新资源转存次数失败", zap.Error(err))
+	}
+}
+
 // transferSingleWithClient 使用指定的client实例进行单个转存
 // ⚠️ 关键：使用传入的client实例，确保整个转存过程（verifyPassCode + getTransferParams + transfer）
 // 使用同一个实例，保持Cookie状态（特别是BDCLND）的连续性
@@ -313,7 +374,9 @@ func (s *transferService) transferSingleWithClient(ctx context.Context, item mod
 	// 执行转存 - 使用传入的client实例
 	// client内部会依次调用: verifyPassCode → getTransferParams → transferFile → createShare
 	// Cookie状态（如BDCLND）在这些步骤间保持连续
+	start := time.Now()
 	transferResult, err := client.Transfer(ctx, item.URL, item.Password, expiredType)
+	observeTransfer(panType, start, err)
 	if err != nil {
 		result.Message = fmt.Sprintf("转存失败: %v", err)
 		return result
@@ -321,6 +384,7 @@ func (s *transferService) transferSingleWithClient(ctx context.Context, item mod
 
 	result.Success = true
 	result.NewURL = transferResult.ShareURL
+	result.Password = transferResult.Password
 	result.Fid = transferResult.Fid
 	result.ExpiredType = transferResult.ExpiredType  // ← 设置网盘API返回的过期类型
 	result.Message = "转存成功"
@@ -0,0 +1,252 @@
+﻿package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"huoxing-search/internal/model"
+	"huoxing-search/internal/netdisk"
+	"huoxing-search/internal/pkg/config"
+	"huoxing-search/internal/repository/repotest"
+)
+
+func newTestTransferService(client netdisk.Netdisk, sourceRepo *repotest.SourceRepository) *transferService {
+	return &transferService{
+		sourceRepo: sourceRepo,
+		netdisk:    &stubNetdiskManager{client: client},
+		config: &config.Config{
+			Transfer: config.TransferConfig{MaxConcurrent: 1, Timeout: 5, MaxSuccess: 3},
+		},
+	}
+}
+
+// searchItems 生成n条搜索结果，链接为 https://pan.quark.cn/s/{i}
+func searchItems(n int) []model.SearchResult {
+	items := make([]model.SearchResult, n)
+	for i := range items {
+		items[i] = model.SearchResult{
+			Title:   fmt.Sprintf("资源%d", i),
+			URL:     fmt.Sprintf("https://pan.quark.cn/s/%d", i),
+			PanType: model.PanTypeQuark,
+		}
+	}
+	return items
+}
+
+// transferFailing 返回对指定链接转存失败、其余成功的客户端
+func transferFailing(failed ...string) *stubNetdisk {
+	return &stubNetdisk{transfer: func(shareURL, password string) (*model.TransferResult, error) {
+		for _, url := range failed {
+			if url == shareURL {
+				return nil, errors.New("分享已失效")
+			}
+		}
+		return &model.TransferResult{Success: true, ShareURL: shareURL + "-mine"}, nil
+	}}
+}
+
+func TestBatchTransfer(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      netdisk.Netdisk
+		items       int
+		maxCount    int
+		maxDisplay  int
+		wantTotal   int
+		wantSuccess int
+		wantFailed  int
+		// wantURLs 期望的结果链接（转存成功的以-mine结尾），并发转存的顺序不固定，按排序后比较
+		wantURLs []string
+	}{
+		{
+			name:     "无结果",
+			client:   transferFailing(),
+			items:    0,
+			maxCount: 2,
+			wantURLs: []string{},
+		},
+		{
+			name:        "转存前N条并补充原始链接",
+			client:      transferFailing(),
+			items:       5,
+			maxCount:    2,
+			maxDisplay:  4,
+			wantTotal:   4,
+			wantSuccess: 2,
+			wantURLs: []string{
+				"https://pan.quark.cn/s/0-mine", "https://pan.quark.cn/s/1-mine",
+				"https://pan.quark.cn/s/2", "https://pan.quark.cn/s/3",
+			},
+		},
+		{
+			name:        "部分转存失败时用原始链接补足展示数量",
+			client:      transferFailing("https://pan.quark.cn/s/0"),
+			items:       5,
+			maxCount:    2,
+			maxDisplay:  3,
+			wantTotal:   3,
+			wantSuccess: 1,
+			wantFailed:  1,
+			wantURLs: []string{
+				"https://pan.quark.cn/s/1-mine",
+				"https://pan.quark.cn/s/2", "https://pan.quark.cn/s/3",
+			},
+		},
+		{
+			name:        "未指定数量时使用配置的max_success",
+			client:      transferFailing(),
+			items:       5,
+			wantTotal:   3,
+			wantSuccess: 3,
+			wantURLs: []string{
+				"https://pan.quark.cn/s/0-mine", "https://pan.quark.cn/s/1-mine", "https://pan.quark.cn/s/2-mine",
+			},
+		},
+		{
+			name:       "网盘未配置时只返回原始链接",
+			client:     nil,
+			items:      3,
+			maxCount:   1,
+			maxDisplay: 2,
+			wantTotal:  2,
+			wantFailed: 1,
+			wantURLs:   []string{"https://pan.quark.cn/s/1", "https://pan.quark.cn/s/2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestTransferService(tt.client, repotest.NewSourceRepository())
+
+			resp, err := s.BatchTransfer(context.Background(), &model.TransferRequest{
+				Items:      searchItems(tt.items),
+				PanType:    model.PanTypeQuark,
+				MaxCount:   tt.maxCount,
+				MaxDisplay: tt.maxDisplay,
+			})
+			if err != nil {
+				t.Fatalf("BatchTransfer() error = %v", err)
+			}
+			if resp.Total != tt.wantTotal || resp.Success != tt.wantSuccess || resp.Failed != tt.wantFailed {
+				t.Errorf("BatchTransfer() total/success/failed = %d/%d/%d, want %d/%d/%d",
+					resp.Total, resp.Success, resp.Failed, tt.wantTotal, tt.wantSuccess, tt.wantFailed)
+			}
+
+			urls := make([]string, len(resp.Results))
+			for i, result := range resp.Results {
+				urls[i] = result.NewURL
+			}
+			sort.Strings(urls)
+			if strings.Join(urls, ",") != strings.Join(tt.wantURLs, ",") {
+				t.Errorf("BatchTransfer() urls = %v, want %v", urls, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestTransferAndSave(t *testing.T) {
+	tests := []struct {
+		name        string
+		expiredType int
+		wantIsTime  int
+	}{
+		{name: "永久资源", expiredType: 0, wantIsTime: 0},
+		{name: "临时资源", expiredType: 2, wantIsTime: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceRepo := repotest.NewSourceRepository()
+			s := newTestTransferService(transferFailing(), sourceRepo)
+
+			resp, err := s.TransferAndSave(context.Background(), &model.TransferRequest{
+				Items:       searchItems(4),
+				PanType:     model.PanTypeQuark,
+				MaxCount:    2,
+				MaxDisplay:  4,
+				ExpiredType: tt.expiredType,
+			})
+			if err != nil {
+				t.Fatalf("TransferAndSave() error = %v", err)
+			}
+			if resp.Total != 4 {
+				t.Errorf("TransferAndSave() total = %d, want 4", resp.Total)
+			}
+
+			// 只保存实际转存的链接，原始链接不入库
+			saved := sourceRepo.All()
+			if len(saved) != 2 {
+				t.Fatalf("保存数量 = %d, want 2", len(saved))
+			}
+			for _, source := range saved {
+				if !strings.HasSuffix(source.URL, "-mine") || source.Status != 1 || source.IsTime != tt.wantIsTime {
+					t.Errorf("保存的资源 = %+v", source)
+				}
+			}
+		})
+	}
+}
+
+func TestTransferAndSaveIgnoresSaveError(t *testing.T) {
+	sourceRepo := repotest.NewSourceRepository()
+	sourceRepo.Err = errors.New("数据库不可用")
+	s := newTestTransferService(transferFailing(), sourceRepo)
+
+	resp, err := s.TransferAndSave(context.Background(), &model.TransferRequest{
+		Items:    searchItems(2),
+		PanType:  model.PanTypeQuark,
+		MaxCount: 2,
+	})
+	if err != nil {
+		t.Fatalf("TransferAndSave() error = %v", err)
+	}
+	if resp.Success != 2 {
+		t.Errorf("TransferAndSave() success = %d, want 2", resp.Success)
+	}
+}
+
+func TestTransferAndSaveKeepsMetadata(t *testing.T) {
+	// 已入库的资源再次被转存时累加转存次数
+	sourceRepo := repotest.NewSourceRepository(&model.Source{
+		Title: "已入库", URL: "https://pan.quark.cn/s/old-mine", Content: "https://pan.quark.cn/s/0", Status: 1,
+	})
+	client := &stubNetdisk{transfer: func(shareURL, password string) (*model.TransferResult, error) {
+		return &model.TransferResult{Success: true, ShareURL: shareURL + "-mine", Password: "n3w1"}, nil
+	}}
+	s := newTestTransferService(client, sourceRepo)
+
+	_, err := s.TransferAndSave(context.Background(), &model.TransferRequest{
+		Items: []model.SearchResult{{
+			Title:    "三体 4K",
+			URL:      "https://pan.quark.cn/s/0",
+			Password: "o1d2",
+			Source:   "qqpd",
+			Size:     "1.5 GB",
+			Time:     "2024-05-01",
+			PanType:  model.PanTypeQuark,
+		}},
+		PanType:  model.PanTypeQuark,
+		MaxCount: 1,
+	})
+	if err != nil {
+		t.Fatalf("TransferAndSave() error = %v", err)
+	}
+
+	saved := sourceRepo.All()
+	if len(saved) != 2 {
+		t.Fatalf("保存数量 = %d, want 2", len(saved))
+	}
+	if saved[0].TransferCount != 1 {
+		t.Errorf("已入库资源转存次数 = %d, want 1", saved[0].TransferCount)
+	}
+
+	source := saved[1]
+	if source.Password != "n3w1" || source.SourceName != "qqpd" || source.SourceTime != "2024-05-01" ||
+		source.Size != 3<<29 || source.TransferCount != 1 || source.Content != "https://pan.quark.cn/s/0" {
+		t.Errorf("保存的资源 = %+v", source)
+	}
+}
@@ -1,7 +1,6 @@
 ﻿package config
 
 import (
-	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
@@ -22,6 +21,7 @@ type Config struct {
 	CachePath       string
 	CacheMaxSizeMB  int
 	CacheTTLMinutes int
+	CacheSerializer string // 缓存序列化器：gob（默认）或 sonic
 	// 压缩相关配置
 	EnableCompression bool
 	MinSizeToCompress int // 最小压缩大小（字节）
@@ -29,8 +29,10 @@ type Config struct {
 	GCPercent      int  // GC触发阈值百分比
 	OptimizeMemory bool // 是否启用内存优化
 	// 插件相关配置
-	PluginTimeoutSeconds int           // 插件超时时间（秒）
-	PluginTimeout        time.Duration // 插件超时时间（Duration）
+	PluginTimeoutSeconds   int           // 插件超时时间（秒）
+	PluginTimeout          time.Duration // 插件超时时间（Duration）
+	PluginBreakerThreshold int           // 插件连续失败多少次后自动熔断，0表示不熔断
+	PluginBreakerCooldown  time.Duration // 熔断后多久重新探测插件
 	// 异步插件相关配置
 	AsyncPluginEnabled        bool          // 是否启用异步插件
 	EnabledPlugins            []string      // 启用的具体插件列表（空表示启用所有）
@@ -56,7 +58,7 @@ type Config struct {
 // 全局配置实例
 var AppConfig *Config
 
-// 初始化配置
+// 初始化配置，各配置项按 环境变量 > SetLayers设置的配置层 > 默认值 的优先级读取
 func Init() {
 	proxyURL := getProxyURL()
 	pluginTimeoutSeconds := getPluginTimeout()
@@ -73,6 +75,7 @@ func Init() {
 		CachePath:       getCachePath(),
 		CacheMaxSizeMB:  getCacheMaxSize(),
 		CacheTTLMinutes: getCacheTTL(),
+		CacheSerializer: getCacheSerializer(),
 		// 压缩相关配置
 		EnableCompression: getEnableCompression(),
 		MinSizeToCompress: getMinSizeToCompress(),
@@ -80,8 +83,10 @@ func Init() {
 		GCPercent:      getGCPercent(),
 		OptimizeMemory: getOptimizeMemory(),
 		// 插件相关配置
-		PluginTimeoutSeconds: pluginTimeoutSeconds,
-		PluginTimeout:        time.Duration(pluginTimeoutSeconds) * time.Second,
+		PluginTimeoutSeconds:   pluginTimeoutSeconds,
+		PluginTimeout:          time.Duration(pluginTimeoutSeconds) * time.Second,
+		PluginBreakerThreshold: getPluginBreakerThreshold(),
+		PluginBreakerCooldown:  getPluginBreakerCooldown(),
 		// 异步插件相关配置
 		AsyncPluginEnabled:        getAsyncPluginEnabled(),
 		EnabledPlugins:            getEnabledPlugins(),
@@ -104,13 +109,15 @@ func Init() {
 
 	}
 	
+	recordSources()
+	
 	// 应用GC配置
 	applyGCSettings()
 }
 
 // 从环境变量获取默认频道列表，如果未设置则使用默认值
 func getDefaultChannels() []string {
-	channelsEnv := os.Getenv("CHANNELS")
+	channelsEnv := getenv("CHANNELS")
 	if channelsEnv == "" {
 		return []string{"tgsearchers4"}
 	}
@@ -119,7 +126,7 @@ func getDefaultChannels() []string {
 
 // 从环境变量获取默认并发数，如果未设置则使用基于环境变量的简单计算
 func getDefaultConcurrency() int {
-	concurrencyEnv := os.Getenv("CONCURRENCY")
+	concurrencyEnv := getenv("CONCURRENCY")
 	if concurrencyEnv != "" {
 		concurrency, err := strconv.Atoi(concurrencyEnv)
 		if err == nil && concurrency > 0 {
@@ -132,7 +139,7 @@ func getDefaultConcurrency() int {
 	channelCount := len(getDefaultChannels())
 	
 	// 估计插件数（从环境变量或默认值，实际在应用启动后会根据真实插件数调整）
-	pluginCountEnv := os.Getenv("PLUGIN_COUNT")
+	pluginCountEnv := getenv("PLUGIN_COUNT")
 	pluginCount := 0
 	if pluginCountEnv != "" {
 		count, err := strconv.Atoi(pluginCountEnv)
@@ -163,7 +170,7 @@ func UpdateDefaultConcurrency(pluginCount int) {
 	}
 	
 	// 只有当未通过环境变量指定并发数时才进行调整
-	concurrencyEnv := os.Getenv("CONCURRENCY")
+	concurrencyEnv := getenv("CONCURRENCY")
 	if concurrencyEnv != "" {
 		return
 	}
@@ -183,7 +190,7 @@ func UpdateDefaultConcurrency(pluginCount int) {
 
 // 从环境变量获取服务端口，如果未设置则使用默认值
 func getPort() string {
-	port := os.Getenv("PORT")
+	port := getenv("PORT")
 	if port == "" {
 		return "8888"
 	}
@@ -192,12 +199,12 @@ func getPort() string {
 
 // 从环境变量获取SOCKS5代理URL，如果未设置则返回空字符串
 func getProxyURL() string {
-	return os.Getenv("PROXY")
+	return getenv("PROXY")
 }
 
 // 从环境变量获取是否启用缓存，如果未设置则默认启用
 func getCacheEnabled() bool {
-	enabled := os.Getenv("CACHE_ENABLED")
+	enabled := getenv("CACHE_ENABLED")
 	if enabled == "" {
 		return true
 	}
@@ -206,7 +213,7 @@ func getCacheEnabled() bool {
 
 // 从环境变量获取缓存路径，如果未设置则使用默认路径
 func getCachePath() string {
-	path := os.Getenv("CACHE_PATH")
+	path := getenv("CACHE_PATH")
 	if path == "" {
 		// 默认在当前目录下创建cache文件夹
 		defaultPath, err := filepath.Abs("./cache")
@@ -220,7 +227,7 @@ func getCachePath() string {
 
 // 从环境变量获取缓存最大大小(MB)，如果未设置则使用默认值
 func getCacheMaxSize() int {
-	sizeEnv := os.Getenv("CACHE_MAX_SIZE")
+	sizeEnv := getenv("CACHE_MAX_SIZE")
 	if sizeEnv == "" {
 		return 100 // 默认100MB
 	}
@@ -233,7 +240,7 @@ func getCacheMaxSize() int {
 
 // 从环境变量获取缓存TTL(分钟)，如果未设置则使用默认值
 func getCacheTTL() int {
-	ttlEnv := os.Getenv("CACHE_TTL")
+	ttlEnv := getenv("CACHE_TTL")
 	if ttlEnv == "" {
 		return 60 // 默认60分钟
 	}
@@ -244,9 +251,18 @@ func getCacheTTL() int {
 	return ttl
 }
 
+// 从环境变量获取缓存序列化器，如果未设置则使用gob
+func getCacheSerializer() string {
+	name := strings.ToLower(strings.TrimSpace(getenv("CACHE_SERIALIZER")))
+	if name == "" {
+		return "gob"
+	}
+	return name
+}
+
 // 从环境变量获取是否启用压缩，如果未设置则默认禁用
 func getEnableCompression() bool {
-	enabled := os.Getenv("ENABLE_COMPRESSION")
+	enabled := getenv("ENABLE_COMPRESSION")
 	if enabled == "" {
 		return false // 默认禁用，因为通常由Nginx等处理
 	}
@@ -255,7 +271,7 @@ func getEnableCompression() bool {
 
 // 从环境变量获取最小压缩大小，如果未设置则使用默认值
 func getMinSizeToCompress() int {
-	sizeEnv := os.Getenv("MIN_SIZE_TO_COMPRESS")
+	sizeEnv := getenv("MIN_SIZE_TO_COMPRESS")
 	if sizeEnv == "" {
 		return 1024 // 默认1KB
 	}
@@ -268,7 +284,7 @@ func getMinSizeToCompress() int {
 
 // 从环境变量获取GC百分比，如果未设置则使用默认值
 func getGCPercent() int {
-	percentEnv := os.Getenv("GC_PERCENT")
+	percentEnv := getenv("GC_PERCENT")
 	if percentEnv == "" {
 		return 50 // 默认50% - 优化内存管理，更频繁的GC避免内存暴涨
 	}
@@ -281,7 +297,7 @@ func getGCPercent() int {
 
 // 从环境变量获取是否优化内存，如果未设置则默认启用
 func getOptimizeMemory() bool {
-	enabled := os.Getenv("OPTIMIZE_MEMORY")
+	enabled := getenv("OPTIMIZE_MEMORY")
 	if enabled == "" {
 		return true // 默认启用
 	}
@@ -290,7 +306,7 @@ func getOptimizeMemory() bool {
 
 // 从环境变量获取插件超时时间（秒），如果未设置则使用默认值
 func getPluginTimeout() int {
-	timeoutEnv := os.Getenv("PLUGIN_TIMEOUT")
+	timeoutEnv := getenv("PLUGIN_TIMEOUT")
 	if timeoutEnv == "" {
 		return 30 // 默认30秒
 	}
@@ -301,9 +317,35 @@ func getPluginTimeout() int {
 	return timeout
 }
 
+// 从环境变量获取插件熔断的连续失败次数，如果未设置则使用默认值，0表示不熔断
+func getPluginBreakerThreshold() int {
+	thresholdEnv := getenv("PLUGIN_BREAKER_THRESHOLD")
+	if thresholdEnv == "" {
+		return 5 // 默认连续失败5次
+	}
+	threshold, err := strconv.Atoi(thresholdEnv)
+	if err != nil || threshold < 0 {
+		return 5
+	}
+	return threshold
+}
+
+// 从环境变量获取插件熔断后的探测间隔，如果未设置则使用默认值
+func getPluginBreakerCooldown() time.Duration {
+	cooldownEnv := getenv("PLUGIN_BREAKER_COOLDOWN")
+	if cooldownEnv == "" {
+		return 5 * time.Minute // 默认5分钟
+	}
+	cooldown, err := strconv.Atoi(cooldownEnv)
+	if err != nil || cooldown <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(cooldown) * time.Second
+}
+
 // 从环境变量获取是否启用异步插件，如果未设置则默认启用
 func getAsyncPluginEnabled() bool {
-	enabled := os.Getenv("ASYNC_PLUGIN_ENABLED")
+	enabled := getenv("ASYNC_PLUGIN_ENABLED")
 	if enabled == "" {
 		return true // 默认启用
 	}
@@ -315,7 +357,7 @@ func getAsyncPluginEnabled() bool {
 // 返回[]string{}表示设置为空字符串（禁用所有插件）
 // 返回具体列表表示仅启用指定插件
 func getEnabledPlugins() []string {
-	plugins, exists := os.LookupEnv("ENABLED_PLUGINS")
+	plugins, exists := lookupEnv("ENABLED_PLUGINS")
 	if !exists {
 		// 未设置环境变量时返回nil，表示启用所有插件
 		return nil
@@ -340,7 +382,7 @@ func getEnabledPlugins() []string {
 
 // 从环境变量获取异步响应超时时间（秒），如果未设置则使用默认值
 func getAsyncResponseTimeout() int {
-	timeoutEnv := os.Getenv("ASYNC_RESPONSE_TIMEOUT")
+	timeoutEnv := getenv("ASYNC_RESPONSE_TIMEOUT")
 	if timeoutEnv == "" {
 		return 4 // 默认4秒
 	}
@@ -353,7 +395,7 @@ func getAsyncResponseTimeout() int {
 
 // 从环境变量获取最大后台工作者数量，如果未设置则自动计算
 func getAsyncMaxBackgroundWorkers() int {
-	sizeEnv := os.Getenv("ASYNC_MAX_BACKGROUND_WORKERS")
+	sizeEnv := getenv("ASYNC_MAX_BACKGROUND_WORKERS")
 	if sizeEnv != "" {
 		size, err := strconv.Atoi(sizeEnv)
 		if err == nil && size > 0 {
@@ -376,7 +418,7 @@ func getAsyncMaxBackgroundWorkers() int {
 
 // 从环境变量获取最大后台任务数量，如果未设置则自动计算
 func getAsyncMaxBackgroundTasks() int {
-	sizeEnv := os.Getenv("ASYNC_MAX_BACKGROUND_TASKS")
+	sizeEnv := getenv("ASYNC_MAX_BACKGROUND_TASKS")
 	if sizeEnv != "" {
 		size, err := strconv.Atoi(sizeEnv)
 		if err == nil && size > 0 {
@@ -398,7 +440,7 @@ func getAsyncMaxBackgroundTasks() int {
 
 // 从环境变量获取异步缓存有效期（小时），如果未设置则使用默认值
 func getAsyncCacheTTLHours() int {
-	ttlEnv := os.Getenv("ASYNC_CACHE_TTL_HOURS")
+	ttlEnv := getenv("ASYNC_CACHE_TTL_HOURS")
 	if ttlEnv == "" {
 		return 1 // 默认1小时
 	}
@@ -411,7 +453,7 @@ func getAsyncCacheTTLHours() int {
 
 // 从环境变量获取HTTP读取超时，如果未设置则自动计算
 func getHTTPReadTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_READ_TIMEOUT")
+	timeoutEnv := getenv("HTTP_READ_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -437,7 +479,7 @@ func getHTTPReadTimeout() time.Duration {
 
 // 从环境变量获取HTTP写入超时，如果未设置则自动计算
 func getHTTPWriteTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_WRITE_TIMEOUT")
+	timeoutEnv := getenv("HTTP_WRITE_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -463,7 +505,7 @@ func getHTTPWriteTimeout() time.Duration {
 
 // 从环境变量获取HTTP空闲超时，如果未设置则自动计算
 func getHTTPIdleTimeout() time.Duration {
-	timeoutEnv := os.Getenv("HTTP_IDLE_TIMEOUT")
+	timeoutEnv := getenv("HTTP_IDLE_TIMEOUT")
 	if timeoutEnv != "" {
 		timeout, err := strconv.Atoi(timeoutEnv)
 		if err == nil && timeout > 0 {
@@ -477,7 +519,7 @@ func getHTTPIdleTimeout() time.Duration {
 
 // 从环境变量获取HTTP最大连接数，如果未设置则自动计算
 func getHTTPMaxConns() int {
-	maxConnsEnv := os.Getenv("HTTP_MAX_CONNS")
+	maxConnsEnv := getenv("HTTP_MAX_CONNS")
 	if maxConnsEnv != "" {
 		maxConns, err := strconv.Atoi(maxConnsEnv)
 		if err == nil && maxConns > 0 {
@@ -500,7 +542,7 @@ func getHTTPMaxConns() int {
 
 // 从环境变量获取异步插件日志开关，如果未设置则使用默认值
 func getAsyncLogEnabled() bool {
-	logEnv := os.Getenv("ASYNC_LOG_ENABLED")
+	logEnv := getenv("ASYNC_LOG_ENABLED")
 	if logEnv == "" {
 		return true // 默认启用日志
 	}
@@ -513,13 +555,13 @@ func getAsyncLogEnabled() bool {
 
 // 从环境变量获取认证开关，如果未设置则默认关闭
 func getAuthEnabled() bool {
-	enabled := os.Getenv("AUTH_ENABLED")
+	enabled := getenv("AUTH_ENABLED")
 	return enabled == "true" || enabled == "1"
 }
 
 // 从环境变量获取用户配置，格式：user1:pass1,user2:pass2
 func getAuthUsers() map[string]string {
-	usersEnv := os.Getenv("AUTH_USERS")
+	usersEnv := getenv("AUTH_USERS")
 	if usersEnv == "" {
 		return nil
 	}
@@ -541,7 +583,7 @@ func getAuthUsers() map[string]string {
 
 // 从环境变量获取Token有效期（小时），如果未设置则使用默认值
 func getAuthTokenExpiry() time.Duration {
-	expiryEnv := os.Getenv("AUTH_TOKEN_EXPIRY")
+	expiryEnv := getenv("AUTH_TOKEN_EXPIRY")
 	if expiryEnv == "" {
 		return 24 * time.Hour // 默认24小时
 	}
@@ -554,7 +596,7 @@ func getAuthTokenExpiry() time.Duration {
 
 // 从环境变量获取JWT密钥，如果未设置则生成随机密钥
 func getAuthJWTSecret() string {
-	secret := os.Getenv("AUTH_JWT_SECRET")
+	secret := getenv("AUTH_JWT_SECRET")
 	if secret == "" {
 		// 生成随机密钥（32字节）
 		import_crypto := "crypto/rand"
@@ -0,0 +1,205 @@
+﻿package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 配置来源，优先级从高到低
+const (
+	SourceEnv     = "env"     // 环境变量
+	SourceDB      = "db"      // 系统配置表
+	SourceYAML    = "yaml"    // config.yaml 的 pansou.engine
+	SourceDefault = "default" // 默认值或自动计算
+)
+
+// Layer 环境变量之外的一层配置来源，Values以环境变量名为键
+type Layer struct {
+	Source string
+	Values map[string]string
+}
+
+var (
+	layersMu sync.RWMutex
+	layers   []Layer
+	sources  map[string]string // 上次Init时各配置项的来源
+)
+
+// SetLayers 设置环境变量之外的配置来源，按优先级从高到低传入，下次Init时生效
+func SetLayers(l ...Layer) {
+	layersMu.Lock()
+	defer layersMu.Unlock()
+	layers = l
+}
+
+// lookup 按 环境变量 > 各配置层 的优先级查找配置值
+// allowEmpty为false时空值视为未设置，继续查找下一层
+func lookup(key string, allowEmpty bool) (string, string, bool) {
+	if v, ok := os.LookupEnv(key); ok && (allowEmpty || v != "") {
+		return v, SourceEnv, true
+	}
+
+	layersMu.RLock()
+	defer layersMu.RUnlock()
+	for _, l := range layers {
+		if v, ok := l.Values[key]; ok && (allowEmpty || v != "") {
+			return v, l.Source, true
+		}
+	}
+	return "", SourceDefault, false
+}
+
+// getenv 读取配置项，未设置时返回空字符串
+func getenv(key string) string {
+	v, _, _ := lookup(key, false)
+	return v
+}
+
+// lookupEnv 读取配置项，区分未设置和设置为空
+func lookupEnv(key string) (string, bool) {
+	v, _, ok := lookup(key, true)
+	return v, ok
+}
+
+// Setting 可在管理后台查看和修改的配置项
+type Setting struct {
+	Key         string // 环境变量名
+	Title       string
+	Description string
+	Number      bool // 数值类型
+	value       func(c *Config) string
+}
+
+// Name 系统配置表中的配置名称，如 PLUGIN_TIMEOUT 对应 pansou_plugin_timeout
+func (s Setting) Name() string {
+	return DBPrefix + strings.ToLower(s.Key)
+}
+
+// YAMLKey config.yaml 中 pansou.engine 下的键名
+func (s Setting) YAMLKey() string {
+	return strings.ToLower(s.Key)
+}
+
+// DBPrefix 系统配置表中Pansou引擎配置的名称前缀
+const DBPrefix = "pansou_"
+
+func itoa(n int) string { return strconv.Itoa(n) }
+
+func seconds(d time.Duration) string { return strconv.Itoa(int(d / time.Second)) }
+
+// Settings 对外开放的配置项（不含独立运行时才使用的端口和认证配置）
+var Settings = []Setting{
+	{Key: "CHANNELS", Title: "TG搜索频道", Description: "搜索的Telegram频道，用逗号分隔",
+		value: func(c *Config) string { return strings.Join(c.DefaultChannels, ",") }},
+	{Key: "CONCURRENCY", Title: "搜索并发数", Description: "单次搜索的最大并发数，留空按频道数+插件数+10自动计算", Number: true,
+		value: func(c *Config) string { return itoa(c.DefaultConcurrency) }},
+	{Key: "PLUGIN_COUNT", Title: "预估插件数", Description: "自动计算并发数时使用的插件数，插件加载后按实际数量调整", Number: true,
+		value: func(c *Config) string { return getenv("PLUGIN_COUNT") }},
+	{Key: "PROXY", Title: "代理地址", Description: "访问Telegram和插件站点使用的代理，如 socks5://127.0.0.1:1080",
+		value: func(c *Config) string { return c.ProxyURL }},
+	{Key: "CACHE_ENABLED", Title: "启用引擎缓存", Description: "是否缓存插件和频道的搜索结果：true/false",
+		value: func(c *Config) string { return strconv.FormatBool(c.CacheEnabled) }},
+	{Key: "CACHE_PATH", Title: "缓存目录", Description: "磁盘缓存目录，默认为运行目录下的cache",
+		value: func(c *Config) string { return c.CachePath }},
+	{Key: "CACHE_MAX_SIZE", Title: "缓存大小上限", Description: "磁盘缓存最大占用(MB)", Number: true,
+		value: func(c *Config) string { return itoa(c.CacheMaxSizeMB) }},
+	{Key: "CACHE_TTL", Title: "缓存有效期", Description: "搜索结果缓存有效期(分钟)", Number: true,
+		value: func(c *Config) string { return itoa(c.CacheTTLMinutes) }},
+	{Key: "CACHE_SERIALIZER", Title: "缓存序列化方式", Description: "gob 或 sonic",
+		value: func(c *Config) string { return c.CacheSerializer }},
+	{Key: "ENABLE_COMPRESSION", Title: "启用缓存压缩", Description: "是否压缩磁盘缓存：true/false",
+		value: func(c *Config) string { return strconv.FormatBool(c.EnableCompression) }},
+	{Key: "MIN_SIZE_TO_COMPRESS", Title: "最小压缩大小", Description: "超过该大小(字节)的缓存才压缩", Number: true,
+		value: func(c *Config) string { return itoa(c.MinSizeToCompress) }},
+	{Key: "GC_PERCENT", Title: "GC触发百分比", Description: "Go运行时的GOGC值", Number: true,
+		value: func(c *Config) string { return itoa(c.GCPercent) }},
+	{Key: "OPTIMIZE_MEMORY", Title: "内存优化", Description: "启动时是否释放空闲内存：true/false",
+		value: func(c *Config) string { return strconv.FormatBool(c.OptimizeMemory) }},
+	{Key: "PLUGIN_TIMEOUT", Title: "插件超时时间", Description: "单个插件搜索的超时时间(秒)", Number: true,
+		value: func(c *Config) string { return itoa(c.PluginTimeoutSeconds) }},
+	{Key: "PLUGIN_BREAKER_THRESHOLD", Title: "插件熔断阈值", Description: "插件连续失败多少次后暂停调用，0表示不熔断", Number: true,
+		value: func(c *Config) string { return itoa(c.PluginBreakerThreshold) }},
+	{Key: "PLUGIN_BREAKER_COOLDOWN", Title: "插件熔断探测间隔", Description: "插件熔断后多久(秒)重新放行一次请求探测是否恢复", Number: true,
+		value: func(c *Config) string { return seconds(c.PluginBreakerCooldown) }},
+	{Key: "ASYNC_PLUGIN_ENABLED", Title: "启用插件搜索", Description: "是否启用插件搜索：true/false",
+		value: func(c *Config) string { return strconv.FormatBool(c.AsyncPluginEnabled) }},
+	{Key: "ENABLED_PLUGINS", Title: "启用的插件", Description: "启用的插件名，用逗号分隔，未设置表示全部启用",
+		value: func(c *Config) string {
+			if c.EnabledPlugins == nil {
+				return "*"
+			}
+			return strings.Join(c.EnabledPlugins, ",")
+		}},
+	{Key: "ASYNC_RESPONSE_TIMEOUT", Title: "插件响应超时", Description: "插件超过该时间(秒)未返回时先响应已有结果，剩余在后台继续", Number: true,
+		value: func(c *Config) string { return itoa(c.AsyncResponseTimeout) }},
+	{Key: "ASYNC_MAX_BACKGROUND_WORKERS", Title: "后台工作者数", Description: "插件后台工作池大小，留空按CPU核数自动计算", Number: true,
+		value: func(c *Config) string { return itoa(c.AsyncMaxBackgroundWorkers) }},
+	{Key: "ASYNC_MAX_BACKGROUND_TASKS", Title: "后台任务上限", Description: "同时执行的插件后台任务上限，留空按工作者数自动计算", Number: true,
+		value: func(c *Config) string { return itoa(c.AsyncMaxBackgroundTasks) }},
+	{Key: "ASYNC_CACHE_TTL_HOURS", Title: "插件缓存有效期", Description: "插件搜索结果缓存有效期(小时)", Number: true,
+		value: func(c *Config) string { return itoa(c.AsyncCacheTTLHours) }},
+	{Key: "ASYNC_LOG_ENABLED", Title: "插件详细日志", Description: "是否输出插件异步搜索的详细日志：true/false",
+		value: func(c *Config) string { return strconv.FormatBool(c.AsyncLogEnabled) }},
+	{Key: "HTTP_READ_TIMEOUT", Title: "HTTP读取超时", Description: "插件请求的读取超时(秒)，留空自动计算", Number: true,
+		value: func(c *Config) string { return seconds(c.HTTPReadTimeout) }},
+	{Key: "HTTP_WRITE_TIMEOUT", Title: "HTTP写入超时", Description: "插件请求的写入超时(秒)，留空自动计算", Number: true,
+		value: func(c *Config) string { return seconds(c.HTTPWriteTimeout) }},
+	{Key: "HTTP_IDLE_TIMEOUT", Title: "HTTP空闲超时", Description: "插件连接的空闲超时(秒)", Number: true,
+		value: func(c *Config) string { return seconds(c.HTTPIdleTimeout) }},
+	{Key: "HTTP_MAX_CONNS", Title: "HTTP最大连接数", Description: "插件请求的最大连接数，留空按CPU核数自动计算", Number: true,
+		value: func(c *Config) string { return itoa(c.HTTPMaxConns) }},
+}
+
+// EffectiveSetting 配置项的生效值及来源
+type EffectiveSetting struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	YAMLKey     string `json:"yaml_key"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+}
+
+// recordSources 记录本次Init时各配置项的来源
+func recordSources() {
+	m := make(map[string]string, len(Settings))
+	for _, s := range Settings {
+		_, source, _ := lookup(s.Key, s.Key == "ENABLED_PLUGINS")
+		m[s.Key] = source
+	}
+	layersMu.Lock()
+	sources = m
+	layersMu.Unlock()
+}
+
+// Effective 当前生效的配置值及来源，未初始化时返回nil
+func Effective() []EffectiveSetting {
+	if AppConfig == nil {
+		return nil
+	}
+	layersMu.RLock()
+	recorded := sources
+	layersMu.RUnlock()
+
+	result := make([]EffectiveSetting, 0, len(Settings))
+	for _, s := range Settings {
+		source := recorded[s.Key]
+		if source == "" {
+			source = SourceDefault
+		}
+		result = append(result, EffectiveSetting{
+			Key:         s.Key,
+			Name:        s.Name(),
+			YAMLKey:     s.YAMLKey(),
+			Title:       s.Title,
+			Description: s.Description,
+			Value:       s.value(AppConfig),
+			Source:      source,
+		})
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+﻿package config
+
+import "testing"
+
+func TestLayerPrecedence(t *testing.T) {
+	t.Setenv("PLUGIN_TIMEOUT", "12")
+	t.Setenv("CACHE_TTL", "")
+	SetLayers(
+		Layer{Source: SourceDB, Values: map[string]string{"PLUGIN_TIMEOUT": "20", "CACHE_TTL": "90"}},
+		Layer{Source: SourceYAML, Values: map[string]string{"CACHE_TTL": "30", "CHANNELS": "a,b", "ENABLED_PLUGINS": ""}},
+	)
+	defer SetLayers()
+	Init()
+
+	if AppConfig.PluginTimeoutSeconds != 12 {
+		t.Errorf("PluginTimeoutSeconds = %d, want 环境变量的12", AppConfig.PluginTimeoutSeconds)
+	}
+	// 空的环境变量视为未设置
+	if AppConfig.CacheTTLMinutes != 90 {
+		t.Errorf("CacheTTLMinutes = %d, want 数据库的90", AppConfig.CacheTTLMinutes)
+	}
+	if len(AppConfig.DefaultChannels) != 2 {
+		t.Errorf("DefaultChannels = %v, want 配置文件的[a b]", AppConfig.DefaultChannels)
+	}
+	// ENABLED_PLUGINS 设置为空表示禁用全部插件
+	if AppConfig.EnabledPlugins == nil || len(AppConfig.EnabledPlugins) != 0 {
+		t.Errorf("EnabledPlugins = %#v, want 空列表", AppConfig.EnabledPlugins)
+	}
+
+	want := map[string][2]string{
+		"PLUGIN_TIMEOUT":  {"12", SourceEnv},
+		"CACHE_TTL":       {"90", SourceDB},
+		"CHANNELS":        {"a,b", SourceYAML},
+		"ENABLED_PLUGINS": {"", SourceYAML},
+		"CACHE_MAX_SIZE":  {"100", SourceDefault},
+	}
+	for _, s := range Effective() {
+		if w, ok := want[s.Key]; ok && (s.Value != w[0] || s.Source != w[1]) {
+			t.Errorf("%s = %q(%s), want %q(%s)", s.Key, s.Value, s.Source, w[0], w[1])
+		}
+	}
+}
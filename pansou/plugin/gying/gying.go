@@ -240,7 +240,7 @@ const HTMLTemplate = `<!DOCTYPE html>
 
             <details>
                 <summary style="cursor: pointer; padding: 10px 0; font-weight: bold;">登录</summary>
-                <div style="background: #2d3748; color: #68d391; padding: 10px; border-radius: 6px; font-family: monospace; font-size: 12px; overflow-x: auto;">curl -X POST https://your-domain.com/gying/HASH_PLACEHOLDER \
+                <div style="background: #2d3748; color: #68d391; padding: 10px; border-radius: 6px; font-family: monospace; font-size: 12px; overflow-x: auto;">curl -X POST https://your-domain.comBASE_PATH_PLACEHOLDER/HASH_PLACEHOLDER \
   -H "Content-Type: application/json" \
   -d '{"action": "login", "username": "user", "password": "pass"}'</div>
             </details>
@@ -249,7 +249,7 @@ const HTMLTemplate = `<!DOCTYPE html>
 
     <script>
         const HASH = 'HASH_PLACEHOLDER';
-        const API_URL = '/gying/' + HASH;
+        const API_URL = 'BASE_PATH_PLACEHOLDER/' + HASH;
         let statusCheckInterval = null;
 
         window.onload = function() {
@@ -766,14 +766,17 @@ func (p *GyingPlugin) getActiveUsers() []*User {
 // handleManagePage GET路由处理
 func (p *GyingPlugin) handleManagePage(c *gin.Context) {
 	param := c.Param("param")
+	// 路由可能挂载在任意前缀下（如 /admin/plugins/gying），页面和跳转都基于实际访问路径
+	basePath := strings.TrimSuffix(c.Request.URL.Path, "/"+param)
 
 	// 判断是用户名还是hash
 	if len(param) == 64 && p.isHexString(param) {
 		html := strings.ReplaceAll(HTMLTemplate, "HASH_PLACEHOLDER", param)
+		html = strings.ReplaceAll(html, "BASE_PATH_PLACEHOLDER", basePath)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 	} else {
 		hash := p.generateHash(param)
-		c.Redirect(302, "/gying/"+hash)
+		c.Redirect(302, basePath+"/"+hash)
 	}
 }
 
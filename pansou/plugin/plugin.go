@@ -3,6 +3,7 @@
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -155,6 +156,7 @@ func GetPluginByName(name string) (AsyncSearchPlugin, bool) {
 
 // PluginManager 异步插件管理器
 type PluginManager struct {
+	mu      sync.RWMutex
 	plugins []AsyncSearchPlugin
 }
 
@@ -167,6 +169,8 @@ func NewPluginManager() *PluginManager {
 
 // RegisterPlugin 注册异步插件
 func (pm *PluginManager) RegisterPlugin(plugin AsyncSearchPlugin) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	pm.plugins = append(pm.plugins, plugin)
 }
 
@@ -210,8 +214,20 @@ func (pm *PluginManager) RegisterGlobalPluginsWithFilter(enabledPlugins []string
 	}
 }
 
+// ReplaceGlobalPluginsWithFilter 按过滤器重新注册全局异步插件，替换已注册的插件（配置热更新时使用）
+func (pm *PluginManager) ReplaceGlobalPluginsWithFilter(enabledPlugins []string) {
+	next := NewPluginManager()
+	next.RegisterGlobalPluginsWithFilter(enabledPlugins)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.plugins = next.plugins
+}
+
 // GetPlugins 获取所有注册的异步插件
 func (pm *PluginManager) GetPlugins() []AsyncSearchPlugin {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
 	return pm.plugins
 }
 
@@ -376,6 +392,163 @@ func recordAsyncCompletion() {
 	atomic.AddInt64(&asyncCompletions, 1)
 }
 
+// 插件调用结果
+const (
+	pluginCallSuccess = iota
+	pluginCallCacheHit
+	pluginCallError
+	pluginCallTimeout
+)
+
+// pluginLatencyWindow 计算耗时分位数时保留的最近调用次数
+const pluginLatencyWindow = 256
+
+// pluginCounter 单个插件的调用计数
+type pluginCounter struct {
+	requests      int64
+	cacheHits     int64
+	errors        int64
+	timeouts      int64
+	results       int64
+	durationNanos int64
+	lastSuccess   int64 // 最近一次成功的时间（Unix秒）
+	
+	mu        sync.Mutex
+	latencies [pluginLatencyWindow]int64 // 最近调用的耗时（环形缓冲，不含缓存命中）
+	latencyN  int                        // 已记录的耗时总数
+}
+
+// 各插件调用计数，键为插件名
+var pluginCounters sync.Map
+
+func getPluginCounter(name string) *pluginCounter {
+	value, _ := pluginCounters.LoadOrStore(name, &pluginCounter{})
+	return value.(*pluginCounter)
+}
+
+// recordPluginCall 记录一次插件调用及其响应耗时，并更新插件的熔断状态 (内部使用)
+// results为成功时返回的结果数，err为失败原因
+func recordPluginCall(name string, start time.Time, outcome int, results int, err error) {
+	counter := getPluginCounter(name)
+	duration := int64(time.Since(start))
+	
+	atomic.AddInt64(&counter.requests, 1)
+	atomic.AddInt64(&counter.durationNanos, duration)
+	if outcome != pluginCallCacheHit {
+		counter.mu.Lock()
+		counter.latencies[counter.latencyN%pluginLatencyWindow] = duration
+		counter.latencyN++
+		counter.mu.Unlock()
+	}
+	
+	switch outcome {
+	case pluginCallSuccess:
+		atomic.AddInt64(&counter.results, int64(results))
+		atomic.StoreInt64(&counter.lastSuccess, time.Now().Unix())
+		recordBreakerSuccess(name)
+	case pluginCallCacheHit:
+		atomic.AddInt64(&counter.cacheHits, 1)
+		recordBreakerProbeSkipped(name)
+	case pluginCallError:
+		atomic.AddInt64(&counter.errors, 1)
+		reason := "搜索失败"
+		if err != nil {
+			reason = err.Error()
+		}
+		recordBreakerFailure(name, reason)
+	case pluginCallTimeout:
+		atomic.AddInt64(&counter.timeouts, 1)
+		recordBreakerFailure(name, "响应超时")
+	}
+}
+
+// recordPluginBackgroundSuccess 响应超时的插件在后台完成搜索，视为插件可用 (内部使用)
+func recordPluginBackgroundSuccess(name string, results int) {
+	counter := getPluginCounter(name)
+	atomic.AddInt64(&counter.results, int64(results))
+	atomic.StoreInt64(&counter.lastSuccess, time.Now().Unix())
+	recordBreakerSuccess(name)
+}
+
+// p95 最近调用耗时的95分位数
+func (c *pluginCounter) p95() time.Duration {
+	c.mu.Lock()
+	n := c.latencyN
+	if n > pluginLatencyWindow {
+		n = pluginLatencyWindow
+	}
+	latencies := make([]int64, n)
+	copy(latencies, c.latencies[:n])
+	c.mu.Unlock()
+	
+	if n == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return time.Duration(latencies[(n*95+99)/100-1])
+}
+
+// PluginStats 插件调用统计（进程启动以来的累计值）
+type PluginStats struct {
+	Requests        int64   // 调用次数（含缓存命中）
+	CacheHits       int64   // 命中插件缓存的次数
+	Errors          int64   // 返回错误的次数
+	Timeouts        int64   // 超过响应超时、转入后台继续处理的次数
+	Results         int64   // 返回的结果数（不含缓存命中）
+	DurationSeconds float64 // 累计响应耗时
+	P95Seconds      float64 // 最近调用（不含缓存命中）响应耗时的95分位数
+	LastSuccess     int64   // 最近一次成功的时间（Unix秒），0表示启动以来未成功过
+}
+
+// GetPluginStats 获取各插件的调用统计，键为插件名
+func GetPluginStats() map[string]PluginStats {
+	stats := make(map[string]PluginStats)
+	pluginCounters.Range(func(key, value interface{}) bool {
+		counter := value.(*pluginCounter)
+		stats[key.(string)] = PluginStats{
+			Requests:        atomic.LoadInt64(&counter.requests),
+			CacheHits:       atomic.LoadInt64(&counter.cacheHits),
+			Errors:          atomic.LoadInt64(&counter.errors),
+			Timeouts:        atomic.LoadInt64(&counter.timeouts),
+			Results:         atomic.LoadInt64(&counter.results),
+			DurationSeconds: time.Duration(atomic.LoadInt64(&counter.durationNanos)).Seconds(),
+			P95Seconds:      counter.p95().Seconds(),
+			LastSuccess:     atomic.LoadInt64(&counter.lastSuccess),
+		}
+		return true
+	})
+	return stats
+}
+
+// AsyncStats 异步插件系统统计
+type AsyncStats struct {
+	CacheHits          int64 // 插件缓存命中次数
+	CacheMisses        int64 // 插件缓存未命中次数
+	AsyncCompletions   int64 // 响应超时后在后台完成的次数
+	ActiveWorkers      int   // 正在使用的工作槽
+	MaxWorkers         int   // 工作槽总数
+	BackgroundTasks    int   // 正在执行的后台任务数
+	MaxBackgroundTasks int   // 后台任务数上限
+}
+
+// GetAsyncStats 获取异步插件系统统计
+func GetAsyncStats() AsyncStats {
+	maxTasks := defaultMaxBackgroundTasks
+	if config.AppConfig != nil {
+		maxTasks = config.AppConfig.AsyncMaxBackgroundTasks
+	}
+	
+	return AsyncStats{
+		CacheHits:          atomic.LoadInt64(&cacheHits),
+		CacheMisses:        atomic.LoadInt64(&cacheMisses),
+		AsyncCompletions:   atomic.LoadInt64(&asyncCompletions),
+		ActiveWorkers:      len(backgroundWorkerPool),
+		MaxWorkers:         cap(backgroundWorkerPool),
+		BackgroundTasks:    int(atomic.LoadInt32(&backgroundTasksCount)),
+		MaxBackgroundTasks: maxTasks,
+	}
+}
+
 // recordCacheAccess 记录缓存访问次数，用于智能缓存策略（仅内存）
 func recordCacheAccess(key string) {
 	// 更新缓存项的访问时间和计数
@@ -552,6 +725,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		if time.Since(cachedResult.Timestamp) < p.cacheTTL && cachedResult.Complete {
 			recordCacheHit()
 			recordCacheAccess(pluginSpecificCacheKey)
+			recordPluginCall(p.name, now, pluginCallCacheHit, len(cachedResult.Results), nil)
 			
 			// 如果缓存接近过期（已用时间超过TTL的80%），在后台刷新缓存
 			if time.Since(cachedResult.Timestamp) > (p.cacheTTL * 4 / 5) {
@@ -565,6 +739,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		if len(cachedResult.Results) > 0 {
 			recordCacheHit()
 			recordCacheAccess(pluginSpecificCacheKey)
+			recordPluginCall(p.name, now, pluginCallCacheHit, len(cachedResult.Results), nil)
 			
 			// 标记为部分过期
 			if time.Since(cachedResult.Timestamp) >= p.cacheTTL {
@@ -630,6 +805,8 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		case <-doneChan:
 			// 已经响应，只更新缓存
 			if err == nil {
+				recordPluginBackgroundSuccess(p.name, len(results))
+				
 				// 检查是否存在旧缓存
 				var accessCount int = 1
 				var lastAccess time.Time = now
@@ -743,12 +920,15 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	select {
 	case results := <-resultChan:
 		close(doneChan)
+		recordPluginCall(p.name, now, pluginCallSuccess, len(results), nil)
 		return results, nil
 	case err := <-errorChan:
 		close(doneChan)
+		recordPluginCall(p.name, now, pluginCallError, 0, err)
 		return nil, err
 	case <-time.After(responseTimeout):
 		// 插件响应超时，后台继续处理（优化完成，日志简化）
+		recordPluginCall(p.name, now, pluginCallTimeout, 0, nil)
 		
 		// 响应超时，返回空结果，后台继续处理
 		go func() {
@@ -1094,9 +1274,9 @@ func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []mo
 	
 	// 🔥 增强防重复更新机制 - 使用数据哈希确保真正的去重
 	// 生成结果数据的简单哈希标识
-	dataHash := fmt.Sprintf("%d_%d", len(results), results[0].UniqueID)
+	dataHash := fmt.Sprintf("%d_%s", len(results), results[0].UniqueID)
 	if len(results) > 1 {
-		dataHash += fmt.Sprintf("_%d", results[len(results)-1].UniqueID)
+		dataHash += fmt.Sprintf("_%s", results[len(results)-1].UniqueID)
 	}
 	updateKey := fmt.Sprintf("final_%s_%s_%s_%t", p.name, cacheKey, dataHash, isFinal)
 	
@@ -269,21 +269,21 @@ languan8K115"></textarea>
 
             <details>
                 <summary style="cursor: pointer; padding: 10px 0; font-weight: bold;">获取状态</summary>
-                <div class="api-code">curl -X POST https://your-domain.com/qqpd/HASH_PLACEHOLDER \
+                <div class="api-code">curl -X POST https://your-domain.comBASE_PATH_PLACEHOLDER/HASH_PLACEHOLDER \
   -H "Content-Type: application/json" \
   -d '{"action": "get_status"}'</div>
             </details>
 
             <details>
                 <summary style="cursor: pointer; padding: 10px 0; font-weight: bold;">设置频道列表</summary>
-                <div class="api-code">curl -X POST https://your-domain.com/qqpd/HASH_PLACEHOLDER \
+                <div class="api-code">curl -X POST https://your-domain.comBASE_PATH_PLACEHOLDER/HASH_PLACEHOLDER \
   -H "Content-Type: application/json" \
   -d '{"action": "set_channels", "channels": ["pd97631607", "kuake12345"]}'</div>
             </details>
 
             <details>
                 <summary style="cursor: pointer; padding: 10px 0; font-weight: bold;">测试搜索</summary>
-                <div class="api-code">curl -X POST https://your-domain.com/qqpd/HASH_PLACEHOLDER \
+                <div class="api-code">curl -X POST https://your-domain.comBASE_PATH_PLACEHOLDER/HASH_PLACEHOLDER \
   -H "Content-Type: application/json" \
   -d '{"action": "test_search", "keyword": "遮天"}'</div>
             </details>
@@ -292,7 +292,7 @@ languan8K115"></textarea>
 
     <script>
         const HASH = 'HASH_PLACEHOLDER';
-        const API_URL = '/qqpd/' + HASH;
+        const API_URL = 'BASE_PATH_PLACEHOLDER/' + HASH;
         let statusCheckInterval = null;
         let loginCheckInterval = null;
 
@@ -773,16 +773,19 @@ func (p *QQPDPlugin) getActiveUsers() []*User {
 // handleManagePage GET路由处理（合并QQ号转hash和显示页面）
 func (p *QQPDPlugin) handleManagePage(c *gin.Context) {
 	param := c.Param("param")
+	// 路由可能挂载在任意前缀下（如 /admin/plugins/qqpd），页面和跳转都基于实际访问路径
+	basePath := strings.TrimSuffix(c.Request.URL.Path, "/"+param)
 
 	// 判断是QQ号还是hash（hash是64字符的十六进制）
 	if len(param) == 64 && p.isHexString(param) {
 		// 这是hash，直接显示管理页面
 		html := strings.ReplaceAll(HTMLTemplate, "HASH_PLACEHOLDER", param)
+		html = strings.ReplaceAll(html, "BASE_PATH_PLACEHOLDER", basePath)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 	} else {
 		// 这是QQ号，计算hash并重定向
 		hash := p.generateHash(param)
-		c.Redirect(302, "/qqpd/"+hash)
+		c.Redirect(302, basePath+"/"+hash)
 	}
 }
 
@@ -0,0 +1,273 @@
+﻿package plugin
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"huoxing-search/pansou/config"
+)
+
+// ============================================================
+// 插件运行时管理：启用/禁用、优先级覆盖和自动熔断
+// ============================================================
+
+// 熔断状态
+const (
+	BreakerClosed   = "closed"    // 正常调用
+	BreakerOpen     = "open"      // 连续失败已熔断，暂停调用
+	BreakerHalfOpen = "half_open" // 熔断冷却结束，放行一次请求探测是否恢复
+)
+
+// 熔断默认值，未初始化配置时使用
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 5 * time.Minute
+)
+
+// PluginSetting 插件的运行时设置（管理后台修改，不需要重启）
+type PluginSetting struct {
+	Disabled bool // 是否禁用
+	Priority int  // 优先级覆盖（1-4），0表示使用插件自身的优先级
+}
+
+// breaker 单个插件的熔断状态
+type breaker struct {
+	state     string
+	failures  int       // 连续失败次数
+	openedAt  time.Time // 最近一次熔断的时间
+	probeAt   time.Time // 半开状态下探测请求的放行时间，零值表示尚未放行
+	lastError string
+}
+
+var (
+	runtimeMu      sync.RWMutex
+	pluginSettings = make(map[string]PluginSetting)
+	breakers       = make(map[string]*breaker)
+)
+
+// SetPluginSettings 替换全部插件的运行时设置（启动时从数据库加载）
+func SetPluginSettings(settings map[string]PluginSetting) {
+	next := make(map[string]PluginSetting, len(settings))
+	for name, s := range settings {
+		next[name] = s
+	}
+
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	pluginSettings = next
+}
+
+// SetPluginSetting 设置单个插件的运行时设置
+func SetPluginSetting(name string, s PluginSetting) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	pluginSettings[name] = s
+}
+
+// GetPluginSetting 获取单个插件的运行时设置，未设置时返回零值（启用、使用默认优先级）
+func GetPluginSetting(name string) PluginSetting {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return pluginSettings[name]
+}
+
+// SettingsFingerprint 插件运行时设置的摘要，没有任何禁用或优先级覆盖时返回空字符串
+// 搜索结果缓存键包含该摘要，修改插件设置后不再命中旧设置下缓存的结果
+func SettingsFingerprint() string {
+	runtimeMu.RLock()
+	parts := make([]string, 0, len(pluginSettings))
+	for name, s := range pluginSettings {
+		if !s.Disabled && s.Priority == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%t:%d", strings.ToLower(name), s.Disabled, s.Priority))
+	}
+	runtimeMu.RUnlock()
+
+	if len(parts) == 0 {
+		return ""
+	}
+	sort.Strings(parts)
+	hash := md5.Sum([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(hash[:4])
+}
+
+// PluginPriority 插件的生效优先级，优先使用管理后台的覆盖值；插件未注册时返回false
+func PluginPriority(name string) (int, bool) {
+	p, exists := GetPluginByName(name)
+	if !exists {
+		return 0, false
+	}
+	if s := GetPluginSetting(name); s.Priority > 0 {
+		return s.Priority, true
+	}
+	return p.Priority(), true
+}
+
+// breakerSettings 当前的熔断阈值和探测间隔
+func breakerSettings() (int, time.Duration) {
+	if config.AppConfig == nil {
+		return defaultBreakerThreshold, defaultBreakerCooldown
+	}
+	return config.AppConfig.PluginBreakerThreshold, config.AppConfig.PluginBreakerCooldown
+}
+
+// AllowPlugin 本次搜索是否调用该插件：已禁用或熔断中的插件不调用
+// 熔断冷却结束后只放行一次探测请求，探测结果决定恢复还是继续熔断
+func AllowPlugin(name string) bool {
+	_, cooldown := breakerSettings()
+	now := time.Now()
+
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if pluginSettings[name].Disabled {
+		return false
+	}
+
+	b, ok := breakers[name]
+	if !ok {
+		return true
+	}
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probeAt = now
+		return true
+	case BreakerHalfOpen:
+		// 探测请求未返回结果（如被工作池超时丢弃）时，超过冷却时间再放行一次
+		if !b.probeAt.IsZero() && now.Sub(b.probeAt) < cooldown {
+			return false
+		}
+		b.probeAt = now
+		return true
+	}
+	return true
+}
+
+// FilterAvailablePlugins 过滤掉已禁用和熔断中的插件
+func FilterAvailablePlugins(plugins []AsyncSearchPlugin) []AsyncSearchPlugin {
+	available := make([]AsyncSearchPlugin, 0, len(plugins))
+	for _, p := range plugins {
+		if AllowPlugin(p.Name()) {
+			available = append(available, p)
+		}
+	}
+	return available
+}
+
+// recordBreakerSuccess 插件调用成功，关闭熔断并清零连续失败次数 (内部使用)
+func recordBreakerSuccess(name string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if b, ok := breakers[name]; ok {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.probeAt = time.Time{}
+	}
+}
+
+// recordBreakerFailure 插件调用失败，连续失败达到阈值或半开探测失败时熔断 (内部使用)
+func recordBreakerFailure(name string, reason string) {
+	threshold, _ := breakerSettings()
+
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	b, ok := breakers[name]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		breakers[name] = b
+	}
+	b.failures++
+	b.lastError = reason
+	if threshold <= 0 {
+		return
+	}
+	if b.state == BreakerHalfOpen || b.failures >= threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probeAt = time.Time{}
+	}
+}
+
+// recordBreakerProbeSkipped 半开探测命中插件缓存，没有真正访问站点，下次请求重新探测 (内部使用)
+func recordBreakerProbeSkipped(name string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if b, ok := breakers[name]; ok && b.state == BreakerHalfOpen {
+		b.probeAt = time.Time{}
+	}
+}
+
+// ResetBreaker 手动解除插件熔断
+func ResetBreaker(name string) {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	delete(breakers, name)
+}
+
+// PluginStatus 插件的运行状态
+type PluginStatus struct {
+	Name                string
+	DefaultPriority     int    // 插件自身的优先级
+	Priority            int    // 生效的优先级
+	Loaded              bool   // 是否被 ENABLED_PLUGINS 启用并加载到插件管理器
+	Disabled            bool   // 是否在管理后台禁用
+	Breaker             string // 熔断状态
+	ConsecutiveFailures int    // 连续失败次数
+	LastError           string // 最近一次失败原因
+	OpenedAt            int64  // 最近一次熔断的时间（Unix秒），0表示未熔断过
+	Stats               PluginStats
+}
+
+// GetPluginStatuses 获取全部已注册插件的运行状态，按插件名排序
+// loaded为插件管理器中加载的插件
+func GetPluginStatuses(loaded []AsyncSearchPlugin) []PluginStatus {
+	loadedNames := make(map[string]bool, len(loaded))
+	for _, p := range loaded {
+		loadedNames[p.Name()] = true
+	}
+	stats := GetPluginStats()
+
+	plugins := GetRegisteredPlugins()
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name() < plugins[j].Name()
+	})
+
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	statuses := make([]PluginStatus, 0, len(plugins))
+	for _, p := range plugins {
+		name := p.Name()
+		setting := pluginSettings[name]
+		status := PluginStatus{
+			Name:            name,
+			DefaultPriority: p.Priority(),
+			Priority:        p.Priority(),
+			Loaded:          loadedNames[name],
+			Disabled:        setting.Disabled,
+			Breaker:         BreakerClosed,
+			Stats:           stats[name],
+		}
+		if setting.Priority > 0 {
+			status.Priority = setting.Priority
+		}
+		if b, ok := breakers[name]; ok {
+			status.Breaker = b.state
+			status.ConsecutiveFailures = b.failures
+			status.LastError = b.lastError
+			if !b.openedAt.IsZero() {
+				status.OpenedAt = b.openedAt.Unix()
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
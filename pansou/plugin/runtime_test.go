@@ -0,0 +1,127 @@
+﻿package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"huoxing-search/pansou/config"
+	"huoxing-search/pansou/model"
+)
+
+type testPlugin struct {
+	*BaseAsyncPlugin
+}
+
+func (p *testPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	return nil, nil
+}
+
+func TestPluginBreaker(t *testing.T) {
+	prev := config.AppConfig
+	config.AppConfig = &config.Config{
+		AsyncResponseTimeoutDur: time.Second,
+		AsyncCacheTTLHours:      1,
+		PluginBreakerThreshold:  2,
+		PluginBreakerCooldown:   50 * time.Millisecond,
+	}
+	defer func() { config.AppConfig = prev }()
+
+	p := &testPlugin{NewBaseAsyncPlugin("breakertest", 3)}
+	RegisterGlobalPlugin(p)
+	defer ResetBreaker(p.Name())
+
+	fail := func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+		return nil, errors.New("站点无法访问")
+	}
+	succeed := func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error) {
+		return []model.SearchResult{{UniqueID: "breakertest-1"}}, nil
+	}
+	// 关键词每次运行都不同，避免命中上次运行留下的插件缓存
+	run := time.Now().UnixNano()
+	search := func(i int, fn func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error)) {
+		_, _ = p.AsyncSearch(fmt.Sprintf("keyword%d-%d", run, i), fn, "", nil)
+	}
+	before := GetPluginStats()[p.Name()]
+
+	search(1, fail)
+	if !AllowPlugin(p.Name()) {
+		t.Fatal("未达到阈值不应熔断")
+	}
+	search(2, fail)
+	if AllowPlugin(p.Name()) {
+		t.Fatal("连续失败达到阈值后应熔断")
+	}
+
+	// 冷却结束后只放行一次探测，探测失败继续熔断
+	time.Sleep(60 * time.Millisecond)
+	if !AllowPlugin(p.Name()) {
+		t.Fatal("冷却结束后应放行探测请求")
+	}
+	if AllowPlugin(p.Name()) {
+		t.Fatal("探测期间不应放行其他请求")
+	}
+	search(3, fail)
+	if AllowPlugin(p.Name()) {
+		t.Fatal("探测失败后应重新熔断")
+	}
+
+	// 探测成功后恢复
+	time.Sleep(60 * time.Millisecond)
+	if !AllowPlugin(p.Name()) {
+		t.Fatal("冷却结束后应放行探测请求")
+	}
+	search(4, succeed)
+	if !AllowPlugin(p.Name()) || !AllowPlugin(p.Name()) {
+		t.Fatal("探测成功后应恢复调用")
+	}
+
+	stats := GetPluginStats()[p.Name()]
+	if stats.Requests-before.Requests != 4 || stats.Errors-before.Errors != 3 || stats.Results-before.Results != 1 || stats.LastSuccess == 0 || stats.P95Seconds <= 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestPluginSettings(t *testing.T) {
+	p := &testPlugin{NewBaseAsyncPlugin("settingtest", 3)}
+	RegisterGlobalPlugin(p)
+	defer SetPluginSettings(nil)
+
+	if priority, ok := PluginPriority(p.Name()); !ok || priority != 3 {
+		t.Errorf("默认优先级 = %d, %v", priority, ok)
+	}
+	if _, ok := PluginPriority("notexists"); ok {
+		t.Error("未注册的插件不应返回优先级")
+	}
+
+	if fp := SettingsFingerprint(); fp != "" {
+		t.Errorf("未修改设置时摘要 = %q, want 空", fp)
+	}
+
+	SetPluginSettings(map[string]PluginSetting{p.Name(): {Disabled: true, Priority: 1}})
+	disabledFP := SettingsFingerprint()
+	if disabledFP == "" {
+		t.Error("禁用插件后摘要不应为空")
+	}
+	if priority, _ := PluginPriority(p.Name()); priority != 1 {
+		t.Errorf("覆盖后优先级 = %d, want 1", priority)
+	}
+	if got := FilterAvailablePlugins([]AsyncSearchPlugin{p}); len(got) != 0 {
+		t.Error("禁用的插件不应参与搜索")
+	}
+
+	SetPluginSetting(p.Name(), PluginSetting{Priority: 1})
+	if fp := SettingsFingerprint(); fp == "" || fp == disabledFP {
+		t.Errorf("重新启用后摘要 = %q, 应与禁用时(%q)不同", fp, disabledFP)
+	}
+
+	SetPluginSetting(p.Name(), PluginSetting{})
+	if got := FilterAvailablePlugins([]AsyncSearchPlugin{p}); len(got) != 1 {
+		t.Error("重新启用后应参与搜索")
+	}
+	if fp := SettingsFingerprint(); fp != "" {
+		t.Errorf("恢复默认设置后摘要 = %q, want 空", fp)
+	}
+}
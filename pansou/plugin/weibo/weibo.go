@@ -235,7 +235,7 @@ const HTMLTemplate = `<!DOCTYPE html>
 
     <script>
         const HASH = 'HASH_PLACEHOLDER';
-        const API_URL = '/weibo/' + HASH;
+        const API_URL = 'BASE_PATH_PLACEHOLDER/' + HASH;
         let statusCheckInterval = null;
         let loginCheckInterval = null;
 
@@ -610,13 +610,16 @@ func (p *WeiboPlugin) getActiveUsers() []*User {
 
 func (p *WeiboPlugin) handleManagePage(c *gin.Context) {
 	param := c.Param("param")
+	// 路由可能挂载在任意前缀下（如 /admin/plugins/weibo），页面和跳转都基于实际访问路径
+	basePath := strings.TrimSuffix(c.Request.URL.Path, "/"+param)
 
 	if len(param) == 64 && p.isHexString(param) {
 		html := strings.ReplaceAll(HTMLTemplate, "HASH_PLACEHOLDER", param)
+		html = strings.ReplaceAll(html, "BASE_PATH_PLACEHOLDER", basePath)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 	} else {
 		hash := p.generateHash(param)
-		c.Redirect(302, "/weibo/"+hash)
+		c.Redirect(302, basePath+"/"+hash)
 	}
 }
 
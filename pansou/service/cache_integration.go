@@ -83,9 +83,9 @@ func (c *CacheWriteIntegration) HandleCacheWrite(key string, results []model.Sea
 
 // getPluginPriority 获取插件优先级
 func (c *CacheWriteIntegration) getPluginPriority(pluginName string) int {
-	// 从插件管理器动态获取真实的优先级
-	if pluginInstance, exists := plugin.GetPluginByName(pluginName); exists {
-		return pluginInstance.Priority()
+	// 从插件管理器动态获取真实的优先级，管理后台设置的优先级优先
+	if priority, exists := plugin.PluginPriority(pluginName); exists {
+		return priority
 	}
 	
 	// 如果插件不存在，返回默认等级4（最低优先级）
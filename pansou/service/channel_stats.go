@@ -0,0 +1,77 @@
+﻿package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// channelCounter 单个TG频道的搜索计数
+type channelCounter struct {
+	requests      int64
+	hits          int64 // 返回了结果的次数
+	errors        int64
+	results       int64
+	durationNanos int64
+	lastSuccess   int64 // 最近一次成功的时间（Unix秒）
+
+	mu        sync.Mutex
+	lastError string
+}
+
+// 各频道搜索计数，键为频道名
+var channelCounters sync.Map
+
+// recordChannelSearch 记录一次频道搜索的结果和耗时
+func recordChannelSearch(channel string, start time.Time, results int, err error) {
+	value, _ := channelCounters.LoadOrStore(channel, &channelCounter{})
+	counter := value.(*channelCounter)
+
+	atomic.AddInt64(&counter.requests, 1)
+	atomic.AddInt64(&counter.durationNanos, int64(time.Since(start)))
+	if err != nil {
+		atomic.AddInt64(&counter.errors, 1)
+		counter.mu.Lock()
+		counter.lastError = err.Error()
+		counter.mu.Unlock()
+		return
+	}
+	atomic.StoreInt64(&counter.lastSuccess, time.Now().Unix())
+	if results > 0 {
+		atomic.AddInt64(&counter.hits, 1)
+		atomic.AddInt64(&counter.results, int64(results))
+	}
+}
+
+// ChannelStats TG频道搜索统计（进程启动以来的累计值）
+type ChannelStats struct {
+	Requests        int64   // 搜索次数（不含命中TG缓存）
+	Hits            int64   // 返回了结果的次数
+	Errors          int64   // 请求或解析失败的次数
+	Results         int64   // 返回的结果数
+	DurationSeconds float64 // 累计耗时
+	LastSuccess     int64   // 最近一次成功的时间（Unix秒），0表示启动以来未成功过
+	LastError       string  // 最近一次失败原因
+}
+
+// GetChannelStats 获取各频道的搜索统计，键为频道名
+func GetChannelStats() map[string]ChannelStats {
+	stats := make(map[string]ChannelStats)
+	channelCounters.Range(func(key, value interface{}) bool {
+		counter := value.(*channelCounter)
+		counter.mu.Lock()
+		lastError := counter.lastError
+		counter.mu.Unlock()
+		stats[key.(string)] = ChannelStats{
+			Requests:        atomic.LoadInt64(&counter.requests),
+			Hits:            atomic.LoadInt64(&counter.hits),
+			Errors:          atomic.LoadInt64(&counter.errors),
+			Results:         atomic.LoadInt64(&counter.results),
+			DurationSeconds: time.Duration(atomic.LoadInt64(&counter.durationNanos)).Seconds(),
+			LastSuccess:     atomic.LoadInt64(&counter.lastSuccess),
+			LastError:       lastError,
+		}
+		return true
+	})
+	return stats
+}
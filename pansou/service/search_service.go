@@ -347,6 +347,11 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 	}
 }
 
+// RefreshPluginCache 插件列表变化后重新向插件注入主缓存（配置热更新后新启用的插件需要）
+func RefreshPluginCache(pluginManager *plugin.PluginManager) {
+	injectMainCacheToAsyncPlugins(pluginManager, enhancedTwoLevelCache)
+}
+
 // Search 执行搜索
 func (s *SearchService) Search(keyword string, channels []string, concurrency int, forceRefresh bool, resultType string, sourceType string, plugins []string, cloudTypes []string, ext map[string]interface{}) (model.SearchResponse, error) {
 	// 确保ext不为nil
@@ -1241,7 +1246,9 @@ func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh
 	for _, channel := range channels {
 		ch := channel // 创建副本，避免闭包问题
 		tasks = append(tasks, func() interface{} {
+			start := time.Now()
 			results, err := s.searchChannel(keyword, ch)
+			recordChannelSearch(ch, start, len(results), err)
 			if err != nil {
 				return nil
 			}
@@ -1292,7 +1299,8 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
     }
 	
 	// 生成缓存键
-	cacheKey := cache.GeneratePluginCacheKey(keyword, plugins)
+	// 缓存键包含插件设置摘要，禁用插件或调整优先级后不再返回旧设置下的缓存结果
+	cacheKey := cache.GeneratePluginCacheKeyWithSettings(keyword, plugins, plugin.SettingsFingerprint())
 	
 	
 	// 如果未启用强制刷新，尝试从缓存获取结果
@@ -1360,6 +1368,9 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 			// 如果plugins为nil、空数组或只包含空字符串，视为未指定，使用所有插件
 			availablePlugins = allPlugins
 		}
+		
+		// 跳过管理后台禁用和连续失败熔断中的插件
+		availablePlugins = plugin.FilterAvailablePlugins(availablePlugins)
 	}
 	
 	// 控制并发数
@@ -1474,6 +1485,14 @@ func getResultSource(result model.SearchResult) string {
 	return "unknown"
 }
 
+// ResetPluginLevelCache 清空插件等级缓存（管理后台修改插件优先级后调用）
+func ResetPluginLevelCache() {
+	pluginLevelCache.Range(func(key, _ interface{}) bool {
+		pluginLevelCache.Delete(key)
+		return true
+	})
+}
+
 // getPluginLevelBySource 根据来源获取插件等级
 func getPluginLevelBySource(source string) int {
 	// 尝试从缓存获取
@@ -1504,9 +1523,9 @@ func getPluginLevelBySource(source string) int {
 
 // getPluginPriorityByName 根据插件名获取优先级
 func getPluginPriorityByName(pluginName string) int {
-	// 从插件管理器动态获取真实的优先级 (O(1)哈希查找)
-	if pluginInstance, exists := plugin.GetPluginByName(pluginName); exists {
-		return pluginInstance.Priority()
+	// 从插件管理器动态获取真实的优先级 (O(1)哈希查找)，管理后台设置的优先级优先
+	if priority, exists := plugin.PluginPriority(pluginName); exists {
+		return priority
 	}
 	return 3 // 默认等级
 }
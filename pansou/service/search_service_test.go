@@ -0,0 +1,82 @@
+﻿package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractLinkTitlePairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name:    "标题行后跟链接行",
+			content: "名称：流浪地球2 4K\n链接：https://pan.quark.cn/s/abc123",
+			want:    map[string]string{"https://pan.quark.cn/s/abc123": "流浪地球2 4K"},
+		},
+		{
+			name:    "标题与链接同行",
+			content: "🎬 三体 全30集：https://pan.baidu.com/s/1AbCdEf\n🎬 繁花：https://pan.quark.cn/s/def456",
+			want: map[string]string{
+				"https://pan.baidu.com/s/1AbCdEf": "三体 全30集",
+				"https://pan.quark.cn/s/def456":   "繁花",
+			},
+		},
+		{
+			name:    "多组标题链接",
+			content: "狂飙\n链接：https://pan.quark.cn/s/aaa111\n\n漫长的季节\n链接：https://pan.quark.cn/s/bbb222",
+			want: map[string]string{
+				"https://pan.quark.cn/s/aaa111": "狂飙",
+				"https://pan.quark.cn/s/bbb222": "漫长的季节",
+			},
+		},
+		{
+			name:    "网盘名称不作为标题",
+			content: "奥本海默\n夸克：https://pan.quark.cn/s/ccc333",
+			want:    map[string]string{"https://pan.quark.cn/s/ccc333": "奥本海默"},
+		},
+		{
+			name:    "单行多个链接",
+			content: "流浪地球2链接：https://pan.quark.cn/s/abc123 满江红链接：https://pan.quark.cn/s/def456",
+			want: map[string]string{
+				"https://pan.quark.cn/s/abc123": "流浪地球2",
+				"https://pan.quark.cn/s/def456": "满江红",
+			},
+		},
+		{
+			name:    "无链接",
+			content: "这是一段没有链接的文本",
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractLinkTitlePairs(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractLinkTitlePairs(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"  名称：流浪地球2  ", "流浪地球2"},
+		{"片名:三体", "三体"},
+		{"🎬 繁花 📺", "繁花"},
+		{"标题：", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := cleanTitle(tt.title); got != tt.want {
+				t.Errorf("cleanTitle(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+﻿package cache
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// GenerateTGCacheKey 生成TG搜索的缓存键
+func GenerateTGCacheKey(keyword string, channels []string) string {
+	return generateCacheKey("tg", keyword, channels)
+}
+
+// GeneratePluginCacheKey 生成插件搜索的缓存键
+func GeneratePluginCacheKey(keyword string, plugins []string) string {
+	return generateCacheKey("plugin", keyword, plugins)
+}
+
+// GeneratePluginCacheKeyWithSettings 生成插件搜索的缓存键，settings为插件运行时设置的摘要
+// 禁用插件或调整优先级后使用新的缓存键；settings为空时与 GeneratePluginCacheKey 相同
+func GeneratePluginCacheKeyWithSettings(keyword string, plugins []string, settings string) string {
+	if settings == "" {
+		return GeneratePluginCacheKey(keyword, plugins)
+	}
+	return generateCacheKey("plugin@"+settings, keyword, plugins)
+}
+
+// generateCacheKey 根据来源类型、关键词和来源列表生成稳定的缓存键
+// 来源列表会去重、转小写并排序，保证顺序不同的同一组来源命中同一个键
+func generateCacheKey(sourceType string, keyword string, sources []string) string {
+	normalizedKeyword := strings.ToLower(strings.TrimSpace(keyword))
+
+	seen := make(map[string]bool, len(sources))
+	normalized := make([]string, 0, len(sources))
+	for _, s := range sources {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		normalized = append(normalized, s)
+	}
+	sort.Strings(normalized)
+
+	sourcesPart := "all"
+	if len(normalized) > 0 {
+		sourcesPart = strings.Join(normalized, ",")
+	}
+
+	hash := md5.Sum([]byte(sourceType + ":" + normalizedKeyword + ":" + sourcesPart))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,235 @@
+﻿package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"huoxing-search/pansou/model"
+)
+
+func TestShardedMemoryCacheLRU(t *testing.T) {
+	// 单分片、容量10字节，便于观察淘汰顺序
+	c := NewShardedMemoryCache(1, 10)
+	c.Set("a", []byte("1234"), time.Minute)
+	c.Set("b", []byte("1234"), time.Minute)
+	c.Get("a") // a变为最近使用
+	c.Set("c", []byte("1234"), time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("最久未使用的b未被淘汰")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%s 不应被淘汰", key)
+		}
+	}
+
+	c.Set("big", make([]byte, 11), time.Minute)
+	if _, ok := c.Get("big"); ok {
+		t.Error("超过分片容量的数据不应进入内存层")
+	}
+
+	c.Set("expired", []byte("x"), -time.Second)
+	if _, ok := c.Get("expired"); ok {
+		t.Error("过期数据仍可读取")
+	}
+}
+
+func TestShardedDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewShardedDiskCache(dir, 4, 0, true, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("pansou"), 100)
+	if err := c.Set("key", data, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := c.Get("key"); err != nil || !ok || !bytes.Equal(got, data) {
+		t.Fatalf("Get() = %d字节, %v, %v", len(got), ok, err)
+	}
+	if size := c.Stats()["size_bytes"].(int64); size >= int64(len(data)) {
+		t.Errorf("开启压缩后文件大小 = %d，未压缩", size)
+	}
+
+	// 重启后从文件重建索引
+	reopened, err := NewShardedDiskCache(dir, 4, 0, true, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, _ := reopened.Get("key"); !ok || !bytes.Equal(got, data) {
+		t.Error("重启后未能读取已有缓存")
+	}
+
+	reopened.Delete("key")
+	if _, ok, _ := reopened.Get("key"); ok {
+		t.Error("删除后仍可读取")
+	}
+}
+
+func TestShardedDiskCacheEviction(t *testing.T) {
+	c, err := NewShardedDiskCache(t.TempDir(), 4, 1000, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := c.SetWithTimestamp(key, make([]byte, 300), time.Minute, base.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if size := c.Stats()["size_bytes"].(int64); size > 1000 {
+		t.Errorf("总大小 %d 超过上限", size)
+	}
+	if _, ok, _ := c.Get("key0"); ok {
+		t.Error("最旧的缓存未被淘汰")
+	}
+	if _, ok, _ := c.Get("key4"); !ok {
+		t.Error("最新的缓存被淘汰")
+	}
+}
+
+func TestDelayedBatchWriteManager(t *testing.T) {
+	m, _ := NewDelayedBatchWriteManager()
+	m.SetStrategy(CacheStrategyHybrid)
+	m.SetSerializer(NewSonicSerializer())
+
+	var mu sync.Mutex
+	writes := make(map[string]int)
+	var lastData []byte
+	m.SetMainCacheUpdater(func(key string, data []byte, ttl time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		writes[key]++
+		lastData = data
+		return nil
+	})
+
+	// 同一个键的中间结果合并为一次写入，数据取最新一次
+	for i := 1; i <= 3; i++ {
+		results := make([]model.SearchResult, i)
+		_ = m.HandleCacheOperation(&CacheOperation{Key: "k1", Data: results, TTL: time.Minute, Priority: 3})
+	}
+	if n := m.flush(true); n != 1 {
+		t.Fatalf("flush() 写入 %d 次, want 1", n)
+	}
+	var got []model.SearchResult
+	if err := NewSonicSerializer().Deserialize(lastData, &got); err != nil || len(got) != 3 {
+		t.Errorf("写入的数据 = %d 条, %v, want 最新一次的3条", len(got), err)
+	}
+
+	// 最终结果立即写入，并丢弃同键待写入的中间结果
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "k2", TTL: time.Minute, Priority: 2})
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "k2", TTL: time.Minute, IsFinal: true})
+	if n := m.flush(true); n != 0 {
+		t.Errorf("最终结果写入后仍有 %d 个待写入项", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if writes["k1"] != 1 || writes["k2"] != 1 {
+		t.Errorf("writes = %v", writes)
+	}
+	stats := m.GetStats().(BatchWriteStats)
+	if stats.MergedOperations != 2 || stats.ImmediateWrites != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestDelayedBatchWriteManagerSkipsStaleWrites(t *testing.T) {
+	m, _ := NewDelayedBatchWriteManager()
+	m.SetSerializer(NewSonicSerializer())
+
+	var mu sync.Mutex
+	var written [][]byte
+	m.SetMainCacheUpdater(func(key string, data []byte, ttl time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		written = append(written, data)
+		return nil
+	})
+
+	older := time.Now()
+	newer := older.Add(time.Second)
+
+	// 较新的最终结果先写入，随后到达的旧中间结果不应覆盖
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "k", Data: make([]model.SearchResult, 2), TTL: time.Minute, Timestamp: newer, IsFinal: true})
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "k", Data: make([]model.SearchResult, 1), TTL: time.Minute, Timestamp: older, Priority: 1})
+	m.flush(true)
+
+	// 队列中合并时也保留较新的数据
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "q", Data: make([]model.SearchResult, 3), TTL: time.Minute, Timestamp: newer, Priority: 2})
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "q", Data: make([]model.SearchResult, 1), TTL: time.Minute, Timestamp: older, Priority: 2})
+	m.flush(true)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(written) != 2 {
+		t.Fatalf("写入 %d 次, want 2", len(written))
+	}
+	for i, want := range []int{2, 3} {
+		var got []model.SearchResult
+		if err := NewSonicSerializer().Deserialize(written[i], &got); err != nil || len(got) != want {
+			t.Errorf("第%d次写入 %d 条, %v, want %d", i+1, len(got), err, want)
+		}
+	}
+	if stats := m.GetStats().(BatchWriteStats); stats.StaleWrites != 1 {
+		t.Errorf("StaleWrites = %d, want 1", stats.StaleWrites)
+	}
+}
+
+func TestDelayedBatchWriteManagerRetriesFailedWrites(t *testing.T) {
+	m, _ := NewDelayedBatchWriteManager()
+	m.SetSerializer(NewSonicSerializer())
+
+	var mu sync.Mutex
+	failures := map[string]int{"flaky": 1, "broken": batchMaxWriteAttempts + 1}
+	writes := make(map[string]int)
+	m.SetMainCacheUpdater(func(key string, data []byte, ttl time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures[key] > 0 {
+			failures[key]--
+			return errors.New("磁盘已满")
+		}
+		writes[key]++
+		return nil
+	})
+
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "flaky", TTL: time.Minute, Priority: 2})
+	_ = m.HandleCacheOperation(&CacheOperation{Key: "broken", TTL: time.Minute, Priority: 2})
+	if err := m.HandleCacheOperation(&CacheOperation{Key: "final", TTL: time.Minute, IsFinal: true}); err != nil {
+		t.Fatalf("final write error = %v", err)
+	}
+
+	if n := m.flush(true); n != 0 {
+		t.Errorf("first flush wrote %d, want 0", n)
+	}
+	if n := m.flush(true); n != 1 {
+		t.Errorf("second flush wrote %d, want 1 (flaky)", n)
+	}
+	m.flush(true)
+
+	stats := m.GetStats().(BatchWriteStats)
+	if stats.PendingKeys != 0 {
+		t.Errorf("PendingKeys = %d, want 0 after giving up", stats.PendingKeys)
+	}
+	// flaky失败1次，broken每次都失败直到达到最大写入次数
+	if want := int64(1 + batchMaxWriteAttempts); stats.FailedWrites != want {
+		t.Errorf("FailedWrites = %d, want %d", stats.FailedWrites, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if writes["flaky"] != 1 || writes["final"] != 1 || writes["broken"] != 0 {
+		t.Errorf("writes = %v", writes)
+	}
+}
@@ -0,0 +1,446 @@
+﻿package cache
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"huoxing-search/pansou/model"
+)
+
+// CacheWriteStrategy 缓存写入策略
+type CacheWriteStrategy string
+
+const (
+	// CacheStrategyImmediate 每次写入立即落盘
+	CacheStrategyImmediate CacheWriteStrategy = "immediate"
+	// CacheStrategyHybrid 最终结果立即落盘，中间结果按优先级延迟合并写入
+	CacheStrategyHybrid CacheWriteStrategy = "hybrid"
+)
+
+const (
+	// 后台检查待写入队列的周期
+	batchCheckInterval = time.Second
+	// 待写入数据总量超过该值时立即全部刷新
+	batchMaxPendingBytes = 16 * 1024 * 1024
+	// 待写入键数量超过该值时立即全部刷新
+	batchMaxPendingKeys = 500
+	// 单个操作最多写入次数，写入失败时放回队列重试
+	batchMaxWriteAttempts = 3
+	// 已写入版本的保留时长，只需覆盖写入可能乱序的时间窗口
+	writtenVersionRetention = 10 * time.Minute
+	// 按键串行化写入的分段锁数量
+	writeLockShards = 64
+)
+
+// CacheOperation 缓存写入操作
+type CacheOperation struct {
+	Key        string
+	Data       []model.SearchResult
+	TTL        time.Duration
+	PluginName string
+	Keyword    string
+	Timestamp  time.Time // 数据版本，同键只写入不早于已写入版本的数据
+	Priority   int  // 1最高，数字越大优先级越低
+	DataSize   int  // 序列化后的数据大小（估算）
+	IsFinal    bool // 是否为插件的最终结果
+}
+
+// pendingOperation 合并后的待写入操作
+type pendingOperation struct {
+	op          *CacheOperation
+	firstQueued time.Time
+	merged      int
+	attempts    int // 已失败的写入次数
+}
+
+// BatchWriteStats 批量写入统计
+type BatchWriteStats struct {
+	Strategy         CacheWriteStrategy `json:"strategy"`
+	TotalOperations  int64              `json:"total_operations"`
+	ImmediateWrites  int64              `json:"immediate_writes"`
+	BatchedWrites    int64              `json:"batched_writes"`
+	MergedOperations int64              `json:"merged_operations"`
+	FailedWrites     int64              `json:"failed_writes"`
+	RetriedWrites    int64              `json:"retried_writes"`
+	StaleWrites      int64              `json:"stale_writes"`
+	FlushCount       int64              `json:"flush_count"`
+	PendingKeys      int                `json:"pending_keys"`
+	PendingBytes     int64              `json:"pending_bytes"`
+	LastFlushTime    time.Time          `json:"last_flush_time"`
+}
+
+// DelayedBatchWriteManager 延迟批量写入管理器
+// 异步插件会频繁产生同一个键的中间结果，这里按键合并后再按优先级延迟写盘，减少磁盘IO
+type DelayedBatchWriteManager struct {
+	strategy   CacheWriteStrategy
+	serializer Serializer
+	updater    func(string, []byte, time.Duration) error
+
+	mu           sync.Mutex
+	pending      map[string]*pendingOperation
+	pendingBytes int64
+	written      map[string]time.Time // 各键已写入的最新版本
+	writeLocks   [writeLockShards]sync.Mutex
+
+	totalOperations  int64
+	immediateWrites  int64
+	batchedWrites    int64
+	mergedOperations int64
+	failedWrites     int64
+	retriedWrites    int64
+	staleWrites      int64
+	flushCount       int64
+	lastFlushTime    atomic.Value
+
+	initialized bool
+	stopCh      chan struct{}
+	doneCh      chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewDelayedBatchWriteManager 创建延迟批量写入管理器
+func NewDelayedBatchWriteManager() (*DelayedBatchWriteManager, error) {
+	m := &DelayedBatchWriteManager{
+		strategy:   getCacheWriteStrategy(),
+		serializer: newDefaultSerializer(),
+		pending:    make(map[string]*pendingOperation),
+		written:    make(map[string]time.Time),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+	m.lastFlushTime.Store(time.Time{})
+	return m, nil
+}
+
+// getCacheWriteStrategy 从环境变量读取写入策略，默认hybrid
+func getCacheWriteStrategy() CacheWriteStrategy {
+	switch CacheWriteStrategy(strings.ToLower(os.Getenv("CACHE_WRITE_STRATEGY"))) {
+	case CacheStrategyImmediate:
+		return CacheStrategyImmediate
+	default:
+		return CacheStrategyHybrid
+	}
+}
+
+// Initialize 启动后台刷新协程
+func (m *DelayedBatchWriteManager) Initialize() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.initialized {
+		return nil
+	}
+	m.initialized = true
+	go m.flushLoop()
+	return nil
+}
+
+// SetMainCacheUpdater 设置实际写入主缓存的函数
+func (m *DelayedBatchWriteManager) SetMainCacheUpdater(updater func(string, []byte, time.Duration) error) {
+	m.mu.Lock()
+	m.updater = updater
+	m.mu.Unlock()
+}
+
+// SetSerializer 设置序列化器，需与主缓存保持一致
+func (m *DelayedBatchWriteManager) SetSerializer(serializer Serializer) {
+	if serializer == nil {
+		return
+	}
+	m.mu.Lock()
+	m.serializer = serializer
+	m.mu.Unlock()
+}
+
+// SetStrategy 设置写入策略
+func (m *DelayedBatchWriteManager) SetStrategy(strategy CacheWriteStrategy) {
+	m.mu.Lock()
+	m.strategy = strategy
+	m.mu.Unlock()
+}
+
+// HandleCacheOperation 处理一次缓存写入
+func (m *DelayedBatchWriteManager) HandleCacheOperation(op *CacheOperation) error {
+	if op == nil || op.Key == "" {
+		return errors.New("无效的缓存操作")
+	}
+	if op.Timestamp.IsZero() {
+		op.Timestamp = time.Now()
+	}
+	atomic.AddInt64(&m.totalOperations, 1)
+
+	m.mu.Lock()
+	strategy := m.strategy
+	m.mu.Unlock()
+
+	// 立即写入策略或最终结果：直接落盘，并丢弃同键较旧的待写入中间结果
+	if strategy == CacheStrategyImmediate || op.IsFinal {
+		m.mu.Lock()
+		if existing, ok := m.pending[op.Key]; ok && !existing.op.Timestamp.After(op.Timestamp) {
+			m.pendingBytes -= int64(existing.op.DataSize)
+			delete(m.pending, op.Key)
+		}
+		m.mu.Unlock()
+
+		atomic.AddInt64(&m.immediateWrites, 1)
+		err := m.write(op)
+		if err != nil {
+			m.requeue(op, 1)
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.pending[op.Key]; ok {
+		// 同键合并：数据取版本较新的一次（调用方已与已有缓存合并），优先级取较高者
+		if existing.op.Priority < op.Priority {
+			op.Priority = existing.op.Priority
+		}
+		if op.Timestamp.Before(existing.op.Timestamp) {
+			existing.op.Priority = op.Priority
+		} else {
+			m.pendingBytes += int64(op.DataSize - existing.op.DataSize)
+			existing.op = op
+			existing.attempts = 0
+		}
+		existing.merged++
+		atomic.AddInt64(&m.mergedOperations, 1)
+	} else {
+		m.pending[op.Key] = &pendingOperation{op: op, firstQueued: time.Now()}
+		m.pendingBytes += int64(op.DataSize)
+	}
+	overflow := m.pendingBytes > batchMaxPendingBytes || len(m.pending) > batchMaxPendingKeys
+	m.mu.Unlock()
+
+	if overflow {
+		m.flush(true)
+	}
+	return nil
+}
+
+// delayForPriority 不同优先级的最大延迟
+func delayForPriority(priority int) time.Duration {
+	switch {
+	case priority <= 1:
+		return time.Second
+	case priority == 2:
+		return 3 * time.Second
+	case priority == 3:
+		return 5 * time.Second
+	default:
+		return 10 * time.Second
+	}
+}
+
+// flushLoop 后台定期刷新到期的待写入操作
+func (m *DelayedBatchWriteManager) flushLoop() {
+	defer close(m.doneCh)
+	ticker := time.NewTicker(batchCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.flush(false)
+			m.pruneWritten()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// flush 刷新待写入操作；all为true时忽略延迟全部写入
+// 主缓存更新函数尚未设置时保留队列，等待下次刷新
+func (m *DelayedBatchWriteManager) flush(all bool) int {
+	now := time.Now()
+
+	m.mu.Lock()
+	if m.updater == nil || len(m.pending) == 0 {
+		m.mu.Unlock()
+		return 0
+	}
+	due := make([]*pendingOperation, 0, len(m.pending))
+	for key, p := range m.pending {
+		if all || now.Sub(p.firstQueued) >= delayForPriority(p.op.Priority) {
+			due = append(due, p)
+			m.pendingBytes -= int64(p.op.DataSize)
+			delete(m.pending, key)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(due) == 0 {
+		return 0
+	}
+
+	// 高优先级先写
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].op.Priority != due[j].op.Priority {
+			return due[i].op.Priority < due[j].op.Priority
+		}
+		return due[i].op.Timestamp.Before(due[j].op.Timestamp)
+	})
+
+	written := 0
+	for _, p := range due {
+		op := p.op
+		if err := m.write(op); err != nil {
+			if m.requeue(op, p.attempts+1) {
+				fmt.Printf("[批量写入] 写入失败，稍后重试: %s | 插件: %s | 错误: %v\n", op.Key, op.PluginName, err)
+			} else {
+				fmt.Printf("[批量写入] 写入失败，已放弃: %s | 插件: %s | 错误: %v\n", op.Key, op.PluginName, err)
+			}
+			continue
+		}
+		written++
+	}
+	atomic.AddInt64(&m.batchedWrites, int64(written))
+	atomic.AddInt64(&m.flushCount, 1)
+	m.lastFlushTime.Store(now)
+	return written
+}
+
+// write 序列化并写入主缓存
+// 同键写入按键串行执行，版本早于已写入版本的数据直接跳过，避免较慢的旧写入覆盖新数据
+func (m *DelayedBatchWriteManager) write(op *CacheOperation) error {
+	m.mu.Lock()
+	updater := m.updater
+	serializer := m.serializer
+	m.mu.Unlock()
+
+	if updater == nil {
+		// 主缓存尚未就绪，放回队列等待
+		m.requeue(op, 0)
+		return nil
+	}
+
+	lock := m.writeLock(op.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.Lock()
+	last, ok := m.written[op.Key]
+	m.mu.Unlock()
+	if ok && op.Timestamp.Before(last) {
+		atomic.AddInt64(&m.staleWrites, 1)
+		return nil
+	}
+
+	data, err := serializer.Serialize(op.Data)
+	if err != nil {
+		atomic.AddInt64(&m.failedWrites, 1)
+		return fmt.Errorf("序列化缓存数据失败: %w", err)
+	}
+	if err := updater(op.Key, data, op.TTL); err != nil {
+		atomic.AddInt64(&m.failedWrites, 1)
+		return err
+	}
+
+	m.mu.Lock()
+	m.written[op.Key] = op.Timestamp
+	m.mu.Unlock()
+	return nil
+}
+
+// writeLock 键对应的写入锁
+func (m *DelayedBatchWriteManager) writeLock(key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.writeLocks[h.Sum32()%writeLockShards]
+}
+
+// requeue 把写入失败的操作放回队列，超过最大重试次数或同键已有更新的待写入操作时不再放回
+func (m *DelayedBatchWriteManager) requeue(op *CacheOperation, attempts int) bool {
+	if attempts >= batchMaxWriteAttempts {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.pending[op.Key]; ok {
+		if !existing.op.Timestamp.Before(op.Timestamp) {
+			return true
+		}
+		m.pendingBytes += int64(op.DataSize - existing.op.DataSize)
+		existing.op = op
+		existing.attempts = attempts
+	} else {
+		m.pending[op.Key] = &pendingOperation{op: op, firstQueued: time.Now(), attempts: attempts}
+		m.pendingBytes += int64(op.DataSize)
+	}
+	if attempts > 0 {
+		atomic.AddInt64(&m.retriedWrites, 1)
+	}
+	return true
+}
+
+// pruneWritten 清理超过保留时长的已写入版本记录
+func (m *DelayedBatchWriteManager) pruneWritten() {
+	cutoff := time.Now().Add(-writtenVersionRetention)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, ts := range m.written {
+		if ts.Before(cutoff) {
+			delete(m.written, key)
+		}
+	}
+}
+
+// Shutdown 停止后台协程并在超时时间内写入所有待写入数据
+func (m *DelayedBatchWriteManager) Shutdown(timeout time.Duration) error {
+	m.mu.Lock()
+	initialized := m.initialized
+	m.mu.Unlock()
+
+	m.stopOnce.Do(func() { close(m.stopCh) })
+	if initialized {
+		<-m.doneCh
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.flush(true)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		m.mu.Lock()
+		remaining := len(m.pending)
+		m.mu.Unlock()
+		return fmt.Errorf("批量写入关闭超时，剩余 %d 个待写入项", remaining)
+	}
+}
+
+// GetStats 获取统计信息
+func (m *DelayedBatchWriteManager) GetStats() interface{} {
+	m.mu.Lock()
+	pendingKeys := len(m.pending)
+	pendingBytes := m.pendingBytes
+	strategy := m.strategy
+	m.mu.Unlock()
+
+	return BatchWriteStats{
+		Strategy:         strategy,
+		TotalOperations:  atomic.LoadInt64(&m.totalOperations),
+		ImmediateWrites:  atomic.LoadInt64(&m.immediateWrites),
+		BatchedWrites:    atomic.LoadInt64(&m.batchedWrites),
+		MergedOperations: atomic.LoadInt64(&m.mergedOperations),
+		FailedWrites:     atomic.LoadInt64(&m.failedWrites),
+		RetriedWrites:    atomic.LoadInt64(&m.retriedWrites),
+		StaleWrites:      atomic.LoadInt64(&m.staleWrites),
+		FlushCount:       atomic.LoadInt64(&m.flushCount),
+		PendingKeys:      pendingKeys,
+		PendingBytes:     pendingBytes,
+		LastFlushTime:    m.lastFlushTime.Load().(time.Time),
+	}
+}
@@ -0,0 +1,183 @@
+﻿package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"huoxing-search/pansou/config"
+)
+
+const (
+	defaultShardCount = 16
+	// 内存层只占缓存总容量的一部分，其余数据依赖磁盘层
+	memoryCacheRatio = 4
+	// 内存层最小容量
+	minMemoryCacheBytes = 8 * 1024 * 1024
+	// 过期数据清理周期
+	cleanupInterval = 10 * time.Minute
+)
+
+// EnhancedTwoLevelCache 增强版两级缓存：分片内存LRU + 分片磁盘缓存
+type EnhancedTwoLevelCache struct {
+	memory     *ShardedMemoryCache
+	disk       *ShardedDiskCache
+	serializer Serializer
+	mu         sync.RWMutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewEnhancedTwoLevelCache 根据全局配置创建两级缓存
+func NewEnhancedTwoLevelCache() (*EnhancedTwoLevelCache, error) {
+	if config.AppConfig == nil {
+		return nil, fmt.Errorf("pansou配置未初始化")
+	}
+
+	maxSize := int64(config.AppConfig.CacheMaxSizeMB) * 1024 * 1024
+	memorySize := maxSize / memoryCacheRatio
+	if memorySize < minMemoryCacheBytes {
+		memorySize = minMemoryCacheBytes
+	}
+
+	disk, err := NewShardedDiskCache(
+		config.AppConfig.CachePath,
+		defaultShardCount,
+		maxSize,
+		config.AppConfig.EnableCompression,
+		config.AppConfig.MinSizeToCompress,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &EnhancedTwoLevelCache{
+		memory:     NewShardedMemoryCache(defaultShardCount, memorySize),
+		disk:       disk,
+		serializer: newDefaultSerializer(),
+		stopCh:     make(chan struct{}),
+	}
+	go c.cleanupLoop()
+	return c, nil
+}
+
+// newDefaultSerializer 按配置创建序列化器，配置无效时使用gob
+func newDefaultSerializer() Serializer {
+	if config.AppConfig == nil {
+		return NewGobSerializer()
+	}
+	serializer, err := NewSerializer(config.AppConfig.CacheSerializer)
+	if err != nil {
+		fmt.Printf("[缓存] %v，改用gob\n", err)
+		return NewGobSerializer()
+	}
+	return serializer
+}
+
+// cleanupLoop 定期清理两级缓存中的过期数据
+func (c *EnhancedTwoLevelCache) cleanupLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.memory.CleanExpired()
+			c.disk.CleanExpired()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// Get 获取缓存：优先读内存，若磁盘中有更新的版本（如批量写入落盘的最终结果）则以磁盘为准并回填内存
+func (c *EnhancedTwoLevelCache) Get(key string) ([]byte, bool, error) {
+	data, memModified, hit := c.memory.GetWithTimestamp(key)
+	if hit {
+		diskModified, ok := c.disk.LastModified(key)
+		if !ok || !diskModified.After(memModified) {
+			return data, true, nil
+		}
+	}
+
+	diskData, diskModified, diskHit, err := c.disk.GetWithTimestamp(key)
+	if err != nil || !diskHit {
+		if hit {
+			return data, true, nil
+		}
+		return nil, false, err
+	}
+
+	// 回填内存，有效期与磁盘保持一致
+	if ttl := c.disk.RemainingTTL(key); ttl > 0 {
+		c.memory.SetWithTimestamp(key, diskData, ttl, diskModified)
+	}
+	return diskData, true, nil
+}
+
+// Set 写入缓存：内存同步写入，磁盘异步写入
+func (c *EnhancedTwoLevelCache) Set(key string, data []byte, ttl time.Duration) error {
+	now := time.Now()
+	c.memory.SetWithTimestamp(key, data, ttl, now)
+	go func() {
+		if err := c.disk.SetWithTimestamp(key, data, ttl, now); err != nil {
+			fmt.Printf("[缓存] 磁盘写入失败: %s | 错误: %v\n", key, err)
+		}
+	}()
+	return nil
+}
+
+// SetMemoryOnly 只写内存缓存，磁盘由批量写入管理器稍后处理
+func (c *EnhancedTwoLevelCache) SetMemoryOnly(key string, data []byte, ttl time.Duration) error {
+	c.memory.Set(key, data, ttl)
+	return nil
+}
+
+// SetBothLevels 同步写入内存和磁盘
+func (c *EnhancedTwoLevelCache) SetBothLevels(key string, data []byte, ttl time.Duration) error {
+	now := time.Now()
+	c.memory.SetWithTimestamp(key, data, ttl, now)
+	return c.disk.SetWithTimestamp(key, data, ttl, now)
+}
+
+// Delete 删除两级缓存
+func (c *EnhancedTwoLevelCache) Delete(key string) {
+	c.memory.Delete(key)
+	c.disk.Delete(key)
+}
+
+// Clear 清空两级缓存
+func (c *EnhancedTwoLevelCache) Clear() error {
+	c.memory.Clear()
+	return c.disk.Clear()
+}
+
+// GetSerializer 获取序列化器
+func (c *EnhancedTwoLevelCache) GetSerializer() Serializer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.serializer
+}
+
+// SetSerializer 替换序列化器（已有缓存数据需由调用方自行清理）
+func (c *EnhancedTwoLevelCache) SetSerializer(serializer Serializer) {
+	if serializer == nil {
+		return
+	}
+	c.mu.Lock()
+	c.serializer = serializer
+	c.mu.Unlock()
+}
+
+// GetStats 获取两级缓存统计
+func (c *EnhancedTwoLevelCache) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"memory": c.memory.Stats(),
+		"disk":   c.disk.Stats(),
+	}
+}
+
+// Close 停止后台清理任务
+func (c *EnhancedTwoLevelCache) Close() {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+}
@@ -0,0 +1,68 @@
+﻿package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+
+	"huoxing-search/pansou/util/json"
+)
+
+// Serializer 缓存数据序列化接口
+type Serializer interface {
+	Serialize(v interface{}) ([]byte, error)
+	Deserialize(data []byte, v interface{}) error
+}
+
+// GobSerializer 基于encoding/gob的序列化器，体积小，适合磁盘缓存
+type GobSerializer struct{}
+
+// NewGobSerializer 创建Gob序列化器
+func NewGobSerializer() *GobSerializer {
+	return &GobSerializer{}
+}
+
+// Serialize 序列化
+func (s *GobSerializer) Serialize(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Deserialize 反序列化
+func (s *GobSerializer) Deserialize(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// SonicSerializer 基于sonic的JSON序列化器，便于排查缓存内容
+type SonicSerializer struct{}
+
+// NewSonicSerializer 创建Sonic序列化器
+func NewSonicSerializer() *SonicSerializer {
+	return &SonicSerializer{}
+}
+
+// Serialize 序列化
+func (s *SonicSerializer) Serialize(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Deserialize 反序列化
+func (s *SonicSerializer) Deserialize(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// NewSerializer 根据名称创建序列化器，支持 gob / sonic(json)
+func NewSerializer(name string) (Serializer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "gob":
+		return NewGobSerializer(), nil
+	case "sonic", "json":
+		return NewSonicSerializer(), nil
+	default:
+		return nil, fmt.Errorf("不支持的序列化器: %s", name)
+	}
+}
@@ -0,0 +1,388 @@
+﻿package cache
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"huoxing-search/pansou/util"
+)
+
+const (
+	diskCacheMagic      byte = 'P'
+	diskCacheFlagGzip   byte = 1
+	diskCacheHeaderSize      = 18 // magic(1) + flags(1) + expiry(8) + lastModified(8)
+	diskCacheFileSuffix      = ".cache"
+)
+
+// diskCacheEntry 磁盘缓存索引项
+type diskCacheEntry struct {
+	size         int64
+	expiry       time.Time
+	lastModified time.Time
+}
+
+// diskCacheShard 磁盘缓存分片
+type diskCacheShard struct {
+	mu    sync.RWMutex
+	index map[string]*diskCacheEntry
+}
+
+// ShardedDiskCache 分片磁盘缓存
+// 文件按键名前两位分目录存放，内存中维护索引用于容量控制和过期清理
+type ShardedDiskCache struct {
+	basePath  string
+	maxSize   int64
+	shards    []*diskCacheShard
+	shardMask uint32
+	totalSize int64
+
+	compress        bool
+	minCompressSize int
+}
+
+// NewShardedDiskCache 创建分片磁盘缓存，并加载已有缓存文件的索引
+func NewShardedDiskCache(basePath string, shardCount int, maxSizeBytes int64, compress bool, minCompressSize int) (*ShardedDiskCache, error) {
+	if basePath == "" {
+		return nil, errors.New("磁盘缓存路径不能为空")
+	}
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	if shardCount <= 0 {
+		shardCount = 16
+	}
+	n := 1
+	for n < shardCount {
+		n <<= 1
+	}
+
+	c := &ShardedDiskCache{
+		basePath:        basePath,
+		maxSize:         maxSizeBytes,
+		shards:          make([]*diskCacheShard, n),
+		shardMask:       uint32(n - 1),
+		compress:        compress,
+		minCompressSize: minCompressSize,
+	}
+	for i := range c.shards {
+		c.shards[i] = &diskCacheShard{index: make(map[string]*diskCacheEntry)}
+	}
+
+	c.loadIndex()
+	return c, nil
+}
+
+// fileKey 将任意缓存键转换为安全的文件名
+func fileKey(key string) string {
+	if len(key) == 32 && isHex(key) {
+		return key
+	}
+	hash := md5.Sum([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func isHex(s string) bool {
+	for _, ch := range s {
+		if !((ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// filePath 获取缓存文件路径
+func (c *ShardedDiskCache) filePath(fk string) string {
+	return filepath.Join(c.basePath, fk[:2], fk+diskCacheFileSuffix)
+}
+
+// getShard 根据文件键选择分片
+func (c *ShardedDiskCache) getShard(fk string) *diskCacheShard {
+	h := fnv.New32a()
+	h.Write([]byte(fk))
+	return c.shards[h.Sum32()&c.shardMask]
+}
+
+// loadIndex 启动时扫描缓存目录重建索引，顺带删除过期和损坏的文件
+func (c *ShardedDiskCache) loadIndex() {
+	now := time.Now()
+	filepath.Walk(c.basePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, diskCacheFileSuffix) {
+			return nil
+		}
+		fk := strings.TrimSuffix(filepath.Base(path), diskCacheFileSuffix)
+		if len(fk) != 32 || !isHex(fk) {
+			return nil
+		}
+
+		expiry, lastModified, ok := readDiskHeader(path)
+		if !ok || now.After(expiry) {
+			os.Remove(path)
+			return nil
+		}
+
+		shard := c.getShard(fk)
+		shard.index[fk] = &diskCacheEntry{size: info.Size(), expiry: expiry, lastModified: lastModified}
+		c.totalSize += info.Size()
+		return nil
+	})
+}
+
+// readDiskHeader 只读取文件头中的过期时间和修改时间
+func readDiskHeader(path string) (time.Time, time.Time, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	defer f.Close()
+
+	header := make([]byte, diskCacheHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil || header[0] != diskCacheMagic {
+		return time.Time{}, time.Time{}, false
+	}
+	expiry := time.Unix(0, int64(binary.BigEndian.Uint64(header[2:10])))
+	lastModified := time.Unix(0, int64(binary.BigEndian.Uint64(header[10:18])))
+	return expiry, lastModified, true
+}
+
+// Set 写入磁盘缓存（先写临时文件再重命名，避免读到半截数据）
+func (c *ShardedDiskCache) Set(key string, data []byte, ttl time.Duration) error {
+	return c.SetWithTimestamp(key, data, ttl, time.Now())
+}
+
+// SetWithTimestamp 写入磁盘缓存并指定最后修改时间
+func (c *ShardedDiskCache) SetWithTimestamp(key string, data []byte, ttl time.Duration, lastModified time.Time) error {
+	fk := fileKey(key)
+	payload := data
+	var flags byte
+
+	if c.compress && len(data) >= c.minCompressSize {
+		if compressed, err := util.CompressData(data); err == nil && len(compressed) < len(data) {
+			payload = compressed
+			flags |= diskCacheFlagGzip
+		}
+	}
+
+	expiry := time.Now().Add(ttl)
+	buf := make([]byte, diskCacheHeaderSize+len(payload))
+	buf[0] = diskCacheMagic
+	buf[1] = flags
+	binary.BigEndian.PutUint64(buf[2:10], uint64(expiry.UnixNano()))
+	binary.BigEndian.PutUint64(buf[10:18], uint64(lastModified.UnixNano()))
+	copy(buf[diskCacheHeaderSize:], payload)
+
+	path := c.filePath(fk)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建缓存分片目录失败: %w", err)
+	}
+
+	shard := c.getShard(fk)
+	shard.mu.Lock()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf, 0644); err != nil {
+		shard.mu.Unlock()
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		shard.mu.Unlock()
+		return fmt.Errorf("重命名缓存文件失败: %w", err)
+	}
+
+	newSize := int64(len(buf))
+	if old, ok := shard.index[fk]; ok {
+		atomic.AddInt64(&c.totalSize, newSize-old.size)
+	} else {
+		atomic.AddInt64(&c.totalSize, newSize)
+	}
+	shard.index[fk] = &diskCacheEntry{size: newSize, expiry: expiry, lastModified: lastModified}
+	shard.mu.Unlock()
+
+	if c.maxSize > 0 && atomic.LoadInt64(&c.totalSize) > c.maxSize {
+		c.evict()
+	}
+	return nil
+}
+
+// Get 读取磁盘缓存
+func (c *ShardedDiskCache) Get(key string) ([]byte, bool, error) {
+	data, _, hit, err := c.GetWithTimestamp(key)
+	return data, hit, err
+}
+
+// GetWithTimestamp 读取磁盘缓存及其最后修改时间
+func (c *ShardedDiskCache) GetWithTimestamp(key string) ([]byte, time.Time, bool, error) {
+	fk := fileKey(key)
+	shard := c.getShard(fk)
+
+	shard.mu.RLock()
+	entry, ok := shard.index[fk]
+	shard.mu.RUnlock()
+	if !ok {
+		return nil, time.Time{}, false, nil
+	}
+	if time.Now().After(entry.expiry) {
+		c.Delete(key)
+		return nil, time.Time{}, false, nil
+	}
+
+	shard.mu.RLock()
+	raw, err := os.ReadFile(c.filePath(fk))
+	shard.mu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.Delete(key)
+			return nil, time.Time{}, false, nil
+		}
+		return nil, time.Time{}, false, err
+	}
+	if len(raw) < diskCacheHeaderSize || raw[0] != diskCacheMagic {
+		c.Delete(key)
+		return nil, time.Time{}, false, errors.New("缓存文件已损坏")
+	}
+
+	payload := raw[diskCacheHeaderSize:]
+	if raw[1]&diskCacheFlagGzip != 0 {
+		payload, err = util.DecompressData(payload)
+		if err != nil {
+			c.Delete(key)
+			return nil, time.Time{}, false, fmt.Errorf("解压缓存数据失败: %w", err)
+		}
+	}
+	return payload, entry.lastModified, true, nil
+}
+
+// LastModified 获取缓存项的最后修改时间（仅查索引，不读文件）
+func (c *ShardedDiskCache) LastModified(key string) (time.Time, bool) {
+	fk := fileKey(key)
+	shard := c.getShard(fk)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	entry, ok := shard.index[fk]
+	if !ok || time.Now().After(entry.expiry) {
+		return time.Time{}, false
+	}
+	return entry.lastModified, true
+}
+
+// RemainingTTL 获取缓存项的剩余有效期
+func (c *ShardedDiskCache) RemainingTTL(key string) time.Duration {
+	fk := fileKey(key)
+	shard := c.getShard(fk)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	if entry, ok := shard.index[fk]; ok {
+		return time.Until(entry.expiry)
+	}
+	return 0
+}
+
+// Delete 删除磁盘缓存
+func (c *ShardedDiskCache) Delete(key string) {
+	c.deleteByFileKey(fileKey(key))
+}
+
+func (c *ShardedDiskCache) deleteByFileKey(fk string) {
+	shard := c.getShard(fk)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if entry, ok := shard.index[fk]; ok {
+		atomic.AddInt64(&c.totalSize, -entry.size)
+		delete(shard.index, fk)
+	}
+	os.Remove(c.filePath(fk))
+}
+
+// CleanExpired 清理过期文件，返回清理数量
+func (c *ShardedDiskCache) CleanExpired() int {
+	now := time.Now()
+	var expired []string
+	for _, shard := range c.shards {
+		shard.mu.RLock()
+		for fk, entry := range shard.index {
+			if now.After(entry.expiry) {
+				expired = append(expired, fk)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	for _, fk := range expired {
+		c.deleteByFileKey(fk)
+	}
+	return len(expired)
+}
+
+// evict 容量超限时先清理过期项，再按最后修改时间淘汰最旧的文件，直到降到上限的90%
+func (c *ShardedDiskCache) evict() {
+	c.CleanExpired()
+	target := c.maxSize * 9 / 10
+	if atomic.LoadInt64(&c.totalSize) <= target {
+		return
+	}
+
+	type candidate struct {
+		fk           string
+		lastModified time.Time
+	}
+	var candidates []candidate
+	for _, shard := range c.shards {
+		shard.mu.RLock()
+		for fk, entry := range shard.index {
+			candidates = append(candidates, candidate{fk: fk, lastModified: entry.lastModified})
+		}
+		shard.mu.RUnlock()
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastModified.Before(candidates[j].lastModified)
+	})
+
+	for _, cand := range candidates {
+		if atomic.LoadInt64(&c.totalSize) <= target {
+			break
+		}
+		c.deleteByFileKey(cand.fk)
+	}
+}
+
+// Clear 清空磁盘缓存
+func (c *ShardedDiskCache) Clear() error {
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		for fk := range shard.index {
+			os.Remove(c.filePath(fk))
+		}
+		shard.index = make(map[string]*diskCacheEntry)
+		shard.mu.Unlock()
+	}
+	atomic.StoreInt64(&c.totalSize, 0)
+	return nil
+}
+
+// Stats 获取统计信息
+func (c *ShardedDiskCache) Stats() map[string]interface{} {
+	items := 0
+	for _, shard := range c.shards {
+		shard.mu.RLock()
+		items += len(shard.index)
+		shard.mu.RUnlock()
+	}
+	return map[string]interface{}{
+		"path":           c.basePath,
+		"items":          items,
+		"size_bytes":     atomic.LoadInt64(&c.totalSize),
+		"max_size_bytes": c.maxSize,
+		"compression":    c.compress,
+	}
+}
@@ -0,0 +1,220 @@
+﻿package cache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// memoryCacheItem 内存缓存项
+type memoryCacheItem struct {
+	key          string
+	data         []byte
+	expiry       time.Time
+	lastModified time.Time
+	size         int64
+}
+
+// memoryCacheShard 内存缓存分片，每个分片独立加锁并维护自己的LRU链表
+type memoryCacheShard struct {
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	lru     *list.List
+	size    int64
+	maxSize int64
+}
+
+// ShardedMemoryCache 分片LRU内存缓存
+type ShardedMemoryCache struct {
+	shards    []*memoryCacheShard
+	shardMask uint32
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// NewShardedMemoryCache 创建分片内存缓存，shardCount会向上取整为2的幂
+func NewShardedMemoryCache(shardCount int, maxSizeBytes int64) *ShardedMemoryCache {
+	if shardCount <= 0 {
+		shardCount = 16
+	}
+	n := 1
+	for n < shardCount {
+		n <<= 1
+	}
+
+	perShard := maxSizeBytes / int64(n)
+	if perShard <= 0 {
+		perShard = 1
+	}
+
+	c := &ShardedMemoryCache{
+		shards:    make([]*memoryCacheShard, n),
+		shardMask: uint32(n - 1),
+	}
+	for i := range c.shards {
+		c.shards[i] = &memoryCacheShard{
+			items:   make(map[string]*list.Element),
+			lru:     list.New(),
+			maxSize: perShard,
+		}
+	}
+	return c
+}
+
+// getShard 根据键选择分片
+func (c *ShardedMemoryCache) getShard(key string) *memoryCacheShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.shards[h.Sum32()&c.shardMask]
+}
+
+// Set 写入缓存
+func (c *ShardedMemoryCache) Set(key string, data []byte, ttl time.Duration) {
+	c.SetWithTimestamp(key, data, ttl, time.Now())
+}
+
+// SetWithTimestamp 写入缓存并指定最后修改时间
+func (c *ShardedMemoryCache) SetWithTimestamp(key string, data []byte, ttl time.Duration, lastModified time.Time) {
+	shard := c.getShard(key)
+	size := int64(len(data))
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// 单项超过分片容量时不进入内存层
+	if size > shard.maxSize {
+		if elem, ok := shard.items[key]; ok {
+			shard.removeElement(elem)
+		}
+		return
+	}
+
+	item := &memoryCacheItem{
+		key:          key,
+		data:         data,
+		expiry:       time.Now().Add(ttl),
+		lastModified: lastModified,
+		size:         size,
+	}
+
+	if elem, ok := shard.items[key]; ok {
+		old := elem.Value.(*memoryCacheItem)
+		shard.size += size - old.size
+		elem.Value = item
+		shard.lru.MoveToFront(elem)
+	} else {
+		shard.items[key] = shard.lru.PushFront(item)
+		shard.size += size
+	}
+
+	// 超出容量时从链表尾部淘汰
+	for shard.size > shard.maxSize {
+		tail := shard.lru.Back()
+		if tail == nil {
+			break
+		}
+		shard.removeElement(tail)
+		atomic.AddInt64(&c.evictions, 1)
+	}
+}
+
+// Get 读取缓存
+func (c *ShardedMemoryCache) Get(key string) ([]byte, bool) {
+	data, _, ok := c.GetWithTimestamp(key)
+	return data, ok
+}
+
+// GetWithTimestamp 读取缓存及其最后修改时间
+func (c *ShardedMemoryCache) GetWithTimestamp(key string) ([]byte, time.Time, bool) {
+	shard := c.getShard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	elem, ok := shard.items[key]
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, time.Time{}, false
+	}
+
+	item := elem.Value.(*memoryCacheItem)
+	if time.Now().After(item.expiry) {
+		shard.removeElement(elem)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, time.Time{}, false
+	}
+
+	shard.lru.MoveToFront(elem)
+	atomic.AddInt64(&c.hits, 1)
+	return item.data, item.lastModified, true
+}
+
+// Delete 删除缓存
+func (c *ShardedMemoryCache) Delete(key string) {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	if elem, ok := shard.items[key]; ok {
+		shard.removeElement(elem)
+	}
+	shard.mu.Unlock()
+}
+
+// Clear 清空所有分片
+func (c *ShardedMemoryCache) Clear() {
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		shard.items = make(map[string]*list.Element)
+		shard.lru.Init()
+		shard.size = 0
+		shard.mu.Unlock()
+	}
+}
+
+// CleanExpired 清理过期项，返回清理数量
+func (c *ShardedMemoryCache) CleanExpired() int {
+	now := time.Now()
+	cleaned := 0
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		for _, elem := range shard.items {
+			if now.After(elem.Value.(*memoryCacheItem).expiry) {
+				shard.removeElement(elem)
+				cleaned++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return cleaned
+}
+
+// Stats 获取统计信息
+func (c *ShardedMemoryCache) Stats() map[string]interface{} {
+	var items int
+	var size int64
+	for _, shard := range c.shards {
+		shard.mu.Lock()
+		items += len(shard.items)
+		size += shard.size
+		shard.mu.Unlock()
+	}
+	return map[string]interface{}{
+		"shards":     len(c.shards),
+		"items":      items,
+		"size_bytes": size,
+		"hits":       atomic.LoadInt64(&c.hits),
+		"misses":     atomic.LoadInt64(&c.misses),
+		"evictions":  atomic.LoadInt64(&c.evictions),
+	}
+}
+
+// removeElement 移除链表元素（调用方需持有锁）
+func (s *memoryCacheShard) removeElement(elem *list.Element) {
+	item := elem.Value.(*memoryCacheItem)
+	s.lru.Remove(elem)
+	delete(s.items, item.key)
+	s.size -= item.size
+}
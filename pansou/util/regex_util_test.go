@@ -0,0 +1,102 @@
+﻿package util
+
+import "testing"
+
+func TestGetLinkType(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://pan.baidu.com/s/1AbCdEf?pwd=abcd", "baidu"},
+		{"https://PAN.BAIDU.COM/s/1AbCdEf", "baidu"},
+		{"https://pan.quark.cn/s/abc123", "quark"},
+		{"https://www.alipan.com/s/abc123", "aliyun"},
+		{"https://www.aliyundrive.com/s/abc123", "aliyun"},
+		{"https://cloud.189.cn/t/abc123", "tianyi"},
+		{"https://drive.uc.cn/s/abc123", "uc"},
+		{"https://caiyun.139.com/m/i?abc", "mobile"},
+		{"https://115.com/s/abc123?password=x1y2", "115"},
+		{"https://115cdn.com/s/abc123", "115"},
+		{"https://anxia.com/s/abc123", "115"},
+		{"https://mypikpak.com/s/abc123", "pikpak"},
+		{"https://pan.xunlei.com/s/abc123?pwd=x1y2", "xunlei"},
+		{"https://www.123pan.com/s/abc-123", "123"},
+		{"https://www.123684.com/s/abc-123", "123"},
+		{"https://www.123912.com/s/abc-123", "123"},
+		{"ed2k://|file|movie.mkv|123|ABCDEF|/", "ed2k"},
+		{"magnet:?xt=urn:btih:abcdef", "magnet"},
+		{"链接：https://pan.quark.cn/s/abc123", "quark"},
+		{"链接: https://drive.uc.cn/s/abc123", "uc"},
+		{"https://example.com/s/abc123", "others"},
+		{"", "others"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := GetLinkType(tt.url); got != tt.want {
+				t.Errorf("GetLinkType(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		url     string
+		want    string
+	}{
+		{
+			name: "URL中的pwd参数",
+			url:  "https://pan.baidu.com/s/1AbCdEf?pwd=a1b2",
+			want: "a1b2",
+		},
+		{
+			name: "迅雷URL中的pwd参数",
+			url:  "https://pan.xunlei.com/s/abc123?pwd=x1y2#",
+			want: "x1y2",
+		},
+		{
+			name: "天翼云盘URL中的访问码",
+			url:  "https://cloud.189.cn/t/abc123（访问码：k9m2）",
+			want: "k9m2",
+		},
+		{
+			name: "115网盘URL中的password参数",
+			url:  "https://115cdn.com/s/abc123?password=p0q9",
+			want: "p0q9",
+		},
+		{
+			name: "123网盘URL中的提取码",
+			url:  "https://www.123pan.com/s/abc-123提取码:h7j8",
+			want: "h7j8",
+		},
+		{
+			name:    "内容中的提取码",
+			content: "三体 全集\n链接：https://pan.quark.cn/s/abc123 提取码：z9x8 🏷 标签",
+			url:     "https://pan.quark.cn/s/abc123",
+			want:    "z9x8",
+		},
+		{
+			name:    "提取码后无分隔符时取前4位",
+			content: "提取码:ab12资源很多",
+			url:     "https://pan.quark.cn/s/abc123",
+			want:    "ab12",
+		},
+		{
+			name:    "无提取码",
+			content: "三体 全集 https://pan.quark.cn/s/abc123",
+			url:     "https://pan.quark.cn/s/abc123",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractPassword(tt.content, tt.url); got != tt.want {
+				t.Errorf("ExtractPassword(%q, %q) = %q, want %q", tt.content, tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ const menuConfig = [
         group: '搜索配置',
         items: [
             { icon: '🔗', text: '搜索线路', href: '/admin/search/api' },
+            { icon: '🔑', text: 'API Key', href: '/admin/search/apikeys' },
             { icon: '🌐', text: '网盘配置', href: '/admin/system/netdisk' }
         ]
     },
@@ -131,10 +131,15 @@ const API = {
     },
 
     /**
-     * 设置认证token
+     * 设置认证token（refreshToken可选，登录和刷新时一并保存）
      */
-    setToken(token) {
+    setToken(token, refreshToken) {
         localStorage.setItem('admin_token', token);
+        if (refreshToken) {
+            localStorage.setItem('admin_refresh_token', refreshToken);
+        }
+        // 同步写入cookie，供浏览器直接打开的后台页面（如插件管理页）认证
+        document.cookie = 'admin_token=' + encodeURIComponent(token) + '; path=/; SameSite=Strict';
     },
 
     /**
@@ -142,12 +147,72 @@ const API = {
      */
     clearToken() {
         localStorage.removeItem('admin_token');
+        localStorage.removeItem('admin_refresh_token');
+        document.cookie = 'admin_token=; path=/; max-age=0; SameSite=Strict';
+    },
+
+    /**
+     * 读取访问令牌的过期时间（毫秒），无法解析时返回0
+     */
+    getTokenExpiry() {
+        const token = this.getToken();
+        if (!token) return 0;
+        try {
+            const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+            return (payload.exp || 0) * 1000;
+        } catch (e) {
+            return 0;
+        }
+    },
+
+    /**
+     * 用刷新令牌换取新的访问令牌（并发调用共用同一个请求）
+     */
+    refreshToken() {
+        const refreshToken = localStorage.getItem('admin_refresh_token');
+        if (!refreshToken) {
+            return Promise.resolve(false);
+        }
+        if (!this._refreshing) {
+            this._refreshing = fetch(API_BASE + '/auth/refresh', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken })
+            })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.code === 200 && data.data && data.data.token) {
+                        this.setToken(data.data.token, data.data.refresh_token);
+                        return true;
+                    }
+                    return false;
+                })
+                .catch(() => false)
+                .finally(() => {
+                    this._refreshing = null;
+                });
+        }
+        return this._refreshing;
+    },
+
+    /**
+     * 退出登录：吊销服务端令牌后清除本地token
+     */
+    async logout() {
+        try {
+            await this.post('/auth/logout', {
+                refresh_token: localStorage.getItem('admin_refresh_token') || ''
+            });
+        } catch (e) {
+            // 令牌已失效时忽略
+        }
+        this.clearToken();
     },
 
     /**
      * 通用请求方法
      */
-    async request(url, options = {}) {
+    async request(url, options = {}, retried = false) {
         const defaultOptions = {
             method: 'GET',
             headers: {
@@ -164,12 +229,21 @@ const API = {
             finalOptions.headers['Authorization'] = 'Bearer ' + token;
         }
 
+        // 前台页面令牌（开启强制API Key后，本站页面凭此调用搜索接口）
+        const siteToken = document.querySelector('meta[name="site-token"]');
+        if (siteToken && siteToken.content) {
+            finalOptions.headers['X-Site-Token'] = siteToken.content;
+        }
+
         try {
             const response = await fetch(API_BASE + url, finalOptions);
             const data = await response.json();
 
-            // 处理401未授权
+            // 处理401未授权：先尝试用刷新令牌续期并重试一次
             if (data.code === 401) {
+                if (!retried && await this.refreshToken()) {
+                    return this.request(url, options, true);
+                }
                 this.clearToken();
                 if (window.location.pathname.startsWith('/admin')) {
                     window.location.href = '/admin/login';
@@ -230,7 +304,10 @@ const PanTypes = {
     2: { name: '百度', color: 'success' },
     3: { name: '阿里', color: 'success' },
     4: { name: 'UC', color: 'warning' },
-    5: { name: '迅雷', color: 'danger' }
+    5: { name: '迅雷', color: 'danger' },
+    6: { name: '天翼', color: 'primary' },
+    7: { name: '123', color: 'warning' },
+    8: { name: '115', color: 'success' }
 };
 
 /**
@@ -274,6 +351,19 @@ style.textContent = `
 `;
 document.head.appendChild(style);
 
+// 兼容已登录但尚未写入cookie的旧会话
+if (API.getToken() && document.cookie.indexOf('admin_token=') === -1) {
+    API.setToken(API.getToken());
+}
+
+// 访问令牌有效期较短，在过期前2分钟自动续期（部分页面直接读取localStorage中的token发请求）
+setInterval(() => {
+    const expiry = API.getTokenExpiry();
+    if (expiry && expiry - Date.now() < 2 * 60 * 1000) {
+        API.refreshToken();
+    }
+}, 30 * 1000);
+
 // 导出到全局
 window.Utils = Utils;
 window.API = API;